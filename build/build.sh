@@ -4,5 +4,11 @@ set -e -x
 cd $(dirname $0)/..
 source $(dirname $0)/version.sh
 
+# the plugins of register.xml.tpl must be the ones generated from the input and output structs.
+go run ./tools/register_xml -template build/register.xml.tpl
+
+# the static copy of the openapi document served at /qcloud/v1/openapi.json, shipped in the image and the package.
+go run ./tools/openapi -output openapi.json
+
 LINKFLAGS="-linkmode external -extldflags -static -s"
 go build -ldflags "-X main.VERSION=$VERSION $LINKFLAGS" 
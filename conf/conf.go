@@ -9,12 +9,105 @@ import (
 	"strings"
 	"sync"
 	"sync/atomic"
+	"time"
 )
 
 type AppConfig struct {
 	HttpPort        string
 	CMDBLink        string
 	CMDBUserAuthKey string
+	Concurrency     ConcurrencyConfig
+	CloudApi        CloudApiConfig
+	RateLimit       RateLimitConfig
+	Timeouts        TimeoutConfig
+	Log             LogConfig
+	// DefaultRegions are the regions of the plugins which work on all the regions, such as the security group plugin.
+	DefaultRegions  []string
+	Idempotency     IdempotencyConfig
+	Credentials     CredentialsConfig
+	SecretMasterKey string
+	// ReloadInterval is how often the config file is checked for changes, zero disables the reload.
+	ReloadInterval time.Duration
+	// ReadinessCheckRegion is the region where /readyz checks the credential, it is not checked if empty.
+	ReadinessCheckRegion string
+	// MaxRequestBodySize is the max bytes of the body of plugin requests.
+	MaxRequestBodySize int64
+	Shutdown           ShutdownConfig
+	Tracing            TracingConfig
+	Audit              AuditConfig
+}
+
+type ConcurrencyConfig struct {
+	MaxParallelInputs int
+}
+
+type CloudApiConfig struct {
+	Scheme string
+	// Endpoints are keyed by service, such as "cvm" or "legacy_vpc".
+	Endpoints   map[string]string
+	MaxAttempts int
+	RetryBudget time.Duration
+}
+
+type RateLimitConfig struct {
+	Rate int
+	// Rates are keyed by "{service}" or "{service}.{action}".
+	Rates map[string]int
+}
+
+type TimeoutConfig struct {
+	Action time.Duration
+	// Actions are keyed by "{plugin}.{action}", such as "vm.create".
+	Actions         map[string]time.Duration
+	WaitInterval    time.Duration
+	WaitMaxInterval time.Duration
+	WaitBackoff     float64
+	// Waits are keyed by resource, such as "vm".
+	Waits map[string]time.Duration
+}
+
+type LogConfig struct {
+	Level      string
+	File       string
+	MaxSize    int
+	MaxBackups int
+	MaxAge     int
+}
+
+type IdempotencyConfig struct {
+	Dir    string
+	Expire time.Duration
+}
+
+type CredentialsConfig struct {
+	File               string
+	Profile            string
+	StsRoleArn         string
+	StsRoleSessionName string
+	StsRegion          string
+	StsDuration        time.Duration
+}
+
+type ShutdownConfig struct {
+	// GracePeriod is how long the running actions are waited for after SIGTERM before they are canceled.
+	GracePeriod time.Duration
+	// JournalFile records the inputs interrupted by the shutdown, they are only logged if it is empty.
+	JournalFile string
+}
+
+type TracingConfig struct {
+	// Exporter is "otlp" or "file", spans are not exported if it is empty.
+	Exporter     string
+	OtlpEndpoint string
+	File         string
+}
+
+type AuditConfig struct {
+	// File records the cloud API calls which change resources, they are not recorded if it is empty.
+	File       string
+	MaxSize    int
+	MaxBackups int
+	MaxAge     int
 }
 
 type AppConfigMgr struct {
@@ -29,23 +122,119 @@ type Config struct {
 	NotifyerList   []Notifyer
 }
 
+// ENV_PREFIX is the prefix of the environment variables which override the items of the config file,
+// such as QCLOUD_MAX_PARALLEL_INPUTS for max_parallel_inputs. "." of keys is written as "__" and the
+// segments after the first one are kept as they are, such as QCLOUD_CLOUD_API_RATE_LIMIT__cvm__RunInstances.
+const ENV_PREFIX = "QCLOUD_"
+
 var AppConfMgr = &AppConfigMgr{}
+
+// GobalAppConfig is the config read at startup, GetAppConfig returns the current one.
 var GobalAppConfig = &AppConfig{}
 
-func InitConfig(file string) {
+// InitConfig reads the config file and returns it, the app config is rebuilt whenever the file is reloaded.
+func InitConfig(file string) *Config {
 	conf, err := NewConfig(file)
 	if err != nil {
 		fmt.Printf("read config file err: %v\n", err)
-		return
+		return nil
+	}
+
+	appConfig, err := NewAppConfig(conf)
+	if err != nil {
+		fmt.Printf("%v\n", err)
+		return nil
+	}
+	*GobalAppConfig = *appConfig
+	AppConfMgr.Config.Store(appConfig)
+	conf.AddNotifyer(AppConfMgr)
+	return conf
+}
+
+// GetAppConfig returns the current app config, it is replaced when the config file is reloaded.
+func GetAppConfig() *AppConfig {
+	if appConfig, ok := AppConfMgr.Config.Load().(*AppConfig); ok {
+		return appConfig
 	}
+	return GobalAppConfig
+}
 
-	GobalAppConfig.HttpPort, err = conf.GetString("httpport")
+// Callback rebuilds the app config of the reloaded config file, the current one is kept if the file is invalid.
+func (mgr *AppConfigMgr) Callback(conf *Config) {
+	appConfig, err := NewAppConfig(conf)
 	if err != nil {
-		fmt.Printf("get HttpPort err: %v\n", err)
+		fmt.Printf("reload config file err: %v\n", err)
 		return
 	}
+	mgr.Config.Store(appConfig)
+}
 
-	AppConfMgr.Config.Store(GobalAppConfig)
+func NewAppConfig(conf *Config) (*AppConfig, error) {
+	var err error
+	appConfig := &AppConfig{}
+	appConfig.HttpPort, err = conf.GetString("httpport")
+	if err != nil {
+		return nil, fmt.Errorf("get HttpPort err: %v", err)
+	}
+	appConfig.MaxRequestBodySize = int64(conf.GetIntDefault("max_request_body_mb", 10)) << 20
+	appConfig.Concurrency.MaxParallelInputs = conf.GetIntDefault("max_parallel_inputs", 5)
+	appConfig.CloudApi = CloudApiConfig{
+		Scheme:      conf.GetIStringDefault("cloud_api_scheme", ""),
+		Endpoints:   conf.GetStringMapByPrefix("cloud_api_endpoint."),
+		MaxAttempts: conf.GetIntDefault("cloud_api_max_attempts", 0),
+		RetryBudget: conf.GetDurationDefault("cloud_api_retry_budget_seconds", time.Second, 0),
+	}
+	appConfig.RateLimit = RateLimitConfig{
+		Rate:  conf.GetIntDefault("cloud_api_rate_limit", 0),
+		Rates: conf.GetIntMapByPrefix("cloud_api_rate_limit."),
+	}
+	appConfig.Timeouts = TimeoutConfig{
+		Action:          conf.GetDurationDefault("action_timeout_seconds", time.Second, 0),
+		Actions:         conf.GetDurationMapByPrefix("action_timeout_seconds.", time.Second),
+		WaitInterval:    conf.GetDurationDefault("wait_interval_seconds", time.Second, 0),
+		WaitMaxInterval: conf.GetDurationDefault("wait_max_interval_seconds", time.Second, 0),
+		WaitBackoff:     conf.GetFloatDefault("wait_backoff", 0),
+		Waits:           conf.GetDurationMapByPrefix("wait_timeout_seconds.", time.Second),
+	}
+	appConfig.Log = LogConfig{
+		Level:      conf.GetIStringDefault("log_level", "info"),
+		File:       conf.GetIStringDefault("log_file", "logs/wecube-plugins-qcloud.log"),
+		MaxSize:    conf.GetIntDefault("log_max_size_mb", 100),
+		MaxBackups: conf.GetIntDefault("log_max_backups", 1),
+		MaxAge:     conf.GetIntDefault("log_max_age_days", 7),
+	}
+	appConfig.DefaultRegions = conf.GetStringSliceDefault("default_regions", ";", nil)
+	appConfig.Idempotency = IdempotencyConfig{
+		Dir:    conf.GetIStringDefault("idempotency_dir", ""),
+		Expire: conf.GetDurationDefault("idempotency_expire_hours", time.Hour, 0),
+	}
+	appConfig.Credentials = CredentialsConfig{
+		File:               conf.GetIStringDefault("credentials_file", ""),
+		Profile:            conf.GetIStringDefault("credentials_profile", ""),
+		StsRoleArn:         conf.GetIStringDefault("sts_role_arn", ""),
+		StsRoleSessionName: conf.GetIStringDefault("sts_role_session_name", ""),
+		StsRegion:          conf.GetIStringDefault("sts_region", ""),
+		StsDuration:        conf.GetDurationDefault("sts_duration_seconds", time.Second, 0),
+	}
+	appConfig.SecretMasterKey = conf.GetIStringDefault("secret_master_key", "")
+	appConfig.ReloadInterval = conf.GetDurationDefault("config_reload_interval_seconds", time.Second, 0)
+	appConfig.ReadinessCheckRegion = conf.GetIStringDefault("readiness_check_region", "")
+	appConfig.Shutdown = ShutdownConfig{
+		GracePeriod: conf.GetDurationDefault("shutdown_grace_period_seconds", time.Second, 30*time.Second),
+		JournalFile: conf.GetIStringDefault("shutdown_journal_file", ""),
+	}
+	appConfig.Tracing = TracingConfig{
+		Exporter:     conf.GetIStringDefault("tracing_exporter", ""),
+		OtlpEndpoint: conf.GetIStringDefault("tracing_otlp_endpoint", "http://localhost:4318"),
+		File:         conf.GetIStringDefault("tracing_file", "logs/spans.json"),
+	}
+	appConfig.Audit = AuditConfig{
+		File:       conf.GetIStringDefault("audit_journal_file", ""),
+		MaxSize:    conf.GetIntDefault("audit_journal_max_size_mb", 100),
+		MaxBackups: conf.GetIntDefault("audit_journal_max_backups", 0),
+		MaxAge:     conf.GetIntDefault("audit_journal_max_age_days", 0),
+	}
+	return appConfig, nil
 }
 
 func NewConfig(file string) (conf *Config, err error) {
@@ -54,6 +243,10 @@ func NewConfig(file string) (conf *Config, err error) {
 		Items:    make(map[string]string, 1024),
 	}
 
+	modTime, err := conf.getModTime()
+	if err != nil {
+		return
+	}
 	m, err := conf.parse()
 	if err != nil {
 		fmt.Printf("parse conf error:%v\n", err)
@@ -62,11 +255,77 @@ func NewConfig(file string) (conf *Config, err error) {
 
 	conf.RWLock.Lock()
 	conf.Items = m
+	conf.LastUpdateTime = modTime
 	conf.RWLock.Unlock()
 
 	return
 }
 
+// AddNotifyer adds a notifyer which is called after the config file is reloaded, in the order they are added.
+func (c *Config) AddNotifyer(notifyer Notifyer) {
+	c.RWLock.Lock()
+	defer c.RWLock.Unlock()
+
+	c.NotifyerList = append(c.NotifyerList, notifyer)
+}
+
+// Reload parses the config file again if it is modified after the last update, and calls the notifyers.
+// It returns whether the config is reloaded, the current items are kept if the file can't be parsed.
+func (c *Config) Reload() (bool, error) {
+	modTime, err := c.getModTime()
+	if err != nil {
+		return false, err
+	}
+	c.RWLock.RLock()
+	modified := modTime != c.LastUpdateTime
+	c.RWLock.RUnlock()
+	if !modified {
+		return false, nil
+	}
+
+	m, err := c.parse()
+	if err != nil {
+		return false, err
+	}
+	c.RWLock.Lock()
+	c.Items = m
+	c.LastUpdateTime = modTime
+	notifyers := append([]Notifyer{}, c.NotifyerList...)
+	c.RWLock.Unlock()
+
+	for _, notifyer := range notifyers {
+		notifyer.Callback(c)
+	}
+	return true, nil
+}
+
+// Watch reloads the config file every interval until stop is closed.
+func (c *Config) Watch(interval time.Duration, stop <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			if reloaded, err := c.Reload(); err != nil {
+				fmt.Printf("reload config file %s err: %v\n", c.Filename, err)
+			} else if reloaded {
+				fmt.Printf("config file %s is reloaded\n", c.Filename)
+			}
+		}
+	}
+}
+
+func (c *Config) getModTime() (int64, error) {
+	info, err := os.Stat(c.Filename)
+	if err != nil {
+		return 0, err
+	}
+	return info.ModTime().UnixNano(), nil
+}
+
 func (c *Config) parse() (m map[string]string, err error) {
 	m = make(map[string]string, 1024)
 	f, err := os.Open(c.Filename)
@@ -90,9 +349,27 @@ func (c *Config) parse() (m map[string]string, err error) {
 		}
 		lineParse(&lineNo, &line, &m)
 	}
+	for key, value := range getEnvItems(os.Environ()) {
+		m[key] = value
+	}
 	return
 }
 
+// getEnvItems returns the items overridden by the environment variables with ENV_PREFIX.
+func getEnvItems(environ []string) map[string]string {
+	items := make(map[string]string)
+	for _, env := range environ {
+		pair := strings.SplitN(env, "=", 2)
+		if len(pair) != 2 || !strings.HasPrefix(pair[0], ENV_PREFIX) || pair[0] == ENV_PREFIX {
+			continue
+		}
+		segments := strings.Split(strings.TrimPrefix(pair[0], ENV_PREFIX), "__")
+		segments[0] = strings.ToLower(segments[0])
+		items[strings.Join(segments, ".")] = pair[1]
+	}
+	return items
+}
+
 func lineParse(lineNo *int, line *string, m *map[string]string) {
 	*lineNo++
 
@@ -101,18 +378,14 @@ func lineParse(lineNo *int, line *string, m *map[string]string) {
 		return
 	}
 
-	itemSlice := strings.Split(l, "=")
-	if len(itemSlice) == 0 {
-		fmt.Printf("invalid config, line:%d", lineNo)
-		return
-	}
-
+	// the value is split at the first "=", it may have "=" too, such as a base64 key.
+	itemSlice := strings.SplitN(l, "=", 2)
 	key := strings.TrimSpace(itemSlice[0])
 	if len(key) == 0 {
-		fmt.Printf("invalid config, line:%d", lineNo)
+		fmt.Printf("invalid config, line:%d", *lineNo)
 		return
 	}
-	if len(key) == 1 {
+	if len(itemSlice) == 1 {
 		(*m)[key] = ""
 		return
 	}
@@ -151,6 +424,22 @@ func (c *Config) GetIntDefault(key string, defaultInt int) (value int) {
 	return
 }
 
+func (c *Config) GetFloatDefault(key string, defaultFloat float64) (value float64) {
+	c.RWLock.RLock()
+	defer c.RWLock.RUnlock()
+
+	str, ok := c.Items[key]
+	if !ok {
+		value = defaultFloat
+		return
+	}
+	value, err := strconv.ParseFloat(str, 64)
+	if err != nil {
+		value = defaultFloat
+	}
+	return
+}
+
 func (c *Config) GetString(key string) (value string, err error) {
 	c.RWLock.RLock()
 	defer c.RWLock.RUnlock()
@@ -174,6 +463,68 @@ func (c *Config) GetIStringDefault(key string, defaultStr string) (value string)
 	return
 }
 
+// GetStringMapByPrefix returns items whose key starts with prefix, the prefix is trimmed from keys.
+func (c *Config) GetStringMapByPrefix(prefix string) map[string]string {
+	c.RWLock.RLock()
+	defer c.RWLock.RUnlock()
+
+	values := make(map[string]string)
+	for key, value := range c.Items {
+		if strings.HasPrefix(key, prefix) {
+			values[strings.TrimPrefix(key, prefix)] = value
+		}
+	}
+	return values
+}
+
+// GetIntMapByPrefix is the same as GetStringMapByPrefix, items whose value is not an int are skipped.
+func (c *Config) GetIntMapByPrefix(prefix string) map[string]int {
+	values := make(map[string]int)
+	for key, str := range c.GetStringMapByPrefix(prefix) {
+		value, err := strconv.Atoi(str)
+		if err != nil {
+			fmt.Printf("invalid int config %s%s: %v\n", prefix, key, err)
+			continue
+		}
+		values[key] = value
+	}
+	return values
+}
+
+// GetDurationDefault returns the int item in the unit, such as time.Second for the items named "*_seconds".
+func (c *Config) GetDurationDefault(key string, unit time.Duration, defaultDuration time.Duration) time.Duration {
+	value := c.GetIntDefault(key, -1)
+	if value < 0 {
+		return defaultDuration
+	}
+	return time.Duration(value) * unit
+}
+
+// GetDurationMapByPrefix is the same as GetIntMapByPrefix, the values are in the unit.
+func (c *Config) GetDurationMapByPrefix(prefix string, unit time.Duration) map[string]time.Duration {
+	values := make(map[string]time.Duration)
+	for key, value := range c.GetIntMapByPrefix(prefix) {
+		values[key] = time.Duration(value) * unit
+	}
+	return values
+}
+
+// GetStringSliceDefault splits the item by sep, empty values are skipped.
+func (c *Config) GetStringSliceDefault(key string, sep string, defaultSlice []string) []string {
+	str := c.GetIStringDefault(key, "")
+	values := []string{}
+	for _, value := range strings.Split(str, sep) {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+	if len(values) == 0 {
+		return defaultSlice
+	}
+	return values
+}
+
+// Notifyer is called after the config file is reloaded.
 type Notifyer interface {
 	Callback(*Config)
 }
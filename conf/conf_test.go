@@ -0,0 +1,115 @@
+package conf
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type testNotifyer struct {
+	configs []*AppConfig
+}
+
+func (notifyer *testNotifyer) Callback(conf *Config) {
+	appConfig, _ := NewAppConfig(conf)
+	notifyer.configs = append(notifyer.configs, appConfig)
+}
+
+func writeTestConfig(t *testing.T, file string, content string, modTime time.Time) {
+	if err := ioutil.WriteFile(file, []byte(content), 0644); err != nil {
+		t.Fatalf("write config file meet error=%v", err)
+	}
+	if err := os.Chtimes(file, modTime, modTime); err != nil {
+		t.Fatalf("change times of config file meet error=%v", err)
+	}
+}
+
+func TestGetEnvItems(t *testing.T) {
+	items := getEnvItems([]string{
+		"QCLOUD_MAX_PARALLEL_INPUTS=10",
+		"QCLOUD_CLOUD_API_RATE_LIMIT__cvm__RunInstances=5",
+		"QCLOUD_SECRET_MASTER_KEY=a=b",
+		"QCLOUD_=1",
+		"REGIONS=ap-guangzhou",
+	})
+	expected := map[string]string{
+		"max_parallel_inputs":                   "10",
+		"cloud_api_rate_limit.cvm.RunInstances": "5",
+		"secret_master_key":                     "a=b",
+	}
+	if !reflect.DeepEqual(items, expected) {
+		t.Errorf("items=%v, expected %v", items, expected)
+	}
+}
+
+func TestNewAppConfig(t *testing.T) {
+	dir, err := ioutil.TempDir("", "conf")
+	if err != nil {
+		t.Fatalf("create temp dir meet error=%v", err)
+	}
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "app.conf")
+	writeTestConfig(t, file, "httpport = 8081\nwait_timeout_seconds.vm = 600\ndefault_regions = ap-guangzhou; ap-shanghai;\nsecret_master_key = a2V5=\n", time.Now())
+
+	os.Setenv("QCLOUD_LOG_LEVEL", "debug")
+	defer os.Unsetenv("QCLOUD_LOG_LEVEL")
+	conf, err := NewConfig(file)
+	if err != nil {
+		t.Fatalf("NewConfig meet error=%v", err)
+	}
+	appConfig, err := NewAppConfig(conf)
+	if err != nil {
+		t.Fatalf("NewAppConfig meet error=%v", err)
+	}
+	if appConfig.HttpPort != "8081" || appConfig.Log.Level != "debug" || appConfig.Log.MaxSize != 100 ||
+		appConfig.Concurrency.MaxParallelInputs != 5 || appConfig.Timeouts.Waits["vm"] != 10*time.Minute ||
+		!reflect.DeepEqual(appConfig.DefaultRegions, []string{"ap-guangzhou", "ap-shanghai"}) || appConfig.SecretMasterKey != "a2V5=" {
+		t.Errorf("app config=%+v", appConfig)
+	}
+}
+
+func TestConfigReload(t *testing.T) {
+	dir, err := ioutil.TempDir("", "conf")
+	if err != nil {
+		t.Fatalf("create temp dir meet error=%v", err)
+	}
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "app.conf")
+	modTime := time.Now().Add(-time.Hour)
+	writeTestConfig(t, file, "httpport = 8081\nlog_level = info\n", modTime)
+
+	conf, err := NewConfig(file)
+	if err != nil {
+		t.Fatalf("NewConfig meet error=%v", err)
+	}
+	notifyer := &testNotifyer{}
+	conf.AddNotifyer(notifyer)
+
+	if reloaded, err := conf.Reload(); reloaded || err != nil {
+		t.Errorf("unmodified config is reloaded=%v, error=%v", reloaded, err)
+	}
+
+	writeTestConfig(t, file, "httpport = 8081\nlog_level = warning\ncloud_api_rate_limit.cvm = 10\n", modTime.Add(time.Minute))
+	if reloaded, err := conf.Reload(); !reloaded || err != nil {
+		t.Fatalf("modified config is reloaded=%v, error=%v", reloaded, err)
+	}
+	if len(notifyer.configs) != 1 {
+		t.Fatalf("notifyer is called %d times", len(notifyer.configs))
+	}
+	appConfig := notifyer.configs[0]
+	if appConfig.Log.Level != "warning" || appConfig.RateLimit.Rates["cvm"] != 10 {
+		t.Errorf("reloaded app config=%+v", appConfig)
+	}
+
+	// the items are kept if the file is removed.
+	os.Remove(file)
+	if reloaded, err := conf.Reload(); reloaded || err == nil {
+		t.Errorf("removed config is reloaded=%v, error=%v", reloaded, err)
+	}
+	if level, _ := conf.GetString("log_level"); level != "warning" {
+		t.Errorf("log_level=%v after the file is removed", level)
+	}
+}
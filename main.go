@@ -1,15 +1,21 @@
 package main
 
 import (
-	"encoding/json"
+	"context"
+	"errors"
+	"fmt"
 	"net/http"
 	"os"
+	"os/signal"
 	"strings"
+	"syscall"
+	"time"
 
 	_ "github.com/WeBankPartners/wecube-plugins-qcloud/plugins/bussiness_plugins/security_group"
 
 	"github.com/WeBankPartners/wecube-plugins-qcloud/conf"
 	"github.com/WeBankPartners/wecube-plugins-qcloud/plugins"
+	"github.com/WeBankPartners/wecube-plugins-qcloud/router"
 	"github.com/sirupsen/logrus"
 	"github.com/snowzach/rotatefilehook"
 )
@@ -24,70 +30,246 @@ func init() {
 	initRouter()
 }
 
+// configFile is reloaded every config_reload_interval_seconds if it is set.
+var configFile *conf.Config
+
 func main() {
 	logrus.Infof("Start WeCube-Plungins-Qcloud Service ... ")
 
-	if err := http.ListenAndServe(":"+conf.GobalAppConfig.HttpPort, nil); err != nil {
-		logrus.Fatalf("ListenAndServe meet err = %v", err)
+	if configFile != nil && conf.GobalAppConfig.ReloadInterval > 0 {
+		go configFile.Watch(conf.GobalAppConfig.ReloadInterval, nil)
 	}
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGTERM, os.Interrupt)
+	server := &http.Server{Addr: ":" + conf.GobalAppConfig.HttpPort}
+	go func() {
+		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			logrus.Fatalf("ListenAndServe meet err = %v", err)
+		}
+	}()
+
+	sig := <-signals
+	logrus.Infof("receive signal %v, shutdown WeCube-Plungins-Qcloud Service ... ", sig)
+	shutdown(server, conf.GetAppConfig().Shutdown.GracePeriod)
+}
+
+// shutdown stops accepting requests and waits for the running actions, the actions still running after the grace
+// period are canceled and their interrupted inputs are journaled. The requests of the canceled actions are answered
+// before the server is closed.
+func shutdown(server *http.Server, gracePeriod time.Duration) {
+	ctx, cancel := context.WithTimeout(context.Background(), gracePeriod+plugins.ACTION_CANCEL_TIMEOUT)
+	defer cancel()
+
+	serverDone := make(chan error, 1)
+	go func() {
+		serverDone <- server.Shutdown(ctx)
+	}()
+	plugins.DrainActions(gracePeriod)
+	if err := <-serverDone; err != nil {
+		logrus.Warnf("shutdown http server meet err = %v", err)
+	}
+	plugins.FlushSpans(plugins.SPAN_EXPORT_TIMEOUT)
+	if journal := plugins.DefaultAuditJournal; journal != nil {
+		journal.Close()
+	}
+	logrus.Infof("WeCube-Plungins-Qcloud Service is stopped")
 }
 
 func initLogger() {
-	fileName := "logs/wecube-plugins-qcloud.log"
+	logConfig := conf.GobalAppConfig.Log
 	logrus.SetReportCaller(true)
-	file, err := os.OpenFile(fileName, os.O_CREATE|os.O_WRONLY, 0666)
+	file, err := os.OpenFile(logConfig.File, os.O_CREATE|os.O_WRONLY, 0666)
 	if err == nil {
 		logrus.SetOutput(file)
 	}
 
+	// secrets are masked before the entries are written by the output and the hooks below.
+	logrus.AddHook(&plugins.RedactionHook{})
+	// the level of the logger, which may be changed by log_level at runtime, decides which entries are written.
 	rotateFileHook, err := rotatefilehook.NewRotateFileHook(rotatefilehook.RotateFileConfig{
-		Filename:   fileName,
-		MaxSize:    100,
-		MaxBackups: 1,
-		MaxAge:     7,
-		Level:      logrus.InfoLevel,
+		Filename:   logConfig.File,
+		MaxSize:    logConfig.MaxSize,
+		MaxBackups: logConfig.MaxBackups,
+		MaxAge:     logConfig.MaxAge,
+		Level:      logrus.TraceLevel,
 		Formatter:  &logrus.TextFormatter{DisableTimestamp: false, DisableColors: false},
 	})
 	logrus.AddHook(rotateFileHook)
 }
 
+// initConfig applies the config read at startup. The settings applied by applyConfig are applied again
+// when the config file is reloaded, the others such as the log file, idempotency dir and credentials
+// need a restart.
 func initConfig() {
-	conf.InitConfig(CONF_FILE_PATH)
+	configFile = conf.InitConfig(CONF_FILE_PATH)
+	appConfig := conf.GobalAppConfig
+	applyConfig(appConfig)
+	if appConfig.Idempotency.Dir != "" {
+		plugins.DefaultIdempotencyStore = &plugins.IdempotencyStore{
+			Dir:    appConfig.Idempotency.Dir,
+			Expire: appConfig.Idempotency.Expire,
+		}
+	}
+	plugins.DefaultCredentialProvider = newCredentialProvider(appConfig)
+	plugins.SecretMasterKey = appConfig.SecretMasterKey
+	if appConfig.Shutdown.JournalFile != "" {
+		plugins.DefaultInterruptionJournal = &plugins.InterruptionJournal{File: appConfig.Shutdown.JournalFile}
+	}
+	plugins.SetSpanExporter(newSpanExporter(appConfig.Tracing))
+	if appConfig.Audit.File != "" {
+		plugins.DefaultAuditJournal = &plugins.AuditJournal{
+			File:       appConfig.Audit.File,
+			MaxSize:    appConfig.Audit.MaxSize,
+			MaxBackups: appConfig.Audit.MaxBackups,
+			MaxAge:     appConfig.Audit.MaxAge,
+		}
+	}
+	if configFile != nil {
+		configFile.AddNotifyer(&configNotifyer{})
+	}
+}
+
+// newSpanExporter returns the exporter of tracing_exporter, nil if spans are not exported.
+func newSpanExporter(config conf.TracingConfig) plugins.SpanExporter {
+	switch config.Exporter {
+	case "":
+		return nil
+	case plugins.TRACING_EXPORTER_OTLP:
+		return &plugins.OtlpExporter{Endpoint: config.OtlpEndpoint}
+	case plugins.TRACING_EXPORTER_FILE:
+		return &plugins.FileSpanExporter{File: config.File}
+	default:
+		logrus.Warnf("tracing_exporter %v is invalid, spans are not exported", config.Exporter)
+		return nil
+	}
+}
+
+// configNotifyer applies the config after the config file is reloaded.
+type configNotifyer struct{}
+
+func (notifyer *configNotifyer) Callback(*conf.Config) {
+	logrus.Infof("config file is reloaded, apply the config")
+	applyConfig(conf.GetAppConfig())
+}
+
+// applyConfig applies the settings which can be changed at runtime.
+func applyConfig(config *conf.AppConfig) {
+	if level, err := logrus.ParseLevel(config.Log.Level); err != nil {
+		logrus.Warnf("log_level %v is invalid, err=%v", config.Log.Level, err)
+	} else if level != logrus.GetLevel() {
+		logrus.SetLevel(level)
+	}
+	router.SetMaxRequestBodySize(config.MaxRequestBodySize)
+	plugins.SetMaxParallelInputs(config.Concurrency.MaxParallelInputs)
+	plugins.SetRetryPolicy(&plugins.RetryPolicy{
+		MaxAttempts: config.CloudApi.MaxAttempts,
+		Budget:      config.CloudApi.RetryBudget,
+	})
+	plugins.DefaultRateLimiter.SetRates(getRateLimits(config.RateLimit))
+	plugins.SetWaitPolicy(newWaitPolicy(config))
+	plugins.SetActionTimeoutPolicy(newActionTimeoutPolicy(config))
+	plugins.SetDefaultRegions(config.DefaultRegions)
+
+	factory := plugins.GetClientFactory()
+	if config.CloudApi.Scheme != factory.Scheme || !isSameEndpoints(config.CloudApi.Endpoints, factory.Endpoints) {
+		newFactory := *factory
+		newFactory.Scheme = config.CloudApi.Scheme
+		newFactory.Endpoints = config.CloudApi.Endpoints
+		plugins.SetClientFactory(&newFactory)
+	}
+}
+
+func isSameEndpoints(endpoints, otherEndpoints map[string]string) bool {
+	if len(endpoints) != len(otherEndpoints) {
+		return false
+	}
+	for service, endpoint := range endpoints {
+		if otherEndpoint, found := otherEndpoints[service]; !found || otherEndpoint != endpoint {
+			return false
+		}
+	}
+	return true
+}
+
+// getRateLimits returns the rates of the rate limiter, the keys of limits are "{service}" or "{service}.{action}".
+func getRateLimits(config conf.RateLimitConfig) (float64, map[string]float64, map[string]float64) {
+	serviceRates := make(map[string]float64)
+	actionRates := plugins.GetDefaultActionRateLimits()
+	for key, limit := range config.Rates {
+		if strings.Contains(key, ".") {
+			actionRates[key] = float64(limit)
+		} else {
+			serviceRates[key] = float64(limit)
+		}
+	}
+	return float64(config.Rate), serviceRates, actionRates
+}
+
+// newWaitPolicy returns the wait policy of the config, the keys of timeouts are the resources such as "vm".
+func newWaitPolicy(config *conf.AppConfig) *plugins.WaitPolicy {
+	return &plugins.WaitPolicy{
+		Interval:    config.Timeouts.WaitInterval,
+		MaxInterval: config.Timeouts.WaitMaxInterval,
+		Backoff:     config.Timeouts.WaitBackoff,
+		Timeouts:    config.Timeouts.Waits,
+	}
+}
+
+// newActionTimeoutPolicy returns the action timeouts of the config, the keys of timeouts are "{plugin}.{action}".
+func newActionTimeoutPolicy(config *conf.AppConfig) *plugins.ActionTimeoutPolicy {
+	return &plugins.ActionTimeoutPolicy{
+		Timeout:  config.Timeouts.Action,
+		Timeouts: config.Timeouts.Actions,
+	}
+}
+
+// newCredentialProvider returns the provider of the credential used when provider_params do not supply
+// the keys. The credential is read from env or the credentials file, and used to assume the role if it is set.
+func newCredentialProvider(config *conf.AppConfig) plugins.CredentialProvider {
+	chain := plugins.CredentialProviderChain{&plugins.EnvCredentialProvider{}}
+	credentials := config.Credentials
+	if credentials.File != "" {
+		chain = append(chain, &plugins.FileCredentialProvider{Path: credentials.File, Profile: credentials.Profile})
+	}
+	if credentials.StsRoleArn == "" {
+		return chain
+	}
+	return &plugins.StsCredentialProvider{
+		RoleArn:         credentials.StsRoleArn,
+		RoleSessionName: credentials.StsRoleSessionName,
+		Region:          credentials.StsRegion,
+		Duration:        credentials.StsDuration,
+		Base:            chain,
+	}
 }
 
 func initRouter() {
-	//path should be defined as "/[package name]/[version]/[plugin]/[action]"
-	http.HandleFunc("/", routeDispatcher)
+	router.InitRouter(http.DefaultServeMux)
+	router.SetReadinessCheck("config", checkConfig)
+	router.SetReadinessCheck("credential", checkCredential)
+	router.SetReadinessCheck("shutdown", checkShutdown)
 }
 
-func routeDispatcher(w http.ResponseWriter, r *http.Request) {
-	pluginRequest := parsePluginRequest(r)
-	pluginResponse, _ := plugins.Process(pluginRequest)
-	b,_ := json.Marshal(pluginResponse)
-	logrus.Infof("write data to client response=%s", string(b))
-	write(w, pluginResponse)
+func checkShutdown(ctx context.Context) error {
+	if plugins.IsShuttingDown() {
+		return errors.New("plugin is shutting down")
+	}
+	return nil
 }
 
-func write(w http.ResponseWriter, output *plugins.PluginResponse) {
-	w.Header().Set("content-type", "application/json")
-	b, err := json.Marshal(output)
-	if err != nil {
-		logrus.Errorf("write http response (%v) meet error (%v)", output, err)
+func checkConfig(ctx context.Context) error {
+	if configFile == nil {
+		return fmt.Errorf("config file %v is not loaded", CONF_FILE_PATH)
 	}
-	w.Write(b)
+	return nil
 }
 
-func parsePluginRequest(r *http.Request) *plugins.PluginRequest {
-	var pluginInput = plugins.PluginRequest{}
-	pathStrings := strings.Split(r.URL.Path, "/")
-	logrus.Infof("path strings = %v", pathStrings)
-	if len(pathStrings) >= 5 {
-		pluginInput.Version = pathStrings[2]
-		pluginInput.ProviderName = pathStrings[1]
-		pluginInput.Name = pathStrings[len(pathStrings)-2]
-		pluginInput.Action = pathStrings[len(pathStrings)-1]
+// checkCredential checks the credential in readiness_check_region, which may be changed at runtime.
+func checkCredential(ctx context.Context) error {
+	region := conf.GetAppConfig().ReadinessCheckRegion
+	if region == "" {
+		return nil
 	}
-	pluginInput.Parameters = r.Body
-	logrus.Infof("parsed request = %v", pluginInput)
-	return &pluginInput
+	return plugins.CheckCredential(ctx, region)
 }
@@ -0,0 +1,74 @@
+package plugins
+
+import (
+	"context"
+)
+
+type testInput struct {
+	CallBackParameter
+	Guid string
+	Id   string
+	Name string
+}
+
+type testInputs struct {
+	Inputs []testInput
+}
+
+type testOutput struct {
+	CallBackParameter
+	Result
+	Guid     string
+	Id       string
+	Password string
+}
+
+type testOutputs struct {
+	Outputs []testOutput
+}
+
+// testAction runs runInput for each input with runInputs, the inputs are run one by one in order if serial is set.
+// The output of an input has its guid and callback parameter and succeeds, unless runInput returns an error.
+type testAction struct {
+	serial   bool
+	runInput func(ctx context.Context, i int, input testInput, output *testOutput) error
+}
+
+func (action *testAction) ReadParam(ctx context.Context, param interface{}) (interface{}, error) {
+	return param, nil
+}
+
+func (action *testAction) Do(ctx context.Context, param interface{}) (interface{}, error) {
+	inputs := param.(testInputs)
+	outputs := testOutputs{Outputs: make([]testOutput, len(inputs.Inputs))}
+	var serialKeys func(i int) []string
+	if action.serial {
+		serialKeys = func(i int) []string { return []string{"test"} }
+	}
+	err := runInputs(ctx, len(inputs.Inputs), serialKeys, func(ctx context.Context, i int) error {
+		input := inputs.Inputs[i]
+		output := &outputs.Outputs[i]
+		*output = testOutput{CallBackParameter: input.CallBackParameter, Guid: input.Guid, Result: Result{Code: RESULT_CODE_SUCCESS}}
+		if action.runInput == nil {
+			return nil
+		}
+		err := action.runInput(ctx, i, input, output)
+		if err != nil {
+			output.Result.SetError(err)
+		}
+		return err
+	})
+	return &outputs, err
+}
+
+func newTestInputs(guids ...string) testInputs {
+	inputs := testInputs{}
+	for _, guid := range guids {
+		inputs.Inputs = append(inputs.Inputs, testInput{Guid: guid})
+	}
+	return inputs
+}
+
+func getTestOutputs(results interface{}) []testOutput {
+	return results.(*testOutputs).Outputs
+}
@@ -0,0 +1,373 @@
+package plugins
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"gopkg.in/natefinch/lumberjack.v2"
+)
+
+const (
+	// the outcomes of audited calls, the outcome is unknown if the request was sent but no response was
+	// received, the call may have changed resources.
+	AUDIT_OUTCOME_SUCCESS = "success"
+	AUDIT_OUTCOME_FAILURE = "failure"
+	AUDIT_OUTCOME_UNKNOWN = "unknown"
+
+	DEFAULT_AUDIT_QUERY_LIMIT = 1000
+
+	// lumberjack names the rotated files "{name}-{time}{ext}" by the time they are rotated in UTC.
+	auditBackupTimeFormat = "2006-01-02T15-04-05.000"
+)
+
+// AuditRecord is a cloud API call which creates, modifies or deletes resources.
+type AuditRecord struct {
+	Time          time.Time `json:"time"`
+	CorrelationId string    `json:"correlationId,omitempty"`
+	Plugin        string    `json:"plugin,omitempty"`
+	Action        string    `json:"action,omitempty"`
+	Guid          string    `json:"guid,omitempty"`
+	Service       string    `json:"service"`
+	Region        string    `json:"region,omitempty"`
+	// ApiAction is the action of the cloud API, or "{method} {path}" of COS.
+	ApiAction string `json:"apiAction"`
+	// Parameters of the call, whose passwords and secrets are masked.
+	Parameters map[string]interface{} `json:"parameters,omitempty"`
+	// ResourceIds are the ids in the parameters and the response, such as the id of the created instance.
+	ResourceIds  []string `json:"resourceIds,omitempty"`
+	RequestId    string   `json:"requestId,omitempty"`
+	Outcome      string   `json:"outcome"`
+	ErrorCode    string   `json:"errorCode,omitempty"`
+	ErrorMessage string   `json:"errorMessage,omitempty"`
+}
+
+// AuditFilter selects the audit records by guid, resource id and time range, empty fields match all the records.
+type AuditFilter struct {
+	Guid       string
+	ResourceId string
+	StartTime  time.Time
+	EndTime    time.Time
+	// Limit is the max number of records returned, the latest ones are kept. Zero is DEFAULT_AUDIT_QUERY_LIMIT.
+	Limit int
+}
+
+func (filter *AuditFilter) match(record *AuditRecord) bool {
+	if filter.Guid != "" && record.Guid != filter.Guid {
+		return false
+	}
+	if !filter.StartTime.IsZero() && record.Time.Before(filter.StartTime) {
+		return false
+	}
+	if !filter.EndTime.IsZero() && record.Time.After(filter.EndTime) {
+		return false
+	}
+	if filter.ResourceId == "" {
+		return true
+	}
+	for _, resourceId := range record.ResourceIds {
+		if resourceId == filter.ResourceId {
+			return true
+		}
+	}
+	return false
+}
+
+// AuditJournal appends the audit records to File as json lines. The file is rotated once it reaches MaxSize
+// megabytes, the rotated files are kept in the same dir and removed after MaxBackups files or MaxAge days,
+// zero keeps them all.
+type AuditJournal struct {
+	File       string
+	MaxSize    int
+	MaxBackups int
+	MaxAge     int
+
+	mutex  sync.Mutex
+	writer *lumberjack.Logger
+}
+
+// DefaultAuditJournal records the mutating cloud API calls, nil records nothing.
+var DefaultAuditJournal *AuditJournal
+
+func (journal *AuditJournal) Append(record *AuditRecord) error {
+	line, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+
+	journal.mutex.Lock()
+	defer journal.mutex.Unlock()
+
+	if journal.writer == nil {
+		if err = os.MkdirAll(filepath.Dir(journal.File), 0700); err != nil {
+			return err
+		}
+		journal.writer = &lumberjack.Logger{
+			Filename:   journal.File,
+			MaxSize:    journal.MaxSize,
+			MaxBackups: journal.MaxBackups,
+			MaxAge:     journal.MaxAge,
+		}
+	}
+	_, err = journal.writer.Write(append(line, '\n'))
+	return err
+}
+
+// Close closes the file, it is opened again by the next Append.
+func (journal *AuditJournal) Close() error {
+	journal.mutex.Lock()
+	defer journal.mutex.Unlock()
+
+	if journal.writer == nil {
+		return nil
+	}
+	err := journal.writer.Close()
+	journal.writer = nil
+	return err
+}
+
+// Query returns the records of the filter in time order, from the rotated files and the current one.
+func (journal *AuditJournal) Query(filter AuditFilter) ([]AuditRecord, error) {
+	limit := filter.Limit
+	if limit <= 0 {
+		limit = DEFAULT_AUDIT_QUERY_LIMIT
+	}
+
+	// the lock is only held to list the files, so the calls being recorded don't wait for the query. A line
+	// being appended while the file is read is cut, it is skipped like the lines cut by a crash.
+	journal.mutex.Lock()
+	files, err := journal.getFiles(filter.StartTime)
+	journal.mutex.Unlock()
+	if err != nil {
+		return nil, err
+	}
+	records := []AuditRecord{}
+	for _, file := range files {
+		if records, err = readAuditRecords(file, &filter, records); err != nil {
+			return nil, err
+		}
+		if len(records) > limit {
+			records = records[len(records)-limit:]
+		}
+	}
+	return records, nil
+}
+
+// getFiles returns the rotated files in the order they were rotated and then the current file, the rotated
+// files which were rotated before the start time only have earlier records, they are skipped.
+func (journal *AuditJournal) getFiles(startTime time.Time) ([]string, error) {
+	dir := filepath.Dir(journal.File)
+	ext := filepath.Ext(journal.File)
+	prefix := strings.TrimSuffix(filepath.Base(journal.File), ext) + "-"
+	infos, err := ioutil.ReadDir(dir)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	backups := []string{}
+	for _, info := range infos {
+		name := info.Name()
+		if info.IsDir() || !strings.HasPrefix(name, prefix) || !strings.HasSuffix(name, ext) {
+			continue
+		}
+		rotateTime, err := time.Parse(auditBackupTimeFormat, strings.TrimSuffix(strings.TrimPrefix(name, prefix), ext))
+		if err != nil || (!startTime.IsZero() && rotateTime.Before(startTime)) {
+			continue
+		}
+		backups = append(backups, name)
+	}
+	// the names of the same format are sorted by time.
+	sort.Strings(backups)
+
+	files := []string{}
+	for _, backup := range backups {
+		files = append(files, filepath.Join(dir, backup))
+	}
+	return append(files, journal.File), nil
+}
+
+func readAuditRecords(file string, filter *AuditFilter, records []AuditRecord) ([]AuditRecord, error) {
+	f, err := os.Open(file)
+	if os.IsNotExist(err) {
+		return records, nil
+	}
+	if err != nil {
+		return records, err
+	}
+	defer f.Close()
+
+	reader := bufio.NewReader(f)
+	for {
+		line, err := reader.ReadBytes('\n')
+		if len(line) > 0 {
+			record := AuditRecord{}
+			// a line which is cut by a crash is skipped.
+			if json.Unmarshal(line, &record) == nil && filter.match(&record) {
+				records = append(records, record)
+			}
+		}
+		if err != nil {
+			break
+		}
+	}
+	return records, nil
+}
+
+// cosAclHeaderPrefixes are the headers of COS requests which set the acl of buckets and objects, such as the
+// grants of PutBucketACL.
+var cosAclHeaderPrefixes = []string{"X-Cos-Acl", "X-Cos-Grant-"}
+
+// getCosAclParameters adds the acl headers of the COS request to the parameters.
+func getCosAclParameters(request *http.Request, parameters map[string]interface{}) map[string]interface{} {
+	for name, values := range request.Header {
+		for _, prefix := range cosAclHeaderPrefixes {
+			if strings.HasPrefix(name, prefix) && len(values) > 0 {
+				parameters[name] = strings.Join(values, ",")
+			}
+		}
+	}
+	return parameters
+}
+
+// auditTransport records the cloud API requests which change resources in DefaultAuditJournal, the read only
+// requests are not recorded.
+type auditTransport struct {
+	next http.RoundTripper
+}
+
+func (transport *auditTransport) RoundTrip(request *http.Request) (*http.Response, error) {
+	journal := DefaultAuditJournal
+	action := getApiAction(request)
+	if journal == nil || (action != "" && isReadOnlyAction(action)) ||
+		(action == "" && (request.Method == http.MethodGet || request.Method == http.MethodHead)) {
+		return transport.next.RoundTrip(request)
+	}
+
+	ctx := request.Context()
+	record := newAuditRecord(ctx)
+	if action == "" {
+		record.Service, record.Region, record.ApiAction = QCLOUD_SERVICE_COS, getCosRegion(request.URL.Host), request.Method+" "+request.URL.RequestURI()
+		record.Parameters = sanitizeParameters(getCosAclParameters(request, map[string]interface{}{"host": request.URL.Host}))
+		record.ResourceIds = []string{strings.SplitN(request.URL.Host, ".", 2)[0]}
+	} else {
+		record.Service, record.Region, _ = getRateLimitKey(request)
+		record.ApiAction = action
+		var err error
+		if request, record.Parameters, err = getRequestParameters(request); err != nil {
+			return nil, err
+		}
+		record.ResourceIds = collectResourceIds(record.Parameters, record.ResourceIds)
+	}
+
+	response, err := transport.next.RoundTrip(request)
+	record.Time = time.Now()
+	if err != nil {
+		record.Outcome = AUDIT_OUTCOME_UNKNOWN
+		record.ErrorMessage = MaskSecretsInText(err.Error())
+	} else {
+		fillAuditOutcome(record, response)
+	}
+	if appendErr := journal.Append(record); appendErr != nil {
+		Logger(ctx).Errorf("append audit record of %v.%v to %v meet error=%v", record.Service, record.ApiAction, journal.File, appendErr)
+	}
+	return response, err
+}
+
+func newAuditRecord(ctx context.Context) *AuditRecord {
+	record := &AuditRecord{CorrelationId: GetCorrelationId(ctx)}
+	if action := getActionInfo(ctx); action != nil {
+		record.Plugin, record.Action, record.Guid = action.plugin, action.action, action.getInputGuid(ctx)
+	}
+	return record
+}
+
+// fillAuditOutcome sets the outcome, the RequestId and the resource ids of the response, the body is kept for the caller.
+func fillAuditOutcome(record *AuditRecord, response *http.Response) {
+	record.Outcome = AUDIT_OUTCOME_SUCCESS
+	if errorCode := getApiErrorCode(response); errorCode != "" {
+		record.Outcome, record.ErrorCode = AUDIT_OUTCOME_FAILURE, errorCode
+		if apiErr, _ := readApiError(response); apiErr != nil {
+			record.ErrorMessage = MaskSecretsInText(apiErr.Message)
+		}
+	}
+	if record.Service == QCLOUD_SERVICE_COS {
+		record.RequestId = response.Header.Get("X-Cos-Request-Id")
+		return
+	}
+
+	body, err := ioutil.ReadAll(response.Body)
+	response.Body.Close()
+	response.Body = ioutil.NopCloser(bytes.NewReader(body))
+	if err != nil {
+		return
+	}
+	apiResponse := struct {
+		Response map[string]interface{} `json:"Response"`
+	}{}
+	if json.Unmarshal(body, &apiResponse) == nil && apiResponse.Response != nil {
+		record.RequestId, _ = apiResponse.Response["RequestId"].(string)
+		record.ResourceIds = collectResourceIds(apiResponse.Response, record.ResourceIds)
+	}
+}
+
+// collectResourceIds appends the string values of the fields named "...Id", "...Ids" or "...IdSet", such as
+// "InstanceIds" or "vpcId" of the legacy APIs, but not RequestId.
+func collectResourceIds(v interface{}, resourceIds []string) []string {
+	switch value := v.(type) {
+	case map[string]interface{}:
+		for key, item := range value {
+			// the parameters of the legacy APIs are flattened, such as "instanceIds.0".
+			name := strings.TrimRight(key, "0123456789.")
+			if name == "RequestId" || !(strings.HasSuffix(name, "Id") || strings.HasSuffix(name, "Ids") || strings.HasSuffix(name, "IdSet")) {
+				resourceIds = collectResourceIds(item, resourceIds)
+				continue
+			}
+			resourceIds = appendResourceIds(item, resourceIds)
+		}
+	case []interface{}:
+		for _, item := range value {
+			resourceIds = collectResourceIds(item, resourceIds)
+		}
+	}
+	return resourceIds
+}
+
+func appendResourceIds(v interface{}, resourceIds []string) []string {
+	switch value := v.(type) {
+	case string:
+		if value == "" {
+			return resourceIds
+		}
+		for _, resourceId := range resourceIds {
+			if resourceId == value {
+				return resourceIds
+			}
+		}
+		return append(resourceIds, value)
+	case []interface{}:
+		for _, item := range value {
+			resourceIds = appendResourceIds(item, resourceIds)
+		}
+	}
+	return resourceIds
+}
+
+// getCosRegion returns the region of "{bucket}.cos.{region}.myqcloud.com".
+func getCosRegion(host string) string {
+	parts := strings.Split(host, ".")
+	for i := 0; i+1 < len(parts); i++ {
+		if parts[i] == "cos" && strings.Contains(host, "myqcloud.com") {
+			return parts[i+1]
+		}
+	}
+	return ""
+}
@@ -0,0 +1,180 @@
+package plugins
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/common"
+	cvm "github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/cvm/v20170312"
+)
+
+func newTestAuditJournal(t *testing.T) (*AuditJournal, func()) {
+	dir, err := ioutil.TempDir("", "audit")
+	if err != nil {
+		t.Fatalf("create temp dir meet error=%v", err)
+	}
+	journal := &AuditJournal{File: filepath.Join(dir, "audit.log"), MaxSize: 1}
+	DefaultAuditJournal = journal
+	return journal, func() {
+		DefaultAuditJournal = nil
+		journal.Close()
+		os.RemoveAll(dir)
+	}
+}
+
+// newAuditTestAction describes the instances, then runs an instance and terminates it for each input.
+func newAuditTestAction(factory *ClientFactory) *testAction {
+	return &testAction{runInput: func(ctx context.Context, i int, input testInput, output *testOutput) error {
+		client, _ := factory.WithContext(ctx).NewCvmClient("ap-audit", "fake-secret-id", "fake-secret-key")
+		if _, err := client.DescribeInstances(cvm.NewDescribeInstancesRequest()); err != nil {
+			return err
+		}
+		runRequest := cvm.NewRunInstancesRequest()
+		runRequest.InstanceName = common.StringPtr(input.Guid)
+		runRequest.LoginSettings = &cvm.LoginSettings{Password: common.StringPtr("secret-password")}
+		response, err := client.RunInstances(runRequest)
+		if err != nil {
+			return err
+		}
+		terminateRequest := cvm.NewTerminateInstancesRequest()
+		terminateRequest.InstanceIds = response.Response.InstanceIdSet
+		_, err = client.TerminateInstances(terminateRequest)
+		return err
+	}}
+}
+
+func TestAuditMutatingCalls(t *testing.T) {
+	journal, cleanup := newTestAuditJournal(t)
+	defer cleanup()
+
+	server, _ := newScriptedServer(func(action, body string, times int) string {
+		switch action {
+		case "RunInstances":
+			name := "ins-1"
+			if strings.Contains(body, "guid_2") {
+				name = "ins-2"
+			}
+			return `{"Response":{"InstanceIdSet":["` + name + `"],"RequestId":"run-request-id"}}`
+		case "TerminateInstances":
+			if strings.Contains(body, "ins-2") {
+				return errorResponse("ResourceInUse")
+			}
+		}
+		return okResponse
+	})
+	defer server.Close()
+
+	startTime := time.Now()
+	action := newAuditTestAction(newRetryTestFactory(server, &RetryPolicy{}))
+	ctx := ContextWithCorrelationId(context.Background(), "wecube-request-1")
+	results, err := doAction(ctx, "vm", "create", action, newTestInputs("guid_1", "guid_2"))
+	if outputs := getTestOutputs(results); err == nil || outputs[0].Code != RESULT_CODE_SUCCESS || outputs[1].Code != RESULT_CODE_ERROR {
+		t.Errorf("outputs=%+v, error=%v, expected the terminate of guid_2 fails", outputs, err)
+	}
+
+	records, err := journal.Query(AuditFilter{})
+	if err != nil {
+		t.Fatalf("query audit journal meet error=%v", err)
+	}
+	if len(records) != 4 {
+		t.Fatalf("read only calls are recorded or mutating calls are lost, records=%+v", records)
+	}
+	for _, record := range records {
+		if record.ApiAction == "DescribeInstances" {
+			t.Errorf("read only call is recorded, record=%+v", record)
+		}
+		if record.CorrelationId != "wecube-request-1" || record.Plugin != "vm" || record.Action != "create" || record.Service != QCLOUD_SERVICE_CVM || record.Region != "ap-audit" {
+			t.Errorf("record=%+v", record)
+		}
+	}
+
+	records, _ = journal.Query(AuditFilter{Guid: "guid_2"})
+	if len(records) != 2 || records[0].ApiAction != "RunInstances" || records[1].ApiAction != "TerminateInstances" {
+		t.Fatalf("records of guid_2=%+v", records)
+	}
+	run, terminate := records[0], records[1]
+	if run.Outcome != AUDIT_OUTCOME_SUCCESS || run.RequestId != "run-request-id" || len(run.ResourceIds) != 1 || run.ResourceIds[0] != "ins-2" {
+		t.Errorf("record of RunInstances=%+v", run)
+	}
+	if b, _ := json.Marshal(run.Parameters); strings.Contains(string(b), "secret-password") {
+		t.Errorf("password is recorded, parameters=%s", b)
+	}
+	if terminate.Outcome != AUDIT_OUTCOME_FAILURE || terminate.ErrorCode != "ResourceInUse" || terminate.RequestId != "fake-request-id" {
+		t.Errorf("record of TerminateInstances=%+v", terminate)
+	}
+
+	records, _ = journal.Query(AuditFilter{ResourceId: "ins-1"})
+	if len(records) != 2 || records[0].Guid != "guid_1" || records[1].Outcome != AUDIT_OUTCOME_SUCCESS {
+		t.Errorf("records of ins-1=%+v", records)
+	}
+	if records, _ = journal.Query(AuditFilter{EndTime: startTime}); len(records) != 0 {
+		t.Errorf("records before the action=%+v", records)
+	}
+	if records, _ = journal.Query(AuditFilter{StartTime: startTime, Limit: 1}); len(records) != 1 || records[0].ApiAction != "TerminateInstances" {
+		t.Errorf("latest record=%+v", records)
+	}
+}
+
+func TestAuditCosAcl(t *testing.T) {
+	journal, cleanup := newTestAuditJournal(t)
+	defer cleanup()
+
+	transport := &auditTransport{next: roundTripFunc(func(request *http.Request) (*http.Response, error) {
+		return newDryRunResponse(request, http.StatusOK, "application/xml", ""), nil
+	})}
+	request, _ := http.NewRequest(http.MethodPut, "http://bucket-1250000000.cos.ap-guangzhou.myqcloud.com/?acl", nil)
+	request.Header.Add("x-cos-grant-read", `id="qcs::cam::uin/100000000001:uin/100000000002"`)
+	request.Header.Add("x-cos-acl", "private")
+	request.Header.Add("x-cos-security-token", "cos-token")
+	request.Header.Add("Authorization", "q-sign-algorithm=sha1&q-ak=fake-secret-id")
+	if _, err := transport.RoundTrip(request.WithContext(withActionInfo(context.Background(), "bucket", "add-permission", []string{"guid_1"}))); err != nil {
+		t.Fatalf("PutBucketACL meet error=%v", err)
+	}
+
+	records, _ := journal.Query(AuditFilter{Guid: "guid_1"})
+	if len(records) != 1 || records[0].Service != QCLOUD_SERVICE_COS || records[0].ApiAction != "PUT /?acl" || records[0].ResourceIds[0] != "bucket-1250000000" {
+		t.Fatalf("records=%+v", records)
+	}
+	parameters := records[0].Parameters
+	if parameters["X-Cos-Grant-Read"] != `id="qcs::cam::uin/100000000001:uin/100000000002"` || parameters["X-Cos-Acl"] != "private" || len(parameters) != 3 {
+		t.Errorf("parameters=%+v", parameters)
+	}
+}
+
+func TestAuditQueryRotatedFiles(t *testing.T) {
+	journal, cleanup := newTestAuditJournal(t)
+	defer cleanup()
+
+	dir := filepath.Dir(journal.File)
+	oldTime := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	writeBackup := func(rotateTime time.Time, records ...AuditRecord) {
+		lines := []string{}
+		for _, record := range records {
+			b, _ := json.Marshal(record)
+			lines = append(lines, string(b))
+		}
+		name := "audit-" + rotateTime.Format(auditBackupTimeFormat) + ".log"
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(strings.Join(lines, "\n")+"\n"), 0600); err != nil {
+			t.Fatalf("write backup meet error=%v", err)
+		}
+	}
+	writeBackup(oldTime.Add(time.Hour), AuditRecord{Time: oldTime, Guid: "guid_1", ApiAction: "RunInstances"})
+	writeBackup(oldTime.Add(48*time.Hour), AuditRecord{Time: oldTime.Add(24 * time.Hour), Guid: "guid_1", ApiAction: "StopInstances"})
+	journal.Append(&AuditRecord{Time: time.Now(), Guid: "guid_1", ApiAction: "TerminateInstances"})
+
+	records, err := journal.Query(AuditFilter{Guid: "guid_1"})
+	if err != nil || len(records) != 3 || records[0].ApiAction != "RunInstances" || records[2].ApiAction != "TerminateInstances" {
+		t.Fatalf("records=%+v, err=%v", records, err)
+	}
+	records, _ = journal.Query(AuditFilter{StartTime: oldTime.Add(2 * time.Hour)})
+	if len(records) != 2 || records[0].ApiAction != "StopInstances" {
+		t.Errorf("records after the first backup=%+v", records)
+	}
+}
@@ -1,11 +1,8 @@
 package plugins
 
 import (
-	"github.com/sirupsen/logrus"
 	"fmt"
 	"github.com/tencentyun/cos-go-sdk-v5"
-	"net/url"
-	"net/http"
 	"context"
 	"strings"
 )
@@ -22,6 +19,10 @@ func (plugin *BucketPlugin) GetActionByName(actionName string) (Action, error) {
 	return action, nil
 }
 
+func (plugin *BucketPlugin) GetActions() map[string]Action {
+	return BucketActions
+}
+
 var BucketActions = make(map[string]Action)
 
 func init() {
@@ -35,14 +36,14 @@ type BucketInputs struct {
 
 type BucketInput struct {
 	CallBackParameter
-	Guid             string `json:"guid,omitempty"`
-	BucketName       string `json:"bucket_name,omitempty"`
-	ProviderParams   string `json:"provider_params,omitempty"`
-	Location         string `json:"location"`
-	APISecret        string `json:"api_secret"`
-	AccountAppId     string `json:"account_app_id"`
-	IsPublic         string `json:"is_public"`
-	ForceDelete      string `json:"force_delete"`
+	Guid             string `json:"guid,omitempty" register:"required"`
+	BucketName       string `json:"bucket_name,omitempty" register:"required"`
+	ProviderParams   string `json:"provider_params,omitempty" register:"optional"`
+	Location         string `json:"location" register:"required"`
+	APISecret        string `json:"api_secret" register:"required,system_variable=QCLOUD_API_SECRET"`
+	AccountAppId     string `json:"account_app_id" register:"required"`
+	IsPublic         string `json:"is_public" register:"required,actions=create"`
+	ForceDelete      string `json:"force_delete" register:"required,actions=delete"`
 }
 
 type BucketOutputs struct {
@@ -53,9 +54,9 @@ type BucketOutput struct {
 	CallBackParameter
 	Result
 	RequestId    string `json:"request_id,omitempty"`
-	Guid         string `json:"guid,omitempty"`
+	Guid         string `json:"guid,omitempty" register:"optional"`
 	BucketName   string `json:"bucket_name,omitempty"`
-	BucketUrl    string `json:"bucket_url,omitempty"`
+	BucketUrl    string `json:"bucket_url,omitempty" register:"optional,actions=create"`
 }
 
 type BucketCreateAction struct {
@@ -64,7 +65,7 @@ type BucketCreateAction struct {
 type BucketDeleteAction struct {
 }
 
-func (action *BucketCreateAction) ReadParam(param interface{}) (interface{}, error) {
+func (action *BucketCreateAction) ReadParam(ctx context.Context, param interface{}) (interface{}, error) {
 	var inputs BucketInputs
 	err := UnmarshalJson(param, &inputs)
 	if err != nil {
@@ -73,7 +74,7 @@ func (action *BucketCreateAction) ReadParam(param interface{}) (interface{}, err
 	return inputs, nil
 }
 
-func (action *BucketDeleteAction) ReadParam(param interface{}) (interface{}, error) {
+func (action *BucketDeleteAction) ReadParam(ctx context.Context, param interface{}) (interface{}, error) {
 	var inputs BucketInputs
 	err := UnmarshalJson(param, &inputs)
 	if err != nil {
@@ -82,72 +83,69 @@ func (action *BucketDeleteAction) ReadParam(param interface{}) (interface{}, err
 	return inputs, nil
 }
 
-func getCosClient(name,appId,region,secretID,secretKey,bucketUrl string) (client *cos.Client,cosUrl string) {
-	if bucketUrl == "" {
-		bucketUrl = fmt.Sprintf("https://%s-%s.cos.%s.myqcloud.com", name, appId, region)
-	}
-	u, _ := url.Parse(bucketUrl)
-	b := &cos.BaseURL{BucketURL: u}
-	client = cos.NewClient(b, &http.Client{
-		Transport: &cos.AuthorizationTransport{
-			SecretID:  secretID,
-			SecretKey: secretKey,
-		},
-	})
-	return client,bucketUrl
+func getCosClient(name,appId string,params *ProviderParams,bucketUrl string) (client *cos.Client,cosUrl string) {
+	return GetClientFactory().WithProviderParams(params).NewCosClient(name, appId, params.Region, params.SecretID, params.SecretKey, bucketUrl)
 }
 
-func (action *BucketCreateAction) createBucket(bucketInput *BucketInput) (output BucketOutput, err error) {
+func (action *BucketCreateAction) createBucket(ctx context.Context, bucketInput *BucketInput) (output BucketOutput, err error) {
 	output.Guid = bucketInput.Guid
 	output.Result.Code = RESULT_CODE_SUCCESS
 	output.CallBackParameter.Parameter = bucketInput.CallBackParameter.Parameter
 	output.BucketName = fmt.Sprintf("%s-%s", bucketInput.BucketName, bucketInput.AccountAppId)
 
-	if bucketInput.Location != "" && bucketInput.APISecret != "" {
-		bucketInput.ProviderParams = fmt.Sprintf("%s;%s", bucketInput.Location, bucketInput.APISecret)
-	}
-	paramsMap, err := GetMapFromProviderParams(bucketInput.ProviderParams)
+	params, err := NewProviderParams(bucketInput.ProviderParams, bucketInput.Location, bucketInput.APISecret)
 	defer func() {
 		if err != nil {
-			output.Result.Code = RESULT_CODE_ERROR
-			output.Result.Message = err.Error()
+			output.Result.SetError(err)
 		}
 	}()
-	client,bucketUrl := getCosClient(bucketInput.BucketName, bucketInput.AccountAppId, paramsMap["Region"], paramsMap["SecretID"], paramsMap["SecretKey"], "")
+	if err != nil {
+		return output, err
+	}
+	client,bucketUrl := getCosClient(bucketInput.BucketName, bucketInput.AccountAppId, params, "")
+	// the input resumes with the bucket created by its previous run, a bucket whose creation was interrupted
+	// before COS answered is not recorded, so its retry fails as the bucket exists.
+	if getRecordedResourceId(ctx, bucketInput.Guid) == output.BucketName {
+		if _, headErr := client.Bucket.Head(ctx); headErr == nil {
+			Logger(ctx).Infof("bucket:%s already is exist, url:%s", bucketInput.BucketName, bucketUrl)
+			output.BucketUrl = bucketUrl
+			return output, nil
+		}
+	}
 	cosAcl := "private"
 	isPublic := strings.ToLower(bucketInput.IsPublic)
 	if isPublic == "y" || isPublic == "yes" || isPublic == "true" {
 		cosAcl = "public-read"
 	}
 	opt := cos.BucketPutOptions{XCosACL:cosAcl}
-	_,err = client.Bucket.Put(context.Background(), &opt)
+	_,err = client.Bucket.Put(ctx, &opt)
 	if err != nil {
 		err = fmt.Errorf("create bucket:%s error ---> %v", bucketInput.BucketName, err)
 		return output, err
 	}
-	logrus.Printf("create bucket:%s success,url:%s \n", bucketInput.BucketName, bucketUrl)
+	recordResourceId(ctx, bucketInput.Guid, output.BucketName)
+	Logger(ctx).Printf("create bucket:%s success,url:%s \n", bucketInput.BucketName, bucketUrl)
 	output.BucketUrl = bucketUrl
 	return output, err
 }
 
-func (action *BucketCreateAction) Do(input interface{}) (interface{}, error) {
+func (action *BucketCreateAction) Do(ctx context.Context, input interface{}) (interface{}, error) {
 	buckets, _ := input.(BucketInputs)
-	outputs := BucketOutputs{}
-	var finalErr error
-
-	for _, bucket := range buckets.Inputs {
-		bucketOutput, err := action.createBucket(&bucket)
-		if err != nil {
-			finalErr = err
-		}
-		outputs.Outputs = append(outputs.Outputs, bucketOutput)
-	}
+	outputs := BucketOutputs{Outputs: make([]BucketOutput, len(buckets.Inputs))}
+	finalErr := runInputs(ctx, len(buckets.Inputs), func(i int) []string {
+		return []string{buckets.Inputs[i].Guid, buckets.Inputs[i].BucketName}
+	}, func(ctx context.Context, i int) error {
+		bucket := buckets.Inputs[i]
+		bucketOutput, err := action.createBucket(ctx, &bucket)
+		outputs.Outputs[i] = bucketOutput
+		return err
+	})
 
-	logrus.Infof("all buckets = %v are created", buckets)
+	Logger(ctx).Infof("all buckets = %s are created", Sanitize(buckets))
 	return &outputs, finalErr
 }
 
-func (action *BucketDeleteAction) deleteBucket(bucketInput *BucketInput) (output BucketOutput, err error) {
+func (action *BucketDeleteAction) deleteBucket(ctx context.Context, bucketInput *BucketInput) (output BucketOutput, err error) {
 	output.Guid = bucketInput.Guid
 	output.Result.Code = RESULT_CODE_SUCCESS
 	output.CallBackParameter.Parameter = bucketInput.CallBackParameter.Parameter
@@ -156,21 +154,20 @@ func (action *BucketDeleteAction) deleteBucket(bucketInput *BucketInput) (output
 	}
 	output.BucketName = fmt.Sprintf("%s-%s", bucketInput.BucketName, bucketInput.AccountAppId)
 
-	if bucketInput.Location != "" && bucketInput.APISecret != "" {
-		bucketInput.ProviderParams = fmt.Sprintf("%s;%s", bucketInput.Location, bucketInput.APISecret)
-	}
-	paramsMap, err := GetMapFromProviderParams(bucketInput.ProviderParams)
+	params, err := NewProviderParams(bucketInput.ProviderParams, bucketInput.Location, bucketInput.APISecret)
 	defer func() {
 		if err != nil {
-			output.Result.Code = RESULT_CODE_ERROR
-			output.Result.Message = err.Error()
+			output.Result.SetError(err)
 		}
 	}()
-	client,_ := getCosClient(bucketInput.BucketName, bucketInput.AccountAppId, paramsMap["Region"], paramsMap["SecretID"], paramsMap["SecretKey"], "")
+	if err != nil {
+		return output, err
+	}
+	client,_ := getCosClient(bucketInput.BucketName, bucketInput.AccountAppId, params, "")
 	// force
 	forceDelete := strings.ToLower(bucketInput.ForceDelete)
 	if forceDelete == "y" || forceDelete == "yes" || forceDelete == "true" {
-		getResult,_,getErr := client.Bucket.Get(context.Background(), &cos.BucketGetOptions{MaxKeys:1000})
+		getResult,_,getErr := client.Bucket.Get(ctx, &cos.BucketGetOptions{MaxKeys:1000})
 		if getErr != nil {
 			err = fmt.Errorf("force delete bucket:%s fail, get bucket objects error ---> %v ", bucketInput.BucketName, err)
 			return output,err
@@ -178,20 +175,20 @@ func (action *BucketDeleteAction) deleteBucket(bucketInput *BucketInput) (output
 		if len(getResult.Contents) > 0 {
 			var tmpObjects []cos.Object
 			for i,v := range getResult.Contents {
-				logrus.Printf("contents %d: %s \n", i, v.Key)
+				Logger(ctx).Printf("contents %d: %s \n", i, v.Key)
 				tmpObjects = append(tmpObjects, cos.Object{Key:v.Key})
 			}
 			delOpt := &cos.ObjectDeleteMultiOptions{
 				Objects: tmpObjects,
 			}
-			_, _, err = client.Object.DeleteMulti(context.Background(), delOpt)
+			_, _, err = client.Object.DeleteMulti(ctx, delOpt)
 			if err != nil {
 				err = fmt.Errorf("force delete bucket:%s fail, delete objects error ---> %v ", bucketInput.BucketName, err)
 				return output, err
 			}
 		}
 	}
-	_,err = client.Bucket.Delete(context.Background())
+	_,err = client.Bucket.Delete(ctx)
 	if err != nil {
 		err = fmt.Errorf("delete bucket:%s error ---> %v ", bucketInput.BucketName, err)
 		return output, err
@@ -199,35 +196,34 @@ func (action *BucketDeleteAction) deleteBucket(bucketInput *BucketInput) (output
 	return output, err
 }
 
-func (action *BucketDeleteAction) Do(input interface{}) (interface{}, error) {
+func (action *BucketDeleteAction) Do(ctx context.Context, input interface{}) (interface{}, error) {
 	buckets, _ := input.(BucketInputs)
-	outputs := BucketOutputs{}
-	var finalErr error
-
-	for _, bucket := range buckets.Inputs {
-		bucketOutput, err := action.deleteBucket(&bucket)
-		if err != nil {
-			finalErr = err
-		}
-		outputs.Outputs = append(outputs.Outputs, bucketOutput)
-	}
+	outputs := BucketOutputs{Outputs: make([]BucketOutput, len(buckets.Inputs))}
+	finalErr := runInputs(ctx, len(buckets.Inputs), func(i int) []string {
+		return []string{buckets.Inputs[i].Guid, buckets.Inputs[i].BucketName}
+	}, func(ctx context.Context, i int) error {
+		bucket := buckets.Inputs[i]
+		bucketOutput, err := action.deleteBucket(ctx, &bucket)
+		outputs.Outputs[i] = bucketOutput
+		return err
+	})
 
-	logrus.Infof("all buckets = %v are delete", buckets)
+	Logger(ctx).Infof("all buckets = %s are delete", Sanitize(buckets))
 	return &outputs, finalErr
 }
 
-func SetBucketAcl(region,secretID,secretKey,bucketUrl,uin,permission string) error {
-	client,_ := getCosClient("","",region,secretID,secretKey,bucketUrl)
-	bucketAclResult,_,err := client.Bucket.GetACL(context.Background())
+func SetBucketAcl(ctx context.Context, params *ProviderParams, bucketUrl,uin,permission string) error {
+	client,_ := getCosClient("","",params,bucketUrl)
+	bucketAclResult,_,err := client.Bucket.GetACL(ctx)
 	if err != nil {
 		return fmt.Errorf("get bucket owner id fail,error: %v ", err)
 	}
 	var readGrant,writeGrant,fullControlGrant string
 	if len(bucketAclResult.AccessControlList) > 0 {
-		userClient,_ := createUserClient(region,secretID,secretKey)
+		userClient,_ := createUserClient(ctx, params)
 		users,_ := ListSubUsers(userClient)
 		for _,v := range bucketAclResult.AccessControlList {
-			logrus.Infof("access control ---> permission:%s id:%s type:%s ", v.Permission, v.Grantee.ID, v.Grantee.Type)
+			Logger(ctx).Infof("access control ---> permission:%s id:%s type:%s ", v.Permission, v.Grantee.ID, v.Grantee.Type)
 			tmpGranteeId := v.Grantee.ID
 			var newList []string
 			for _,vv := range strings.Split(tmpGranteeId, ",") {
@@ -242,7 +238,7 @@ func SetBucketAcl(region,secretID,secretKey,bucketUrl,uin,permission string) err
 					}
 				}
 				if !userExist {
-					logrus.Infof("user uin:%s this user not exist ")
+					Logger(ctx).Infof("user uin:%s this user not exist ", vv)
 					continue
 				}
 				newList = append(newList, fmt.Sprintf("id=\"%s\"", vv))
@@ -262,7 +258,7 @@ func SetBucketAcl(region,secretID,secretKey,bucketUrl,uin,permission string) err
 		case "write": writeGrant = appendGrantId(writeGrant, grantId)
 		case "full_control": fullControlGrant = appendGrantId(fullControlGrant, grantId)
 	}
-	logrus.Infof("--------> read:%s  write:%s  full_control:%s", readGrant, writeGrant, fullControlGrant)
+	Logger(ctx).Infof("--------> read:%s  write:%s  full_control:%s", readGrant, writeGrant, fullControlGrant)
 	opt := &cos.BucketPutACLOptions{
 		Header: &cos.ACLHeaderOptions{
 			XCosGrantRead: readGrant,
@@ -270,9 +266,9 @@ func SetBucketAcl(region,secretID,secretKey,bucketUrl,uin,permission string) err
 			XCosGrantFullControl: fullControlGrant,
 		},
 	}
-	_,err = client.Bucket.PutACL(context.Background(), opt)
+	_,err = client.Bucket.PutACL(ctx, opt)
 	if err != nil {
-		logrus.Errorf("set bucket acl with grant:%s error %v ", grantId, err)
+		Logger(ctx).Errorf("set bucket acl with grant:%s error %v ", grantId, err)
 	}
 	return err
 }
@@ -1,31 +1,27 @@
 package securitygroup
 
 import (
+	"context"
 	"fmt"
 
 	"github.com/WeBankPartners/wecube-plugins-qcloud/plugins"
 	"github.com/sirupsen/logrus"
 	bm "github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/bm/v20180423"
 	"github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/common"
-	"github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/common/profile"
-)
-
-const (
-	QCLOUD_ENDPOINT_BM = "bm.tencentcloudapi.com"
 )
 
 //resource type
 type BmResourceType struct {
 }
 
-func (resourceType *BmResourceType) QueryInstancesById(providerParams string, instanceIds []string) (map[string]ResourceInstance, error) {
-	logrus.Infof("BmResourceType QueryInstancesById: request instanceIds=%++v", instanceIds)
+func (resourceType *BmResourceType) QueryInstancesById(ctx context.Context, providerParams string, instanceIds []string) (map[string]ResourceInstance, error) {
+	plugins.Logger(ctx).Infof("BmResourceType QueryInstancesById: request instanceIds=%++v", instanceIds)
 
 	result := make(map[string]ResourceInstance)
 	if len(instanceIds) == 0 {
 		err := fmt.Errorf("instanceIds is empty")
 
-		logrus.Errorf("BmResourceType QueryInstancesById meet error=%v", err)
+		plugins.Logger(ctx).Errorf("BmResourceType QueryInstancesById meet error=%v", err)
 		return result, err
 	}
 
@@ -33,10 +29,14 @@ func (resourceType *BmResourceType) QueryInstancesById(providerParams string, in
 		Name:   "instanceId",
 		Values: instanceIds,
 	}
-	paramsMap, _ := plugins.GetMapFromProviderParams(providerParams)
-	deviceInfoSet, err := QueryBmInstance(providerParams, filter)
+	params, err := plugins.ParseProviderParams(providerParams)
+	if err != nil {
+		plugins.Logger(ctx).Errorf("BmResourceType QueryInstancesById ParseProviderParams meet error=%v", err)
+		return result, err
+	}
+	deviceInfoSet, err := QueryBmInstance(ctx, providerParams, filter)
 	if err != nil {
-		logrus.Errorf("BmResourceType QueryInstancesById QueryBmInstance meet error=%v", err)
+		plugins.Logger(ctx).Errorf("BmResourceType QueryInstancesById QueryBmInstance meet error=%v", err)
 		return result, err
 	}
 
@@ -46,25 +46,25 @@ func (resourceType *BmResourceType) QueryInstancesById(providerParams string, in
 			Name:                    *deviceInfo.Alias,
 			WanIp:                   *deviceInfo.WanIp,
 			LanIp:                   *deviceInfo.LanIp,
-			Region:                  paramsMap["Region"],
+			Region:                  params.Region,
 			SupportSecurityGroupApi: false,
 		}
 
 		result[*deviceInfo.InstanceId] = instance
 	}
 
-	logrus.Infof("BmResourceType QueryInstancesById: result=%++v", result)
+	plugins.Logger(ctx).Infof("BmResourceType QueryInstancesById: result=%++v", result)
 	return result, nil
 }
 
-func (resourceType *BmResourceType) QueryInstancesByIp(providerParams string, ips []string) (map[string]ResourceInstance, error) {
-	logrus.Infof("BmResourceType QueryInstancesByIp: request ips=%++v", ips)
+func (resourceType *BmResourceType) QueryInstancesByIp(ctx context.Context, providerParams string, ips []string) (map[string]ResourceInstance, error) {
+	plugins.Logger(ctx).Infof("BmResourceType QueryInstancesByIp: request ips=%++v", ips)
 
 	result := make(map[string]ResourceInstance)
 	if len(ips) == 0 {
 		err := fmt.Errorf("ips is empty")
 
-		logrus.Errorf("BmResourceType QueryInstancesByIp meet error=%v", err)
+		plugins.Logger(ctx).Errorf("BmResourceType QueryInstancesByIp meet error=%v", err)
 		return result, err
 	}
 
@@ -72,10 +72,14 @@ func (resourceType *BmResourceType) QueryInstancesByIp(providerParams string, ip
 		Name:   "lanIp",
 		Values: ips,
 	}
-	paramsMap, _ := plugins.GetMapFromProviderParams(providerParams)
-	deviceInfoSet, err := QueryBmInstance(providerParams, filter)
+	params, err := plugins.ParseProviderParams(providerParams)
 	if err != nil {
-		logrus.Errorf("BmResourceType QueryInstancesByIp meet error=%v", err)
+		plugins.Logger(ctx).Errorf("BmResourceType QueryInstancesByIp ParseProviderParams meet error=%v", err)
+		return result, err
+	}
+	deviceInfoSet, err := QueryBmInstance(ctx, providerParams, filter)
+	if err != nil {
+		plugins.Logger(ctx).Errorf("BmResourceType QueryInstancesByIp meet error=%v", err)
 		return result, err
 	}
 
@@ -85,14 +89,14 @@ func (resourceType *BmResourceType) QueryInstancesByIp(providerParams string, ip
 			Name:                    *deviceInfo.Alias,
 			WanIp:                   *deviceInfo.WanIp,
 			LanIp:                   *deviceInfo.LanIp,
-			Region:                  paramsMap["Region"],
+			Region:                  params.Region,
 			SupportSecurityGroupApi: false,
 		}
 
 		result[*deviceInfo.LanIp] = instance
 	}
 
-	logrus.Infof("BmResourceType QueryInstancesByIp: result=%++v", result)
+	plugins.Logger(ctx).Infof("BmResourceType QueryInstancesByIp: result=%++v", result)
 	return result, nil
 }
 
@@ -127,21 +131,21 @@ func (instance BmInstance) GetName() string {
 	return instance.Name
 }
 
-func (instance BmInstance) QuerySecurityGroups(providerParams string) ([]string, error) {
+func (instance BmInstance) QuerySecurityGroups(ctx context.Context, providerParams string) ([]string, error) {
 	securityGroups, err := QueryBmInstanceSecurityGroups(providerParams, instance.Id)
 	if err != nil {
-		logrus.Errorf("BmInstance QuerySecurityGroups meet error=%v", err)
+		plugins.Logger(ctx).Errorf("BmInstance QuerySecurityGroups meet error=%v", err)
 		return []string{}, err
 	}
 
-	logrus.Infof("BmInstance QuerySecurityGroups: return=[%++v]", securityGroups)
+	plugins.Logger(ctx).Infof("BmInstance QuerySecurityGroups: return=[%++v]", securityGroups)
 	return securityGroups, nil
 }
 
-func (instance BmInstance) AssociateSecurityGroups(providerParams string, securityGroups []string) error {
+func (instance BmInstance) AssociateSecurityGroups(ctx context.Context, providerParams string, securityGroups []string) error {
 	err := BindBmInstanceSecurityGroups(providerParams, instance.Id, securityGroups)
 	if err != nil {
-		logrus.Errorf("BmInstance AssociateSecurityGroups meet error=%v", err)
+		plugins.Logger(ctx).Errorf("BmInstance AssociateSecurityGroups meet error=%v", err)
 	}
 
 	return err
@@ -162,11 +166,11 @@ func (instance BmInstance) IsSupportSecurityGroupApi() bool {
 	return instance.SupportSecurityGroupApi
 }
 
-func (instance BmInstance) GetBackendTargets(providerParams string, proto string, port string) ([]ResourceInstance, []string, error) {
+func (instance BmInstance) GetBackendTargets(ctx context.Context, providerParams string, proto string, port string) ([]ResourceInstance, []string, error) {
 	instances := []ResourceInstance{}
 	err := fmt.Errorf("bm do not support GetBackendTargets function")
 
-	logrus.Errorf("BmInstance GetBackendTargets meet error=%v", err)
+	plugins.Logger(ctx).Errorf("BmInstance GetBackendTargets meet error=%v", err)
 	return instances, []string{}, err
 }
 
@@ -175,38 +179,34 @@ func (instance BmInstance) GetIp() string {
 	return instance.LanIp
 }
 
-func createBmClient(region, secretId, secretKey string) (client *bm.Client, err error) {
-	credential := common.NewCredential(secretId, secretKey)
-	clientProfile := profile.NewClientProfile()
-	clientProfile.HttpProfile.Endpoint = QCLOUD_ENDPOINT_BM
-
-	client, err = bm.NewClient(credential, region, clientProfile)
+func createBmClient(ctx context.Context, params *plugins.ProviderParams) (client *bm.Client, err error) {
+	client, err = plugins.GetClientFactory().WithContext(ctx).WithProviderParams(params).NewBmClient(params.Region, params.SecretID, params.SecretKey)
 	if err != nil {
-		logrus.Errorf("createBmClient: failed to create Qcloud bm client, err=%v", err)
+		plugins.Logger(ctx).Errorf("createBmClient: failed to create Qcloud bm client, err=%v", err)
 	}
 
 	return client, err
 }
 
-func QueryBmInstance(providerParams string, filter plugins.Filter) ([]*bm.DeviceInfo, error) {
-	logrus.Infof("QueryBmInstance: request filter=%++v", filter)
+func QueryBmInstance(ctx context.Context, providerParams string, filter plugins.Filter) ([]*bm.DeviceInfo, error) {
+	plugins.Logger(ctx).Infof("QueryBmInstance: request filter=%++v", filter)
 
 	validFilterNames := []string{"instanceId", "lanIp"}
 	filterValues := common.StringPtrs(filter.Values)
 
-	paramsMap, err := plugins.GetMapFromProviderParams(providerParams)
+	params, err := plugins.ParseProviderParams(providerParams)
 	if err != nil {
-		logrus.Errorf("QueryBmInstance GetMapFromProviderParams meet error=%v", err)
+		plugins.Logger(ctx).Errorf("QueryBmInstance ParseProviderParams meet error=%v", err)
 		return nil, err
 	}
-	client, err := createBmClient(paramsMap["Region"], paramsMap["SecretID"], paramsMap["SecretKey"])
+	client, err := createBmClient(ctx, params)
 	if err != nil {
-		logrus.Errorf("QueryBmInstance createBmClient meet error=%v", err)
+		plugins.Logger(ctx).Errorf("QueryBmInstance createBmClient meet error=%v", err)
 		return nil, err
 	}
 
 	if err := plugins.IsValidValue(filter.Name, validFilterNames); err != nil {
-		logrus.Errorf("QueryBmInstance IsValidValue meet error=%v", err)
+		plugins.Logger(ctx).Errorf("QueryBmInstance IsValidValue meet error=%v", err)
 		return nil, err
 	}
 
@@ -224,11 +224,11 @@ func QueryBmInstance(providerParams string, filter plugins.Filter) ([]*bm.Device
 
 	response, err := client.DescribeDevices(request)
 	if err != nil {
-		logrus.Errorf("QueryBmInstance DescribeDevices meet error=%v", err)
+		plugins.Logger(ctx).Errorf("QueryBmInstance DescribeDevices meet error=%v", err)
 		return nil, err
 	}
 
-	logrus.Infof("QueryBmInstance: return=%++v", response.Response.DeviceInfoSet)
+	plugins.Logger(ctx).Infof("QueryBmInstance: return=%++v", response.Response.DeviceInfoSet)
 	return response.Response.DeviceInfoSet, nil
 }
 
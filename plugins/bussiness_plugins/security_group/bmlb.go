@@ -1,6 +1,7 @@
 package securitygroup
 
 import (
+	"context"
 	"fmt"
 	"strconv"
 
@@ -8,25 +9,20 @@ import (
 	"github.com/sirupsen/logrus"
 	bmlb "github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/bmlb/v20180625"
 	"github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/common"
-	"github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/common/profile"
-)
-
-const (
-	QCLOUD_ENDPOINT_BMLB = "bmlb.tencentcloudapi.com"
 )
 
 //resource type
 type BmlbResourceType struct {
 }
 
-func (resourceType *BmlbResourceType) QueryInstancesById(providerParams string, instanceIds []string) (map[string]ResourceInstance, error) {
-	logrus.Infof("BmlbResourceType QueryInstancesById: request instanceIds=%++v", instanceIds)
+func (resourceType *BmlbResourceType) QueryInstancesById(ctx context.Context, providerParams string, instanceIds []string) (map[string]ResourceInstance, error) {
+	plugins.Logger(ctx).Infof("BmlbResourceType QueryInstancesById: request instanceIds=%++v", instanceIds)
 
 	result := make(map[string]ResourceInstance)
 	if len(instanceIds) == 0 {
 		err := fmt.Errorf("instanceIds is empty")
 
-		logrus.Errorf("BmlbResourceType QueryInstancesById meet error=%v", err)
+		plugins.Logger(ctx).Errorf("BmlbResourceType QueryInstancesById meet error=%v", err)
 		return result, err
 	}
 
@@ -34,10 +30,14 @@ func (resourceType *BmlbResourceType) QueryInstancesById(providerParams string,
 		Name:   "instanceId",
 		Values: instanceIds,
 	}
-	paramsMap, _ := plugins.GetMapFromProviderParams(providerParams)
-	loadBalancerSet, err := QueryBmlbInstance(providerParams, filter)
+	params, err := plugins.ParseProviderParams(providerParams)
+	if err != nil {
+		plugins.Logger(ctx).Errorf("BmlbResourceType QueryInstancesById ParseProviderParams meet error=%v", err)
+		return result, err
+	}
+	loadBalancerSet, err := QueryBmlbInstance(ctx, providerParams, filter)
 	if err != nil {
-		logrus.Errorf("BmlbResourceType QueryInstancesById meet error=%v", err)
+		plugins.Logger(ctx).Errorf("BmlbResourceType QueryInstancesById meet error=%v", err)
 		return result, err
 	}
 
@@ -47,7 +47,7 @@ func (resourceType *BmlbResourceType) QueryInstancesById(providerParams string,
 			Name:                    *loadBalancer.LoadBalancerName,
 			Vip:                     "",
 			VpcId:                   *loadBalancer.VpcId,
-			Region:                  paramsMap["Region"],
+			Region:                  params.Region,
 			SupportSecurityGroupApi: false,
 		}
 		if len(common.StringValues(loadBalancer.LoadBalancerVips)) > 0 {
@@ -56,18 +56,18 @@ func (resourceType *BmlbResourceType) QueryInstancesById(providerParams string,
 		result[*loadBalancer.LoadBalancerId] = instance
 	}
 
-	logrus.Infof("BmlbResourceType QueryInstancesById: result=%++v", result)
+	plugins.Logger(ctx).Infof("BmlbResourceType QueryInstancesById: result=%++v", result)
 	return result, nil
 }
 
-func (resourceType *BmlbResourceType) QueryInstancesByIp(providerParams string, ips []string) (map[string]ResourceInstance, error) {
-	logrus.Infof("BmlbResourceType QueryInstancesByIp: request ips=%++v", ips)
+func (resourceType *BmlbResourceType) QueryInstancesByIp(ctx context.Context, providerParams string, ips []string) (map[string]ResourceInstance, error) {
+	plugins.Logger(ctx).Infof("BmlbResourceType QueryInstancesByIp: request ips=%++v", ips)
 
 	result := make(map[string]ResourceInstance)
 	if len(ips) == 0 {
 		err := fmt.Errorf("ips is empty")
 
-		logrus.Errorf("BmlbResourceType QueryInstancesByIp meet error=%v", err)
+		plugins.Logger(ctx).Errorf("BmlbResourceType QueryInstancesByIp meet error=%v", err)
 		return result, err
 	}
 
@@ -75,10 +75,14 @@ func (resourceType *BmlbResourceType) QueryInstancesByIp(providerParams string,
 		Name:   "vip",
 		Values: ips,
 	}
-	paramsMap, _ := plugins.GetMapFromProviderParams(providerParams)
-	loadBalancerSet, err := QueryBmlbInstance(providerParams, filter)
+	params, err := plugins.ParseProviderParams(providerParams)
 	if err != nil {
-		logrus.Errorf("BmlbResourceType QueryInstancesByIp meet error=%v", err)
+		plugins.Logger(ctx).Errorf("BmlbResourceType QueryInstancesByIp ParseProviderParams meet error=%v", err)
+		return result, err
+	}
+	loadBalancerSet, err := QueryBmlbInstance(ctx, providerParams, filter)
+	if err != nil {
+		plugins.Logger(ctx).Errorf("BmlbResourceType QueryInstancesByIp meet error=%v", err)
 		return result, err
 	}
 
@@ -88,7 +92,7 @@ func (resourceType *BmlbResourceType) QueryInstancesByIp(providerParams string,
 			Name:                    *loadBalancer.LoadBalancerName,
 			Vip:                     "",
 			VpcId:                   *loadBalancer.VpcId,
-			Region:                  paramsMap["Region"],
+			Region:                  params.Region,
 			SupportSecurityGroupApi: false,
 		}
 		if len(common.StringValues(loadBalancer.LoadBalancerVips)) > 0 {
@@ -97,13 +101,13 @@ func (resourceType *BmlbResourceType) QueryInstancesByIp(providerParams string,
 		} else {
 			err := fmt.Errorf("loadBalancer[%v].LoadBalancerVips is nil", *loadBalancer.LoadBalancerId)
 
-			logrus.Errorf("BmlbResourceType QueryInstancesByIp meet error=%v", err)
+			plugins.Logger(ctx).Errorf("BmlbResourceType QueryInstancesByIp meet error=%v", err)
 			return result, err
 		}
 
 	}
 
-	logrus.Infof("BmlbResourceType QueryInstancesByIp: result=%++v", result)
+	plugins.Logger(ctx).Infof("BmlbResourceType QueryInstancesByIp: result=%++v", result)
 	return result, nil
 }
 
@@ -151,17 +155,17 @@ func (instance BmlbInstance) GetRegion() string {
 	return instance.Region
 }
 
-func (instance BmlbInstance) QuerySecurityGroups(providerParams string) ([]string, error) {
+func (instance BmlbInstance) QuerySecurityGroups(ctx context.Context, providerParams string) ([]string, error) {
 	err := fmt.Errorf("bmlb do not support security group")
 
-	logrus.Errorf("BmlbInstance QuerySecurityGroups meet error=%v", err)
+	plugins.Logger(ctx).Errorf("BmlbInstance QuerySecurityGroups meet error=%v", err)
 	return []string{}, err
 }
 
-func (instance BmlbInstance) AssociateSecurityGroups(providerParams string, securityGroups []string) error {
+func (instance BmlbInstance) AssociateSecurityGroups(ctx context.Context, providerParams string, securityGroups []string) error {
 	err := fmt.Errorf("bmlb do not associate security groups function")
 
-	logrus.Errorf("BmlbInstance AssociateSecurityGroups meet error=%v", err)
+	plugins.Logger(ctx).Errorf("BmlbInstance AssociateSecurityGroups meet error=%v", err)
 	return err
 }
 
@@ -170,19 +174,19 @@ func (instance BmlbInstance) IsSupportSecurityGroupApi() bool {
 	return instance.SupportSecurityGroupApi
 }
 
-func (instance BmlbInstance) GetBackendTargets(providerParams string, protocol string, port string) ([]ResourceInstance, []string, error) {
-	logrus.Infof("BmlbInstance GetBackendTargets: reuqest protocol=%v, port=%v", protocol, port)
+func (instance BmlbInstance) GetBackendTargets(ctx context.Context, providerParams string, protocol string, port string) ([]ResourceInstance, []string, error) {
+	plugins.Logger(ctx).Infof("BmlbInstance GetBackendTargets: reuqest protocol=%v, port=%v", protocol, port)
 
 	results := []ResourceInstance{}
 	ports := []string{}
-	paramsMap, err := plugins.GetMapFromProviderParams(providerParams)
+	params, err := plugins.ParseProviderParams(providerParams)
 	if err != nil {
-		logrus.Errorf("BmlbInstance GetBackendTargets GetMapFromProviderParams meet error=%v", err)
+		plugins.Logger(ctx).Errorf("BmlbInstance GetBackendTargets ParseProviderParams meet error=%v", err)
 		return results, ports, err
 	}
-	client, err := createBmlbClient(paramsMap["Region"], paramsMap["SecretID"], paramsMap["SecretKey"])
+	client, err := createBmlbClient(ctx, params)
 	if err != nil {
-		logrus.Errorf("BmlbInstance GetBackendTargets createBmlbClient meet error=%v", err)
+		plugins.Logger(ctx).Errorf("BmlbInstance GetBackendTargets createBmlbClient meet error=%v", err)
 		return results, ports, err
 	}
 
@@ -192,7 +196,7 @@ func (instance BmlbInstance) GetBackendTargets(providerParams string, protocol s
 
 	response, err := client.DescribeDevicesBindInfo(request)
 	if err != nil {
-		logrus.Errorf("BmlbInstance GetBackendTargets DescribeDevicesBindInfo meet error=%v", err)
+		plugins.Logger(ctx).Errorf("BmlbInstance GetBackendTargets DescribeDevicesBindInfo meet error=%v", err)
 		return results, ports, err
 	}
 
@@ -208,38 +212,33 @@ func (instance BmlbInstance) GetBackendTargets(providerParams string, protocol s
 		}
 	}
 
-	logrus.Infof("BmlbInstance GetBackendTargets: return results=%++v, ports=%++v", results, ports)
+	plugins.Logger(ctx).Infof("BmlbInstance GetBackendTargets: return results=%++v, ports=%++v", results, ports)
 	return results, ports, err
 }
 
-func createBmlbClient(region, secretId, secretKey string) (client *bmlb.Client, err error) {
-	credential := common.NewCredential(secretId, secretKey)
-
-	clientProfile := profile.NewClientProfile()
-	clientProfile.HttpProfile.Endpoint = QCLOUD_ENDPOINT_BMLB
-
-	client, err = bmlb.NewClient(credential, region, clientProfile)
+func createBmlbClient(ctx context.Context, params *plugins.ProviderParams) (client *bmlb.Client, err error) {
+	client, err = plugins.GetClientFactory().WithContext(ctx).WithProviderParams(params).NewBmlbClient(params.Region, params.SecretID, params.SecretKey)
 	if err != nil {
-		logrus.Errorf("createBmlbClient: failed to create Qcloud bm client, err=%v", err)
+		plugins.Logger(ctx).Errorf("createBmlbClient: failed to create Qcloud bm client, err=%v", err)
 	}
 
 	return client, err
 }
 
-func QueryBmlbInstance(providerParams string, filter plugins.Filter) ([]*bmlb.LoadBalancer, error) {
-	logrus.Infof("QueryBmlbInstance: request filter=%++v", filter)
+func QueryBmlbInstance(ctx context.Context, providerParams string, filter plugins.Filter) ([]*bmlb.LoadBalancer, error) {
+	plugins.Logger(ctx).Infof("QueryBmlbInstance: request filter=%++v", filter)
 
 	validFilterNames := []string{"instanceId", "vip"}
 	filterValues := common.StringPtrs(filter.Values)
 
-	paramsMap, err := plugins.GetMapFromProviderParams(providerParams)
+	params, err := plugins.ParseProviderParams(providerParams)
 	if err != nil {
-		logrus.Errorf("QueryBmlbInstance GetMapFromProviderParams meet error=%v", err)
+		plugins.Logger(ctx).Errorf("QueryBmlbInstance ParseProviderParams meet error=%v", err)
 		return nil, err
 	}
-	client, err := createBmlbClient(paramsMap["Region"], paramsMap["SecretID"], paramsMap["SecretKey"])
+	client, err := createBmlbClient(ctx, params)
 	if err != nil {
-		logrus.Errorf("QueryBmlbInstance createBmlbClient meet error=%v", err)
+		plugins.Logger(ctx).Errorf("QueryBmlbInstance createBmlbClient meet error=%v", err)
 		return nil, err
 	}
 
@@ -259,10 +258,10 @@ func QueryBmlbInstance(providerParams string, filter plugins.Filter) ([]*bmlb.Lo
 
 	response, err := client.DescribeLoadBalancers(request)
 	if err != nil {
-		logrus.Errorf("QueryBmlbInstance DescribeLoadBalancers meet error=%v", err)
+		plugins.Logger(ctx).Errorf("QueryBmlbInstance DescribeLoadBalancers meet error=%v", err)
 		return nil, err
 	}
 
-	logrus.Infof("QueryBmlbInstance: return=%++v", response.Response.LoadBalancerSet)
+	plugins.Logger(ctx).Infof("QueryBmlbInstance: return=%++v", response.Response.LoadBalancerSet)
 	return response.Response.LoadBalancerSet, nil
 }
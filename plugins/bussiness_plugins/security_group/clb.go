@@ -1,6 +1,7 @@
 package securitygroup
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"strconv"
@@ -10,7 +11,6 @@ import (
 	"github.com/sirupsen/logrus"
 	clb "github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/clb/v20180317"
 	"github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/common"
-	"github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/common/profile"
 )
 
 type ClbResourceType struct {
@@ -24,18 +24,14 @@ type ClbInstance struct {
 	Vip     string
 }
 
-func createClbClient(providerParams string) (client *clb.Client, err error) {
-	paramsMap, err := plugins.GetMapFromProviderParams(providerParams)
+func createClbClient(ctx context.Context, providerParams string) (client *clb.Client, err error) {
+	params, err := plugins.ParseProviderParams(providerParams)
 	if err != nil {
-		logrus.Errorf("createClbClient GetMapFromProviderParams meet error=%v", err)
+		plugins.Logger(ctx).Errorf("createClbClient ParseProviderParams meet error=%v", err)
 		return nil, err
 	}
 
-	credential := common.NewCredential(paramsMap["SecretID"], paramsMap["SecretKey"])
-	clientProfile := profile.NewClientProfile()
-	clientProfile.HttpProfile.Endpoint = "clb.tencentcloudapi.com"
-
-	return clb.NewClient(credential, paramsMap["Region"], clientProfile)
+	return plugins.GetClientFactory().WithContext(ctx).NewClbClient(params.Region, params.SecretID, params.SecretKey)
 }
 
 func (resourceType *ClbResourceType) IsSupportEgressPolicy() bool {
@@ -43,18 +39,18 @@ func (resourceType *ClbResourceType) IsSupportEgressPolicy() bool {
 	return false
 }
 
-func (resourceType *ClbResourceType) QueryInstancesById(providerParams string, instanceIds []string) (map[string]ResourceInstance, error) {
-	logrus.Infof("ClbResourceType QueryInstancesById: request instanceIds=%++v", instanceIds)
+func (resourceType *ClbResourceType) QueryInstancesById(ctx context.Context, providerParams string, instanceIds []string) (map[string]ResourceInstance, error) {
+	plugins.Logger(ctx).Infof("ClbResourceType QueryInstancesById: request instanceIds=%++v", instanceIds)
 
 	result := make(map[string]ResourceInstance)
 	if len(instanceIds) == 0 {
 		err := fmt.Errorf("instanceIds is empty")
 
-		logrus.Errorf("ClbResourceType QueryInstancesById meet error=%v", err)
+		plugins.Logger(ctx).Errorf("ClbResourceType QueryInstancesById meet error=%v", err)
 		return result, err
 	}
 
-	client, _ := createClbClient(providerParams)
+	client, _ := createClbClient(ctx, providerParams)
 	var offset, limit int64 = 0, int64(len(instanceIds))
 	region, _ := plugins.GetRegionFromProviderParams(providerParams)
 
@@ -65,7 +61,7 @@ func (resourceType *ClbResourceType) QueryInstancesById(providerParams string, i
 
 	resp, err := client.DescribeLoadBalancers(request)
 	if err != nil {
-		logrus.Errorf("ClbResourceType QueryInstancesById DescribeLoadBalancers meet err0r=%v", err)
+		plugins.Logger(ctx).Errorf("ClbResourceType QueryInstancesById DescribeLoadBalancers meet err0r=%v", err)
 		return result, err
 	}
 
@@ -82,22 +78,22 @@ func (resourceType *ClbResourceType) QueryInstancesById(providerParams string, i
 		result[*lb.LoadBalancerId] = instance
 	}
 
-	logrus.Infof("ClbResourceType QueryInstancesById: result=%++v", result)
+	plugins.Logger(ctx).Infof("ClbResourceType QueryInstancesById: result=%++v", result)
 	return result, nil
 }
 
-func (resourceType *ClbResourceType) QueryInstancesByIp(providerParams string, ips []string) (map[string]ResourceInstance, error) {
-	logrus.Infof("ClbResourceType QueryInstancesByIp: request ips=%++v", ips)
+func (resourceType *ClbResourceType) QueryInstancesByIp(ctx context.Context, providerParams string, ips []string) (map[string]ResourceInstance, error) {
+	plugins.Logger(ctx).Infof("ClbResourceType QueryInstancesByIp: request ips=%++v", ips)
 
 	result := make(map[string]ResourceInstance)
 	if len(ips) == 0 {
 		err := fmt.Errorf("ips is empty")
 
-		logrus.Errorf("ClbResourceType QueryInstancesByIp meet error=%v", err)
+		plugins.Logger(ctx).Errorf("ClbResourceType QueryInstancesByIp meet error=%v", err)
 		return result, err
 	}
 
-	client, _ := createClbClient(providerParams)
+	client, _ := createClbClient(ctx, providerParams)
 	var offset, limit int64 = 0, int64(len(ips))
 	region, _ := plugins.GetRegionFromProviderParams(providerParams)
 
@@ -108,7 +104,7 @@ func (resourceType *ClbResourceType) QueryInstancesByIp(providerParams string, i
 
 	resp, err := client.DescribeLoadBalancers(request)
 	if err != nil {
-		logrus.Errorf("ClbResourceType QueryInstancesByIp DescribeLoadBalancers meet error=%v", err)
+		plugins.Logger(ctx).Errorf("ClbResourceType QueryInstancesByIp DescribeLoadBalancers meet error=%v", err)
 		return result, err
 	}
 
@@ -125,7 +121,7 @@ func (resourceType *ClbResourceType) QueryInstancesByIp(providerParams string, i
 		}
 	}
 
-	logrus.Infof("ClbResourceType QueryInstancesById: result=%++v", result)
+	plugins.Logger(ctx).Infof("ClbResourceType QueryInstancesById: result=%++v", result)
 	return result, nil
 }
 
@@ -158,17 +154,17 @@ func (instance ClbInstance) GetRegion() string {
 	return instance.Region
 }
 
-func (instance ClbInstance) QuerySecurityGroups(providerParams string) ([]string, error) {
+func (instance ClbInstance) QuerySecurityGroups(ctx context.Context, providerParams string) ([]string, error) {
 	err := errors.New("clb do not support query security groups function")
 
-	logrus.Errorf("ClbInstance QuerySecurityGroups meet error=%v", err)
+	plugins.Logger(ctx).Errorf("ClbInstance QuerySecurityGroups meet error=%v", err)
 	return []string{}, err
 }
 
-func (instance ClbInstance) AssociateSecurityGroups(providerParams string, securityGroups []string) error {
+func (instance ClbInstance) AssociateSecurityGroups(ctx context.Context, providerParams string, securityGroups []string) error {
 	err := errors.New("clb do not support query security groups function")
 
-	logrus.Errorf("ClbInstance AssociateSecurityGroups meet error=%v", err)
+	plugins.Logger(ctx).Errorf("ClbInstance AssociateSecurityGroups meet error=%v", err)
 	return err
 }
 
@@ -177,17 +173,17 @@ func (instance ClbInstance) IsSupportSecurityGroupApi() bool {
 	return false
 }
 
-func (instance ClbInstance) GetBackendTargets(providerParams string, protocol string, port string) ([]ResourceInstance, []string, error) {
-	logrus.Infof("ClbInstance GetBackendTargets: reuqest protocol=%v, port=%v", protocol, port)
+func (instance ClbInstance) GetBackendTargets(ctx context.Context, providerParams string, protocol string, port string) ([]ResourceInstance, []string, error) {
+	plugins.Logger(ctx).Infof("ClbInstance GetBackendTargets: reuqest protocol=%v, port=%v", protocol, port)
 
 	instances := []ResourceInstance{}
-	client, _ := createClbClient(providerParams)
+	client, _ := createClbClient(ctx, providerParams)
 	proto := strings.ToUpper(protocol)
 	portInt64, err := strconv.ParseInt(port, 10, 64)
 	if err != nil {
 		err := fmt.Errorf("%s is invalid port", port)
 
-		logrus.Errorf("ClbInstance GetBackendTargets ParseInt meet error=%v", err)
+		plugins.Logger(ctx).Errorf("ClbInstance GetBackendTargets ParseInt meet error=%v", err)
 		return instances, []string{}, err
 	}
 
@@ -196,7 +192,7 @@ func (instance ClbInstance) GetBackendTargets(providerParams string, protocol st
 	if instance.Forward == 1 {
 		instanceIds, ports, err = getAppLbBackends(client, instance.Id, proto, portInt64)
 		if err != nil {
-			logrus.Errorf("ClbInstance GetBackendTargets getAppLbBackends meet error=%v", err)
+			plugins.Logger(ctx).Errorf("ClbInstance GetBackendTargets getAppLbBackends meet error=%v", err)
 			return instances, []string{}, err
 		}
 	}
@@ -204,7 +200,7 @@ func (instance ClbInstance) GetBackendTargets(providerParams string, protocol st
 	if instance.Forward == 0 {
 		instanceIds, ports, err = getClassicLbBackends(client, instance.Id, proto, portInt64)
 		if err != nil {
-			logrus.Errorf("ClbInstance GetBackendTargets getClassicLbBackends meet error=%v", err)
+			plugins.Logger(ctx).Errorf("ClbInstance GetBackendTargets getClassicLbBackends meet error=%v", err)
 			return instances, []string{}, err
 		}
 	}
@@ -212,9 +208,9 @@ func (instance ClbInstance) GetBackendTargets(providerParams string, protocol st
 	portsStr := []string{}
 	cvmType := CvmResourceType{}
 
-	instanceMap, err := cvmType.QueryInstancesById(providerParams, instanceIds)
+	instanceMap, err := cvmType.QueryInstancesById(ctx, providerParams, instanceIds)
 	if err != nil {
-		logrus.Errorf("ClbInstance GetBackendTargets QueryInstancesById meet error=%v", err)
+		plugins.Logger(ctx).Errorf("ClbInstance GetBackendTargets QueryInstancesById meet error=%v", err)
 		return instances, []string{}, err
 	}
 
@@ -230,7 +226,7 @@ func (instance ClbInstance) GetBackendTargets(providerParams string, protocol st
 		portsStr = append(portsStr, fmt.Sprintf("%v", ports[i]))
 	}
 
-	logrus.Infof("ClbInstance GetBackendTargets: return results=%++v, ports=%++v", instances, portsStr)
+	plugins.Logger(ctx).Infof("ClbInstance GetBackendTargets: return results=%++v, ports=%++v", instances, portsStr)
 	return instances, portsStr, err
 }
 
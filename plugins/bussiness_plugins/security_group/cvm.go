@@ -1,6 +1,7 @@
 package securitygroup
 
 import (
+	"context"
 	"fmt"
 
 	"github.com/WeBankPartners/wecube-plugins-qcloud/plugins"
@@ -13,14 +14,14 @@ import (
 type CvmResourceType struct {
 }
 
-func (resourceType *CvmResourceType) QueryInstancesById(providerParams string, instanceIds []string) (map[string]ResourceInstance, error) {
-	logrus.Infof("CvmResourceType QueryInstancesById: request instanceIds=%++v", instanceIds)
+func (resourceType *CvmResourceType) QueryInstancesById(ctx context.Context, providerParams string, instanceIds []string) (map[string]ResourceInstance, error) {
+	plugins.Logger(ctx).Infof("CvmResourceType QueryInstancesById: request instanceIds=%++v", instanceIds)
 
 	result := make(map[string]ResourceInstance)
 	if len(instanceIds) == 0 {
 		err := fmt.Errorf("instanceIds is empty")
 
-		logrus.Errorf("CvmResourceType QueryInstancesById meet error=%v", err)
+		plugins.Logger(ctx).Errorf("CvmResourceType QueryInstancesById meet error=%v", err)
 		return result, err
 	}
 
@@ -28,10 +29,14 @@ func (resourceType *CvmResourceType) QueryInstancesById(providerParams string, i
 		Name:   "instanceId",
 		Values: instanceIds,
 	}
-	paramsMap, _ := plugins.GetMapFromProviderParams(providerParams)
-	items, err := plugins.QueryCvmInstance(providerParams, filter)
+	params, err := plugins.ParseProviderParams(providerParams)
 	if err != nil {
-		logrus.Errorf("CvmResourceType QueryInstancesById QueryCvmInstance meet error=%v", err)
+		plugins.Logger(ctx).Errorf("CvmResourceType QueryInstancesById ParseProviderParams meet error=%v", err)
+		return result, err
+	}
+	items, err := plugins.QueryCvmInstance(ctx, providerParams, filter)
+	if err != nil {
+		plugins.Logger(ctx).Errorf("CvmResourceType QueryInstancesById QueryCvmInstance meet error=%v", err)
 		return result, err
 	}
 
@@ -42,24 +47,24 @@ func (resourceType *CvmResourceType) QueryInstancesById(providerParams string, i
 			PrivateIps:              common.StringValues(item.PrivateIpAddresses),
 			PublicIps:               common.StringValues(item.PublicIpAddresses),
 			SecurityGroups:          common.StringValues(item.SecurityGroupIds),
-			Region:                  paramsMap["Region"],
+			Region:                  params.Region,
 			SupportSecurityGroupApi: true,
 		}
 		result[*item.InstanceId] = instance
 	}
 
-	logrus.Infof("CvmResourceType QueryInstancesById: result=%++v", result)
+	plugins.Logger(ctx).Infof("CvmResourceType QueryInstancesById: result=%++v", result)
 	return result, nil
 }
 
-func (resourceType *CvmResourceType) QueryInstancesByIp(providerParams string, ips []string) (map[string]ResourceInstance, error) {
-	logrus.Infof("CvmResourceType QueryInstancesByIp: request ips=%++v", ips)
+func (resourceType *CvmResourceType) QueryInstancesByIp(ctx context.Context, providerParams string, ips []string) (map[string]ResourceInstance, error) {
+	plugins.Logger(ctx).Infof("CvmResourceType QueryInstancesByIp: request ips=%++v", ips)
 
 	result := make(map[string]ResourceInstance)
 	if len(ips) == 0 {
 		err := fmt.Errorf("ips is empty")
 
-		logrus.Errorf("CvmResourceType QueryInstancesByIp meet error=%v", err)
+		plugins.Logger(ctx).Errorf("CvmResourceType QueryInstancesByIp meet error=%v", err)
 		return result, err
 	}
 	total := []*cvm.Instance{}
@@ -75,15 +80,19 @@ func (resourceType *CvmResourceType) QueryInstancesByIp(providerParams string, i
 			Name:   "privateIpAddress",
 			Values: ips[i*5 : last],
 		}
-		items, err := plugins.QueryCvmInstance(providerParams, filter)
+		items, err := plugins.QueryCvmInstance(ctx, providerParams, filter)
 		if err != nil {
-			logrus.Errorf("CvmResourceType QueryInstancesByIp QueryCvmInstance meet error=%v", err)
+			plugins.Logger(ctx).Errorf("CvmResourceType QueryInstancesByIp QueryCvmInstance meet error=%v", err)
 			return result, err
 		}
 		total = append(total, items...)
 	}
 
-	paramsMap, _ := plugins.GetMapFromProviderParams(providerParams)
+	params, err := plugins.ParseProviderParams(providerParams)
+	if err != nil {
+		plugins.Logger(ctx).Errorf("CvmResourceType QueryInstancesByIp ParseProviderParams meet error=%v", err)
+		return result, err
+	}
 	for _, item := range total {
 		instance := CvmInstance{
 			Id:                      *item.InstanceId,
@@ -91,13 +100,13 @@ func (resourceType *CvmResourceType) QueryInstancesByIp(providerParams string, i
 			PrivateIps:              common.StringValues(item.PrivateIpAddresses),
 			PublicIps:               common.StringValues(item.PublicIpAddresses),
 			SecurityGroups:          common.StringValues(item.SecurityGroupIds),
-			Region:                  paramsMap["Region"],
+			Region:                  params.Region,
 			SupportSecurityGroupApi: true,
 		}
 		result[common.StringValues(item.PrivateIpAddresses)[0]] = instance
 	}
 
-	logrus.Infof("CvmResourceType QueryInstancesByIp: result=%++v", result)
+	plugins.Logger(ctx).Infof("CvmResourceType QueryInstancesByIp: result=%++v", result)
 	return result, nil
 }
 
@@ -135,15 +144,15 @@ func (instance CvmInstance) GetName() string {
 	return instance.Name
 }
 
-func (instance CvmInstance) QuerySecurityGroups(providerParams string) ([]string, error) {
-	logrus.Infof("CvmInstance QuerySecurityGroups: return=[%++v]", instance.SecurityGroups)
+func (instance CvmInstance) QuerySecurityGroups(ctx context.Context, providerParams string) ([]string, error) {
+	plugins.Logger(ctx).Infof("CvmInstance QuerySecurityGroups: return=[%++v]", instance.SecurityGroups)
 	return instance.SecurityGroups, nil
 }
 
-func (instance CvmInstance) AssociateSecurityGroups(providerParams string, securityGroups []string) error {
-	err := plugins.BindCvmInstanceSecurityGroups(providerParams, instance.Id, securityGroups)
+func (instance CvmInstance) AssociateSecurityGroups(ctx context.Context, providerParams string, securityGroups []string) error {
+	err := plugins.BindCvmInstanceSecurityGroups(ctx, providerParams, instance.Id, securityGroups)
 	if err != nil {
-		logrus.Errorf("CvmInstance AssociateSecurityGroups meet error=%v", err)
+		plugins.Logger(ctx).Errorf("CvmInstance AssociateSecurityGroups meet error=%v", err)
 	}
 	return err
 }
@@ -168,11 +177,11 @@ func (instance CvmInstance) IsSupportSecurityGroupApi() bool {
 	return instance.SupportSecurityGroupApi
 }
 
-func (instance CvmInstance) GetBackendTargets(providerParams string, proto string, port string) ([]ResourceInstance, []string, error) {
+func (instance CvmInstance) GetBackendTargets(ctx context.Context, providerParams string, proto string, port string) ([]ResourceInstance, []string, error) {
 	instances := []ResourceInstance{}
 	err := fmt.Errorf("cvm do not support GetBackendTargets function")
 
-	logrus.Errorf("CvmInstance GetBackendTargets meet error=%v", err)
+	plugins.Logger(ctx).Errorf("CvmInstance GetBackendTargets meet error=%v", err)
 	return instances, []string{}, err
 }
 
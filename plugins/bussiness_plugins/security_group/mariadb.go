@@ -1,6 +1,7 @@
 package securitygroup
 
 import (
+	"context"
 	"fmt"
 
 	"github.com/WeBankPartners/wecube-plugins-qcloud/plugins"
@@ -11,14 +12,14 @@ import (
 type MariadbResourceType struct {
 }
 
-func (resourceType *MariadbResourceType) QueryInstancesById(providerParams string, instanceIds []string) (map[string]ResourceInstance, error) {
-	logrus.Infof("MariadbResourceType QueryInstancesById: request instanceIds=%++v", instanceIds)
+func (resourceType *MariadbResourceType) QueryInstancesById(ctx context.Context, providerParams string, instanceIds []string) (map[string]ResourceInstance, error) {
+	plugins.Logger(ctx).Infof("MariadbResourceType QueryInstancesById: request instanceIds=%++v", instanceIds)
 
 	result := make(map[string]ResourceInstance)
 	if len(instanceIds) == 0 {
 		err := fmt.Errorf("instanceIds is empty")
 
-		logrus.Errorf("MariadbResourceType QueryInstancesById meet error=%v", err)
+		plugins.Logger(ctx).Errorf("MariadbResourceType QueryInstancesById meet error=%v", err)
 		return result, err
 	}
 
@@ -26,10 +27,14 @@ func (resourceType *MariadbResourceType) QueryInstancesById(providerParams strin
 		Name:   "instanceId",
 		Values: instanceIds,
 	}
-	paramsMap, _ := plugins.GetMapFromProviderParams(providerParams)
-	instances, err := plugins.QueryMariadbInstance(providerParams, filter)
+	params, err := plugins.ParseProviderParams(providerParams)
 	if err != nil {
-		logrus.Errorf("MariadbResourceType QueryInstancesById QueryMariadbInstance meet error=%v", err)
+		plugins.Logger(ctx).Errorf("MariadbResourceType QueryInstancesById ParseProviderParams meet error=%v", err)
+		return result, err
+	}
+	instances, err := plugins.QueryMariadbInstance(ctx, providerParams, filter)
+	if err != nil {
+		plugins.Logger(ctx).Errorf("MariadbResourceType QueryInstancesById QueryMariadbInstance meet error=%v", err)
 		return result, err
 	}
 
@@ -38,25 +43,25 @@ func (resourceType *MariadbResourceType) QueryInstancesById(providerParams strin
 			Id:                      *instance.InstanceId,
 			Name:                    *instance.InstanceName,
 			Vip:                     *instance.Vip,
-			Region:                  paramsMap["Region"],
+			Region:                  params.Region,
 			SupportSecurityGroupApi: false,
 		}
 
 		result[*instance.InstanceId] = mariadbInstance
 	}
 
-	logrus.Infof("MariadbResourceType QueryInstancesById: result=%++v", result)
+	plugins.Logger(ctx).Infof("MariadbResourceType QueryInstancesById: result=%++v", result)
 	return result, nil
 }
 
-func (resourceType *MariadbResourceType) QueryInstancesByIp(providerParams string, ips []string) (map[string]ResourceInstance, error) {
-	logrus.Infof("MariadbResourceType QueryInstancesByIp: request ips=%++v", ips)
+func (resourceType *MariadbResourceType) QueryInstancesByIp(ctx context.Context, providerParams string, ips []string) (map[string]ResourceInstance, error) {
+	plugins.Logger(ctx).Infof("MariadbResourceType QueryInstancesByIp: request ips=%++v", ips)
 
 	result := make(map[string]ResourceInstance)
 	if len(ips) == 0 {
 		err := fmt.Errorf("ips is empty")
 
-		logrus.Errorf("MariadbResourceType QueryInstancesByIp meet error=%v", err)
+		plugins.Logger(ctx).Errorf("MariadbResourceType QueryInstancesByIp meet error=%v", err)
 		return result, err
 	}
 
@@ -64,10 +69,14 @@ func (resourceType *MariadbResourceType) QueryInstancesByIp(providerParams strin
 		Name:   "vip",
 		Values: ips,
 	}
-	paramsMap, _ := plugins.GetMapFromProviderParams(providerParams)
-	instances, err := plugins.QueryMariadbInstance(providerParams, filter)
+	params, err := plugins.ParseProviderParams(providerParams)
+	if err != nil {
+		plugins.Logger(ctx).Errorf("MariadbResourceType QueryInstancesByIp ParseProviderParams meet error=%v", err)
+		return result, err
+	}
+	instances, err := plugins.QueryMariadbInstance(ctx, providerParams, filter)
 	if err != nil {
-		logrus.Errorf("MariadbResourceType QueryInstancesByIp QueryCvmInstance meet error=%v", err)
+		plugins.Logger(ctx).Errorf("MariadbResourceType QueryInstancesByIp QueryCvmInstance meet error=%v", err)
 		return result, err
 	}
 
@@ -76,14 +85,14 @@ func (resourceType *MariadbResourceType) QueryInstancesByIp(providerParams strin
 			Id:                      *instance.InstanceId,
 			Name:                    *instance.InstanceName,
 			Vip:                     *instance.Vip,
-			Region:                  paramsMap["Region"],
+			Region:                  params.Region,
 			SupportSecurityGroupApi: false,
 		}
 
 		result[*instance.Vip] = mariadbInstance
 	}
 
-	logrus.Infof("MariadbResourceType QueryInstancesByIp: result=%++v", result)
+	plugins.Logger(ctx).Infof("MariadbResourceType QueryInstancesByIp: result=%++v", result)
 	return result, nil
 }
 
@@ -115,21 +124,21 @@ func (instance MariadbInstance) GetName() string {
 	return instance.Name
 }
 
-func (instance MariadbInstance) QuerySecurityGroups(providerParams string) ([]string, error) {
+func (instance MariadbInstance) QuerySecurityGroups(ctx context.Context, providerParams string) ([]string, error) {
 	securityGroups, err := plugins.QueryMariadbInstanceSecurityGroups(providerParams, instance.Id)
 	if err != nil {
-		logrus.Errorf("MariadbInstance QuerySecurityGroups meet error=%v", err)
+		plugins.Logger(ctx).Errorf("MariadbInstance QuerySecurityGroups meet error=%v", err)
 		return []string{}, err
 	}
 
-	logrus.Infof("MariadbInstance QuerySecurityGroups: return=[%++v]", securityGroups)
+	plugins.Logger(ctx).Infof("MariadbInstance QuerySecurityGroups: return=[%++v]", securityGroups)
 	return securityGroups, nil
 }
 
-func (instance MariadbInstance) AssociateSecurityGroups(providerParams string, securityGroups []string) error {
+func (instance MariadbInstance) AssociateSecurityGroups(ctx context.Context, providerParams string, securityGroups []string) error {
 	err := plugins.BindMariadbInstanceSecurityGroups(providerParams, instance.Id, securityGroups)
 	if err != nil {
-		logrus.Errorf("MariadbInstance AssociateSecurityGroups meet error=%v", err)
+		plugins.Logger(ctx).Errorf("MariadbInstance AssociateSecurityGroups meet error=%v", err)
 	}
 
 	return err
@@ -150,11 +159,11 @@ func (instance MariadbInstance) IsSupportSecurityGroupApi() bool {
 	return instance.SupportSecurityGroupApi
 }
 
-func (instance MariadbInstance) GetBackendTargets(providerParams string, proto string, port string) ([]ResourceInstance, []string, error) {
+func (instance MariadbInstance) GetBackendTargets(ctx context.Context, providerParams string, proto string, port string) ([]ResourceInstance, []string, error) {
 	instances := []ResourceInstance{}
 	err := fmt.Errorf("mariadb do not support GetBackendTargets function")
 
-	logrus.Errorf("MariadbInstance GetBackendTargets meet error=%v", err)
+	plugins.Logger(ctx).Errorf("MariadbInstance GetBackendTargets meet error=%v", err)
 	return instances, []string{}, err
 }
 
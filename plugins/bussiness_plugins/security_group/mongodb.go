@@ -1,12 +1,12 @@
 package securitygroup
 
 import (
+	"context"
 	"fmt"
 
 	"github.com/WeBankPartners/wecube-plugins-qcloud/plugins"
 	"github.com/sirupsen/logrus"
 	"github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/common"
-	"github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/common/profile"
 	mongodb "github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/mongodb/v20180408"
 )
 
@@ -20,32 +20,28 @@ type MongodbInstance struct {
 	Vip    string
 }
 
-func createMongodbClient(providerParams string) (client *mongodb.Client, err error) {
-	paramsMap, err := plugins.GetMapFromProviderParams(providerParams)
+func createMongodbClient(ctx context.Context, providerParams string) (client *mongodb.Client, err error) {
+	params, err := plugins.ParseProviderParams(providerParams)
 	if err != nil {
-		logrus.Errorf("createBmClient: failed to create Qcloud mongodb client, err=%v", err)
+		plugins.Logger(ctx).Errorf("createBmClient: failed to create Qcloud mongodb client, err=%v", err)
 		return nil, err
 	}
 
-	credential := common.NewCredential(paramsMap["SecretID"], paramsMap["SecretKey"])
-	clientProfile := profile.NewClientProfile()
-	clientProfile.HttpProfile.Endpoint = "mongodb.tencentcloudapi.com"
-
-	return mongodb.NewClient(credential, paramsMap["Region"], clientProfile)
+	return plugins.GetClientFactory().WithContext(ctx).NewMongodbClient(params.Region, params.SecretID, params.SecretKey)
 }
 
-func (resourceType *MongodbResourceType) QueryInstancesById(providerParams string, instanceIds []string) (map[string]ResourceInstance, error) {
-	logrus.Infof("MongodbResourceType QueryInstancesById: request instanceIds=%++v", instanceIds)
+func (resourceType *MongodbResourceType) QueryInstancesById(ctx context.Context, providerParams string, instanceIds []string) (map[string]ResourceInstance, error) {
+	plugins.Logger(ctx).Infof("MongodbResourceType QueryInstancesById: request instanceIds=%++v", instanceIds)
 
 	result := make(map[string]ResourceInstance)
 	if len(instanceIds) == 0 {
 		err := fmt.Errorf("instanceIds is empty")
 
-		logrus.Errorf("MongodbResourceType QueryInstancesById meet error=%v", err)
+		plugins.Logger(ctx).Errorf("MongodbResourceType QueryInstancesById meet error=%v", err)
 		return result, err
 	}
 
-	client, _ := createMongodbClient(providerParams)
+	client, _ := createMongodbClient(ctx, providerParams)
 	var offset, limit uint64 = 0, uint64(len(instanceIds))
 	region, _ := plugins.GetRegionFromProviderParams(providerParams)
 
@@ -56,12 +52,12 @@ func (resourceType *MongodbResourceType) QueryInstancesById(providerParams strin
 
 	resp, err := client.DescribeDBInstances(request)
 	if err != nil {
-		logrus.Errorf("MongodbResourceType QueryInstancesById DescribeDBInstances meet error=%v", err)
+		plugins.Logger(ctx).Errorf("MongodbResourceType QueryInstancesById DescribeDBInstances meet error=%v", err)
 		return result, err
 	}
 
 	if *resp.Response.TotalCount == 0 {
-		logrus.Infof("MongodbResourceType QueryInstancesById DescribeDBInstances: Response.TotalCount==0")
+		plugins.Logger(ctx).Infof("MongodbResourceType QueryInstancesById DescribeDBInstances: Response.TotalCount==0")
 		return result, nil
 	}
 
@@ -75,12 +71,12 @@ func (resourceType *MongodbResourceType) QueryInstancesById(providerParams strin
 		result[*mongodb.InstanceId] = instance
 	}
 
-	logrus.Infof("MongodbResourceType QueryInstancesById: result=%++v", result)
+	plugins.Logger(ctx).Infof("MongodbResourceType QueryInstancesById: result=%++v", result)
 	return result, nil
 }
 
-func queryMongodbInstances(providerParams string, offset uint64, limit uint64) ([]*mongodb.MongoDBInstanceDetail, uint64, error) {
-	client, _ := createMongodbClient(providerParams)
+func queryMongodbInstances(ctx context.Context, providerParams string, offset uint64, limit uint64) ([]*mongodb.MongoDBInstanceDetail, uint64, error) {
+	client, _ := createMongodbClient(ctx, providerParams)
 	result := []*mongodb.MongoDBInstanceDetail{}
 	request := mongodb.NewDescribeDBInstancesRequest()
 	request.Offset = &offset
@@ -88,37 +84,37 @@ func queryMongodbInstances(providerParams string, offset uint64, limit uint64) (
 
 	resp, err := client.DescribeDBInstances(request)
 	if err != nil {
-		logrus.Errorf("queryMongodbInstances DescribeDBInstances meet error=%v", err)
+		plugins.Logger(ctx).Errorf("queryMongodbInstances DescribeDBInstances meet error=%v", err)
 		return result, 0, err
 	}
 
 	if *resp.Response.TotalCount == 0 {
-		logrus.Infof("queryMongodbInstances DescribeDBInstances: Response.TotalCount==0")
+		plugins.Logger(ctx).Infof("queryMongodbInstances DescribeDBInstances: Response.TotalCount==0")
 		return result, 0, nil
 	}
 
-	logrus.Infof("queryMongodbInstances: return Response.InstanceDetails=%++v", resp.Response.InstanceDetails)
+	plugins.Logger(ctx).Infof("queryMongodbInstances: return Response.InstanceDetails=%++v", resp.Response.InstanceDetails)
 	return resp.Response.InstanceDetails, *resp.Response.TotalCount, nil
 }
 
-func (resourceType *MongodbResourceType) QueryInstancesByIp(providerParams string, ips []string) (map[string]ResourceInstance, error) {
-	logrus.Infof("MongodbResourceType QueryInstancesByIp: request ips=%++v", ips)
+func (resourceType *MongodbResourceType) QueryInstancesByIp(ctx context.Context, providerParams string, ips []string) (map[string]ResourceInstance, error) {
+	plugins.Logger(ctx).Infof("MongodbResourceType QueryInstancesByIp: request ips=%++v", ips)
 
 	var offset, limit uint64 = 0, 100
 	result := make(map[string]ResourceInstance)
 	if len(ips) == 0 {
 		err := fmt.Errorf("ips is empty")
 
-		logrus.Errorf("MongodbResourceType QueryInstancesByIp meet error=%v", err)
+		plugins.Logger(ctx).Errorf("MongodbResourceType QueryInstancesByIp meet error=%v", err)
 		return result, err
 	}
 
 	region, _ := plugins.GetRegionFromProviderParams(providerParams)
 
 	for {
-		mongodbs, total, err := queryMongodbInstances(providerParams, offset, limit)
+		mongodbs, total, err := queryMongodbInstances(ctx, providerParams, offset, limit)
 		if err != nil {
-			logrus.Errorf("MongodbResourceType queryMongodbInstances meet error=%v", err)
+			plugins.Logger(ctx).Errorf("MongodbResourceType queryMongodbInstances meet error=%v", err)
 			return result, err
 		}
 
@@ -143,7 +139,7 @@ func (resourceType *MongodbResourceType) QueryInstancesByIp(providerParams strin
 		}
 	}
 
-	logrus.Infof("MongodbResourceType: result=%++v", result)
+	plugins.Logger(ctx).Infof("MongodbResourceType: result=%++v", result)
 	return result, nil
 }
 
@@ -181,17 +177,17 @@ func (instance MongodbInstance) GetIp() string {
 	return instance.Vip
 }
 
-func (instance MongodbInstance) QuerySecurityGroups(providerParams string) ([]string, error) {
+func (instance MongodbInstance) QuerySecurityGroups(ctx context.Context, providerParams string) ([]string, error) {
 	err := fmt.Errorf("mongodb do not support query security group api")
 
-	logrus.Errorf("MongodbInstance QuerySecurityGroups meet error=%v", err)
+	plugins.Logger(ctx).Errorf("MongodbInstance QuerySecurityGroups meet error=%v", err)
 	return []string{}, err
 }
 
-func (instance MongodbInstance) AssociateSecurityGroups(providerParams string, securityGroups []string) error {
+func (instance MongodbInstance) AssociateSecurityGroups(ctx context.Context, providerParams string, securityGroups []string) error {
 	err := fmt.Errorf("mongodb do not support associateSecurityGroup api")
 
-	logrus.Errorf("MongodbInstance AssociateSecurityGroups meet error=%v", err)
+	plugins.Logger(ctx).Errorf("MongodbInstance AssociateSecurityGroups meet error=%v", err)
 	return err
 }
 
@@ -200,9 +196,9 @@ func (instance MongodbInstance) IsSupportSecurityGroupApi() bool {
 	return false
 }
 
-func (instance MongodbInstance) GetBackendTargets(providerParams string, proto string, port string) ([]ResourceInstance, []string, error) {
+func (instance MongodbInstance) GetBackendTargets(ctx context.Context, providerParams string, proto string, port string) ([]ResourceInstance, []string, error) {
 	err := fmt.Errorf("mongodb do not support backendTarget")
 
-	logrus.Errorf("MongodbInstance GetBackendTargets meet error=%v", err)
+	plugins.Logger(ctx).Errorf("MongodbInstance GetBackendTargets meet error=%v", err)
 	return []ResourceInstance{}, []string{}, err
 }
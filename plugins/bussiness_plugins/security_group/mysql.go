@@ -1,6 +1,7 @@
 package securitygroup
 
 import (
+	"context"
 	"fmt"
 
 	"github.com/WeBankPartners/wecube-plugins-qcloud/plugins"
@@ -18,14 +19,14 @@ var (
 type MysqlResourceType struct {
 }
 
-func (resourceType *MysqlResourceType) QueryInstancesById(providerParams string, instanceIds []string) (map[string]ResourceInstance, error) {
-	logrus.Infof("MysqlResourceType QueryInstancesById: request instanceIds=%++v", instanceIds)
+func (resourceType *MysqlResourceType) QueryInstancesById(ctx context.Context, providerParams string, instanceIds []string) (map[string]ResourceInstance, error) {
+	plugins.Logger(ctx).Infof("MysqlResourceType QueryInstancesById: request instanceIds=%++v", instanceIds)
 
 	result := make(map[string]ResourceInstance)
 	if len(instanceIds) == 0 {
 		err := fmt.Errorf("instanceIds is empty")
 
-		logrus.Errorf("MysqlResourceType QueryInstancesById meet error=%v", err)
+		plugins.Logger(ctx).Errorf("MysqlResourceType QueryInstancesById meet error=%v", err)
 		return result, err
 	}
 
@@ -33,10 +34,14 @@ func (resourceType *MysqlResourceType) QueryInstancesById(providerParams string,
 		Name:   "instanceId",
 		Values: instanceIds,
 	}
-	paramsMap, _ := plugins.GetMapFromProviderParams(providerParams)
-	items, err := plugins.QueryMysqlInstance(providerParams, filter)
+	params, err := plugins.ParseProviderParams(providerParams)
 	if err != nil {
-		logrus.Errorf("MysqlResourceType QueryInstancesById QueryMysqlInstance meet error=%v", err)
+		plugins.Logger(ctx).Errorf("MysqlResourceType QueryInstancesById ParseProviderParams meet error=%v", err)
+		return result, err
+	}
+	items, err := plugins.QueryMysqlInstance(ctx, providerParams, filter)
+	if err != nil {
+		plugins.Logger(ctx).Errorf("MysqlResourceType QueryInstancesById QueryMysqlInstance meet error=%v", err)
 		return result, err
 	}
 
@@ -45,32 +50,32 @@ func (resourceType *MysqlResourceType) QueryInstancesById(providerParams string,
 			Id:     *item.InstanceId,
 			Name:   *item.InstanceName,
 			Vip:    *item.Vip,
-			Region: paramsMap["Region"],
+			Region: params.Region,
 		}
 
 		if isSupport, ok := DEVICE_TYPE_MAP[*item.DeviceType]; ok {
 			instance.SupportSecurityGroupApi = isSupport
 		} else {
 			err := fmt.Errorf("failed to get instance.DeviceType")
-			logrus.Errorf("MysqlResourceType QueryInstancesById meet error=%v", err)
+			plugins.Logger(ctx).Errorf("MysqlResourceType QueryInstancesById meet error=%v", err)
 			return result, err
 		}
 
 		result[*item.InstanceId] = instance
 	}
 
-	logrus.Infof("MysqlResourceType QueryInstancesById: result=%++v", result)
+	plugins.Logger(ctx).Infof("MysqlResourceType QueryInstancesById: result=%++v", result)
 	return result, nil
 }
 
-func (resourceType *MysqlResourceType) QueryInstancesByIp(providerParams string, ips []string) (map[string]ResourceInstance, error) {
-	logrus.Infof("MysqlResourceType QueryInstancesByIp: request ips=%++v", ips)
+func (resourceType *MysqlResourceType) QueryInstancesByIp(ctx context.Context, providerParams string, ips []string) (map[string]ResourceInstance, error) {
+	plugins.Logger(ctx).Infof("MysqlResourceType QueryInstancesByIp: request ips=%++v", ips)
 
 	result := make(map[string]ResourceInstance)
 	if len(ips) == 0 {
 		err := fmt.Errorf("ips is empty")
 
-		logrus.Errorf("MysqlResourceType QueryInstancesByIp meet error=%v", err)
+		plugins.Logger(ctx).Errorf("MysqlResourceType QueryInstancesByIp meet error=%v", err)
 		return result, err
 	}
 
@@ -79,19 +84,23 @@ func (resourceType *MysqlResourceType) QueryInstancesByIp(providerParams string,
 		Values: ips,
 	}
 
-	items, err := plugins.QueryMysqlInstance(providerParams, filter)
+	items, err := plugins.QueryMysqlInstance(ctx, providerParams, filter)
 	if err != nil {
-		logrus.Errorf("MysqlResourceType QueryInstancesByIp QueryMysqlInstance meet error=%v", err)
+		plugins.Logger(ctx).Errorf("MysqlResourceType QueryInstancesByIp QueryMysqlInstance meet error=%v", err)
 		return result, err
 	}
 
-	paramsMap, _ := plugins.GetMapFromProviderParams(providerParams)
+	params, err := plugins.ParseProviderParams(providerParams)
+	if err != nil {
+		plugins.Logger(ctx).Errorf("MysqlResourceType QueryInstancesByIp ParseProviderParams meet error=%v", err)
+		return result, err
+	}
 	for _, item := range items {
 		instance := MysqlInstance{
 			Id:     *item.InstanceId,
 			Name:   *item.InstanceName,
 			Vip:    *item.Vip,
-			Region: paramsMap["Region"],
+			Region: params.Region,
 		}
 
 		if isSupport, ok := DEVICE_TYPE_MAP[*item.DeviceType]; ok {
@@ -99,14 +108,14 @@ func (resourceType *MysqlResourceType) QueryInstancesByIp(providerParams string,
 		} else {
 			err := fmt.Errorf("failed to get instance.DeviceType")
 
-			logrus.Errorf("MysqlResourceType QueryInstancesByIp meet error=%v", err)
+			plugins.Logger(ctx).Errorf("MysqlResourceType QueryInstancesByIp meet error=%v", err)
 			return result, err
 		}
 
 		result[*item.Vip] = instance
 	}
 
-	logrus.Infof("MysqlResourceType QueryInstancesByIp: result=%++v", result)
+	plugins.Logger(ctx).Infof("MysqlResourceType QueryInstancesByIp: result=%++v", result)
 	return result, nil
 }
 
@@ -144,21 +153,21 @@ func (instance MysqlInstance) GetName() string {
 	return instance.Name
 }
 
-func (instance MysqlInstance) QuerySecurityGroups(providerParams string) ([]string, error) {
-	securityGroups, err := plugins.QueryMySqlInstanceSecurityGroups(providerParams, instance.Id)
+func (instance MysqlInstance) QuerySecurityGroups(ctx context.Context, providerParams string) ([]string, error) {
+	securityGroups, err := plugins.QueryMySqlInstanceSecurityGroups(ctx, providerParams, instance.Id)
 	if err != nil {
-		logrus.Errorf("MysqlInstance QuerySecurityGroups meet error=%v", err)
+		plugins.Logger(ctx).Errorf("MysqlInstance QuerySecurityGroups meet error=%v", err)
 		return []string{}, err
 	}
 
-	logrus.Infof("MysqlInstance QuerySecurityGroups: securityGroups=%++v", securityGroups)
+	plugins.Logger(ctx).Infof("MysqlInstance QuerySecurityGroups: securityGroups=%++v", securityGroups)
 	return securityGroups, nil
 }
 
-func (instance MysqlInstance) AssociateSecurityGroups(providerParams string, securityGroups []string) error {
-	err := plugins.BindMySqlInstanceSecurityGroups(providerParams, instance.Id, securityGroups)
+func (instance MysqlInstance) AssociateSecurityGroups(ctx context.Context, providerParams string, securityGroups []string) error {
+	err := plugins.BindMySqlInstanceSecurityGroups(ctx, providerParams, instance.Id, securityGroups)
 	if err != nil {
-		logrus.Errorf("MysqlInstance AssociateSecurityGroups meet error=%v", err)
+		plugins.Logger(ctx).Errorf("MysqlInstance AssociateSecurityGroups meet error=%v", err)
 	}
 
 	return err
@@ -179,14 +188,14 @@ func (instance MysqlInstance) IsSupportSecurityGroupApi() bool {
 	return instance.SupportSecurityGroupApi
 }
 
-func (instance MysqlInstance) GetBackendTargets(providerParams string, port string, proto string) ([]ResourceInstance, []string, error) {
+func (instance MysqlInstance) GetBackendTargets(ctx context.Context, providerParams string, port string, proto string) ([]ResourceInstance, []string, error) {
 	instances, ports := []ResourceInstance{}, []string{}
 	err := fmt.Errorf("mysql do not support GetBackendTargets function")
 	if err != nil {
-		logrus.Errorf("MysqlInstance GetBackendTargets meet error=%v", err)
+		plugins.Logger(ctx).Errorf("MysqlInstance GetBackendTargets meet error=%v", err)
 		return instances, ports, err
 	}
 
-	logrus.Infof("MysqlInstance GetBackendTargets: return instances=%++v, ports=%++v", instances, ports)
+	plugins.Logger(ctx).Infof("MysqlInstance GetBackendTargets: return instances=%++v, ports=%++v", instances, ports)
 	return instances, ports, nil
 }
@@ -2,33 +2,20 @@ package securitygroup
 
 import (
 	"errors"
-	"fmt"
 	"os"
 	"strings"
 
+	"github.com/WeBankPartners/wecube-plugins-qcloud/plugins"
 	"github.com/sirupsen/logrus"
 )
 
-const ENV_SECRET_ID = "SECRET_ID"
-const ENV_SECRET_KEY = "SECRET_KEY"
+const ENV_SECRET_ID = plugins.ENV_SECRET_ID
+const ENV_SECRET_KEY = plugins.ENV_SECRET_KEY
 const ENV_SUPPORT_REGIONS = "REGIONS" //用分号隔开多个地域
 
+// getProviderParams returns the provider params of the region, the credential is provided by the
+// credential provider of the client factory, such as SECRET_ID and SECRET_KEY in env.
 func getProviderParams(region string) (string, error) {
-	secretId := os.Getenv(ENV_SECRET_ID)
-	secretKey := os.Getenv(ENV_SECRET_KEY)
-
-	if secretId == "" {
-		err := errors.New("can't get secretId from env")
-
-		logrus.Errorf("getProviderParams meet error=%v", err)
-		return "", err
-	}
-	if secretKey == "" {
-		err := errors.New("can't get secretKey from env")
-
-		logrus.Errorf("getProviderParams meet error=%v", err)
-		return "", err
-	}
 	if region == "" {
 		err := errors.New("input region is empty")
 
@@ -36,13 +23,23 @@ func getProviderParams(region string) (string, error) {
 		return "", err
 	}
 
-	return fmt.Sprintf("Region=%s;SecretID=%s;SecretKey=%s", region, secretId, secretKey), nil
+	params := &plugins.ProviderParams{Region: region}
+	return params.String(), nil
 }
 
+// getRegions returns the regions in env, or the default regions of the config if env is not set.
 func getRegions() ([]string, error) {
-	regions := strings.Split(os.Getenv(ENV_SUPPORT_REGIONS), ";")
+	regions := []string{}
+	for _, region := range strings.Split(os.Getenv(ENV_SUPPORT_REGIONS), ";") {
+		if region = strings.TrimSpace(region); region != "" {
+			regions = append(regions, region)
+		}
+	}
+	if len(regions) == 0 {
+		regions = plugins.GetDefaultRegions()
+	}
 	if len(regions) == 0 {
-		err := errors.New("can't get region from env")
+		err := errors.New("can't get region from env or default_regions of the config")
 
 		logrus.Errorf("getRegions meet error=%v", err)
 		return regions, err
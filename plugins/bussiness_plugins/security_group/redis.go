@@ -1,12 +1,12 @@
 package securitygroup
 
 import (
+	"context"
 	"fmt"
 
 	"github.com/WeBankPartners/wecube-plugins-qcloud/plugins"
 	"github.com/sirupsen/logrus"
 	"github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/common"
-	"github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/common/profile"
 	redis "github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/redis/v20180412"
 )
 
@@ -25,32 +25,28 @@ type RedisInstance struct {
 	Vip    string
 }
 
-func createRedisClient(providerParams string) (client *redis.Client, err error) {
-	paramsMap, err := plugins.GetMapFromProviderParams(providerParams)
+func createRedisClient(ctx context.Context, providerParams string) (client *redis.Client, err error) {
+	params, err := plugins.ParseProviderParams(providerParams)
 	if err != nil {
-		logrus.Errorf("createRedisClient GetMapFromProviderParams meet error=%v", err)
+		plugins.Logger(ctx).Errorf("createRedisClient ParseProviderParams meet error=%v", err)
 		return nil, err
 	}
 
-	credential := common.NewCredential(paramsMap["SecretID"], paramsMap["SecretKey"])
-	clientProfile := profile.NewClientProfile()
-	clientProfile.HttpProfile.Endpoint = "redis.tencentcloudapi.com"
-
-	return redis.NewClient(credential, paramsMap["Region"], clientProfile)
+	return plugins.GetClientFactory().WithContext(ctx).NewRedisClient(params.Region, params.SecretID, params.SecretKey)
 }
 
-func redisQueryInstances(providerParams string, searchKeys []string, searchKeyType string) (map[string]ResourceInstance, error) {
-	logrus.Infof("redisQueryInstances: request searchKeys=%++v, searchKeyType=%++v", searchKeys, searchKeyType)
+func redisQueryInstances(ctx context.Context, providerParams string, searchKeys []string, searchKeyType string) (map[string]ResourceInstance, error) {
+	plugins.Logger(ctx).Infof("redisQueryInstances: request searchKeys=%++v, searchKeyType=%++v", searchKeys, searchKeyType)
 
 	result := make(map[string]ResourceInstance)
-	client, _ := createRedisClient(providerParams)
+	client, _ := createRedisClient(ctx, providerParams)
 	var offset, limit uint64 = 0, uint64(len(searchKeys))
 	region, _ := plugins.GetRegionFromProviderParams(providerParams)
 
 	if searchKeyType != REDIS_SEARCH_KEY_IP && searchKeyType != REDIS_SEARCH_KEY_ID {
 		err := fmt.Errorf("invalid redis searchkey(%s)", searchKeyType)
 
-		logrus.Errorf("redisQueryInstances meet error=%v", err)
+		plugins.Logger(ctx).Errorf("redisQueryInstances meet error=%v", err)
 		return result, err
 	}
 
@@ -61,12 +57,12 @@ func redisQueryInstances(providerParams string, searchKeys []string, searchKeyTy
 
 	resp, err := client.DescribeInstances(request)
 	if err != nil {
-		logrus.Errorf("redisQueryInstances DescribeInstances meet error=%v", err)
+		plugins.Logger(ctx).Errorf("redisQueryInstances DescribeInstances meet error=%v", err)
 		return result, err
 	}
 
 	if *resp.Response.TotalCount == 0 {
-		logrus.Infof("redisQueryInstances DescribeInstances: Response.TotalCount==0")
+		plugins.Logger(ctx).Infof("redisQueryInstances DescribeInstances: Response.TotalCount==0")
 		return result, nil
 	}
 
@@ -84,29 +80,29 @@ func redisQueryInstances(providerParams string, searchKeys []string, searchKeyTy
 		}
 	}
 
-	logrus.Infof("redisQueryInstances: result=%++v", result)
+	plugins.Logger(ctx).Infof("redisQueryInstances: result=%++v", result)
 	return result, nil
 }
 
-func (resourceType *RedisResourceType) QueryInstancesById(providerParams string, instanceIds []string) (map[string]ResourceInstance, error) {
-	instances, err := redisQueryInstances(providerParams, instanceIds, REDIS_SEARCH_KEY_ID)
+func (resourceType *RedisResourceType) QueryInstancesById(ctx context.Context, providerParams string, instanceIds []string) (map[string]ResourceInstance, error) {
+	instances, err := redisQueryInstances(ctx, providerParams, instanceIds, REDIS_SEARCH_KEY_ID)
 	if err != nil {
-		logrus.Errorf("RedisResourceType QueryInstancesById meet error=%v", err)
+		plugins.Logger(ctx).Errorf("RedisResourceType QueryInstancesById meet error=%v", err)
 		return instances, err
 	}
 
-	logrus.Infof("RedisResourceType QueryInstancesById: return instances=%++v", instances)
+	plugins.Logger(ctx).Infof("RedisResourceType QueryInstancesById: return instances=%++v", instances)
 	return instances, nil
 }
 
-func (resourceType *RedisResourceType) QueryInstancesByIp(providerParams string, ips []string) (map[string]ResourceInstance, error) {
-	instances, err := redisQueryInstances(providerParams, ips, REDIS_SEARCH_KEY_IP)
+func (resourceType *RedisResourceType) QueryInstancesByIp(ctx context.Context, providerParams string, ips []string) (map[string]ResourceInstance, error) {
+	instances, err := redisQueryInstances(ctx, providerParams, ips, REDIS_SEARCH_KEY_IP)
 	if err != nil {
-		logrus.Errorf("RedisResourceType QueryInstancesByIp meet error=%v", err)
+		plugins.Logger(ctx).Errorf("RedisResourceType QueryInstancesByIp meet error=%v", err)
 		return instances, err
 	}
 
-	logrus.Infof("RedisResourceType QueryInstancesByIp: return instances=%++v", instances)
+	plugins.Logger(ctx).Infof("RedisResourceType QueryInstancesByIp: return instances=%++v", instances)
 	return instances, nil
 }
 
@@ -145,17 +141,17 @@ func (instance RedisInstance) GetIp() string {
 	return instance.Vip
 }
 
-func (instance RedisInstance) QuerySecurityGroups(providerParams string) ([]string, error) {
+func (instance RedisInstance) QuerySecurityGroups(ctx context.Context, providerParams string) ([]string, error) {
 	err := fmt.Errorf("redis do not support query security group api")
 
-	logrus.Errorf("RedisInstance QuerySecurityGroups meet error=%v", err)
+	plugins.Logger(ctx).Errorf("RedisInstance QuerySecurityGroups meet error=%v", err)
 	return []string{}, err
 }
 
-func (instance RedisInstance) AssociateSecurityGroups(providerParams string, securityGroups []string) error {
+func (instance RedisInstance) AssociateSecurityGroups(ctx context.Context, providerParams string, securityGroups []string) error {
 	err := fmt.Errorf("redis do not support associateSecurityGroup api")
 
-	logrus.Errorf("RedisInstance AssociateSecurityGroups meet error=%v", err)
+	plugins.Logger(ctx).Errorf("RedisInstance AssociateSecurityGroups meet error=%v", err)
 	return err
 }
 
@@ -164,9 +160,9 @@ func (instance RedisInstance) IsSupportSecurityGroupApi() bool {
 	return false
 }
 
-func (instance RedisInstance) GetBackendTargets(providerParams string, proto string, port string) ([]ResourceInstance, []string, error) {
+func (instance RedisInstance) GetBackendTargets(ctx context.Context, providerParams string, proto string, port string) ([]ResourceInstance, []string, error) {
 	err := fmt.Errorf("redis do not support backendTarget")
 
-	logrus.Errorf("RedisInstance GetBackendTargets meet error=%v", err)
+	plugins.Logger(ctx).Errorf("RedisInstance GetBackendTargets meet error=%v", err)
 	return []ResourceInstance{}, []string{}, err
 }
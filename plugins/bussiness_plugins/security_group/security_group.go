@@ -1,6 +1,7 @@
 package securitygroup
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -31,15 +32,15 @@ type ResourceInstance interface {
 	GetName() string
 	GetRegion() string
 	GetIp() string
-	QuerySecurityGroups(providerParams string) ([]string, error)
-	AssociateSecurityGroups(providerParams string, securityGroups []string) error
+	QuerySecurityGroups(ctx context.Context, providerParams string) ([]string, error)
+	AssociateSecurityGroups(ctx context.Context, providerParams string, securityGroups []string) error
 	IsSupportSecurityGroupApi() bool
-	GetBackendTargets(providerParams string, proto string, port string) ([]ResourceInstance, []string, error)
+	GetBackendTargets(ctx context.Context, providerParams string, proto string, port string) ([]ResourceInstance, []string, error)
 }
 
 type ResourceType interface {
-	QueryInstancesById(providerParams string, instanceIds []string) (map[string]ResourceInstance, error)
-	QueryInstancesByIp(providerParams string, ips []string) (map[string]ResourceInstance, error)
+	QueryInstancesById(ctx context.Context, providerParams string, instanceIds []string) (map[string]ResourceInstance, error)
+	QueryInstancesByIp(ctx context.Context, providerParams string, ips []string) (map[string]ResourceInstance, error)
 	IsLoadBalanceType() bool
 	IsSupportEgressPolicy() bool
 }
@@ -112,6 +113,10 @@ func (plugin *BussinessSecurityGroupPlugin) GetActionByName(actionName string) (
 	return action, nil
 }
 
+func (plugin *BussinessSecurityGroupPlugin) GetActions() map[string]plugins.Action {
+	return SecurityGroupActions
+}
+
 var SecurityGroupActions = make(map[string]plugins.Action)
 
 func init() {
@@ -138,23 +143,23 @@ type QueryIpsResult struct {
 	InstanceMap map[string]ResourceInstance
 }
 
-func queryOneRegionInstanceByIps(providerParams string, region string, ips []string, ch chan QueryIpsResult) {
+func queryOneRegionInstanceByIps(ctx context.Context, providerParams string, region string, ips []string, ch chan QueryIpsResult) {
 	result := QueryIpsResult{
 		Err:         nil,
 		InstanceMap: make(map[string]ResourceInstance),
 	}
 	start := time.Now()
 	defer func() {
-		logrus.Infof("queryOneRegionInstanceByIps region(%s) ips (%v) taken %v,result=%++v", region, ips, time.Since(start), result)
+		plugins.Logger(ctx).Infof("queryOneRegionInstanceByIps region(%s) ips (%v) taken %v,result=%++v", region, ips, time.Since(start), result)
 	}()
 
 	rtnIps := 0
 	for _, resType := range resourceTypeMap {
-		instanceMap, err := resType.QueryInstancesByIp(providerParams, ips)
-		logrus.Infof("findInstanceByIp QueryInstancesByIp instanceMap:%++v", instanceMap)
+		instanceMap, err := resType.QueryInstancesByIp(ctx, providerParams, ips)
+		plugins.Logger(ctx).Infof("findInstanceByIp QueryInstancesByIp instanceMap:%++v", instanceMap)
 		if err != nil {
 			result.Err = err
-			logrus.Errorf("findInstanceByIp QueryInstancesByIp meet error=%v\n", err)
+			plugins.Logger(ctx).Errorf("findInstanceByIp QueryInstancesByIp meet error=%v\n", err)
 			break
 		}
 
@@ -170,12 +175,12 @@ func queryOneRegionInstanceByIps(providerParams string, region string, ips []str
 	ch <- result
 }
 
-func getResourceAllIp(sourceIps []string, destIps []string) (map[string]ResourceInstance, error) {
+func getResourceAllIp(ctx context.Context, sourceIps []string, destIps []string) (map[string]ResourceInstance, error) {
 	totalMap := make(map[string]ResourceInstance)
 	chResult := make(chan QueryIpsResult)
 	regions, err := getRegions()
 	if err != nil {
-		logrus.Errorf("findInstanceByIp getRegions meet err=%v\n", err)
+		plugins.Logger(ctx).Errorf("findInstanceByIp getRegions meet err=%v\n", err)
 		return nil, err
 	}
 
@@ -196,7 +201,7 @@ func getResourceAllIp(sourceIps []string, destIps []string) (map[string]Resource
 		if err != nil {
 			return totalMap, err
 		}
-		go queryOneRegionInstanceByIps(providerParams, region, ips, chResult)
+		go queryOneRegionInstanceByIps(ctx, providerParams, region, ips, chResult)
 	}
 
 	returnedIp := 0
@@ -266,15 +271,15 @@ type CalcSecurityPoliciesResult struct {
 type CalcSecurityPolicyAction struct {
 }
 
-func (action *CalcSecurityPolicyAction) ReadParam(param interface{}) (interface{}, error) {
+func (action *CalcSecurityPolicyAction) ReadParam(ctx context.Context, param interface{}) (interface{}, error) {
 	var input CalcSecurityPoliciesRequest
 	err := unmarshalJson(param, &input)
 	if err != nil {
-		logrus.Errorf("CalcSecurityPolicyAction ReadParam UnmarshalJson: failed to unmarsh, err=%v, param=%v", err, param)
+		plugins.Logger(ctx).Errorf("CalcSecurityPolicyAction ReadParam UnmarshalJson: failed to unmarsh, err=%v", err)
 		return nil, err
 	}
 
-	logrus.Infof("CalcSecurityPolicyAction ReadParam: return=%++v", input)
+	plugins.Logger(ctx).Infof("CalcSecurityPolicyAction ReadParam: return=%++v", input)
 	return input, nil
 }
 
@@ -320,8 +325,8 @@ func (action *CalcSecurityPolicyAction) CheckParam(input interface{}) error {
 	return nil
 }
 
-func newPolicies(instance ResourceInstance, myIp string, peerIp string, proto string, port string, action string, desc string) ([]SecurityPolicy, error) {
-	logrus.Infof("newPolicies: request instance=%++v, myIp=%v, peerIp=%v, protocol=%v, port=%v, action=%v, description=%v", instance, myIp, peerIp, proto, port, action, desc)
+func newPolicies(ctx context.Context, instance ResourceInstance, myIp string, peerIp string, proto string, port string, action string, desc string) ([]SecurityPolicy, error) {
+	plugins.Logger(ctx).Infof("newPolicies: request instance=%++v, myIp=%v, peerIp=%v, protocol=%v, port=%v, action=%v, description=%v", instance, myIp, peerIp, proto, port, action, desc)
 
 	policies := []SecurityPolicy{}
 	resType, _ := getResouceTypeByName(instance.ResourceTypeName())
@@ -342,7 +347,7 @@ func newPolicies(instance ResourceInstance, myIp string, peerIp string, proto st
 		}
 		policies := append(policies, newPolicy)
 
-		logrus.Infof("newPolicies: return policies=%++v", policies)
+		plugins.Logger(ctx).Infof("newPolicies: return policies=%++v", policies)
 		return policies, nil
 	}
 
@@ -354,17 +359,17 @@ func newPolicies(instance ResourceInstance, myIp string, peerIp string, proto st
 		if _, err := strconv.Atoi(splitPort); err != nil {
 			err := fmt.Errorf("loadbalancer do not support port format like %s", port)
 
-			logrus.Errorf("newPolicies strconv.Atoi meet error=%v", err)
+			plugins.Logger(ctx).Errorf("newPolicies strconv.Atoi meet error=%v", err)
 			return policies, err
 		}
-		instances, ports, err := instance.GetBackendTargets(providerParams, proto, splitPort)
+		instances, ports, err := instance.GetBackendTargets(ctx, providerParams, proto, splitPort)
 		if err != nil {
-			logrus.Errorf("newPolicies GetBackendTargets meet error=%v", err)
+			plugins.Logger(ctx).Errorf("newPolicies GetBackendTargets meet error=%v", err)
 			return policies, err
 		}
 		if len(instances) == 0 {
 			err := fmt.Errorf("loadbalancer(%s) port (%v) do not have any backends", instance.GetIp(), splitPort)
-			logrus.Errorf("newPolicies GetBackendTargets meet error=%v", err)
+			plugins.Logger(ctx).Errorf("newPolicies GetBackendTargets meet error=%v", err)
 			return policies, err
 		}
 
@@ -385,53 +390,53 @@ func newPolicies(instance ResourceInstance, myIp string, peerIp string, proto st
 		}
 	}
 
-	logrus.Infof("newPolicies: return policies=%++v", policies)
+	plugins.Logger(ctx).Infof("newPolicies: return policies=%++v", policies)
 	return policies, nil
 }
 
-func calcPolicies(devIp string, ipMap map[string]ResourceInstance, peerIps []string, proto string, ports []string,
+func calcPolicies(ctx context.Context, devIp string, ipMap map[string]ResourceInstance, peerIps []string, proto string, ports []string,
 	action string, description string, direction string) ([]SecurityPolicy, error) {
-	logrus.Infof("calcPolicies: reuqest devIp=%v, peerIps=%++v, protocol=%v, ports=%++v, action=%v, description=%v, direction=%v", devIp, peerIps, proto, ports, action, description, direction)
+	plugins.Logger(ctx).Infof("calcPolicies: reuqest devIp=%v, peerIps=%++v, protocol=%v, ports=%++v, action=%v, description=%v, direction=%v", devIp, peerIps, proto, ports, action, description, direction)
 
 	policies := []SecurityPolicy{}
 
 	//check if dev exist
 	instance, err := findInstanceByIp(devIp, ipMap)
 	if err != nil {
-		logrus.Errorf("calcPolicies findInstanceByIp meet error=%v", err)
+		plugins.Logger(ctx).Errorf("calcPolicies findInstanceByIp meet error=%v", err)
 		return policies, err
 	}
 
 	resType, err := getResouceTypeByName(instance.ResourceTypeName())
 	if err != nil {
-		logrus.Errorf("calcPolicies getResouceTypeByName meet error=%v", err)
+		plugins.Logger(ctx).Errorf("calcPolicies getResouceTypeByName meet error=%v", err)
 		return policies, err
 	}
 
 	if direction == EGRESS_RULE {
 		if false == resType.IsSupportEgressPolicy() {
 			err := fmt.Errorf("%s is %s device,do not support egress", devIp, instance.ResourceTypeName())
-			logrus.Errorf("calcPolicies IsSupportEgressPolicy meet error=%v", err)
+			plugins.Logger(ctx).Errorf("calcPolicies IsSupportEgressPolicy meet error=%v", err)
 			return policies, err
 		}
 	}
 
 	for _, peerIp := range peerIps {
 		peerInstance, err := findInstanceByIp(peerIp, ipMap)
-		logrus.Infof("calcPolicies findInstanceByip peerIp=%s, instance=%++v, err=%v\n", peerIp, peerInstance, err)
+		plugins.Logger(ctx).Infof("calcPolicies findInstanceByip peerIp=%s, instance=%++v, err=%v\n", peerIp, peerInstance, err)
 		if err == nil {
 			peerResType, _ := getResouceTypeByName(peerInstance.ResourceTypeName())
 			if direction == INGRESS_RULE && nil != peerResType && peerResType.IsLoadBalanceType() {
 				err := fmt.Errorf("对端设备(%s) 是负载均衡设备,入栈规则不支持对端IP为负载均衡设备", peerIp)
-				logrus.Infof("calcPolicies getResouceTypeByName meet error=%v", err)
+				plugins.Logger(ctx).Infof("calcPolicies getResouceTypeByName meet error=%v", err)
 				return policies, err
 			}
 		}
 
 		for _, port := range ports {
-			newPolicies, err := newPolicies(instance, devIp, peerIp, proto, port, action, description)
+			newPolicies, err := newPolicies(ctx, instance, devIp, peerIp, proto, port, action, description)
 			if err != nil {
-				logrus.Errorf("calcPolicies newPolicies meet error=%v", err)
+				plugins.Logger(ctx).Errorf("calcPolicies newPolicies meet error=%v", err)
 				return policies, err
 			}
 			if len(newPolicies) > 0 {
@@ -440,31 +445,31 @@ func calcPolicies(devIp string, ipMap map[string]ResourceInstance, peerIps []str
 		}
 	}
 
-	logrus.Infof("calcPolicies: retuern policies=%++v", policies)
+	plugins.Logger(ctx).Infof("calcPolicies: retuern policies=%++v", policies)
 	return policies, nil
 }
 
-func (action *CalcSecurityPolicyAction) Do(input interface{}) (interface{}, error) {
+func (action *CalcSecurityPolicyAction) Do(ctx context.Context, input interface{}) (interface{}, error) {
 	req, _ := input.(CalcSecurityPoliciesRequest)
-	logrus.Infof("CalcSecurityPolicyAction Do: request input=%++v", input)
+	plugins.Logger(ctx).Infof("CalcSecurityPolicyAction Do: request input=%++v", input)
 
 	result := CalcSecurityPoliciesResult{}
 	start := time.Now()
 	ports, _ := getPortsByPolicyFormat(req.DestPort)
-	logrus.Infof("CalcSecurityPolicyAction Do: ports=%++v", ports)
+	plugins.Logger(ctx).Infof("CalcSecurityPolicyAction Do: ports=%++v", ports)
 
-	ipMaps, err := getResourceAllIp(req.SourceIps, req.DestIps)
-	logrus.Infof("CalcSecurityPolicyAction Do getResourceAllIp: len(ipMaps)=%v ipMaps=%++v", len(ipMaps), ipMaps)
+	ipMaps, err := getResourceAllIp(ctx, req.SourceIps, req.DestIps)
+	plugins.Logger(ctx).Infof("CalcSecurityPolicyAction Do getResourceAllIp: len(ipMaps)=%v ipMaps=%++v", len(ipMaps), ipMaps)
 
 	if err != nil {
-		logrus.Infof("getResourceAllIp meet err=%v", err)
+		plugins.Logger(ctx).Infof("getResourceAllIp meet err=%v", err)
 		result.TimeTaken = fmt.Sprintf("%v", time.Since(start))
 		return result, err
 	}
 	//calc egress policies
 	if isContainInList(EGRESS_RULE, req.PolicyDirections) {
 		for _, ip := range req.SourceIps {
-			policies, err := calcPolicies(ip, ipMaps, req.DestIps, req.Protocol, ports, req.PolicyAction, req.Description, EGRESS_RULE)
+			policies, err := calcPolicies(ctx, ip, ipMaps, req.DestIps, req.Protocol, ports, req.PolicyAction, req.Description, EGRESS_RULE)
 			result.EgressPolicies = append(result.EgressPolicies, policies...)
 			if err != nil {
 				result.TimeTaken = fmt.Sprintf("%v", time.Since(start))
@@ -476,7 +481,7 @@ func (action *CalcSecurityPolicyAction) Do(input interface{}) (interface{}, erro
 	//calc ingress policies
 	if isContainInList(INGRESS_RULE, req.PolicyDirections) {
 		for _, ip := range req.DestIps {
-			policies, err := calcPolicies(ip, ipMaps, req.SourceIps, req.Protocol, ports, req.PolicyAction, req.Description, INGRESS_RULE)
+			policies, err := calcPolicies(ctx, ip, ipMaps, req.SourceIps, req.Protocol, ports, req.PolicyAction, req.Description, INGRESS_RULE)
 			result.IngressPolicies = append(result.IngressPolicies, policies...)
 			if err != nil {
 				result.TimeTaken = fmt.Sprintf("%v", time.Since(start))
@@ -489,7 +494,7 @@ func (action *CalcSecurityPolicyAction) Do(input interface{}) (interface{}, erro
 	result.IngressPoliciesTotal = len(result.IngressPolicies)
 	result.EgressPoliciesTotal = len(result.EgressPolicies)
 
-	logrus.Infof("CalcSecurityPolicyAction Do: return result=%++v", result)
+	plugins.Logger(ctx).Infof("CalcSecurityPolicyAction Do: return result=%++v", result)
 	return result, nil
 }
 
@@ -520,14 +525,14 @@ type ApplySecurityPoliciesResult struct {
 	EgressApplyResult  ApplyResult `json:"egress"`
 }
 
-func (action *ApplySecurityPolicyAction) ReadParam(param interface{}) (interface{}, error) {
+func (action *ApplySecurityPolicyAction) ReadParam(ctx context.Context, param interface{}) (interface{}, error) {
 	var input ApplySecurityPoliciesRequest
 	err := unmarshalJson(param, &input)
 	if err != nil {
-		logrus.Errorf("ApplySecurityPolicyAction:unmarshal failed,err=%v,param=%v", err, param)
+		plugins.Logger(ctx).Errorf("ApplySecurityPolicyAction:unmarshal failed,err=%v", err)
 		return nil, err
 	}
-	logrus.Infof("ApplySecurityPolicyAction ReadParam: input=%++v", input)
+	plugins.Logger(ctx).Infof("ApplySecurityPolicyAction ReadParam: input=%++v", input)
 	return input, nil
 }
 
@@ -550,22 +555,22 @@ func (action *ApplySecurityPolicyAction) CheckParam(input interface{}) error {
 	return nil
 }
 
-func (action *ApplySecurityPolicyAction) Do(input interface{}) (interface{}, error) {
+func (action *ApplySecurityPolicyAction) Do(ctx context.Context, input interface{}) (interface{}, error) {
 	var err error
 	req, _ := input.(ApplySecurityPoliciesRequest)
 	result := ApplySecurityPoliciesResult{}
 	start := time.Now()
-	logrus.Infof("ApplySecurityPolicyAction Do: req=%++v", req)
+	plugins.Logger(ctx).Infof("ApplySecurityPolicyAction Do: req=%++v", req)
 
-	result.IngressApplyResult = applyPolicies(req.IngressPolicies, INGRESS_RULE)
-	result.EgressApplyResult = applyPolicies(req.EgressPolicies, EGRESS_RULE)
+	result.IngressApplyResult = applyPolicies(ctx, req.IngressPolicies, INGRESS_RULE)
+	result.EgressApplyResult = applyPolicies(ctx, req.EgressPolicies, EGRESS_RULE)
 
 	result.TimeTaken = fmt.Sprintf("%v", time.Since(start))
 	if result.IngressApplyResult.FailedTotal > 0 || result.EgressApplyResult.FailedTotal > 0 {
 		err = errors.New("have some failed polices,please check policy applied detail")
 	}
 
-	logrus.Infof("ApplySecurityPolicyAction Do: result=%++v", result)
+	plugins.Logger(ctx).Infof("ApplySecurityPolicyAction Do: result=%++v", result)
 	return result, err
 }
 
@@ -575,8 +580,8 @@ func fillSecuityPoliciesWithErrMsg(policies []*SecurityPolicy, err error) {
 	}
 }
 
-func applyPolicies(policies []SecurityPolicy, direction string) ApplyResult {
-	logrus.Infof("applyPolicies: input policies=%++v direction=%++v", policies, direction)
+func applyPolicies(ctx context.Context, policies []SecurityPolicy, direction string) ApplyResult {
+	plugins.Logger(ctx).Infof("applyPolicies: input policies=%++v direction=%++v", policies, direction)
 
 	result := ApplyResult{}
 	instanceMap := make(map[string][]*SecurityPolicy)
@@ -594,66 +599,66 @@ func applyPolicies(policies []SecurityPolicy, direction string) ApplyResult {
 			result.UndoPolicies = append(result.UndoPolicies, policies[i])
 		}
 	}
-	logrus.Infof("applyPolicies: instanceMap=%++v", instanceMap)
+	plugins.Logger(ctx).Infof("applyPolicies: instanceMap=%++v", instanceMap)
 
 	for _, policies := range instanceMap {
 		resType, err := getResouceTypeByName(policies[0].Type)
 		if err != nil {
-			logrus.Errorf("applyPolicies getResouceTypeByName meet error=%v", err)
+			plugins.Logger(ctx).Errorf("applyPolicies getResouceTypeByName meet error=%v", err)
 			fillSecuityPoliciesWithErrMsg(policies, err)
 			continue
 		}
 
 		providerParams, err := getProviderParams(policies[0].Region)
 		if err != nil {
-			logrus.Errorf("applyPolicies getProviderParams meet error=%v", err)
+			plugins.Logger(ctx).Errorf("applyPolicies getProviderParams meet error=%v", err)
 			fillSecuityPoliciesWithErrMsg(policies, err)
 			continue
 		}
 
-		instances, err := resType.QueryInstancesById(providerParams, []string{policies[0].Id})
+		instances, err := resType.QueryInstancesById(ctx, providerParams, []string{policies[0].Id})
 		if err != nil {
-			logrus.Errorf("applyPolicies QueryInstancesById meet error=%v", err)
+			plugins.Logger(ctx).Errorf("applyPolicies QueryInstancesById meet error=%v", err)
 			fillSecuityPoliciesWithErrMsg(policies, err)
 			continue
 		}
 		if len(instances) == 0 {
 			err := fmt.Errorf("can't found instanceId(%s)", policies[0].Id)
-			logrus.Errorf("applyPolicies QueryInstancesById meet error=%v", err)
+			plugins.Logger(ctx).Errorf("applyPolicies QueryInstancesById meet error=%v", err)
 
 			fillSecuityPoliciesWithErrMsg(policies, err)
 			continue
 		}
 		instance := instances[policies[0].Id]
-		logrus.Infof("applyPolicies instance=%++v", instance)
+		plugins.Logger(ctx).Infof("applyPolicies instance=%++v", instance)
 
-		existSecurityGroups, err := instance.QuerySecurityGroups(providerParams)
+		existSecurityGroups, err := instance.QuerySecurityGroups(ctx, providerParams)
 		if err != nil {
-			logrus.Errorf("applyPolicies QuerySecurityGroups meet error=%v", err)
+			plugins.Logger(ctx).Errorf("applyPolicies QuerySecurityGroups meet error=%v", err)
 			fillSecuityPoliciesWithErrMsg(policies, err)
 			continue
 		}
 
-		logrus.Infof("applyPolicies existSecurityGroups=%++v", existSecurityGroups)
-		newSecurityGroups, err := createPolicies(providerParams, existSecurityGroups, policies, direction)
+		plugins.Logger(ctx).Infof("applyPolicies existSecurityGroups=%++v", existSecurityGroups)
+		newSecurityGroups, err := createPolicies(ctx, providerParams, existSecurityGroups, policies, direction)
 		if err != nil {
-			logrus.Errorf("applyPolicies createPolicies meet error=%v", err)
+			plugins.Logger(ctx).Errorf("applyPolicies createPolicies meet error=%v", err)
 
-			destroyPolicies(providerParams, policies, direction)
+			destroyPolicies(ctx, providerParams, policies, direction)
 			fillSecuityPoliciesWithErrMsg(policies, err)
 			continue
 		}
-		logrus.Infof("applyPolicies newSecurityGroups:%v", newSecurityGroups)
+		plugins.Logger(ctx).Infof("applyPolicies newSecurityGroups:%v", newSecurityGroups)
 
 		if len(newSecurityGroups) > 0 {
 			groups := []string{}
 			groups = append(groups, newSecurityGroups...)
 			groups = append(groups, existSecurityGroups...)
 
-			if err = instance.AssociateSecurityGroups(providerParams, groups); err != nil {
-				logrus.Errorf("applyPolicies AssociateSecurityGroups meet error=%v", err)
+			if err = instance.AssociateSecurityGroups(ctx, providerParams, groups); err != nil {
+				plugins.Logger(ctx).Errorf("applyPolicies AssociateSecurityGroups meet error=%v", err)
 
-				destroyPolicies(providerParams, policies, direction)
+				destroyPolicies(ctx, providerParams, policies, direction)
 				bindError := fmt.Errorf("resourceType(%s) instance(%s) AssociateSecurityGroups[%v] meet err=%v", policies[0].Type, policies[0].Ip, groups, err)
 				fillSecuityPoliciesWithErrMsg(policies, bindError)
 				continue
@@ -674,7 +679,7 @@ func applyPolicies(policies []SecurityPolicy, direction string) ApplyResult {
 	result.SuccessTotal = len(result.SuccessPolicies)
 	result.FailedTotal = len(result.FailedPolicies)
 
-	logrus.Infof("applyPolicies: result=%++v", result)
+	plugins.Logger(ctx).Infof("applyPolicies: result=%++v", result)
 	return result
 }
 
@@ -735,12 +740,12 @@ func sortSecurityGroupsIds(num []int, securityGroupsIds []string) ([]string, err
 	return securityGroupsIds, nil
 }
 
-func getSecurityGroupFreePolicyNum(providerParams string, securityGroup string, direction string) (int, error) {
-	logrus.Infof("getSecurityGroupFreePolicyNum: input securityGroup=%v direction=%v", securityGroup, direction)
+func getSecurityGroupFreePolicyNum(ctx context.Context, providerParams string, securityGroup string, direction string) (int, error) {
+	plugins.Logger(ctx).Infof("getSecurityGroupFreePolicyNum: input securityGroup=%v direction=%v", securityGroup, direction)
 
-	policiesSet, err := plugins.QuerySecurityGroupPolicies(providerParams, securityGroup)
+	policiesSet, err := plugins.QuerySecurityGroupPolicies(ctx, providerParams, securityGroup)
 	if err != nil {
-		logrus.Errorf("getSecurityGroupFreePolicyNum meet error=%v\n", err)
+		plugins.Logger(ctx).Errorf("getSecurityGroupFreePolicyNum meet error=%v\n", err)
 		return 0, err
 	}
 
@@ -751,14 +756,14 @@ func getSecurityGroupFreePolicyNum(providerParams string, securityGroup string,
 	return MAX_SEUCRITY_RULE_NUM - len(policiesSet.Egress), nil
 }
 
-func getSecurityGroupNames(providerParams string, securityGroupIds []string) ([]string, error) {
-	logrus.Infof("getSecurityGroupNames: input securityGroupIds=%++v", securityGroupIds)
+func getSecurityGroupNames(ctx context.Context, providerParams string, securityGroupIds []string) ([]string, error) {
+	plugins.Logger(ctx).Infof("getSecurityGroupNames: input securityGroupIds=%++v", securityGroupIds)
 
 	securityGroupNames := []string{}
 	idNameMap := make(map[string]string)
-	securityGroupSet, err := plugins.QuerySecurityGroups(providerParams, securityGroupIds)
+	securityGroupSet, err := plugins.QuerySecurityGroups(ctx, providerParams, securityGroupIds)
 	if err != nil {
-		logrus.Errorf("getSecurityGroupNames QuerySecurityGroups meet error=%v", err)
+		plugins.Logger(ctx).Errorf("getSecurityGroupNames QuerySecurityGroups meet error=%v", err)
 		return securityGroupNames, err
 	}
 
@@ -771,32 +776,32 @@ func getSecurityGroupNames(providerParams string, securityGroupIds []string) ([]
 			securityGroupNames = append(securityGroupNames, name)
 		} else {
 			err := fmt.Errorf("can't found groupId(%s) detail", id)
-			logrus.Errorf("getSecurityGroupNames meet error=%v", err)
+			plugins.Logger(ctx).Errorf("getSecurityGroupNames meet error=%v", err)
 
 			return securityGroupNames, err
 		}
 	}
 
-	logrus.Infof("getSecurityGroupNames: return securityGroupNames=%++v", securityGroupNames)
+	plugins.Logger(ctx).Infof("getSecurityGroupNames: return securityGroupNames=%++v", securityGroupNames)
 	return securityGroupNames, nil
 }
 
 //format ip-auto-2
-func createNewAutomationSecurityGroups(providerParams string, ip string, newCreatedSecurityGroupNum int, auotNumIndex int) ([]string, error) {
-	logrus.Infof("createNewAutomationSecurityGroups: input ip=%v newCreatedSecurityGroupNum=%v auotNumIndex=%v", ip, newCreatedSecurityGroupNum, auotNumIndex)
+func createNewAutomationSecurityGroups(ctx context.Context, providerParams string, ip string, newCreatedSecurityGroupNum int, auotNumIndex int) ([]string, error) {
+	plugins.Logger(ctx).Infof("createNewAutomationSecurityGroups: input ip=%v newCreatedSecurityGroupNum=%v auotNumIndex=%v", ip, newCreatedSecurityGroupNum, auotNumIndex)
 
 	newSecurityGroupIds := []string{}
 	for i := 0; i < newCreatedSecurityGroupNum; i++ {
 		securityGroupName := fmt.Sprintf("%s-auto-%d", ip, auotNumIndex+i)
-		securityGroupId, err := plugins.CreateSecurityGroup(providerParams, securityGroupName, "automation created")
+		securityGroupId, err := plugins.CreateSecurityGroup(ctx, providerParams, securityGroupName, "automation created")
 		if err != nil {
-			logrus.Errorf("createNewAutomationSecurityGroups CreateSecurityGroup meet err=%v", err)
+			plugins.Logger(ctx).Errorf("createNewAutomationSecurityGroups CreateSecurityGroup meet err=%v", err)
 			return newSecurityGroupIds, err
 		}
 		newSecurityGroupIds = append(newSecurityGroupIds, securityGroupId)
 	}
 
-	logrus.Errorf("createNewAutomationSecurityGroups: return newSecurityGroupIds=%++v", newSecurityGroupIds)
+	plugins.Logger(ctx).Errorf("createNewAutomationSecurityGroups: return newSecurityGroupIds=%++v", newSecurityGroupIds)
 	return newSecurityGroupIds, nil
 }
 
@@ -832,8 +837,8 @@ func newSecurityPolicySet(policies []*SecurityPolicy, direction string, isSetPol
 	return securityGroupPolicySet
 }
 
-func addPoliciesToSecurityGroup(providerParams string, securityGroupId string, policies []*SecurityPolicy, direction string) error {
-	logrus.Infof("addPoliciesToSecurityGroup: input securityGroupId=%v policies=%++v direction=%v", securityGroupId, policies, direction)
+func addPoliciesToSecurityGroup(ctx context.Context, providerParams string, securityGroupId string, policies []*SecurityPolicy, direction string) error {
+	plugins.Logger(ctx).Infof("addPoliciesToSecurityGroup: input securityGroupId=%v policies=%++v direction=%v", securityGroupId, policies, direction)
 
 	req := vpc.NewCreateSecurityGroupPoliciesRequest()
 	req.SecurityGroupId = &securityGroupId
@@ -844,7 +849,7 @@ func addPoliciesToSecurityGroup(providerParams string, securityGroupId string, p
 	}
 	defer func() {
 		if err != nil {
-			logrus.Errorf("addPoliciesToSecurityGroup add policy to securityGroup(%s) meet err =%v", securityGroupId, err)
+			plugins.Logger(ctx).Errorf("addPoliciesToSecurityGroup add policy to securityGroup(%s) meet err =%v", securityGroupId, err)
 			errMsg := fmt.Sprintf("addPoliciesToSecurityGroup add policy to securityGroup(%s) meet err =%v", securityGroupId, err)
 			for _, policy := range policies {
 				policy.ErrorMsg = errMsg
@@ -852,10 +857,14 @@ func addPoliciesToSecurityGroup(providerParams string, securityGroupId string, p
 		}
 	}()
 
-	paramsMap, err := plugins.GetMapFromProviderParams(providerParams)
-	client, err := plugins.CreateVpcClient(paramsMap["Region"], paramsMap["SecretID"], paramsMap["SecretKey"])
+	params, err := plugins.ParseProviderParams(providerParams)
+	if err != nil {
+		plugins.Logger(ctx).Errorf("addPoliciesToSecurityGroup ParseProviderParams meet error=%v", err)
+		return err
+	}
+	client, err := plugins.CreateVpcClient(ctx, params)
 	if err != nil {
-		logrus.Errorf("addPoliciesToSecurityGroup CreateVpcClient meet error=%v", err)
+		plugins.Logger(ctx).Errorf("addPoliciesToSecurityGroup CreateVpcClient meet error=%v", err)
 		return err
 	}
 
@@ -870,8 +879,8 @@ func addPoliciesToSecurityGroup(providerParams string, securityGroupId string, p
 	return err
 }
 
-func createPolicies(providerParams string, existSecurityGroups []string, policies []*SecurityPolicy, direction string) ([]string, error) {
-	logrus.Infof("createPolicies: input existSecurityGroups=%++v policies=%++v direction=%v", existSecurityGroups, policies, direction)
+func createPolicies(ctx context.Context, providerParams string, existSecurityGroups []string, policies []*SecurityPolicy, direction string) ([]string, error) {
+	plugins.Logger(ctx).Infof("createPolicies: input existSecurityGroups=%++v policies=%++v direction=%v", existSecurityGroups, policies, direction)
 
 	newSecurityGroups := []string{}
 	freePolicyNumMap := make(map[string]int)
@@ -882,25 +891,25 @@ func createPolicies(providerParams string, existSecurityGroups []string, policie
 		return newSecurityGroups, nil
 	}
 
-	securityGroupsNames, err := getSecurityGroupNames(providerParams, existSecurityGroups)
+	securityGroupsNames, err := getSecurityGroupNames(ctx, providerParams, existSecurityGroups)
 	if err != nil {
-		logrus.Errorf("createPolicies getSecurityGroupNames meet error=%v", err)
+		plugins.Logger(ctx).Errorf("createPolicies getSecurityGroupNames meet error=%v", err)
 		return newSecurityGroups, err
 	}
-	logrus.Infof("createPolicies getSecurityGroupNames: securityGroupsNames:%v", securityGroupsNames)
+	plugins.Logger(ctx).Infof("createPolicies getSecurityGroupNames: securityGroupsNames:%v", securityGroupsNames)
 
 	createdSecurityGroups, autoCreatedStartIndex, err := getAutoCreatedSecurityGroups(policies[0].Ip, securityGroupsNames, existSecurityGroups)
 	if err != nil {
-		logrus.Errorf("createPolicies getAutoCreatedSecurityGroups meet error=%v", err)
+		plugins.Logger(ctx).Errorf("createPolicies getAutoCreatedSecurityGroups meet error=%v", err)
 		return newSecurityGroups, err
 	}
-	logrus.Infof("createPolicies createdSecurityGroups=%v, autoCreatedStartIndex=%v", createdSecurityGroups, autoCreatedStartIndex)
+	plugins.Logger(ctx).Infof("createPolicies createdSecurityGroups=%v, autoCreatedStartIndex=%v", createdSecurityGroups, autoCreatedStartIndex)
 
 	//计算已经存在的安全组中还能插入多少条
 	for _, securityGroup := range createdSecurityGroups {
-		freeNum, err := getSecurityGroupFreePolicyNum(providerParams, securityGroup, direction)
+		freeNum, err := getSecurityGroupFreePolicyNum(ctx, providerParams, securityGroup, direction)
 		if err != nil {
-			logrus.Errorf("createPolicies getSecurityGroupFreePolicyNum meet error=%v", err)
+			plugins.Logger(ctx).Errorf("createPolicies getSecurityGroupFreePolicyNum meet error=%v", err)
 			return newSecurityGroups, err
 		}
 		freePolicyNumMap[securityGroup] = freeNum
@@ -911,12 +920,12 @@ func createPolicies(providerParams string, existSecurityGroups []string, policie
 	//计算需要新创建几个安全组
 	if freePoliciesNum < len(policies) {
 		newSecurityGroupNum := (len(policies) - freePoliciesNum + MAX_SEUCRITY_RULE_NUM - 1) / MAX_SEUCRITY_RULE_NUM
-		newSecurityGroups, err = createNewAutomationSecurityGroups(providerParams, policies[0].Ip, newSecurityGroupNum, autoCreatedStartIndex)
+		newSecurityGroups, err = createNewAutomationSecurityGroups(ctx, providerParams, policies[0].Ip, newSecurityGroupNum, autoCreatedStartIndex)
 		if err != nil {
-			logrus.Errorf("createPolicies createNewAutomationSecurityGroups meet error=%v", err)
+			plugins.Logger(ctx).Errorf("createPolicies createNewAutomationSecurityGroups meet error=%v", err)
 			return newSecurityGroups, err
 		}
-		logrus.Infof("createPolicies newSecurityGroups=%v", newSecurityGroups)
+		plugins.Logger(ctx).Infof("createPolicies newSecurityGroups=%v", newSecurityGroups)
 		securityGroupsIds = append(securityGroupsIds, newSecurityGroups...)
 
 		for _, securityGroup := range newSecurityGroups {
@@ -924,7 +933,7 @@ func createPolicies(providerParams string, existSecurityGroups []string, policie
 		}
 	}
 
-	logrus.Infof("createPolicies freePolicyNumMap=%v", freePolicyNumMap)
+	plugins.Logger(ctx).Infof("createPolicies freePolicyNumMap=%v", freePolicyNumMap)
 	//开始将策略加到安全组中
 	offset, limit := 0, 0
 
@@ -936,8 +945,8 @@ func createPolicies(providerParams string, existSecurityGroups []string, policie
 		} else {
 			limit = len(policies) - offset
 		}
-		if err := addPoliciesToSecurityGroup(providerParams, securityGroupId, policies[offset:offset+limit], direction); err != nil {
-			logrus.Errorf("createPolicies addPoliciesToSecurityGroup meet error=%v", err)
+		if err := addPoliciesToSecurityGroup(ctx, providerParams, securityGroupId, policies[offset:offset+limit], direction); err != nil {
+			plugins.Logger(ctx).Errorf("createPolicies addPoliciesToSecurityGroup meet error=%v", err)
 			return newSecurityGroups, err
 		}
 
@@ -947,23 +956,27 @@ func createPolicies(providerParams string, existSecurityGroups []string, policie
 		offset += limit
 	}
 
-	logrus.Infof("createPolicies: return newSecurityGroups=%++v", newSecurityGroups)
+	plugins.Logger(ctx).Infof("createPolicies: return newSecurityGroups=%++v", newSecurityGroups)
 	return newSecurityGroups, nil
 }
 
-func destroyPolicies(providerParams string, policies []*SecurityPolicy, direction string) error {
-	logrus.Infof("destroyPolicies: input policies=%++v direction=%v", policies, direction)
+func destroyPolicies(ctx context.Context, providerParams string, policies []*SecurityPolicy, direction string) error {
+	plugins.Logger(ctx).Infof("destroyPolicies: input policies=%++v direction=%v", policies, direction)
 
 	securityGroupMap := make(map[string][]*SecurityPolicy)
 	for _, policy := range policies {
 		securityGroupMap[policy.SecurityGroupId] = append(securityGroupMap[policy.SecurityGroupId], policy)
-		logrus.Infof("destroyPolicies policy=%++v", *policy)
+		plugins.Logger(ctx).Infof("destroyPolicies policy=%++v", *policy)
 	}
 
-	paramsMap, err := plugins.GetMapFromProviderParams(providerParams)
-	client, err := plugins.CreateVpcClient(paramsMap["Region"], paramsMap["SecretID"], paramsMap["SecretKey"])
+	params, err := plugins.ParseProviderParams(providerParams)
+	if err != nil {
+		plugins.Logger(ctx).Errorf("destroyPolicies ParseProviderParams meet error=%v", err)
+		return err
+	}
+	client, err := plugins.CreateVpcClient(ctx, params)
 	if err != nil {
-		logrus.Errorf("destroyPolicies CreateVpcClient meet error=%v", err)
+		plugins.Logger(ctx).Errorf("destroyPolicies CreateVpcClient meet error=%v", err)
 		return err
 	}
 
@@ -975,7 +988,7 @@ func destroyPolicies(providerParams string, policies []*SecurityPolicy, directio
 
 		_, err := client.DeleteSecurityGroupPolicies(req)
 		if err != nil {
-			logrus.Errorf("destroyPolicies DeleteSecurityGroupPolicies meet error=%v,req=%++v", err, *req)
+			plugins.Logger(ctx).Errorf("destroyPolicies DeleteSecurityGroupPolicies meet error=%v,req=%++v", err, *req)
 			return err
 		}
 	}
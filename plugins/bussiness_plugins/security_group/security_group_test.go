@@ -1,6 +1,7 @@
 package securitygroup
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
@@ -142,7 +143,7 @@ func TestDestroyPolicies(t *testing.T) {
 		},
 	}
 	direction := "ingress"
-	err := destroyPolicies(providerParams, policies, direction)
+	err := destroyPolicies(context.Background(), providerParams, policies, direction)
 	if err != nil {
 		t.Errorf("failed %v", err)
 		return
@@ -0,0 +1,229 @@
+package plugins
+
+import (
+	"context"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+)
+
+// FieldSchema is a field of the inputs or outputs of an action, named by its json name.
+type FieldSchema struct {
+	Name string `json:"name"`
+	// Type is one of string, integer, number, boolean, array and object.
+	Type string `json:"type"`
+	// Items is the type of the items of an array.
+	Items     string `json:"items,omitempty"`
+	Sensitive bool   `json:"sensitive,omitempty"`
+	// Enum are the valid values of the field, named by its "enum" tag.
+	Enum []string `json:"enum,omitempty"`
+	// Fields are the fields of an object or of the items of an array of objects.
+	Fields []FieldSchema `json:"fields,omitempty"`
+}
+
+type ActionCatalog struct {
+	Name string `json:"name"`
+	// Inputs are the fields of one item of "inputs", or of the request if the action does not take a list of inputs.
+	Inputs []FieldSchema `json:"inputs"`
+	// Outputs are the fields of one item of "outputs", they are empty if the action does not return a list of outputs.
+	Outputs []FieldSchema `json:"outputs,omitempty"`
+
+	// listInputs is true if the action takes a list of inputs.
+	listInputs bool
+}
+
+type PluginCatalog struct {
+	Name    string          `json:"name"`
+	Actions []ActionCatalog `json:"actions"`
+}
+
+// the schemas of the actions never change, they are derived once, keyed by "{plugin}.{action}".
+var (
+	actionCatalogsMutex sync.Mutex
+	actionCatalogs      = make(map[string]ActionCatalog)
+)
+
+// the depth of nested objects in schemas, deeper fields are described as objects without fields.
+const MAX_SCHEMA_DEPTH = 5
+
+// ENUM_TAG names the valid values of a field in fieldEnums, e.g. `enum:"charge_type"`.
+const ENUM_TAG = "enum"
+
+// fieldEnums are the valid values checked by the actions, keyed by the enum tag of the fields.
+var fieldEnums = map[string][]string{
+	"charge_type":            chargeTypes,
+	"lb_type":                lbTypes,
+	"clb_protocol":           clbProtocols,
+	"route_gateway_type":     routeGatewayTypes,
+	"mysql_instance_role":    mysqlInstanceRoles,
+	"mysql_charset":          mysqlCharsets,
+	"lower_case_table_names": lowerCaseTableNames,
+	"mariadb_version":        mariadbVersions,
+	"file_system_type":       fileSystemTypes,
+	"security_policy_type":   securityPolicyTypes,
+	"security_policy_action": securityPolicyActions,
+}
+
+// GetCatalog returns the registered plugins and their actions sorted by name, with the fields of their inputs and outputs.
+func GetCatalog() []PluginCatalog {
+	pluginsMutex.Lock()
+	names := []string{}
+	registered := make(map[string]Plugin)
+	for name, plugin := range plugins {
+		names = append(names, name)
+		registered[name] = plugin
+	}
+	pluginsMutex.Unlock()
+	sort.Strings(names)
+
+	catalogs := []PluginCatalog{}
+	for _, name := range names {
+		actions := registered[name].GetActions()
+		actionNames := []string{}
+		for actionName := range actions {
+			actionNames = append(actionNames, actionName)
+		}
+		sort.Strings(actionNames)
+
+		catalog := PluginCatalog{Name: name, Actions: []ActionCatalog{}}
+		for _, actionName := range actionNames {
+			catalog.Actions = append(catalog.Actions, getActionCatalog(name, actionName, actions[actionName]))
+		}
+		catalogs = append(catalogs, catalog)
+	}
+	return catalogs
+}
+
+func getActionCatalog(pluginName, actionName string, action Action) ActionCatalog {
+	key := pluginName + "." + actionName
+	actionCatalogsMutex.Lock()
+	defer actionCatalogsMutex.Unlock()
+
+	if catalog, found := actionCatalogs[key]; found {
+		return catalog
+	}
+	catalog := ActionCatalog{Name: actionName, Inputs: []FieldSchema{}}
+	param, results := getActionSamples(key, action)
+	if inputs := getSliceField(param, "Inputs"); inputs.IsValid() {
+		catalog.listInputs = true
+		catalog.Inputs = getFieldSchemas(inputs.Type().Elem(), 0)
+		if outputs := getSliceField(results, "Outputs"); outputs.IsValid() {
+			catalog.Outputs = getFieldSchemas(outputs.Type().Elem(), 0)
+		}
+	} else if param != nil {
+		catalog.Inputs = getFieldSchemas(reflect.TypeOf(param), 0)
+	}
+	actionCatalogs[key] = catalog
+	return catalog
+}
+
+// getActionSamples returns the param of the action read from an empty request, and the results of running
+// the action with no inputs, which tell the type of the outputs. The action is only run if it takes a list
+// of inputs, in dry run mode with a canceled context, so no cloud API request is sent.
+func getActionSamples(key string, action Action) (param interface{}, results interface{}) {
+	defer func() {
+		if r := recover(); r != nil {
+			logrus.Warnf("derive the schema of action %v meet panic: %v", key, r)
+		}
+	}()
+
+	param = readSampleParam(key, action)
+	inputs := getSliceField(param, "Inputs")
+	if !inputs.IsValid() || inputs.Len() > 0 {
+		return param, nil
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	ctx, _ = WithDryRun(ctx, nil)
+	results, _ = action.Do(ctx, param)
+	return param, results
+}
+
+// readSampleParam returns the param which the action reads from an empty request, whose types are the inputs of the action.
+func readSampleParam(key string, action Action) interface{} {
+	defer func() {
+		if r := recover(); r != nil {
+			logrus.Warnf("read the param of action %v meet panic: %v", key, r)
+		}
+	}()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	param, err := action.ReadParam(ctx, strings.NewReader("{}"))
+	if err != nil {
+		logrus.Warnf("derive the schema of action %v meet error=%v", key, err)
+		return nil
+	}
+	return param
+}
+
+// getFieldSchemas returns the schemas of the fields of a struct, fields of embedded structs are promoted as json does.
+func getFieldSchemas(t reflect.Type, depth int) []FieldSchema {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	schemas := []FieldSchema{}
+	if t.Kind() != reflect.Struct {
+		return schemas
+	}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name := strings.Split(field.Tag.Get("json"), ",")[0]
+		if name == "-" || field.PkgPath != "" {
+			continue
+		}
+		if field.Anonymous && name == "" && indirectType(field.Type).Kind() == reflect.Struct {
+			schemas = append(schemas, getFieldSchemas(field.Type, depth)...)
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		schema := FieldSchema{Name: name, Type: getSchemaType(field.Type)}
+		schema.Sensitive = isSensitiveField(name, getSensitiveFields()) || isSensitiveField(name, providerParamsFields)
+		if enumName := field.Tag.Get(ENUM_TAG); enumName != "" {
+			enum, found := fieldEnums[enumName]
+			if !found {
+				logrus.Warnf("enum %v of field %v is not found", enumName, name)
+			}
+			schema.Enum = enum
+		}
+		elemType := indirectType(field.Type)
+		if schema.Type == "array" {
+			elemType = indirectType(elemType.Elem())
+			schema.Items = getSchemaType(elemType)
+		}
+		if elemType.Kind() == reflect.Struct && depth < MAX_SCHEMA_DEPTH {
+			schema.Fields = getFieldSchemas(elemType, depth+1)
+		}
+		schemas = append(schemas, schema)
+	}
+	return schemas
+}
+
+func indirectType(t reflect.Type) reflect.Type {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t
+}
+
+func getSchemaType(t reflect.Type) string {
+	switch indirectType(t).Kind() {
+	case reflect.String:
+		return "string"
+	case reflect.Bool:
+		return "boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "integer"
+	case reflect.Float32, reflect.Float64:
+		return "number"
+	case reflect.Slice, reflect.Array:
+		return "array"
+	}
+	return "object"
+}
@@ -0,0 +1,85 @@
+package plugins
+
+import (
+	"reflect"
+	"testing"
+)
+
+func findFieldSchema(schemas []FieldSchema, name string) *FieldSchema {
+	for i := range schemas {
+		if schemas[i].Name == name {
+			return &schemas[i]
+		}
+	}
+	return nil
+}
+
+func TestGetCatalog(t *testing.T) {
+	var vpcCatalog *PluginCatalog
+	catalogs := GetCatalog()
+	for i := range catalogs {
+		if i > 0 && catalogs[i-1].Name >= catalogs[i].Name {
+			t.Errorf("plugins are not sorted, %v before %v", catalogs[i-1].Name, catalogs[i].Name)
+		}
+		if catalogs[i].Name == "vpc" {
+			vpcCatalog = &catalogs[i]
+		}
+	}
+	if vpcCatalog == nil {
+		t.Fatalf("vpc is not in the catalog")
+	}
+
+	var createCatalog *ActionCatalog
+	for i := range vpcCatalog.Actions {
+		if vpcCatalog.Actions[i].Name == "create" {
+			createCatalog = &vpcCatalog.Actions[i]
+		}
+	}
+	if createCatalog == nil {
+		t.Fatalf("vpc create is not in the catalog, actions=%+v", vpcCatalog.Actions)
+	}
+
+	for _, name := range []string{"callbackParameter", "guid", "cidr_block"} {
+		if schema := findFieldSchema(createCatalog.Inputs, name); schema == nil || schema.Type != "string" || schema.Sensitive {
+			t.Errorf("input %v, schema=%+v", name, schema)
+		}
+	}
+	if schema := findFieldSchema(createCatalog.Inputs, "provider_params"); schema == nil || !schema.Sensitive {
+		t.Errorf("input provider_params, schema=%+v", schema)
+	}
+	for _, name := range []string{"id", "route_table_id", "errorCode"} {
+		if schema := findFieldSchema(createCatalog.Outputs, name); schema == nil || schema.Type != "string" {
+			t.Errorf("output %v, schema=%+v", name, schema)
+		}
+	}
+}
+
+func TestGetFieldSchemas(t *testing.T) {
+	type Rule struct {
+		Port     int    `json:"port"`
+		Password string `json:"password"`
+	}
+	type Input struct {
+		Rules   []*Rule  `json:"rules"`
+		Tags    []string `json:"tags,omitempty"`
+		Enabled *bool    `json:"enabled"`
+		Ignored string   `json:"-"`
+		ratio   float64
+	}
+
+	schemas := getFieldSchemas(reflect.TypeOf(&Input{}), 0)
+	if len(schemas) != 3 {
+		t.Fatalf("schemas=%+v", schemas)
+	}
+	rules := schemas[0]
+	if rules.Name != "rules" || rules.Type != "array" || rules.Items != "object" || len(rules.Fields) != 2 ||
+		rules.Fields[0].Type != "integer" || !rules.Fields[1].Sensitive {
+		t.Errorf("rules=%+v", rules)
+	}
+	if tags := schemas[1]; tags.Name != "tags" || tags.Type != "array" || tags.Items != "string" || len(tags.Fields) != 0 {
+		t.Errorf("tags=%+v", tags)
+	}
+	if enabled := schemas[2]; enabled.Name != "enabled" || enabled.Type != "boolean" {
+		t.Errorf("enabled=%+v", enabled)
+	}
+}
@@ -2,6 +2,7 @@ package plugins
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -17,6 +18,9 @@ import (
 
 var cbsActions = make(map[string]Action)
 
+// fileSystemTypes are the file systems the disks are formatted with.
+var fileSystemTypes = []string{"ext3", "ext4", "xfs"}
+
 //将监听器藏起来
 func init() {
 	cbsActions["create-mount"] = new(CreateAndMountCbsDiskAction)
@@ -34,6 +38,10 @@ func (plugin *CbsPlugin) GetActionByName(actionName string) (Action, error) {
 	return action, nil
 }
 
+func (plugin *CbsPlugin) GetActions() map[string]Action {
+	return cbsActions
+}
+
 type CreateAndMountCbsDiskAction struct {
 }
 
@@ -43,24 +51,25 @@ type CreateAndMountCbsDiskInputs struct {
 
 type CreateAndMountCbsDiskInput struct {
 	CallBackParameter
-	Guid             string `json:"guid,omitempty"`
-	ProviderParams   string `json:"provider_params,omitempty"`
-	DiskType         string `json:"disk_type,omitempty"`
-	DiskSize         string `json:"disk_size,omitempty"`
-	DiskName         string `json:"disk_name,omitempty"`
-	Id               string `json:"id,omitempty"`
-	DiskChargeType   string `json:"disk_charge_type,omitempty"`
-	DiskChargePeriod string `json:"disk_charge_period,omitempty"`
-	Location         string `json:"location"`
-	APISecret        string `json:"api_secret"`
+	WaitParameter
+	Guid             string `json:"guid,omitempty" register:"required"`
+	ProviderParams   string `json:"provider_params,omitempty" register:"optional"`
+	DiskType         string `json:"disk_type,omitempty" register:"required"`
+	DiskSize         string `json:"disk_size,omitempty" register:"required"`
+	DiskName         string `json:"disk_name,omitempty" register:"optional"`
+	Id               string `json:"id,omitempty" register:"optional"`
+	DiskChargeType   string `json:"disk_charge_type,omitempty" register:"required"`
+	DiskChargePeriod string `json:"disk_charge_period,omitempty" register:"optional"`
+	Location         string `json:"location" register:"required"`
+	APISecret        string `json:"api_secret" register:"required,system_variable=QCLOUD_API_SECRET"`
 
 	//use to attch and format
-	InstanceId       string `json:"instance_id,omitempty"`
-	InstanceGuid     string `json:"instance_guid,omitempty"`
-	InstanceSeed     string `json:"seed,omitempty"`
-	InstancePassword string `json:"password,omitempty"`
-	FileSystemType   string `json:"file_system_type,omitempty"`
-	MountDir         string `json:"mount_dir,omitempty"`
+	InstanceId       string `json:"instance_id,omitempty" register:"required"`
+	InstanceGuid     string `json:"instance_guid,omitempty" register:"required"`
+	InstanceSeed     string `json:"seed,omitempty" register:"required,system_variable=ENCRYPT_SEED"`
+	InstancePassword string `json:"password,omitempty" register:"required"`
+	FileSystemType   string `json:"file_system_type,omitempty" register:"required" enum:"file_system_type"`
+	MountDir         string `json:"mount_dir,omitempty" register:"required"`
 }
 
 type CreateAndMountCbsDiskOutputs struct {
@@ -70,12 +79,12 @@ type CreateAndMountCbsDiskOutputs struct {
 type CreateAndMountCbsDiskOutput struct {
 	CallBackParameter
 	Result
-	Guid       string `json:"guid,omitempty"`
-	VolumeName string `json:"volume_name,omitempty"`
-	DiskId     string `json:"disk_id,omitempty"`
+	Guid       string `json:"guid,omitempty" register:"optional"`
+	VolumeName string `json:"volume_name,omitempty" register:"optional"`
+	DiskId     string `json:"disk_id,omitempty" register:"optional"`
 }
 
-func (action *CreateAndMountCbsDiskAction) ReadParam(param interface{}) (interface{}, error) {
+func (action *CreateAndMountCbsDiskAction) ReadParam(ctx context.Context, param interface{}) (interface{}, error) {
 	var inputs CreateAndMountCbsDiskInputs
 	err := UnmarshalJson(param, &inputs)
 	if err != nil {
@@ -113,13 +122,13 @@ func checkParam(input CreateAndMountCbsDiskInput) error {
 		return errors.New(" mountDir is empty")
 	}
 
-	if err := IsValidValue(input.FileSystemType, []string{"ext3", "ext4", "xfs"}); err != nil {
+	if err := IsValidValue(input.FileSystemType, fileSystemTypes); err != nil {
 		return fmt.Errorf("%s is not valid file system type", input.FileSystemType)
 	}
 	return nil
 }
 
-func buyCbsAndAttachToVm(input CreateAndMountCbsDiskInput) (string, error) {
+func buyCbsAndAttachToVm(ctx context.Context, input CreateAndMountCbsDiskInput) (string, error) {
 	storageAction := StorageCreateAction{}
 
 	storageInput := StorageInput{
@@ -140,7 +149,7 @@ func buyCbsAndAttachToVm(input CreateAndMountCbsDiskInput) (string, error) {
 	storageInputs := StorageInputs{}
 	storageInputs.Inputs = append(storageInputs.Inputs, storageInput)
 
-	outputs, err := storageAction.Do(storageInputs)
+	outputs, err := storageAction.Do(ctx, storageInputs)
 	if err != nil {
 		return "", err
 	}
@@ -155,13 +164,13 @@ func buyCbsAndAttachToVm(input CreateAndMountCbsDiskInput) (string, error) {
 	return storageOutputs.Outputs[0].Id, nil
 }
 
-func getInstancePrivateIp(providerParam string, instanceId string) (string, error) {
+func getInstancePrivateIp(ctx context.Context, providerParam string, instanceId string) (string, error) {
 	filter := Filter{
 		Name:   "instanceId",
 		Values: []string{instanceId},
 	}
 
-	items, err := QueryCvmInstance(providerParam, filter)
+	items, err := QueryCvmInstance(ctx, providerParam, filter)
 	if err != nil {
 		return "", err
 	}
@@ -281,17 +290,17 @@ func formatAndMountDisk(ip, password, volumeName, fileSystemType, mountDir strin
 	return err
 }
 
-func getNewCreateDiskVolumeName(ip, password string, lastUnformatedDisks []string) (string, error) {
-	lastUnformatedDiskNum := len(lastUnformatedDisks)
+func getNewCreateDiskVolumeName(ctx context.Context, ip, password string, lastUnformatedDisks []string, timeout string) (string, error) {
+	waiter, err := NewWaiter(WAIT_RESOURCE_DISK, ip, timeout)
+	if err != nil {
+		return "", err
+	}
 
-	for i := 0; i < 20; i++ {
+	newVolumeName := ""
+	err = waiter.Wait(ctx, func() (string, bool, error) {
 		newDisks, err := getUnformatDisks(ip, password)
 		if err != nil {
-			return "", err
-		}
-		if len(newDisks) == lastUnformatedDiskNum {
-			time.Sleep(5 * time.Second)
-			continue
+			return "", false, err
 		}
 		for _, volumeName := range newDisks {
 			bFind := false
@@ -302,40 +311,40 @@ func getNewCreateDiskVolumeName(ip, password string, lastUnformatedDisks []strin
 				}
 			}
 			if bFind == false {
-				return volumeName, nil
+				newVolumeName = volumeName
+				return "", true, nil
 			}
 		}
-	}
-
-	return "", errors.New("getNewCreateDiskVolumeName timeout")
+		return "", false, nil
+	})
+	return newVolumeName, err
 }
 
-func createAndMountCbsDisk(input CreateAndMountCbsDiskInput) (output CreateAndMountCbsDiskOutput, err error) {
+func createAndMountCbsDisk(ctx context.Context, input CreateAndMountCbsDiskInput) (output CreateAndMountCbsDiskOutput, err error) {
 	output.Guid = input.Guid
 	output.CallBackParameter.Parameter = input.CallBackParameter.Parameter
 	defer func() {
 		if err == nil {
 			output.Result.Code = RESULT_CODE_SUCCESS
 		} else {
-			output.Result.Code = RESULT_CODE_ERROR
-			output.Result.Message = err.Error()
+			output.Result.SetError(err)
 		}
 	}()
 
-	if err = checkParam(input); err != nil {
+	if err = AsValidationError(checkParam(input)); err != nil {
 		return output, err
 	}
-	if input.Location != "" && input.APISecret != "" {
-		input.ProviderParams = fmt.Sprintf("%s;%s", input.Location, input.APISecret)
+	if input.ProviderParams, err = mergeProviderParams(input.ProviderParams, input.Location, input.APISecret); err != nil {
+		return output, err
 	}
-	privateIp, err := getInstancePrivateIp(input.ProviderParams, input.InstanceId)
+	privateIp, err := getInstancePrivateIp(ctx, input.ProviderParams, input.InstanceId)
 	if err != nil {
 		return output, err
 	}
 
 	password, err := utils.AesDePassword(input.InstanceGuid, input.InstanceSeed, input.InstancePassword)
 	if err != nil {
-		logrus.Errorf("AesDePassword meet error(%v)", err)
+		Logger(ctx).Errorf("AesDePassword meet error(%v)", err)
 		return output, err
 	}
 
@@ -346,12 +355,12 @@ func createAndMountCbsDisk(input CreateAndMountCbsDiskInput) (output CreateAndMo
 	}
 
 	//buy and attach disk to vm
-	output.DiskId, err = buyCbsAndAttachToVm(input)
+	output.DiskId, err = buyCbsAndAttachToVm(ctx, input)
 	if err != nil {
 		return output, err
 	}
 
-	output.VolumeName, err = getNewCreateDiskVolumeName(privateIp, password, oldUnformatDisks)
+	output.VolumeName, err = getNewCreateDiskVolumeName(ctx, privateIp, password, oldUnformatDisks, input.WaitTimeout)
 	if err != nil {
 		return output, err
 	}
@@ -359,23 +368,22 @@ func createAndMountCbsDisk(input CreateAndMountCbsDiskInput) (output CreateAndMo
 	//format and mount
 	err = formatAndMountDisk(privateIp, password, output.VolumeName, input.FileSystemType, input.MountDir)
 	if err != nil {
-		logrus.Errorf("formatAndMountDisk meet err=%v", err)
+		Logger(ctx).Errorf("formatAndMountDisk meet err=%v", err)
 	}
 	return output, err
 }
 
-func (action *CreateAndMountCbsDiskAction) Do(input interface{}) (interface{}, error) {
+func (action *CreateAndMountCbsDiskAction) Do(ctx context.Context, input interface{}) (interface{}, error) {
 	inputs, _ := input.(CreateAndMountCbsDiskInputs)
-	outputs := CreateAndMountCbsDiskOutputs{}
-	var finalErr error
-
-	for _, input := range inputs.Inputs {
-		output, err := createAndMountCbsDisk(input)
-		if err != nil {
-			finalErr = err
-		}
-		outputs.Outputs = append(outputs.Outputs, output)
-	}
+	outputs := CreateAndMountCbsDiskOutputs{Outputs: make([]CreateAndMountCbsDiskOutput, len(inputs.Inputs))}
+	finalErr := runInputs(ctx, len(inputs.Inputs), func(i int) []string {
+		return []string{inputs.Inputs[i].Guid, inputs.Inputs[i].Id, inputs.Inputs[i].InstanceId}
+	}, func(ctx context.Context, i int) error {
+		input := inputs.Inputs[i]
+		output, err := createAndMountCbsDisk(ctx, input)
+		outputs.Outputs[i] = output
+		return err
+	})
 
 	return outputs, finalErr
 }
@@ -390,19 +398,19 @@ type UmountCbsDiskInputs struct {
 
 type UmountCbsDiskInput struct {
 	CallBackParameter
-	Guid           string `json:"guid,omitempty"`
-	ProviderParams string `json:"provider_params,omitempty"`
-	Id             string `json:"id,omitempty"`
-	VolumeName     string `json:"volume_name,omitempty"`
-	MountDir       string `json:"mount_dir,omitempty"`
-	Location       string `json:"location"`
-	APISecret      string `json:"api_secret"`
+	Guid           string `json:"guid,omitempty" register:"required"`
+	ProviderParams string `json:"provider_params,omitempty" register:"optional"`
+	Id             string `json:"id,omitempty" register:"required"`
+	VolumeName     string `json:"volume_name,omitempty" register:"required"`
+	MountDir       string `json:"mount_dir,omitempty" register:"required"`
+	Location       string `json:"location" register:"required"`
+	APISecret      string `json:"api_secret" register:"required,system_variable=QCLOUD_API_SECRET"`
 
 	//use to attch and format
-	InstanceId       string `json:"instance_id,omitempty"`
-	InstanceGuid     string `json:"instance_guid,omitempty"`
-	InstanceSeed     string `json:"seed,omitempty"`
-	InstancePassword string `json:"password,omitempty"`
+	InstanceId       string `json:"instance_id,omitempty" register:"required"`
+	InstanceGuid     string `json:"instance_guid,omitempty" register:"required"`
+	InstanceSeed     string `json:"seed,omitempty" register:"required,system_variable=ENCRYPT_SEED"`
+	InstancePassword string `json:"password,omitempty" register:"required"`
 }
 
 type UmountCbsDiskOutputs struct {
@@ -412,10 +420,10 @@ type UmountCbsDiskOutputs struct {
 type UmountCbsDiskOutput struct {
 	CallBackParameter
 	Result
-	Guid string `json:"guid,omitempty"`
+	Guid string `json:"guid,omitempty" register:"optional"`
 }
 
-func (action *UmountAndTerminateDiskAction) ReadParam(param interface{}) (interface{}, error) {
+func (action *UmountAndTerminateDiskAction) ReadParam(ctx context.Context, param interface{}) (interface{}, error) {
 	var inputs UmountCbsDiskInputs
 	err := UnmarshalJson(param, &inputs)
 	if err != nil {
@@ -462,7 +470,7 @@ func umountDisk(ip, password, volumeName, mountDir string) error {
 	return err
 }
 
-func terminateDisk(providerParams, id string) error {
+func terminateDisk(ctx context.Context, providerParams, id string) error {
 	action := StorageTerminateAction{}
 	input := StorageInput{
 		ProviderParams: providerParams,
@@ -471,25 +479,26 @@ func terminateDisk(providerParams, id string) error {
 
 	inputs := StorageInputs{}
 	inputs.Inputs = append(inputs.Inputs, input)
-	_, err := action.Do(inputs)
+	_, err := action.Do(ctx, inputs)
 	return err
 }
 
-func umountAndTerminateCbsDisk(input UmountCbsDiskInput) error {
+func umountAndTerminateCbsDisk(ctx context.Context, input UmountCbsDiskInput) error {
 	if err := checkUmountDiskParam(input); err != nil {
 		return err
 	}
-	if input.Location != "" && input.APISecret != "" {
-		input.ProviderParams = fmt.Sprintf("%s;%s", input.Location, input.APISecret)
+	var err error
+	if input.ProviderParams, err = mergeProviderParams(input.ProviderParams, input.Location, input.APISecret); err != nil {
+		return err
 	}
-	privateIp, err := getInstancePrivateIp(input.ProviderParams, input.InstanceId)
+	privateIp, err := getInstancePrivateIp(ctx, input.ProviderParams, input.InstanceId)
 	if err != nil {
 		return err
 	}
 
 	password, err := utils.AesDePassword(input.InstanceGuid, input.InstanceSeed, input.InstancePassword)
 	if err != nil {
-		logrus.Errorf("AesDePassword meet error(%v)", err)
+		Logger(ctx).Errorf("AesDePassword meet error(%v)", err)
 		return err
 	}
 
@@ -497,29 +506,31 @@ func umountAndTerminateCbsDisk(input UmountCbsDiskInput) error {
 		return err
 	}
 
-	return terminateDisk(input.ProviderParams, input.Id)
+	return terminateDisk(ctx, input.ProviderParams, input.Id)
 }
 
-func (action *UmountAndTerminateDiskAction) Do(input interface{}) (interface{}, error) {
+func (action *UmountAndTerminateDiskAction) Do(ctx context.Context, input interface{}) (interface{}, error) {
 	inputs, _ := input.(UmountCbsDiskInputs)
-	outputs := UmountCbsDiskOutputs{}
-	var finalErr error
-
-	for _, input := range inputs.Inputs {
+	outputs := UmountCbsDiskOutputs{Outputs: make([]UmountCbsDiskOutput, len(inputs.Inputs))}
+	finalErr := runInputs(ctx, len(inputs.Inputs), func(i int) []string {
+		return []string{inputs.Inputs[i].Guid, inputs.Inputs[i].Id, inputs.Inputs[i].InstanceId}
+	}, func(ctx context.Context, i int) error {
+		input := inputs.Inputs[i]
 		output := UmountCbsDiskOutput{
 			Guid: input.Guid,
 		}
 		output.CallBackParameter.Parameter = input.CallBackParameter.Parameter
 		output.Result.Code = RESULT_CODE_SUCCESS
 
-		if err := umountAndTerminateCbsDisk(input); err != nil {
-			output.Result.Code = RESULT_CODE_ERROR
-			output.Result.Message = err.Error()
-			finalErr = err
+		if err := umountAndTerminateCbsDisk(ctx, input); err != nil {
+			output.Result.SetError(err)
+			outputs.Outputs[i] = output
+			return err
 		}
 
-		outputs.Outputs = append(outputs.Outputs, output)
-	}
+		outputs.Outputs[i] = output
+		return nil
+	})
 
 	return outputs, finalErr
 }
@@ -1,14 +1,13 @@
 package plugins
 
 import (
+	"context"
 	"errors"
 	"fmt"
-	"time"
+	"strconv"
 
 	"github.com/sirupsen/logrus"
 	clb "github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/clb/v20180317"
-	"github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/common"
-	"github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/common/profile"
 )
 
 const (
@@ -16,6 +15,8 @@ const (
 	LB_TYPE_INTERNAL = "internal_lb"
 )
 
+var lbTypes = []string{LB_TYPE_EXTERNAL, LB_TYPE_INTERNAL}
+
 var clbActions = make(map[string]Action)
 
 //将监听器藏起来
@@ -24,13 +25,8 @@ func init() {
 	clbActions["terminate"] = new(TerminateClbAction)
 }
 
-func createClbClient(region, secretId, secretKey string) (client *clb.Client, err error) {
-	credential := common.NewCredential(secretId, secretKey)
-
-	clientProfile := profile.NewClientProfile()
-	clientProfile.HttpProfile.Endpoint = "clb.tencentcloudapi.com"
-
-	return clb.NewClient(credential, region, clientProfile)
+func createClbClient(ctx context.Context, params *ProviderParams) (client *clb.Client, err error) {
+	return GetClientFactory().WithContext(ctx).WithProviderParams(params).NewClbClient(params.Region, params.SecretID, params.SecretKey)
 }
 
 type ClbPlugin struct {
@@ -45,6 +41,10 @@ func (plugin *ClbPlugin) GetActionByName(actionName string) (Action, error) {
 	return action, nil
 }
 
+func (plugin *ClbPlugin) GetActions() map[string]Action {
+	return clbActions
+}
+
 type CreateClbAction struct {
 }
 
@@ -54,15 +54,16 @@ type CreateClbInputs struct {
 
 type CreateClbInput struct {
 	CallBackParameter
-	Guid           string `json:"guid"`
-	ProviderParams string `json:"provider_params"`
-	Name           string `json:"name"`
-	Type           string `json:"type"`
-	VpcId          string `json:"vpc_id"`
-	SubnetId       string `json:"subnet_id"`
-	Id             string `json:"id"`
-	Location       string `json:"location"`
-	APISecret      string `json:"api_secret"`
+	WaitParameter
+	Guid           string `json:"guid" register:"required"`
+	ProviderParams string `json:"provider_params" register:"optional"`
+	Name           string `json:"name" register:"optional"`
+	Type           string `json:"type" register:"required" enum:"lb_type"`
+	VpcId          string `json:"vpc_id" register:"required"`
+	SubnetId       string `json:"subnet_id" register:"required"`
+	Id             string `json:"id" register:"optional"`
+	Location       string `json:"location" register:"required"`
+	APISecret      string `json:"api_secret" register:"required,system_variable=QCLOUD_API_SECRET"`
 }
 
 type CreateClbOutputs struct {
@@ -72,12 +73,12 @@ type CreateClbOutputs struct {
 type CreateClbOutput struct {
 	CallBackParameter
 	Result
-	Guid string `json:"guid,omitempty"`
-	Id   string `json:"id,omitempty"`
-	Vip  string `json:"vip,omitempty"`
+	Guid string `json:"guid,omitempty" register:"optional"`
+	Id   string `json:"id,omitempty" register:"optional"`
+	Vip  string `json:"vip,omitempty" register:"optional"`
 }
 
-func (action *CreateClbAction) ReadParam(param interface{}) (interface{}, error) {
+func (action *CreateClbAction) ReadParam(ctx context.Context, param interface{}) (interface{}, error) {
 	var inputs CreateClbInputs
 	err := UnmarshalJson(param, &inputs)
 	if err != nil {
@@ -102,7 +103,7 @@ func createClbCheckParam(input CreateClbInput) error {
 		return errors.New("VpcId is empty")
 	}
 
-	if input.Type != LB_TYPE_EXTERNAL && input.Type != LB_TYPE_INTERNAL {
+	if err := IsValidValue(input.Type, lbTypes); err != nil {
 		return fmt.Errorf("invalid lbType(%v)", input.Type)
 	}
 	if input.Type == LB_TYPE_INTERNAL && input.SubnetId == "" {
@@ -159,25 +160,30 @@ func getLoadBalanceType(lbType string) (string, error) {
 	return "", fmt.Errorf("%s is invalid lbType", lbType)
 }
 
-func waitClbReady(client *clb.Client, id string) (*ClbDetail, error) {
-	for i := 0; i < 30; i++ {
-		clbDetail, err := queryClbDetailById(client, id)
+func waitClbReady(ctx context.Context, client *clb.Client, id string, timeout string) (*ClbDetail, error) {
+	waiter, err := NewWaiter(WAIT_RESOURCE_CLB, id, timeout)
+	if err != nil {
+		return nil, err
+	}
+
+	var clbDetail *ClbDetail
+	err = waiter.Wait(ctx, func() (string, bool, error) {
+		clbDetail, err = queryClbDetailById(client, id)
 		if err != nil {
-			return nil, err
+			return "", false, err
 		}
 		if clbDetail == nil {
-			return nil, fmt.Errorf("lb(%s) not found", id)
-		}
-		if clbDetail.Status == 1 {
-			return clbDetail, nil
-		} else {
-			time.Sleep(10 * time.Second)
+			return "", false, fmt.Errorf("lb(%s) not found", id)
 		}
+		return strconv.FormatUint(clbDetail.Status, 10), clbDetail.Status == 1, nil
+	})
+	if err != nil {
+		return nil, err
 	}
-	return nil, fmt.Errorf("wait lb(%s) ready timeout", id)
+	return clbDetail, nil
 }
 
-func createClb(client *clb.Client, input CreateClbInput) (output CreateClbOutput, err error) {
+func createClb(ctx context.Context, client *clb.Client, input CreateClbInput) (output CreateClbOutput, err error) {
 	var lbForward int64 = 1
 	output.Guid = input.Guid
 	output.Result.Code = RESULT_CODE_SUCCESS
@@ -185,12 +191,11 @@ func createClb(client *clb.Client, input CreateClbInput) (output CreateClbOutput
 
 	defer func() {
 		if err != nil {
-			output.Result.Code = RESULT_CODE_ERROR
-			output.Result.Message = err.Error()
+			output.Result.SetError(err)
 		}
 	}()
 
-	if err = createClbCheckParam(input); err != nil {
+	if err = AsValidationError(createClbCheckParam(input)); err != nil {
 		return output, err
 	}
 
@@ -232,7 +237,9 @@ func createClb(client *clb.Client, input CreateClbInput) (output CreateClbOutput
 		return output, err
 	}
 
-	clbDetail, err = waitClbReady(client, *resp.Response.LoadBalancerIds[0])
+	recordResourceId(ctx, input.Guid, *resp.Response.LoadBalancerIds[0])
+
+	clbDetail, err = waitClbReady(ctx, client, *resp.Response.LoadBalancerIds[0], input.WaitTimeout)
 	if err != nil {
 		return output, err
 	}
@@ -242,23 +249,30 @@ func createClb(client *clb.Client, input CreateClbInput) (output CreateClbOutput
 	return output, err
 }
 
-func (action *CreateClbAction) Do(input interface{}) (interface{}, error) {
+func (action *CreateClbAction) Do(ctx context.Context, input interface{}) (interface{}, error) {
 	inputs, _ := input.(CreateClbInputs)
-	outputs := CreateClbOutputs{}
-	var finalErr error
-
-	for _, input := range inputs.Inputs {
-		if input.Location != "" && input.APISecret != "" {
-			input.ProviderParams = fmt.Sprintf("%s;%s", input.Location, input.APISecret)
+	outputs := CreateClbOutputs{Outputs: make([]CreateClbOutput, len(inputs.Inputs))}
+	finalErr := runInputs(ctx, len(inputs.Inputs), func(i int) []string {
+		return []string{inputs.Inputs[i].Guid, inputs.Inputs[i].Id}
+	}, func(ctx context.Context, i int) error {
+		input := inputs.Inputs[i]
+		params, err := NewProviderParams(input.ProviderParams, input.Location, input.APISecret)
+		if err != nil {
+			output := CreateClbOutput{Guid: input.Guid}
+			output.CallBackParameter.Parameter = input.CallBackParameter.Parameter
+			output.Result.SetError(err)
+			outputs.Outputs[i] = output
+			return err
 		}
-		paramsMap, _ := GetMapFromProviderParams(input.ProviderParams)
-		client, _ := createClbClient(paramsMap["Region"], paramsMap["SecretID"], paramsMap["SecretKey"])
-		output, err := createClb(client, input)
+		client, _ := createClbClient(ctx, params)
+		output, err := createClb(ctx, client, input)
 		if err != nil {
-			finalErr = err
+			outputs.Outputs[i] = output
+			return err
 		}
-		outputs.Outputs = append(outputs.Outputs, output)
-	}
+		outputs.Outputs[i] = output
+		return nil
+	})
 
 	return &outputs, finalErr
 }
@@ -272,11 +286,11 @@ type TerminateClbInputs struct {
 
 type TerminateClbInput struct {
 	CallBackParameter
-	Guid           string `json:"guid"`
-	ProviderParams string `json:"provider_params"`
-	Id             string `json:"id"`
-	Location       string `json:"location"`
-	APISecret      string `json:"api_secret"`
+	Guid           string `json:"guid" register:"required"`
+	ProviderParams string `json:"provider_params" register:"optional"`
+	Id             string `json:"id" register:"required"`
+	Location       string `json:"location" register:"required"`
+	APISecret      string `json:"api_secret" register:"required,system_variable=QCLOUD_API_SECRET"`
 }
 
 type TerminateClbOutputs struct {
@@ -286,10 +300,10 @@ type TerminateClbOutputs struct {
 type TerminateClbOutput struct {
 	CallBackParameter
 	Result
-	Guid string `json:"guid,omitempty"`
+	Guid string `json:"guid,omitempty" register:"optional"`
 }
 
-func (action *TerminateClbAction) ReadParam(param interface{}) (interface{}, error) {
+func (action *TerminateClbAction) ReadParam(ctx context.Context, param interface{}) (interface{}, error) {
 	var inputs TerminateClbInputs
 	err := UnmarshalJson(param, &inputs)
 	if err != nil {
@@ -315,7 +329,7 @@ func terminateClbCheckParam(input TerminateClbInput) error {
 }
 
 func terminateClb(client *clb.Client, input TerminateClbInput) error {
-	if err := terminateClbCheckParam(input); err != nil {
+	if err := AsValidationError(terminateClbCheckParam(input)); err != nil {
 		return err
 	}
 	// check whether the clb is existed.
@@ -340,30 +354,34 @@ func terminateClb(client *clb.Client, input TerminateClbInput) error {
 	return err
 }
 
-func (action *TerminateClbAction) Do(input interface{}) (interface{}, error) {
+func (action *TerminateClbAction) Do(ctx context.Context, input interface{}) (interface{}, error) {
 	inputs, _ := input.(TerminateClbInputs)
-	outputs := TerminateClbOutputs{}
-	var finalErr error
-
-	for _, input := range inputs.Inputs {
+	outputs := TerminateClbOutputs{Outputs: make([]TerminateClbOutput, len(inputs.Inputs))}
+	finalErr := runInputs(ctx, len(inputs.Inputs), func(i int) []string {
+		return []string{inputs.Inputs[i].Guid, inputs.Inputs[i].Id}
+	}, func(ctx context.Context, i int) error {
+		input := inputs.Inputs[i]
 		output := TerminateClbOutput{
 			Guid: input.Guid,
 		}
 		output.CallBackParameter.Parameter = input.CallBackParameter.Parameter
 		output.Result.Code = RESULT_CODE_SUCCESS
-		if input.Location != "" && input.APISecret != "" {
-			input.ProviderParams = fmt.Sprintf("%s;%s", input.Location, input.APISecret)
+		params, err := NewProviderParams(input.ProviderParams, input.Location, input.APISecret)
+		if err != nil {
+			output.Result.SetError(err)
+			outputs.Outputs[i] = output
+			return err
 		}
-		paramsMap, _ := GetMapFromProviderParams(input.ProviderParams)
-		client, _ := createClbClient(paramsMap["Region"], paramsMap["SecretID"], paramsMap["SecretKey"])
+		client, _ := createClbClient(ctx, params)
 		if err := terminateClb(client, input); err != nil {
-			output.Result.Code = RESULT_CODE_ERROR
-			output.Result.Message = err.Error()
-			finalErr = err
+			output.Result.SetError(err)
+			outputs.Outputs[i] = output
+			return err
 		}
 
-		outputs.Outputs = append(outputs.Outputs, output)
-	}
+		outputs.Outputs[i] = output
+		return nil
+	})
 
 	return &outputs, finalErr
 }
@@ -1,17 +1,21 @@
 package plugins
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"strconv"
 	"strings"
-	"time"
 
-	"github.com/sirupsen/logrus"
 	clb "github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/clb/v20180317"
 	cvm "github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/cvm/v20170312"
 )
 
+const (
+	CLB_TASK_STATUS_SUCCESS = 0
+	CLB_TASK_STATUS_FAILED  = 1
+)
+
 var clbTargetActions = make(map[string]Action)
 
 func init() {
@@ -31,6 +35,10 @@ func (plugin *ClbTargetPlugin) GetActionByName(actionName string) (Action, error
 	return action, nil
 }
 
+func (plugin *ClbTargetPlugin) GetActions() map[string]Action {
+	return clbTargetActions
+}
+
 type AddBackTargetAction struct {
 }
 
@@ -40,16 +48,17 @@ type BackTargetInputs struct {
 
 type BackTargetInput struct {
 	CallBackParameter
-	Guid           string `json:"guid"`
-	ProviderParams string `json:"provider_params"`
-	LbId           string `json:"lb_id"`
-	Port           string `json:"lb_port"`
-	Protocol       string `json:"protocol"`
-	HostIds        string `json:"host_ids"`
-	HostPorts      string `json:"host_ports"`
-	Location       string `json:"location"`
-	APISecret      string `json:"api_secret"`
-	DeleteListener string `json:"delete_listener"`
+	WaitParameter
+	Guid           string `json:"guid" register:"required"`
+	ProviderParams string `json:"provider_params" register:"optional"`
+	LbId           string `json:"lb_id" register:"required"`
+	Port           string `json:"lb_port" register:"required"`
+	Protocol       string `json:"protocol" register:"required" enum:"clb_protocol"`
+	HostIds        string `json:"host_ids" register:"required"`
+	HostPorts      string `json:"host_ports" register:"required"`
+	Location       string `json:"location" register:"required"`
+	APISecret      string `json:"api_secret" register:"required,system_variable=QCLOUD_API_SECRET"`
+	DeleteListener string `json:"delete_listener" register:"optional,actions=del-backtarget"`
 }
 
 type BackTargetOutputs struct {
@@ -59,11 +68,11 @@ type BackTargetOutputs struct {
 type BackTargetOutput struct {
 	CallBackParameter
 	Result
-	ListenerId string `json:"listener_id,omitempty"`
-	Guid       string `json:"guid,omitempty"`
+	ListenerId string `json:"listener_id,omitempty" register:"optional,actions=add-backtarget"`
+	Guid       string `json:"guid,omitempty" register:"optional"`
 }
 
-func (action *AddBackTargetAction) ReadParam(param interface{}) (interface{}, error) {
+func (action *AddBackTargetAction) ReadParam(ctx context.Context, param interface{}) (interface{}, error) {
 	var inputs BackTargetInputs
 	err := UnmarshalJson(param, &inputs)
 	if err != nil {
@@ -84,12 +93,15 @@ func isValidPort(port string) error {
 	return nil
 }
 
+// clbProtocols are the valid protocols of the listeners, they are case insensitive.
+var clbProtocols = []string{"TCP", "UDP"}
+
 func isValidProtocol(protocol string) error {
 	if protocol == "" {
 		return errors.New("protocol is empty")
 	}
 
-	if !strings.EqualFold(strings.ToUpper(protocol), "TCP") && !strings.EqualFold(strings.ToUpper(protocol), "UDP") {
+	if err := IsValidValue(strings.ToUpper(protocol), clbProtocols); err != nil {
 		return fmt.Errorf("protocol(%s) is invalid", protocol)
 	}
 	return nil
@@ -124,7 +136,7 @@ func clbTargetCheckParam(input BackTargetInput) error {
 	return nil
 }
 
-func createListener(client *clb.Client, lbId string, proto string, port int64) (string, error) {
+func createListener(ctx context.Context, client *clb.Client, lbId string, proto string, port int64, timeout string) (string, error) {
 	ports := []*int64{&port}
 	upperProto := strings.ToUpper(proto)
 	request := clb.NewCreateListenerRequest()
@@ -141,8 +153,10 @@ func createListener(client *clb.Client, lbId string, proto string, port int64) (
 		return "", fmt.Errorf("createLbListener response have %d entries,it shoud be 1", len(response.Response.ListenerIds))
 	}
 
-	//sleep  to wait listener create ok
-	time.Sleep(10 * time.Second)
+	//wait listener create ok
+	if err = waitClbTask(ctx, client, *response.Response.RequestId, timeout); err != nil {
+		return "", err
+	}
 
 	return *response.Response.ListenerIds[0], nil
 }
@@ -165,7 +179,7 @@ func queryClbListener(client *clb.Client, lbId string, proto string, port int64)
 	return "", nil
 }
 
-func ensureListenerExist(client *clb.Client, lbId string, proto string, port int64) (string, error) {
+func ensureListenerExist(ctx context.Context, client *clb.Client, lbId string, proto string, port int64, timeout string) (string, error) {
 	listenerId, err := queryClbListener(client, lbId, proto, port)
 	if err != nil {
 		return "", err
@@ -175,10 +189,10 @@ func ensureListenerExist(client *clb.Client, lbId string, proto string, port int
 		return listenerId, nil
 	}
 
-	return createListener(client, lbId, proto, port)
+	return createListener(ctx, client, lbId, proto, port, timeout)
 }
 
-func ensureAddListenerBackHost(client *clb.Client, lbId string, listenerId string, instanceId string, port int64) error {
+func ensureAddListenerBackHost(ctx context.Context, client *clb.Client, lbId string, listenerId string, instanceId string, port int64, timeout string) error {
 	cvmType := "CVM"
 	target := &clb.Target{
 		Port:       &port,
@@ -190,49 +204,69 @@ func ensureAddListenerBackHost(client *clb.Client, lbId string, listenerId strin
 	request.ListenerId = &listenerId
 	request.Targets = []*clb.Target{target}
 
-	var err error
-	count := 1
-
-	for {
-		_, err = client.RegisterTargets(request)
-		if err == nil {
-			break
-		}
-		if count <= 30 {
-			time.Sleep(5 * time.Second)
-		} else {
-			logrus.Infof("after %v seconds, failed to add listener back host, error=%v", count*5, err)
-			return err
+	waiter, err := NewWaiter(WAIT_RESOURCE_CLB, lbId, timeout)
+	if err != nil {
+		return err
+	}
+	// the lb rejects the request while it is handling another task, try again until it is accepted.
+	taskId := ""
+	err = waiter.Wait(ctx, func() (string, bool, error) {
+		response, err := client.RegisterTargets(request)
+		if err != nil {
+			return err.Error(), false, nil
 		}
-		count++
+		taskId = *response.Response.RequestId
+		return "", true, nil
+	})
+	if err != nil {
+		Logger(ctx).Infof("failed to add listener back host, error=%v", err)
+		return err
 	}
 
-	return err
+	return waitClbTask(ctx, client, taskId, timeout)
+}
+
+// waitClbTask waits for the async task of clb, the task id is the request id of the async action.
+func waitClbTask(ctx context.Context, client *clb.Client, taskId string, timeout string) error {
+	waiter, err := NewWaiter(WAIT_RESOURCE_CLB_TASK, taskId, timeout)
+	if err != nil {
+		return err
+	}
+
+	request := clb.NewDescribeTaskStatusRequest()
+	request.TaskId = &taskId
+	return waiter.WithFailureStates(strconv.Itoa(CLB_TASK_STATUS_FAILED)).Wait(ctx, func() (string, bool, error) {
+		response, err := client.DescribeTaskStatus(request)
+		if err != nil {
+			return "", false, err
+		}
+		status := *response.Response.Status
+		return strconv.FormatInt(status, 10), status == CLB_TASK_STATUS_SUCCESS, nil
+	})
 }
 
-func (action *AddBackTargetAction) addBackTarget(input *BackTargetInput) (output BackTargetOutput, err error) {
+func (action *AddBackTargetAction) addBackTarget(ctx context.Context, input *BackTargetInput) (output BackTargetOutput, err error) {
 	defer func() {
 		output.Guid = input.Guid
 		output.CallBackParameter.Parameter = input.CallBackParameter.Parameter
 		if err == nil {
 			output.Result.Code = RESULT_CODE_SUCCESS
 		} else {
-			output.Result.Code = RESULT_CODE_ERROR
-			output.Result.Message = err.Error()
+			output.Result.SetError(err)
 		}
 	}()
 
-	if err = clbTargetCheckParam(*input); err != nil {
-		logrus.Errorf("clbTargetCheckParam meet error=%v", err)
+	if err = AsValidationError(clbTargetCheckParam(*input)); err != nil {
+		Logger(ctx).Errorf("clbTargetCheckParam meet error=%v", err)
 		return
 	}
 
 	//check if lb exist
-	if input.Location != "" && input.APISecret != "" {
-		input.ProviderParams = fmt.Sprintf("%s;%s", input.Location, input.APISecret)
+	params, err := NewProviderParams(input.ProviderParams, input.Location, input.APISecret)
+	if err != nil {
+		return
 	}
-	paramsMap, _ := GetMapFromProviderParams(input.ProviderParams)
-	client, _ := createClbClient(paramsMap["Region"], paramsMap["SecretID"], paramsMap["SecretKey"])
+	client, _ := createClbClient(ctx, params)
 	detail, err := queryClbDetailById(client, input.LbId)
 	if err != nil {
 		return
@@ -243,27 +277,27 @@ func (action *AddBackTargetAction) addBackTarget(input *BackTargetInput) (output
 	}
 
 	portInt64, _ := strconv.ParseInt(input.Port, 10, 64)
-	listenerId, err := ensureListenerExist(client, input.LbId, input.Protocol, portInt64)
+	listenerId, err := ensureListenerExist(ctx, client, input.LbId, input.Protocol, portInt64, input.WaitTimeout)
 	if err != nil {
-		logrus.Errorf("ensureListenerExist meet error=%v", err)
+		Logger(ctx).Errorf("ensureListenerExist meet error=%v", err)
 		return
 	}
 	output.ListenerId = listenerId
 	hostIds, err := GetArrayFromString(input.HostIds, ARRAY_SIZE_REAL, 0)
 	if err != nil {
-		logrus.Errorf("GetArrayFromString meet error=%v, rawData=%v", err, input.HostIds)
+		Logger(ctx).Errorf("GetArrayFromString meet error=%v, rawData=%v", err, input.HostIds)
 		return
 	}
 
 	hostPorts, err := GetArrayFromString(input.HostPorts, ARRAY_SIZE_AS_EXPECTED, len(hostIds))
 	if err != nil {
-		logrus.Errorf("GetArrayFromString meet error=%v, rawData=%v", err, input.HostPorts)
+		Logger(ctx).Errorf("GetArrayFromString meet error=%v, rawData=%v", err, input.HostPorts)
 		return
 	}
 
 	for _, port := range hostPorts {
 		if err = isValidPort(port); err != nil {
-			logrus.Errorf("isValidPort meet error=%v, port=%v", err, port)
+			Logger(ctx).Errorf("isValidPort meet error=%v, port=%v", err, port)
 			return
 		}
 	}
@@ -273,20 +307,20 @@ func (action *AddBackTargetAction) addBackTarget(input *BackTargetInput) (output
 		describeInstancesParams := cvm.DescribeInstancesRequest{
 			InstanceIds: []*string{&hostId},
 		}
-		clientCvm, _ := createCvmClient(paramsMap["Region"], paramsMap["SecretID"], paramsMap["SecretKey"])
+		clientCvm, _ := createCvmClient(ctx, params)
 		var describeInstancesResponse *cvm.DescribeInstancesResponse
 		describeInstancesResponse, err = describeInstancesFromCvm(clientCvm, describeInstancesParams)
 		if err != nil {
-			logrus.Errorf("describeInstancesFromCvm meet error=%v", err)
+			Logger(ctx).Errorf("describeInstancesFromCvm meet error=%v", err)
 			return
 		}
 		if len(describeInstancesResponse.Response.InstanceSet) == 0 {
-			logrus.Errorf("hostId=[%v] is not existed", hostId)
+			Logger(ctx).Errorf("hostId=[%v] is not existed", hostId)
 			err = fmt.Errorf("hostId=[%v] is not existed", hostId)
 			return
 		}
-		if err = ensureAddListenerBackHost(client, input.LbId, listenerId, hostId, hostPort); err != nil {
-			logrus.Errorf("ensureAddListenerBackHost meet error=%v", err)
+		if err = ensureAddListenerBackHost(ctx, client, input.LbId, listenerId, hostId, hostPort, input.WaitTimeout); err != nil {
+			Logger(ctx).Errorf("ensureAddListenerBackHost meet error=%v", err)
 			return
 		}
 	}
@@ -294,20 +328,19 @@ func (action *AddBackTargetAction) addBackTarget(input *BackTargetInput) (output
 	return
 }
 
-func (action *AddBackTargetAction) Do(input interface{}) (interface{}, error) {
+func (action *AddBackTargetAction) Do(ctx context.Context, input interface{}) (interface{}, error) {
 	inputs, _ := input.(BackTargetInputs)
-	outputs := BackTargetOutputs{}
-	var finalErr error
-
-	for _, input := range inputs.Inputs {
-		output, err := action.addBackTarget(&input)
-		if err != nil {
-			finalErr = err
-		}
-		outputs.Outputs = append(outputs.Outputs, output)
-	}
-
-	logrus.Infof("all clb-target = %v are added", inputs)
+	outputs := BackTargetOutputs{Outputs: make([]BackTargetOutput, len(inputs.Inputs))}
+	finalErr := runInputs(ctx, len(inputs.Inputs), func(i int) []string {
+		return []string{inputs.Inputs[i].Guid, inputs.Inputs[i].LbId}
+	}, func(ctx context.Context, i int) error {
+		input := inputs.Inputs[i]
+		output, err := action.addBackTarget(ctx, &input)
+		outputs.Outputs[i] = output
+		return err
+	})
+
+	Logger(ctx).Infof("all clb-target = %s are added", Sanitize(inputs))
 	return &outputs, finalErr
 }
 
@@ -324,7 +357,7 @@ type DelBackTargetOutput struct {
 	Guid string `json:"guid,omitempty"`
 }
 
-func (action *DelBackTargetAction) ReadParam(param interface{}) (interface{}, error) {
+func (action *DelBackTargetAction) ReadParam(ctx context.Context, param interface{}) (interface{}, error) {
 	var inputs BackTargetInputs
 	err := UnmarshalJson(param, &inputs)
 	if err != nil {
@@ -333,7 +366,7 @@ func (action *DelBackTargetAction) ReadParam(param interface{}) (interface{}, er
 	return inputs, nil
 }
 
-func ensureDelListenerBackHost(client *clb.Client, lbId string, listenerId string, hostPort int64, instanceId string) error {
+func ensureDelListenerBackHost(ctx context.Context, client *clb.Client, lbId string, listenerId string, hostPort int64, instanceId string, timeout string) error {
 	cvmType := "CVM"
 	target := &clb.Target{
 		Port:       &hostPort,
@@ -345,66 +378,67 @@ func ensureDelListenerBackHost(client *clb.Client, lbId string, listenerId strin
 	request.ListenerId = &listenerId
 	request.Targets = []*clb.Target{target}
 
-	var err error
-	count := 1
-
-	for {
-		_, err = client.DeregisterTargets(request)
-		if err == nil {
-			break
-		}
-		if count <= 30 {
-			time.Sleep(5 * time.Second)
-		} else {
-			logrus.Infof("after %v seconds, failed to delete listener back host, error=%v", count*5, err)
-			return err
+	waiter, err := NewWaiter(WAIT_RESOURCE_CLB, lbId, timeout)
+	if err != nil {
+		return err
+	}
+	// the lb rejects the request while it is handling another task, try again until it is accepted.
+	taskId := ""
+	err = waiter.Wait(ctx, func() (string, bool, error) {
+		response, err := client.DeregisterTargets(request)
+		if err != nil {
+			return err.Error(), false, nil
 		}
-		count++
+		taskId = *response.Response.RequestId
+		return "", true, nil
+	})
+	if err != nil {
+		Logger(ctx).Infof("failed to delete listener back host, error=%v", err)
+		return err
 	}
 
-	return err
+	return waitClbTask(ctx, client, taskId, timeout)
 }
 
-func (action *DelBackTargetAction) delBackTarget(input *BackTargetInput) (output BackTargetOutput, err error) {
+func (action *DelBackTargetAction) delBackTarget(ctx context.Context, input *BackTargetInput) (output BackTargetOutput, err error) {
 	defer func() {
 		output.Guid = input.Guid
 		output.CallBackParameter.Parameter = input.CallBackParameter.Parameter
 		if err == nil {
 			output.Result.Code = RESULT_CODE_SUCCESS
 		} else {
-			output.Result.Code = RESULT_CODE_ERROR
-			output.Result.Message = err.Error()
+			output.Result.SetError(err)
 		}
 	}()
 
-	if err = clbTargetCheckParam(*input); err != nil {
-		logrus.Errorf("clbTargetCheckParam meet error=%v", err)
+	if err = AsValidationError(clbTargetCheckParam(*input)); err != nil {
+		Logger(ctx).Errorf("clbTargetCheckParam meet error=%v", err)
 		return
 	}
 
 	//check if lb exist
-	if input.Location != "" && input.APISecret != "" {
-		input.ProviderParams = fmt.Sprintf("%s;%s", input.Location, input.APISecret)
+	params, err := NewProviderParams(input.ProviderParams, input.Location, input.APISecret)
+	if err != nil {
+		return
 	}
-	paramsMap, _ := GetMapFromProviderParams(input.ProviderParams)
-	client, _ := createClbClient(paramsMap["Region"], paramsMap["SecretID"], paramsMap["SecretKey"])
+	client, _ := createClbClient(ctx, params)
 	detail, err := queryClbDetailById(client, input.LbId)
 	if err != nil {
 		return
 	}
 	if detail == nil {
-		logrus.Infof("lb[%v] is not existed.", input.LbId)
+		Logger(ctx).Infof("lb[%v] is not existed.", input.LbId)
 		return
 	}
 
 	portInt64, _ := strconv.ParseInt(input.Port, 10, 64)
 	listenerId, err := queryClbListener(client, input.LbId, input.Protocol, portInt64)
 	if err != nil {
-		logrus.Errorf("Delete clb-target query cli listener error : %v ", err)
+		Logger(ctx).Errorf("Delete clb-target query cli listener error : %v ", err)
 		return
 	}
 	if listenerId == "" {
-		logrus.Infof("can't found lb(%v) listnerId by proto(%v) and port(%v)", input.LbId, input.Protocol, portInt64)
+		Logger(ctx).Infof("can't found lb(%v) listnerId by proto(%v) and port(%v)", input.LbId, input.Protocol, portInt64)
 		//err = fmt.Errorf("can't found lb(%v) listnerId by proto(%v) and port(%v)", input.LbId, input.Protocol, portInt64)
 		return
 	}
@@ -436,11 +470,11 @@ func (action *DelBackTargetAction) delBackTarget(input *BackTargetInput) (output
 	queryTargetRequest.ListenerIds = tmpListenIds
 	queryTargetResponse,err := client.DescribeTargets(queryTargetRequest)
 	if err != nil {
-		logrus.Errorf("query back target request error=%v ", err)
+		Logger(ctx).Errorf("query back target request error=%v ", err)
 		return
 	}
 	if len(queryTargetResponse.Response.Listeners) == 0 {
-		logrus.Infof("query back target response listener is empty ")
+		Logger(ctx).Infof("query back target response listener is empty ")
 		return
 	}
 	if len(queryTargetResponse.Response.Listeners[0].Targets) > 0 {
@@ -463,90 +497,66 @@ func (action *DelBackTargetAction) delBackTarget(input *BackTargetInput) (output
 			describeInstancesParams := cvm.DescribeInstancesRequest{
 				InstanceIds: []*string{&hostId},
 			}
-			clientCvm, _ := createCvmClient(paramsMap["Region"], paramsMap["SecretID"], paramsMap["SecretKey"])
+			clientCvm, _ := createCvmClient(ctx, params)
 			var describeInstancesResponse *cvm.DescribeInstancesResponse
 			describeInstancesResponse, err = describeInstancesFromCvm(clientCvm, describeInstancesParams)
 			if err != nil {
-				logrus.Errorf("describeInstancesFromCvm meet error=%v", err)
+				Logger(ctx).Errorf("describeInstancesFromCvm meet error=%v", err)
 				return
 			}
 			if len(describeInstancesResponse.Response.InstanceSet) == 0 {
-				logrus.Errorf("hostId=[%v] is not existed", hostId)
+				Logger(ctx).Errorf("hostId=[%v] is not existed", hostId)
 				err = fmt.Errorf("hostId=[%v] is not existed", hostId)
 				return
 			}
 
-			if err = ensureDelListenerBackHost(client, input.LbId, listenerId, hostPort, hostId); err != nil {
-				logrus.Errorf("ensureDelListenerBackHost meet error=%v", err)
+			if err = ensureDelListenerBackHost(ctx, client, input.LbId, listenerId, hostPort, hostId, input.WaitTimeout); err != nil {
+				Logger(ctx).Errorf("ensureDelListenerBackHost meet error=%v", err)
 				return
 			}
 		}
 	}else{
-		logrus.Infof("query back target, listener: %s target already empty ", listenerId)
+		Logger(ctx).Infof("query back target, listener: %s target already empty ", listenerId)
 	}
 
 	if input.DeleteListener != "" {
 		isDeleteListener := strings.ToLower(input.DeleteListener)
 		if isDeleteListener == "y" || isDeleteListener == "yes" || isDeleteListener == "true" {
-			time.Sleep(3*time.Second)
 			var deleteListenerError error
 			deleteListenerRequest := clb.NewDeleteListenerRequest()
 			deleteListenerRequest.LoadBalancerId = &input.LbId
 			deleteListenerRequest.ListenerId = &listenerId
 			deleteListenerResponse, deleteListenerError := client.DeleteListener(deleteListenerRequest)
 			if deleteListenerError != nil {
-				logrus.Errorf("Delete lb listener error=%v ", deleteListenerError)
+				Logger(ctx).Errorf("Delete lb listener error=%v ", deleteListenerError)
 				err = deleteListenerError
 				return
 			}
 			tmpTaskId := *deleteListenerResponse.Response.RequestId
 			if tmpTaskId != "" {
-				count := 0
-				var queryTaskError error
-				for {
-					time.Sleep(3 * time.Second)
-					taskRequest := clb.NewDescribeTaskStatusRequest()
-					taskRequest.TaskId = &tmpTaskId
-					taskResponse := clb.NewDescribeTaskStatusResponse()
-					taskResponse, queryTaskError = client.DescribeTaskStatus(taskRequest)
-					if queryTaskError != nil {
-						logrus.Errorf("Delete clb listener,query task:%s status error=%v ", tmpTaskId, queryTaskError)
-						break
-					}
-					if *taskResponse.Response.Status == 0 {
-						logrus.Infof("Delete clb listener %s success ", listenerId)
-						break
-					}
-					if *taskResponse.Response.Status == 1 {
-						queryTaskError = fmt.Errorf("Delete clb listener fail,please check task:%s detail from tencent cloud consol ", tmpTaskId)
-						break
-					}
-					if count >= 10 {
-						queryTaskError = fmt.Errorf("Query delete clb listener task:%s timeout ", tmpTaskId)
-						break
-					}
-					count++
+				if err = waitClbTask(ctx, client, tmpTaskId, input.WaitTimeout); err != nil {
+					Logger(ctx).Errorf("Delete clb listener fail,please check task:%s detail from tencent cloud consol, error=%v ", tmpTaskId, err)
+					return
 				}
-				err = queryTaskError
+				Logger(ctx).Infof("Delete clb listener %s success ", listenerId)
 			}
 		}
 	}
 	return
 }
 
-func (action *DelBackTargetAction) Do(input interface{}) (interface{}, error) {
+func (action *DelBackTargetAction) Do(ctx context.Context, input interface{}) (interface{}, error) {
 	inputs, _ := input.(BackTargetInputs)
-	outputs := BackTargetOutputs{}
-	var finalErr error
-
-	for _, input := range inputs.Inputs {
-		output, err := action.delBackTarget(&input)
-		if err != nil {
-			finalErr = err
-		}
-		outputs.Outputs = append(outputs.Outputs, output)
-	}
-
-	logrus.Infof("all clb-target = %v are deleted", inputs)
+	outputs := BackTargetOutputs{Outputs: make([]BackTargetOutput, len(inputs.Inputs))}
+	finalErr := runInputs(ctx, len(inputs.Inputs), func(i int) []string {
+		return []string{inputs.Inputs[i].Guid, inputs.Inputs[i].LbId}
+	}, func(ctx context.Context, i int) error {
+		input := inputs.Inputs[i]
+		output, err := action.delBackTarget(ctx, &input)
+		outputs.Outputs[i] = output
+		return err
+	})
+
+	Logger(ctx).Infof("all clb-target = %s are deleted", Sanitize(inputs))
 	return outputs, finalErr
 }
@@ -0,0 +1,470 @@
+package plugins
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strings"
+	"sync"
+	"unsafe"
+
+	vpcExtend "github.com/WeBankPartners/wecube-plugins-qcloud/extend/qcloud"
+	"github.com/sirupsen/logrus"
+	bm "github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/bm/v20180423"
+	bmlb "github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/bmlb/v20180625"
+	cam "github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/cam/v20190116"
+	cbs "github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/cbs/v20170312"
+	cdb "github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/cdb/v20170320"
+	clb "github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/clb/v20180317"
+	"github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/common"
+	"github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/common/profile"
+	cvm "github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/cvm/v20170312"
+	mariadb "github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/mariadb/v20170312"
+	mongodb "github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/mongodb/v20180408"
+	redis "github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/redis/v20180412"
+	vpc "github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/vpc/v20170312"
+	cos "github.com/tencentyun/cos-go-sdk-v5"
+	legacyCommon "github.com/zqfan/tencentcloud-sdk-go/common"
+	unversioned "github.com/zqfan/tencentcloud-sdk-go/services/vpc/unversioned"
+)
+
+const (
+	QCLOUD_SERVICE_CVM     = "cvm"
+	QCLOUD_SERVICE_VPC     = "vpc"
+	QCLOUD_SERVICE_CBS     = "cbs"
+	QCLOUD_SERVICE_CLB     = "clb"
+	QCLOUD_SERVICE_CDB     = "cdb"
+	QCLOUD_SERVICE_REDIS   = "redis"
+	QCLOUD_SERVICE_MARIADB = "mariadb"
+	QCLOUD_SERVICE_CAM     = "cam"
+	QCLOUD_SERVICE_BM      = "bm"
+	QCLOUD_SERVICE_BMLB    = "bmlb"
+	QCLOUD_SERVICE_MONGODB = "mongodb"
+	QCLOUD_SERVICE_COS     = "cos"
+
+	// legacy services are called through the "{service}.api.qcloud.com" API (nat gateway, peering connection etc.)
+	QCLOUD_LEGACY_SERVICE_PREFIX = "legacy_"
+	QCLOUD_SERVICE_LEGACY_VPC    = QCLOUD_LEGACY_SERVICE_PREFIX + "vpc"
+
+	QCLOUD_API_DOMAIN        = "tencentcloudapi.com"
+	QCLOUD_LEGACY_API_DOMAIN = "api.qcloud.com"
+	QCLOUD_API_SCHEME        = "https"
+)
+
+// ClientFactory creates the clients of all the Tencent Cloud services used by plugins.
+// The zero value talks to the public Tencent Cloud API.
+type ClientFactory struct {
+	// Scheme of the cloud API, "https" if empty.
+	Scheme string
+	// Endpoints overrides the endpoint(host[:port]) of a service, the key is the service name,
+	// such as "cvm", "cos" or "legacy_vpc".
+	Endpoints map[string]string
+	// Transport sends all the cloud API requests, http.DefaultTransport is used if nil.
+	Transport http.RoundTripper
+	// Retry retries the requests failed by throttling or transient errors, DefaultRetryPolicy is used if nil.
+	Retry *RetryPolicy
+	// RateLimit limits the requests per (service, region, credential), DefaultRateLimiter is used if nil.
+	RateLimit *RateLimiter
+	// Credentials provides the credential of the clients whose secret id and key are empty,
+	// DefaultCredentialProvider is used if nil.
+	Credentials CredentialProvider
+
+	// ctx is bound by WithContext, requests of the clients created by the factory are canceled with it.
+	ctx context.Context
+	// token and language are set by WithProviderParams, token is sent with the keys of the request.
+	token    string
+	language string
+}
+
+var (
+	clientFactoryMutex sync.RWMutex
+	clientFactory      = &ClientFactory{}
+)
+
+// SetClientFactory replaces the factory used by all plugins, nil restores the default one.
+func SetClientFactory(factory *ClientFactory) {
+	if factory == nil {
+		factory = &ClientFactory{}
+	}
+
+	clientFactoryMutex.Lock()
+	clientFactory = factory
+	clientFactoryMutex.Unlock()
+
+	logrus.Infof("cloud api client factory is set, scheme=%v, endpoints=%v", factory.GetScheme(), factory.Endpoints)
+}
+
+func GetClientFactory() *ClientFactory {
+	clientFactoryMutex.RLock()
+	defer clientFactoryMutex.RUnlock()
+
+	return clientFactory
+}
+
+// WithContext returns a copy of the factory, the clients it creates stop sending requests,
+// retrying and waiting for the rate limiter once ctx is done.
+func (factory *ClientFactory) WithContext(ctx context.Context) *ClientFactory {
+	newFactory := *factory
+	newFactory.ctx = ctx
+	return &newFactory
+}
+
+// WithProviderParams returns a copy of the factory which creates clients with the token, language
+// and endpoint overrides of the provider params.
+func (factory *ClientFactory) WithProviderParams(params *ProviderParams) *ClientFactory {
+	newFactory := *factory
+	newFactory.token = params.Token
+	newFactory.language = params.Language
+	if len(params.Endpoints) > 0 {
+		newFactory.Endpoints = make(map[string]string, len(factory.Endpoints)+len(params.Endpoints))
+		for service, endpoint := range factory.Endpoints {
+			newFactory.Endpoints[service] = endpoint
+		}
+		for service, endpoint := range params.Endpoints {
+			newFactory.Endpoints[service] = endpoint
+		}
+	}
+	return &newFactory
+}
+
+func (factory *ClientFactory) GetScheme() string {
+	if factory.Scheme == "" {
+		return QCLOUD_API_SCHEME
+	}
+	return strings.ToLower(factory.Scheme)
+}
+
+// GetEndpoint returns the endpoint of the service, such as "cvm.tencentcloudapi.com".
+func (factory *ClientFactory) GetEndpoint(service string) string {
+	if endpoint, found := factory.Endpoints[service]; found && endpoint != "" {
+		return endpoint
+	}
+	if strings.HasPrefix(service, QCLOUD_LEGACY_SERVICE_PREFIX) {
+		return strings.TrimPrefix(service, QCLOUD_LEGACY_SERVICE_PREFIX) + "." + QCLOUD_LEGACY_API_DOMAIN
+	}
+	return service + "." + QCLOUD_API_DOMAIN
+}
+
+func (factory *ClientFactory) GetCredentialProvider() CredentialProvider {
+	if factory.Credentials == nil {
+		return DefaultCredentialProvider
+	}
+	return factory.Credentials
+}
+
+// getCredential returns the keys supplied by the request with the token of the provider params, or the credential of the provider
+// if the request supplies none. The empty keys are returned with the error if the provider fails,
+// so the clients of callers which ignore the error get the authentication error of the cloud API.
+func (factory *ClientFactory) getCredential(secretId, secretKey string) (*Credential, error) {
+	requestCredential := &Credential{SecretId: secretId, SecretKey: secretKey, Token: factory.token}
+	if secretId != "" || secretKey != "" {
+		return requestCredential, nil
+	}
+	credential, err := factory.GetCredentialProvider().GetCredential()
+	if err != nil {
+		logrus.Errorf("get credential meet error=%v", err)
+		return requestCredential, fmt.Errorf("get credential meet error=%v", err)
+	}
+	if credential == nil {
+		return requestCredential, errors.New("SecretID and SecretKey are not in provider_params, and no credential is configured")
+	}
+	return credential, nil
+}
+
+func (factory *ClientFactory) GetRetryPolicy() *RetryPolicy {
+	if factory.Retry == nil {
+		return getRetryPolicy()
+	}
+	return factory.Retry
+}
+
+func (factory *ClientFactory) GetRateLimiter() *RateLimiter {
+	if factory.RateLimit == nil {
+		return DefaultRateLimiter
+	}
+	return factory.RateLimit
+}
+
+// GetTransport returns the transport which should be used by clients created by the factory,
+// every attempt of a retried request waits for the rate limiter and is counted by the metrics. Requests of actions in dry run mode
+// which change resources are collected in the plan instead of being sent, the sent ones are recorded in the audit journal.
+func (factory *ClientFactory) GetTransport() http.RoundTripper {
+	var transport http.RoundTripper = &dryRunTransport{
+		next: &retryTransport{
+			policy: factory.GetRetryPolicy(),
+			next: &rateLimitTransport{
+				limiter: factory.GetRateLimiter(),
+				next:    &tracingTransport{next: &auditTransport{next: &metricsTransport{next: &clientFactoryTransport{factory: factory}}}},
+			},
+		},
+	}
+	if factory.ctx != nil {
+		transport = &contextTransport{ctx: factory.ctx, next: transport}
+	}
+	return transport
+}
+
+func (factory *ClientFactory) baseTransport() http.RoundTripper {
+	if factory.Transport == nil {
+		return http.DefaultTransport
+	}
+	return factory.Transport
+}
+
+func (factory *ClientFactory) newClientProfile(service string) *profile.ClientProfile {
+	clientProfile := profile.NewClientProfile()
+	clientProfile.HttpProfile.Endpoint = factory.GetEndpoint(service)
+	clientProfile.HttpProfile.Scheme = strings.ToUpper(factory.GetScheme())
+	if factory.language != "" {
+		clientProfile.Language = factory.language
+	}
+	return clientProfile
+}
+
+func (factory *ClientFactory) initClient(client *common.Client) {
+	client.WithHttpTransport(factory.GetTransport())
+}
+
+func (factory *ClientFactory) NewCvmClient(region, secretId, secretKey string) (*cvm.Client, error) {
+	credential, credentialErr := factory.getCredential(secretId, secretKey)
+	client, err := cvm.NewClient(credential.toSdkCredential(), region, factory.newClientProfile(QCLOUD_SERVICE_CVM))
+	if err != nil {
+		return nil, err
+	}
+	factory.initClient(&client.Client)
+	return client, credentialErr
+}
+
+func (factory *ClientFactory) NewVpcClient(region, secretId, secretKey string) (*vpc.Client, error) {
+	credential, credentialErr := factory.getCredential(secretId, secretKey)
+	client, err := vpc.NewClient(credential.toSdkCredential(), region, factory.newClientProfile(QCLOUD_SERVICE_VPC))
+	if err != nil {
+		return nil, err
+	}
+	factory.initClient(&client.Client)
+	return client, credentialErr
+}
+
+func (factory *ClientFactory) NewCbsClient(region, secretId, secretKey string) (*cbs.Client, error) {
+	credential, credentialErr := factory.getCredential(secretId, secretKey)
+	client, err := cbs.NewClient(credential.toSdkCredential(), region, factory.newClientProfile(QCLOUD_SERVICE_CBS))
+	if err != nil {
+		return nil, err
+	}
+	factory.initClient(&client.Client)
+	return client, credentialErr
+}
+
+func (factory *ClientFactory) NewClbClient(region, secretId, secretKey string) (*clb.Client, error) {
+	credential, credentialErr := factory.getCredential(secretId, secretKey)
+	client, err := clb.NewClient(credential.toSdkCredential(), region, factory.newClientProfile(QCLOUD_SERVICE_CLB))
+	if err != nil {
+		return nil, err
+	}
+	factory.initClient(&client.Client)
+	return client, credentialErr
+}
+
+func (factory *ClientFactory) NewCdbClient(region, secretId, secretKey string) (*cdb.Client, error) {
+	credential, credentialErr := factory.getCredential(secretId, secretKey)
+	client, err := cdb.NewClient(credential.toSdkCredential(), region, factory.newClientProfile(QCLOUD_SERVICE_CDB))
+	if err != nil {
+		return nil, err
+	}
+	factory.initClient(&client.Client)
+	return client, credentialErr
+}
+
+func (factory *ClientFactory) NewRedisClient(region, secretId, secretKey string) (*redis.Client, error) {
+	credential, credentialErr := factory.getCredential(secretId, secretKey)
+	client, err := redis.NewClient(credential.toSdkCredential(), region, factory.newClientProfile(QCLOUD_SERVICE_REDIS))
+	if err != nil {
+		return nil, err
+	}
+	factory.initClient(&client.Client)
+	return client, credentialErr
+}
+
+func (factory *ClientFactory) NewMariadbClient(region, secretId, secretKey string) (*mariadb.Client, error) {
+	credential, credentialErr := factory.getCredential(secretId, secretKey)
+	client, err := mariadb.NewClient(credential.toSdkCredential(), region, factory.newClientProfile(QCLOUD_SERVICE_MARIADB))
+	if err != nil {
+		return nil, err
+	}
+	factory.initClient(&client.Client)
+	return client, credentialErr
+}
+
+func (factory *ClientFactory) NewCamClient(region, secretId, secretKey string) (*cam.Client, error) {
+	credential, credentialErr := factory.getCredential(secretId, secretKey)
+	client, err := cam.NewClient(credential.toSdkCredential(), region, factory.newClientProfile(QCLOUD_SERVICE_CAM))
+	if err != nil {
+		return nil, err
+	}
+	factory.initClient(&client.Client)
+	return client, credentialErr
+}
+
+func (factory *ClientFactory) NewBmClient(region, secretId, secretKey string) (*bm.Client, error) {
+	credential, credentialErr := factory.getCredential(secretId, secretKey)
+	client, err := bm.NewClient(credential.toSdkCredential(), region, factory.newClientProfile(QCLOUD_SERVICE_BM))
+	if err != nil {
+		return nil, err
+	}
+	factory.initClient(&client.Client)
+	return client, credentialErr
+}
+
+func (factory *ClientFactory) NewBmlbClient(region, secretId, secretKey string) (*bmlb.Client, error) {
+	credential, credentialErr := factory.getCredential(secretId, secretKey)
+	client, err := bmlb.NewClient(credential.toSdkCredential(), region, factory.newClientProfile(QCLOUD_SERVICE_BMLB))
+	if err != nil {
+		return nil, err
+	}
+	factory.initClient(&client.Client)
+	return client, credentialErr
+}
+
+func (factory *ClientFactory) NewMongodbClient(region, secretId, secretKey string) (*mongodb.Client, error) {
+	credential, credentialErr := factory.getCredential(secretId, secretKey)
+	client, err := mongodb.NewClient(credential.toSdkCredential(), region, factory.newClientProfile(QCLOUD_SERVICE_MONGODB))
+	if err != nil {
+		return nil, err
+	}
+	factory.initClient(&client.Client)
+	return client, credentialErr
+}
+
+// NewCosClient returns the client of the bucket and the bucket url, the default bucket url is
+// "{scheme}://{name}-{appId}.cos.{region}.myqcloud.com". Requests are signed when they are sent,
+// so the client keeps working after the temporary credential is refreshed.
+func (factory *ClientFactory) NewCosClient(name, appId, region, secretId, secretKey, bucketUrl string) (*cos.Client, string) {
+	if bucketUrl == "" {
+		endpoint := fmt.Sprintf("cos.%s.myqcloud.com", region)
+		if override, found := factory.Endpoints[QCLOUD_SERVICE_COS]; found && override != "" {
+			endpoint = override
+		}
+		bucketUrl = fmt.Sprintf("%s://%s-%s.%s", factory.GetScheme(), name, appId, endpoint)
+	}
+	u, _ := url.Parse(bucketUrl)
+	client := cos.NewClient(&cos.BaseURL{BucketURL: u}, &http.Client{
+		Transport: &cosCredentialTransport{
+			factory:   factory,
+			secretId:  secretId,
+			secretKey: secretKey,
+			next:      factory.GetTransport(),
+		},
+	})
+	return client, bucketUrl
+}
+
+// NewLegacyVpcClient returns the client of the legacy vpc API, its requests are sent by legacyApiTransport.
+func (factory *ClientFactory) NewLegacyVpcClient(region, secretId, secretKey string) (*unversioned.Client, error) {
+	credential, credentialErr := factory.getCredential(secretId, secretKey)
+	client, err := unversioned.NewClientWithSecretId(credential.SecretId, credential.SecretKey, region)
+	if err != nil {
+		return nil, err
+	}
+	factory.initLegacyClient(&client.Client, credential)
+	return client, credentialErr
+}
+
+func (factory *ClientFactory) NewVpcPeeringConnectionClient(region, secretId, secretKey string) (*vpcExtend.Client, error) {
+	credential, credentialErr := factory.getCredential(secretId, secretKey)
+	client, err := vpcExtend.NewClientWithSecretId(credential.SecretId, credential.SecretKey, region)
+	if err != nil {
+		return nil, err
+	}
+	factory.initLegacyClient(&client.Client, credential)
+	return client, credentialErr
+}
+
+// initLegacyClient sends the requests of the legacy client by legacyApiTransport. The legacy sdk does not
+// accept a transport, its private http client which uses http.DefaultTransport is given one, so the
+// transport is only used by this client.
+func (factory *ClientFactory) initLegacyClient(client *legacyCommon.Client, credential *Credential) {
+	field := reflect.ValueOf(client).Elem().FieldByName("httpClient")
+	if field.Kind() != reflect.Ptr || field.IsNil() {
+		logrus.Errorf("http client of the legacy sdk is not found, legacy api requests are sent by http.DefaultTransport")
+		return
+	}
+	httpClient := (*http.Client)(unsafe.Pointer(field.Pointer()))
+	httpClient.Transport = &legacyApiTransport{factory: factory, credential: credential}
+}
+
+// newStsClient returns the client of STS with the given credential, it is used to get the credential of others.
+func (factory *ClientFactory) newStsClient(region string, credential *Credential) *common.Client {
+	client := &common.Client{}
+	client.Init(region).
+		WithCredential(credential.toSdkCredential()).
+		WithProfile(factory.newClientProfile(QCLOUD_SERVICE_STS))
+	factory.initClient(client)
+	return client
+}
+
+// clientFactoryTransport applies the scheme of the factory and sends requests by the transport of the factory.
+type clientFactoryTransport struct {
+	factory *ClientFactory
+}
+
+func (transport *clientFactoryTransport) RoundTrip(request *http.Request) (*http.Response, error) {
+	factory := transport.factory
+	if scheme := factory.GetScheme(); request.URL.Scheme != scheme {
+		request = cloneRequestWithUrl(request)
+		request.URL.Scheme = scheme
+	}
+	return factory.baseTransport().RoundTrip(request)
+}
+
+// contextTransport sends requests with the context bound to the client factory.
+type contextTransport struct {
+	ctx  context.Context
+	next http.RoundTripper
+}
+
+func (transport *contextTransport) RoundTrip(request *http.Request) (*http.Response, error) {
+	if err := transport.ctx.Err(); err != nil {
+		return nil, err
+	}
+	return transport.next.RoundTrip(request.WithContext(transport.ctx))
+}
+
+// legacyApiTransport sends the requests of a legacy client ("{service}.api.qcloud.com") by the factory which
+// created the client. The legacy sdk can not send the token of a temporary credential, so it is added to
+// the requests which are signed again.
+type legacyApiTransport struct {
+	factory    *ClientFactory
+	credential *Credential
+}
+
+func (transport *legacyApiTransport) RoundTrip(request *http.Request) (*http.Response, error) {
+	if credential := transport.credential; credential != nil && credential.Token != "" {
+		var err error
+		if request, err = signLegacyRequestWithToken(request, credential); err != nil {
+			return nil, err
+		}
+	}
+
+	factory := transport.factory
+	if host := request.URL.Hostname(); strings.HasSuffix(host, "."+QCLOUD_LEGACY_API_DOMAIN) {
+		service := QCLOUD_LEGACY_SERVICE_PREFIX + strings.TrimSuffix(host, "."+QCLOUD_LEGACY_API_DOMAIN)
+		if endpoint := factory.GetEndpoint(service); endpoint != request.URL.Host {
+			// keep the Host header, legacy API requests are signed with the original domain.
+			request = cloneRequestWithUrl(request)
+			request.Host = host
+			request.URL.Host = endpoint
+		}
+	}
+	return factory.GetTransport().RoundTrip(request)
+}
+
+func cloneRequestWithUrl(request *http.Request) *http.Request {
+	newRequest := new(http.Request)
+	*newRequest = *request
+	newUrl := *request.URL
+	newRequest.URL = &newUrl
+	return newRequest
+}
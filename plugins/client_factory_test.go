@@ -0,0 +1,163 @@
+package plugins
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+
+	cvm "github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/cvm/v20170312"
+	unversioned "github.com/zqfan/tencentcloud-sdk-go/services/vpc/unversioned"
+)
+
+type recordedRequest struct {
+	Host          string
+	Path          string
+	Action        string
+	Authorization string
+}
+
+func newRecordingServer(body string) (*httptest.Server, func() []recordedRequest) {
+	mutex := sync.Mutex{}
+	requests := []recordedRequest{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		action := r.Header.Get("X-TC-Action")
+		if action == "" {
+			action = r.Form.Get("Action")
+		}
+		mutex.Lock()
+		requests = append(requests, recordedRequest{
+			Host:          r.Host,
+			Path:          r.URL.Path,
+			Action:        action,
+			Authorization: r.Header.Get("Authorization"),
+		})
+		mutex.Unlock()
+		w.Write([]byte(body))
+	}))
+	return server, func() []recordedRequest {
+		mutex.Lock()
+		defer mutex.Unlock()
+		return append([]recordedRequest{}, requests...)
+	}
+}
+
+func TestClientFactoryEndpoint(t *testing.T) {
+	factory := &ClientFactory{Endpoints: map[string]string{QCLOUD_SERVICE_CVM: "127.0.0.1:9000"}}
+
+	if endpoint := factory.GetEndpoint(QCLOUD_SERVICE_CVM); endpoint != "127.0.0.1:9000" {
+		t.Errorf("cvm endpoint = %v", endpoint)
+	}
+	if endpoint := factory.GetEndpoint(QCLOUD_SERVICE_VPC); endpoint != "vpc.tencentcloudapi.com" {
+		t.Errorf("vpc endpoint = %v", endpoint)
+	}
+	if endpoint := factory.GetEndpoint(QCLOUD_SERVICE_LEGACY_VPC); endpoint != "vpc.api.qcloud.com" {
+		t.Errorf("legacy vpc endpoint = %v", endpoint)
+	}
+	if scheme := factory.GetScheme(); scheme != "https" {
+		t.Errorf("scheme = %v", scheme)
+	}
+}
+
+func TestClientFactoryRoutesClientToEndpoint(t *testing.T) {
+	server, getRequests := newRecordingServer(`{"Response":{"TotalCount":0,"InstanceSet":[],"RequestId":"fake-request-id"}}`)
+	defer server.Close()
+	serverUrl, _ := url.Parse(server.URL)
+
+	factory := &ClientFactory{
+		Scheme:    "http",
+		Endpoints: map[string]string{QCLOUD_SERVICE_CVM: serverUrl.Host},
+	}
+	client, err := factory.NewCvmClient("ap-guangzhou", "fake-secret-id", "fake-secret-key")
+	if err != nil {
+		t.Fatalf("NewCvmClient meet error=%v", err)
+	}
+	response, err := client.DescribeInstances(cvm.NewDescribeInstancesRequest())
+	if err != nil {
+		t.Fatalf("DescribeInstances meet error=%v", err)
+	}
+	if *response.Response.RequestId != "fake-request-id" {
+		t.Errorf("request id = %v", *response.Response.RequestId)
+	}
+
+	requests := getRequests()
+	if len(requests) != 1 {
+		t.Fatalf("server got %d requests, expected 1", len(requests))
+	}
+	if requests[0].Host != serverUrl.Host || requests[0].Action != "DescribeInstances" {
+		t.Errorf("unexpected request %++v", requests[0])
+	}
+	if !strings.Contains(requests[0].Authorization, "/cvm/tc3_request") {
+		t.Errorf("unexpected authorization %v", requests[0].Authorization)
+	}
+}
+
+func TestClientFactoryRoutesLegacyClientToEndpoint(t *testing.T) {
+	server, getRequests := newRecordingServer(`{"code":0,"message":"","totalCount":0,"data":[]}`)
+	defer server.Close()
+	serverUrl, _ := url.Parse(server.URL)
+
+	defaultTransport := http.DefaultTransport
+	defer SetClientFactory(GetClientFactory())
+	SetClientFactory(&ClientFactory{
+		Scheme:    "http",
+		Endpoints: map[string]string{QCLOUD_SERVICE_LEGACY_VPC: serverUrl.Host},
+	})
+
+	client, _ := newVpcClient(context.Background(), &ProviderParams{Region: "ap-guangzhou", SecretID: "fake-secret-id", SecretKey: "fake-secret-key"})
+	if _, err := client.DescribeNatGateway(unversioned.NewDescribeNatGatewayRequest()); err != nil {
+		t.Fatalf("DescribeNatGateway meet error=%v", err)
+	}
+	if http.DefaultTransport != defaultTransport {
+		t.Errorf("http.DefaultTransport is replaced by %T", http.DefaultTransport)
+	}
+
+	requests := getRequests()
+	if len(requests) != 1 {
+		t.Fatalf("server got %d requests, expected 1", len(requests))
+	}
+	if requests[0].Host != "vpc.api.qcloud.com" || requests[0].Action != "DescribeNatGateway" {
+		t.Errorf("unexpected request %++v", requests[0])
+	}
+}
+
+func TestLegacyClientIsCanceledWithContext(t *testing.T) {
+	server, getRequests := newRecordingServer(`{"code":0,"message":"","totalCount":0,"data":[]}`)
+	defer server.Close()
+	serverUrl, _ := url.Parse(server.URL)
+
+	defer SetClientFactory(GetClientFactory())
+	SetClientFactory(&ClientFactory{
+		Scheme:    "http",
+		Endpoints: map[string]string{QCLOUD_SERVICE_LEGACY_VPC: serverUrl.Host},
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	client, _ := newVpcClient(ctx, &ProviderParams{Region: "ap-guangzhou", SecretID: "fake-secret-id", SecretKey: "fake-secret-key"})
+	if _, err := client.DescribeNatGateway(unversioned.NewDescribeNatGatewayRequest()); err != nil {
+		t.Fatalf("DescribeNatGateway meet error=%v", err)
+	}
+	cancel()
+	if _, err := client.DescribeNatGateway(unversioned.NewDescribeNatGatewayRequest()); err == nil {
+		t.Errorf("request of canceled context succeeded")
+	}
+	if requests := getRequests(); len(requests) != 1 {
+		t.Errorf("server got %d requests, expected 1", len(requests))
+	}
+}
+
+func TestClientFactoryCosBucketUrl(t *testing.T) {
+	factory := &ClientFactory{}
+	if _, bucketUrl := factory.NewCosClient("bucket", "1250000000", "ap-guangzhou", "id", "key", ""); bucketUrl != "https://bucket-1250000000.cos.ap-guangzhou.myqcloud.com" {
+		t.Errorf("bucket url = %v", bucketUrl)
+	}
+
+	factory = &ClientFactory{Scheme: "http", Endpoints: map[string]string{QCLOUD_SERVICE_COS: "cos.local:9000"}}
+	if _, bucketUrl := factory.NewCosClient("bucket", "1250000000", "ap-guangzhou", "id", "key", ""); bucketUrl != "http://bucket-1250000000.cos.local:9000" {
+		t.Errorf("bucket url = %v", bucketUrl)
+	}
+}
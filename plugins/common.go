@@ -19,13 +19,31 @@ const (
 	ARRAY_SIZE_AS_EXPECTED = "fillArrayWithExpectedNum"
 )
 
+// chargeTypes are the valid charge types of vm, mysql and redis.
+var chargeTypes = []string{CHARGE_TYPE_PREPAID, CHARGE_TYPE_BY_HOUR}
+
 type CallBackParameter struct {
 	Parameter string `json:"callbackParameter,omitempty"`
 }
 
 type Result struct {
-	Code    string `json:"errorCode"`
-	Message string `json:"errorMessage"`
+	Code    string `json:"errorCode" register:"context"`
+	Message string `json:"errorMessage" register:"context"`
+	// ErrorCategory, ErrorReason and Retryable classify the error, they are empty on success.
+	// ErrorReason is the error code of the cloud API or of the plugin, such as "LimitExceeded.Quota".
+	ErrorCategory string `json:"errorCategory,omitempty"`
+	ErrorReason   string `json:"errorReason,omitempty"`
+	Retryable     bool   `json:"retryable,omitempty"`
+	// RequestIds are the RequestIds of the cloud API requests sent by the input, in the order they were sent.
+	RequestIds []string `json:"requestIds,omitempty"`
+}
+
+// SetError sets the error result and the category of the error.
+func (result *Result) SetError(err error) {
+	pluginErr := ClassifyError(err)
+	result.Code = RESULT_CODE_ERROR
+	result.Message = err.Error()
+	result.ErrorCategory, result.ErrorReason, result.Retryable = pluginErr.Category, pluginErr.Code, pluginErr.Retryable
 }
 
 type Filter struct {
@@ -60,34 +78,23 @@ func IsValidValue(inputValue string, validValues []string) error {
 }
 
 func GetRegionFromProviderParams(providerParams string) (string, error) {
-	paramMap, err := GetMapFromProviderParams(providerParams)
+	params, err := ParseProviderParams(providerParams)
 	if err != nil {
-		return "", err
-	}
-
-	region, ok := paramMap["Region"]
-	if !ok {
-		return region, fmt.Errorf("region not found in providerParams")
+		return params.Region, err
 	}
-	return region, nil
+	return params.Region, nil
 }
 
+// GetMapFromProviderParams returns the items of provider params by key without checking the keys.
+// Deprecated: use NewProviderParams, which merges location and api_secret and validates the keys.
 func GetMapFromProviderParams(providerParams string) (map[string]string, error) {
 	rtnMap := make(map[string]string)
-	params := strings.Split(providerParams, ";")
-
-	if len(params) == 0 {
-		return rtnMap, nil
+	items, err := splitProviderParams(providerParams)
+	if err != nil {
+		return rtnMap, fmt.Errorf("GetMapFromProviderParams meet illegal format, error=%v", err)
 	}
-
-	for _, param := range params {
-		afterTrimParam := strings.Trim(param, " ")
-		kv := strings.Split(afterTrimParam, "=")
-		if len(kv) == 2 {
-			rtnMap[kv[0]] = kv[1]
-		} else {
-			return rtnMap, fmt.Errorf("GetMapFromProviderParams meet illegal format param=%s", param)
-		}
+	for _, item := range items {
+		rtnMap[item[0]] = item[1]
 	}
 	return rtnMap, nil
 }
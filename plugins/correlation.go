@@ -0,0 +1,240 @@
+package plugins
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"reflect"
+	"regexp"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// CORRELATION_ID_HEADER carries the correlation id of the platform request, one is generated if the request
+	// does not have a valid one. It is returned in the header and the body of the response.
+	CORRELATION_ID_HEADER = "X-Correlation-Id"
+
+	LOG_FIELD_CORRELATION_ID = "correlationId"
+	LOG_FIELD_TRACE_ID       = "traceId"
+	LOG_FIELD_INPUT_INDEX    = "input"
+
+	MAX_CORRELATION_ID_LENGTH = 128
+	// the client tokens of the cloud APIs are at most 64 characters.
+	MAX_CLIENT_TOKEN_LENGTH = 64
+)
+
+var correlationIdPattern = regexp.MustCompile(`^[0-9A-Za-z._:-]+$`)
+
+type correlationIdKey struct{}
+
+type inputIndexKey struct{}
+
+type inputIndexesKey struct{}
+
+type requestIdRecorderKey struct{}
+
+type actionInfoKey struct{}
+
+// actionInfo is the plugin action run with ctx, guids are the guids of its inputs.
+type actionInfo struct {
+	plugin string
+	action string
+	guids  []string
+}
+
+// NewCorrelationId returns a random id of 32 hex characters.
+func NewCorrelationId() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return strconv.FormatInt(time.Now().UnixNano(), 16)
+	}
+	return hex.EncodeToString(b)
+}
+
+// ContextWithCorrelationId returns ctx with the correlation id, a new one is generated if the id is empty
+// or it is not made of letters, digits and "._:-" within MAX_CORRELATION_ID_LENGTH characters.
+func ContextWithCorrelationId(ctx context.Context, correlationId string) context.Context {
+	if correlationId != "" && (len(correlationId) > MAX_CORRELATION_ID_LENGTH || !correlationIdPattern.MatchString(correlationId)) {
+		logrus.Warnf("correlation id %q is invalid, generate a new one", correlationId)
+		correlationId = ""
+	}
+	if correlationId == "" {
+		correlationId = NewCorrelationId()
+	}
+	return context.WithValue(ctx, correlationIdKey{}, correlationId)
+}
+
+// GetCorrelationId returns the correlation id of ctx, empty if there is none.
+func GetCorrelationId(ctx context.Context) string {
+	correlationId, _ := ctx.Value(correlationIdKey{}).(string)
+	return correlationId
+}
+
+// Logger returns the logger of ctx, whose entries have the correlation id, the trace id and the index of
+// the input, so the entries of concurrent requests and inputs can be told apart.
+func Logger(ctx context.Context) *logrus.Entry {
+	fields := logrus.Fields{}
+	if correlationId := GetCorrelationId(ctx); correlationId != "" {
+		fields[LOG_FIELD_CORRELATION_ID] = correlationId
+	}
+	if span := SpanFromContext(ctx); span != nil {
+		fields[LOG_FIELD_TRACE_ID] = span.TraceId.String()
+	}
+	if i, found := getInputIndex(ctx); found {
+		fields[LOG_FIELD_INPUT_INDEX] = i
+	}
+	return logrus.WithFields(fields)
+}
+
+// detachContext returns a background context with the correlation id and the span of ctx, for the work
+// which goes on after ctx is done, such as async tasks.
+func detachContext(ctx context.Context) context.Context {
+	detached := ContextWithSpan(context.Background(), SpanFromContext(ctx))
+	if correlationId := GetCorrelationId(ctx); correlationId != "" {
+		detached = context.WithValue(detached, correlationIdKey{}, correlationId)
+	}
+	return detached
+}
+
+// withInputIndex returns ctx of the input at index i of runInputs. The index is mapped to the index of the
+// action inputs if only some of them are run, and the index of the outer input is kept if runInputs is
+// called by an input.
+func withInputIndex(ctx context.Context, i int) context.Context {
+	if _, found := getInputIndex(ctx); found {
+		return ctx
+	}
+	if indexes, ok := ctx.Value(inputIndexesKey{}).([]int); ok && i < len(indexes) {
+		i = indexes[i]
+	}
+	return context.WithValue(ctx, inputIndexKey{}, i)
+}
+
+// withInputIndexes tells runInputs the index of the action inputs of each input it runs, they are the
+// inputs which the idempotency store does not replay.
+func withInputIndexes(ctx context.Context, indexes []int) context.Context {
+	return context.WithValue(ctx, inputIndexesKey{}, indexes)
+}
+
+func getInputIndex(ctx context.Context) (int, bool) {
+	i, ok := ctx.Value(inputIndexKey{}).(int)
+	return i, ok
+}
+
+func withActionInfo(ctx context.Context, pluginName, actionName string, guids []string) context.Context {
+	return context.WithValue(ctx, actionInfoKey{}, &actionInfo{plugin: pluginName, action: actionName, guids: guids})
+}
+
+// getActionInfo returns the plugin action of ctx, nil if ctx is not of an action.
+func getActionInfo(ctx context.Context) *actionInfo {
+	info, _ := ctx.Value(actionInfoKey{}).(*actionInfo)
+	return info
+}
+
+// getInputGuid returns the guid of the input of ctx, or the guid of the only input if ctx is not of an input.
+func (info *actionInfo) getInputGuid(ctx context.Context) string {
+	if i, found := getInputIndex(ctx); found {
+		if i < len(info.guids) {
+			return info.guids[i]
+		}
+		return ""
+	}
+	if len(info.guids) == 1 {
+		return info.guids[0]
+	}
+	return ""
+}
+
+// isUniqueGuid tells whether no other input of the action has the guid.
+func (info *actionInfo) isUniqueGuid(guid string) bool {
+	count := 0
+	for _, inputGuid := range info.guids {
+		if inputGuid == guid {
+			count++
+		}
+	}
+	return count == 1
+}
+
+// GetClientToken returns the client token of the cloud APIs which create resources, nil if ctx has no
+// correlation id. The token is the hash of the correlation id, the plugin, the action and the guid of the
+// input, so a retry of the same input with the same correlation id does not create the resource twice,
+// while other inputs and actions sharing the correlation id never get the same token. The index of the
+// input is added if its guid is empty or shared by other inputs.
+func GetClientToken(ctx context.Context) *string {
+	correlationId := GetCorrelationId(ctx)
+	if correlationId == "" {
+		return nil
+	}
+	key := correlationId
+	withIndex := true
+	if info := getActionInfo(ctx); info != nil {
+		guid := info.getInputGuid(ctx)
+		key += "/" + info.plugin + "/" + info.action + "/" + guid
+		withIndex = guid == "" || !info.isUniqueGuid(guid)
+	}
+	if i, found := getInputIndex(ctx); found && withIndex {
+		key += "/" + strconv.Itoa(i)
+	}
+	sum := sha256.Sum256([]byte(key))
+	token := hex.EncodeToString(sum[:])[:MAX_CLIENT_TOKEN_LENGTH]
+	return &token
+}
+
+// requestIdRecorder keeps the RequestIds of the cloud API requests sent by each input of an action.
+type requestIdRecorder struct {
+	mutex      sync.Mutex
+	requestIds map[int][]string
+}
+
+func withRequestIdRecorder(ctx context.Context) (context.Context, *requestIdRecorder) {
+	recorder := &requestIdRecorder{requestIds: make(map[int][]string)}
+	return context.WithValue(ctx, requestIdRecorderKey{}, recorder), recorder
+}
+
+// recordRequestId logs the RequestId of the cloud API request with the correlation id, and keeps it for
+// the output of the input which sent the request.
+func recordRequestId(ctx context.Context, service, action, region, requestId string) {
+	Logger(ctx).Infof("cloud api %v.%v in region %v returns RequestId %v", service, action, region, requestId)
+	recorder, ok := ctx.Value(requestIdRecorderKey{}).(*requestIdRecorder)
+	i, found := getInputIndex(ctx)
+	if !ok || !found {
+		return
+	}
+
+	recorder.mutex.Lock()
+	defer recorder.mutex.Unlock()
+
+	recorder.requestIds[i] = append(recorder.requestIds[i], requestId)
+}
+
+// fillOutputs sets the RequestIds of the result of each output by the index of its input.
+func (recorder *requestIdRecorder) fillOutputs(actionResult interface{}) {
+	outputs := getSliceField(actionResult, "Outputs")
+	if !outputs.IsValid() {
+		return
+	}
+
+	recorder.mutex.Lock()
+	defer recorder.mutex.Unlock()
+
+	for i := 0; i < outputs.Len(); i++ {
+		requestIds, found := recorder.requestIds[i]
+		if !found {
+			continue
+		}
+		output := reflect.Indirect(outputs.Index(i))
+		if output.Kind() != reflect.Struct {
+			continue
+		}
+		if field := output.FieldByName("Result"); field.IsValid() && field.CanAddr() {
+			if result, ok := field.Addr().Interface().(*Result); ok {
+				result.RequestIds = append([]string{}, requestIds...)
+			}
+		}
+	}
+}
@@ -0,0 +1,173 @@
+package plugins
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/common"
+	cvm "github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/cvm/v20170312"
+)
+
+func TestGetClientToken(t *testing.T) {
+	if token := GetClientToken(context.Background()); token != nil {
+		t.Errorf("client token without correlation id=%v", *token)
+	}
+
+	ctx := ContextWithCorrelationId(context.Background(), "wecube-request-1")
+	vmCtx := withActionInfo(ctx, "vm", "create", []string{"guid_1", "guid_2"})
+	token1, token2 := GetClientToken(withInputIndex(vmCtx, 0)), GetClientToken(withInputIndex(vmCtx, 1))
+	if token1 == nil || len(*token1) != MAX_CLIENT_TOKEN_LENGTH || *token1 == *token2 {
+		t.Errorf("client tokens of inputs=%v, %v", token1, token2)
+	}
+	// the idempotency store runs the input 1 only, it is the input of guid_2.
+	if token := GetClientToken(withInputIndex(withInputIndexes(vmCtx, []int{1}), 0)); *token != *token2 {
+		t.Errorf("client token of rerun input=%v, expected %v", *token, *token2)
+	}
+	// the token is of the guid, not of the position of the input.
+	if token := GetClientToken(withInputIndex(withActionInfo(ctx, "vm", "create", []string{"guid_2"}), 0)); *token != *token2 {
+		t.Errorf("client token of guid_2 alone=%v, expected %v", *token, *token2)
+	}
+
+	// other requests sharing the correlation id get other tokens.
+	tokens := map[string]bool{*token1: true, *token2: true}
+	for _, otherCtx := range []context.Context{
+		withActionInfo(ctx, "vm", "create", []string{"guid_3"}),
+		withActionInfo(ctx, "storage", "create", []string{"guid_1"}),
+		withActionInfo(ctx, "mysql", "create", []string{"guid_1"}),
+	} {
+		token := GetClientToken(withInputIndex(otherCtx, 0))
+		if tokens[*token] {
+			t.Errorf("client token %v is shared by requests of the same correlation id", *token)
+		}
+		tokens[*token] = true
+	}
+
+	// inputs sharing the guid are told apart by their index.
+	sharedCtx := withActionInfo(ctx, "vm", "create", []string{"guid_1", "guid_1"})
+	if *GetClientToken(withInputIndex(sharedCtx, 0)) == *GetClientToken(withInputIndex(sharedCtx, 1)) {
+		t.Errorf("inputs of the same guid share the client token")
+	}
+
+	if correlationId := GetCorrelationId(ContextWithCorrelationId(context.Background(), "bad id\n")); correlationId == "bad id\n" || correlationId == "" {
+		t.Errorf("invalid correlation id is kept, correlation id=%q", correlationId)
+	}
+}
+
+// newCorrelationTestAction describes instances once for the first input and twice for the second one.
+func newCorrelationTestAction(factory *ClientFactory) *testAction {
+	return &testAction{runInput: func(ctx context.Context, i int, input testInput, output *testOutput) error {
+		client, _ := factory.WithContext(ctx).NewCvmClient("ap-correlation", "fake-secret-id", "fake-secret-key")
+		for call := 0; call <= i; call++ {
+			if _, err := client.DescribeInstances(cvm.NewDescribeInstancesRequest()); err != nil {
+				return err
+			}
+		}
+		return nil
+	}}
+}
+
+func TestRequestIdsOfOutputs(t *testing.T) {
+	requestCount := 0
+	server, _ := newScriptedServer(func(action, body string, times int) string {
+		requestCount++
+		return fmt.Sprintf(`{"Response":{"RequestId":"request-%d"}}`, requestCount)
+	})
+	defer server.Close()
+
+	action := newCorrelationTestAction(newRetryTestFactory(server, &RetryPolicy{}))
+	ctx := ContextWithCorrelationId(context.Background(), "wecube-request-1")
+	results, err := doAction(ctx, "test", "describe", action, newTestInputs("guid_1", "guid_2"))
+	if err != nil {
+		t.Fatalf("do action meet error=%v", err)
+	}
+
+	outputs := getTestOutputs(results)
+	if outputs[0].Guid != "guid_1" || outputs[1].Guid != "guid_2" || len(outputs[0].RequestIds) != 1 || len(outputs[1].RequestIds) != 2 {
+		t.Fatalf("outputs=%+v", outputs)
+	}
+	requestIds := map[string]bool{}
+	for _, output := range outputs {
+		for _, requestId := range output.RequestIds {
+			requestIds[requestId] = true
+		}
+	}
+	if len(requestIds) != 3 {
+		t.Errorf("request ids of outputs=%v", requestIds)
+	}
+}
+
+func TestRetryOfIdempotentActionKeepsInputIndexes(t *testing.T) {
+	store, clean := newTestIdempotencyStore(t)
+	defer clean()
+	defer func(store *IdempotencyStore) { DefaultIdempotencyStore = store }(DefaultIdempotencyStore)
+	DefaultIdempotencyStore = store
+	journal, cleanup := newTestAuditJournal(t)
+	defer cleanup()
+
+	// the client tokens sent by each input, the first request of guid_2 fails.
+	mutex := sync.Mutex{}
+	tokens := make(map[string][]string)
+	requestCount := 0
+	server, _ := newScriptedServer(func(action, body string, times int) string {
+		request := struct {
+			InstanceName string
+			ClientToken  string
+		}{}
+		json.Unmarshal([]byte(body), &request)
+		mutex.Lock()
+		defer mutex.Unlock()
+		requestCount++
+		tokens[request.InstanceName] = append(tokens[request.InstanceName], request.ClientToken)
+		if request.InstanceName == "guid_2" && len(tokens["guid_2"]) == 1 {
+			return errorResponse("ResourceInsufficient")
+		}
+		return fmt.Sprintf(`{"Response":{"InstanceIdSet":["ins-%d"],"RequestId":"request-%d"}}`, requestCount, requestCount)
+	})
+	defer server.Close()
+
+	factory := newRetryTestFactory(server, &RetryPolicy{})
+	action := &testAction{serial: true, runInput: func(ctx context.Context, i int, input testInput, output *testOutput) error {
+		client, _ := factory.WithContext(ctx).NewCvmClient("ap-retry", "fake-secret-id", "fake-secret-key")
+		request := cvm.NewRunInstancesRequest()
+		request.InstanceName = common.StringPtr(input.Guid)
+		request.ClientToken = GetClientToken(ctx)
+		response, err := client.RunInstances(request)
+		if err != nil {
+			return err
+		}
+		output.Id = *response.Response.InstanceIdSet[0]
+		return nil
+	}}
+
+	ctx := ContextWithCorrelationId(context.Background(), "corr-1")
+	inputs := newTestInputs("guid_1", "guid_2")
+	if _, err := doAction(ctx, "vm", "create", action, inputs); err == nil {
+		t.Fatalf("the first run succeeded")
+	}
+	// guid_1 is replayed, guid_2 is run again as the input 1 of the action.
+	results, err := doAction(ctx, "vm", "create", action, inputs)
+	if err != nil {
+		t.Fatalf("retry meet error=%v", err)
+	}
+
+	if len(tokens["guid_1"]) != 1 || len(tokens["guid_2"]) != 2 || tokens["guid_2"][0] != tokens["guid_2"][1] || tokens["guid_2"][1] == tokens["guid_1"][0] {
+		t.Errorf("client tokens=%v", tokens)
+	}
+	outputs := getTestOutputs(results)
+	if outputs[0].Id != "ins-1" || len(outputs[0].RequestIds) != 0 {
+		t.Errorf("replayed output=%+v", outputs[0])
+	}
+	if outputs[1].Id != "ins-3" || len(outputs[1].RequestIds) != 1 || outputs[1].RequestIds[0] != "request-3" {
+		t.Errorf("output of the retried input=%+v", outputs[1])
+	}
+	records, _ := journal.Query(AuditFilter{Guid: "guid_2"})
+	if len(records) != 2 || records[0].Outcome != AUDIT_OUTCOME_FAILURE || records[1].ResourceIds[0] != "ins-3" {
+		t.Errorf("records of guid_2=%+v", records)
+	}
+	if records, _ = journal.Query(AuditFilter{Guid: "guid_1"}); len(records) != 1 {
+		t.Errorf("records of guid_1=%+v", records)
+	}
+}
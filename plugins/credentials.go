@@ -0,0 +1,342 @@
+package plugins
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/common"
+	tchttp "github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/common/http"
+	cos "github.com/tencentyun/cos-go-sdk-v5"
+	legacyCommon "github.com/zqfan/tencentcloud-sdk-go/common"
+)
+
+const (
+	QCLOUD_SERVICE_STS = "sts"
+	STS_API_VERSION    = "2018-08-13"
+
+	ENV_TENCENTCLOUD_SECRET_ID     = "TENCENTCLOUD_SECRET_ID"
+	ENV_TENCENTCLOUD_SECRET_KEY    = "TENCENTCLOUD_SECRET_KEY"
+	ENV_TENCENTCLOUD_SESSION_TOKEN = "TENCENTCLOUD_SESSION_TOKEN"
+	// the env of the keys used by the security group business plugin before.
+	ENV_SECRET_ID  = "SECRET_ID"
+	ENV_SECRET_KEY = "SECRET_KEY"
+
+	DEFAULT_CREDENTIALS_PROFILE = "default"
+	DEFAULT_STS_REGION          = "ap-guangzhou"
+	DEFAULT_STS_SESSION_NAME    = "wecube-plugins-qcloud"
+	DEFAULT_STS_DURATION        = 2 * time.Hour
+	// temporary credentials are refreshed when they expire in a quarter of their duration,
+	// so the clients created before the refresh can still finish their actions.
+	STS_REFRESH_RATIO = 4
+)
+
+// Credential signs the cloud API requests, Token and Expiration are set for temporary credentials.
+type Credential struct {
+	SecretId   string
+	SecretKey  string
+	Token      string
+	Expiration time.Time
+}
+
+func (credential *Credential) toSdkCredential() *common.Credential {
+	return common.NewTokenCredential(credential.SecretId, credential.SecretKey, credential.Token)
+}
+
+func (credential *Credential) isExpired(now time.Time) bool {
+	return !credential.Expiration.IsZero() && !now.Before(credential.Expiration)
+}
+
+// CredentialProvider provides the credential of the requests whose provider_params do not supply
+// SecretID and SecretKey. GetCredential returns nil without error if the provider has no credential.
+type CredentialProvider interface {
+	GetCredential() (*Credential, error)
+}
+
+// DefaultCredentialProvider is used by the client factories without Credentials,
+// the credentials can be set in env if it is not replaced by the config.
+var DefaultCredentialProvider CredentialProvider = CredentialProviderChain{&EnvCredentialProvider{}}
+
+// CredentialProviderChain returns the credential of the first provider which has one.
+type CredentialProviderChain []CredentialProvider
+
+func (chain CredentialProviderChain) GetCredential() (*Credential, error) {
+	for _, provider := range chain {
+		credential, err := provider.GetCredential()
+		if err != nil || credential != nil {
+			return credential, err
+		}
+	}
+	return nil, nil
+}
+
+// EnvCredentialProvider reads TENCENTCLOUD_SECRET_ID, TENCENTCLOUD_SECRET_KEY and TENCENTCLOUD_SESSION_TOKEN,
+// or SECRET_ID and SECRET_KEY.
+type EnvCredentialProvider struct{}
+
+func (provider *EnvCredentialProvider) GetCredential() (*Credential, error) {
+	if secretId, secretKey := os.Getenv(ENV_TENCENTCLOUD_SECRET_ID), os.Getenv(ENV_TENCENTCLOUD_SECRET_KEY); secretId != "" && secretKey != "" {
+		return &Credential{SecretId: secretId, SecretKey: secretKey, Token: os.Getenv(ENV_TENCENTCLOUD_SESSION_TOKEN)}, nil
+	}
+	if secretId, secretKey := os.Getenv(ENV_SECRET_ID), os.Getenv(ENV_SECRET_KEY); secretId != "" && secretKey != "" {
+		return &Credential{SecretId: secretId, SecretKey: secretKey}, nil
+	}
+	return nil, nil
+}
+
+// FileCredentialProvider reads the credential of a profile in a credentials file, such as a mounted secret.
+// The file is in the format of the Tencent Cloud CLI, and is read again once it is modified:
+//
+//	[default]
+//	secret_id = AKIDxxxxxxxx
+//	secret_key = xxxxxxxx
+//	token = xxxxxxxx
+type FileCredentialProvider struct {
+	Path string
+	// Profile is the section of the credential, "default" if empty.
+	Profile string
+
+	mutex      sync.Mutex
+	modTime    time.Time
+	credential *Credential
+}
+
+func (provider *FileCredentialProvider) GetCredential() (*Credential, error) {
+	info, err := os.Stat(provider.Path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	provider.mutex.Lock()
+	defer provider.mutex.Unlock()
+
+	if provider.credential != nil && info.ModTime().Equal(provider.modTime) {
+		return provider.credential, nil
+	}
+	profile := provider.Profile
+	if profile == "" {
+		profile = DEFAULT_CREDENTIALS_PROFILE
+	}
+	items, err := readCredentialsFile(provider.Path, profile)
+	if err != nil {
+		return nil, err
+	}
+	if items["secret_id"] == "" || items["secret_key"] == "" {
+		return nil, fmt.Errorf("secret_id or secret_key of profile %s is not found in credentials file %s", profile, provider.Path)
+	}
+	provider.credential = &Credential{SecretId: items["secret_id"], SecretKey: items["secret_key"], Token: items["token"]}
+	provider.modTime = info.ModTime()
+	logrus.Infof("credential of profile %s is loaded from %s", profile, provider.Path)
+	return provider.credential, nil
+}
+
+// readCredentialsFile returns the items of a profile in an ini file.
+func readCredentialsFile(path, profile string) (map[string]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	items := make(map[string]string)
+	section := ""
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || line[0] == '#' || line[0] == ';' {
+			continue
+		}
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			section = strings.TrimSpace(line[1 : len(line)-1])
+			continue
+		}
+		if kv := strings.SplitN(line, "=", 2); section == profile && len(kv) == 2 {
+			items[strings.TrimSpace(kv[0])] = strings.TrimSpace(kv[1])
+		}
+	}
+	return items, scanner.Err()
+}
+
+// StsCredentialProvider assumes a CAM role by STS with the credential of Base, and refreshes
+// the temporary credential before it expires.
+type StsCredentialProvider struct {
+	RoleArn string
+	// RoleSessionName is "wecube-plugins-qcloud" if empty.
+	RoleSessionName string
+	// Region of the STS API, "ap-guangzhou" if empty.
+	Region string
+	// Duration of the temporary credentials, 2 hours if zero.
+	Duration time.Duration
+	Base     CredentialProvider
+
+	mutex      sync.Mutex
+	credential *Credential
+}
+
+func (provider *StsCredentialProvider) GetCredential() (*Credential, error) {
+	provider.mutex.Lock()
+	defer provider.mutex.Unlock()
+
+	duration := provider.Duration
+	if duration <= 0 {
+		duration = DEFAULT_STS_DURATION
+	}
+	now := time.Now()
+	if provider.credential != nil && provider.credential.Expiration.Sub(now) > duration/STS_REFRESH_RATIO {
+		return provider.credential, nil
+	}
+
+	credential, err := provider.assumeRole(duration)
+	if err != nil {
+		if provider.credential != nil && !provider.credential.isExpired(now) {
+			logrus.Warnf("refresh credential of role %s meet error=%v, the current one expires at %v",
+				provider.RoleArn, err, provider.credential.Expiration)
+			return provider.credential, nil
+		}
+		return nil, err
+	}
+	logrus.Infof("credential of role %s is refreshed, expires at %v", provider.RoleArn, credential.Expiration)
+	provider.credential = credential
+	return credential, nil
+}
+
+func (provider *StsCredentialProvider) assumeRole(duration time.Duration) (*Credential, error) {
+	if provider.Base == nil {
+		return nil, errors.New("no base credential provider to assume role")
+	}
+	base, err := provider.Base.GetCredential()
+	if err != nil {
+		return nil, err
+	}
+	if base == nil {
+		return nil, fmt.Errorf("no base credential to assume role %s", provider.RoleArn)
+	}
+
+	region, sessionName := provider.Region, provider.RoleSessionName
+	if region == "" {
+		region = DEFAULT_STS_REGION
+	}
+	if sessionName == "" {
+		sessionName = DEFAULT_STS_SESSION_NAME
+	}
+	// the refresh is shared by all requests, it is not canceled with any of them.
+	client := GetClientFactory().newStsClient(region, base)
+	request := newAssumeRoleRequest()
+	request.RoleArn = common.StringPtr(provider.RoleArn)
+	request.RoleSessionName = common.StringPtr(sessionName)
+	request.DurationSeconds = common.Uint64Ptr(uint64(duration / time.Second))
+	response := newAssumeRoleResponse()
+	if err = client.Send(request, response); err != nil {
+		return nil, fmt.Errorf("assume role %s meet error=%v", provider.RoleArn, err)
+	}
+
+	result := response.Response
+	if result == nil || result.Credentials == nil || result.Credentials.TmpSecretId == nil ||
+		result.Credentials.TmpSecretKey == nil || result.Credentials.Token == nil || result.ExpiredTime == nil {
+		return nil, fmt.Errorf("assume role %s returns no credential", provider.RoleArn)
+	}
+	return &Credential{
+		SecretId:   *result.Credentials.TmpSecretId,
+		SecretKey:  *result.Credentials.TmpSecretKey,
+		Token:      *result.Credentials.Token,
+		Expiration: time.Unix(*result.ExpiredTime, 0),
+	}, nil
+}
+
+type assumeRoleRequest struct {
+	*tchttp.BaseRequest
+
+	RoleArn         *string `json:"RoleArn,omitempty" name:"RoleArn"`
+	RoleSessionName *string `json:"RoleSessionName,omitempty" name:"RoleSessionName"`
+	DurationSeconds *uint64 `json:"DurationSeconds,omitempty" name:"DurationSeconds"`
+}
+
+type assumeRoleResponse struct {
+	*tchttp.BaseResponse
+	Response *struct {
+		Credentials *struct {
+			Token        *string `json:"Token,omitempty"`
+			TmpSecretId  *string `json:"TmpSecretId,omitempty"`
+			TmpSecretKey *string `json:"TmpSecretKey,omitempty"`
+		} `json:"Credentials,omitempty"`
+		ExpiredTime *int64  `json:"ExpiredTime,omitempty"`
+		RequestId   *string `json:"RequestId,omitempty"`
+	} `json:"Response"`
+}
+
+func newAssumeRoleRequest() *assumeRoleRequest {
+	request := &assumeRoleRequest{BaseRequest: &tchttp.BaseRequest{}}
+	request.Init().WithApiInfo(QCLOUD_SERVICE_STS, STS_API_VERSION, "AssumeRole")
+	return request
+}
+
+func newAssumeRoleResponse() *assumeRoleResponse {
+	return &assumeRoleResponse{BaseResponse: &tchttp.BaseResponse{}}
+}
+
+// cosCredentialTransport signs COS requests with the credential of the client factory when they are sent.
+type cosCredentialTransport struct {
+	factory   *ClientFactory
+	secretId  string
+	secretKey string
+	next      http.RoundTripper
+}
+
+func (transport *cosCredentialTransport) RoundTrip(request *http.Request) (*http.Response, error) {
+	credential, err := transport.factory.getCredential(transport.secretId, transport.secretKey)
+	if err != nil {
+		return nil, err
+	}
+	authorization := &cos.AuthorizationTransport{
+		SecretID:     credential.SecretId,
+		SecretKey:    credential.SecretKey,
+		SessionToken: credential.Token,
+		Transport:    transport.next,
+	}
+	return authorization.RoundTrip(request)
+}
+
+// signLegacyRequestWithToken adds the token of the temporary credential to a legacy API request and signs it again.
+func signLegacyRequestWithToken(request *http.Request, credential *Credential) (*http.Request, error) {
+	if request.Method != http.MethodGet {
+		return nil, fmt.Errorf("legacy api request of method %s can not be signed with temporary credential", request.Method)
+	}
+	legacyRequest := &legacyCommon.BaseRequest{}
+	legacyRequest.Init()
+	if request.URL.Path != legacyRequest.GetPath() {
+		return nil, fmt.Errorf("legacy api request of path %s can not be signed with temporary credential", request.URL.Path)
+	}
+	host := request.Host
+	if host == "" {
+		host = request.URL.Host
+	}
+	legacyRequest.SetDomain(host)
+
+	query := request.URL.Query()
+	params := legacyRequest.GetParams()
+	for key := range query {
+		params[key] = query.Get(key)
+	}
+	legacyCredential := legacyCommon.NewTokenCredential(credential.SecretId, credential.SecretKey, credential.Token)
+	if err := legacyCommon.Sign(legacyRequest, legacyCredential, query.Get("SignatureMethod")); err != nil {
+		return nil, err
+	}
+
+	values := url.Values{}
+	for key, value := range params {
+		values.Set(key, value)
+	}
+	request = cloneRequestWithUrl(request)
+	request.URL.RawQuery = values.Encode()
+	return request, nil
+}
@@ -0,0 +1,221 @@
+package plugins
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	cvm "github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/cvm/v20170312"
+	legacyCommon "github.com/zqfan/tencentcloud-sdk-go/common"
+	unversioned "github.com/zqfan/tencentcloud-sdk-go/services/vpc/unversioned"
+)
+
+type staticCredentialProvider struct {
+	credential *Credential
+}
+
+func (provider *staticCredentialProvider) GetCredential() (*Credential, error) {
+	return provider.credential, nil
+}
+
+// newCredentialServer answers AssumeRole with a temporary credential which expires in expire, and records
+// the requests of other actions.
+func newCredentialServer(expire time.Duration) (*httptest.Server, func() []*http.Request) {
+	mutex := sync.Mutex{}
+	requests := []*http.Request{}
+	assumed := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		mutex.Lock()
+		defer mutex.Unlock()
+		requests = append(requests, r)
+		if r.Header.Get("X-TC-Action") == "AssumeRole" {
+			assumed++
+			fmt.Fprintf(w, `{"Response":{"Credentials":{"Token":"token-%d","TmpSecretId":"tmp-secret-id-%d","TmpSecretKey":"tmp-secret-key"},"ExpiredTime":%d,"RequestId":""}}`,
+				assumed, assumed, time.Now().Add(expire).Unix())
+			return
+		}
+		w.Write([]byte(`{"Response":{"RequestId":""},"code":0,"message":"","data":[]}`))
+	}))
+	return server, func() []*http.Request {
+		mutex.Lock()
+		defer mutex.Unlock()
+		return append([]*http.Request{}, requests...)
+	}
+}
+
+func setCredentialTestFactory(server *httptest.Server, credentials CredentialProvider) {
+	serverUrl, _ := url.Parse(server.URL)
+	SetClientFactory(&ClientFactory{
+		Scheme: "http",
+		Endpoints: map[string]string{
+			QCLOUD_SERVICE_STS:        serverUrl.Host,
+			QCLOUD_SERVICE_CVM:        serverUrl.Host,
+			QCLOUD_SERVICE_LEGACY_VPC: serverUrl.Host,
+		},
+		RateLimit:   &RateLimiter{Rate: -1, ActionRates: map[string]float64{}},
+		Credentials: credentials,
+	})
+}
+
+func TestEnvAndFileCredentialProviders(t *testing.T) {
+	for _, key := range []string{ENV_TENCENTCLOUD_SECRET_ID, ENV_TENCENTCLOUD_SECRET_KEY, ENV_SECRET_ID, ENV_SECRET_KEY} {
+		defer os.Setenv(key, os.Getenv(key))
+		os.Unsetenv(key)
+	}
+	dir, err := ioutil.TempDir("", "credentials")
+	if err != nil {
+		t.Fatalf("create temp dir meet error=%v", err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "credentials")
+
+	fileProvider := &FileCredentialProvider{Path: path, Profile: "wecube"}
+	chain := CredentialProviderChain{&EnvCredentialProvider{}, fileProvider}
+	if credential, err := chain.GetCredential(); credential != nil || err != nil {
+		t.Errorf("no env and file, credential=%+v, error=%v", credential, err)
+	}
+
+	ioutil.WriteFile(path, []byte("[default]\nsecret_id = id-1\nsecret_key = key-1\n\n[wecube]\nsecret_id = id-2\nsecret_key = key-2\n"), 0600)
+	if credential, err := chain.GetCredential(); err != nil || credential == nil || credential.SecretId != "id-2" || credential.SecretKey != "key-2" {
+		t.Errorf("file, credential=%+v, error=%v", credential, err)
+	}
+	// the mounted file is replaced.
+	ioutil.WriteFile(path, []byte("[wecube]\nsecret_id = id-3\nsecret_key = key-3\ntoken = token-3\n"), 0600)
+	os.Chtimes(path, time.Now(), time.Now().Add(time.Minute))
+	if credential, err := chain.GetCredential(); err != nil || credential == nil || credential.SecretId != "id-3" || credential.Token != "token-3" {
+		t.Errorf("modified file, credential=%+v, error=%v", credential, err)
+	}
+
+	os.Setenv(ENV_SECRET_ID, "id-4")
+	os.Setenv(ENV_SECRET_KEY, "key-4")
+	if credential, err := chain.GetCredential(); err != nil || credential == nil || credential.SecretId != "id-4" {
+		t.Errorf("env, credential=%+v, error=%v", credential, err)
+	}
+	os.Setenv(ENV_TENCENTCLOUD_SECRET_ID, "id-5")
+	os.Setenv(ENV_TENCENTCLOUD_SECRET_KEY, "key-5")
+	if credential, err := chain.GetCredential(); err != nil || credential == nil || credential.SecretId != "id-5" {
+		t.Errorf("tencentcloud env, credential=%+v, error=%v", credential, err)
+	}
+}
+
+func TestStsCredentialProviderRefreshesCredential(t *testing.T) {
+	server, getRequests := newCredentialServer(time.Hour)
+	defer server.Close()
+	defer SetClientFactory(GetClientFactory())
+	setCredentialTestFactory(server, nil)
+
+	provider := &StsCredentialProvider{
+		RoleArn:  "qcs::cam::uin/100000000001:roleName/wecube",
+		Duration: time.Hour,
+		Base:     &staticCredentialProvider{&Credential{SecretId: "base-secret-id", SecretKey: "base-secret-key"}},
+	}
+	for i := 0; i < 2; i++ {
+		credential, err := provider.GetCredential()
+		if err != nil || credential == nil || credential.SecretId != "tmp-secret-id-1" || credential.Token != "token-1" {
+			t.Fatalf("credential=%+v, error=%v", credential, err)
+		}
+	}
+	requests := getRequests()
+	if len(requests) != 1 || !strings.Contains(requests[0].Header.Get("Authorization"), "Credential=base-secret-id/") {
+		t.Fatalf("AssumeRole should be called once with the base credential, requests=%v", len(requests))
+	}
+
+	// refreshed before it expires.
+	provider.credential.Expiration = time.Now().Add(time.Minute)
+	if credential, err := provider.GetCredential(); err != nil || credential.SecretId != "tmp-secret-id-2" {
+		t.Errorf("refreshed credential=%+v, error=%v", credential, err)
+	}
+
+	// the current credential is used until it expires if the refresh fails.
+	provider.credential.Expiration = time.Now().Add(time.Minute)
+	provider.Base = CredentialProviderChain{}
+	if credential, err := provider.GetCredential(); err != nil || credential.SecretId != "tmp-secret-id-2" {
+		t.Errorf("credential=%+v, error=%v", credential, err)
+	}
+	provider.credential.Expiration = time.Now()
+	if credential, err := provider.GetCredential(); err == nil {
+		t.Errorf("expired credential=%+v is returned", credential)
+	}
+}
+
+func TestClientFactoryUsesCredentialProvider(t *testing.T) {
+	server, getRequests := newCredentialServer(time.Hour)
+	defer server.Close()
+	defer SetClientFactory(GetClientFactory())
+	setCredentialTestFactory(server, &StsCredentialProvider{
+		RoleArn: "qcs::cam::uin/100000000001:roleName/wecube",
+		Base:    &staticCredentialProvider{&Credential{SecretId: "base-secret-id", SecretKey: "base-secret-key"}},
+	})
+
+	client, err := GetClientFactory().NewCvmClient("ap-guangzhou", "", "")
+	if err != nil {
+		t.Fatalf("NewCvmClient meet error=%v", err)
+	}
+	client.DescribeInstances(cvm.NewDescribeInstancesRequest())
+	requests := getRequests()
+	if len(requests) != 2 || requests[1].Header.Get("X-TC-Token") != "token-1" ||
+		!strings.Contains(requests[1].Header.Get("Authorization"), "Credential=tmp-secret-id-1/") {
+		t.Errorf("DescribeInstances is not signed by the temporary credential, requests=%v", len(requests))
+	}
+
+	// the keys of the request are used.
+	client, _ = GetClientFactory().NewCvmClient("ap-guangzhou", "request-secret-id", "request-secret-key")
+	client.DescribeInstances(cvm.NewDescribeInstancesRequest())
+	if requests = getRequests(); len(requests) != 3 || requests[2].Header.Get("X-TC-Token") != "" ||
+		!strings.Contains(requests[2].Header.Get("Authorization"), "Credential=request-secret-id/") {
+		t.Errorf("DescribeInstances is not signed by the keys of the request")
+	}
+
+	GetClientFactory().Credentials = CredentialProviderChain{}
+	if client, err = GetClientFactory().NewCvmClient("ap-guangzhou", "", ""); err == nil || client == nil {
+		t.Errorf("no credential, client=%v, error=%v", client, err)
+	}
+}
+
+func TestLegacyClientSignsTemporaryCredential(t *testing.T) {
+	server, getRequests := newCredentialServer(time.Hour)
+	defer server.Close()
+	defer SetClientFactory(GetClientFactory())
+	setCredentialTestFactory(server, &staticCredentialProvider{&Credential{
+		SecretId:   "tmp-secret-id",
+		SecretKey:  "tmp-secret-key",
+		Token:      "tmp-token",
+		Expiration: time.Now().Add(time.Hour),
+	}})
+
+	client, err := GetClientFactory().NewLegacyVpcClient("ap-guangzhou", "", "")
+	if err != nil {
+		t.Fatalf("NewLegacyVpcClient meet error=%v", err)
+	}
+	if _, err = client.DescribeNatGateway(unversioned.NewDescribeNatGatewayRequest()); err != nil {
+		t.Fatalf("DescribeNatGateway meet error=%v", err)
+	}
+
+	requests := getRequests()
+	if len(requests) != 1 {
+		t.Fatalf("server got %d requests", len(requests))
+	}
+	query := requests[0].URL.Query()
+	if query.Get("Token") != "tmp-token" || query.Get("SecretId") != "tmp-secret-id" {
+		t.Fatalf("query=%v", query)
+	}
+	expected := &legacyCommon.BaseRequest{}
+	expected.Init()
+	expected.SetDomain(requests[0].Host)
+	for key := range query {
+		expected.GetParams()[key] = query.Get(key)
+	}
+	legacyCommon.Sign(expected, legacyCommon.NewBasicCredential("tmp-secret-id", "tmp-secret-key"), query.Get("SignatureMethod"))
+	if signature := expected.GetParams()["Signature"]; signature != query.Get("Signature") {
+		t.Errorf("signature=%v, expected %v", query.Get("Signature"), signature)
+	}
+}
@@ -0,0 +1,203 @@
+package plugins
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// the cloud API returns this error code when a request with DryRun would have succeeded.
+const QCLOUD_ERR_CODE_DRY_RUN_OPERATION = "DryRunOperation"
+
+// legacyCommonParameters are the parameters of legacy API requests which are not shown in the plan.
+var legacyCommonParameters = []string{"Action", "Region", "Version", "Timestamp", "Nonce", "SecretId", "Signature", "SignatureMethod", "RequestClient", "Token"}
+
+// PlannedCall is a cloud API call which changes resources and would be made by the action.
+type PlannedCall struct {
+	Guid       string                 `json:"guid,omitempty"`
+	Service    string                 `json:"service"`
+	Region     string                 `json:"region,omitempty"`
+	Action     string                 `json:"action"`
+	Parameters map[string]interface{} `json:"parameters,omitempty"`
+	// Checked is true if the call was sent with DryRun and checked by the cloud API, otherwise it is simulated.
+	Checked bool `json:"checked"`
+}
+
+// DryRunPlan collects the calls of an action run in dry run mode.
+//
+// In dry run mode the action validates its inputs and looks up resources as usual, but the cloud API
+// calls which change resources are not sent. Calls of the APIs which support DryRun are sent with it,
+// the others are answered with the DryRunOperation error, so the input stops at its first change.
+type DryRunPlan struct {
+	Calls []PlannedCall `json:"calls"`
+
+	mutex   sync.Mutex
+	guids   []string
+	current int
+}
+
+type dryRunPlanKey struct{}
+
+// WithDryRun returns the context which runs actions in dry run mode and the plan which collects their calls.
+func WithDryRun(ctx context.Context, guids []string) (context.Context, *DryRunPlan) {
+	plan := &DryRunPlan{Calls: []PlannedCall{}, guids: guids, current: -1}
+	return context.WithValue(ctx, dryRunPlanKey{}, plan), plan
+}
+
+func getDryRunPlan(ctx context.Context) *DryRunPlan {
+	plan, _ := ctx.Value(dryRunPlanKey{}).(*DryRunPlan)
+	return plan
+}
+
+// IsDryRun tells whether ctx runs the action in dry run mode, actions set DryRun of the requests which support it.
+func IsDryRun(ctx context.Context) bool {
+	return getDryRunPlan(ctx) != nil
+}
+
+// setCurrentInput is called by runInputs, which runs inputs one by one in dry run mode, so calls are
+// reported with the guid of their input.
+func (plan *DryRunPlan) setCurrentInput(i int) {
+	plan.mutex.Lock()
+	defer plan.mutex.Unlock()
+
+	plan.current = i
+}
+
+func (plan *DryRunPlan) add(call PlannedCall) {
+	plan.mutex.Lock()
+	defer plan.mutex.Unlock()
+
+	if plan.current >= 0 && plan.current < len(plan.guids) {
+		call.Guid = plan.guids[plan.current]
+	} else if len(plan.guids) == 1 {
+		call.Guid = plan.guids[0]
+	}
+	plan.Calls = append(plan.Calls, call)
+}
+
+// finishDryRun marks the outputs of the inputs stopped by DryRunOperation as succeeded,
+// the returned error is the error of the last input which failed for another reason.
+func finishDryRun(actionResult interface{}, err error) error {
+	if _, ok := err.(*ActionCanceledError); ok {
+		return err
+	}
+	outputs := getSliceField(actionResult, "Outputs")
+	if !outputs.IsValid() {
+		if err != nil && strings.Contains(err.Error(), QCLOUD_ERR_CODE_DRY_RUN_OPERATION) {
+			return nil
+		}
+		return err
+	}
+
+	var finalErr error
+	for i, result := range getResultsFromOutputs(actionResult) {
+		if result.Code == RESULT_CODE_SUCCESS {
+			continue
+		}
+		if strings.Contains(result.Message, QCLOUD_ERR_CODE_DRY_RUN_OPERATION) {
+			setOutputResult(outputs.Index(i), Result{Code: RESULT_CODE_SUCCESS, Message: "dry run, no resource is changed"})
+			continue
+		}
+		finalErr = errors.New(result.Message)
+	}
+	return finalErr
+}
+
+// dryRunTransport sends the read only requests of an action in dry run mode and collects the others in its plan.
+type dryRunTransport struct {
+	next http.RoundTripper
+}
+
+func (transport *dryRunTransport) RoundTrip(request *http.Request) (*http.Response, error) {
+	plan := getDryRunPlan(request.Context())
+	if plan == nil {
+		return transport.next.RoundTrip(request)
+	}
+
+	action := getApiAction(request)
+	// requests without an action are requests of COS.
+	if action == "" {
+		if request.Method == http.MethodGet || request.Method == http.MethodHead {
+			return transport.next.RoundTrip(request)
+		}
+		plan.add(PlannedCall{
+			Service:    QCLOUD_SERVICE_COS,
+			Action:     request.Method + " " + request.URL.Path,
+			Parameters: map[string]interface{}{"host": request.URL.Host},
+		})
+		return newDryRunResponse(request, http.StatusBadRequest, "application/xml", fmt.Sprintf(
+			"<Error><Code>%s</Code><Message>dry run, the request is not sent</Message></Error>", QCLOUD_ERR_CODE_DRY_RUN_OPERATION)), nil
+	}
+	if isReadOnlyAction(action) {
+		return transport.next.RoundTrip(request)
+	}
+
+	service, region, _ := getRateLimitKey(request)
+	request, parameters, err := getRequestParameters(request)
+	if err != nil {
+		return nil, err
+	}
+	call := PlannedCall{Service: service, Region: region, Action: action, Parameters: parameters}
+	if dryRun, ok := parameters["DryRun"].(bool); ok && dryRun {
+		call.Checked = true
+		plan.add(call)
+		return transport.next.RoundTrip(request)
+	}
+	plan.add(call)
+	return newDryRunResponse(request, http.StatusOK, "application/json", fmt.Sprintf(
+		`{"Response":{"Error":{"Code":"%s","Message":"dry run, the request is not sent"},"RequestId":""}}`, QCLOUD_ERR_CODE_DRY_RUN_OPERATION)), nil
+}
+
+// getRequestParameters returns the parameters of a cloud API request, the json body of the current API or
+// the query of the legacy API, and the request which can still be sent. The sensitive fields are masked.
+func getRequestParameters(request *http.Request) (*http.Request, map[string]interface{}, error) {
+	parameters := make(map[string]interface{})
+	if query := request.URL.Query(); query.Get("Action") != "" {
+		for key, values := range query {
+			if len(values) > 0 {
+				parameters[key] = values[0]
+			}
+		}
+		for _, key := range legacyCommonParameters {
+			delete(parameters, key)
+		}
+		return request, sanitizeParameters(parameters), nil
+	}
+
+	if request.Body == nil {
+		return request, parameters, nil
+	}
+	body, err := ioutil.ReadAll(request.Body)
+	request.Body.Close()
+	if err != nil {
+		return nil, nil, err
+	}
+	request = cloneRequestWithUrl(request)
+	request.Body = ioutil.NopCloser(bytes.NewReader(body))
+	if len(body) > 0 {
+		if err = json.Unmarshal(body, &parameters); err != nil {
+			return nil, nil, err
+		}
+	}
+	return request, sanitizeParameters(parameters), nil
+}
+
+func newDryRunResponse(request *http.Request, statusCode int, contentType, body string) *http.Response {
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", statusCode, http.StatusText(statusCode)),
+		StatusCode:    statusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        http.Header{"Content-Type": []string{contentType}},
+		Body:          ioutil.NopCloser(strings.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       request,
+	}
+}
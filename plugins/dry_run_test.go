@@ -0,0 +1,109 @@
+package plugins
+
+import (
+	"context"
+	"net/url"
+	"testing"
+
+	"github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/common"
+	cvm "github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/cvm/v20170312"
+	unversioned "github.com/zqfan/tencentcloud-sdk-go/services/vpc/unversioned"
+)
+
+func TestDryRunTransportSimulatesChanges(t *testing.T) {
+	server, getRequestCount := newScriptedServer(func(action, body string, times int) string {
+		if action == "RunInstances" {
+			return errorResponse(QCLOUD_ERR_CODE_DRY_RUN_OPERATION)
+		}
+		return okResponse
+	})
+	defer server.Close()
+
+	ctx, plan := WithDryRun(context.Background(), []string{"guid_1"})
+	factory := newRetryTestFactory(server, &RetryPolicy{}).WithContext(ctx)
+	client, _ := factory.NewCvmClient("ap-guangzhou", "fake-secret-id", "fake-secret-key")
+	if _, err := client.DescribeInstances(cvm.NewDescribeInstancesRequest()); err != nil {
+		t.Fatalf("DescribeInstances meet error=%v", err)
+	}
+
+	request := cvm.NewStartInstancesRequest()
+	request.InstanceIds = []*string{common.StringPtr("ins-00000001")}
+	if _, err := client.StartInstances(request); getErrorCode(err) != QCLOUD_ERR_CODE_DRY_RUN_OPERATION {
+		t.Errorf("StartInstances, error=%v", err)
+	}
+
+	runRequest := cvm.NewRunInstancesRequest()
+	runRequest.DryRun = common.BoolPtr(true)
+	runRequest.LoginSettings = &cvm.LoginSettings{Password: common.StringPtr("Ab888888")}
+	if _, err := client.RunInstances(runRequest); getErrorCode(err) != QCLOUD_ERR_CODE_DRY_RUN_OPERATION {
+		t.Errorf("RunInstances, error=%v", err)
+	}
+	if count := getRequestCount(); count != 2 {
+		t.Errorf("server got %d requests, expected DescribeInstances and RunInstances", count)
+	}
+
+	if len(plan.Calls) != 2 {
+		t.Fatalf("calls=%+v", plan.Calls)
+	}
+	start, run := plan.Calls[0], plan.Calls[1]
+	if start.Guid != "guid_1" || start.Service != QCLOUD_SERVICE_CVM || start.Region != "ap-guangzhou" || start.Action != "StartInstances" || start.Checked {
+		t.Errorf("StartInstances call=%+v", start)
+	}
+	if run.Action != "RunInstances" || !run.Checked {
+		t.Errorf("RunInstances call=%+v", run)
+	}
+	if loginSettings, _ := run.Parameters["LoginSettings"].(map[string]interface{}); loginSettings["Password"] != "******" {
+		t.Errorf("password is not masked, parameters=%+v", run.Parameters)
+	}
+}
+
+func TestDryRunTransportSimulatesLegacyChanges(t *testing.T) {
+	server, getRequests := newRecordingServer(`{"code":0,"message":"","totalCount":0,"data":[]}`)
+	defer server.Close()
+	serverUrl, _ := url.Parse(server.URL)
+
+	defer SetClientFactory(GetClientFactory())
+	SetClientFactory(&ClientFactory{
+		Scheme:    "http",
+		Endpoints: map[string]string{QCLOUD_SERVICE_LEGACY_VPC: serverUrl.Host},
+	})
+
+	ctx, plan := WithDryRun(context.Background(), nil)
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	client, _ := newVpcClient(ctx, &ProviderParams{Region: "ap-guangzhou", SecretID: "fake-secret-id", SecretKey: "fake-secret-key"})
+	if _, err := client.DescribeNatGateway(unversioned.NewDescribeNatGatewayRequest()); err != nil {
+		t.Fatalf("DescribeNatGateway meet error=%v", err)
+	}
+	request := unversioned.NewCreateNatGatewayRequest()
+	request.NatName = common.StringPtr("NAT-A")
+	if _, err := client.CreateNatGateway(request); err == nil {
+		t.Errorf("CreateNatGateway succeeded in dry run mode")
+	}
+
+	if requests := getRequests(); len(requests) != 1 || requests[0].Action != "DescribeNatGateway" {
+		t.Errorf("server got requests %+v, expected DescribeNatGateway", requests)
+	}
+	if len(plan.Calls) != 1 || plan.Calls[0].Service != QCLOUD_SERVICE_LEGACY_VPC || plan.Calls[0].Action != "CreateNatGateway" ||
+		plan.Calls[0].Parameters["natName"] != "NAT-A" || plan.Calls[0].Parameters["SecretId"] != nil {
+		t.Errorf("calls=%+v", plan.Calls)
+	}
+}
+
+func TestFinishDryRun(t *testing.T) {
+	outputs := testOutputs{Outputs: []testOutput{
+		{Guid: "guid_1", Result: Result{Code: RESULT_CODE_ERROR, Message: "RunInstances meet error=[TencentCloudSDKError] Code=DryRunOperation"}},
+		{Guid: "guid_2", Result: Result{Code: RESULT_CODE_SUCCESS}},
+	}}
+	if err := finishDryRun(outputs, nil); err != nil || outputs.Outputs[0].Code != RESULT_CODE_SUCCESS {
+		t.Errorf("outputs=%+v, error=%v", outputs, err)
+	}
+	if output := outputs.Outputs[0]; output.Guid != "guid_1" || output.Message != "dry run, no resource is changed" {
+		t.Errorf("output of the dry run error=%+v", output)
+	}
+
+	outputs.Outputs[1].Result = Result{Code: RESULT_CODE_ERROR, Message: "ImageId is empty"}
+	if err := finishDryRun(outputs, nil); err == nil || err.Error() != "ImageId is empty" {
+		t.Errorf("error=%v, expected the error of the invalid input", err)
+	}
+}
@@ -1,18 +1,22 @@
 package plugins
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"strconv"
-	"time"
+	"strings"
 
-	"github.com/sirupsen/logrus"
 	"github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/common"
-	"github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/common/profile"
 	vpc "github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/vpc/v20170312"
 	unversioned "github.com/zqfan/tencentcloud-sdk-go/services/vpc/unversioned"
 )
 
+const (
+	VPC_TASK_STATUS_SUCCESS = 0
+	VPC_TASK_STATUS_FAILED  = 1
+)
+
 var EIPActions = make(map[string]Action)
 
 func init() {
@@ -24,21 +28,12 @@ func init() {
 	EIPActions["unbindnat"] = new(EIPUnBindNatAction)
 }
 
-func newVpcClient(region, secretId, secretKey string) (*unversioned.Client, error) {
-	return unversioned.NewClientWithSecretId(
-		secretId,
-		secretKey,
-		region,
-	)
+func newVpcClient(ctx context.Context, params *ProviderParams) (*unversioned.Client, error) {
+	return GetClientFactory().WithContext(ctx).WithProviderParams(params).NewLegacyVpcClient(params.Region, params.SecretID, params.SecretKey)
 }
 
-func CreateEIPClient(region, secretId, secretKey string) (client *vpc.Client, err error) {
-	credential := common.NewCredential(secretId, secretKey)
-
-	clientProfile := profile.NewClientProfile()
-	clientProfile.HttpProfile.Endpoint = "vpc.tencentcloudapi.com"
-
-	return vpc.NewClient(credential, region, clientProfile)
+func CreateEIPClient(ctx context.Context, params *ProviderParams) (client *vpc.Client, err error) {
+	return GetClientFactory().WithContext(ctx).WithProviderParams(params).NewVpcClient(params.Region, params.SecretID, params.SecretKey)
 }
 
 type EIPInputs struct {
@@ -47,6 +42,7 @@ type EIPInputs struct {
 
 type EIPInput struct {
 	CallBackParameter
+	WaitParameter
 	Guid           string `json:"guid,omitempty"`
 	ProviderParams string `json:"provider_params,omitempty"`
 	AddressCount   string `json:"address_count,omitempty"`
@@ -88,10 +84,14 @@ func (plugin *EIPPlugin) GetActionByName(actionName string) (Action, error) {
 	return action, nil
 }
 
+func (plugin *EIPPlugin) GetActions() map[string]Action {
+	return EIPActions
+}
+
 type EIPCreateAction struct {
 }
 
-func (action *EIPCreateAction) ReadParam(param interface{}) (interface{}, error) {
+func (action *EIPCreateAction) ReadParam(ctx context.Context, param interface{}) (interface{}, error) {
 	var inputs EIPInputs
 	err := UnmarshalJson(param, &inputs)
 	if err != nil {
@@ -100,40 +100,39 @@ func (action *EIPCreateAction) ReadParam(param interface{}) (interface{}, error)
 	return inputs, nil
 }
 
-func (action *EIPCreateAction) createEIP(eip *EIPInput) (EIPOutput, error) {
+func (action *EIPCreateAction) createEIP(ctx context.Context, eip *EIPInput) (EIPOutput, error) {
 	output := EIPOutput{
 		Guid: eip.Guid,
 	}
 	output.CallBackParameter.Parameter = eip.CallBackParameter.Parameter
 	output.Result.Code = RESULT_CODE_SUCCESS
 
-	if eip.Location != "" && eip.APISecret != "" {
-		eip.ProviderParams = fmt.Sprintf("%s;%s", eip.Location, eip.APISecret)
+	params, err := NewProviderParams(eip.ProviderParams, eip.Location, eip.APISecret)
+	if err != nil {
+		output.Result.SetError(err)
+		return output, err
 	}
-	paramsMap, _ := GetMapFromProviderParams(eip.ProviderParams)
-	client, err := CreateEIPClient(paramsMap["Region"], paramsMap["SecretID"], paramsMap["SecretKey"])
+	client, err := CreateEIPClient(ctx, params)
 	if err != nil {
-		output.Result.Code = RESULT_CODE_ERROR
-		output.Result.Message = err.Error()
+		output.Result.SetError(err)
 		return output, err
 	}
 
-	// check whther the eip is existed
+	// check whther the eips are existed, the id of a resumed input lists all the eips it allocated.
+	req := vpc.NewDescribeAddressesRequest()
 	if eip.Id != "" {
-		address, ok, err := queryEipById(client, eip.Id)
+		ids := strings.Split(eip.Id, ",")
+		addresses, err := queryEipsByIds(client, ids)
 		if err != nil {
-			logrus.Errorf("queryEipById meet error=%v", err)
-			output.Result.Code = RESULT_CODE_ERROR
-			output.Result.Message = err.Error()
+			Logger(ctx).Errorf("queryEipsByIds meet error=%v", err)
+			output.Result.SetError(err)
 			return output, err
 		}
-		if ok {
-			logrus.Infof("the eip[%v] already is exist.", eip.Id)
-			var eipInfo EIPInfo
-			eipInfo.Id = *address.AddressId
-			eipInfo.EIP = *address.AddressIp
-			output.EIPS = append(output.EIPS, eipInfo)
-			return output, nil
+		if len(addresses) == len(ids) {
+			Logger(ctx).Infof("the eip[%v] already is exist.", eip.Id)
+			req.AddressIds = common.StringPtrs(ids)
+			err = waitEipsCreated(ctx, client, req, eip, &output)
+			return output, err
 		}
 	}
 
@@ -153,50 +152,92 @@ func (action *EIPCreateAction) createEIP(eip *EIPInput) (EIPOutput, error) {
 		return output, fmt.Errorf("failed to CreateEIP, error=%s", err)
 	}
 
-	req := vpc.NewDescribeAddressesRequest()
 	output.RequestId = *response.Response.RequestId
 	if len(response.Response.AddressSet) == 0 {
 		output.Result.Code = RESULT_CODE_ERROR
 		output.Result.Message = fmt.Sprintf("allocate eip meet error, the return eip is zero")
 		return output, fmt.Errorf("allocate eip meet error, the return eip is zero")
 	}
+	ids := []string{}
 	for i := 0; i < len(response.Response.AddressSet); i++ {
 		req.AddressIds = append(req.AddressIds, response.Response.AddressSet[i])
+		ids = append(ids, *response.Response.AddressSet[i])
 	}
+	recordResourceId(ctx, eip.Guid, strings.Join(ids, ","))
+
+	err = waitEipsCreated(ctx, client, req, eip, &output)
+	return output, err
+}
 
+// waitEipsCreated waits for the eips of the request and adds them to the output.
+func waitEipsCreated(ctx context.Context, client *vpc.Client, req *vpc.DescribeAddressesRequest, eip *EIPInput, output *EIPOutput) error {
 	//query eips info get eip ip
-	for {
+	waiter, err := NewWaiter(WAIT_RESOURCE_EIP, output.RequestId, eip.WaitTimeout)
+	if err != nil {
+		output.Result.SetError(err)
+		return err
+	}
+	err = waiter.Wait(ctx, func() (string, bool, error) {
 		queryEIPResponse, err := client.DescribeAddresses(req)
 		if err != nil {
-			output.Result.Code = RESULT_CODE_ERROR
-			output.Result.Message = fmt.Sprintf("query eip info meet error : %s", err)
-			return output, fmt.Errorf("query eip info meet error : %s", err)
+			return "", false, fmt.Errorf("query eip info meet error : %s", err)
 		}
 		if len(queryEIPResponse.Response.AddressSet) == 0 {
-			output.Result.Code = RESULT_CODE_ERROR
-			output.Result.Message = fmt.Sprintf("after create eip can't get eip info")
-			return output, fmt.Errorf("after create eip can't get eip info")
+			return "", false, fmt.Errorf("after create eip can't get eip info")
 		}
-		count := 0
 		for _, info := range queryEIPResponse.Response.AddressSet {
 			if *info.AddressStatus == "CREATING" {
-				count++
-				break
+				return *info.AddressStatus, false, nil
 			}
 		}
-		if count == 0 {
-			for _, info := range queryEIPResponse.Response.AddressSet {
-				var eipInfo EIPInfo
-				eipInfo.Id = *info.AddressId
-				eipInfo.EIP = *info.AddressIp
-				output.EIPS = append(output.EIPS, eipInfo)
-			}
-			break
+		for _, info := range queryEIPResponse.Response.AddressSet {
+			var eipInfo EIPInfo
+			eipInfo.Id = *info.AddressId
+			eipInfo.EIP = *info.AddressIp
+			output.EIPS = append(output.EIPS, eipInfo)
 		}
-		time.Sleep(1 * time.Second)
+		return "", true, nil
+	})
+	if err != nil {
+		output.Result.SetError(err)
 	}
 
-	return output, err
+	return err
+}
+
+// waitVpcTaskResult waits for the async task of the legacy vpc API.
+func waitVpcTaskResult(ctx context.Context, client *unversioned.Client, resource string, taskId *int, timeout string) error {
+	if taskId == nil {
+		return errors.New("the task id of legacy vpc API is empty")
+	}
+	waiter, err := NewWaiter(resource, fmt.Sprintf("task-%d", *taskId), timeout)
+	if err != nil {
+		return err
+	}
+
+	request := unversioned.NewDescribeVpcTaskResultRequest()
+	request.TaskId = taskId
+	return waiter.Wait(ctx, func() (string, bool, error) {
+		response, err := client.DescribeVpcTaskResult(request)
+		if err != nil {
+			return "", false, err
+		}
+		status := *response.Data.Status
+		if status == VPC_TASK_STATUS_FAILED {
+			return "", false, fmt.Errorf("task(%d) execute failed, err = %v", *taskId, *response.Data.Output.ErrorMsg)
+		}
+		return strconv.Itoa(status), status == VPC_TASK_STATUS_SUCCESS, nil
+	})
+}
+
+func queryEipsByIds(client *vpc.Client, ids []string) ([]*vpc.Address, error) {
+	request := vpc.NewDescribeAddressesRequest()
+	request.AddressIds = common.StringPtrs(ids)
+	response, err := client.DescribeAddresses(request)
+	if err != nil {
+		return nil, err
+	}
+	return response.Response.AddressSet, nil
 }
 
 func queryEipById(client *vpc.Client, id string) (*vpc.Address, bool, error) {
@@ -215,28 +256,26 @@ func queryEipById(client *vpc.Client, id string) (*vpc.Address, bool, error) {
 	return response.Response.AddressSet[0], true, nil
 }
 
-func (action *EIPCreateAction) Do(input interface{}) (interface{}, error) {
+func (action *EIPCreateAction) Do(ctx context.Context, input interface{}) (interface{}, error) {
 	eips, _ := input.(EIPInputs)
-	outputs := EIPOutputs{}
-	var finalErr error
-
-	for _, subnet := range eips.Inputs {
-		output, err := action.createEIP(&subnet)
-		if err != nil {
-			finalErr = err
-		}
-
-		outputs.Outputs = append(outputs.Outputs, output)
-	}
-
-	logrus.Infof("all eip = %v are created", eips)
+	outputs := EIPOutputs{Outputs: make([]EIPOutput, len(eips.Inputs))}
+	finalErr := runInputs(ctx, len(eips.Inputs), func(i int) []string {
+		return []string{eips.Inputs[i].Guid, eips.Inputs[i].Id}
+	}, func(ctx context.Context, i int) error {
+		subnet := eips.Inputs[i]
+		output, err := action.createEIP(ctx, &subnet)
+		outputs.Outputs[i] = output
+		return err
+	})
+
+	Logger(ctx).Infof("all eip = %s are created", Sanitize(eips))
 	return &outputs, finalErr
 }
 
 type EIPTerminateAction struct {
 }
 
-func (action *EIPTerminateAction) ReadParam(param interface{}) (interface{}, error) {
+func (action *EIPTerminateAction) ReadParam(ctx context.Context, param interface{}) (interface{}, error) {
 	var inputs EIPInputs
 	err := UnmarshalJson(param, &inputs)
 	if err != nil {
@@ -245,30 +284,30 @@ func (action *EIPTerminateAction) ReadParam(param interface{}) (interface{}, err
 	return inputs, nil
 }
 
-func (action *EIPTerminateAction) terminateEIP(eip *EIPInput) (EIPOutput, error) {
+func (action *EIPTerminateAction) terminateEIP(ctx context.Context, eip *EIPInput) (EIPOutput, error) {
 	output := EIPOutput{
 		Guid: eip.Guid,
 	}
 	output.Result.Code = RESULT_CODE_SUCCESS
 	output.CallBackParameter.Parameter = eip.CallBackParameter.Parameter
 
-	if eip.Location != "" && eip.APISecret != "" {
-		eip.ProviderParams = fmt.Sprintf("%s;%s", eip.Location, eip.APISecret)
+	params, err := NewProviderParams(eip.ProviderParams, eip.Location, eip.APISecret)
+	if err != nil {
+		output.Result.SetError(err)
+		return output, err
 	}
-	paramsMap, err := GetMapFromProviderParams(eip.ProviderParams)
-	client, _ := CreateEIPClient(paramsMap["Region"], paramsMap["SecretID"], paramsMap["SecretKey"])
+	client, _ := CreateEIPClient(ctx, params)
 
 	// check whther the eip is existed.
 	if eip.Id != "" {
 		_, ok, err := queryEipById(client, eip.Id)
 		if err != nil {
-			logrus.Errorf("queryEipById meet error=%v", err)
-			output.Result.Code = RESULT_CODE_ERROR
-			output.Result.Message = err.Error()
+			Logger(ctx).Errorf("queryEipById meet error=%v", err)
+			output.Result.SetError(err)
 			return output, err
 		}
 		if !ok {
-			logrus.Infof("the eip[%v] already is not exist.", eip.Id)
+			Logger(ctx).Infof("the eip[%v] already is not exist.", eip.Id)
 			return output, nil
 		}
 	}
@@ -287,17 +326,17 @@ func (action *EIPTerminateAction) terminateEIP(eip *EIPInput) (EIPOutput, error)
 	return output, nil
 }
 
-func (action *EIPTerminateAction) Do(input interface{}) (interface{}, error) {
+func (action *EIPTerminateAction) Do(ctx context.Context, input interface{}) (interface{}, error) {
 	eips, _ := input.(EIPInputs)
-	outputs := EIPOutputs{}
-	var finalErr error
-	for _, eip := range eips.Inputs {
-		output, err := action.terminateEIP(&eip)
-		if err != nil {
-			finalErr = err
-		}
-		outputs.Outputs = append(outputs.Outputs, output)
-	}
+	outputs := EIPOutputs{Outputs: make([]EIPOutput, len(eips.Inputs))}
+	finalErr := runInputs(ctx, len(eips.Inputs), func(i int) []string {
+		return []string{eips.Inputs[i].Guid, eips.Inputs[i].Id, eips.Inputs[i].InstanceId, eips.Inputs[i].NatId}
+	}, func(ctx context.Context, i int) error {
+		eip := eips.Inputs[i]
+		output, err := action.terminateEIP(ctx, &eip)
+		outputs.Outputs[i] = output
+		return err
+	})
 
 	return &outputs, finalErr
 }
@@ -334,7 +373,7 @@ func queryEIPInfo(client *vpc.Client, eip *EIPInput) error {
 type EIPAttachAction struct {
 }
 
-func (action *EIPAttachAction) ReadParam(param interface{}) (interface{}, error) {
+func (action *EIPAttachAction) ReadParam(ctx context.Context, param interface{}) (interface{}, error) {
 	var inputs EIPInputs
 	err := UnmarshalJson(param, &inputs)
 	if err != nil {
@@ -354,24 +393,24 @@ func eipAttachCheckParam(input *EIPInput) error {
 	return nil
 }
 
-func (action *EIPAttachAction) attachEIP(eip *EIPInput) (EIPOutput, error) {
+func (action *EIPAttachAction) attachEIP(ctx context.Context, eip *EIPInput) (EIPOutput, error) {
 	output := EIPOutput{
 		Guid: eip.Guid,
 	}
 	output.CallBackParameter.Parameter = eip.CallBackParameter.Parameter
 	output.Result.Code = RESULT_CODE_SUCCESS
 
-	if err := eipAttachCheckParam(eip); err != nil {
-		output.Result.Code = RESULT_CODE_ERROR
-		output.Result.Message = err.Error()
+	if err := AsValidationError(eipAttachCheckParam(eip)); err != nil {
+		output.Result.SetError(err)
 		return output, err
 	}
 
-	if eip.Location != "" && eip.APISecret != "" {
-		eip.ProviderParams = fmt.Sprintf("%s;%s", eip.Location, eip.APISecret)
+	params, err := NewProviderParams(eip.ProviderParams, eip.Location, eip.APISecret)
+	if err != nil {
+		output.Result.SetError(err)
+		return output, err
 	}
-	paramsMap, err := GetMapFromProviderParams(eip.ProviderParams)
-	client, _ := CreateEIPClient(paramsMap["Region"], paramsMap["SecretID"], paramsMap["SecretKey"])
+	client, _ := CreateEIPClient(ctx, params)
 
 	request := vpc.NewAssociateAddressRequest()
 	request.AddressId = &eip.Id
@@ -388,18 +427,17 @@ func (action *EIPAttachAction) attachEIP(eip *EIPInput) (EIPOutput, error) {
 	return output, nil
 }
 
-func (action *EIPAttachAction) Do(input interface{}) (interface{}, error) {
+func (action *EIPAttachAction) Do(ctx context.Context, input interface{}) (interface{}, error) {
 	eips, _ := input.(EIPInputs)
-	outputs := EIPOutputs{}
-	var finalErr error
-
-	for _, eip := range eips.Inputs {
-		output, err := action.attachEIP(&eip)
-		if err != nil {
-			finalErr = err
-		}
-		outputs.Outputs = append(outputs.Outputs, output)
-	}
+	outputs := EIPOutputs{Outputs: make([]EIPOutput, len(eips.Inputs))}
+	finalErr := runInputs(ctx, len(eips.Inputs), func(i int) []string {
+		return []string{eips.Inputs[i].Guid, eips.Inputs[i].Id, eips.Inputs[i].InstanceId}
+	}, func(ctx context.Context, i int) error {
+		eip := eips.Inputs[i]
+		output, err := action.attachEIP(ctx, &eip)
+		outputs.Outputs[i] = output
+		return err
+	})
 
 	return &outputs, finalErr
 }
@@ -407,7 +445,7 @@ func (action *EIPAttachAction) Do(input interface{}) (interface{}, error) {
 type EIPDetachAction struct {
 }
 
-func (action *EIPDetachAction) ReadParam(param interface{}) (interface{}, error) {
+func (action *EIPDetachAction) ReadParam(ctx context.Context, param interface{}) (interface{}, error) {
 	var inputs EIPInputs
 	err := UnmarshalJson(param, &inputs)
 	if err != nil {
@@ -425,24 +463,24 @@ func eipDetachCheckParam(eip *EIPInput) error {
 	return nil
 }
 
-func (action *EIPDetachAction) detachEIP(eip *EIPInput) (EIPOutput, error) {
+func (action *EIPDetachAction) detachEIP(ctx context.Context, eip *EIPInput) (EIPOutput, error) {
 	output := EIPOutput{
 		Guid: eip.Guid,
 	}
 	output.CallBackParameter.Parameter = eip.CallBackParameter.Parameter
 	output.Result.Code = RESULT_CODE_SUCCESS
 
-	if err := eipDetachCheckParam(eip); err != nil {
-		output.Result.Code = RESULT_CODE_ERROR
-		output.Result.Message = err.Error()
+	if err := AsValidationError(eipDetachCheckParam(eip)); err != nil {
+		output.Result.SetError(err)
 		return output, err
 	}
 
-	if eip.Location != "" && eip.APISecret != "" {
-		eip.ProviderParams = fmt.Sprintf("%s;%s", eip.Location, eip.APISecret)
+	params, err := NewProviderParams(eip.ProviderParams, eip.Location, eip.APISecret)
+	if err != nil {
+		output.Result.SetError(err)
+		return output, err
 	}
-	paramsMap, err := GetMapFromProviderParams(eip.ProviderParams)
-	client, _ := CreateEIPClient(paramsMap["Region"], paramsMap["SecretID"], paramsMap["SecretKey"])
+	client, _ := CreateEIPClient(ctx, params)
 
 	request := vpc.NewDisassociateAddressRequest()
 	request.AddressId = &eip.Id
@@ -458,17 +496,17 @@ func (action *EIPDetachAction) detachEIP(eip *EIPInput) (EIPOutput, error) {
 	return output, nil
 }
 
-func (action *EIPDetachAction) Do(input interface{}) (interface{}, error) {
+func (action *EIPDetachAction) Do(ctx context.Context, input interface{}) (interface{}, error) {
 	eips, _ := input.(EIPInputs)
-	outputs := EIPOutputs{}
-	var finalErr error
-	for _, eip := range eips.Inputs {
-		output, err := action.detachEIP(&eip)
-		if err != nil {
-			finalErr = err
-		}
-		outputs.Outputs = append(outputs.Outputs, output)
-	}
+	outputs := EIPOutputs{Outputs: make([]EIPOutput, len(eips.Inputs))}
+	finalErr := runInputs(ctx, len(eips.Inputs), func(i int) []string {
+		return []string{eips.Inputs[i].Guid, eips.Inputs[i].Id, eips.Inputs[i].InstanceId}
+	}, func(ctx context.Context, i int) error {
+		eip := eips.Inputs[i]
+		output, err := action.detachEIP(ctx, &eip)
+		outputs.Outputs[i] = output
+		return err
+	})
 
 	return &outputs, finalErr
 }
@@ -476,7 +514,7 @@ func (action *EIPDetachAction) Do(input interface{}) (interface{}, error) {
 type EIPBindNatAction struct {
 }
 
-func (action *EIPBindNatAction) ReadParam(param interface{}) (interface{}, error) {
+func (action *EIPBindNatAction) ReadParam(ctx context.Context, param interface{}) (interface{}, error) {
 	var inputs EIPInputs
 	err := UnmarshalJson(param, &inputs)
 	if err != nil {
@@ -500,23 +538,23 @@ func eIPBindNatActionCheckParam(eip *EIPInput) error {
 	return nil
 }
 
-func (action *EIPBindNatAction) bindNatGateway(eip *EIPInput) (EIPOutput, error) {
+func (action *EIPBindNatAction) bindNatGateway(ctx context.Context, eip *EIPInput) (EIPOutput, error) {
 	output := EIPOutput{
 		Guid: eip.Guid,
 	}
 	output.CallBackParameter.Parameter = eip.CallBackParameter.Parameter
 	output.Result.Code = RESULT_CODE_SUCCESS
 
-	if err := eIPBindNatActionCheckParam(eip); err != nil {
-		output.Result.Code = RESULT_CODE_ERROR
-		output.Result.Message = err.Error()
+	if err := AsValidationError(eIPBindNatActionCheckParam(eip)); err != nil {
+		output.Result.SetError(err)
 		return output, err
 	}
-	if eip.Location != "" && eip.APISecret != "" {
-		eip.ProviderParams = fmt.Sprintf("%s;%s", eip.Location, eip.APISecret)
+	params, err := NewProviderParams(eip.ProviderParams, eip.Location, eip.APISecret)
+	if err != nil {
+		output.Result.SetError(err)
+		return output, err
 	}
-	paramsMap, err := GetMapFromProviderParams(eip.ProviderParams)
-	client, _ := newVpcClient(paramsMap["Region"], paramsMap["SecretID"], paramsMap["SecretKey"])
+	client, _ := newVpcClient(ctx, params)
 
 	eIPBindNatActionCheckParam(eip)
 	request := unversioned.NewEipBindNatGatewayRequest()
@@ -527,33 +565,13 @@ func (action *EIPBindNatAction) bindNatGateway(eip *EIPInput) (EIPOutput, error)
 	}
 	response, err := client.EipBindNatGateway(request)
 	if err != nil {
-		output.Result.Code = RESULT_CODE_ERROR
-		output.Result.Message = err.Error()
+		output.Result.SetError(err)
 		return output, err
 	}
-	taskReq := unversioned.NewDescribeVpcTaskResultRequest()
-	taskReq.TaskId = response.TaskId
-	count := 0
-	for {
-		taskResp, err := client.DescribeVpcTaskResult(taskReq)
-		if err != nil {
-			return output, err
-		}
-		if *taskResp.Data.Status == 0 {
-			break
-		}
-		if *taskResp.Data.Status == 1 {
-			output.Result.Code = RESULT_CODE_ERROR
-			output.Result.Message = fmt.Sprintf("terminateNatGateway execute failed, err = %v", *taskResp.Data.Output.ErrorMsg)
-			return output, fmt.Errorf("terminateNatGateway execute failed, err = %v", *taskResp.Data.Output.ErrorMsg)
-		}
-		time.Sleep(5 * time.Second)
-		count++
-		if count >= 20 {
-			output.Result.Code = RESULT_CODE_ERROR
-			output.Result.Message = fmt.Sprintf("terminateNatGateway query result timeout")
-			return output, fmt.Errorf("terminateNatGateway query result timeout")
-		}
+	if err = waitVpcTaskResult(ctx, client, WAIT_RESOURCE_EIP, response.TaskId, eip.WaitTimeout); err != nil {
+		output.Result.Code = RESULT_CODE_ERROR
+		output.Result.Message = fmt.Sprintf("eip bind nat gateway meet error = %v", err)
+		return output, fmt.Errorf("eip bind nat gateway meet error = %v", err)
 	}
 
 	output.RequestId = "legacy qcloud API doesn't support returnning request id"
@@ -561,17 +579,17 @@ func (action *EIPBindNatAction) bindNatGateway(eip *EIPInput) (EIPOutput, error)
 	return output, nil
 }
 
-func (action *EIPBindNatAction) Do(input interface{}) (interface{}, error) {
+func (action *EIPBindNatAction) Do(ctx context.Context, input interface{}) (interface{}, error) {
 	eips, _ := input.(EIPInputs)
-	outputs := EIPOutputs{}
-	var finalErr error
-	for _, eip := range eips.Inputs {
-		output, err := action.bindNatGateway(&eip)
-		if err != nil {
-			finalErr = err
-		}
-		outputs.Outputs = append(outputs.Outputs, output)
-	}
+	outputs := EIPOutputs{Outputs: make([]EIPOutput, len(eips.Inputs))}
+	finalErr := runInputs(ctx, len(eips.Inputs), func(i int) []string {
+		return []string{eips.Inputs[i].Guid, eips.Inputs[i].Id, eips.Inputs[i].NatId}
+	}, func(ctx context.Context, i int) error {
+		eip := eips.Inputs[i]
+		output, err := action.bindNatGateway(ctx, &eip)
+		outputs.Outputs[i] = output
+		return err
+	})
 
 	return &outputs, finalErr
 }
@@ -579,7 +597,7 @@ func (action *EIPBindNatAction) Do(input interface{}) (interface{}, error) {
 type EIPUnBindNatAction struct {
 }
 
-func (action *EIPUnBindNatAction) ReadParam(param interface{}) (interface{}, error) {
+func (action *EIPUnBindNatAction) ReadParam(ctx context.Context, param interface{}) (interface{}, error) {
 	var inputs EIPInputs
 	err := UnmarshalJson(param, &inputs)
 	if err != nil {
@@ -603,24 +621,24 @@ func eIPUnBindNatCheckParam(eip *EIPInput) error {
 	return nil
 }
 
-func (action *EIPUnBindNatAction) unbindNatGateway(eip *EIPInput) (EIPOutput, error) {
+func (action *EIPUnBindNatAction) unbindNatGateway(ctx context.Context, eip *EIPInput) (EIPOutput, error) {
 	output := EIPOutput{
 		Guid: eip.Guid,
 	}
 	output.CallBackParameter.Parameter = eip.CallBackParameter.Parameter
 	output.Result.Code = RESULT_CODE_SUCCESS
 
-	if err := eIPUnBindNatCheckParam(eip); err != nil {
-		output.Result.Code = RESULT_CODE_ERROR
-		output.Result.Message = err.Error()
+	if err := AsValidationError(eIPUnBindNatCheckParam(eip)); err != nil {
+		output.Result.SetError(err)
 		return output, err
 	}
 
-	if eip.Location != "" && eip.APISecret != "" {
-		eip.ProviderParams = fmt.Sprintf("%s;%s", eip.Location, eip.APISecret)
+	params, err := NewProviderParams(eip.ProviderParams, eip.Location, eip.APISecret)
+	if err != nil {
+		output.Result.SetError(err)
+		return output, err
 	}
-	paramsMap, err := GetMapFromProviderParams(eip.ProviderParams)
-	client, _ := newVpcClient(paramsMap["Region"], paramsMap["SecretID"], paramsMap["SecretKey"])
+	client, _ := newVpcClient(ctx, params)
 
 	request := unversioned.NewEipUnBindNatGatewayRequest()
 	request.VpcId = &eip.VpcId
@@ -634,31 +652,10 @@ func (action *EIPUnBindNatAction) unbindNatGateway(eip *EIPInput) (EIPOutput, er
 		output.Result.Message = fmt.Sprintf("Failed to unbind nat gateway (EIP Id=%v), error=%s", eip.Id, err)
 		return output, fmt.Errorf("Failed to unbind nat gateway (EIP Id=%v), error=%s", eip.Id, err)
 	}
-	taskReq := unversioned.NewDescribeVpcTaskResultRequest()
-	taskReq.TaskId = response.TaskId
-	count := 0
-	for {
-		taskResp, err := client.DescribeVpcTaskResult(taskReq)
-		if err != nil {
-			output.Result.Code = RESULT_CODE_ERROR
-			output.Result.Message = err.Error()
-			return output, err
-		}
-		if *taskResp.Data.Status == 0 {
-			break
-		}
-		if *taskResp.Data.Status == 1 {
-			output.Result.Code = RESULT_CODE_ERROR
-			output.Result.Message = fmt.Sprintf("eip unbind nat gateway execute failed, err = %v", *taskResp.Data.Output.ErrorMsg)
-			return output, fmt.Errorf("eip unbind nat gateway execute failed, err = %v", *taskResp.Data.Output.ErrorMsg)
-		}
-		time.Sleep(5 * time.Second)
-		count++
-		if count >= 20 {
-			output.Result.Code = RESULT_CODE_ERROR
-			output.Result.Message = fmt.Sprintf("eip unbind nat gateway query result timeout")
-			return output, fmt.Errorf("eip unbind nat gateway query result timeout")
-		}
+	if err = waitVpcTaskResult(ctx, client, WAIT_RESOURCE_EIP, response.TaskId, eip.WaitTimeout); err != nil {
+		output.Result.Code = RESULT_CODE_ERROR
+		output.Result.Message = fmt.Sprintf("eip unbind nat gateway meet error = %v", err)
+		return output, fmt.Errorf("eip unbind nat gateway meet error = %v", err)
 	}
 
 	output.RequestId = "legacy qcloud API doesn't support returnning request id"
@@ -666,18 +663,17 @@ func (action *EIPUnBindNatAction) unbindNatGateway(eip *EIPInput) (EIPOutput, er
 	return output, nil
 }
 
-func (action *EIPUnBindNatAction) Do(input interface{}) (interface{}, error) {
+func (action *EIPUnBindNatAction) Do(ctx context.Context, input interface{}) (interface{}, error) {
 	eips, _ := input.(EIPInputs)
-	outputs := EIPOutputs{}
-	var finalErr error
-
-	for _, eip := range eips.Inputs {
-		output, err := action.unbindNatGateway(&eip)
-		if err != nil {
-			finalErr = err
-		}
-		outputs.Outputs = append(outputs.Outputs, output)
-	}
+	outputs := EIPOutputs{Outputs: make([]EIPOutput, len(eips.Inputs))}
+	finalErr := runInputs(ctx, len(eips.Inputs), func(i int) []string {
+		return []string{eips.Inputs[i].Guid, eips.Inputs[i].Id, eips.Inputs[i].NatId}
+	}, func(ctx context.Context, i int) error {
+		eip := eips.Inputs[i]
+		output, err := action.unbindNatGateway(ctx, &eip)
+		outputs.Outputs[i] = output
+		return err
+	})
 
 	return &outputs, finalErr
 }
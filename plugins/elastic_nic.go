@@ -1,14 +1,12 @@
 package plugins
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"strings"
-	"time"
 
 	"github.com/sirupsen/logrus"
-	"github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/common"
-	"github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/common/profile"
 	vpc "github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/vpc/v20170312"
 )
 
@@ -25,13 +23,8 @@ func init() {
 	ElasticNicActions["detach"] = new(ElasticNicDetachAction)
 }
 
-func CreateElasticNicClient(region, secretId, secretKey string) (client *vpc.Client, err error) {
-	credential := common.NewCredential(secretId, secretKey)
-
-	clientProfile := profile.NewClientProfile()
-	clientProfile.HttpProfile.Endpoint = "vpc.tencentcloudapi.com"
-
-	return vpc.NewClient(credential, region, clientProfile)
+func CreateElasticNicClient(ctx context.Context, params *ProviderParams) (client *vpc.Client, err error) {
+	return GetClientFactory().WithContext(ctx).WithProviderParams(params).NewVpcClient(params.Region, params.SecretID, params.SecretKey)
 }
 
 type ElasticNicInputs struct {
@@ -40,6 +33,7 @@ type ElasticNicInputs struct {
 
 type ElasticNicInput struct {
 	CallBackParameter
+	WaitParameter
 	Guid               string   `json:"guid,omitempty"`
 	ProviderParams     string   `json:"provider_params,omitempty"`
 	Name               string   `json:"name,omitempty"`
@@ -80,10 +74,14 @@ func (plugin *ElasticNicPlugin) GetActionByName(actionName string) (Action, erro
 	return action, nil
 }
 
+func (plugin *ElasticNicPlugin) GetActions() map[string]Action {
+	return ElasticNicActions
+}
+
 type ElasticNicCreateAction struct {
 }
 
-func (action *ElasticNicCreateAction) ReadParam(param interface{}) (interface{}, error) {
+func (action *ElasticNicCreateAction) ReadParam(ctx context.Context, param interface{}) (interface{}, error) {
 	var inputs ElasticNicInputs
 	err := UnmarshalJson(param, &inputs)
 	if err != nil {
@@ -114,31 +112,30 @@ func elasticNicCreateCheckParam(elasticNic *ElasticNicInput) error {
 	return nil
 }
 
-func (action *ElasticNicCreateAction) createElasticNic(ElasticNicInput *ElasticNicInput) (ElasticNicOutput, error) {
+func (action *ElasticNicCreateAction) createElasticNic(ctx context.Context, ElasticNicInput *ElasticNicInput) (ElasticNicOutput, error) {
 	output := ElasticNicOutput{
 		Guid: ElasticNicInput.Guid,
 	}
 	output.CallBackParameter.Parameter = ElasticNicInput.CallBackParameter.Parameter
 	output.Result.Code = RESULT_CODE_SUCCESS
 
-	if err := elasticNicCreateCheckParam(ElasticNicInput); err != nil {
-		output.Result.Code = RESULT_CODE_ERROR
-		output.Result.Message = err.Error()
+	if err := AsValidationError(elasticNicCreateCheckParam(ElasticNicInput)); err != nil {
+		output.Result.SetError(err)
 		return output, err
 	}
 
-	if ElasticNicInput.Location != "" && ElasticNicInput.APISecret != "" {
-		ElasticNicInput.ProviderParams = fmt.Sprintf("%s;%s", ElasticNicInput.Location, ElasticNicInput.APISecret)
+	params, err := NewProviderParams(ElasticNicInput.ProviderParams, ElasticNicInput.Location, ElasticNicInput.APISecret)
+	if err != nil {
+		output.Result.SetError(err)
+		return output, err
 	}
-	paramsMap, err := GetMapFromProviderParams(ElasticNicInput.ProviderParams)
-	client, _ := CreateElasticNicClient(paramsMap["Region"], paramsMap["SecretID"], paramsMap["SecretKey"])
+	client, _ := CreateElasticNicClient(ctx, params)
 
 	//check resource exist
 	if ElasticNicInput.Id != "" {
 		queryElasticNiResponse, flag, err := queryElasticNicInfo(client, ElasticNicInput)
 		if err != nil && flag == false {
-			output.Result.Code = RESULT_CODE_ERROR
-			output.Result.Message = err.Error()
+			output.Result.SetError(err)
 			return output, err
 		}
 
@@ -160,9 +157,8 @@ func (action *ElasticNicCreateAction) createElasticNic(ElasticNicInput *ElasticN
 	}
 	response, err := client.CreateNetworkInterface(request)
 	if err != nil {
-		logrus.Errorf("failed to create elastic nic, error=%s", err)
-		output.Result.Code = RESULT_CODE_ERROR
-		output.Result.Message = err.Error()
+		Logger(ctx).Errorf("failed to create elastic nic, error=%s", err)
+		output.Result.SetError(err)
 		return output, err
 	}
 
@@ -180,27 +176,26 @@ func (action *ElasticNicCreateAction) createElasticNic(ElasticNicInput *ElasticN
 	return output, nil
 }
 
-func (action *ElasticNicCreateAction) Do(input interface{}) (interface{}, error) {
+func (action *ElasticNicCreateAction) Do(ctx context.Context, input interface{}) (interface{}, error) {
 	elasticNics, _ := input.(ElasticNicInputs)
-	outputs := ElasticNicOutputs{}
-	var finalErr error
-
-	for _, elasticNic := range elasticNics.Inputs {
-		elasticNicOutput, err := action.createElasticNic(&elasticNic)
-		if err != nil {
-			finalErr = err
-		}
-		outputs.Outputs = append(outputs.Outputs, elasticNicOutput)
-	}
+	outputs := ElasticNicOutputs{Outputs: make([]ElasticNicOutput, len(elasticNics.Inputs))}
+	finalErr := runInputs(ctx, len(elasticNics.Inputs), func(i int) []string {
+		return []string{elasticNics.Inputs[i].Guid, elasticNics.Inputs[i].Id}
+	}, func(ctx context.Context, i int) error {
+		elasticNic := elasticNics.Inputs[i]
+		elasticNicOutput, err := action.createElasticNic(ctx, &elasticNic)
+		outputs.Outputs[i] = elasticNicOutput
+		return err
+	})
 
-	logrus.Infof("all elasticNics = %v are created", elasticNics)
+	Logger(ctx).Infof("all elasticNics = %s are created", Sanitize(elasticNics))
 	return &outputs, finalErr
 }
 
 type ElasticNicTerminateAction struct {
 }
 
-func (action *ElasticNicTerminateAction) ReadParam(param interface{}) (interface{}, error) {
+func (action *ElasticNicTerminateAction) ReadParam(ctx context.Context, param interface{}) (interface{}, error) {
 	var inputs ElasticNicInputs
 	err := UnmarshalJson(param, &inputs)
 	if err != nil {
@@ -226,29 +221,28 @@ func elasticNicTerminateCheckParam(elasticNic *ElasticNicInput) error {
 	return nil
 }
 
-func (action *ElasticNicTerminateAction) terminateElasticNic(ElasticNicInput *ElasticNicInput) (ElasticNicOutput, error) {
+func (action *ElasticNicTerminateAction) terminateElasticNic(ctx context.Context, ElasticNicInput *ElasticNicInput) (ElasticNicOutput, error) {
 	output := ElasticNicOutput{
 		Guid: ElasticNicInput.Guid,
 	}
 	output.Result.Code = RESULT_CODE_SUCCESS
 	output.CallBackParameter.Parameter = ElasticNicInput.CallBackParameter.Parameter
 
-	if err := elasticNicTerminateCheckParam(ElasticNicInput); err != nil {
-		output.Result.Code = RESULT_CODE_ERROR
-		output.Result.Message = err.Error()
+	if err := AsValidationError(elasticNicTerminateCheckParam(ElasticNicInput)); err != nil {
+		output.Result.SetError(err)
 		return output, err
 	}
-	if ElasticNicInput.Location != "" && ElasticNicInput.APISecret != "" {
-		ElasticNicInput.ProviderParams = fmt.Sprintf("%s;%s", ElasticNicInput.Location, ElasticNicInput.APISecret)
+	params, err := NewProviderParams(ElasticNicInput.ProviderParams, ElasticNicInput.Location, ElasticNicInput.APISecret)
+	if err != nil {
+		output.Result.SetError(err)
+		return output, err
 	}
-	paramsMap, err := GetMapFromProviderParams(ElasticNicInput.ProviderParams)
-	client, _ := CreateElasticNicClient(paramsMap["Region"], paramsMap["SecretID"], paramsMap["SecretKey"])
+	client, _ := CreateElasticNicClient(ctx, params)
 
 	// check whether elastic nic is exist.
 	_, flag, err := queryElasticNicInfo(client, ElasticNicInput)
 	if err != nil {
-		output.Result.Code = RESULT_CODE_ERROR
-		output.Result.Message = err.Error()
+		output.Result.SetError(err)
 		return output, err
 	}
 	if !flag {
@@ -258,17 +252,15 @@ func (action *ElasticNicTerminateAction) terminateElasticNic(ElasticNicInput *El
 	//check elastic nic status can detach
 	err = ensureElasticNicDetach(client, ElasticNicInput)
 	if err != nil {
-		output.Result.Code = RESULT_CODE_ERROR
-		output.Result.Message = err.Error()
+		output.Result.SetError(err)
 		return output, err
 	}
 	request := vpc.NewDeleteNetworkInterfaceRequest()
 	request.NetworkInterfaceId = &ElasticNicInput.Id
 	response, err := client.DeleteNetworkInterface(request)
 	if err != nil {
-		logrus.Errorf("failed to terminate elastic nic, error=%s", err)
-		output.Result.Code = RESULT_CODE_ERROR
-		output.Result.Message = err.Error()
+		Logger(ctx).Errorf("failed to terminate elastic nic, error=%s", err)
+		output.Result.SetError(err)
 		return output, err
 	}
 
@@ -277,19 +269,19 @@ func (action *ElasticNicTerminateAction) terminateElasticNic(ElasticNicInput *El
 	return output, nil
 }
 
-func (action *ElasticNicTerminateAction) Do(input interface{}) (interface{}, error) {
+func (action *ElasticNicTerminateAction) Do(ctx context.Context, input interface{}) (interface{}, error) {
 	elasticNics, _ := input.(ElasticNicInputs)
-	outputs := ElasticNicOutputs{}
-	var finalErr error
-	for _, elasticNic := range elasticNics.Inputs {
-		elasticNicOutput, err := action.terminateElasticNic(&elasticNic)
-		if err != nil {
-			finalErr = err
-		}
-		outputs.Outputs = append(outputs.Outputs, elasticNicOutput)
-	}
+	outputs := ElasticNicOutputs{Outputs: make([]ElasticNicOutput, len(elasticNics.Inputs))}
+	finalErr := runInputs(ctx, len(elasticNics.Inputs), func(i int) []string {
+		return []string{elasticNics.Inputs[i].Guid, elasticNics.Inputs[i].Id, elasticNics.Inputs[i].InstanceId}
+	}, func(ctx context.Context, i int) error {
+		elasticNic := elasticNics.Inputs[i]
+		elasticNicOutput, err := action.terminateElasticNic(ctx, &elasticNic)
+		outputs.Outputs[i] = elasticNicOutput
+		return err
+	})
 
-	logrus.Infof("all elasticNics = %v are terminate", elasticNics)
+	Logger(ctx).Infof("all elasticNics = %s are terminate", Sanitize(elasticNics))
 	return outputs, finalErr
 }
 
@@ -336,7 +328,7 @@ func queryElasticNicInfo(client *vpc.Client, input *ElasticNicInput) (*ElasticNi
 type ElasticNicAttachAction struct {
 }
 
-func (action *ElasticNicAttachAction) ReadParam(param interface{}) (interface{}, error) {
+func (action *ElasticNicAttachAction) ReadParam(ctx context.Context, param interface{}) (interface{}, error) {
 	var inputs ElasticNicInputs
 	err := UnmarshalJson(param, &inputs)
 	if err != nil {
@@ -364,69 +356,67 @@ func elasticNicAttachCheckParam(elasticNic *ElasticNicInput) error {
 	return nil
 }
 
-func (action *ElasticNicAttachAction) attachElasticNic(ElasticNicInput *ElasticNicInput) (ElasticNicOutput, error) {
+func (action *ElasticNicAttachAction) attachElasticNic(ctx context.Context, ElasticNicInput *ElasticNicInput) (ElasticNicOutput, error) {
 	output := ElasticNicOutput{
 		Guid: ElasticNicInput.Guid,
 	}
 	output.Result.Code = RESULT_CODE_SUCCESS
 	output.CallBackParameter.Parameter = ElasticNicInput.CallBackParameter.Parameter
 
-	if err := elasticNicAttachCheckParam(ElasticNicInput); err != nil {
-		output.Result.Code = RESULT_CODE_ERROR
-		output.Result.Message = err.Error()
+	if err := AsValidationError(elasticNicAttachCheckParam(ElasticNicInput)); err != nil {
+		output.Result.SetError(err)
 		return output, err
 	}
 
-	if ElasticNicInput.Location != "" && ElasticNicInput.APISecret != "" {
-		ElasticNicInput.ProviderParams = fmt.Sprintf("%s;%s", ElasticNicInput.Location, ElasticNicInput.APISecret)
+	params, err := NewProviderParams(ElasticNicInput.ProviderParams, ElasticNicInput.Location, ElasticNicInput.APISecret)
+	if err != nil {
+		output.Result.SetError(err)
+		return output, err
 	}
-	paramsMap, err := GetMapFromProviderParams(ElasticNicInput.ProviderParams)
-	client, _ := CreateElasticNicClient(paramsMap["Region"], paramsMap["SecretID"], paramsMap["SecretKey"])
+	client, _ := CreateElasticNicClient(ctx, params)
 
 	request := vpc.NewAttachNetworkInterfaceRequest()
 
 	request.NetworkInterfaceId = &ElasticNicInput.Id
 	request.InstanceId = &ElasticNicInput.InstanceId
 
-	logrus.Infof("request:%v", ElasticNicInput)
+	Logger(ctx).Infof("request:%s", Sanitize(ElasticNicInput))
 	response, err := client.AttachNetworkInterface(request)
 	if err != nil {
-		logrus.Errorf("failed to attach elastic nic, error=%s", err)
-		output.Result.Code = RESULT_CODE_ERROR
-		output.Result.Message = err.Error()
+		Logger(ctx).Errorf("failed to attach elastic nic, error=%s", err)
+		output.Result.SetError(err)
 		return output, err
 	}
 
 	output.RequestId = *response.Response.RequestId
-	err = checkElasticNicState(client, ElasticNicInput.Id, true, ELASTIC_NIC_STATE_AVAILABLE)
+	err = checkElasticNicState(ctx, client, ElasticNicInput.Id, true, ELASTIC_NIC_STATE_AVAILABLE, ElasticNicInput.WaitTimeout)
 	if err != nil {
-		output.Result.Code = RESULT_CODE_ERROR
-		output.Result.Message = err.Error()
+		output.Result.SetError(err)
 	}
 
 	return output, err
 }
 
-func (action *ElasticNicAttachAction) Do(input interface{}) (interface{}, error) {
+func (action *ElasticNicAttachAction) Do(ctx context.Context, input interface{}) (interface{}, error) {
 	elasticNics, _ := input.(ElasticNicInputs)
-	outputs := ElasticNicOutputs{}
-	var finalErr error
-	for _, elasticNic := range elasticNics.Inputs {
-		elasticNicOutput, err := action.attachElasticNic(&elasticNic)
-		if err != nil {
-			finalErr = err
-		}
-		outputs.Outputs = append(outputs.Outputs, elasticNicOutput)
-	}
+	outputs := ElasticNicOutputs{Outputs: make([]ElasticNicOutput, len(elasticNics.Inputs))}
+	finalErr := runInputs(ctx, len(elasticNics.Inputs), func(i int) []string {
+		return []string{elasticNics.Inputs[i].Guid, elasticNics.Inputs[i].Id, elasticNics.Inputs[i].InstanceId}
+	}, func(ctx context.Context, i int) error {
+		elasticNic := elasticNics.Inputs[i]
+		elasticNicOutput, err := action.attachElasticNic(ctx, &elasticNic)
+		outputs.Outputs[i] = elasticNicOutput
+		return err
+	})
 
-	logrus.Infof("all elasticNics = %v are attach", elasticNics)
+	Logger(ctx).Infof("all elasticNics = %s are attach", Sanitize(elasticNics))
 	return &outputs, finalErr
 }
 
 type ElasticNicDetachAction struct {
 }
 
-func (action *ElasticNicDetachAction) ReadParam(param interface{}) (interface{}, error) {
+func (action *ElasticNicDetachAction) ReadParam(ctx context.Context, param interface{}) (interface{}, error) {
 	var inputs ElasticNicInputs
 	err := UnmarshalJson(param, &inputs)
 	if err != nil {
@@ -454,24 +444,24 @@ func elasticNicDetachCheckParam(elasticNic *ElasticNicInput) error {
 	return nil
 }
 
-func (action *ElasticNicDetachAction) detachElasticNic(ElasticNicInput *ElasticNicInput) (ElasticNicOutput, error) {
+func (action *ElasticNicDetachAction) detachElasticNic(ctx context.Context, ElasticNicInput *ElasticNicInput) (ElasticNicOutput, error) {
 	output := ElasticNicOutput{
 		Guid: ElasticNicInput.Guid,
 	}
 	output.CallBackParameter.Parameter = ElasticNicInput.CallBackParameter.Parameter
 	output.Result.Code = RESULT_CODE_SUCCESS
 
-	if err := elasticNicDetachCheckParam(ElasticNicInput); err != nil {
-		output.Result.Code = RESULT_CODE_ERROR
-		output.Result.Message = err.Error()
+	if err := AsValidationError(elasticNicDetachCheckParam(ElasticNicInput)); err != nil {
+		output.Result.SetError(err)
 		return output, err
 	}
 
-	if ElasticNicInput.Location != "" && ElasticNicInput.APISecret != "" {
-		ElasticNicInput.ProviderParams = fmt.Sprintf("%s;%s", ElasticNicInput.Location, ElasticNicInput.APISecret)
+	params, err := NewProviderParams(ElasticNicInput.ProviderParams, ElasticNicInput.Location, ElasticNicInput.APISecret)
+	if err != nil {
+		output.Result.SetError(err)
+		return output, err
 	}
-	paramsMap, err := GetMapFromProviderParams(ElasticNicInput.ProviderParams)
-	client, _ := CreateElasticNicClient(paramsMap["Region"], paramsMap["SecretID"], paramsMap["SecretKey"])
+	client, _ := CreateElasticNicClient(ctx, params)
 
 	request := vpc.NewDetachNetworkInterfaceRequest()
 
@@ -480,34 +470,32 @@ func (action *ElasticNicDetachAction) detachElasticNic(ElasticNicInput *ElasticN
 
 	response, err := client.DetachNetworkInterface(request)
 	if err != nil {
-		logrus.Errorf("failed to detach elastic nic, error=%s", err)
-		output.Result.Code = RESULT_CODE_ERROR
-		output.Result.Message = err.Error()
+		Logger(ctx).Errorf("failed to detach elastic nic, error=%s", err)
+		output.Result.SetError(err)
 		return output, err
 	}
 	output.RequestId = *response.Response.RequestId
-	err = checkElasticNicState(client, ElasticNicInput.Id, true, ELASTIC_NIC_STATE_AVAILABLE)
+	err = checkElasticNicState(ctx, client, ElasticNicInput.Id, true, ELASTIC_NIC_STATE_AVAILABLE, ElasticNicInput.WaitTimeout)
 	if err != nil {
-		output.Result.Code = RESULT_CODE_ERROR
-		output.Result.Message = err.Error()
+		output.Result.SetError(err)
 	}
 
 	return output, err
 }
 
-func (action *ElasticNicDetachAction) Do(input interface{}) (interface{}, error) {
+func (action *ElasticNicDetachAction) Do(ctx context.Context, input interface{}) (interface{}, error) {
 	elasticNics, _ := input.(ElasticNicInputs)
-	outputs := ElasticNicOutputs{}
-	var finalErr error
-	for _, elasticNic := range elasticNics.Inputs {
-		elasticNicOutput, err := action.detachElasticNic(&elasticNic)
-		if err != nil {
-			finalErr = err
-		}
-		outputs.Outputs = append(outputs.Outputs, elasticNicOutput)
-	}
+	outputs := ElasticNicOutputs{Outputs: make([]ElasticNicOutput, len(elasticNics.Inputs))}
+	finalErr := runInputs(ctx, len(elasticNics.Inputs), func(i int) []string {
+		return []string{elasticNics.Inputs[i].Guid, elasticNics.Inputs[i].Id, elasticNics.Inputs[i].InstanceId}
+	}, func(ctx context.Context, i int) error {
+		elasticNic := elasticNics.Inputs[i]
+		elasticNicOutput, err := action.detachElasticNic(ctx, &elasticNic)
+		outputs.Outputs[i] = elasticNicOutput
+		return err
+	})
 
-	logrus.Infof("all elasticNics = %v are detach", elasticNics)
+	Logger(ctx).Infof("all elasticNics = %s are detach", Sanitize(elasticNics))
 	return &outputs, finalErr
 }
 
@@ -551,32 +539,27 @@ func queryElasticNicById(client *vpc.Client, id string) (*vpc.NetworkInterface,
 	return response.Response.NetworkInterfaceSet[0], true, nil
 }
 
-// isExist:  the disk is exist or not expected; state: the disk state expected.
+// isExist:  the nic is exist or not expected; state: the nic state expected.
 // 1. if state is not "", the isExist must be true; 2. if state is "", the isExist can be false or true.
-func checkElasticNicState(client *vpc.Client, id string, isExist bool, state string) error {
-	count := 1
-	for {
+func checkElasticNicState(ctx context.Context, client *vpc.Client, id string, isExist bool, state string, timeout string) error {
+	waiter, err := NewWaiter(WAIT_RESOURCE_ELASTIC_NIC, id, timeout)
+	if err != nil {
+		return err
+	}
+	return waiter.Wait(ctx, func() (string, bool, error) {
 		nic, ok, err := queryElasticNicById(client, id)
 		if err != nil {
-			return err
+			return "", false, err
 		}
 
-		// check whether the disk is existed.
-		if state == "" {
-			if isExist == ok {
-				return nil
-			}
-		} else {
-			// if the state is expected, return no error; the isExist is true default.
-			if ok && *nic.State == state {
-				return nil
-			}
+		// check whether the nic is existed.
+		if !ok {
+			return "", state == "" && !isExist, nil
 		}
-
-		if count > 20 {
-			logrus.Errorf("after %vs, the nic[%v] state=%v", 5*count, id, *nic.State)
-			return fmt.Errorf("after %vs, the nic[%v] state=%v", 5*count, id, *nic.State)
+		if state == "" {
+			return *nic.State, isExist, nil
 		}
-		time.Sleep(5 * time.Second)
-	}
+		// if the state is expected, return no error; the isExist is true default.
+		return *nic.State, *nic.State == state, nil
+	})
 }
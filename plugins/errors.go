@@ -0,0 +1,159 @@
+package plugins
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"regexp"
+	"strings"
+
+	"github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/common/errors"
+	legacy "github.com/zqfan/tencentcloud-sdk-go/common"
+)
+
+// the categories of errors, they tell workflows how to handle a failed input without parsing the message.
+const (
+	// ERROR_CATEGORY_VALIDATION is a wrong input, it fails again until the input is fixed.
+	ERROR_CATEGORY_VALIDATION = "VALIDATION"
+	// ERROR_CATEGORY_QUOTA is a quota or inventory which is used up.
+	ERROR_CATEGORY_QUOTA = "QUOTA_EXCEEDED"
+	// ERROR_CATEGORY_PERMISSION is a credential which is invalid or not allowed to do the action.
+	ERROR_CATEGORY_PERMISSION = "PERMISSION_DENIED"
+	// ERROR_CATEGORY_THROTTLING is a request rejected by the request limit of the cloud API.
+	ERROR_CATEGORY_THROTTLING = "THROTTLING"
+	// ERROR_CATEGORY_NOT_FOUND is a resource which does not exist or is already gone.
+	ERROR_CATEGORY_NOT_FOUND = "NOT_FOUND"
+	// ERROR_CATEGORY_CONFLICT is a resource in use or in a state which does not allow the action.
+	ERROR_CATEGORY_CONFLICT = "CONFLICT"
+	// ERROR_CATEGORY_TIMEOUT is an action or a wait which does not finish in time.
+	ERROR_CATEGORY_TIMEOUT = "TIMEOUT"
+	// ERROR_CATEGORY_CANCELED is an action canceled by the caller.
+	ERROR_CATEGORY_CANCELED = "CANCELED"
+	// ERROR_CATEGORY_UNAVAILABLE is a transient error of the cloud API or the network.
+	ERROR_CATEGORY_UNAVAILABLE = "UNAVAILABLE"
+	// ERROR_CATEGORY_INTERNAL is any other error.
+	ERROR_CATEGORY_INTERNAL = "INTERNAL"
+)
+
+// the codes of the errors raised by plugins, errors of the cloud API keep their own codes.
+const (
+	ERROR_CODE_INVALID_PARAMETER      = "InvalidParameter"
+	ERROR_CODE_WAIT_TIMEOUT           = "WaitTimeout"
+	ERROR_CODE_ACTION_TIMEOUT         = "ActionTimeout"
+	ERROR_CODE_ACTION_CANCELED        = "ActionCanceled"
+	ERROR_CODE_IN_PROGRESS            = "InProgress"
+	ERROR_CODE_INPUT_CHANGED          = "InputChanged"
+	ERROR_CODE_TASK_NOT_FOUND         = "TaskNotFound"
+	ERROR_CODE_NETWORK                = "NetworkError"
+	ERROR_CODE_PLUGIN_NOT_FOUND       = "PluginNotFound"
+	ERROR_CODE_ACTION_NOT_FOUND       = "ActionNotFound"
+	ERROR_CODE_PATH_NOT_FOUND         = "PathNotFound"
+	ERROR_CODE_METHOD_NOT_ALLOWED     = "MethodNotAllowed"
+	ERROR_CODE_UNSUPPORTED_MEDIA_TYPE = "UnsupportedMediaType"
+	ERROR_CODE_REQUEST_TOO_LARGE      = "RequestTooLarge"
+	ERROR_CODE_INTERNAL               = "InternalError"
+	ERROR_CODE_SHUTTING_DOWN          = "ShuttingDown"
+	ERROR_CODE_AUDIT_DISABLED         = "AuditDisabled"
+)
+
+// errorCodeCategories maps the codes of the cloud API to categories, a code matches the item of its
+// own or of its prefix, such as "LimitExceeded.Quota" matches "LimitExceeded". The first match wins.
+var errorCodeCategories = []struct {
+	codes     []string
+	category  string
+	retryable bool
+}{
+	{throttledErrorCodes, ERROR_CATEGORY_THROTTLING, true},
+	{transientErrorCodes, ERROR_CATEGORY_UNAVAILABLE, true},
+	{[]string{"AuthFailure", "UnauthorizedOperation", "OperationDenied.AccessDenied"}, ERROR_CATEGORY_PERMISSION, false},
+	{[]string{"LimitExceeded", "ResourceInsufficient", "ResourcesSoldOut", "InsufficientBalance", "FailedOperation.InsufficientBalance"}, ERROR_CATEGORY_QUOTA, false},
+	{[]string{"ResourceInUse", "ResourceUnavailable", "UnsupportedOperation", "OperationDenied", "IncorrectState"}, ERROR_CATEGORY_CONFLICT, false},
+	{[]string{"InvalidParameter", "InvalidParameterValue", "MissingParameter", "UnknownParameter", "InvalidFilter", "InvalidAction"}, ERROR_CATEGORY_VALIDATION, false},
+}
+
+// apiErrorCodePattern finds the code of a cloud API error in the message of an error which wraps it.
+var apiErrorCodePattern = regexp.MustCompile(`\[(?:TencentCloudSDKError|APIError)\] Code=([\w.]+)`)
+
+// PluginError is an error with a stable category, the code of the cloud API or of the plugin, and
+// whether the same input may succeed if it is run again.
+type PluginError struct {
+	Category  string
+	Code      string
+	Message   string
+	Retryable bool
+}
+
+func (e *PluginError) Error() string {
+	return e.Message
+}
+
+func newPluginError(category, code string, retryable bool, format string, args ...interface{}) *PluginError {
+	return &PluginError{Category: category, Code: code, Message: fmt.Sprintf(format, args...), Retryable: retryable}
+}
+
+// NewValidationError returns the error of a wrong input.
+func NewValidationError(format string, args ...interface{}) error {
+	return newPluginError(ERROR_CATEGORY_VALIDATION, ERROR_CODE_INVALID_PARAMETER, false, format, args...)
+}
+
+// AsValidationError marks the error of checking an input as a validation error, nil is kept. The errors
+// which are classified, such as the errors of the cloud API called by the check, keep their category.
+func AsValidationError(err error) error {
+	if err == nil {
+		return nil
+	}
+	if pluginErr := ClassifyError(err); pluginErr.Category != ERROR_CATEGORY_INTERNAL {
+		return pluginErr
+	}
+	return NewValidationError("%s", err.Error())
+}
+
+// ClassifyError returns the error with its category, errors of the cloud API are classified by their
+// codes, also when they are wrapped by fmt.Errorf. Unknown errors are internal and not retryable.
+func ClassifyError(err error) *PluginError {
+	switch e := err.(type) {
+	case nil:
+		return nil
+	case *PluginError:
+		return e
+	case *errors.TencentCloudSDKError:
+		return classifyErrorCode(e.Code, err.Error())
+	case *legacy.APIError:
+		return classifyErrorCode(e.Code, err.Error())
+	case *ActionCanceledError:
+		if e.Err == context.DeadlineExceeded {
+			return &PluginError{Category: ERROR_CATEGORY_TIMEOUT, Code: ERROR_CODE_ACTION_TIMEOUT, Message: err.Error(), Retryable: true}
+		}
+		return &PluginError{Category: ERROR_CATEGORY_CANCELED, Code: ERROR_CODE_ACTION_CANCELED, Message: err.Error(), Retryable: true}
+	case net.Error:
+		return &PluginError{Category: ERROR_CATEGORY_UNAVAILABLE, Code: ERROR_CODE_NETWORK, Message: err.Error(), Retryable: true}
+	}
+	return classifyErrorMessage(err.Error())
+}
+
+// classifyErrorMessage classifies an error by its message, such as the message in the result of an output.
+func classifyErrorMessage(message string) *PluginError {
+	if match := apiErrorCodePattern.FindStringSubmatch(message); match != nil {
+		return classifyErrorCode(match[1], message)
+	}
+	switch {
+	case strings.Contains(message, context.DeadlineExceeded.Error()):
+		return &PluginError{Category: ERROR_CATEGORY_TIMEOUT, Code: ERROR_CODE_ACTION_TIMEOUT, Message: message, Retryable: true}
+	case strings.Contains(message, context.Canceled.Error()):
+		return &PluginError{Category: ERROR_CATEGORY_CANCELED, Code: ERROR_CODE_ACTION_CANCELED, Message: message, Retryable: true}
+	}
+	return &PluginError{Category: ERROR_CATEGORY_INTERNAL, Message: message}
+}
+
+func classifyErrorCode(code, message string) *PluginError {
+	// such as "ResourceNotFound" and "InvalidParameterValue.SubnetNotFound".
+	if strings.Contains(code, "NotFound") {
+		return &PluginError{Category: ERROR_CATEGORY_NOT_FOUND, Code: code, Message: message}
+	}
+	for _, item := range errorCodeCategories {
+		if matchErrorCode(code, item.codes) {
+			return &PluginError{Category: item.category, Code: code, Message: message, Retryable: item.retryable}
+		}
+	}
+	return &PluginError{Category: ERROR_CATEGORY_INTERNAL, Code: code, Message: message}
+}
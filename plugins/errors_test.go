@@ -0,0 +1,109 @@
+package plugins
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/common/errors"
+	legacy "github.com/zqfan/tencentcloud-sdk-go/common"
+)
+
+func TestClassifyError(t *testing.T) {
+	cases := []struct {
+		err       error
+		category  string
+		code      string
+		retryable bool
+	}{
+		{errors.NewTencentCloudSDKError("RequestLimitExceeded", "too many requests", "id-1"), ERROR_CATEGORY_THROTTLING, "RequestLimitExceeded", true},
+		{errors.NewTencentCloudSDKError("LimitExceeded.Quota", "quota", "id-1"), ERROR_CATEGORY_QUOTA, "LimitExceeded.Quota", false},
+		{errors.NewTencentCloudSDKError("AuthFailure.SignatureFailure", "signature", "id-1"), ERROR_CATEGORY_PERMISSION, "AuthFailure.SignatureFailure", false},
+		{errors.NewTencentCloudSDKError("InvalidParameterValue.SubnetNotFound", "subnet", "id-1"), ERROR_CATEGORY_NOT_FOUND, "InvalidParameterValue.SubnetNotFound", false},
+		{errors.NewTencentCloudSDKError("ResourceInUse", "in use", "id-1"), ERROR_CATEGORY_CONFLICT, "ResourceInUse", false},
+		{errors.NewTencentCloudSDKError("ResourceUnavailable.ServiceUnavailable", "unavailable", "id-1"), ERROR_CATEGORY_UNAVAILABLE, "ResourceUnavailable.ServiceUnavailable", true},
+		{errors.NewTencentCloudSDKError("InvalidParameterValue", "value", "id-1"), ERROR_CATEGORY_VALIDATION, "InvalidParameterValue", false},
+		{legacy.NewAPIError("InvalidNatGatewayId.NotFound", "nat", 0, "id-1"), ERROR_CATEGORY_NOT_FOUND, "InvalidNatGatewayId.NotFound", false},
+		{fmt.Errorf("createVpc meet error=%v", errors.NewTencentCloudSDKError("UnauthorizedOperation", "denied", "id-1")), ERROR_CATEGORY_PERMISSION, "UnauthorizedOperation", false},
+		{&ActionCanceledError{Err: context.DeadlineExceeded}, ERROR_CATEGORY_TIMEOUT, ERROR_CODE_ACTION_TIMEOUT, true},
+		{&ActionCanceledError{Err: context.Canceled}, ERROR_CATEGORY_CANCELED, ERROR_CODE_ACTION_CANCELED, true},
+		{NewValidationError("vpc id is empty"), ERROR_CATEGORY_VALIDATION, ERROR_CODE_INVALID_PARAMETER, false},
+		{AsValidationError(fmt.Errorf("vpc id is empty")), ERROR_CATEGORY_VALIDATION, ERROR_CODE_INVALID_PARAMETER, false},
+		{AsValidationError(errors.NewTencentCloudSDKError("ResourceNotFound", "vpc", "id-1")), ERROR_CATEGORY_NOT_FOUND, "ResourceNotFound", false},
+		{fmt.Errorf("unknown"), ERROR_CATEGORY_INTERNAL, "", false},
+	}
+	for _, c := range cases {
+		pluginErr := ClassifyError(c.err)
+		if pluginErr.Category != c.category || pluginErr.Code != c.code || pluginErr.Retryable != c.retryable || pluginErr.Error() != c.err.Error() {
+			t.Errorf("error %v is classified as %+v, expected %v %v %v", c.err, pluginErr, c.category, c.code, c.retryable)
+		}
+	}
+	if ClassifyError(nil) != nil {
+		t.Errorf("nil is classified")
+	}
+}
+
+func TestResultSetErrorIsBackwardCompatible(t *testing.T) {
+	result := Result{}
+	b, _ := json.Marshal(result)
+	if string(b) != `{"errorCode":"","errorMessage":""}` {
+		t.Errorf("json of the empty result=%s", b)
+	}
+
+	result.SetError(fmt.Errorf("wait vm(ins-1) meet error=%v", errors.NewTencentCloudSDKError("RequestLimitExceeded", "too many requests", "id-1")))
+	b, _ = json.Marshal(result)
+	if !strings.HasPrefix(string(b), `{"errorCode":"1","errorMessage":"wait vm(ins-1) meet error=[TencentCloudSDKError] Code=RequestLimitExceeded`) ||
+		!strings.HasSuffix(string(b), `"errorCategory":"THROTTLING","errorReason":"RequestLimitExceeded","retryable":true}`) {
+		t.Errorf("json of the error result=%s", b)
+	}
+}
+
+type errorTestOutput struct {
+	Guid   string
+	Result Result
+}
+
+type errorTestOutputs struct {
+	Outputs []errorTestOutput
+}
+
+func TestClassifyOutputErrors(t *testing.T) {
+	results := errorTestOutputs{Outputs: []errorTestOutput{
+		{Guid: "guid_1", Result: Result{Code: RESULT_CODE_SUCCESS}},
+		{Guid: "guid_2", Result: Result{Code: RESULT_CODE_ERROR, Message: "Failed to release EIP(Id=eip-1), error=[APIError] Code=InvalidAddressId.NotFound, Message=not found"}},
+		{Guid: "guid_3", Result: Result{Code: RESULT_CODE_ERROR, Message: "quota", ErrorCategory: ERROR_CATEGORY_QUOTA}},
+	}}
+	classifyOutputErrors(&results)
+
+	outputs := results.Outputs
+	if outputs[0].Result.ErrorCategory != "" || outputs[2].Result.ErrorCategory != ERROR_CATEGORY_QUOTA {
+		t.Errorf("outputs=%+v", outputs)
+	}
+	if outputs[1].Result.ErrorCategory != ERROR_CATEGORY_NOT_FOUND || outputs[1].Result.ErrorReason != "InvalidAddressId.NotFound" {
+		t.Errorf("result of guid_2=%+v", outputs[1].Result)
+	}
+}
+
+func TestProcessValidationError(t *testing.T) {
+	response, _ := Process(context.Background(), &PluginRequest{ProviderName: PROVIDER_NAME, Version: VERSION, Name: "vpc", Action: "create", Parameters: strings.NewReader("{")})
+	if response.ResultCode != RESULT_CODE_ERROR || response.ErrorCategory != ERROR_CATEGORY_VALIDATION ||
+		response.ErrorReason != ERROR_CODE_INVALID_PARAMETER || response.Retryable {
+		t.Errorf("response=%+v", response)
+	}
+}
+
+func TestProcessNotFoundError(t *testing.T) {
+	response, _ := Process(context.Background(), &PluginRequest{ProviderName: PROVIDER_NAME, Version: VERSION, Name: "not-exist"})
+	if response.ResultCode != RESULT_CODE_ERROR || response.ErrorCategory != ERROR_CATEGORY_NOT_FOUND ||
+		response.ErrorReason != ERROR_CODE_PLUGIN_NOT_FOUND || response.Retryable {
+		t.Errorf("response=%+v", response)
+	}
+
+	response, _ = Process(context.Background(), &PluginRequest{ProviderName: PROVIDER_NAME, Version: VERSION, Name: "vpc", Action: "not-exist"})
+	if response.ResultCode != RESULT_CODE_ERROR || response.ErrorCategory != ERROR_CATEGORY_NOT_FOUND ||
+		response.ErrorReason != ERROR_CODE_ACTION_NOT_FOUND || response.Retryable {
+		t.Errorf("response=%+v", response)
+	}
+}
@@ -0,0 +1,233 @@
+package plugins
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+const (
+	DEFAULT_MAX_PARALLEL_INPUTS = 5
+)
+
+var maxParallelInputs int32 = DEFAULT_MAX_PARALLEL_INPUTS
+
+type inputProgressKey struct{}
+
+// inputProgressFunc is called by runInputs when an input of the action starts and when it is done, err is
+// the error of the input once it is done. i is the index of the action input.
+type inputProgressFunc func(i int, done bool, err error)
+
+// withInputProgress returns ctx whose runInputs reports the progress of each input to progress, the inputs
+// run by an input are not reported.
+func withInputProgress(ctx context.Context, progress inputProgressFunc) context.Context {
+	return context.WithValue(ctx, inputProgressKey{}, progress)
+}
+
+// SetMaxParallelInputs sets how many inputs of one action may run at the same time,
+// values less than 1 fall back to DEFAULT_MAX_PARALLEL_INPUTS.
+func SetMaxParallelInputs(max int) {
+	if max < 1 {
+		max = DEFAULT_MAX_PARALLEL_INPUTS
+	}
+	atomic.StoreInt32(&maxParallelInputs, int32(max))
+}
+
+func GetMaxParallelInputs() int {
+	return int(atomic.LoadInt32(&maxParallelInputs))
+}
+
+// ActionCanceledError is returned by runInputs when ctx is done before all the inputs succeed,
+// the indexes tell which inputs finished, failed or were never started.
+type ActionCanceledError struct {
+	Total      int
+	Finished   []int
+	Failed     []int
+	NotStarted []int
+	// Guids of the inputs, they are filled by the caller to report the inputs by guid.
+	Guids []string
+	Err   error
+}
+
+func (e *ActionCanceledError) Error() string {
+	return fmt.Sprintf("action is canceled (%v), %v of %v inputs finished%v, %v failed%v, %v not started%v",
+		e.Err, len(e.Finished), e.Total, e.formatGuids(e.Finished), len(e.Failed), e.formatGuids(e.Failed),
+		len(e.NotStarted), e.formatGuids(e.NotStarted))
+}
+
+func (e *ActionCanceledError) formatGuids(indexes []int) string {
+	guids := []string{}
+	for _, i := range indexes {
+		if i < len(e.Guids) && e.Guids[i] != "" {
+			guids = append(guids, e.Guids[i])
+		}
+	}
+	if len(guids) == 0 {
+		return ""
+	}
+	return " [" + strings.Join(guids, ",") + "]"
+}
+
+// runInputs calls runInput for every input index in [0, total) with at most
+// GetMaxParallelInputs() inputs running at the same time.
+//
+// serialKeys returns the keys an input depends on, such as its guid or the id of
+// the resource it modifies. Inputs sharing any non-empty key are run one after
+// another in their original order. serialKeys may be nil.
+//
+// runInput should store its output at index i, so output order is kept. The
+// returned error is the error of the last failed input, same as a sequential loop.
+//
+// Once ctx is done no more input is started, the inputs already running stop at their
+// next cloud API call or wait, and an *ActionCanceledError is returned.
+//
+// In dry run mode inputs are run one by one in order, so the planned calls are reported by input.
+//
+// Each input is run in its own span, runInput should use the ctx it is given so the cloud API calls
+// and waits of the input are traced as its children.
+func runInputs(ctx context.Context, total int, serialKeys func(i int) []string, runInput func(ctx context.Context, i int) error) error {
+	errs := make([]error, total)
+	started := make([]bool, total)
+	groups := groupInputsBySerialKeys(total, serialKeys)
+
+	parallel := GetMaxParallelInputs()
+	plan := getDryRunPlan(ctx)
+	if plan != nil {
+		parallel = 1
+	}
+	if parallel > 1 && len(groups) > 1 {
+		Logger(ctx).Infof("run %v inputs in %v groups, max parallel = %v", total, len(groups), parallel)
+	}
+
+	semaphore := make(chan struct{}, parallel)
+	wg := sync.WaitGroup{}
+	for _, group := range groups {
+		wg.Add(1)
+		go func(indexes []int) {
+			defer wg.Done()
+			for _, i := range indexes {
+				select {
+				case <-ctx.Done():
+					return
+				case semaphore <- struct{}{}:
+				}
+				if ctx.Err() != nil {
+					<-semaphore
+					return
+				}
+				started[i] = true
+				if plan != nil {
+					plan.setCurrentInput(i)
+				}
+				errs[i] = runInputSafely(ctx, i, runInput)
+				<-semaphore
+			}
+		}(group)
+		// the plan of dry run lists the calls in the order of inputs.
+		if plan != nil {
+			wg.Wait()
+		}
+	}
+	wg.Wait()
+
+	var finalErr error
+	canceledErr := &ActionCanceledError{Total: total, Err: ctx.Err()}
+	for i, err := range errs {
+		if err != nil {
+			finalErr = err
+		}
+		switch {
+		case !started[i]:
+			canceledErr.NotStarted = append(canceledErr.NotStarted, i)
+		case err != nil:
+			canceledErr.Failed = append(canceledErr.Failed, i)
+		default:
+			canceledErr.Finished = append(canceledErr.Finished, i)
+		}
+	}
+	if canceledErr.Err != nil && len(canceledErr.Finished) < total {
+		Logger(ctx).Warnf("run inputs is canceled (%v), finished=%v, failed=%v, not started=%v",
+			canceledErr.Err, canceledErr.Finished, canceledErr.Failed, canceledErr.NotStarted)
+		return canceledErr
+	}
+	return finalErr
+}
+
+func runInputSafely(ctx context.Context, i int, runInput func(ctx context.Context, i int) error) (err error) {
+	_, nested := getInputIndex(ctx)
+	ctx = withInputIndex(ctx, i)
+	if progress, ok := ctx.Value(inputProgressKey{}).(inputProgressFunc); ok && !nested {
+		index, _ := getInputIndex(ctx)
+		progress(index, false, nil)
+		defer func() {
+			progress(index, true, err)
+		}()
+	}
+
+	ctx, span := StartSpan(ctx, "input", SPAN_KIND_INTERNAL)
+	span.SetAttribute("input.index", i)
+	defer func() {
+		if r := recover(); r != nil {
+			Logger(ctx).Errorf("input[%v] panic: %v", i, r)
+			err = fmt.Errorf("input[%v] panic: %v", i, r)
+		}
+		span.End(err)
+	}()
+	return runInput(ctx, i)
+}
+
+// groupInputsBySerialKeys puts inputs which share a key (directly or through other
+// inputs) into the same group, indexes in each group and the groups are sorted.
+func groupInputsBySerialKeys(total int, serialKeys func(i int) []string) [][]int {
+	parents := make([]int, total)
+	for i := range parents {
+		parents[i] = i
+	}
+	var find func(i int) int
+	find = func(i int) int {
+		if parents[i] != i {
+			parents[i] = find(parents[i])
+		}
+		return parents[i]
+	}
+
+	if serialKeys != nil {
+		owners := make(map[string]int)
+		for i := 0; i < total; i++ {
+			for _, key := range serialKeys(i) {
+				if key == "" {
+					continue
+				}
+				owner, found := owners[key]
+				if !found {
+					owners[key] = i
+					continue
+				}
+				if root, ownerRoot := find(i), find(owner); root != ownerRoot {
+					if root < ownerRoot {
+						parents[ownerRoot] = root
+					} else {
+						parents[root] = ownerRoot
+					}
+				}
+			}
+		}
+	}
+
+	groupByRoot := make(map[int][]int)
+	for i := 0; i < total; i++ {
+		root := find(i)
+		groupByRoot[root] = append(groupByRoot[root], i)
+	}
+	groups := make([][]int, 0, len(groupByRoot))
+	for _, group := range groupByRoot {
+		groups = append(groups, group)
+	}
+	sort.Slice(groups, func(i, j int) bool {
+		return groups[i][0] < groups[j][0]
+	})
+	return groups
+}
@@ -0,0 +1,213 @@
+package plugins
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestGroupInputsBySerialKeys(t *testing.T) {
+	keys := [][]string{
+		{"guid-0", "sg-1"},
+		{"guid-1", ""},
+		{"guid-2", "sg-2"},
+		{"guid-3", "sg-1"},
+		{"guid-4", "sg-2", "ins-1"},
+		{"guid-5", "ins-1"},
+		{"guid-1"},
+	}
+	groups := groupInputsBySerialKeys(len(keys), func(i int) []string {
+		return keys[i]
+	})
+
+	expected := [][]int{{0, 3}, {1, 6}, {2, 4, 5}}
+	if !reflect.DeepEqual(groups, expected) {
+		t.Fatalf("groups = %v, expected %v", groups, expected)
+	}
+}
+
+func TestGroupInputsWithoutSerialKeys(t *testing.T) {
+	groups := groupInputsBySerialKeys(3, nil)
+
+	expected := [][]int{{0}, {1}, {2}}
+	if !reflect.DeepEqual(groups, expected) {
+		t.Fatalf("groups = %v, expected %v", groups, expected)
+	}
+}
+
+func TestRunInputsKeepsOrderAndLimitsParallel(t *testing.T) {
+	defer SetMaxParallelInputs(GetMaxParallelInputs())
+	SetMaxParallelInputs(3)
+
+	var running, maxRunning int32
+	outputs := make([]string, 10)
+	err := runInputs(context.Background(), len(outputs), nil, func(ctx context.Context, i int) error {
+		current := atomic.AddInt32(&running, 1)
+		for {
+			max := atomic.LoadInt32(&maxRunning)
+			if current <= max || atomic.CompareAndSwapInt32(&maxRunning, max, current) {
+				break
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+		atomic.AddInt32(&running, -1)
+
+		outputs[i] = fmt.Sprintf("output-%d", i)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("runInputs meet error=%v", err)
+	}
+
+	for i, output := range outputs {
+		if output != fmt.Sprintf("output-%d", i) {
+			t.Errorf("outputs[%d] = %v", i, output)
+		}
+	}
+	if maxRunning > 3 {
+		t.Errorf("max running inputs = %d, expected no more than 3", maxRunning)
+	}
+	if maxRunning < 2 {
+		t.Errorf("max running inputs = %d, expected inputs to run in parallel", maxRunning)
+	}
+}
+
+func TestRunInputsRunsSameKeyInOrder(t *testing.T) {
+	defer SetMaxParallelInputs(GetMaxParallelInputs())
+	SetMaxParallelInputs(5)
+
+	keys := []string{"sg-1", "sg-2", "sg-1", "sg-2", "sg-1"}
+	mutex := sync.Mutex{}
+	order := make(map[string][]int)
+	err := runInputs(context.Background(), len(keys), func(i int) []string {
+		return []string{keys[i]}
+	}, func(ctx context.Context, i int) error {
+		time.Sleep(time.Duration(len(keys)-i) * time.Millisecond)
+		mutex.Lock()
+		order[keys[i]] = append(order[keys[i]], i)
+		mutex.Unlock()
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("runInputs meet error=%v", err)
+	}
+
+	expected := map[string][]int{"sg-1": {0, 2, 4}, "sg-2": {1, 3}}
+	if !reflect.DeepEqual(order, expected) {
+		t.Fatalf("order = %v, expected %v", order, expected)
+	}
+}
+
+func TestRunInputsReturnsLastError(t *testing.T) {
+	outputs := make([]Result, 4)
+	err := runInputs(context.Background(), len(outputs), nil, func(ctx context.Context, i int) error {
+		outputs[i].Code = RESULT_CODE_SUCCESS
+		if i == 1 || i == 2 {
+			outputs[i].Code = RESULT_CODE_ERROR
+			return fmt.Errorf("input %d failed", i)
+		}
+		if i == 3 {
+			panic("unexpected")
+		}
+		return nil
+	})
+
+	if err == nil || err.Error() != "input[3] panic: unexpected" {
+		t.Fatalf("err = %v, expected panic of input 3", err)
+	}
+	expectedCodes := []string{RESULT_CODE_SUCCESS, RESULT_CODE_ERROR, RESULT_CODE_ERROR, RESULT_CODE_SUCCESS}
+	for i, output := range outputs {
+		if output.Code != expectedCodes[i] {
+			t.Errorf("outputs[%d].Code = %v, expected %v", i, output.Code, expectedCodes[i])
+		}
+	}
+
+	err = runInputs(context.Background(), 2, nil, func(ctx context.Context, i int) error {
+		if i == 0 {
+			return errors.New("input 0 failed")
+		}
+		return nil
+	})
+	if err == nil || err.Error() != "input 0 failed" {
+		t.Fatalf("err = %v, expected error of input 0", err)
+	}
+}
+
+func TestRunInputsStopsWhenCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	ran := make([]bool, 4)
+	// the same key runs the inputs one by one in order.
+	err := runInputs(ctx, len(ran), func(i int) []string { return []string{"sg-1"} }, func(ctx context.Context, i int) error {
+		ran[i] = true
+		if i == 1 {
+			cancel()
+			return ctx.Err()
+		}
+		return nil
+	})
+
+	canceledErr, ok := err.(*ActionCanceledError)
+	if !ok {
+		t.Fatalf("err = %v, expected ActionCanceledError", err)
+	}
+	if !reflect.DeepEqual(canceledErr.Finished, []int{0}) || !reflect.DeepEqual(canceledErr.Failed, []int{1}) ||
+		!reflect.DeepEqual(canceledErr.NotStarted, []int{2, 3}) || canceledErr.Err != context.Canceled {
+		t.Errorf("err = %+v", canceledErr)
+	}
+	if ran[2] || ran[3] {
+		t.Errorf("inputs started after cancel, ran = %v", ran)
+	}
+}
+
+func TestDoActionReportsNotStartedInputs(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	inputs := testInputs{}
+	for i := 0; i < 3; i++ {
+		inputs.Inputs = append(inputs.Inputs, testInput{Guid: fmt.Sprintf("guid-%d", i), CallBackParameter: CallBackParameter{Parameter: fmt.Sprintf("cb-%d", i)}})
+	}
+	// the inputs are run one by one, so the third input is not started once the second one cancels the action.
+	action := &testAction{serial: true, runInput: func(ctx context.Context, i int, input testInput, output *testOutput) error {
+		if i == 1 {
+			cancel()
+		}
+		return nil
+	}}
+
+	results, err := doAction(ctx, "test", "cancel", action, inputs)
+	expectedErr := "action is canceled (context canceled), 2 of 3 inputs finished [guid-0,guid-1], 0 failed, 1 not started [guid-2]"
+	if err == nil || err.Error() != expectedErr {
+		t.Errorf("err = %v", err)
+	}
+	outputs := getTestOutputs(results)
+	for i := 0; i < 2; i++ {
+		if outputs[i].Parameter != fmt.Sprintf("cb-%d", i) || outputs[i].Code != RESULT_CODE_SUCCESS {
+			t.Errorf("outputs[%d] = %+v", i, outputs[i])
+		}
+	}
+	if outputs[2].Guid != "guid-2" || outputs[2].Parameter != "cb-2" || outputs[2].Code != RESULT_CODE_ERROR {
+		t.Errorf("outputs[2] = %+v", outputs[2])
+	}
+}
+
+func TestActionTimeoutPolicy(t *testing.T) {
+	policy := &ActionTimeoutPolicy{Timeout: time.Minute, Timeouts: map[string]time.Duration{"vm.create": time.Hour}}
+	if timeout := policy.GetTimeout("vm", "create"); timeout != time.Hour {
+		t.Errorf("vm.create timeout = %v", timeout)
+	}
+	if timeout := policy.GetTimeout("vm", "terminate"); timeout != time.Minute {
+		t.Errorf("vm.terminate timeout = %v", timeout)
+	}
+
+	ctx, cancel := (&ActionTimeoutPolicy{}).WithTimeout(context.Background(), "vm", "create")
+	defer cancel()
+	if _, found := ctx.Deadline(); found {
+		t.Errorf("no timeout, but the context has a deadline")
+	}
+}
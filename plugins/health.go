@@ -0,0 +1,18 @@
+package plugins
+
+import (
+	"context"
+
+	cvm "github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/cvm/v20170312"
+)
+
+// CheckCredential checks the credential used when provider_params do not supply the keys, by DescribeZones
+// of cvm in the region, which changes no resource.
+func CheckCredential(ctx context.Context, region string) error {
+	client, err := GetClientFactory().WithContext(ctx).NewCvmClient(region, "", "")
+	if err != nil {
+		return err
+	}
+	_, err = client.DescribeZones(cvm.NewDescribeZonesRequest())
+	return err
+}
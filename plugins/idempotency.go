@@ -0,0 +1,582 @@
+package plugins
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/WeBankPartners/wecube-plugins-qcloud/plugins/utils"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	DEFAULT_IDEMPOTENCY_EXPIRE_DURATION = 24 * time.Hour
+
+	idempotencyCleanInterval = time.Hour
+)
+
+// IdempotencyRecord is what the store remembers of an input of a create action, keyed by (plugin, action, guid).
+type IdempotencyRecord struct {
+	Plugin string `json:"plugin"`
+	Action string `json:"action"`
+	Guid   string `json:"guid"`
+	// InputHash tells whether a retry sends the same input, records of other inputs are not replayed.
+	InputHash string `json:"inputHash"`
+	// Status is TASK_STATUS_RUNNING, TASK_STATUS_SUCCESS or TASK_STATUS_FAILED.
+	Status string `json:"status"`
+	// ResourceId is the id of the created resource, it is recorded as soon as the cloud API returns it.
+	ResourceId string `json:"resourceId,omitempty"`
+	// ResourceSecrets are the encrypted secrets the action generated for the resource, such as the password of
+	// a vm, so the input which resumes with the resource returns them.
+	ResourceSecrets map[string]string `json:"resourceSecrets,omitempty"`
+	// Output is the output of the input whose secrets are masked, EncryptedOutput is the whole output encrypted
+	// with the key of the input, which is replayed.
+	Output          json.RawMessage `json:"output,omitempty"`
+	EncryptedOutput string          `json:"encryptedOutput,omitempty"`
+	CreateTime      time.Time       `json:"createTime"`
+	UpdateTime      time.Time       `json:"updateTime"`
+
+	outputKey string
+}
+
+// IdempotencyStore persists the outputs of create actions in Dir, one file per (plugin, action, guid).
+//
+// When an action is retried with the same inputs, the inputs which succeeded before are not run again
+// and their previous outputs are replayed. The inputs which were running or failed after their resource
+// was created are run again with the id of that resource, so the create action finds it instead of
+// creating another one. An input which is changed after its resource was created is rejected, since
+// the resource was not created by it.
+//
+// The outputs are encrypted with a key derived from their inputs, which have the secrets of the cloud API
+// and are not stored, so the secrets of the outputs such as the secret key of a user are not on disk.
+type IdempotencyStore struct {
+	Dir string
+	// Expire is how long a record is kept after its last update, DEFAULT_IDEMPOTENCY_EXPIRE_DURATION is used if zero.
+	Expire time.Duration
+
+	mutex     sync.Mutex
+	running   map[string]bool
+	lastClean time.Time
+}
+
+// DefaultIdempotencyStore is used by create actions, nil disables the replay.
+var DefaultIdempotencyStore *IdempotencyStore
+
+// IsIdempotentAction tells whether the outputs of the action are stored, they are the actions which create resources.
+func IsIdempotentAction(actionName string) bool {
+	return actionName == "create" || actionName == "add" || strings.HasPrefix(actionName, "create-")
+}
+
+func getIdempotencyKey(pluginName, actionName, guid string) string {
+	return pluginName + "/" + actionName + "/" + guid
+}
+
+func (store *IdempotencyStore) getExpire() time.Duration {
+	if store.Expire <= 0 {
+		return DEFAULT_IDEMPOTENCY_EXPIRE_DURATION
+	}
+	return store.Expire
+}
+
+func (store *IdempotencyStore) getFilename(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return filepath.Join(store.Dir, hex.EncodeToString(sum[:])+".json")
+}
+
+// Get returns the record of the key, nil if it is not found or expired.
+func (store *IdempotencyStore) Get(pluginName, actionName, guid string) (*IdempotencyRecord, error) {
+	data, err := ioutil.ReadFile(store.getFilename(getIdempotencyKey(pluginName, actionName, guid)))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	record := &IdempotencyRecord{}
+	if err = json.Unmarshal(data, record); err != nil {
+		return nil, err
+	}
+	if record.Plugin != pluginName || record.Action != actionName || record.Guid != guid {
+		return nil, fmt.Errorf("idempotency record of %v is not of this key", getIdempotencyKey(pluginName, actionName, guid))
+	}
+	if time.Since(record.UpdateTime) > store.getExpire() {
+		return nil, nil
+	}
+	return record, nil
+}
+
+// Put writes the record to a temporary file and renames it, so a crash never leaves a partial record.
+func (store *IdempotencyStore) Put(record *IdempotencyRecord) error {
+	record.UpdateTime = time.Now()
+	if record.CreateTime.IsZero() {
+		record.CreateTime = record.UpdateTime
+	}
+	data, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+
+	if err = os.MkdirAll(store.Dir, 0700); err != nil {
+		return err
+	}
+	filename := store.getFilename(getIdempotencyKey(record.Plugin, record.Action, record.Guid))
+	file, err := ioutil.TempFile(store.Dir, ".record-")
+	if err != nil {
+		return err
+	}
+	if _, err = file.Write(data); err == nil {
+		err = file.Sync()
+	}
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(file.Name(), filename)
+	}
+	if err != nil {
+		os.Remove(file.Name())
+	}
+	return err
+}
+
+// RemoveExpired removes the records which were not updated within the expire duration.
+func (store *IdempotencyStore) RemoveExpired() {
+	files, err := ioutil.ReadDir(store.Dir)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			logrus.Warnf("read idempotency dir %v meet error=%v", store.Dir, err)
+		}
+		return
+	}
+	for _, file := range files {
+		if file.IsDir() || time.Since(file.ModTime()) <= store.getExpire() {
+			continue
+		}
+		if err := os.Remove(filepath.Join(store.Dir, file.Name())); err != nil {
+			logrus.Warnf("remove expired idempotency record %v meet error=%v", file.Name(), err)
+		}
+	}
+}
+
+func (store *IdempotencyStore) removeExpiredPeriodically() {
+	store.mutex.Lock()
+	if time.Since(store.lastClean) < idempotencyCleanInterval {
+		store.mutex.Unlock()
+		return
+	}
+	store.lastClean = time.Now()
+	store.mutex.Unlock()
+
+	store.RemoveExpired()
+}
+
+// acquire marks the key as run by this process, it fails if another request of this process is running it.
+func (store *IdempotencyStore) acquire(key string) bool {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	if store.running[key] {
+		return false
+	}
+	if store.running == nil {
+		store.running = make(map[string]bool)
+	}
+	store.running[key] = true
+	return true
+}
+
+func (store *IdempotencyStore) release(key string) {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	delete(store.running, key)
+}
+
+func hashInput(input reflect.Value) string {
+	data, err := json.Marshal(input.Interface())
+	if err != nil {
+		return ""
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// getOutputKey returns the key which encrypts the output of the input, it is not the input hash in the record.
+func getOutputKey(input reflect.Value) string {
+	data, err := json.Marshal(input.Interface())
+	if err != nil {
+		return ""
+	}
+	sum := sha256.Sum256(append([]byte("output:"), data...))
+	return hex.EncodeToString(sum[:])[:32]
+}
+
+func (record *IdempotencyRecord) setOutput(output interface{}) error {
+	data, err := json.Marshal(output)
+	if err != nil {
+		return err
+	}
+	if record.outputKey == "" {
+		return fmt.Errorf("the input has no key to encrypt its output")
+	}
+	if record.EncryptedOutput, err = utils.AesEncode(record.outputKey, string(data)); err != nil {
+		return err
+	}
+	record.Output = json.RawMessage(Sanitize(output))
+	return nil
+}
+
+func (record *IdempotencyRecord) getOutput() (json.RawMessage, error) {
+	if record.EncryptedOutput == "" {
+		return nil, fmt.Errorf("the output is not encrypted")
+	}
+	data, err := utils.AesDecode(record.outputKey, record.EncryptedOutput)
+	if err != nil {
+		return nil, err
+	}
+	return json.RawMessage(data), nil
+}
+
+type idempotencySessionKey struct{}
+
+// idempotencySession is the state of one action run by the store, its records are keyed by guid.
+type idempotencySession struct {
+	store   *IdempotencyStore
+	mutex   sync.Mutex
+	records map[string]*IdempotencyRecord
+}
+
+// recordResourceId remembers the id of the resource created for the input, it should be called by create actions
+// right after the cloud API returns the id, so a retry after a crash or timeout resumes with the resource.
+func recordResourceId(ctx context.Context, guid, id string) {
+	session, ok := ctx.Value(idempotencySessionKey{}).(*idempotencySession)
+	if !ok || guid == "" || id == "" {
+		return
+	}
+
+	session.mutex.Lock()
+	defer session.mutex.Unlock()
+
+	record, found := session.records[guid]
+	if !found {
+		return
+	}
+	record.ResourceId = id
+	if err := session.store.Put(record); err != nil {
+		Logger(ctx).Errorf("record resource id %v of input guid %v meet error=%v", id, guid, err)
+	}
+}
+
+// getRecordedResourceId returns the id of the resource recorded for the input by a previous run, empty if there is
+// none. It is used by the create actions whose inputs have no id field to resume with the resource.
+func getRecordedResourceId(ctx context.Context, guid string) string {
+	session, ok := ctx.Value(idempotencySessionKey{}).(*idempotencySession)
+	if !ok || guid == "" {
+		return ""
+	}
+
+	session.mutex.Lock()
+	defer session.mutex.Unlock()
+
+	if record, found := session.records[guid]; found {
+		return record.ResourceId
+	}
+	return ""
+}
+
+// recordResourceSecret remembers an encrypted secret of the resource created for the input, it should be called by
+// create actions before the cloud API which creates the resource, so a retry which resumes with the resource gets it.
+func recordResourceSecret(ctx context.Context, guid, name, encrypted string) {
+	session, ok := ctx.Value(idempotencySessionKey{}).(*idempotencySession)
+	if !ok || guid == "" {
+		return
+	}
+
+	session.mutex.Lock()
+	defer session.mutex.Unlock()
+
+	record, found := session.records[guid]
+	if !found {
+		return
+	}
+	if record.ResourceSecrets == nil {
+		record.ResourceSecrets = make(map[string]string)
+	}
+	record.ResourceSecrets[name] = encrypted
+	if err := session.store.Put(record); err != nil {
+		Logger(ctx).Errorf("record resource secret %v of input guid %v meet error=%v", name, guid, err)
+	}
+}
+
+// getResourceSecret returns the encrypted secret recorded for the input, empty if there is none.
+func getResourceSecret(ctx context.Context, guid, name string) string {
+	session, ok := ctx.Value(idempotencySessionKey{}).(*idempotencySession)
+	if !ok || guid == "" {
+		return ""
+	}
+
+	session.mutex.Lock()
+	defer session.mutex.Unlock()
+
+	if record, found := session.records[guid]; found {
+		return record.ResourceSecrets[name]
+	}
+	return ""
+}
+
+// Do runs the action with the inputs which have no successful record and replays the outputs of the others.
+func (store *IdempotencyStore) Do(ctx context.Context, pluginName, actionName string, action Action, actionParam interface{}) (interface{}, error) {
+	inputs := getSliceField(actionParam, "Inputs")
+	if !inputs.IsValid() || inputs.Len() == 0 {
+		return action.Do(ctx, actionParam)
+	}
+	store.removeExpiredPeriodically()
+
+	total := inputs.Len()
+	guids := getGuidsFromInputs(actionParam)
+	guidCounts := make(map[string]int)
+	for _, guid := range guids {
+		guidCounts[guid]++
+	}
+
+	session := &idempotencySession{store: store, records: make(map[string]*IdempotencyRecord)}
+	replays := make(map[int]json.RawMessage)
+	conflicts := make(map[int]error)
+	runIndexes := []int{}
+	resumed := false
+	runInputs := reflect.MakeSlice(inputs.Type(), 0, total)
+	for i := 0; i < total; i++ {
+		guid := guids[i]
+		input := inputs.Index(i)
+		// inputs without guid or sharing their guid are run as usual.
+		if guid == "" || guidCounts[guid] > 1 {
+			runIndexes = append(runIndexes, i)
+			runInputs = reflect.Append(runInputs, input)
+			continue
+		}
+
+		key := getIdempotencyKey(pluginName, actionName, guid)
+		if !store.acquire(key) {
+			conflicts[i] = newPluginError(ERROR_CATEGORY_CONFLICT, ERROR_CODE_IN_PROGRESS, true, "input is being run by another request of the same guid")
+			continue
+		}
+		defer store.release(key)
+
+		inputHash := hashInput(input)
+		record, err := store.Get(pluginName, actionName, guid)
+		if err != nil {
+			Logger(ctx).Warnf("get idempotency record of %v meet error=%v, the input is run again", key, err)
+		}
+		if record != nil && record.InputHash != inputHash && record.ResourceId != "" {
+			conflicts[i] = newPluginError(ERROR_CATEGORY_CONFLICT, ERROR_CODE_INPUT_CHANGED, false,
+				"resource %v was created for another input of guid %v, run the same input or use another guid", record.ResourceId, guid)
+			continue
+		}
+		if record == nil || record.InputHash != inputHash {
+			record = &IdempotencyRecord{Plugin: pluginName, Action: actionName, Guid: guid, InputHash: inputHash}
+		}
+		record.outputKey = getOutputKey(input)
+		if record.Status == TASK_STATUS_SUCCESS {
+			output, err := record.getOutput()
+			if err == nil {
+				Logger(ctx).Infof("input guid %v of plugin[%v]-action[%v] succeeded at %v, replay its output", guid, pluginName, actionName, record.UpdateTime)
+				replays[i] = output
+				continue
+			}
+			Logger(ctx).Warnf("get output of idempotency record of %v meet error=%v, the input is run again", key, err)
+		}
+		record.Status = TASK_STATUS_RUNNING
+		record.Output, record.EncryptedOutput = nil, ""
+		if err = store.Put(record); err != nil {
+			Logger(ctx).Errorf("put idempotency record of %v meet error=%v", key, err)
+		}
+		session.records[guid] = record
+
+		if record.ResourceId != "" {
+			Logger(ctx).Infof("input guid %v of plugin[%v]-action[%v] resumes with resource %v", guid, pluginName, actionName, record.ResourceId)
+			input = withResourceId(input, record.ResourceId)
+			resumed = true
+		}
+		runIndexes = append(runIndexes, i)
+		runInputs = reflect.Append(runInputs, input)
+	}
+
+	if len(replays) == 0 && len(conflicts) == 0 && !resumed {
+		results, err := action.Do(withInputIndexes(context.WithValue(ctx, idempotencySessionKey{}, session), runIndexes), actionParam)
+		session.finish(runIndexes, getSliceField(results, "Outputs"))
+		return results, err
+	}
+
+	// runInputs sees the inputs to run only, their indexes are mapped back to the indexes of the action inputs,
+	// so the client tokens, the audit records and the RequestIds are of the right inputs.
+	results, err := action.Do(withInputIndexes(context.WithValue(ctx, idempotencySessionKey{}, session), runIndexes), withField(actionParam, "Inputs", runInputs))
+	runOutputs := getSliceField(results, "Outputs")
+	session.finish(runIndexes, runOutputs)
+	if !runOutputs.IsValid() {
+		return results, err
+	}
+	if len(runIndexes) == 0 {
+		// the action was only called to get the type of its outputs.
+		err = nil
+	}
+
+	outputs := reflect.MakeSlice(runOutputs.Type(), total, total)
+	for j, i := range runIndexes {
+		if j < runOutputs.Len() {
+			outputs.Index(i).Set(runOutputs.Index(j))
+		}
+	}
+	for i, data := range replays {
+		output := reflect.New(runOutputs.Type().Elem())
+		if er := json.Unmarshal(data, output.Interface()); er != nil {
+			Logger(ctx).Errorf("replay output of input guid %v meet error=%v", guids[i], er)
+		}
+		outputs.Index(i).Set(output.Elem())
+		copyInputIdentity(inputs.Index(i), outputs.Index(i))
+	}
+	conflictIndexes := []int{}
+	for i, conflictErr := range conflicts {
+		conflictResult := Result{}
+		conflictResult.SetError(conflictErr)
+		copyInputIdentity(inputs.Index(i), outputs.Index(i))
+		setOutputResult(outputs.Index(i), conflictResult)
+		conflictIndexes = append(conflictIndexes, i)
+	}
+	sort.Ints(conflictIndexes)
+
+	if canceledErr, ok := err.(*ActionCanceledError); ok {
+		canceledErr.Total = total
+		canceledErr.Finished = mapIndexes(canceledErr.Finished, runIndexes)
+		canceledErr.Failed = append(mapIndexes(canceledErr.Failed, runIndexes), conflictIndexes...)
+		canceledErr.NotStarted = mapIndexes(canceledErr.NotStarted, runIndexes)
+		for i := range replays {
+			canceledErr.Finished = append(canceledErr.Finished, i)
+		}
+		sort.Ints(canceledErr.Finished)
+		sort.Ints(canceledErr.Failed)
+	} else if err == nil && len(conflictIndexes) > 0 {
+		err = conflicts[conflictIndexes[0]]
+	}
+	return withField(results, "Outputs", outputs), err
+}
+
+// finish records the outputs of the inputs run by the action.
+func (session *idempotencySession) finish(runIndexes []int, runOutputs reflect.Value) {
+	session.mutex.Lock()
+	defer session.mutex.Unlock()
+
+	finished := make(map[string]bool)
+	for j := range runIndexes {
+		var output reflect.Value
+		if runOutputs.IsValid() && j < runOutputs.Len() {
+			output = reflect.Indirect(runOutputs.Index(j))
+		}
+		if !output.IsValid() || output.Kind() != reflect.Struct {
+			continue
+		}
+		guid := ""
+		if field := output.FieldByName("Guid"); field.IsValid() && field.Kind() == reflect.String {
+			guid = field.String()
+		}
+		record, found := session.records[guid]
+		if !found {
+			continue
+		}
+		finished[guid] = true
+
+		record.Status = TASK_STATUS_FAILED
+		if field := output.FieldByName("Result"); field.IsValid() {
+			if result, ok := field.Interface().(Result); ok && result.Code == RESULT_CODE_SUCCESS {
+				record.Status = TASK_STATUS_SUCCESS
+			}
+		}
+		if id := getResourceId(output); id != "" {
+			record.ResourceId = id
+		}
+		if record.Status == TASK_STATUS_SUCCESS {
+			if err := record.setOutput(output.Interface()); err != nil {
+				logrus.Errorf("record output of input guid %v meet error=%v", guid, err)
+				record.Status = TASK_STATUS_FAILED
+			}
+		}
+		if err := session.store.Put(record); err != nil {
+			logrus.Errorf("put idempotency record of input guid %v meet error=%v", guid, err)
+		}
+	}
+
+	// the inputs without output were not started or panicked.
+	for guid, record := range session.records {
+		if finished[guid] {
+			continue
+		}
+		record.Status = TASK_STATUS_FAILED
+		if err := session.store.Put(record); err != nil {
+			logrus.Errorf("put idempotency record of input guid %v meet error=%v", guid, err)
+		}
+	}
+}
+
+// resourceIdFields are the fields of inputs and outputs which hold the id of the resource.
+var resourceIdFields = []string{"Id", "ID"}
+
+func getResourceId(s reflect.Value) string {
+	for _, name := range resourceIdFields {
+		if field := s.FieldByName(name); field.IsValid() && field.Kind() == reflect.String {
+			return field.String()
+		}
+	}
+	return ""
+}
+
+// withResourceId returns a copy of the input with the resource id set if the input has an empty id field.
+func withResourceId(input reflect.Value, id string) reflect.Value {
+	s := reflect.Indirect(input)
+	if s.Kind() != reflect.Struct {
+		return input
+	}
+	for _, name := range resourceIdFields {
+		if field := s.FieldByName(name); field.IsValid() && field.Kind() == reflect.String {
+			if field.String() != "" {
+				return input
+			}
+			return reflect.ValueOf(withField(input.Interface(), name, reflect.ValueOf(id).Convert(field.Type())))
+		}
+	}
+	return input
+}
+
+// withField returns a copy of the struct or the pointer to struct s with the field replaced.
+func withField(s interface{}, name string, value reflect.Value) interface{} {
+	v := reflect.ValueOf(s)
+	if v.Kind() == reflect.Ptr {
+		copied := reflect.New(v.Elem().Type())
+		copied.Elem().Set(v.Elem())
+		copied.Elem().FieldByName(name).Set(value)
+		return copied.Interface()
+	}
+	copied := reflect.New(v.Type()).Elem()
+	copied.Set(v)
+	copied.FieldByName(name).Set(value)
+	return copied.Interface()
+}
+
+func mapIndexes(indexes []int, runIndexes []int) []int {
+	mapped := []int{}
+	for _, j := range indexes {
+		if j < len(runIndexes) {
+			mapped = append(mapped, runIndexes[j])
+		}
+	}
+	return mapped
+}
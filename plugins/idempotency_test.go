@@ -0,0 +1,362 @@
+package plugins
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/WeBankPartners/wecube-plugins-qcloud/plugins/utils"
+	cvm "github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/cvm/v20170312"
+)
+
+// idempotencyTestResources creates a resource for each input without id, the inputs of failGuids fail after creation.
+type idempotencyTestResources struct {
+	mutex     sync.Mutex
+	created   int
+	ran       []string
+	failGuids map[string]bool
+}
+
+// newAction returns the action which creates the resources, its inputs are run in order so the ids are predictable.
+func (resources *idempotencyTestResources) newAction() *testAction {
+	return &testAction{serial: true, runInput: func(ctx context.Context, i int, input testInput, output *testOutput) error {
+		resources.mutex.Lock()
+		resources.ran = append(resources.ran, input.Guid)
+		if input.Id == "" {
+			resources.created++
+			input.Id = fmt.Sprintf("res-%d", resources.created)
+			recordResourceId(ctx, input.Guid, input.Id)
+		}
+		resources.mutex.Unlock()
+
+		if resources.failGuids[input.Guid] {
+			return fmt.Errorf("wait timeout")
+		}
+		output.Id = input.Id
+		return nil
+	}}
+}
+
+func newTestIdempotencyStore(t *testing.T) (*IdempotencyStore, func()) {
+	dir, err := ioutil.TempDir("", "idempotency")
+	if err != nil {
+		t.Fatalf("create temp dir meet error=%v", err)
+	}
+	return &IdempotencyStore{Dir: dir}, func() { os.RemoveAll(dir) }
+}
+
+func getTestOutputIds(results interface{}) []string {
+	ids := []string{}
+	for _, output := range getTestOutputs(results) {
+		ids = append(ids, output.Id)
+	}
+	return ids
+}
+
+func TestIdempotencyStorePutAndExpire(t *testing.T) {
+	store, clean := newTestIdempotencyStore(t)
+	defer clean()
+
+	record := &IdempotencyRecord{Plugin: "vm", Action: "create", Guid: "guid_1", Status: TASK_STATUS_RUNNING, ResourceId: "ins-1"}
+	if err := store.Put(record); err != nil {
+		t.Fatalf("Put meet error=%v", err)
+	}
+	got, err := store.Get("vm", "create", "guid_1")
+	if err != nil || got == nil || got.ResourceId != "ins-1" || got.Status != TASK_STATUS_RUNNING || got.CreateTime.IsZero() {
+		t.Errorf("record=%+v, error=%v", got, err)
+	}
+	if got, err = store.Get("vm", "create", "guid_2"); got != nil || err != nil {
+		t.Errorf("record of another guid=%+v, error=%v", got, err)
+	}
+
+	store.Expire = time.Millisecond
+	time.Sleep(10 * time.Millisecond)
+	if got, err = store.Get("vm", "create", "guid_1"); got != nil || err != nil {
+		t.Errorf("expired record=%+v, error=%v", got, err)
+	}
+	store.RemoveExpired()
+	if files, _ := ioutil.ReadDir(store.Dir); len(files) != 0 {
+		t.Errorf("%d files are left after removing expired records", len(files))
+	}
+}
+
+func TestIdempotencyStoreReplaysAndResumes(t *testing.T) {
+	store, clean := newTestIdempotencyStore(t)
+	defer clean()
+
+	resources := &idempotencyTestResources{failGuids: map[string]bool{"guid_2": true}}
+	action := resources.newAction()
+	inputs := testInputs{Inputs: []testInput{{Guid: "guid_1", Name: "a"}, {Guid: "guid_2", Name: "b"}}}
+	results, err := store.Do(context.Background(), "test", "create", action, inputs)
+	if err == nil || strings.Join(getTestOutputIds(results), ",") != "res-1," {
+		t.Fatalf("first run, outputs=%+v, error=%v", results, err)
+	}
+	if outputs := getTestOutputs(results); outputs[1].Code != RESULT_CODE_ERROR || outputs[1].Message != "wait timeout" {
+		t.Errorf("failed output=%+v", outputs[1])
+	}
+
+	// guid_1 is replayed, guid_2 resumes with the resource created by the first run.
+	resources.failGuids = nil
+	resources.ran = nil
+	results, err = store.Do(context.Background(), "test", "create", action, inputs)
+	if err != nil || strings.Join(getTestOutputIds(results), ",") != "res-1,res-2" {
+		t.Fatalf("retry, outputs=%+v, error=%v", results, err)
+	}
+	if strings.Join(resources.ran, ",") != "guid_2" || resources.created != 2 {
+		t.Errorf("retry ran %v, %d resources created", resources.ran, resources.created)
+	}
+	if outputs := getTestOutputs(results); outputs[0].Guid != "guid_1" || outputs[0].Code != RESULT_CODE_SUCCESS {
+		t.Errorf("replayed output=%+v", outputs[0])
+	}
+	if inputs.Inputs[1].Id != "" {
+		t.Errorf("the inputs of the caller are modified, %+v", inputs.Inputs[1])
+	}
+
+	// the input of guid_1 is changed after its resource was created, it is rejected.
+	resources.ran = nil
+	inputs.Inputs[0].Name = "c"
+	results, err = store.Do(context.Background(), "test", "create", action, inputs)
+	if ClassifyError(err).Code != ERROR_CODE_INPUT_CHANGED || strings.Join(resources.ran, ",") != "" {
+		t.Errorf("changed input, ran=%v, outputs=%+v, error=%v", resources.ran, results, err)
+	}
+	if outputs := getTestOutputs(results); outputs[0].Guid != "guid_1" || outputs[0].Code != RESULT_CODE_ERROR || !strings.Contains(outputs[0].Message, "res-1") ||
+		outputs[1].Id != "res-2" {
+		t.Errorf("outputs of changed input=%+v", outputs)
+	}
+
+	// an input changed before its resource was created is run again.
+	action = &testAction{runInput: func(ctx context.Context, i int, input testInput, output *testOutput) error {
+		resources.ran = append(resources.ran, input.Guid)
+		return fmt.Errorf("invalid name")
+	}}
+	store.Do(context.Background(), "test", "create", action, newTestInputs("guid_3"))
+	resources.ran = nil
+	inputs = testInputs{Inputs: []testInput{{Guid: "guid_3", Name: "d"}}}
+	results, err = store.Do(context.Background(), "test", "create", resources.newAction(), inputs)
+	if strings.Join(resources.ran, ",") != "guid_3" {
+		t.Errorf("input changed before creation, ran=%v, outputs=%+v, error=%v", resources.ran, results, err)
+	}
+}
+
+func TestIdempotencyStoreEncryptsOutputs(t *testing.T) {
+	store, clean := newTestIdempotencyStore(t)
+	defer clean()
+
+	ran := 0
+	action := &testAction{runInput: func(ctx context.Context, i int, input testInput, output *testOutput) error {
+		ran++
+		output.Id, output.Password = "res-1", "Ab888888"
+		return nil
+	}}
+	inputs := newTestInputs("guid_1")
+	if _, err := store.Do(context.Background(), "test", "create", action, inputs); err != nil {
+		t.Fatalf("first run meet error=%v", err)
+	}
+	files, _ := ioutil.ReadDir(store.Dir)
+	for _, file := range files {
+		if data, _ := ioutil.ReadFile(store.Dir + "/" + file.Name()); strings.Contains(string(data), "Ab888888") || !strings.Contains(string(data), "res-1") {
+			t.Errorf("record=%s", data)
+		}
+	}
+
+	results, err := store.Do(context.Background(), "test", "create", action, inputs)
+	if outputs := getTestOutputs(results); err != nil || ran != 1 || outputs[0].Id != "res-1" || outputs[0].Password != "Ab888888" {
+		t.Errorf("replay, ran %d times, outputs=%+v, error=%v", ran, outputs, err)
+	}
+}
+
+func TestIdempotencyStoreRejectsRunningGuid(t *testing.T) {
+	store, clean := newTestIdempotencyStore(t)
+	defer clean()
+
+	key := getIdempotencyKey("test", "create", "guid_1")
+	store.acquire(key)
+	defer store.release(key)
+
+	resources := &idempotencyTestResources{}
+	results, err := store.Do(context.Background(), "test", "create", resources.newAction(), newTestInputs("guid_1", "guid_2"))
+	outputs := getTestOutputs(results)
+	if err == nil || outputs[0].Guid != "guid_1" || outputs[0].Code != RESULT_CODE_ERROR || outputs[1].Id != "res-1" {
+		t.Errorf("outputs=%+v, error=%v", outputs, err)
+	}
+	if strings.Join(resources.ran, ",") != "guid_2" {
+		t.Errorf("ran %v", resources.ran)
+	}
+}
+
+func TestIdempotentVmCreateResumesWithPassword(t *testing.T) {
+	store, clean := newTestIdempotencyStore(t)
+	defer clean()
+	defer func(store *IdempotencyStore) { DefaultIdempotencyStore = store }(DefaultIdempotencyStore)
+	DefaultIdempotencyStore = store
+
+	// the vm is pending until running is set, it has no private ip.
+	mutex := sync.Mutex{}
+	running, passwords := false, []string{}
+	server, _ := newScriptedServer(func(action, body string, times int) string {
+		mutex.Lock()
+		defer mutex.Unlock()
+		switch action {
+		case "RunInstances":
+			request := cvm.RunInstancesRequest{}
+			json.Unmarshal([]byte(body), &request)
+			passwords = append(passwords, *request.LoginSettings.Password)
+			return `{"Response":{"InstanceIdSet":["ins-1"],"RequestId":"fake-request-id"}}`
+		case "DescribeInstances":
+			state := "PENDING"
+			if running && times > 1 {
+				state = INSTANCE_STATE_RUNNING
+			}
+			return `{"Response":{"TotalCount":1,"InstanceSet":[{"InstanceId":"ins-1","InstanceState":"` + state + `","CPU":1,"Memory":2,"PrivateIpAddresses":[]}],"RequestId":"fake-request-id"}}`
+		}
+		return okResponse
+	})
+	defer server.Close()
+	defer SetClientFactory(GetClientFactory())
+	SetClientFactory(newRetryTestFactory(server, &RetryPolicy{}))
+	defer func(policy *WaitPolicy) { DefaultWaitPolicy = policy }(DefaultWaitPolicy)
+	DefaultWaitPolicy = &WaitPolicy{Interval: time.Millisecond, MaxInterval: time.Millisecond, Timeouts: map[string]time.Duration{WAIT_RESOURCE_VM: 20 * time.Millisecond}}
+
+	inputs := VmCreateInputs{Inputs: []VmCreateInput{{
+		Guid:               "guid_1",
+		Seed:               "seed",
+		ProviderParams:     "Region=ap-resume;AvailableZone=ap-resume-1;SecretID=fake-secret-id;SecretKey=fake-secret-key",
+		VpcId:              "vpc-1",
+		SubnetId:           "subnet-1",
+		InstanceType:       "S1.SMALL1",
+		ImageId:            "img-1",
+		SystemDiskSize:     "50",
+		InstanceChargeType: "POSTPAID_BY_HOUR",
+	}}}
+	if _, err := doAction(context.Background(), "vm", "create", &VmCreateAction{}, inputs); err == nil {
+		t.Fatalf("the vm is running before the wait timeout")
+	}
+
+	mutex.Lock()
+	running = true
+	mutex.Unlock()
+	DefaultWaitPolicy.Timeouts = nil
+	results, err := doAction(context.Background(), "vm", "create", &VmCreateAction{}, inputs)
+	if err != nil {
+		t.Fatalf("resume meet error=%v", err)
+	}
+	output := results.(*VmCreateOutputs).Outputs[0]
+	if len(passwords) != 1 || output.Id != "ins-1" || output.InstanceState != INSTANCE_STATE_RUNNING || output.InstancePrivateIp != "" {
+		t.Fatalf("passwords=%v, output=%+v", passwords, output)
+	}
+	// the resumed output has the encrypted password generated by the first run.
+	if password, err := utils.AesDePassword("guid_1", "seed", output.Password); err != nil || password != passwords[0] {
+		t.Errorf("password of output=%v, decrypted=%v, error=%v", output.Password, password, err)
+	}
+}
+
+func TestIdempotentEipCreateResumesWithAllAddresses(t *testing.T) {
+	store, clean := newTestIdempotencyStore(t)
+	defer clean()
+	defer func(store *IdempotencyStore) { DefaultIdempotencyStore = store }(DefaultIdempotencyStore)
+	DefaultIdempotencyStore = store
+
+	// the eips are creating until ready is set.
+	mutex := sync.Mutex{}
+	ready, allocated := false, 0
+	server, _ := newScriptedServer(func(action, body string, times int) string {
+		mutex.Lock()
+		defer mutex.Unlock()
+		switch action {
+		case "AllocateAddresses":
+			allocated++
+			return `{"Response":{"AddressSet":["eip-1","eip-2"],"RequestId":"fake-request-id"}}`
+		case "DescribeAddresses":
+			status := "CREATING"
+			if ready {
+				status = "UNBIND"
+			}
+			return `{"Response":{"TotalCount":2,"AddressSet":[{"AddressId":"eip-1","AddressIp":"1.1.1.1","AddressStatus":"` + status + `"},
+				{"AddressId":"eip-2","AddressIp":"1.1.1.2","AddressStatus":"` + status + `"}],"RequestId":"fake-request-id"}}`
+		}
+		return okResponse
+	})
+	defer server.Close()
+	defer SetClientFactory(GetClientFactory())
+	SetClientFactory(newRetryTestFactory(server, &RetryPolicy{}))
+	defer func(policy *WaitPolicy) { DefaultWaitPolicy = policy }(DefaultWaitPolicy)
+	DefaultWaitPolicy = &WaitPolicy{Interval: time.Millisecond, MaxInterval: time.Millisecond, Timeouts: map[string]time.Duration{WAIT_RESOURCE_EIP: 20 * time.Millisecond}}
+
+	inputs := EIPInputs{Inputs: []EIPInput{{
+		Guid:           "guid_1",
+		ProviderParams: "Region=ap-resume;AvailableZone=ap-resume-1;SecretID=fake-secret-id;SecretKey=fake-secret-key",
+		AddressCount:   "2",
+	}}}
+	if _, err := doAction(context.Background(), "eip", "create", &EIPCreateAction{}, inputs); err == nil {
+		t.Fatalf("the eips are created before the wait timeout")
+	}
+
+	mutex.Lock()
+	ready = true
+	mutex.Unlock()
+	results, err := doAction(context.Background(), "eip", "create", &EIPCreateAction{}, inputs)
+	if err != nil {
+		t.Fatalf("resume meet error=%v", err)
+	}
+	output := results.(*EIPOutputs).Outputs[0]
+	if allocated != 1 || len(output.EIPS) != 2 || output.EIPS[0].Id != "eip-1" || output.EIPS[1].Id != "eip-2" {
+		t.Errorf("allocated %d times, output=%+v", allocated, output)
+	}
+}
+
+func TestIdempotentBucketCreateResumesWithBucket(t *testing.T) {
+	store, clean := newTestIdempotencyStore(t)
+	defer clean()
+	defer func(store *IdempotencyStore) { DefaultIdempotencyStore = store }(DefaultIdempotencyStore)
+	DefaultIdempotencyStore = store
+
+	methods := []string{}
+	defer SetClientFactory(GetClientFactory())
+	SetClientFactory(&ClientFactory{
+		RateLimit: &RateLimiter{Rate: -1, ActionRates: map[string]float64{}},
+		Retry:     &RetryPolicy{},
+		Transport: roundTripFunc(func(request *http.Request) (*http.Response, error) {
+			methods = append(methods, request.Method)
+			response := &http.Response{StatusCode: http.StatusOK, Header: http.Header{}, Body: ioutil.NopCloser(strings.NewReader("")), Request: request}
+			if request.Method == http.MethodPut {
+				response.StatusCode = http.StatusConflict
+				response.Body = ioutil.NopCloser(strings.NewReader(`<Error><Code>BucketAlreadyOwnedByYou</Code><Message>exists</Message></Error>`))
+			}
+			return response, nil
+		}),
+	})
+
+	inputs := BucketInputs{Inputs: []BucketInput{{
+		Guid:           "guid_1",
+		BucketName:     "bucket",
+		AccountAppId:   "1250000000",
+		ProviderParams: "Region=ap-guangzhou;SecretID=fake-secret-id;SecretKey=fake-secret-key",
+	}}}
+	// the previous run crashed after the bucket was created.
+	store.Put(&IdempotencyRecord{Plugin: "bucket", Action: "create", Guid: "guid_1", InputHash: hashInput(reflect.ValueOf(inputs.Inputs[0])),
+		Status: TASK_STATUS_RUNNING, ResourceId: "bucket-1250000000"})
+
+	results, err := doAction(context.Background(), "bucket", "create", &BucketCreateAction{}, inputs)
+	if err != nil {
+		t.Fatalf("resume meet error=%v", err)
+	}
+	output := results.(*BucketOutputs).Outputs[0]
+	if strings.Join(methods, ",") != http.MethodHead || output.BucketUrl != "https://bucket-1250000000.cos.ap-guangzhou.myqcloud.com" {
+		t.Errorf("requests=%v, output=%+v", methods, output)
+	}
+
+	// a bucket which is not recorded is created.
+	inputs.Inputs[0].Guid = "guid_2"
+	methods = nil
+	if _, err = doAction(context.Background(), "bucket", "create", &BucketCreateAction{}, inputs); err == nil || strings.Join(methods, ",") != http.MethodPut {
+		t.Errorf("requests=%v, error=%v", methods, err)
+	}
+}
@@ -3,6 +3,7 @@ package plugins
 import (
 	"bufio"
 	"bytes"
+	"context"
 	"errors"
 	"fmt"
 	"io"
@@ -36,6 +37,10 @@ func (plugin *LogPlugin) GetActionByName(actionName string) (Action, error) {
 	return action, nil
 }
 
+func (plugin *LogPlugin) GetActions() map[string]Action {
+	return LogActions
+}
+
 //LogSearchAction .
 type LogSearchAction struct {
 }
@@ -68,7 +73,7 @@ type SearchOutput struct {
 }
 
 //ReadParam .
-func (action *LogSearchAction) ReadParam(param interface{}) (interface{}, error) {
+func (action *LogSearchAction) ReadParam(ctx context.Context, param interface{}) (interface{}, error) {
 	var inputs SearchInputs
 	err := UnmarshalJson(param, &inputs)
 	if err != nil {
@@ -87,7 +92,7 @@ func logSearchCheckParam(log *SearchInput) error {
 }
 
 //Do .
-func (action *LogSearchAction) Do(input interface{}) (interface{}, error) {
+func (action *LogSearchAction) Do(ctx context.Context, input interface{}) (interface{}, error) {
 	logs, _ := input.(SearchInputs)
 	var logoutputs SearchOutputs
 	var finalErr error
@@ -228,7 +233,7 @@ type SearchDetailOutput struct {
 }
 
 //ReadParam .
-func (action *LogSearchDetailAction) ReadParam(param interface{}) (interface{}, error) {
+func (action *LogSearchDetailAction) ReadParam(ctx context.Context, param interface{}) (interface{}, error) {
 	var inputs SearchDetailInputs
 	err := UnmarshalJson(param, &inputs)
 	if err != nil {
@@ -250,7 +255,7 @@ func logSearchDetailCheckParam(log *SearchDetailInput) error {
 }
 
 //Do .
-func (action *LogSearchDetailAction) Do(input interface{}) (interface{}, error) {
+func (action *LogSearchDetailAction) Do(ctx context.Context, input interface{}) (interface{}, error) {
 	logs, _ := input.(SearchDetailInputs)
 	var finalErr error
 	var logoutputs SearchDetailOutputs
@@ -259,9 +264,8 @@ func (action *LogSearchDetailAction) Do(input interface{}) (interface{}, error)
 		var info SearchDetailOutput
 		info.CallBackParameter.Parameter = logs.Inputs[i].CallBackParameter.Parameter
 		info.Result.Code = RESULT_CODE_SUCCESS
-		if err := logSearchDetailCheckParam(&logs.Inputs[i]); err != nil {
-			info.Result.Code = RESULT_CODE_ERROR
-			info.Result.Message = err.Error()
+		if err := AsValidationError(logSearchDetailCheckParam(&logs.Inputs[i])); err != nil {
+			info.Result.SetError(err)
 			finalErr = err
 			logoutputs.Outputs = append(logoutputs.Outputs, info)
 			continue
@@ -269,8 +273,7 @@ func (action *LogSearchDetailAction) Do(input interface{}) (interface{}, error)
 
 		text, err := action.SearchDetail(&logs.Inputs[i])
 		if err != nil {
-			info.Result.Code = RESULT_CODE_ERROR
-			info.Result.Message = err.Error()
+			info.Result.SetError(err)
 			finalErr = err
 			logoutputs.Outputs = append(logoutputs.Outputs, info)
 			continue
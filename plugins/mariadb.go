@@ -1,17 +1,16 @@
 package plugins
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"strconv"
-	"time"
 
 	"strings"
 
 	"github.com/WeBankPartners/wecube-plugins-qcloud/plugins/utils"
 	"github.com/sirupsen/logrus"
 	"github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/common"
-	"github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/common/profile"
 	mariadb "github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/mariadb/v20170312"
 )
 
@@ -44,27 +43,28 @@ type MariadbInputs struct {
 
 type MariadbInput struct {
 	CallBackParameter
-	Guid           string `json:"guid,omitempty"`
-	Seed           string `json:"seed,omitempty"`
-	ProviderParams string `json:"provider_params,omitempty"`
-	UserName       string `json:"user_name,omitempty"`
-	Location       string `json:"location"`
-	APISecret      string `json:"api_secret"`
-
-	Id           string `json:"id,omitempty"`
-	Zones        string `json:"zones,omitempty"` //split by ,
-	NodeCount    string `json:"node_count,omitempty"`
-	MemorySize   string `json:"memory_size,omitempty"`
-	StorageSize  string `json:"storage_size,omitempty"`
-	VpcId        string `json:"vpc_id,omitempty"`
-	SubnetId     string `json:"subnet_id,omitempty"`
-	ChargePeriod string `json:"charge_period,omitempty"`
-	DbVersion    string `json:"db_version,omitempty"`
-	Password     string `json:"password,omitempty"`
+	WaitParameter
+	Guid           string `json:"guid,omitempty" register:"required"`
+	Seed           string `json:"seed,omitempty" register:"required,system_variable=ENCRYPT_SEED"`
+	ProviderParams string `json:"provider_params,omitempty" register:"optional"`
+	UserName       string `json:"user_name,omitempty" register:"required"`
+	Location       string `json:"location" register:"required"`
+	APISecret      string `json:"api_secret" register:"required,system_variable=QCLOUD_API_SECRET"`
+
+	Id           string `json:"id,omitempty" register:"optional"`
+	Zones        string `json:"zones,omitempty" register:"required"` //split by ,
+	NodeCount    string `json:"node_count,omitempty" register:"required"`
+	MemorySize   string `json:"memory_size,omitempty" register:"required"`
+	StorageSize  string `json:"storage_size,omitempty" register:"required"`
+	VpcId        string `json:"vpc_id,omitempty" register:"required"`
+	SubnetId     string `json:"subnet_id,omitempty" register:"required"`
+	ChargePeriod string `json:"charge_period,omitempty" register:"required"`
+	DbVersion    string `json:"db_version,omitempty" register:"required" enum:"mariadb_version"`
+	Password     string `json:"password,omitempty" register:"optional"`
 
 	//初始化时使用
-	CharacterSet        string `json:"character_set,omitempty"`
-	LowerCaseTableNames string `json:"lower_case_table_names,omitempty"`
+	CharacterSet        string `json:"character_set,omitempty" register:"required,system_variable=QCLOUD_MYSQL_CHARACTER_SET"`
+	LowerCaseTableNames string `json:"lower_case_table_names,omitempty" register:"required,system_variable=QCLOUD_MYSQL_LOWER_CASE_TABLE_NAMES"`
 }
 
 type MariadbOutputs struct {
@@ -75,12 +75,12 @@ type MariadbOutput struct {
 	CallBackParameter
 	Result
 	RequestId string `json:"request_id,omitempty"`
-	Guid      string `json:"guid,omitempty"`
-	Id        string `json:"id,omitempty"`
-	PrivateIp string `json:"private_ip,omitempty"`
-	Port      string `json:"private_port,omitempty"`
-	UserName  string `json:"user_name,omitempty"`
-	Password  string `json:"password,omitempty"`
+	Guid      string `json:"guid,omitempty" register:"optional"`
+	Id        string `json:"id,omitempty" register:"optional"`
+	PrivateIp string `json:"private_ip,omitempty" register:"optional"`
+	Port      string `json:"private_port,omitempty" register:"optional"`
+	UserName  string `json:"user_name,omitempty" register:"optional"`
+	Password  string `json:"password,omitempty" register:"optional"`
 }
 
 type MariadbPlugin struct {
@@ -95,10 +95,14 @@ func (plugin *MariadbPlugin) GetActionByName(actionName string) (Action, error)
 	return action, nil
 }
 
+func (plugin *MariadbPlugin) GetActions() map[string]Action {
+	return MariadbActions
+}
+
 type MariadbCreateAction struct {
 }
 
-func (action *MariadbCreateAction) ReadParam(param interface{}) (interface{}, error) {
+func (action *MariadbCreateAction) ReadParam(ctx context.Context, param interface{}) (interface{}, error) {
 	var inputs MariadbInputs
 	err := UnmarshalJson(param, &inputs)
 	if err != nil {
@@ -159,34 +163,35 @@ func mariadbCreateCheckParam(input *MariadbInput) error {
 	return nil
 }
 
-func (action *MariadbCreateAction) Do(input interface{}) (interface{}, error) {
+func (action *MariadbCreateAction) Do(ctx context.Context, input interface{}) (interface{}, error) {
 	req, _ := input.(MariadbInputs)
-	outputs := MariadbOutputs{}
-	var finalErr error
-	for _, input := range req.Inputs {
-		output, err := action.createAndInitMariadb(&input)
-		if err != nil {
-			finalErr = err
-		}
-		outputs.Outputs = append(outputs.Outputs, output)
-	}
+	outputs := MariadbOutputs{Outputs: make([]MariadbOutput, len(req.Inputs))}
+	finalErr := runInputs(ctx, len(req.Inputs), func(i int) []string {
+		return []string{req.Inputs[i].Guid, req.Inputs[i].Id}
+	}, func(ctx context.Context, i int) error {
+		input := req.Inputs[i]
+		output, err := action.createAndInitMariadb(ctx, &input)
+		outputs.Outputs[i] = output
+		return err
+	})
 
-	logrus.Infof("all mariadb instances = %v are created", outputs)
+	Logger(ctx).Infof("all mariadb instances = %s are created", Sanitize(outputs))
 	return &outputs, finalErr
 }
 
-func isValidMariadbVersion(version string) error {
-	validVersions := []string{
-		MARIADB_VERSION_10_0_10,
-		MARIADB_VERSION_10_01_09,
-		MARIADB_VERSION_05_07_17,
-	}
+// mariadbVersions are the valid versions of mariadb, the default version is used if it is empty.
+var mariadbVersions = []string{
+	MARIADB_VERSION_10_0_10,
+	MARIADB_VERSION_10_01_09,
+	MARIADB_VERSION_05_07_17,
+}
 
+func isValidMariadbVersion(version string) error {
 	if version == "" {
 		return nil
 	}
 
-	for _, validVersion := range validVersions {
+	for _, validVersion := range mariadbVersions {
 		if validVersion == version {
 			return nil
 		}
@@ -194,47 +199,43 @@ func isValidMariadbVersion(version string) error {
 	return errors.New("invalid mariadb version")
 }
 
-func CreateMariadbClient(region, secretId, secretKey string) (client *mariadb.Client, err error) {
-	credential := common.NewCredential(secretId, secretKey)
-
-	clientProfile := profile.NewClientProfile()
-	clientProfile.HttpProfile.Endpoint = "mariadb.tencentcloudapi.com"
-
-	return mariadb.NewClient(credential, region, clientProfile)
+func CreateMariadbClient(ctx context.Context, params *ProviderParams) (client *mariadb.Client, err error) {
+	return GetClientFactory().WithContext(ctx).WithProviderParams(params).NewMariadbClient(params.Region, params.SecretID, params.SecretKey)
 }
 
-func getInstanceIdByDealName(client *mariadb.Client, dealName string) (string, error) {
-	count := 0
+func getInstanceIdByDealName(ctx context.Context, client *mariadb.Client, dealName string, timeout string) (string, error) {
 	request := mariadb.NewDescribeOrdersRequest()
 	request.DealNames = []*string{&dealName}
 
-	for {
+	waiter, err := NewWaiter(WAIT_RESOURCE_MARIADB, dealName, timeout)
+	if err != nil {
+		return "", err
+	}
+	instanceId := ""
+	err = waiter.Wait(ctx, func() (string, bool, error) {
 		resp, err := client.DescribeOrders(request)
 		if err != nil {
-			return "", err
+			return "", false, err
 		}
 
 		if len(resp.Response.TotalCount) == 0 {
-			logrus.Errorf("getInstanceIdByDealName(%s) totalcount length is 0=", dealName)
-			return "", errors.New("descirbeOrder totalcount length is 0 ")
+			Logger(ctx).Errorf("getInstanceIdByDealName(%s) totalcount length is 0=", dealName)
+			return "", false, errors.New("descirbeOrder totalcount length is 0 ")
 		}
 		if *resp.Response.TotalCount[0] != 1 {
-			logrus.Errorf("getInstanceIdByDealName(%s) totalcount=%v", dealName, *resp.Response.TotalCount[0])
-			return "", errors.New("descirbeOrder totalcount!=1")
+			Logger(ctx).Errorf("getInstanceIdByDealName(%s) totalcount=%v", dealName, *resp.Response.TotalCount[0])
+			return "", false, errors.New("descirbeOrder totalcount!=1")
 		}
 		if len(resp.Response.Deals[0].InstanceIds) == 1 {
-			return *resp.Response.Deals[0].InstanceIds[0], nil
-		}
-
-		time.Sleep(10 * time.Second)
-		count++
-		if count >= 180 {
-			return "", errors.New("getInstanceIdByDealName timeout")
+			instanceId = *resp.Response.Deals[0].InstanceIds[0]
+			return "", true, nil
 		}
-	}
+		return "", false, nil
+	})
+	return instanceId, err
 }
 
-func createMariadbInstance(client *mariadb.Client, input *MariadbInput) (string, string, error) {
+func createMariadbInstance(ctx context.Context, client *mariadb.Client, input *MariadbInput) (string, string, error) {
 	zones := []*string{}
 	for _, zone := range strings.Split(input.Zones, ",") {
 		newZone := zone
@@ -272,9 +273,9 @@ func createMariadbInstance(client *mariadb.Client, input *MariadbInput) (string,
 		return "", "", err
 	}
 
-	instanceId, err := getInstanceIdByDealName(client, *resp.Response.DealName)
+	instanceId, err := getInstanceIdByDealName(ctx, client, *resp.Response.DealName, input.WaitTimeout)
 	if err != nil {
-		logrus.Errorf("getInstanceIdByDealName(%s) meet error(%v)", *resp.Response.DealName, err)
+		Logger(ctx).Errorf("getInstanceIdByDealName(%s) meet error(%v)", *resp.Response.DealName, err)
 		return "", "", err
 	}
 
@@ -301,57 +302,54 @@ func isMariadbExist(client *mariadb.Client, instanceId string) (bool, error) {
 	return true, nil
 }
 
-func waitMariadbToDesireStatus(client *mariadb.Client, instanceId string, desireState int64) (string, int64, error) {
-	count := 0
+func waitMariadbToDesireStatus(ctx context.Context, client *mariadb.Client, instanceId string, desireState int64, timeout string) (string, int64, error) {
 	request := mariadb.NewDescribeDBInstancesRequest()
 	request.InstanceIds = []*string{&instanceId}
 
-	for {
+	waiter, err := NewWaiter(WAIT_RESOURCE_MARIADB, instanceId, timeout)
+	if err != nil {
+		return "", 0, err
+	}
+	vip, vport := "", int64(0)
+	err = waiter.Wait(ctx, func() (string, bool, error) {
 		response, err := client.DescribeDBInstances(request)
 		if err != nil {
-			return "", 0, err
+			return "", false, err
 		}
 
 		if *response.Response.TotalCount == 0 {
-			return "", 0, fmt.Errorf("the mariadb (instanceId = %v) not found", instanceId)
-		}
-
-		if *response.Response.Instances[0].Status == desireState {
-			return *response.Response.Instances[0].Vip, *response.Response.Instances[0].Vport, nil
+			return "", false, fmt.Errorf("the mariadb (instanceId = %v) not found", instanceId)
 		}
 
-		time.Sleep(10 * time.Second)
-		count++
-		if count >= 360 {
-			return "", 0, errors.New("waitMariadbRunning timeout")
+		status := *response.Response.Instances[0].Status
+		if status == desireState {
+			vip, vport = *response.Response.Instances[0].Vip, *response.Response.Instances[0].Vport
 		}
-	}
+		return strconv.FormatInt(status, 10), status == desireState, nil
+	})
+	return vip, vport, err
 }
 
-func waitFlowSuccess(client *mariadb.Client, flowId *int64) error {
-	count := 0
+func waitFlowSuccess(ctx context.Context, client *mariadb.Client, flowId *int64, timeout string) error {
 	req := mariadb.NewDescribeFlowRequest()
 	req.FlowId = flowId
 
-	for {
+	waiter, err := NewWaiter(WAIT_RESOURCE_MARIADB_FLOW, strconv.FormatInt(*flowId, 10), timeout)
+	if err != nil {
+		return err
+	}
+	return waiter.Wait(ctx, func() (string, bool, error) {
 		response, err := client.DescribeFlow(req)
 		if err != nil {
-			return err
-		}
-
-		if *response.Response.Status == MARIADB_FLOW_SUCCESS_STATUS {
-			return nil
-		}
-		if *response.Response.Status == MARIADB_FLOW_FAILED_STATUS {
-			return errors.New("waitFlowSuccess,describe get failed status")
+			return "", false, err
 		}
 
-		time.Sleep(10 * time.Second)
-		count++
-		if count >= 90 {
-			return errors.New("waitMariadbRunning timeout")
+		status := *response.Response.Status
+		if status == MARIADB_FLOW_FAILED_STATUS {
+			return "", false, errors.New("waitFlowSuccess,describe get failed status")
 		}
-	}
+		return strconv.FormatInt(status, 10), status == MARIADB_FLOW_SUCCESS_STATUS, nil
+	})
 }
 
 func createMariadbAccount(client *mariadb.Client, instanceId string, userName string, password string) error {
@@ -369,7 +367,7 @@ func createMariadbAccount(client *mariadb.Client, instanceId string, userName st
 	return err
 }
 
-func initMariadb(client *mariadb.Client, instanceId string, charset string, lowCaseTableName string) error {
+func initMariadb(ctx context.Context, client *mariadb.Client, instanceId string, charset string, lowCaseTableName string, timeout string) error {
 	charSetParamName := "character_set_server"
 	lowCaseParamName := "lower_case_table_names"
 
@@ -391,7 +389,7 @@ func initMariadb(client *mariadb.Client, instanceId string, charset string, lowC
 		return err
 	}
 
-	return waitFlowSuccess(client, resp.Response.FlowId)
+	return waitFlowSuccess(ctx, client, resp.Response.FlowId, timeout)
 }
 
 func grantAccountPrivileges(client *mariadb.Client, userName string, instanceId string) error {
@@ -420,7 +418,7 @@ func grantAccountPrivileges(client *mariadb.Client, userName string, instanceId
 	return err
 }
 
-func (action *MariadbCreateAction) createAndInitMariadb(input *MariadbInput) (output MariadbOutput, err error) {
+func (action *MariadbCreateAction) createAndInitMariadb(ctx context.Context, input *MariadbInput) (output MariadbOutput, err error) {
 	output.Guid = input.Guid
 	output.Id = input.Id
 	output.Result.Code = RESULT_CODE_SUCCESS
@@ -428,12 +426,11 @@ func (action *MariadbCreateAction) createAndInitMariadb(input *MariadbInput) (ou
 
 	defer func() {
 		if err != nil {
-			output.Result.Code = RESULT_CODE_ERROR
-			output.Result.Message = err.Error()
+			output.Result.SetError(err)
 		}
 	}()
 
-	if err = mariadbCreateCheckParam(input); err != nil {
+	if err = AsValidationError(mariadbCreateCheckParam(input)); err != nil {
 		return output, err
 	}
 
@@ -448,7 +445,7 @@ func (action *MariadbCreateAction) createAndInitMariadb(input *MariadbInput) (ou
 	}
 
 	if err = isValidMariadbVersion(input.DbVersion); err != nil {
-		logrus.Errorf("invalid mariadb version(%s)", input.DbVersion)
+		Logger(ctx).Errorf("invalid mariadb version(%s)", input.DbVersion)
 		return output, err
 	}
 
@@ -456,63 +453,63 @@ func (action *MariadbCreateAction) createAndInitMariadb(input *MariadbInput) (ou
 		input.Password = utils.CreateRandomPassword()
 	}
 
-	if input.Location != "" && input.APISecret != "" {
-		input.ProviderParams = fmt.Sprintf("%s;%s", input.Location, input.APISecret)
+	params, err := NewProviderParams(input.ProviderParams, input.Location, input.APISecret)
+	if err != nil {
+		return output, err
 	}
-	paramsMap, _ := GetMapFromProviderParams(input.ProviderParams)
-	client, err := CreateMariadbClient(paramsMap["Region"], paramsMap["SecretID"], paramsMap["SecretKey"])
+	client, err := CreateMariadbClient(ctx, params)
 	if err != nil {
-		logrus.Errorf("CreateMariadbClient meet error(%v)", err)
+		Logger(ctx).Errorf("CreateMariadbClient meet error(%v)", err)
 		return output, err
 	}
 
 	exit, err := isMariadbExist(client, input.Id)
 	if err != nil {
-		logrus.Errorf("isMariadbExist(%s) meet error", input.DbVersion)
+		Logger(ctx).Errorf("isMariadbExist(%s) meet error", input.DbVersion)
 		return output, err
 	}
 	if exit {
-		logrus.Infof("mariadb instance(%s) is already exist", input.Id)
+		Logger(ctx).Infof("mariadb instance(%s) is already exist", input.Id)
 		err = fmt.Errorf("mariadb instance(%s) is already exist", input.Id)
 		return output, err
 	}
 
-	requestId, instanceId, err := createMariadbInstance(client, input)
+	requestId, instanceId, err := createMariadbInstance(ctx, client, input)
 	if err != nil {
-		logrus.Errorf("createMariadbInstance meet error(%v)", err)
+		Logger(ctx).Errorf("createMariadbInstance meet error(%v)", err)
 		return output, err
 	}
 
-	_, _, err = waitMariadbToDesireStatus(client, instanceId, MARIADB_WAIT_INIT_STATUS)
+	_, _, err = waitMariadbToDesireStatus(ctx, client, instanceId, MARIADB_WAIT_INIT_STATUS, input.WaitTimeout)
 	if err != nil {
-		logrus.Errorf("waitMariadbToDesireState meet error(%v)", err)
+		Logger(ctx).Errorf("waitMariadbToDesireState meet error(%v)", err)
 		return output, err
 	}
 
-	if err = initMariadb(client, instanceId, input.CharacterSet, input.LowerCaseTableNames); err != nil {
-		logrus.Errorf("initMariadb meet error(%v)", err)
+	if err = initMariadb(ctx, client, instanceId, input.CharacterSet, input.LowerCaseTableNames, input.WaitTimeout); err != nil {
+		Logger(ctx).Errorf("initMariadb meet error(%v)", err)
 		return output, err
 	}
 
-	vip, vport, err := waitMariadbToDesireStatus(client, instanceId, MARIADB_RUNNING_STATUS)
+	vip, vport, err := waitMariadbToDesireStatus(ctx, client, instanceId, MARIADB_RUNNING_STATUS, input.WaitTimeout)
 	if err != nil {
-		logrus.Errorf("waitMariadbToDesireState meet error(%v)", err)
+		Logger(ctx).Errorf("waitMariadbToDesireState meet error(%v)", err)
 		return output, err
 	}
 
 	if err = createMariadbAccount(client, instanceId, input.UserName, input.Password); err != nil {
-		logrus.Errorf("createMariadbAccount meet error(%v),password=%v", err, input.Password)
+		Logger(ctx).Errorf("createMariadbAccount meet error(%v)", err)
 		return output, err
 	}
 
 	if err = grantAccountPrivileges(client, input.UserName, instanceId); err != nil {
-		logrus.Errorf("grantAccountPrivileges meet error(%v)", err)
+		Logger(ctx).Errorf("grantAccountPrivileges meet error(%v)", err)
 		return output, err
 	}
 
 	output.Password, err = utils.AesEnPassword(input.Guid, input.Seed, input.Password, utils.DEFALT_CIPHER)
 	if err != nil {
-		logrus.Errorf("AesEnPassword meet error(%v)", err)
+		Logger(ctx).Errorf("AesEnPassword meet error(%v)", err)
 		return output, err
 	}
 
@@ -525,16 +522,16 @@ func (action *MariadbCreateAction) createAndInitMariadb(input *MariadbInput) (ou
 	return output, err
 }
 
-func QueryMariadbInstance(providerParams string, filter Filter) ([]*mariadb.DBInstance, error) {
+func QueryMariadbInstance(ctx context.Context, providerParams string, filter Filter) ([]*mariadb.DBInstance, error) {
 	validFilterNames := []string{"instanceId", "vip"}
 	filterValues := common.StringPtrs(filter.Values)
 	var offset, limit int64 = 0, int64(len(filterValues))
-	logrus.Infof("QueryMariadbInstance providerParams:%v, filter:%++v", providerParams, filter)
-	paramsMap, err := GetMapFromProviderParams(providerParams)
+	Logger(ctx).Infof("QueryMariadbInstance providerParams:%v, filter:%++v", providerParams, filter)
+	params, err := ParseProviderParams(providerParams)
 	if err != nil {
 		return nil, err
 	}
-	client, err := CreateMariadbClient(paramsMap["Region"], paramsMap["SecretID"], paramsMap["SecretKey"])
+	client, err := CreateMariadbClient(ctx, params)
 	if err != nil {
 		return nil, err
 	}
@@ -557,7 +554,7 @@ func QueryMariadbInstance(providerParams string, filter Filter) ([]*mariadb.DBIn
 
 	response, err := client.DescribeDBInstances(request)
 	if err != nil {
-		logrus.Errorf("mariadb DescribeDBInstances meet err=%v", err)
+		Logger(ctx).Errorf("mariadb DescribeDBInstances meet err=%v", err)
 		return nil, err
 	}
 
@@ -0,0 +1,368 @@
+package plugins
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// METRICS_CONTENT_TYPE is the content type of the text format of prometheus written by WriteMetrics.
+const METRICS_CONTENT_TYPE = "text/plain; version=0.0.4; charset=utf-8"
+
+// the buckets of the histograms in seconds.
+var (
+	requestDurationBuckets = []float64{0.1, 0.5, 1, 2.5, 5, 10, 30, 60, 120, 300, 600, 1800, 3600}
+	apiDurationBuckets     = []float64{0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30}
+	waitDurationBuckets    = []float64{1, 5, 10, 30, 60, 120, 300, 600, 1800, 3600}
+)
+
+var (
+	pluginRequestsTotal = newCounterVec("qcloud_plugin_requests_total",
+		"Plugin requests by plugin, action and result code.", "plugin", "action", "result_code")
+	pluginRequestDuration = newHistogramVec("qcloud_plugin_request_duration_seconds",
+		"Duration of plugin requests, async requests end once their task is submitted.", requestDurationBuckets, "plugin", "action", "result_code")
+	pluginInputsTotal = newCounterVec("qcloud_plugin_inputs_total",
+		"Inputs run by actions by result, the error category tells why inputs failed.", "plugin", "action", "result", "error_category")
+	cloudApiRequestsTotal = newCounterVec("qcloud_cloud_api_requests_total",
+		"Cloud API requests sent by service, action, region and error code, every retry is counted.", "service", "action", "region", "error_code")
+	cloudApiRequestDuration = newHistogramVec("qcloud_cloud_api_request_duration_seconds",
+		"Duration of cloud API requests, the delay of the rate limiter is not included.", apiDurationBuckets, "service", "action", "region", "error_code")
+	waitDuration = newHistogramVec("qcloud_wait_duration_seconds",
+		"Duration of waiting for resources by resource and result.", waitDurationBuckets, "resource", "result")
+	inFlightActionsGauge = &gaugeFunc{
+		name:       "qcloud_plugin_inflight_actions",
+		help:       "Actions being run, both of requests and of async tasks.",
+		labelNames: []string{"plugin", "action"},
+		collect:    countInFlightActions,
+	}
+	rateLimitTokensGauge = newRateLimitGauge("qcloud_rate_limit_tokens",
+		"Tokens left in the buckets of the rate limiter, negative when requests are waiting.", func(state RateLimiterState) float64 { return state.Tokens })
+	rateLimitRequestsGauge = newRateLimitGauge("qcloud_rate_limit_requests",
+		"Requests which took a token of the buckets of the rate limiter.", func(state RateLimiterState) float64 { return float64(state.Requests) })
+	rateLimitWaitsGauge = newRateLimitGauge("qcloud_rate_limit_waits",
+		"Requests which waited for a token of the buckets of the rate limiter.", func(state RateLimiterState) float64 { return float64(state.Waits) })
+	rateLimitWaitSecondsGauge = newRateLimitGauge("qcloud_rate_limit_wait_seconds",
+		"Time requests waited for a token of the buckets of the rate limiter.", func(state RateLimiterState) float64 { return state.WaitTime.Seconds() })
+
+	metricCollectors = []metricCollector{pluginRequestsTotal, pluginRequestDuration, pluginInputsTotal, inFlightActionsGauge,
+		cloudApiRequestsTotal, cloudApiRequestDuration, waitDuration,
+		rateLimitTokensGauge, rateLimitRequestsGauge, rateLimitWaitsGauge, rateLimitWaitSecondsGauge}
+)
+
+// the results of waits in qcloud_wait_duration_seconds.
+const (
+	WAIT_RESULT_DONE     = "done"
+	WAIT_RESULT_FAILED   = "failed"
+	WAIT_RESULT_TIMEOUT  = "timeout"
+	WAIT_RESULT_CANCELED = "canceled"
+	WAIT_RESULT_ERROR    = "error"
+)
+
+type metricCollector interface {
+	write(w io.Writer)
+}
+
+// WriteMetrics writes all the metrics in the text format of prometheus.
+func WriteMetrics(w io.Writer) {
+	for _, collector := range metricCollectors {
+		collector.write(w)
+	}
+}
+
+type counterVec struct {
+	name       string
+	help       string
+	labelNames []string
+
+	mutex  sync.Mutex
+	values map[string]*counterValue
+}
+
+type counterValue struct {
+	labels []string
+	value  float64
+}
+
+func newCounterVec(name, help string, labelNames ...string) *counterVec {
+	return &counterVec{name: name, help: help, labelNames: labelNames, values: make(map[string]*counterValue)}
+}
+
+func (counter *counterVec) add(value float64, labels ...string) {
+	key := strings.Join(labels, "\xff")
+	counter.mutex.Lock()
+	defer counter.mutex.Unlock()
+
+	v, found := counter.values[key]
+	if !found {
+		v = &counterValue{labels: labels}
+		counter.values[key] = v
+	}
+	v.value += value
+}
+
+func (counter *counterVec) get(labels ...string) float64 {
+	counter.mutex.Lock()
+	defer counter.mutex.Unlock()
+
+	if v, found := counter.values[strings.Join(labels, "\xff")]; found {
+		return v.value
+	}
+	return 0
+}
+
+func (counter *counterVec) write(w io.Writer) {
+	counter.mutex.Lock()
+	defer counter.mutex.Unlock()
+
+	writeMetricHeader(w, counter.name, counter.help, "counter")
+	for _, key := range sortedKeys(counter.values) {
+		v := counter.values[key]
+		fmt.Fprintf(w, "%s%s %s\n", counter.name, formatLabels(counter.labelNames, v.labels), formatValue(v.value))
+	}
+}
+
+type histogramVec struct {
+	name       string
+	help       string
+	labelNames []string
+	buckets    []float64
+
+	mutex  sync.Mutex
+	values map[string]*histogramValue
+}
+
+type histogramValue struct {
+	labels []string
+	// counts are the observations of each bucket, not cumulative.
+	counts []uint64
+	count  uint64
+	sum    float64
+}
+
+func newHistogramVec(name, help string, buckets []float64, labelNames ...string) *histogramVec {
+	return &histogramVec{name: name, help: help, labelNames: labelNames, buckets: buckets, values: make(map[string]*histogramValue)}
+}
+
+func (histogram *histogramVec) observe(value float64, labels ...string) {
+	key := strings.Join(labels, "\xff")
+	histogram.mutex.Lock()
+	defer histogram.mutex.Unlock()
+
+	v, found := histogram.values[key]
+	if !found {
+		v = &histogramValue{labels: labels, counts: make([]uint64, len(histogram.buckets))}
+		histogram.values[key] = v
+	}
+	if i := sort.SearchFloat64s(histogram.buckets, value); i < len(histogram.buckets) {
+		v.counts[i]++
+	}
+	v.count++
+	v.sum += value
+}
+
+func (histogram *histogramVec) getCount(labels ...string) uint64 {
+	histogram.mutex.Lock()
+	defer histogram.mutex.Unlock()
+
+	if v, found := histogram.values[strings.Join(labels, "\xff")]; found {
+		return v.count
+	}
+	return 0
+}
+
+func (histogram *histogramVec) write(w io.Writer) {
+	histogram.mutex.Lock()
+	defer histogram.mutex.Unlock()
+
+	writeMetricHeader(w, histogram.name, histogram.help, "histogram")
+	labelNames := append(append([]string{}, histogram.labelNames...), "le")
+	for _, key := range sortedKeys(histogram.values) {
+		v := histogram.values[key]
+		cumulative := uint64(0)
+		for i, bucket := range histogram.buckets {
+			cumulative += v.counts[i]
+			fmt.Fprintf(w, "%s_bucket%s %d\n", histogram.name, formatLabels(labelNames, append(append([]string{}, v.labels...), formatValue(bucket))), cumulative)
+		}
+		fmt.Fprintf(w, "%s_bucket%s %d\n", histogram.name, formatLabels(labelNames, append(append([]string{}, v.labels...), "+Inf")), v.count)
+		fmt.Fprintf(w, "%s_sum%s %s\n", histogram.name, formatLabels(histogram.labelNames, v.labels), formatValue(v.sum))
+		fmt.Fprintf(w, "%s_count%s %d\n", histogram.name, formatLabels(histogram.labelNames, v.labels), v.count)
+	}
+}
+
+// gaugeFunc is a gauge whose values are collected when the metrics are written, keyed by the joined labels.
+type gaugeFunc struct {
+	name       string
+	help       string
+	labelNames []string
+	collect    func() map[string]float64
+}
+
+func (gauge *gaugeFunc) write(w io.Writer) {
+	writeMetricHeader(w, gauge.name, gauge.help, "gauge")
+	values := gauge.collect()
+	keys := []string{}
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		fmt.Fprintf(w, "%s%s %s\n", gauge.name, formatLabels(gauge.labelNames, strings.Split(key, "\xff")), formatValue(values[key]))
+	}
+}
+
+func countInFlightActions() map[string]float64 {
+	inFlightMutex.Lock()
+	defer inFlightMutex.Unlock()
+
+	counts := make(map[string]float64)
+	for tracked := range inFlightActions {
+		counts[tracked.plugin+"\xff"+tracked.action]++
+	}
+	return counts
+}
+
+// newRateLimitGauge returns a gauge of the value of each bucket of the rate limiter of the client factory.
+func newRateLimitGauge(name, help string, value func(state RateLimiterState) float64) *gaugeFunc {
+	return &gaugeFunc{
+		name:       name,
+		help:       help,
+		labelNames: []string{"service", "action", "region", "secret_id"},
+		collect: func() map[string]float64 {
+			values := make(map[string]float64)
+			for _, state := range GetClientFactory().GetRateLimiter().States() {
+				values[strings.Join([]string{state.Service, state.Action, state.Region, state.SecretId}, "\xff")] = value(state)
+			}
+			return values
+		},
+	}
+}
+
+func writeMetricHeader(w io.Writer, name, help, metricType string) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, metricType)
+}
+
+func sortedKeys(values interface{}) []string {
+	keys := []string{}
+	switch m := values.(type) {
+	case map[string]*counterValue:
+		for key := range m {
+			keys = append(keys, key)
+		}
+	case map[string]*histogramValue:
+		for key := range m {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+var labelValueReplacer = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func formatLabels(names, values []string) string {
+	if len(names) == 0 {
+		return ""
+	}
+	pairs := make([]string, len(names))
+	for i, name := range names {
+		value := ""
+		if i < len(values) {
+			value = values[i]
+		}
+		pairs[i] = name + `="` + labelValueReplacer.Replace(value) + `"`
+	}
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+func formatValue(value float64) string {
+	if math.IsInf(value, 1) {
+		return "+Inf"
+	}
+	return strconv.FormatFloat(value, 'g', -1, 64)
+}
+
+// observePluginRequest records a request handled by Process.
+func observePluginRequest(pluginName, actionName, resultCode string, duration time.Duration) {
+	pluginRequestsTotal.add(1, pluginName, actionName, resultCode)
+	pluginRequestDuration.observe(duration.Seconds(), pluginName, actionName, resultCode)
+}
+
+// observeInputResults records the result of each output of an action.
+func observeInputResults(pluginName, actionName string, results interface{}) {
+	for _, result := range getResultsFromOutputs(results) {
+		if result.Code == RESULT_CODE_SUCCESS {
+			pluginInputsTotal.add(1, pluginName, actionName, "success", "")
+		} else {
+			pluginInputsTotal.add(1, pluginName, actionName, "failure", result.ErrorCategory)
+		}
+	}
+}
+
+// observeWait records a wait of the resource, the result is told by the error which the wait returns.
+func observeWait(resource string, duration time.Duration, result string) {
+	waitDuration.observe(duration.Seconds(), resource, result)
+}
+
+// metricsTransport records every cloud API request which is sent, with the code of its error.
+type metricsTransport struct {
+	next http.RoundTripper
+}
+
+func (transport *metricsTransport) RoundTrip(request *http.Request) (*http.Response, error) {
+	action := getApiAction(request)
+	if action == "" {
+		return transport.next.RoundTrip(request)
+	}
+
+	service, region, _ := getRateLimitKey(request)
+	start := time.Now()
+	response, err := transport.next.RoundTrip(request)
+	duration := time.Since(start)
+
+	errorCode := ""
+	if err != nil {
+		errorCode = ERROR_CODE_NETWORK
+	} else {
+		errorCode = getApiErrorCode(response)
+	}
+	cloudApiRequestsTotal.add(1, service, action, region, errorCode)
+	cloudApiRequestDuration.observe(duration.Seconds(), service, action, region, errorCode)
+	return response, err
+}
+
+// getApiErrorCode returns the code of the error in the body of the cloud API response, or of the legacy API
+// whose error code is not zero. The body is kept for the caller.
+func getApiErrorCode(response *http.Response) string {
+	if response.StatusCode >= http.StatusBadRequest {
+		return "HTTP" + strconv.Itoa(response.StatusCode)
+	}
+	if apiErr, err := readApiError(response); err != nil || apiErr != nil {
+		if err != nil {
+			return ERROR_CODE_NETWORK
+		}
+		return apiErr.Code
+	}
+
+	body, _ := ioutil.ReadAll(response.Body)
+	response.Body = ioutil.NopCloser(bytes.NewReader(body))
+	legacyResponse := struct {
+		Code     *int   `json:"code"`
+		CodeDesc string `json:"codeDesc"`
+	}{}
+	if json.Unmarshal(body, &legacyResponse) == nil && legacyResponse.Code != nil && *legacyResponse.Code != 0 {
+		if legacyResponse.CodeDesc != "" {
+			return legacyResponse.CodeDesc
+		}
+		return strconv.Itoa(*legacyResponse.Code)
+	}
+	return ""
+}
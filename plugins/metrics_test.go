@@ -0,0 +1,108 @@
+package plugins
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/common"
+	cvm "github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/cvm/v20170312"
+)
+
+func TestWriteMetrics(t *testing.T) {
+	counter := newCounterVec("test_total", "Test counter.", "name")
+	counter.add(1, `a"b`)
+	counter.add(2, `a"b`)
+	histogram := newHistogramVec("test_seconds", "Test histogram.", []float64{1, 5}, "name")
+	histogram.observe(0.5, "a")
+	histogram.observe(3, "a")
+	histogram.observe(10, "a")
+
+	buffer := &bytes.Buffer{}
+	counter.write(buffer)
+	histogram.write(buffer)
+	expected := `# HELP test_total Test counter.
+# TYPE test_total counter
+test_total{name="a\"b"} 3
+# HELP test_seconds Test histogram.
+# TYPE test_seconds histogram
+test_seconds_bucket{name="a",le="1"} 1
+test_seconds_bucket{name="a",le="5"} 2
+test_seconds_bucket{name="a",le="+Inf"} 3
+test_seconds_sum{name="a"} 13.5
+test_seconds_count{name="a"} 3
+`
+	if buffer.String() != expected {
+		t.Errorf("metrics=\n%s", buffer.String())
+	}
+}
+
+func TestMetricsTransport(t *testing.T) {
+	server, _ := newScriptedServer(func(action, body string, times int) string {
+		if action == "StartInstances" {
+			return errorResponse("InvalidInstanceId.NotFound")
+		}
+		return okResponse
+	})
+	defer server.Close()
+
+	okLabels := []string{QCLOUD_SERVICE_CVM, "DescribeInstances", "ap-metrics", ""}
+	errorLabels := []string{QCLOUD_SERVICE_CVM, "StartInstances", "ap-metrics", "InvalidInstanceId.NotFound"}
+	okCount, errorCount := cloudApiRequestsTotal.get(okLabels...), cloudApiRequestsTotal.get(errorLabels...)
+
+	client, _ := newRetryTestFactory(server, &RetryPolicy{}).NewCvmClient("ap-metrics", "fake-secret-id", "fake-secret-key")
+	if _, err := client.DescribeInstances(cvm.NewDescribeInstancesRequest()); err != nil {
+		t.Fatalf("DescribeInstances meet error=%v", err)
+	}
+	request := cvm.NewStartInstancesRequest()
+	request.InstanceIds = []*string{common.StringPtr("ins-00000001")}
+	if _, err := client.StartInstances(request); getErrorCode(err) != "InvalidInstanceId.NotFound" {
+		t.Errorf("StartInstances, error=%v", err)
+	}
+
+	if count := cloudApiRequestsTotal.get(okLabels...); count != okCount+1 {
+		t.Errorf("count of DescribeInstances=%v", count)
+	}
+	if count := cloudApiRequestsTotal.get(errorLabels...); count != errorCount+1 {
+		t.Errorf("count of StartInstances=%v", count)
+	}
+	if count := cloudApiRequestDuration.getCount(okLabels...); count == 0 {
+		t.Errorf("duration of DescribeInstances is not observed")
+	}
+}
+
+func TestWaitMetrics(t *testing.T) {
+	count := waitDuration.getCount("metrics", WAIT_RESULT_TIMEOUT)
+	waiter := &Waiter{Resource: "metrics", Id: "res-1", Timeout: time.Millisecond, Interval: time.Millisecond, MaxInterval: time.Millisecond, Backoff: 1}
+	if err := waiter.Wait(context.Background(), func() (string, bool, error) { return "PENDING", false, nil }); err == nil {
+		t.Fatalf("wait does not time out")
+	}
+	if newCount := waitDuration.getCount("metrics", WAIT_RESULT_TIMEOUT); newCount != count+1 {
+		t.Errorf("count of wait timeout=%v", newCount)
+	}
+}
+
+func TestRateLimitMetrics(t *testing.T) {
+	defer SetClientFactory(GetClientFactory())
+	limiter := &RateLimiter{Rate: 1, ActionRates: map[string]float64{}}
+	SetClientFactory(&ClientFactory{RateLimit: limiter})
+
+	limiter.Reserve(QCLOUD_SERVICE_CVM, "ap-metrics", "AKID00000001", "DescribeInstances")
+	limiter.Reserve(QCLOUD_SERVICE_CVM, "ap-metrics", "AKID00000001", "DescribeInstances")
+
+	buffer := &bytes.Buffer{}
+	WriteMetrics(buffer)
+	labels := `{service="cvm",action="DescribeInstances",region="ap-metrics",secret_id="AKID****0001"}`
+	for _, metric := range []string{
+		"qcloud_rate_limit_requests" + labels + " 2\n",
+		"qcloud_rate_limit_waits" + labels + " 1\n",
+		"# TYPE qcloud_rate_limit_tokens gauge\nqcloud_rate_limit_tokens" + labels + " ",
+		"qcloud_rate_limit_wait_seconds" + labels + " ",
+	} {
+		if !strings.Contains(buffer.String(), metric) {
+			t.Errorf("metric %q is not found in\n%s", metric, buffer.String())
+		}
+	}
+}
@@ -1,23 +1,25 @@
 package plugins
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"strconv"
 	"strings"
-	"time"
 
 	"github.com/WeBankPartners/wecube-plugins-qcloud/plugins/utils"
 	"github.com/sirupsen/logrus"
 	cdb "github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/cdb/v20170320"
 	"github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/common"
-	"github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/common/profile"
 )
 
 const (
 	MYSQL_VM_STATUS_RUNNING  = 1
 	MYSQL_VM_STATUS_ISOLATED = 5
 
+	MYSQL_TASK_STATUS_SUCCESS = "SUCCESS"
+	MYSQL_TASK_STATUS_FAILED  = "FAILED"
+
 	MYSQL_INSTANCE_ROLE_MASTER            = "master"
 	MYSQL_INSTANCE_ROLE_READONLY          = "ro"
 	MYSQL_INSTANCE_ROLE_DISASTER_RECOVERY = "dr"
@@ -34,17 +36,12 @@ func init() {
 	MysqlVmActions["bind-security-group"] = new(MysqlBindSecurityGroupAction)
 }
 
-func CreateMysqlVmClient(region, secretId, secretKey string) (client *cdb.Client, err error) {
-	credential := common.NewCredential(secretId, secretKey)
-
-	clientProfile := profile.NewClientProfile()
-	clientProfile.HttpProfile.Endpoint = "cdb.tencentcloudapi.com"
-	client, err = cdb.NewClient(credential, region, clientProfile)
+func CreateMysqlVmClient(ctx context.Context, params *ProviderParams) (client *cdb.Client, err error) {
+	client, err = GetClientFactory().WithContext(ctx).WithProviderParams(params).NewCdbClient(params.Region, params.SecretID, params.SecretKey)
 	if err != nil {
-		logrus.Errorf("CreateMysqlVmClient meet error=%v", err)
+		Logger(ctx).Errorf("CreateMysqlVmClient meet error=%v", err)
 	}
-
-	return client, err
+	return
 }
 
 type MysqlVmInputs struct {
@@ -53,30 +50,31 @@ type MysqlVmInputs struct {
 
 type MysqlVmInput struct {
 	CallBackParameter
-	Guid             string `json:"guid,omitempty"`
-	Seed             string `json:"seed,omitempty"`
-	ProviderParams   string `json:"provider_params,omitempty"`
-	InstanceRole     string `json:"instance_role,omitempty"`
-	MasterInstanceId string `json:"master_instance_id,omitempty"`
-	MasterRegion     string `json:"master_region,omitempty"`
-	EngineVersion    string `json:"engine_version,omitempty"`
-	MemorySize       string `json:"memory_size,omitempty"`
-	VolumeSize       string `json:"volume_size,omitempty"`
-	VpcId            string `json:"vpc_id,omitempty"`
-	SubnetId         string `json:"subnet_id,omitempty"`
-	Name             string `json:"name,omitempty"`
-	Id               string `json:"id,omitempty"`
+	WaitParameter
+	Guid             string `json:"guid,omitempty" register:"required"`
+	Seed             string `json:"seed,omitempty" register:"required,system_variable=ENCRYPT_SEED,actions=create"`
+	ProviderParams   string `json:"provider_params,omitempty" register:"optional"`
+	InstanceRole     string `json:"instance_role,omitempty" register:"required,actions=create" enum:"mysql_instance_role"`
+	MasterInstanceId string `json:"master_instance_id,omitempty" register:"optional,actions=create"`
+	MasterRegion     string `json:"master_region,omitempty" register:"optional,actions=create"`
+	EngineVersion    string `json:"engine_version,omitempty" register:"required,actions=create"`
+	MemorySize       string `json:"memory_size,omitempty" register:"required,actions=create"`
+	VolumeSize       string `json:"volume_size,omitempty" register:"required,actions=create"`
+	VpcId            string `json:"vpc_id,omitempty" register:"required,actions=create"`
+	SubnetId         string `json:"subnet_id,omitempty" register:"required,actions=create"`
+	Name             string `json:"name,omitempty" register:"optional,actions=create"`
+	Id               string `json:"id,omitempty" register:"required=terminate|restart"`
 	Count            int64  `json:"count,omitempty"`
-	ChargeType       string `json:"charge_type,omitempty"`
-	ChargePeriod     string `json:"charge_period,omitempty"`
-	Password         string `json:"password,omitempty"`
-	UserName         string `json:"user_name,omitempty"`
-	Location         string `json:"location"`
-	APISecret        string `json:"api_secret"`
+	ChargeType       string `json:"charge_type,omitempty" register:"required,actions=create" enum:"charge_type"`
+	ChargePeriod     string `json:"charge_period,omitempty" register:"optional,actions=create"`
+	Password         string `json:"password,omitempty" register:"optional,actions=create"`
+	UserName         string `json:"user_name,omitempty" register:"required,actions=create"`
+	Location         string `json:"location" register:"required"`
+	APISecret        string `json:"api_secret" register:"required,system_variable=QCLOUD_API_SECRET"`
 
 	//初始化时使用
-	CharacterSet        string `json:"character_set,omitempty"`
-	LowerCaseTableNames string `json:"lower_case_table_names,omitempty"`
+	CharacterSet        string `json:"character_set,omitempty" register:"required,system_variable=QCLOUD_MYSQL_CHARACTER_SET,actions=create" enum:"mysql_charset"`
+	LowerCaseTableNames string `json:"lower_case_table_names,omitempty" register:"required,system_variable=QCLOUD_MYSQL_LOWER_CASE_TABLE_NAMES,actions=create" enum:"lower_case_table_names"`
 }
 
 type MysqlVmOutputs struct {
@@ -87,14 +85,14 @@ type MysqlVmOutput struct {
 	CallBackParameter
 	Result
 	RequestId string `json:"request_id,omitempty"`
-	Guid      string `json:"guid,omitempty"`
-	Id        string `json:"id,omitempty"`
-	PrivateIp string `json:"private_ip,omitempty"`
+	Guid      string `json:"guid,omitempty" register:"optional"`
+	Id        string `json:"id,omitempty" register:"optional,actions=create"`
+	PrivateIp string `json:"private_ip,omitempty" register:"optional,actions=create"`
 
 	//用户名和密码
-	Port     string `json:"private_port,omitempty"`
-	UserName string `json:"user_name,omitempty"`
-	Password string `json:"password,omitempty"`
+	Port     string `json:"private_port,omitempty" register:"optional,actions=create"`
+	UserName string `json:"user_name,omitempty" register:"optional,actions=create"`
+	Password string `json:"password,omitempty" register:"optional,actions=create"`
 }
 
 type MysqlVmPlugin struct {
@@ -109,10 +107,14 @@ func (plugin *MysqlVmPlugin) GetActionByName(actionName string) (Action, error)
 	return action, nil
 }
 
+func (plugin *MysqlVmPlugin) GetActions() map[string]Action {
+	return MysqlVmActions
+}
+
 type MysqlVmCreateAction struct {
 }
 
-func (action *MysqlVmCreateAction) ReadParam(param interface{}) (interface{}, error) {
+func (action *MysqlVmCreateAction) ReadParam(ctx context.Context, param interface{}) (interface{}, error) {
 	var inputs MysqlVmInputs
 	err := UnmarshalJson(param, &inputs)
 	if err != nil {
@@ -121,11 +123,19 @@ func (action *MysqlVmCreateAction) ReadParam(param interface{}) (interface{}, er
 	return inputs, nil
 }
 
+// mysqlCharsets are the valid character sets of mysql, they are case insensitive.
+var mysqlCharsets = []string{"utf8", "latin1", "gbk", "utf8mb4"}
+
+var lowerCaseTableNames = []string{"0", "1"}
+
+var mysqlInstanceRoles = []string{
+	MYSQL_INSTANCE_ROLE_MASTER,
+	MYSQL_INSTANCE_ROLE_READONLY,
+	MYSQL_INSTANCE_ROLE_DISASTER_RECOVERY,
+}
+
 func isVaildCharset(charset string) error {
-	validCharsets := []string{
-		"utf8", "latin1", "gbk", "utf8mb4",
-	}
-	for _, valid := range validCharsets {
+	for _, valid := range mysqlCharsets {
 		lowerCharset := strings.ToLower(charset)
 		if lowerCharset == valid {
 			return nil
@@ -135,20 +145,14 @@ func isVaildCharset(charset string) error {
 }
 
 func isValidLowerCaseTableNames(value string) error {
-	if value != "1" && value != "0" {
+	if err := IsValidValue(value, lowerCaseTableNames); err != nil {
 		return fmt.Errorf("lowerCaseTableNames(%v) is invalid", value)
 	}
 	return nil
 }
 
 func isValidMysqlMasterRole(r string) error {
-	validRoles := []string{
-		MYSQL_INSTANCE_ROLE_MASTER,
-		MYSQL_INSTANCE_ROLE_READONLY,
-		MYSQL_INSTANCE_ROLE_DISASTER_RECOVERY,
-	}
-
-	for _, role := range validRoles {
+	for _, role := range mysqlInstanceRoles {
 		if role == r {
 			return nil
 		}
@@ -188,7 +192,7 @@ func (action *MysqlVmCreateAction) MysqlVmCreateCheckParam(input MysqlVmInput) e
 		return fmt.Errorf("subnet_id is empty")
 	}
 
-	if input.ChargeType != CHARGE_TYPE_PREPAID && input.ChargeType != CHARGE_TYPE_BY_HOUR {
+	if err := IsValidValue(input.ChargeType, chargeTypes); err != nil {
 		return fmt.Errorf("charge_type is wrong")
 	}
 
@@ -225,8 +229,9 @@ func (action *MysqlVmCreateAction) MysqlVmCreateCheckParam(input MysqlVmInput) e
 	return nil
 }
 
-func (action *MysqlVmCreateAction) createMysqlVmWithPrepaid(client *cdb.Client, mysqlVmInput *MysqlVmInput) (string, string, error) {
+func (action *MysqlVmCreateAction) createMysqlVmWithPrepaid(ctx context.Context, client *cdb.Client, mysqlVmInput *MysqlVmInput) (string, string, error) {
 	request := cdb.NewCreateDBInstanceRequest()
+	request.ClientToken = GetClientToken(ctx)
 	memory, err := strconv.ParseInt(mysqlVmInput.MemorySize, 10, 64)
 	if err != nil && memory <= 0 {
 		return "", "", fmt.Errorf("wrong MemrorySize string. %v", err)
@@ -265,14 +270,14 @@ func (action *MysqlVmCreateAction) createMysqlVmWithPrepaid(client *cdb.Client,
 	mysqlVmInput.Count = 1
 	request.GoodsNum = &mysqlVmInput.Count
 
-	if mysqlVmInput.Location != "" && mysqlVmInput.APISecret != "" {
-		mysqlVmInput.ProviderParams = fmt.Sprintf("%s;%s", mysqlVmInput.Location, mysqlVmInput.APISecret)
-	}
-	zone, err := getZoneFromProviderParams(mysqlVmInput.ProviderParams)
+	params, err := NewProviderParams(mysqlVmInput.ProviderParams, mysqlVmInput.Location, mysqlVmInput.APISecret)
 	if err != nil {
 		return "", "", err
 	}
-	request.Zone = common.StringPtr(zone)
+	if params.Zone == "" {
+		return "", "", fmt.Errorf("AvailableZone of provider_params is empty")
+	}
+	request.Zone = common.StringPtr(params.Zone)
 
 	response, err := client.CreateDBInstance(request)
 	if err != nil {
@@ -286,25 +291,9 @@ func (action *MysqlVmCreateAction) createMysqlVmWithPrepaid(client *cdb.Client,
 	return *response.Response.InstanceIds[0], *response.Response.RequestId, nil
 }
 
-func getZoneFromProviderParams(ProviderParams string) (string, error) {
-	var err error
-	var zone string
-	var ok bool
-	if ProviderParams == "" {
-		err = fmt.Errorf("mysqlVmCreateAtion:input ProviderParams is empty")
-		return fmt.Sprintf("getZoneFromProviderParams meet err=%v", err), err
-	}
-	paramsMap, _ := GetMapFromProviderParams(ProviderParams)
-	if zone, ok = paramsMap["AvailableZone"]; !ok {
-		err = fmt.Errorf("mysqlVmCreateAtion: failed to get AvailableZone from input ProviderParams")
-		return fmt.Sprintf("getZoneFromProviderParams meet err=%v", err), err
-	}
-
-	return zone, nil
-}
-
-func (action *MysqlVmCreateAction) createMysqlVmWithPostByHour(client *cdb.Client, mysqlVmInput *MysqlVmInput) (string, string, error) {
+func (action *MysqlVmCreateAction) createMysqlVmWithPostByHour(ctx context.Context, client *cdb.Client, mysqlVmInput *MysqlVmInput) (string, string, error) {
 	request := cdb.NewCreateDBInstanceHourRequest()
+	request.ClientToken = GetClientToken(ctx)
 	memory, err := strconv.ParseInt(mysqlVmInput.MemorySize, 10, 64)
 	if err != nil && memory <= 0 {
 		return "", "", fmt.Errorf("wrong MemrorySize string. %v", err)
@@ -337,14 +326,14 @@ func (action *MysqlVmCreateAction) createMysqlVmWithPostByHour(client *cdb.Clien
 		request.MasterInstanceId = &mysqlVmInput.MasterInstanceId
 	}
 
-	if mysqlVmInput.Location != "" && mysqlVmInput.APISecret != "" {
-		mysqlVmInput.ProviderParams = fmt.Sprintf("%s;%s", mysqlVmInput.Location, mysqlVmInput.APISecret)
-	}
-	zone, err := getZoneFromProviderParams(mysqlVmInput.ProviderParams)
+	params, err := NewProviderParams(mysqlVmInput.ProviderParams, mysqlVmInput.Location, mysqlVmInput.APISecret)
 	if err != nil {
 		return "", "", err
 	}
-	request.Zone = common.StringPtr(zone)
+	if params.Zone == "" {
+		return "", "", fmt.Errorf("AvailableZone of provider_params is empty")
+	}
+	request.Zone = common.StringPtr(params.Zone)
 
 	response, err := client.CreateDBInstanceHour(request)
 	if err != nil {
@@ -385,50 +374,52 @@ func initMysqlInstance(client *cdb.Client, instanceId string, charset string, lo
 	return password, fmt.Sprintf("%v", defaultPort), nil
 }
 
-func ensureMysqlInit(client *cdb.Client, instanceId string, charset string, lowerCaseTableName string, password string) (string, string, error) {
-	maxTryNum := 20
-
+func ensureMysqlInit(ctx context.Context, client *cdb.Client, instanceId string, charset string, lowerCaseTableName string, password string, timeout string) (string, string, error) {
 	if password == "" {
 		password = utils.CreateRandomPassword()
 	}
 
-	for i := 0; i < maxTryNum; i++ {
-		password, port, _ := initMysqlInstance(client, instanceId, charset, lowerCaseTableName, password)
+	waiter, err := NewWaiter(WAIT_RESOURCE_MYSQL, instanceId, timeout)
+	if err != nil {
+		return "", "", err
+	}
+	port := ""
+	err = waiter.Wait(ctx, func() (string, bool, error) {
+		password, port, _ = initMysqlInstance(client, instanceId, charset, lowerCaseTableName, password)
 		initFlag, err := queryMySqlInstanceInitFlag(client, instanceId)
 		if err != nil {
-			return password, port, err
+			return "", false, err
 		}
-		if initFlag == 1 {
-			return password, port, nil
-		}
-		time.Sleep(10 * time.Second)
+		return fmt.Sprintf("initFlag=%v", initFlag), initFlag == 1, nil
+	})
+	if err != nil {
+		return "", "", err
 	}
-	return "", "", fmt.Errorf("timeout")
+	return password, port, nil
 }
 
-func (action *MysqlVmCreateAction) createMysqlVm(mysqlVmInput *MysqlVmInput) (output MysqlVmOutput, err error) {
+func (action *MysqlVmCreateAction) createMysqlVm(ctx context.Context, mysqlVmInput *MysqlVmInput) (output MysqlVmOutput, err error) {
 	output.Guid = mysqlVmInput.Guid
 	output.Result.Code = RESULT_CODE_SUCCESS
 	output.CallBackParameter.Parameter = mysqlVmInput.CallBackParameter.Parameter
-	err = action.MysqlVmCreateCheckParam(*mysqlVmInput)
+	err = AsValidationError(action.MysqlVmCreateCheckParam(*mysqlVmInput))
 	if err != nil {
-		output.Result.Code = RESULT_CODE_ERROR
-		output.Result.Message = err.Error()
+		output.Result.SetError(err)
 		return output, err
 	}
 
-	if mysqlVmInput.Location != "" && mysqlVmInput.APISecret != "" {
-		mysqlVmInput.ProviderParams = fmt.Sprintf("%s;%s", mysqlVmInput.Location, mysqlVmInput.APISecret)
+	params, err := NewProviderParams(mysqlVmInput.ProviderParams, mysqlVmInput.Location, mysqlVmInput.APISecret)
+	if err != nil {
+		output.Result.SetError(err)
+		return output, err
 	}
-	paramsMap, _ := GetMapFromProviderParams(mysqlVmInput.ProviderParams)
-	client, _ := CreateMysqlVmClient(paramsMap["Region"], paramsMap["SecretID"], paramsMap["SecretKey"])
+	client, _ := CreateMysqlVmClient(ctx, params)
 
 	//check resource exist
 	if mysqlVmInput.Id != "" {
 		queryMysqlVmInstanceInfoResponse, flag, err := queryMysqlVMInstancesInfo(client, mysqlVmInput.Id)
 		if err != nil && flag == false {
-			output.Result.Code = RESULT_CODE_ERROR
-			output.Result.Message = err.Error()
+			output.Result.SetError(err)
 			return output, err
 		}
 
@@ -441,21 +432,20 @@ func (action *MysqlVmCreateAction) createMysqlVm(mysqlVmInput *MysqlVmInput) (ou
 
 	var instanceId, requestId, privateIp string
 	if mysqlVmInput.ChargeType == CHARGE_TYPE_PREPAID {
-		instanceId, requestId, err = action.createMysqlVmWithPrepaid(client, mysqlVmInput)
+		instanceId, requestId, err = action.createMysqlVmWithPrepaid(ctx, client, mysqlVmInput)
 	} else {
-		instanceId, requestId, err = action.createMysqlVmWithPostByHour(client, mysqlVmInput)
+		instanceId, requestId, err = action.createMysqlVmWithPostByHour(ctx, client, mysqlVmInput)
 	}
 	if err != nil {
-		output.Result.Code = RESULT_CODE_ERROR
-		output.Result.Message = err.Error()
+		output.Result.SetError(err)
 		return output, err
 	}
 
 	if instanceId != "" {
-		privateIp, err = action.waitForMysqlVmCreationToFinish(client, instanceId)
+		recordResourceId(ctx, mysqlVmInput.Guid, instanceId)
+		privateIp, err = action.waitForMysqlVmCreationToFinish(ctx, client, instanceId, mysqlVmInput.WaitTimeout)
 		if err != nil {
-			output.Result.Code = RESULT_CODE_ERROR
-			output.Result.Message = err.Error()
+			output.Result.SetError(err)
 			return output, err
 		}
 	}
@@ -467,61 +457,55 @@ func (action *MysqlVmCreateAction) createMysqlVm(mysqlVmInput *MysqlVmInput) (ou
 		return output, nil
 	}
 
-	password, port, err := ensureMysqlInit(client, instanceId, mysqlVmInput.CharacterSet, mysqlVmInput.LowerCaseTableNames, mysqlVmInput.Password)
+	password, port, err := ensureMysqlInit(ctx, client, instanceId, mysqlVmInput.CharacterSet, mysqlVmInput.LowerCaseTableNames, mysqlVmInput.Password, mysqlVmInput.WaitTimeout)
 	if err != nil {
-		output.Result.Code = RESULT_CODE_ERROR
-		output.Result.Message = err.Error()
+		output.Result.SetError(err)
 		return output, err
 	}
 	output.Port = port
 	output.UserName = "root"
 
-	logrus.Infof("mysql[%v] initial done", instanceId)
+	Logger(ctx).Infof("mysql[%v] initial done", instanceId)
 
 	// create user and add user privileges
 	AsyncRequestId := ""
 	if mysqlVmInput.UserName != "root" {
 		// create user
-		logrus.Infof("mysql[%v] create account[%v]", instanceId, mysqlVmInput.UserName)
+		Logger(ctx).Infof("mysql[%v] create account[%v]", instanceId, mysqlVmInput.UserName)
 		AsyncRequestId, password, err = action.createMysqlVmAccount(client, instanceId, mysqlVmInput.UserName, password, "%")
 		if err != nil {
-			output.Result.Code = RESULT_CODE_ERROR
-			output.Result.Message = err.Error()
+			output.Result.SetError(err)
 			return output, err
 		}
 		// if err == nil the task is successd
-		logrus.Infof("waiting mysql[%v] to create account[%v]", instanceId, mysqlVmInput.UserName)
-		err = action.describeMysqlVmAsyncRequestInfo(client, AsyncRequestId)
+		Logger(ctx).Infof("waiting mysql[%v] to create account[%v]", instanceId, mysqlVmInput.UserName)
+		err = waitForAsyncTaskToFinish(ctx, client, AsyncRequestId, mysqlVmInput.WaitTimeout)
 		if err != nil {
-			output.Result.Code = RESULT_CODE_ERROR
-			output.Result.Message = err.Error()
+			output.Result.SetError(err)
 			return output, err
 		}
 
 		// add privileges to user
-		logrus.Infof("mysql[%v] add privileges to account[%v]", instanceId, mysqlVmInput.UserName)
+		Logger(ctx).Infof("mysql[%v] add privileges to account[%v]", instanceId, mysqlVmInput.UserName)
 		AsyncRequestId, err = action.addMysqlVmAccountPrivileges(client, instanceId, mysqlVmInput.UserName, "%")
 		if err != nil {
-			output.Result.Code = RESULT_CODE_ERROR
-			output.Result.Message = err.Error()
+			output.Result.SetError(err)
 			return output, err
 		}
 		// if err == nil the task is successd
-		logrus.Infof("waiting mysql[%v] to add privileges to account[%v]", instanceId, mysqlVmInput.UserName)
-		err = action.describeMysqlVmAsyncRequestInfo(client, AsyncRequestId)
+		Logger(ctx).Infof("waiting mysql[%v] to add privileges to account[%v]", instanceId, mysqlVmInput.UserName)
+		err = waitForAsyncTaskToFinish(ctx, client, AsyncRequestId, mysqlVmInput.WaitTimeout)
 		if err != nil {
-			output.Result.Code = RESULT_CODE_ERROR
-			output.Result.Message = err.Error()
+			output.Result.SetError(err)
 			return output, err
 		}
-		logrus.Infof("mysql[%v] create account[%v] done", instanceId, mysqlVmInput.UserName)
+		Logger(ctx).Infof("mysql[%v] create account[%v] done", instanceId, mysqlVmInput.UserName)
 	}
 
 	output.Password, err = utils.AesEnPassword(mysqlVmInput.Guid, mysqlVmInput.Seed, password, utils.DEFALT_CIPHER)
 	if err != nil {
-		logrus.Errorf("AesEnPassword meet error(%v)", err)
-		output.Result.Code = RESULT_CODE_ERROR
-		output.Result.Message = err.Error()
+		Logger(ctx).Errorf("AesEnPassword meet error(%v)", err)
+		output.Result.SetError(err)
 		return output, err
 	}
 
@@ -543,7 +527,7 @@ func (action *MysqlVmCreateAction) createMysqlVmAccount(client *cdb.Client, inst
 		},
 	}
 	request.Accounts = account
-	logrus.Infof("mysql[%v] create account[%v] request:%v", instanceId, userName, request)
+	logrus.Infof("mysql[%v] create account[%v] request:%s", instanceId, userName, Sanitize(request))
 	response, err := client.CreateAccounts(request)
 	if err != nil {
 		return AsyncRequestId, Password, err
@@ -595,29 +579,6 @@ func (acton *MysqlVmCreateAction) addMysqlVmAccountPrivileges(client *cdb.Client
 	return AsyncRequestId, err
 }
 
-func (action *MysqlVmCreateAction) describeMysqlVmAsyncRequestInfo(client *cdb.Client, AsyncRequestId string) error {
-	request := cdb.NewDescribeAsyncRequestInfoRequest()
-	request.AsyncRequestId = &AsyncRequestId
-	count := 0
-
-	for {
-		response, err := client.DescribeAsyncRequestInfo(request)
-		if err != nil {
-			return err
-		}
-		status := *response.Response.Status
-		if status == "SUCCESS" {
-			return nil
-		}
-		time.Sleep(10 * time.Second)
-		count++
-		if count >= 20 {
-			break
-		}
-	}
-	return errors.New("describeMysqlVmAsyncRequestInfo timeout")
-}
-
 func queryMySqlInstanceInitFlag(client *cdb.Client, instanceId string) (int64, error) {
 	var initFlag int64 = 0
 	request := cdb.NewDescribeDBInstancesRequest()
@@ -634,61 +595,61 @@ func queryMySqlInstanceInitFlag(client *cdb.Client, instanceId string) (int64, e
 	return *response.Response.Items[0].InitFlag, nil
 }
 
-func (action *MysqlVmCreateAction) waitForMysqlVmCreationToFinish(client *cdb.Client, instanceId string) (string, error) {
-	time.Sleep(2 * time.Second)
+func (action *MysqlVmCreateAction) waitForMysqlVmCreationToFinish(ctx context.Context, client *cdb.Client, instanceId string, timeout string) (string, error) {
 	request := cdb.NewDescribeDBInstancesRequest()
 	request.InstanceIds = append(request.InstanceIds, &instanceId)
-	count := 0
 
-	for {
+	waiter, err := NewWaiter(WAIT_RESOURCE_MYSQL, instanceId, timeout)
+	if err != nil {
+		return "", err
+	}
+	privateIp := ""
+	check := 0
+	err = waiter.Wait(ctx, func() (string, bool, error) {
+		// the new instance may not be found at the first check.
+		check++
 		response, err := client.DescribeDBInstances(request)
 		if err != nil {
-			if count > 0 {
-				return "", err
+			if check > 1 {
+				return "", false, err
 			}
-		} else {
-			if len(response.Response.Items) == 0 {
-				if count > 0 {
-					return "", fmt.Errorf("the mysql vm (instanceId = %v) not found", instanceId)
-				}
-			} else {
-				if *response.Response.Items[0].Status == MYSQL_VM_STATUS_RUNNING {
-					return *response.Response.Items[0].Vip, nil
-				}
+			return "", false, nil
+		}
+		if len(response.Response.Items) == 0 {
+			if check > 1 {
+				return "", false, fmt.Errorf("the mysql vm (instanceId = %v) not found", instanceId)
 			}
+			return "", false, nil
 		}
-
-		time.Sleep(10 * time.Second)
-		count++
-		if count >= 90 {
-			return "", errors.New("waitForMysqlVmCreationToFinish timeout")
+		status := *response.Response.Items[0].Status
+		if status == MYSQL_VM_STATUS_RUNNING {
+			privateIp = *response.Response.Items[0].Vip
 		}
-	}
-	return "", fmt.Errorf("timeout")
+		return strconv.Itoa(int(status)), status == MYSQL_VM_STATUS_RUNNING, nil
+	})
+	return privateIp, err
 }
 
-func (action *MysqlVmCreateAction) Do(input interface{}) (interface{}, error) {
+func (action *MysqlVmCreateAction) Do(ctx context.Context, input interface{}) (interface{}, error) {
 	mysqlVms, _ := input.(MysqlVmInputs)
-	outputs := MysqlVmOutputs{}
-	var finalErr error
-
-	for _, mysqlVm := range mysqlVms.Inputs {
-		output, err := action.createMysqlVm(&mysqlVm)
-		if err != nil {
-			finalErr = err
-		}
-
-		outputs.Outputs = append(outputs.Outputs, output)
-	}
+	outputs := MysqlVmOutputs{Outputs: make([]MysqlVmOutput, len(mysqlVms.Inputs))}
+	finalErr := runInputs(ctx, len(mysqlVms.Inputs), func(i int) []string {
+		return []string{mysqlVms.Inputs[i].Guid, mysqlVms.Inputs[i].Id, mysqlVms.Inputs[i].MasterInstanceId}
+	}, func(ctx context.Context, i int) error {
+		mysqlVm := mysqlVms.Inputs[i]
+		output, err := action.createMysqlVm(ctx, &mysqlVm)
+		outputs.Outputs[i] = output
+		return err
+	})
 
-	logrus.Infof("all mysqlVms = %v are created", mysqlVms)
+	Logger(ctx).Infof("all mysqlVms = %s are created", Sanitize(mysqlVms))
 	return &outputs, finalErr
 }
 
 type MysqlVmTerminateAction struct {
 }
 
-func (action *MysqlVmTerminateAction) ReadParam(param interface{}) (interface{}, error) {
+func (action *MysqlVmTerminateAction) ReadParam(ctx context.Context, param interface{}) (interface{}, error) {
 	var inputs MysqlVmInputs
 	err := UnmarshalJson(param, &inputs)
 	if err != nil {
@@ -705,32 +666,30 @@ func mysqlVmTerminateCheckParam(mysqlVm *MysqlVmInput) error {
 	return nil
 }
 
-func (action *MysqlVmTerminateAction) terminateMysqlVm(mysqlVmInput *MysqlVmInput) (output MysqlVmOutput, err error) {
+func (action *MysqlVmTerminateAction) terminateMysqlVm(ctx context.Context, mysqlVmInput *MysqlVmInput) (output MysqlVmOutput, err error) {
 	output.Guid = mysqlVmInput.Guid
 	output.Result.Code = RESULT_CODE_SUCCESS
 
 	defer func() {
 		if err != nil {
-			output.Result.Code = RESULT_CODE_ERROR
-			output.Result.Message = err.Error()
+			output.Result.SetError(err)
 		}
 	}()
 
-	if err = mysqlVmTerminateCheckParam(mysqlVmInput); err != nil {
+	if err = AsValidationError(mysqlVmTerminateCheckParam(mysqlVmInput)); err != nil {
 		return output, err
 	}
 
-	if mysqlVmInput.Location != "" && mysqlVmInput.APISecret != "" {
-		mysqlVmInput.ProviderParams = fmt.Sprintf("%s;%s", mysqlVmInput.Location, mysqlVmInput.APISecret)
+	params, err := NewProviderParams(mysqlVmInput.ProviderParams, mysqlVmInput.Location, mysqlVmInput.APISecret)
+	if err != nil {
+		return output, err
 	}
-	paramsMap, err := GetMapFromProviderParams(mysqlVmInput.ProviderParams)
-	client, _ := CreateMysqlVmClient(paramsMap["Region"], paramsMap["SecretID"], paramsMap["SecretKey"])
+	client, _ := CreateMysqlVmClient(ctx, params)
 
 	// check whther the mysql is exist.
 	_, flag, err := queryMysqlVMInstancesInfo(client, mysqlVmInput.Id)
 	if err != nil {
-		output.Result.Code = RESULT_CODE_ERROR
-		output.Result.Message = err.Error()
+		output.Result.SetError(err)
 		return output, err
 	}
 
@@ -748,7 +707,7 @@ func (action *MysqlVmTerminateAction) terminateMysqlVm(mysqlVmInput *MysqlVmInpu
 		return output, err
 	}
 
-	err = action.waitForMysqlVmTerminationToFinish(client, mysqlVmInput.Id)
+	err = action.waitForMysqlVmTerminationToFinish(ctx, client, mysqlVmInput.Id, mysqlVmInput.WaitTimeout)
 	if err != nil {
 		return output, err
 	}
@@ -762,7 +721,7 @@ func (action *MysqlVmTerminateAction) terminateMysqlVm(mysqlVmInput *MysqlVmInpu
 		return output, err
 	}
 
-	err = action.waitForMysqlVmTOfflineToFinish(client, mysqlVmInput.Id)
+	err = action.waitForMysqlVmTOfflineToFinish(ctx, client, mysqlVmInput.Id, mysqlVmInput.WaitTimeout)
 	if err != nil {
 		return output, err
 	}
@@ -772,67 +731,56 @@ func (action *MysqlVmTerminateAction) terminateMysqlVm(mysqlVmInput *MysqlVmInpu
 	return output, err
 }
 
-func (action *MysqlVmTerminateAction) waitForMysqlVmTerminationToFinish(client *cdb.Client, instanceId string) error {
-	time.Sleep(2 * time.Second)
+func (action *MysqlVmTerminateAction) waitForMysqlVmTerminationToFinish(ctx context.Context, client *cdb.Client, instanceId string, timeout string) error {
 	request := cdb.NewDescribeDBInstancesRequest()
 	request.InstanceIds = append(request.InstanceIds, &instanceId)
-	count := 0
-	for {
+
+	waiter, err := NewWaiter(WAIT_RESOURCE_MYSQL, instanceId, timeout)
+	if err != nil {
+		return err
+	}
+	check := 0
+	return waiter.Wait(ctx, func() (string, bool, error) {
+		// the instance may still be found at the first check.
+		check++
 		response, err := client.DescribeDBInstances(request)
 		if err != nil {
-			if count > 0 {
-				return err
-			}
-		} else {
-			if len(response.Response.Items) == 0 {
-				if count > 0 {
-					return nil
-				}
-			} else {
-				if *response.Response.Items[0].Status == MYSQL_VM_STATUS_ISOLATED {
-					return nil
-				}
+			if check > 1 {
+				return "", false, err
 			}
+			return "", false, nil
 		}
-
-		time.Sleep(10 * time.Second)
-		count++
-		if count >= 20 {
-			return errors.New("waitForMysqlVmTerminationToFinish timeout")
+		if len(response.Response.Items) == 0 {
+			return "", check > 1, nil
 		}
-	}
+		status := *response.Response.Items[0].Status
+		return strconv.Itoa(int(status)), status == MYSQL_VM_STATUS_ISOLATED, nil
+	})
 }
 
-func (action *MysqlVmTerminateAction) waitForMysqlVmTOfflineToFinish(client *cdb.Client, instanceId string) error {
-	time.Sleep(2 * time.Second)
-	count := 0
-	for {
-		_,b,err := queryMysqlVMInstancesInfo(client, instanceId)
-		if b == false && err == nil {
-			return nil
-		}
-
-		time.Sleep(3 * time.Second)
-		count++
-		if count >= 60 {
-			return errors.New("waitForMysqlVmOfflineToFinish timeout")
-		}
+func (action *MysqlVmTerminateAction) waitForMysqlVmTOfflineToFinish(ctx context.Context, client *cdb.Client, instanceId string, timeout string) error {
+	waiter, err := NewWaiter(WAIT_RESOURCE_MYSQL, instanceId, timeout)
+	if err != nil {
+		return err
 	}
+	return waiter.Wait(ctx, func() (string, bool, error) {
+		_, b, err := queryMysqlVMInstancesInfo(client, instanceId)
+		return "", b == false && err == nil, nil
+	})
 }
 
-func (action *MysqlVmTerminateAction) Do(input interface{}) (interface{}, error) {
+func (action *MysqlVmTerminateAction) Do(ctx context.Context, input interface{}) (interface{}, error) {
 	mysqlVms, _ := input.(MysqlVmInputs)
-	outputs := MysqlVmOutputs{}
-	var finalErr error
-
-	for _, mysqlVm := range mysqlVms.Inputs {
-		output, err := action.terminateMysqlVm(&mysqlVm)
+	outputs := MysqlVmOutputs{Outputs: make([]MysqlVmOutput, len(mysqlVms.Inputs))}
+	finalErr := runInputs(ctx, len(mysqlVms.Inputs), func(i int) []string {
+		return []string{mysqlVms.Inputs[i].Guid, mysqlVms.Inputs[i].Id}
+	}, func(ctx context.Context, i int) error {
+		mysqlVm := mysqlVms.Inputs[i]
+		output, err := action.terminateMysqlVm(ctx, &mysqlVm)
 		output.CallBackParameter.Parameter = mysqlVm.CallBackParameter.Parameter
-		if err != nil {
-			finalErr = err
-		}
-		outputs.Outputs = append(outputs.Outputs, output)
-	}
+		outputs.Outputs[i] = output
+		return err
+	})
 
 	return &outputs, finalErr
 }
@@ -840,7 +788,7 @@ func (action *MysqlVmTerminateAction) Do(input interface{}) (interface{}, error)
 type MysqlVmRestartAction struct {
 }
 
-func (action *MysqlVmRestartAction) ReadParam(param interface{}) (interface{}, error) {
+func (action *MysqlVmRestartAction) ReadParam(ctx context.Context, param interface{}) (interface{}, error) {
 	var inputs MysqlVmInputs
 	err := UnmarshalJson(param, &inputs)
 	if err != nil {
@@ -857,59 +805,51 @@ func mysqlVmRestartCheckParam(mysqlVm *MysqlVmInput) error {
 	return nil
 }
 
-func (action *MysqlVmRestartAction) restartMysqlVm(mysqlVmInput MysqlVmInput) error {
-	if mysqlVmInput.Location != "" && mysqlVmInput.APISecret != "" {
-		mysqlVmInput.ProviderParams = fmt.Sprintf("%s;%s", mysqlVmInput.Location, mysqlVmInput.APISecret)
+func (action *MysqlVmRestartAction) restartMysqlVm(ctx context.Context, mysqlVmInput MysqlVmInput) error {
+	params, err := NewProviderParams(mysqlVmInput.ProviderParams, mysqlVmInput.Location, mysqlVmInput.APISecret)
+	if err != nil {
+		return err
 	}
-	paramsMap, err := GetMapFromProviderParams(mysqlVmInput.ProviderParams)
-	client, _ := CreateMysqlVmClient(paramsMap["Region"], paramsMap["SecretID"], paramsMap["SecretKey"])
+	client, _ := CreateMysqlVmClient(ctx, params)
 
 	request := cdb.NewRestartDBInstancesRequest()
 	request.InstanceIds = []*string{&mysqlVmInput.Id}
 
 	response, err := client.RestartDBInstances(request)
 	if err != nil {
-		logrus.Errorf("failed to restart MysqlVm (mysqlVmId=%v), error=%s", mysqlVmInput.Id, err)
+		Logger(ctx).Errorf("failed to restart MysqlVm (mysqlVmId=%v), error=%s", mysqlVmInput.Id, err)
 		return err
 	}
 
-	logrus.Infof("restartMysqlVm AsyncRequestId = %v", *response.Response.AsyncRequestId)
+	Logger(ctx).Infof("restartMysqlVm AsyncRequestId = %v", *response.Response.AsyncRequestId)
 
-	return waitForAsyncTaskToFinish(client, *response.Response.AsyncRequestId)
+	return waitForAsyncTaskToFinish(ctx, client, *response.Response.AsyncRequestId, mysqlVmInput.WaitTimeout)
 }
 
-func waitForAsyncTaskToFinish(client *cdb.Client, requestId string) error {
-	time.Sleep(2 * time.Second)
+func waitForAsyncTaskToFinish(ctx context.Context, client *cdb.Client, requestId string, timeout string) error {
 	taskReq := cdb.NewDescribeAsyncRequestInfoRequest()
 	taskReq.AsyncRequestId = &requestId
-	count := 0
-	for {
+
+	waiter, err := NewWaiter(WAIT_RESOURCE_MYSQL_TASK, requestId, timeout)
+	if err != nil {
+		return err
+	}
+	return waiter.WithFailureStates(MYSQL_TASK_STATUS_FAILED).Wait(ctx, func() (string, bool, error) {
 		taskResp, err := client.DescribeAsyncRequestInfo(taskReq)
 		if err != nil {
-			return err
-		}
-
-		if *taskResp.Response.Status == "SUCCESS" {
-			return nil
-		}
-		if *taskResp.Response.Status == "FAILED" {
-			return fmt.Errorf("waitForAsyncTaskToFinish failed, request id = %v", requestId)
+			return "", false, err
 		}
-
-		time.Sleep(10 * time.Second)
-		count++
-		if count >= 20 {
-			return fmt.Errorf("waitForAsyncTaskToFinish timeout, request id = %v", requestId)
-		}
-	}
+		return *taskResp.Response.Status, *taskResp.Response.Status == MYSQL_TASK_STATUS_SUCCESS, nil
+	})
 }
 
-func (action *MysqlVmRestartAction) Do(input interface{}) (interface{}, error) {
+func (action *MysqlVmRestartAction) Do(ctx context.Context, input interface{}) (interface{}, error) {
 	mysqlVms, _ := input.(MysqlVmInputs)
-	outputs := MysqlVmOutputs{}
-	var finalErr error
-
-	for _, mysqlVm := range mysqlVms.Inputs {
+	outputs := MysqlVmOutputs{Outputs: make([]MysqlVmOutput, len(mysqlVms.Inputs))}
+	finalErr := runInputs(ctx, len(mysqlVms.Inputs), func(i int) []string {
+		return []string{mysqlVms.Inputs[i].Guid, mysqlVms.Inputs[i].Id}
+	}, func(ctx context.Context, i int) error {
+		mysqlVm := mysqlVms.Inputs[i]
 		output := MysqlVmOutput{
 			Guid: mysqlVm.Guid,
 		}
@@ -917,21 +857,20 @@ func (action *MysqlVmRestartAction) Do(input interface{}) (interface{}, error) {
 		output.Id = mysqlVm.Id
 		output.Result.Code = RESULT_CODE_SUCCESS
 
-		if err := mysqlVmRestartCheckParam(&mysqlVm); err != nil {
-			output.Result.Code = RESULT_CODE_ERROR
-			output.Result.Message = err.Error()
-			finalErr = err
-			outputs.Outputs = append(outputs.Outputs, output)
-			continue
+		if err := AsValidationError(mysqlVmRestartCheckParam(&mysqlVm)); err != nil {
+			output.Result.SetError(err)
+			outputs.Outputs[i] = output
+			return err
 		}
 
-		if err := action.restartMysqlVm(mysqlVm); err != nil {
-			output.Result.Code = RESULT_CODE_ERROR
-			output.Result.Message = err.Error()
-			finalErr = err
+		if err := action.restartMysqlVm(ctx, mysqlVm); err != nil {
+			output.Result.SetError(err)
+			outputs.Outputs[i] = output
+			return err
 		}
-		outputs.Outputs = append(outputs.Outputs, output)
-	}
+		outputs.Outputs[i] = output
+		return nil
+	})
 
 	return outputs, finalErr
 }
@@ -962,14 +901,17 @@ func queryMysqlVMInstancesInfo(client *cdb.Client, mysqlId string) (*cdb.Describ
 }
 
 //--------------query mysql instance ------------------//
-func QueryMysqlInstance(providerParams string, filter Filter) ([]*cdb.InstanceInfo, error) {
+func QueryMysqlInstance(ctx context.Context, providerParams string, filter Filter) ([]*cdb.InstanceInfo, error) {
 	validFilterNames := []string{"instanceId", "vip"}
 	filterValues := common.StringPtrs(filter.Values)
 	emptyInstances := []*cdb.InstanceInfo{}
 	var offset, limit uint64 = 0, uint64(len(filterValues))
 
-	paramsMap, err := GetMapFromProviderParams(providerParams)
-	client, err := CreateMysqlVmClient(paramsMap["Region"], paramsMap["SecretID"], paramsMap["SecretKey"])
+	params, err := ParseProviderParams(providerParams)
+	if err != nil {
+		return emptyInstances, err
+	}
+	client, err := CreateMysqlVmClient(ctx, params)
 	if err != nil {
 		return emptyInstances, err
 	}
@@ -989,7 +931,7 @@ func QueryMysqlInstance(providerParams string, filter Filter) ([]*cdb.InstanceIn
 
 	response, err := client.DescribeDBInstances(request)
 	if err != nil {
-		logrus.Errorf("cdb DescribeDBInstances meet err=%v", err)
+		Logger(ctx).Errorf("cdb DescribeDBInstances meet err=%v", err)
 		return emptyInstances, err
 	}
 
@@ -997,10 +939,13 @@ func QueryMysqlInstance(providerParams string, filter Filter) ([]*cdb.InstanceIn
 }
 
 //-------------query security group by instanceId-----------//
-func QueryMySqlInstanceSecurityGroups(providerParams string, instanceId string) ([]string, error) {
+func QueryMySqlInstanceSecurityGroups(ctx context.Context, providerParams string, instanceId string) ([]string, error) {
 	securityGroups := []string{}
-	paramsMap, err := GetMapFromProviderParams(providerParams)
-	client, err := CreateMysqlVmClient(paramsMap["Region"], paramsMap["SecretID"], paramsMap["SecretKey"])
+	params, err := ParseProviderParams(providerParams)
+	if err != nil {
+		return securityGroups, err
+	}
+	client, err := CreateMysqlVmClient(ctx, params)
 	if err != nil {
 		return securityGroups, err
 	}
@@ -1010,7 +955,7 @@ func QueryMySqlInstanceSecurityGroups(providerParams string, instanceId string)
 
 	response, err := client.DescribeDBSecurityGroups(request)
 	if err != nil {
-		logrus.Errorf("cdb DescribeDBSecurityGroups meet err=%v", err)
+		Logger(ctx).Errorf("cdb DescribeDBSecurityGroups meet err=%v", err)
 		return securityGroups, err
 	}
 
@@ -1020,9 +965,12 @@ func QueryMySqlInstanceSecurityGroups(providerParams string, instanceId string)
 	return securityGroups, nil
 }
 
-func BindMySqlInstanceSecurityGroups(providerParams string, instanceId string, securityGroups []string) error {
-	paramsMap, err := GetMapFromProviderParams(providerParams)
-	client, err := CreateMysqlVmClient(paramsMap["Region"], paramsMap["SecretID"], paramsMap["SecretKey"])
+func BindMySqlInstanceSecurityGroups(ctx context.Context, providerParams string, instanceId string, securityGroups []string) error {
+	params, err := ParseProviderParams(providerParams)
+	if err != nil {
+		return err
+	}
+	client, err := CreateMysqlVmClient(ctx, params)
 	if err != nil {
 		return err
 	}
@@ -1039,7 +987,7 @@ func BindMySqlInstanceSecurityGroups(providerParams string, instanceId string, s
 
 	_, err = client.ModifyDBInstanceSecurityGroups(request)
 	if err != nil {
-		logrus.Errorf("cdb ModifyDBInstanceSecurityGroups meet err=%v", err)
+		Logger(ctx).Errorf("cdb ModifyDBInstanceSecurityGroups meet err=%v", err)
 	}
 
 	return err
@@ -1054,12 +1002,12 @@ type MysqlBindSecurityGroupInputs struct {
 
 type MysqlBindSecurityGroupInput struct {
 	CallBackParameter
-	Guid             string `json:"guid,omitempty"`
-	ProviderParams   string `json:"provider_params,omitempty"`
-	MySqlId          string `json:"mysql_id,omitempty"`
-	SecurityGroupIds string `json:"security_group_ids,omitempty"`
-	Location         string `json:"location"`
-	APISecret        string `json:"api_secret"`
+	Guid             string `json:"guid,omitempty" register:"required"`
+	ProviderParams   string `json:"provider_params,omitempty" register:"optional"`
+	MySqlId          string `json:"mysql_id,omitempty" register:"required"`
+	SecurityGroupIds string `json:"security_group_ids,omitempty" register:"required"`
+	Location         string `json:"location" register:"required"`
+	APISecret        string `json:"api_secret" register:"required,system_variable=QCLOUD_API_SECRET"`
 }
 
 type MysqlBindSecurityGroupOutputs struct {
@@ -1069,10 +1017,10 @@ type MysqlBindSecurityGroupOutputs struct {
 type MysqlBindSecurityGroupOutput struct {
 	CallBackParameter
 	Result
-	Guid string `json:"guid,omitempty"`
+	Guid string `json:"guid,omitempty" register:"optional"`
 }
 
-func (action *MysqlBindSecurityGroupAction) ReadParam(param interface{}) (interface{}, error) {
+func (action *MysqlBindSecurityGroupAction) ReadParam(ctx context.Context, param interface{}) (interface{}, error) {
 	var inputs MysqlBindSecurityGroupInputs
 	err := UnmarshalJson(param, &inputs)
 	if err != nil {
@@ -1081,12 +1029,13 @@ func (action *MysqlBindSecurityGroupAction) ReadParam(param interface{}) (interf
 	return inputs, nil
 }
 
-func (action *MysqlBindSecurityGroupAction) Do(input interface{}) (interface{}, error) {
+func (action *MysqlBindSecurityGroupAction) Do(ctx context.Context, input interface{}) (interface{}, error) {
 	inputs, _ := input.(MysqlBindSecurityGroupInputs)
-	outputs := MysqlBindSecurityGroupOutputs{}
-	var finalErr error
-
-	for _, input := range inputs.Inputs {
+	outputs := MysqlBindSecurityGroupOutputs{Outputs: make([]MysqlBindSecurityGroupOutput, len(inputs.Inputs))}
+	finalErr := runInputs(ctx, len(inputs.Inputs), func(i int) []string {
+		return []string{inputs.Inputs[i].Guid, inputs.Inputs[i].MySqlId}
+	}, func(ctx context.Context, i int) error {
+		input := inputs.Inputs[i]
 		output := MysqlBindSecurityGroupOutput{
 			Guid: input.Guid,
 		}
@@ -1094,16 +1043,19 @@ func (action *MysqlBindSecurityGroupAction) Do(input interface{}) (interface{},
 		output.Result.Code = RESULT_CODE_SUCCESS
 
 		securityGroups, _ := GetArrayFromString(input.SecurityGroupIds, ARRAY_SIZE_REAL, 0)
-		if input.Location != "" && input.APISecret != "" {
-			input.ProviderParams = fmt.Sprintf("%s;%s", input.Location, input.APISecret)
+		providerParams, err := mergeProviderParams(input.ProviderParams, input.Location, input.APISecret)
+		if err == nil {
+			err = BindMySqlInstanceSecurityGroups(ctx, providerParams, input.MySqlId, securityGroups)
 		}
-		if err := BindMySqlInstanceSecurityGroups(input.ProviderParams, input.MySqlId, securityGroups); err != nil {
-			output.Result.Message = err.Error()
+		if err != nil {
+			output.Result.SetError(err)
 			output.Result.Code = RESULT_CODE_ERROR
-			finalErr = err
+			outputs.Outputs[i] = output
+			return err
 		}
-		outputs.Outputs = append(outputs.Outputs, output)
-	}
+		outputs.Outputs[i] = output
+		return nil
+	})
 	return &outputs, finalErr
 }
 
@@ -1122,14 +1074,15 @@ type MysqlCreateBackupInputs struct {
 
 type MysqlCreateBackupInput struct {
 	CallBackParameter
-	Guid           string `json:"guid,omitempty"`
-	ProviderParams string `json:"provider_params,omitempty"`
-	MysqlId        string `json:"mysql_id,omitempty"`
-	BackUpMethod   string `json:"backup_method,omitempty"`
-	BackUpDatabase string `json:"backup_database,omitempty"`
-	BackUpTable    string `json:"backup_table,omitempty"`
-	Location       string `json:"location"`
-	APISecret      string `json:"api_secret"`
+	WaitParameter
+	Guid           string `json:"guid,omitempty" register:"required"`
+	ProviderParams string `json:"provider_params,omitempty" register:"optional"`
+	MysqlId        string `json:"mysql_id,omitempty" register:"required"`
+	BackUpMethod   string `json:"backup_method,omitempty" register:"required,system_variable=QCLOUD_MYSQL_BACKUP_TYPE"`
+	BackUpDatabase string `json:"backup_database,omitempty" register:"required"`
+	BackUpTable    string `json:"backup_table,omitempty" register:"optional"`
+	Location       string `json:"location" register:"required"`
+	APISecret      string `json:"api_secret" register:"required,system_variable=QCLOUD_API_SECRET"`
 }
 
 type MysqlCreateBackupOutputs struct {
@@ -1139,11 +1092,11 @@ type MysqlCreateBackupOutputs struct {
 type MysqlCreateBackupOutput struct {
 	CallBackParameter
 	Result
-	Guid     string `json:"guid,omitempty"`
-	BackupId string `json:"backup_id,omitempty"`
+	Guid     string `json:"guid,omitempty" register:"optional"`
+	BackupId string `json:"backup_id,omitempty" register:"optional"`
 }
 
-func (action *MysqlCreateBackupAction) ReadParam(param interface{}) (interface{}, error) {
+func (action *MysqlCreateBackupAction) ReadParam(ctx context.Context, param interface{}) (interface{}, error) {
 	var inputs MysqlCreateBackupInputs
 	err := UnmarshalJson(param, &inputs)
 	if err != nil {
@@ -1152,7 +1105,7 @@ func (action *MysqlCreateBackupAction) ReadParam(param interface{}) (interface{}
 	return inputs, nil
 }
 
-func createMysqlBackup(input *MysqlCreateBackupInput) (string, error) {
+func createMysqlBackup(ctx context.Context, input *MysqlCreateBackupInput) (string, error) {
 	var err error
 	if input.MysqlId == "" {
 		return "", fmt.Errorf("mysqlId is empty")
@@ -1169,11 +1122,11 @@ func createMysqlBackup(input *MysqlCreateBackupInput) (string, error) {
 
 	tables, _ := GetArrayFromString(input.BackUpTable, ARRAY_SIZE_REAL, 0)
 
-	if input.Location != "" && input.APISecret != "" {
-		input.ProviderParams = fmt.Sprintf("%s;%s", input.Location, input.APISecret)
+	params, err := NewProviderParams(input.ProviderParams, input.Location, input.APISecret)
+	if err != nil {
+		return "", err
 	}
-	paramsMap, err := GetMapFromProviderParams(input.ProviderParams)
-	client, err := CreateMysqlVmClient(paramsMap["Region"], paramsMap["SecretID"], paramsMap["SecretKey"])
+	client, err := CreateMysqlVmClient(ctx, params)
 	if err != nil {
 		return "", err
 	}
@@ -1181,19 +1134,19 @@ func createMysqlBackup(input *MysqlCreateBackupInput) (string, error) {
 	// check resource exist
 	_, flag, err := queryMysqlVMInstancesInfo(client, input.MysqlId)
 	if err != nil && flag == false {
-		logrus.Errorf("queryMysqlVMInstancesInfo meet error=%v, mysqlId=[%v]", err, input.MysqlId)
+		Logger(ctx).Errorf("queryMysqlVMInstancesInfo meet error=%v, mysqlId=[%v]", err, input.MysqlId)
 		return "", err
 	}
 
 	if err == nil && flag == false {
-		logrus.Errorf("mysql[mysqlId=%v] is not existed", input.MysqlId)
+		Logger(ctx).Errorf("mysql[mysqlId=%v] is not existed", input.MysqlId)
 		err = fmt.Errorf("mysql[mysqlId=%v] is not existed", input.MysqlId)
 		return "", err
 	}
 
 	responseBackups, err := describeBackups(client, input.MysqlId)
 	if err != nil {
-		logrus.Errorf("describeBackups meet error=%v, mysqlId=[%v]", err, input.MysqlId)
+		Logger(ctx).Errorf("describeBackups meet error=%v, mysqlId=[%v]", err, input.MysqlId)
 		return "", err
 	}
 
@@ -1208,7 +1161,7 @@ func createMysqlBackup(input *MysqlCreateBackupInput) (string, error) {
 		}
 	}
 	if len(backupRunning) > 0 {
-		logrus.Errorf("can not create mysql backup: the mysql[%v] has running backup=%v now", input.MysqlId, backupRunning)
+		Logger(ctx).Errorf("can not create mysql backup: the mysql[%v] has running backup=%v now", input.MysqlId, backupRunning)
 		err = fmt.Errorf("can not create mysql backup: the mysql[%v] has running backup=%v now", input.MysqlId, backupRunning)
 		return "", err
 	}
@@ -1237,50 +1190,28 @@ func createMysqlBackup(input *MysqlCreateBackupInput) (string, error) {
 
 	response, err := client.CreateBackup(request)
 	if err != nil {
-		logrus.Errorf("failed to create mysql[instanceId=%v] backup, error=%v", input.MysqlId, err)
+		Logger(ctx).Errorf("failed to create mysql[instanceId=%v] backup, error=%v", input.MysqlId, err)
 		return "", err
 	}
 	backupId := strconv.Itoa(int(*response.Response.BackupId))
 
-	var allBackups []*cdb.BackupInfo
-	count := 1
-
-	for {
-		allBackups, err = describeBackups(client, input.MysqlId)
+	waiter, err := NewWaiter(WAIT_RESOURCE_MYSQL_BACKUP, backupId, input.WaitTimeout)
+	if err != nil {
+		return backupId, err
+	}
+	err = waiter.WithFailureStates(MYSQL_TASK_STATUS_FAILED).Wait(ctx, func() (string, bool, error) {
+		allBackups, err := describeBackups(client, input.MysqlId)
 		if err != nil {
-			logrus.Errorf("describeBackups meet error=%v, mysqlId=[%v]", err, input.MysqlId)
-			return "", err
+			Logger(ctx).Errorf("describeBackups meet error=%v, mysqlId=[%v]", err, input.MysqlId)
+			return "", false, err
 		}
-		var flag bool
 		for _, backup := range allBackups {
 			if strconv.Itoa(int(*backup.BackupId)) == backupId {
-				flag = true
-				if *backup.Status == "SUCCESS" {
-					return backupId, nil
-				}
-				if *backup.Status == "FAILED" {
-					logrus.Errorf("Falied to create mysql[instacneId=%v] backup: backup[backupId=%v] status is failded", input.MysqlId, backupId)
-					err = fmt.Errorf("Falied to create mysql[instacneId=%v] backup: backup[backupId=%v] status is failded", input.MysqlId, backupId)
-					return backupId, err
-				}
+				return *backup.Status, *backup.Status == MYSQL_TASK_STATUS_SUCCESS, nil
 			}
 		}
-		if flag == false {
-			logrus.Errorf("Falied to create mysql[instacneId=%v] backup: backup[backupId=%v] is not found", input.MysqlId, backupId)
-			err = fmt.Errorf("Falied to create mysql[instacneId=%v] backup: backup[backupId=%v] is not found", input.MysqlId, backupId)
-			return backupId, err
-		}
-
-		if count <= 20 {
-			time.Sleep(5 * time.Second)
-			count++
-		} else {
-			break
-		}
-	}
-
-	logrus.Errorf("after %v seconds, mysql[instacneId=%v] backup[backupId=%v] status is running", count*5, input.MysqlId, backupId)
-	err = fmt.Errorf("after %v seconds, mysql[instacneId=%v] backup[backupId=%v] status is running", count*5, input.MysqlId, backupId)
+		return "", false, fmt.Errorf("Falied to create mysql[instacneId=%v] backup: backup[backupId=%v] is not found", input.MysqlId, backupId)
+	})
 	return backupId, err
 }
 
@@ -1296,27 +1227,29 @@ func describeBackups(client *cdb.Client, mysqlId string) ([]*cdb.BackupInfo, err
 	return backupResponse.Response.Items, nil
 }
 
-func (action *MysqlCreateBackupAction) Do(input interface{}) (interface{}, error) {
+func (action *MysqlCreateBackupAction) Do(ctx context.Context, input interface{}) (interface{}, error) {
 	inputs, _ := input.(MysqlCreateBackupInputs)
-	outputs := MysqlCreateBackupOutputs{}
-	var finalErr error
-
-	for _, input := range inputs.Inputs {
+	outputs := MysqlCreateBackupOutputs{Outputs: make([]MysqlCreateBackupOutput, len(inputs.Inputs))}
+	finalErr := runInputs(ctx, len(inputs.Inputs), func(i int) []string {
+		return []string{inputs.Inputs[i].Guid, inputs.Inputs[i].MysqlId}
+	}, func(ctx context.Context, i int) error {
+		input := inputs.Inputs[i]
 		output := MysqlCreateBackupOutput{
 			Guid: input.Guid,
 		}
 		output.CallBackParameter.Parameter = input.CallBackParameter.Parameter
 		output.Result.Code = RESULT_CODE_SUCCESS
 
-		backUpId, err := createMysqlBackup(&input)
+		backUpId, err := createMysqlBackup(ctx, &input)
 		if err != nil {
-			output.Result.Code = RESULT_CODE_ERROR
-			output.Result.Message = err.Error()
-			finalErr = err
+			output.Result.SetError(err)
+			outputs.Outputs[i] = output
+			return err
 		}
 		output.BackupId = backUpId
-		outputs.Outputs = append(outputs.Outputs, output)
-	}
+		outputs.Outputs[i] = output
+		return nil
+	})
 	return outputs, finalErr
 }
 
@@ -1330,12 +1263,13 @@ type MysqlDeleteBackupInputs struct {
 
 type MysqlDeleteBackupInput struct {
 	CallBackParameter
-	Guid           string `json:"guid,omitempty"`
-	ProviderParams string `json:"provider_params,omitempty"`
-	MySqlId        string `json:"mysql_id,omitempty"`
-	BackupId       string `json:"backup_id,omitempty"`
-	Location       string `json:"location"`
-	APISecret      string `json:"api_secret"`
+	WaitParameter
+	Guid           string `json:"guid,omitempty" register:"required"`
+	ProviderParams string `json:"provider_params,omitempty" register:"optional"`
+	MySqlId        string `json:"mysql_id,omitempty" register:"required"`
+	BackupId       string `json:"backup_id,omitempty" register:"required"`
+	Location       string `json:"location" register:"required"`
+	APISecret      string `json:"api_secret" register:"required,system_variable=QCLOUD_API_SECRET"`
 }
 
 type MysqlDeleteBackupOutputs struct {
@@ -1345,10 +1279,10 @@ type MysqlDeleteBackupOutputs struct {
 type MysqlDeleteBackupOutput struct {
 	CallBackParameter
 	Result
-	Guid string `json:"guid,omitempty"`
+	Guid string `json:"guid,omitempty" register:"optional"`
 }
 
-func (action *MysqlDeleteBackupAction) ReadParam(param interface{}) (interface{}, error) {
+func (action *MysqlDeleteBackupAction) ReadParam(ctx context.Context, param interface{}) (interface{}, error) {
 	var inputs MysqlDeleteBackupInputs
 	err := UnmarshalJson(param, &inputs)
 	if err != nil {
@@ -1357,7 +1291,7 @@ func (action *MysqlDeleteBackupAction) ReadParam(param interface{}) (interface{}
 	return inputs, nil
 }
 
-func deleteMysqlBackup(input *MysqlDeleteBackupInput) error {
+func deleteMysqlBackup(ctx context.Context, input *MysqlDeleteBackupInput) error {
 	var err error
 	if input.MySqlId == "" {
 		return fmt.Errorf("MySqlId is empty")
@@ -1366,11 +1300,11 @@ func deleteMysqlBackup(input *MysqlDeleteBackupInput) error {
 		return fmt.Errorf("BackupId is empty")
 	}
 
-	if input.Location != "" && input.APISecret != "" {
-		input.ProviderParams = fmt.Sprintf("%s;%s", input.Location, input.APISecret)
+	params, err := NewProviderParams(input.ProviderParams, input.Location, input.APISecret)
+	if err != nil {
+		return err
 	}
-	paramsMap, err := GetMapFromProviderParams(input.ProviderParams)
-	client, err := CreateMysqlVmClient(paramsMap["Region"], paramsMap["SecretID"], paramsMap["SecretKey"])
+	client, err := CreateMysqlVmClient(ctx, params)
 	if err != nil {
 		return err
 	}
@@ -1378,19 +1312,19 @@ func deleteMysqlBackup(input *MysqlDeleteBackupInput) error {
 	// check resource exist
 	_, flag, err := queryMysqlVMInstancesInfo(client, input.MySqlId)
 	if err != nil && flag == false {
-		logrus.Errorf("queryMysqlVMInstancesInfo meet error=%v, mysqlId=[%v]", err, input.MySqlId)
+		Logger(ctx).Errorf("queryMysqlVMInstancesInfo meet error=%v, mysqlId=[%v]", err, input.MySqlId)
 		return err
 	}
 
 	if err == nil && flag == false {
-		logrus.Errorf("mysql[mysqlId=%v] is not existed", input.MySqlId)
+		Logger(ctx).Errorf("mysql[mysqlId=%v] is not existed", input.MySqlId)
 		err = fmt.Errorf("mysql[mysqlId=%v] is not existed", input.MySqlId)
 		return err
 	}
 
 	responseBackups, err := describeBackups(client, input.MySqlId)
 	if err != nil {
-		logrus.Errorf("DescribeBackups meet error=%v, mysqlId=[%v]", err, input.MySqlId)
+		Logger(ctx).Errorf("DescribeBackups meet error=%v, mysqlId=[%v]", err, input.MySqlId)
 		return err
 	}
 	backupFlag := false
@@ -1401,7 +1335,7 @@ func deleteMysqlBackup(input *MysqlDeleteBackupInput) error {
 		}
 	}
 	if backupFlag == false {
-		logrus.Errorf("backup[backupId=%v] is not existed", input.BackupId)
+		Logger(ctx).Errorf("backup[backupId=%v] is not existed", input.BackupId)
 		return fmt.Errorf("backup[backupId=%v] is not existed", input.BackupId)
 	}
 
@@ -1415,59 +1349,49 @@ func deleteMysqlBackup(input *MysqlDeleteBackupInput) error {
 	request.BackupId = &backupIdInt64
 	_, err = client.DeleteBackup(request)
 	if err != nil {
-		logrus.Errorf("failed to delete mysql[instanceId=%v] backup[backupId=%v], error=%v", input.MySqlId, input.BackupId, err)
+		Logger(ctx).Errorf("failed to delete mysql[instanceId=%v] backup[backupId=%v], error=%v", input.MySqlId, input.BackupId, err)
 		return err
 	}
 
-	count := 1
-	for {
+	waiter, err := NewWaiter(WAIT_RESOURCE_MYSQL_BACKUP, input.BackupId, input.WaitTimeout)
+	if err != nil {
+		return err
+	}
+	return waiter.Wait(ctx, func() (string, bool, error) {
 		allBackups, err := describeBackups(client, input.MySqlId)
 		if err != nil {
-			logrus.Errorf("describeBackups meet error=%v, mysqlId=[%v]", err, input.MySqlId)
-			return err
+			Logger(ctx).Errorf("describeBackups meet error=%v, mysqlId=[%v]", err, input.MySqlId)
+			return "", false, err
 		}
-		var flag bool
 		for _, backup := range allBackups {
 			if strconv.Itoa(int(*backup.BackupId)) == input.BackupId {
-				flag = true
-				break
+				return *backup.Status, false, nil
 			}
 		}
-		if flag == false {
-			logrus.Infof("success to delete mysql[instanceId=%v] backup[backupId=%v]", input.MySqlId, input.BackupId)
-			return nil
-		}
-		if count <= 20 {
-			time.Sleep(5 * time.Second)
-			count++
-		} else {
-			break
-		}
-	}
-
-	logrus.Errorf("after %v seconds, mysql[instacneId=%v] backup[backupId=%v] still be exist", count*5, input.MySqlId, input.BackupId)
-	err = fmt.Errorf("after %v seconds, mysql[instacneId=%v] backup[backupId=%v] status is running", count*5, input.MySqlId, input.BackupId)
-	return err
+		return "", true, nil
+	})
 }
 
-func (action *MysqlDeleteBackupAction) Do(input interface{}) (interface{}, error) {
+func (action *MysqlDeleteBackupAction) Do(ctx context.Context, input interface{}) (interface{}, error) {
 	inputs, _ := input.(MysqlDeleteBackupInputs)
-	outputs := MysqlDeleteBackupOutputs{}
-	var finalErr error
-
-	for _, input := range inputs.Inputs {
+	outputs := MysqlDeleteBackupOutputs{Outputs: make([]MysqlDeleteBackupOutput, len(inputs.Inputs))}
+	finalErr := runInputs(ctx, len(inputs.Inputs), func(i int) []string {
+		return []string{inputs.Inputs[i].Guid, inputs.Inputs[i].MySqlId}
+	}, func(ctx context.Context, i int) error {
+		input := inputs.Inputs[i]
 		output := MysqlDeleteBackupOutput{
 			Guid: input.Guid,
 		}
 		output.CallBackParameter.Parameter = input.CallBackParameter.Parameter
 		output.Result.Code = RESULT_CODE_SUCCESS
 
-		if err := deleteMysqlBackup(&input); err != nil {
-			output.Result.Code = RESULT_CODE_ERROR
-			output.Result.Message = err.Error()
-			finalErr = err
+		if err := deleteMysqlBackup(ctx, &input); err != nil {
+			output.Result.SetError(err)
+			outputs.Outputs[i] = output
+			return err
 		}
-		outputs.Outputs = append(outputs.Outputs, output)
-	}
+		outputs.Outputs[i] = output
+		return nil
+	})
 	return outputs, finalErr
 }
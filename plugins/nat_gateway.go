@@ -1,10 +1,10 @@
 package plugins
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"strconv"
-	"time"
 
 	"github.com/sirupsen/logrus"
 	vpc "github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/vpc/v20170312"
@@ -30,6 +30,10 @@ func (plugin *NatGatewayPlugin) GetActionByName(actionName string) (Action, erro
 	return action, nil
 }
 
+func (plugin *NatGatewayPlugin) GetActions() map[string]Action {
+	return NatGatewayActions
+}
+
 type NatGatewayCreateAction struct {
 }
 
@@ -39,19 +43,20 @@ type NatGatewayInputs struct {
 
 type NatGatewayInput struct {
 	CallBackParameter
-	Guid            string `json:"guid,omitempty"`
-	ProviderParams  string `json:"provider_params,omitempty"`
-	Name            string `json:"name,omitempty"`
-	VpcId           string `json:"vpc_id,omitempty"`
-	MaxConcurrent   string `json:"max_concurrent,omitempty"`
-	BandWidth       string `json:"bandwidth,omitempty"`
+	WaitParameter
+	Guid            string `json:"guid,omitempty" register:"required"`
+	ProviderParams  string `json:"provider_params,omitempty" register:"optional"`
+	Name            string `json:"name,omitempty" register:"required,actions=create"`
+	VpcId           string `json:"vpc_id,omitempty" register:"required"`
+	MaxConcurrent   string `json:"max_concurrent,omitempty" register:"required,actions=create"`
+	BandWidth       string `json:"bandwidth,omitempty" register:"required,actions=create"`
 	AssignedEipSet  string `json:"assigned_eip_set,omitempty"`
 	AutoAllocEipNum int    `json:"auto_alloc_eip_num,omitempty"`
-	Id              string `json:"id,omitempty"`
+	Id              string `json:"id,omitempty" register:"required=terminate"`
 	Eip             string `json:"eip,omitempty"`
 	EipId           string `json:"eip_id,omitempty"`
-	Location        string `json:"location"`
-	APISecret       string `json:"api_secret"`
+	Location        string `json:"location" register:"required"`
+	APISecret       string `json:"api_secret" register:"required,system_variable=QCLOUD_API_SECRET"`
 }
 
 type NatGatewayOutputs struct {
@@ -62,13 +67,13 @@ type NatGatewayOutput struct {
 	CallBackParameter
 	Result
 	RequestId string `json:"request_id,omitempty"`
-	Guid      string `json:"guid,omitempty"`
-	Id        string `json:"id,omitempty"`
+	Guid      string `json:"guid,omitempty" register:"optional"`
+	Id        string `json:"id,omitempty" register:"optional,actions=create"`
 	Eip       string `json:"eip,omitempty"`
-	EipId     string `json:"eip_id,omitempty"`
+	EipId     string `json:"eip_id,omitempty" register:"context,actions=create"`
 }
 
-func (action *NatGatewayCreateAction) ReadParam(param interface{}) (interface{}, error) {
+func (action *NatGatewayCreateAction) ReadParam(ctx context.Context, param interface{}) (interface{}, error) {
 	var inputs NatGatewayInputs
 	err := UnmarshalJson(param, &inputs)
 	if err != nil {
@@ -97,25 +102,24 @@ func natGatewayCreateCheckParam(natGateway *NatGatewayInput) error {
 	return nil
 }
 
-func (action *NatGatewayCreateAction) createNatGateway(natGateway *NatGatewayInput) (output NatGatewayOutput, err error) {
+func (action *NatGatewayCreateAction) createNatGateway(ctx context.Context, natGateway *NatGatewayInput) (output NatGatewayOutput, err error) {
 	output.Guid = natGateway.Guid
 	output.CallBackParameter.Parameter = natGateway.CallBackParameter.Parameter
 	output.Result.Code = RESULT_CODE_SUCCESS
 
-	if natGateway.Location != "" && natGateway.APISecret != "" {
-		natGateway.ProviderParams = fmt.Sprintf("%s;%s", natGateway.Location, natGateway.APISecret)
-	}
-	paramsMap, _ := GetMapFromProviderParams(natGateway.ProviderParams)
-	client, _ := newVpcClient(paramsMap["Region"], paramsMap["SecretID"], paramsMap["SecretKey"])
-
 	defer func() {
 		if err != nil {
-			output.Result.Code = RESULT_CODE_ERROR
-			output.Result.Message = err.Error()
+			output.Result.SetError(err)
 		}
 	}()
 
-	if err = natGatewayCreateCheckParam(natGateway); err != nil {
+	params, err := NewProviderParams(natGateway.ProviderParams, natGateway.Location, natGateway.APISecret)
+	if err != nil {
+		return output, err
+	}
+	client, _ := newVpcClient(ctx, params)
+
+	if err = AsValidationError(natGatewayCreateCheckParam(natGateway)); err != nil {
 		return output, err
 	}
 
@@ -164,68 +168,57 @@ func (action *NatGatewayCreateAction) createNatGateway(natGateway *NatGatewayInp
 
 	output.RequestId = "legacy qcloud API doesn't support returnning request id"
 	output.Id = *createResp.NatGatewayId
+	recordResourceId(ctx, natGateway.Guid, output.Id)
 
 	// query eip info
 	req := vpc.NewDescribeAddressesRequest()
-	Client, err := CreateEIPClient(paramsMap["Region"], paramsMap["SecretID"], paramsMap["SecretKey"])
+	Client, err := CreateEIPClient(ctx, params)
 	if err != nil {
 		return output, err
 	}
 
-	count := 0
-	var queryEIPResponse *vpc.DescribeAddressesResponse
-	for {
-		if count > 20 {
-			return output, fmt.Errorf("after %vs, query nat eip info timeout", count*10)
-		}
-		queryEIPResponse, err = Client.DescribeAddresses(req)
+	waiter, err := NewWaiter(WAIT_RESOURCE_NAT_GATEWAY, output.Id, natGateway.WaitTimeout)
+	if err != nil {
+		return output, err
+	}
+	err = waiter.Wait(ctx, func() (string, bool, error) {
+		queryEIPResponse, err := Client.DescribeAddresses(req)
 		if err != nil {
-			err = fmt.Errorf("query eip info meet error : %s", err)
-			return output, err
-		}
-		if len(queryEIPResponse.Response.AddressSet) == 0 {
-			time.Sleep(10 * time.Second)
-			count++
-			continue
+			return "", false, fmt.Errorf("query eip info meet error : %s", err)
 		}
-		flag := false
 		for _, eip := range queryEIPResponse.Response.AddressSet {
-			if *eip.AddressStatus == "BIND" && *eip.InstanceId == output.Id {
+			if *eip.AddressStatus == "BIND" && eip.InstanceId != nil && *eip.InstanceId == output.Id {
 				output.Eip = *eip.AddressIp
 				output.EipId = *eip.AddressId
-				flag = true
-				break
+				return *eip.AddressStatus, true, nil
 			}
 		}
-		if flag {
-			break
-		}
-	}
+		return "", false, nil
+	})
 
 	return output, err
 }
 
-func (action *NatGatewayCreateAction) Do(input interface{}) (interface{}, error) {
+func (action *NatGatewayCreateAction) Do(ctx context.Context, input interface{}) (interface{}, error) {
 	natGateways, _ := input.(NatGatewayInputs)
-	outputs := NatGatewayOutputs{}
-	var finalErr error
-	for _, natGateway := range natGateways.Inputs {
-		output, err := action.createNatGateway(&natGateway)
-		if err != nil {
-			finalErr = err
-		}
-
-		outputs.Outputs = append(outputs.Outputs, output)
-	}
-
-	logrus.Infof("all natGateways = %v are created", natGateways)
+	outputs := NatGatewayOutputs{Outputs: make([]NatGatewayOutput, len(natGateways.Inputs))}
+	finalErr := runInputs(ctx, len(natGateways.Inputs), func(i int) []string {
+		return []string{natGateways.Inputs[i].Guid, natGateways.Inputs[i].Id}
+	}, func(ctx context.Context, i int) error {
+		natGateway := natGateways.Inputs[i]
+		output, err := action.createNatGateway(ctx, &natGateway)
+		outputs.Outputs[i] = output
+		return err
+	})
+
+	Logger(ctx).Infof("all natGateways = %s are created", Sanitize(natGateways))
 	return &outputs, finalErr
 }
 
 type NatGatewayTerminateAction struct {
 }
 
-func (action *NatGatewayTerminateAction) ReadParam(param interface{}) (interface{}, error) {
+func (action *NatGatewayTerminateAction) ReadParam(ctx context.Context, param interface{}) (interface{}, error) {
 	var input NatGatewayInputs
 	err := UnmarshalJson(param, &input)
 	if err != nil {
@@ -248,14 +241,17 @@ func natGatewayTerminateCheckParam(natGateway *NatGatewayInput) error {
 	return nil
 }
 
-func getNatGatewayEips(providerParams string, natGatewayId string) ([]*string, error) {
+func getNatGatewayEips(ctx context.Context, providerParams string, natGatewayId string) ([]*string, error) {
 	eips := []*string{}
 	if natGatewayId == "" {
 		return eips, fmt.Errorf("natGatewayId is empty")
 	}
 
-	paramsMap, err := GetMapFromProviderParams(providerParams)
-	client, _ := CreateVpcClient(paramsMap["Region"], paramsMap["SecretID"], paramsMap["SecretKey"])
+	params, err := ParseProviderParams(providerParams)
+	if err != nil {
+		return eips, err
+	}
+	client, _ := CreateVpcClient(ctx, params)
 
 	request := vpc.NewDescribeNatGatewaysRequest()
 	request.NatGatewayIds = []*string{&natGatewayId}
@@ -275,9 +271,12 @@ func getNatGatewayEips(providerParams string, natGatewayId string) ([]*string, e
 	return eips, nil
 }
 
-func deleteNatGatewayEips(providerParams string, eips []*string) error {
-	paramsMap, err := GetMapFromProviderParams(providerParams)
-	client, _ := CreateEIPClient(paramsMap["Region"], paramsMap["SecretID"], paramsMap["SecretKey"])
+func deleteNatGatewayEips(ctx context.Context, providerParams string, eips []*string) error {
+	params, err := ParseProviderParams(providerParams)
+	if err != nil {
+		return err
+	}
+	client, _ := CreateEIPClient(ctx, params)
 	request := vpc.NewReleaseAddressesRequest()
 	request.AddressIds = eips
 
@@ -285,26 +284,25 @@ func deleteNatGatewayEips(providerParams string, eips []*string) error {
 	return err
 }
 
-func (action *NatGatewayTerminateAction) terminateNatGateway(natGateway *NatGatewayInput) (output NatGatewayOutput, err error) {
+func (action *NatGatewayTerminateAction) terminateNatGateway(ctx context.Context, natGateway *NatGatewayInput) (output NatGatewayOutput, err error) {
 	var eips []*string
 	output.Guid = natGateway.Guid
 	output.Result.Code = RESULT_CODE_SUCCESS
 	output.CallBackParameter.Parameter = natGateway.CallBackParameter.Parameter
 
-	if natGateway.Location != "" && natGateway.APISecret != "" {
-		natGateway.ProviderParams = fmt.Sprintf("%s;%s", natGateway.Location, natGateway.APISecret)
-	}
-	paramsMap, _ := GetMapFromProviderParams(natGateway.ProviderParams)
-	client, _ := newVpcClient(paramsMap["Region"], paramsMap["SecretID"], paramsMap["SecretKey"])
-
 	defer func() {
 		if err != nil {
-			output.Result.Code = RESULT_CODE_ERROR
-			output.Result.Message = err.Error()
+			output.Result.SetError(err)
 		}
 	}()
 
-	if err = natGatewayTerminateCheckParam(natGateway); err != nil {
+	params, err := NewProviderParams(natGateway.ProviderParams, natGateway.Location, natGateway.APISecret)
+	if err != nil {
+		return output, err
+	}
+	client, _ := newVpcClient(ctx, params)
+
+	if err = AsValidationError(natGatewayTerminateCheckParam(natGateway)); err != nil {
 		return output, err
 	}
 
@@ -320,7 +318,7 @@ func (action *NatGatewayTerminateAction) terminateNatGateway(natGateway *NatGate
 		return output, nil
 	}
 
-	if eips, err = getNatGatewayEips(natGateway.ProviderParams, natGateway.Id); err != nil {
+	if eips, err = getNatGatewayEips(ctx, params.String(), natGateway.Id); err != nil {
 		return output, err
 	}
 
@@ -332,33 +330,11 @@ func (action *NatGatewayTerminateAction) terminateNatGateway(natGateway *NatGate
 		return output, err
 	}
 
-	taskReq := unversioned.NewDescribeVpcTaskResultRequest()
-	taskReq.TaskId = deleteResp.TaskId
-	count := 0
-	var taskResp *unversioned.DescribeVpcTaskResultResponse
-
-	for {
-		taskResp, err = client.DescribeVpcTaskResult(taskReq)
-		if err != nil {
-			return output, err
-		}
-
-		if *taskResp.Data.Status == 0 {
-			err = deleteNatGatewayEips(natGateway.ProviderParams, eips)
-			break
-		}
-		if *taskResp.Data.Status == 1 {
-			err = fmt.Errorf("terminateNatGateway execute failed, err = %v", *taskResp.Data.Output.ErrorMsg)
-			return output, err
-		}
-
-		time.Sleep(10 * time.Second)
-		count++
-		if count >= 20 {
-			err = fmt.Errorf("after %vs terminateNatGateway query result timeout", count*10)
-			return output, err
-		}
+	if err = waitVpcTaskResult(ctx, client, WAIT_RESOURCE_NAT_GATEWAY, deleteResp.TaskId, natGateway.WaitTimeout); err != nil {
+		err = fmt.Errorf("terminateNatGateway meet error = %v", err)
+		return output, err
 	}
+	err = deleteNatGatewayEips(ctx, params.String(), eips)
 
 	output.RequestId = "legacy qcloud API doesn't support returnning request id"
 	output.Id = natGateway.Id
@@ -366,17 +342,17 @@ func (action *NatGatewayTerminateAction) terminateNatGateway(natGateway *NatGate
 	return output, err
 }
 
-func (action *NatGatewayTerminateAction) Do(input interface{}) (interface{}, error) {
+func (action *NatGatewayTerminateAction) Do(ctx context.Context, input interface{}) (interface{}, error) {
 	natGateways, _ := input.(NatGatewayInputs)
-	outputs := NatGatewayOutputs{}
-	var finalErr error
-	for _, natGateway := range natGateways.Inputs {
-		output, err := action.terminateNatGateway(&natGateway)
-		if err != nil {
-			finalErr = err
-		}
-		outputs.Outputs = append(outputs.Outputs, output)
-	}
+	outputs := NatGatewayOutputs{Outputs: make([]NatGatewayOutput, len(natGateways.Inputs))}
+	finalErr := runInputs(ctx, len(natGateways.Inputs), func(i int) []string {
+		return []string{natGateways.Inputs[i].Guid, natGateways.Inputs[i].Id}
+	}, func(ctx context.Context, i int) error {
+		natGateway := natGateways.Inputs[i]
+		output, err := action.terminateNatGateway(ctx, &natGateway)
+		outputs.Outputs[i] = output
+		return err
+	})
 
 	return &outputs, finalErr
 }
@@ -0,0 +1,266 @@
+package plugins
+
+import (
+	"encoding/json"
+	"reflect"
+)
+
+const (
+	OPENAPI_VERSION = "3.0.3"
+	OPENAPI_TITLE   = "wecube-plugins-qcloud"
+
+	// OPENAPI_FORMAT_PASSWORD marks the sensitive fields, their values are masked in logs and responses.
+	OPENAPI_FORMAT_PASSWORD = "password"
+
+	OPENAPI_SCHEMA_PLUGIN_RESPONSE = "PluginResponse"
+	OPENAPI_SCHEMA_TASK_BRIEF      = "TaskBrief"
+	OPENAPI_PARAMETER_ASYNC        = "async"
+	OPENAPI_PARAMETER_DRY_RUN      = "dry_run"
+	OPENAPI_PARAMETER_CORRELATION  = "correlationId"
+	OPENAPI_RESPONSE_ERROR         = "Error"
+)
+
+type OpenApiDocument struct {
+	OpenApi    string                                  `json:"openapi"`
+	Info       OpenApiInfo                             `json:"info"`
+	Tags       []OpenApiTag                            `json:"tags"`
+	Paths      map[string]map[string]*OpenApiOperation `json:"paths"`
+	Components OpenApiComponents                       `json:"components"`
+}
+
+type OpenApiInfo struct {
+	Title       string `json:"title"`
+	Description string `json:"description,omitempty"`
+	Version     string `json:"version"`
+}
+
+type OpenApiTag struct {
+	Name string `json:"name"`
+}
+
+type OpenApiComponents struct {
+	Schemas    map[string]*OpenApiSchema    `json:"schemas"`
+	Parameters map[string]*OpenApiParameter `json:"parameters"`
+	Responses  map[string]*OpenApiResponse  `json:"responses"`
+}
+
+type OpenApiOperation struct {
+	OperationId string                      `json:"operationId"`
+	Tags        []string                    `json:"tags"`
+	Parameters  []*OpenApiParameter         `json:"parameters,omitempty"`
+	RequestBody *OpenApiRequestBody         `json:"requestBody,omitempty"`
+	Responses   map[string]*OpenApiResponse `json:"responses"`
+}
+
+// OpenApiParameter is a parameter, or a reference to one of the components if Ref is set.
+type OpenApiParameter struct {
+	Ref         string         `json:"$ref,omitempty"`
+	Name        string         `json:"name,omitempty"`
+	In          string         `json:"in,omitempty"`
+	Description string         `json:"description,omitempty"`
+	Schema      *OpenApiSchema `json:"schema,omitempty"`
+}
+
+type OpenApiRequestBody struct {
+	Required bool                         `json:"required"`
+	Content  map[string]*OpenApiMediaType `json:"content"`
+}
+
+type OpenApiMediaType struct {
+	Schema *OpenApiSchema `json:"schema"`
+}
+
+// OpenApiResponse is a response, or a reference to one of the components if Ref is set.
+type OpenApiResponse struct {
+	Ref         string                       `json:"$ref,omitempty"`
+	Description string                       `json:"description,omitempty"`
+	Headers     map[string]*OpenApiHeader    `json:"headers,omitempty"`
+	Content     map[string]*OpenApiMediaType `json:"content,omitempty"`
+}
+
+type OpenApiHeader struct {
+	Description string         `json:"description,omitempty"`
+	Schema      *OpenApiSchema `json:"schema"`
+}
+
+// OpenApiSchema is the schema of a value, or a reference to one of the components if Ref is set.
+type OpenApiSchema struct {
+	Ref         string                    `json:"$ref,omitempty"`
+	Type        string                    `json:"type,omitempty"`
+	Format      string                    `json:"format,omitempty"`
+	Description string                    `json:"description,omitempty"`
+	Enum        []string                  `json:"enum,omitempty"`
+	Items       *OpenApiSchema            `json:"items,omitempty"`
+	Properties  map[string]*OpenApiSchema `json:"properties,omitempty"`
+	AllOf       []*OpenApiSchema          `json:"allOf,omitempty"`
+	AnyOf       []*OpenApiSchema          `json:"anyOf,omitempty"`
+	Nullable    bool                      `json:"nullable,omitempty"`
+}
+
+// GetOpenApiDocument describes the actions of the registered plugins in OpenAPI 3, one POST operation of
+// "/[package name]/[version]/[plugin]/[action]" per action, with the fields of the catalog as the schemas.
+func GetOpenApiDocument() *OpenApiDocument {
+	response := getOpenApiObjectSchema(getFieldSchemas(reflect.TypeOf(PluginResponse{}), 0))
+	// the results are described by the operations, they are the outputs of the action or the task of async requests.
+	response.Properties["results"] = &OpenApiSchema{}
+
+	document := &OpenApiDocument{
+		OpenApi: OPENAPI_VERSION,
+		Info: OpenApiInfo{
+			Title:       OPENAPI_TITLE,
+			Description: "The actions of the plugins of Tencent Cloud, generated from their input and output structs.",
+			Version:     VERSION,
+		},
+		Tags:  []OpenApiTag{},
+		Paths: make(map[string]map[string]*OpenApiOperation),
+		Components: OpenApiComponents{
+			Schemas: map[string]*OpenApiSchema{
+				OPENAPI_SCHEMA_PLUGIN_RESPONSE: response,
+				OPENAPI_SCHEMA_TASK_BRIEF:      getOpenApiObjectSchema(getFieldSchemas(reflect.TypeOf(TaskBrief{}), 0)),
+			},
+			Parameters: getOpenApiParameters(),
+			Responses: map[string]*OpenApiResponse{
+				OPENAPI_RESPONSE_ERROR: {
+					Description: "the request is rejected before the action is run",
+					Content:     getJsonContent(getOpenApiRef("schemas", OPENAPI_SCHEMA_PLUGIN_RESPONSE)),
+				},
+			},
+		},
+	}
+
+	for _, plugin := range GetCatalog() {
+		document.Tags = append(document.Tags, OpenApiTag{Name: plugin.Name})
+		for _, action := range plugin.Actions {
+			path := "/" + PROVIDER_NAME + "/" + VERSION + "/" + plugin.Name + "/" + action.Name
+			document.Paths[path] = map[string]*OpenApiOperation{
+				"post": getOpenApiOperation(document, plugin.Name, action),
+			}
+		}
+	}
+	return document
+}
+
+// GetOpenApiJson returns the indented json of the document, which is served and shipped with the builds.
+func GetOpenApiJson() ([]byte, error) {
+	return json.MarshalIndent(GetOpenApiDocument(), "", "  ")
+}
+
+// getOpenApiOperation adds the schemas of the inputs and outputs of the action to the components, as
+// "{plugin}.{action}.Input" and "{plugin}.{action}.Output", and returns the operation which refers to them.
+func getOpenApiOperation(document *OpenApiDocument, pluginName string, action ActionCatalog) *OpenApiOperation {
+	operationId := pluginName + "." + action.Name
+	inputName := operationId + ".Input"
+	document.Components.Schemas[inputName] = getOpenApiObjectSchema(action.Inputs)
+
+	request := getOpenApiRef("schemas", inputName)
+	results := &OpenApiSchema{Description: "the results of the action"}
+	if action.listInputs {
+		outputName := operationId + ".Output"
+		document.Components.Schemas[outputName] = getOpenApiObjectSchema(action.Outputs)
+		request = &OpenApiSchema{
+			Type: "object",
+			Properties: map[string]*OpenApiSchema{
+				"inputs": {Type: "array", Items: getOpenApiRef("schemas", inputName)},
+			},
+		}
+		results = &OpenApiSchema{
+			Type: "object",
+			Properties: map[string]*OpenApiSchema{
+				"outputs": {Type: "array", Items: getOpenApiRef("schemas", outputName)},
+			},
+		}
+	}
+
+	return &OpenApiOperation{
+		OperationId: operationId,
+		Tags:        []string{pluginName},
+		Parameters: []*OpenApiParameter{
+			{Ref: "#/components/parameters/" + OPENAPI_PARAMETER_ASYNC},
+			{Ref: "#/components/parameters/" + OPENAPI_PARAMETER_DRY_RUN},
+			{Ref: "#/components/parameters/" + OPENAPI_PARAMETER_CORRELATION},
+		},
+		RequestBody: &OpenApiRequestBody{Required: true, Content: getJsonContent(request)},
+		Responses: map[string]*OpenApiResponse{
+			"200": {
+				Description: "the action is run, the errors of the inputs are in their outputs",
+				Headers: map[string]*OpenApiHeader{
+					CORRELATION_ID_HEADER: {Schema: &OpenApiSchema{Type: "string"}},
+				},
+				Content: getJsonContent(&OpenApiSchema{AllOf: []*OpenApiSchema{
+					getOpenApiRef("schemas", OPENAPI_SCHEMA_PLUGIN_RESPONSE),
+					{
+						Type: "object",
+						Properties: map[string]*OpenApiSchema{
+							"results": {
+								AnyOf:    []*OpenApiSchema{results, getOpenApiRef("schemas", OPENAPI_SCHEMA_TASK_BRIEF)},
+								Nullable: true,
+							},
+						},
+					},
+				}}),
+			},
+			"default": {Ref: "#/components/responses/" + OPENAPI_RESPONSE_ERROR},
+		},
+	}
+}
+
+func getOpenApiParameters() map[string]*OpenApiParameter {
+	return map[string]*OpenApiParameter{
+		OPENAPI_PARAMETER_ASYNC: {
+			Name:        "async",
+			In:          "query",
+			Description: "run the action as a task, the results are the task polled at /" + PROVIDER_NAME + "/" + VERSION + "/tasks/{taskId}",
+			Schema:      &OpenApiSchema{Type: "boolean"},
+		},
+		OPENAPI_PARAMETER_DRY_RUN: {
+			Name:        "dry_run",
+			In:          "query",
+			Description: "validate the inputs and return the plan of the cloud API calls without changing any resource",
+			Schema:      &OpenApiSchema{Type: "boolean"},
+		},
+		OPENAPI_PARAMETER_CORRELATION: {
+			Name:        CORRELATION_ID_HEADER,
+			In:          "header",
+			Description: "the correlation id of the logs of the request, one is generated if it is empty",
+			Schema:      &OpenApiSchema{Type: "string"},
+		},
+	}
+}
+
+func getOpenApiRef(component, name string) *OpenApiSchema {
+	return &OpenApiSchema{Ref: "#/components/" + component + "/" + name}
+}
+
+func getJsonContent(schema *OpenApiSchema) map[string]*OpenApiMediaType {
+	return map[string]*OpenApiMediaType{"application/json": {Schema: schema}}
+}
+
+func getOpenApiObjectSchema(fields []FieldSchema) *OpenApiSchema {
+	schema := &OpenApiSchema{Type: "object", Properties: make(map[string]*OpenApiSchema)}
+	for _, field := range fields {
+		schema.Properties[field.Name] = getOpenApiSchema(field)
+	}
+	return schema
+}
+
+func getOpenApiSchema(field FieldSchema) *OpenApiSchema {
+	if field.Type == "object" {
+		return getOpenApiObjectSchema(field.Fields)
+	}
+	schema := &OpenApiSchema{Type: field.Type, Enum: field.Enum}
+	if field.Sensitive {
+		schema.Format = OPENAPI_FORMAT_PASSWORD
+	}
+	if field.Type == "array" {
+		switch field.Items {
+		case "object":
+			schema.Items = getOpenApiObjectSchema(field.Fields)
+		case "array":
+			// the items of nested arrays are not described by the catalog.
+			schema.Items = &OpenApiSchema{Type: "array", Items: &OpenApiSchema{}}
+		default:
+			schema.Items = &OpenApiSchema{Type: field.Items}
+		}
+	}
+	return schema
+}
@@ -0,0 +1,97 @@
+package plugins
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestGetOpenApiDocument(t *testing.T) {
+	document := GetOpenApiDocument()
+	if document.OpenApi != OPENAPI_VERSION || document.Info.Version != VERSION {
+		t.Fatalf("openapi=%v, info=%+v", document.OpenApi, document.Info)
+	}
+
+	operation := document.Paths["/qcloud/v1/vm/create"]["post"]
+	if operation == nil || operation.OperationId != "vm.create" || operation.RequestBody == nil || operation.Responses["200"] == nil {
+		t.Fatalf("vm create, operation=%+v", operation)
+	}
+	input := document.Components.Schemas["vm.create.Input"]
+	if input == nil || document.Components.Schemas["vm.create.Output"] == nil {
+		t.Fatalf("schemas of vm create are not in the components")
+	}
+	if chargeType := input.Properties["instance_charge_type"]; chargeType == nil || !reflect.DeepEqual(chargeType.Enum, chargeTypes) {
+		t.Errorf("instance_charge_type=%+v", chargeType)
+	}
+	if providerParams := input.Properties["provider_params"]; providerParams == nil || providerParams.Format != OPENAPI_FORMAT_PASSWORD {
+		t.Errorf("provider_params=%+v", providerParams)
+	}
+
+	enums := map[string][]string{
+		"clb.create.Input.type":                             lbTypes,
+		"clb-target.add-backtarget.Input.protocol":          clbProtocols,
+		"route-policy.create.Input.gateway_type":            routeGatewayTypes,
+		"mysql.create.Input.charge_type":                    chargeTypes,
+		"mysql.create.Input.character_set":                  mysqlCharsets,
+		"redis.create.Input.billing_mode":                   chargeTypes,
+		"mariadb.create.Input.db_version":                   mariadbVersions,
+		"cbs.create-mount.Input.file_system_type":           fileSystemTypes,
+		"security-policy.create-policies.Input.policy_type": securityPolicyTypes,
+	}
+	for name, enum := range enums {
+		i := strings.LastIndex(name, ".")
+		schema := document.Components.Schemas[name[:i]]
+		if schema == nil || schema.Properties[name[i+1:]] == nil || !reflect.DeepEqual(schema.Properties[name[i+1:]].Enum, enum) {
+			t.Errorf("enum of %v, schema=%+v", name, schema)
+		}
+	}
+
+	// all the references are to the components of the document.
+	b, err := json.Marshal(document)
+	if err != nil {
+		t.Fatalf("marshal the document meet error=%v", err)
+	}
+	components := map[string]map[string]bool{"schemas": {}, "parameters": {}, "responses": {}}
+	for name := range document.Components.Schemas {
+		components["schemas"][name] = true
+	}
+	for name := range document.Components.Parameters {
+		components["parameters"][name] = true
+	}
+	for name := range document.Components.Responses {
+		components["responses"][name] = true
+	}
+	for _, ref := range strings.Split(string(b), `"$ref":"`)[1:] {
+		path := strings.Split(strings.TrimPrefix(ref[:strings.Index(ref, `"`)], "#/components/"), "/")
+		if len(path) != 2 || !components[path[0]][path[1]] {
+			t.Errorf("reference %v is not found", ref[:strings.Index(ref, `"`)])
+		}
+	}
+}
+
+func TestGetOpenApiSchema(t *testing.T) {
+	fields := []FieldSchema{
+		{Name: "rules", Type: "array", Items: "object", Fields: []FieldSchema{{Name: "port", Type: "integer"}}},
+		{Name: "tags", Type: "array", Items: "string"},
+		{Name: "matrix", Type: "array", Items: "array"},
+		{Name: "password", Type: "string", Sensitive: true},
+		{Name: "protocol", Type: "string", Enum: clbProtocols},
+	}
+	schema := getOpenApiObjectSchema(fields)
+	if rules := schema.Properties["rules"]; rules.Type != "array" || rules.Items.Type != "object" || rules.Items.Properties["port"].Type != "integer" {
+		t.Errorf("rules=%+v", rules)
+	}
+	if tags := schema.Properties["tags"]; tags.Type != "array" || tags.Items.Type != "string" {
+		t.Errorf("tags=%+v", tags)
+	}
+	if matrix := schema.Properties["matrix"]; matrix.Items.Type != "array" || matrix.Items.Items == nil {
+		t.Errorf("matrix=%+v", matrix)
+	}
+	if password := schema.Properties["password"]; password.Format != OPENAPI_FORMAT_PASSWORD {
+		t.Errorf("password=%+v", password)
+	}
+	if protocol := schema.Properties["protocol"]; !reflect.DeepEqual(protocol.Enum, []string{"TCP", "UDP"}) {
+		t.Errorf("protocol=%+v", protocol)
+	}
+}
@@ -1,20 +1,17 @@
 package plugins
 
 import (
+	"context"
 	"errors"
 	"fmt"
-	"time"
+	"strconv"
 
 	vpcExtend "github.com/WeBankPartners/wecube-plugins-qcloud/extend/qcloud"
 	"github.com/sirupsen/logrus"
 )
 
-func newVpcPeeringConnectionClient(region, secretId, secretKey string) (*vpcExtend.Client, error) {
-	return vpcExtend.NewClientWithSecretId(
-		secretId,
-		secretKey,
-		region,
-	)
+func newVpcPeeringConnectionClient(ctx context.Context, params *ProviderParams) (*vpcExtend.Client, error) {
+	return GetClientFactory().WithContext(ctx).WithProviderParams(params).NewVpcPeeringConnectionClient(params.Region, params.SecretID, params.SecretKey)
 }
 
 var PeeringConnectionActions = make(map[string]Action)
@@ -33,18 +30,19 @@ type PeeringConnectionInputs struct {
 
 type PeeringConnectionInput struct {
 	CallBackParameter
-	Guid               string `json:"guid,omitempty"`
-	ProviderParams     string `json:"provider_params,omitempty"`
-	Name               string `json:"name,omitempty"`
-	PeerProviderParams string `json:"peer_provider_params,omitempty"`
-	VpcId              string `json:"vpc_id,omitempty"`
-	PeerVpcId          string `json:"peer_vpc_id,omitempty"`
-	PeerUin            string `json:"peer_uin,omitempty"`
-	Bandwidth          string `json:"bandwidth,omitempty"`
-	Id                 string `json:"id,omitempty"`
-	Location           string `json:"location"`
-	APISecret          string `json:"api_secret"`
-	PeerLocation       string `json:"peer_location"`
+	WaitParameter
+	Guid               string `json:"guid,omitempty" register:"required"`
+	ProviderParams     string `json:"provider_params,omitempty" register:"optional"`
+	Name               string `json:"name,omitempty" register:"optional,actions=create"`
+	PeerProviderParams string `json:"peer_provider_params,omitempty" register:"optional"`
+	VpcId              string `json:"vpc_id,omitempty" register:"required,actions=create"`
+	PeerVpcId          string `json:"peer_vpc_id,omitempty" register:"required,actions=create"`
+	PeerUin            string `json:"peer_uin,omitempty" register:"required,actions=create"`
+	Bandwidth          string `json:"bandwidth,omitempty" register:"required,actions=create"`
+	Id                 string `json:"id,omitempty" register:"required=terminate"`
+	Location           string `json:"location" register:"required"`
+	APISecret          string `json:"api_secret" register:"required,system_variable=QCLOUD_API_SECRET"`
+	PeerLocation       string `json:"peer_location" register:"required"`
 	// PeerAPISecret      string `json:"peer_api_secret"`
 }
 
@@ -56,8 +54,8 @@ type PeeringConnectionOutput struct {
 	CallBackParameter
 	Result
 	RequestId string `json:"request_id,omitempty"`
-	Guid      string `json:"guid,omitempty"`
-	Id        string `json:"id,omitempty"`
+	Guid      string `json:"guid,omitempty" register:"optional"`
+	Id        string `json:"id,omitempty" register:"optional,actions=create"`
 }
 
 func (plugin *PeeringConnectionPlugin) GetActionByName(actionName string) (Action, error) {
@@ -69,10 +67,14 @@ func (plugin *PeeringConnectionPlugin) GetActionByName(actionName string) (Actio
 	return action, nil
 }
 
+func (plugin *PeeringConnectionPlugin) GetActions() map[string]Action {
+	return PeeringConnectionActions
+}
+
 type PeeringConnectionCreateAction struct {
 }
 
-func (action *PeeringConnectionCreateAction) ReadParam(param interface{}) (interface{}, error) {
+func (action *PeeringConnectionCreateAction) ReadParam(ctx context.Context, param interface{}) (interface{}, error) {
 	var inputs PeeringConnectionInputs
 	err := UnmarshalJson(param, &inputs)
 	if err != nil {
@@ -100,7 +102,7 @@ func peeringConnectionCreateCheckParam(peeringConnection PeeringConnectionInput)
 	return nil
 }
 
-func (action *PeeringConnectionCreateAction) createPeeringConnectionAtSameRegion(client *vpcExtend.Client, peeringConnection PeeringConnectionInput, paramsMap map[string]string) (string, error) {
+func (action *PeeringConnectionCreateAction) createPeeringConnectionAtSameRegion(client *vpcExtend.Client, peeringConnection PeeringConnectionInput) (string, error) {
 	createReq := vpcExtend.NewCreateVpcPeeringConnectionRequest()
 	createReq.VpcId = &peeringConnection.VpcId
 	createReq.PeerVpcId = &peeringConnection.PeerVpcId
@@ -113,58 +115,38 @@ func (action *PeeringConnectionCreateAction) createPeeringConnectionAtSameRegion
 	}
 	return *createResp.PeeringConnectionId, nil
 }
-func (action *PeeringConnectionCreateAction) createPeeringConnectionCrossRegion(client *vpcExtend.Client, peeringConnection PeeringConnectionInput, paramsMap map[string]string) (string, error) {
+func (action *PeeringConnectionCreateAction) createPeeringConnectionCrossRegion(ctx context.Context, client *vpcExtend.Client, peeringConnection PeeringConnectionInput, peerParams *ProviderParams) (string, error) {
 	createReq := vpcExtend.NewCreateVpcPeeringConnectionExRequest()
 	createReq.VpcId = &peeringConnection.VpcId
 	createReq.PeerVpcId = &peeringConnection.PeerVpcId
 	createReq.PeeringConnectionName = &peeringConnection.Name
 	createReq.PeerUin = &peeringConnection.PeerUin
-	region := paramsMap["Region"]
-	createReq.PeerRegion = &region
+	createReq.PeerRegion = &peerParams.Region
 	createReq.Bandwidth = &peeringConnection.Bandwidth
 
 	createResp, err := client.CreateVpcPeeringConnectionEx(createReq)
 	if err != nil {
 		return "", err
 	}
-	logrus.Infof("createPeeringConnection is completed, UniqVpcPeerId = %v", createResp.UniqVpcPeerId)
+	Logger(ctx).Infof("createPeeringConnection is completed, UniqVpcPeerId = %v", createResp.UniqVpcPeerId)
 
-	taskReq := vpcExtend.NewDescribeVpcTaskResultRequest()
-	taskReq.TaskId = createResp.TaskId
-	count := 0
-	for {
-		taskResp, err := client.DescribeVpcTaskResult(taskReq)
-		if err != nil {
-			return "", err
-		}
-
-		if *taskResp.Data.Status == 0 {
-			return *taskResp.Data.Output.UniqVpcPeerId, nil
-		}
-		if *taskResp.Data.Status == 1 {
-			return "", errors.New("createPeeringConnection execute failed ,need retry")
-		}
-
-		time.Sleep(10 * time.Second)
-		count++
-		if count >= 20 {
-			return "", errors.New("createPeeringConnection query result timeout")
-		}
+	taskResp, err := waitPeeringConnectionTask(ctx, client, createResp.TaskId, peeringConnection.WaitTimeout)
+	if err != nil {
+		return "", fmt.Errorf("createPeeringConnection meet error = %v", err)
 	}
-
-	return "", nil
+	return *taskResp.Data.Output.UniqVpcPeerId, nil
 }
 
-func (action *PeeringConnectionCreateAction) createPeeringConnection(peeringConnection PeeringConnectionInput) (string, error) {
-	if peeringConnection.Location != "" && peeringConnection.APISecret != "" {
-		peeringConnection.ProviderParams = fmt.Sprintf("%s;%s", peeringConnection.Location, peeringConnection.APISecret)
+func (action *PeeringConnectionCreateAction) createPeeringConnection(ctx context.Context, peeringConnection PeeringConnectionInput) (string, error) {
+	params, err := NewProviderParams(peeringConnection.ProviderParams, peeringConnection.Location, peeringConnection.APISecret)
+	if err != nil {
+		return "", err
 	}
-	paramsMap, _ := GetMapFromProviderParams(peeringConnection.ProviderParams)
-	if peeringConnection.PeerProviderParams == "" {
-		peeringConnection.PeerProviderParams = peeringConnection.PeerLocation
+	peerParams, err := NewProviderParams(peeringConnection.PeerProviderParams, peeringConnection.PeerLocation, "")
+	if err != nil {
+		return "", err
 	}
-	peerParamsMap, _ := GetMapFromProviderParams(peeringConnection.PeerProviderParams)
-	client, _ := newVpcPeeringConnectionClient(paramsMap["Region"], paramsMap["SecretID"], paramsMap["SecretKey"])
+	client, _ := newVpcPeeringConnectionClient(ctx, params)
 
 	//check resource exist
 	if peeringConnection.Id != "" {
@@ -178,55 +160,53 @@ func (action *PeeringConnectionCreateAction) createPeeringConnection(peeringConn
 		}
 	}
 
-	if paramsMap["Region"] == peerParamsMap["Region"] {
-		return action.createPeeringConnectionAtSameRegion(client, peeringConnection, paramsMap)
+	if params.Region == peerParams.Region {
+		return action.createPeeringConnectionAtSameRegion(client, peeringConnection)
 	} else {
-		return action.createPeeringConnectionCrossRegion(client, peeringConnection, peerParamsMap)
+		return action.createPeeringConnectionCrossRegion(ctx, client, peeringConnection, peerParams)
 	}
 }
 
-func (action *PeeringConnectionCreateAction) Do(input interface{}) (interface{}, error) {
+func (action *PeeringConnectionCreateAction) Do(ctx context.Context, input interface{}) (interface{}, error) {
 	peeringConnections, _ := input.(PeeringConnectionInputs)
-	outputs := PeeringConnectionOutputs{}
-	var finalErr error
-
-	for _, peeringConnection := range peeringConnections.Inputs {
+	outputs := PeeringConnectionOutputs{Outputs: make([]PeeringConnectionOutput, len(peeringConnections.Inputs))}
+	finalErr := runInputs(ctx, len(peeringConnections.Inputs), func(i int) []string {
+		return []string{peeringConnections.Inputs[i].Guid, peeringConnections.Inputs[i].Id}
+	}, func(ctx context.Context, i int) error {
+		peeringConnection := peeringConnections.Inputs[i]
 		output := PeeringConnectionOutput{
 			Guid: peeringConnection.Guid,
 		}
 		output.Result.Code = RESULT_CODE_SUCCESS
 		output.CallBackParameter.Parameter = peeringConnection.CallBackParameter.Parameter
 
-		if err := peeringConnectionCreateCheckParam(peeringConnection); err != nil {
-			finalErr = err
-			output.Result.Code = RESULT_CODE_ERROR
-			output.Result.Message = err.Error()
-			outputs.Outputs = append(outputs.Outputs, output)
-			continue
+		if err := AsValidationError(peeringConnectionCreateCheckParam(peeringConnection)); err != nil {
+			output.Result.SetError(err)
+			outputs.Outputs[i] = output
+			return err
 		}
 
-		peeringConnectionId, err := action.createPeeringConnection(peeringConnection)
+		peeringConnectionId, err := action.createPeeringConnection(ctx, peeringConnection)
 		if err != nil {
-			finalErr = err
-			output.Result.Code = RESULT_CODE_ERROR
-			output.Result.Message = err.Error()
-			outputs.Outputs = append(outputs.Outputs, output)
-			continue
+			output.Result.SetError(err)
+			outputs.Outputs[i] = output
+			return err
 		}
 
 		output.Id = peeringConnectionId
 		output.RequestId = "legacy qcloud API doesn't support returnning request id"
-		outputs.Outputs = append(outputs.Outputs, output)
-	}
+		outputs.Outputs[i] = output
+		return nil
+	})
 
-	logrus.Infof("all PeeringConnections = %v are created", peeringConnections)
+	Logger(ctx).Infof("all PeeringConnections = %s are created", Sanitize(peeringConnections))
 	return &outputs, finalErr
 }
 
 type PeeringConnectionTerminateAction struct {
 }
 
-func (action *PeeringConnectionTerminateAction) ReadParam(param interface{}) (interface{}, error) {
+func (action *PeeringConnectionTerminateAction) ReadParam(ctx context.Context, param interface{}) (interface{}, error) {
 	var inputs PeeringConnectionInputs
 	err := UnmarshalJson(param, &inputs)
 	if err != nil {
@@ -268,7 +248,7 @@ func (action *PeeringConnectionTerminateAction) deletePeeringConnectionAtSameReg
 	return nil
 }
 
-func (action *PeeringConnectionTerminateAction) deletePeeringConnectionCrossRegion(client *vpcExtend.Client, peeringConnection PeeringConnectionInput) error {
+func (action *PeeringConnectionTerminateAction) deletePeeringConnectionCrossRegion(ctx context.Context, client *vpcExtend.Client, peeringConnection PeeringConnectionInput) error {
 	request := vpcExtend.NewDeleteVpcPeeringConnectionExRequest()
 	request.PeeringConnectionId = &peeringConnection.Id
 	response, err := client.DeletePeeringConnectionEx(request)
@@ -276,95 +256,75 @@ func (action *PeeringConnectionTerminateAction) deletePeeringConnectionCrossRegi
 		return fmt.Errorf("terminate peering connection(id = %v) in cloud meet error = %v", peeringConnection.Id, err)
 	}
 
-	taskReq := vpcExtend.NewDescribeVpcTaskResultRequest()
-	taskReq.TaskId = response.TaskId
-	count := 0
-	for {
-		taskResp, err := client.DescribeVpcTaskResult(taskReq)
-		if err != nil {
-			return err
-		}
-
-		if *taskResp.Data.Status == 0 {
-			return nil
-		}
-		if *taskResp.Data.Status == 1 {
-			return errors.New("terminatePeeringConnection execute failed ,need retry")
-		}
-
-		time.Sleep(10 * time.Second)
-		count++
-		if count >= 20 {
-			return errors.New("terminatePeeringConnection query result timeout")
-		}
+	if _, err = waitPeeringConnectionTask(ctx, client, response.TaskId, peeringConnection.WaitTimeout); err != nil {
+		return fmt.Errorf("terminatePeeringConnection meet error = %v", err)
 	}
 
-	logrus.Infof("terminate peering connection task id = %v", response.TaskId)
+	Logger(ctx).Infof("terminate peering connection task id = %v", *response.TaskId)
 	return nil
 }
 
-func (action *PeeringConnectionTerminateAction) terminatePeeringConnection(peeringConnection PeeringConnectionInput) error {
-	if peeringConnection.Location != "" && peeringConnection.APISecret != "" {
-		peeringConnection.ProviderParams = fmt.Sprintf("%s;%s", peeringConnection.Location, peeringConnection.APISecret)
+func (action *PeeringConnectionTerminateAction) terminatePeeringConnection(ctx context.Context, peeringConnection PeeringConnectionInput) error {
+	params, err := NewProviderParams(peeringConnection.ProviderParams, peeringConnection.Location, peeringConnection.APISecret)
+	if err != nil {
+		return err
 	}
-	paramsMap, _ := GetMapFromProviderParams(peeringConnection.ProviderParams)
-	if peeringConnection.PeerProviderParams == "" {
-		peeringConnection.PeerProviderParams = peeringConnection.PeerLocation
+	peerParams, err := NewProviderParams(peeringConnection.PeerProviderParams, peeringConnection.PeerLocation, "")
+	if err != nil {
+		return err
 	}
-	peerParamsMap, _ := GetMapFromProviderParams(peeringConnection.PeerProviderParams)
-	client, _ := newVpcPeeringConnectionClient(paramsMap["Region"], paramsMap["SecretID"], paramsMap["SecretKey"])
+	client, _ := newVpcPeeringConnectionClient(ctx, params)
 
 	// check resource exist.
 	PeeringConnectionId, err := queryPeeringConnectionsInfo(client, peeringConnection)
 	if err != nil {
-		logrus.Errorf("queryPeeringConnectionsInfo meet error=%v", err)
+		Logger(ctx).Errorf("queryPeeringConnectionsInfo meet error=%v", err)
 		return err
 	}
 
 	if PeeringConnectionId == "" {
-		logrus.Infof("the PeeringConnection[%v] is not exist.", peeringConnection.Id)
+		Logger(ctx).Infof("the PeeringConnection[%v] is not exist.", peeringConnection.Id)
 		return nil
 	}
 
-	if paramsMap["Region"] == peerParamsMap["Region"] {
+	if params.Region == peerParams.Region {
 		return action.deletePeeringConnectionAtSameRegion(client, peeringConnection)
 	} else {
-		return action.deletePeeringConnectionCrossRegion(client, peeringConnection)
+		return action.deletePeeringConnectionCrossRegion(ctx, client, peeringConnection)
 	}
 }
 
-func (action *PeeringConnectionTerminateAction) Do(input interface{}) (interface{}, error) {
+func (action *PeeringConnectionTerminateAction) Do(ctx context.Context, input interface{}) (interface{}, error) {
 	peeringConnections, _ := input.(PeeringConnectionInputs)
-	outputs := PeeringConnectionOutputs{}
-	var finalErr error
-	for _, peeringConnection := range peeringConnections.Inputs {
+	outputs := PeeringConnectionOutputs{Outputs: make([]PeeringConnectionOutput, len(peeringConnections.Inputs))}
+	finalErr := runInputs(ctx, len(peeringConnections.Inputs), func(i int) []string {
+		return []string{peeringConnections.Inputs[i].Guid, peeringConnections.Inputs[i].Id}
+	}, func(ctx context.Context, i int) error {
+		peeringConnection := peeringConnections.Inputs[i]
 		output := PeeringConnectionOutput{
 			Guid: peeringConnection.Guid,
 		}
 		output.Result.Code = RESULT_CODE_SUCCESS
 		output.CallBackParameter.Parameter = peeringConnection.CallBackParameter.Parameter
 
-		if err := peeringConnectionTerminateCheckParam(&peeringConnection); err != nil {
-			finalErr = err
-			output.Result.Code = RESULT_CODE_ERROR
-			output.Result.Message = err.Error()
-			outputs.Outputs = append(outputs.Outputs, output)
-			continue
+		if err := AsValidationError(peeringConnectionTerminateCheckParam(&peeringConnection)); err != nil {
+			output.Result.SetError(err)
+			outputs.Outputs[i] = output
+			return err
 		}
 
-		err := action.terminatePeeringConnection(peeringConnection)
+		err := action.terminatePeeringConnection(ctx, peeringConnection)
 		if err != nil {
-			finalErr = err
-			output.Result.Code = RESULT_CODE_ERROR
-			output.Result.Message = err.Error()
-			outputs.Outputs = append(outputs.Outputs, output)
-			continue
+			output.Result.SetError(err)
+			outputs.Outputs[i] = output
+			return err
 		}
 
 		output.RequestId = "legacy qcloud API doesn't support returnning request id"
 		output.Id = peeringConnection.Id
-		outputs.Outputs = append(outputs.Outputs, output)
-	}
+		outputs.Outputs[i] = output
+		return nil
+	})
 
 	return &outputs, finalErr
 }
@@ -389,3 +349,30 @@ func queryPeeringConnectionsInfo(client *vpcExtend.Client, input PeeringConnecti
 
 	return input.Id, nil
 }
+
+// waitPeeringConnectionTask waits for the async task of peering connection, the failed task needs retry.
+func waitPeeringConnectionTask(ctx context.Context, client *vpcExtend.Client, taskId *int, timeout string) (*vpcExtend.DescribeVpcTaskResultResponse, error) {
+	if taskId == nil {
+		return nil, errors.New("the task id of peering connection is empty")
+	}
+	waiter, err := NewWaiter(WAIT_RESOURCE_PEERING_CONNECTION, fmt.Sprintf("task-%d", *taskId), timeout)
+	if err != nil {
+		return nil, err
+	}
+
+	request := vpcExtend.NewDescribeVpcTaskResultRequest()
+	request.TaskId = taskId
+	var response *vpcExtend.DescribeVpcTaskResultResponse
+	err = waiter.Wait(ctx, func() (string, bool, error) {
+		response, err = client.DescribeVpcTaskResult(request)
+		if err != nil {
+			return "", false, err
+		}
+		status := *response.Data.Status
+		if status == VPC_TASK_STATUS_FAILED {
+			return "", false, fmt.Errorf("task(%d) execute failed ,need retry", *taskId)
+		}
+		return strconv.Itoa(status), status == VPC_TASK_STATUS_SUCCESS, nil
+	})
+	return response, err
+}
@@ -1,8 +1,11 @@
 package plugins
 
 import (
+	"context"
 	"fmt"
+	"reflect"
 	"sync"
+	"time"
 
 	"github.com/sirupsen/logrus"
 )
@@ -19,12 +22,59 @@ var (
 
 type Plugin interface {
 	GetActionByName(actionName string) (Action, error)
+	// GetActions returns the actions of the plugin by name, they are listed by the catalog.
+	GetActions() map[string]Action
 }
 
 type Action interface {
-	ReadParam(param interface{}) (interface{}, error)
+	ReadParam(ctx context.Context, param interface{}) (interface{}, error)
 	//CheckParam(param interface{}) error
-	Do(param interface{}) (interface{}, error)
+	Do(ctx context.Context, param interface{}) (interface{}, error)
+}
+
+// ActionTimeoutPolicy is the server side deadline of actions, zero means no deadline.
+type ActionTimeoutPolicy struct {
+	Timeout time.Duration
+	// Timeouts overrides the timeout of actions, the key is "{plugin}.{action}" such as "vm.create".
+	Timeouts map[string]time.Duration
+}
+
+var (
+	actionTimeoutPolicyMutex   sync.RWMutex
+	DefaultActionTimeoutPolicy = &ActionTimeoutPolicy{}
+)
+
+// SetActionTimeoutPolicy replaces DefaultActionTimeoutPolicy, actions started afterwards use the new policy,
+// nil restores the default one.
+func SetActionTimeoutPolicy(policy *ActionTimeoutPolicy) {
+	if policy == nil {
+		policy = &ActionTimeoutPolicy{}
+	}
+	actionTimeoutPolicyMutex.Lock()
+	DefaultActionTimeoutPolicy = policy
+	actionTimeoutPolicyMutex.Unlock()
+}
+
+func getActionTimeoutPolicy() *ActionTimeoutPolicy {
+	actionTimeoutPolicyMutex.RLock()
+	defer actionTimeoutPolicyMutex.RUnlock()
+
+	return DefaultActionTimeoutPolicy
+}
+
+func (policy *ActionTimeoutPolicy) GetTimeout(pluginName, actionName string) time.Duration {
+	if timeout, found := policy.Timeouts[pluginName+"."+actionName]; found && timeout > 0 {
+		return timeout
+	}
+	return policy.Timeout
+}
+
+// WithTimeout returns the context of the action, which is done when ctx is done or the timeout of the action expires.
+func (policy *ActionTimeoutPolicy) WithTimeout(ctx context.Context, pluginName, actionName string) (context.Context, context.CancelFunc) {
+	if timeout := policy.GetTimeout(pluginName, actionName); timeout > 0 {
+		return context.WithTimeout(ctx, timeout)
+	}
+	return context.WithCancel(ctx)
 }
 
 func RegisterPlugin(name string, plugin Plugin) {
@@ -36,6 +86,7 @@ func RegisterPlugin(name string, plugin Plugin) {
 	}
 
 	plugins[name] = plugin
+	resetSensitiveFields()
 }
 
 func getPluginByName(name string) (Plugin, error) {
@@ -43,11 +94,24 @@ func getPluginByName(name string) (Plugin, error) {
 	defer pluginsMutex.Unlock()
 	plugin, found := plugins[name]
 	if !found {
-		return nil, fmt.Errorf("plugin[%s] not found", name)
+		return nil, newPluginError(ERROR_CATEGORY_NOT_FOUND, ERROR_CODE_PLUGIN_NOT_FOUND, false, "plugin[%s] not found", name)
 	}
 	return plugin, nil
 }
 
+// GetAction returns the action of the plugin, the error is in the not found category if either of them is not registered.
+func GetAction(pluginName, actionName string) (Action, error) {
+	plugin, err := getPluginByName(pluginName)
+	if err != nil {
+		return nil, err
+	}
+	action, err := plugin.GetActionByName(actionName)
+	if err != nil {
+		return nil, newPluginError(ERROR_CATEGORY_NOT_FOUND, ERROR_CODE_ACTION_NOT_FOUND, false, "%s", err.Error())
+	}
+	return action, nil
+}
+
 func init() {
 	RegisterPlugin("vm", new(VmPlugin))
 	RegisterPlugin("storage", new(StoragePlugin))
@@ -78,59 +142,176 @@ type PluginRequest struct {
 	Name         string
 	Action       string
 	Parameters   interface{}
+	Async        bool
+	// DryRun validates the inputs and looks up resources without changing any, it is never run async.
+	DryRun bool
 }
 
 type PluginResponse struct {
 	ResultCode string      `json:"resultCode"`
 	ResultMsg  string      `json:"resultMessage"`
 	Results    interface{} `json:"results"`
+	// ErrorCategory, ErrorReason and Retryable classify the error of the request, same as the result of outputs.
+	ErrorCategory string `json:"errorCategory,omitempty"`
+	ErrorReason   string `json:"errorReason,omitempty"`
+	Retryable     bool   `json:"retryable,omitempty"`
+	// Plan is the cloud API calls the action would make, it is only returned in dry run mode.
+	Plan *DryRunPlan `json:"plan,omitempty"`
+	// CorrelationId is in the log entries of the request, paired with the RequestIds of the cloud API requests.
+	CorrelationId string `json:"correlationId,omitempty"`
 }
 
-func Process(pluginRequest *PluginRequest) (*PluginResponse, error) {
+// Process runs the action of the request, the action is canceled when ctx is done.
+// Async actions are not canceled with ctx, they only stop at the timeout of the action.
+// A correlation id is generated if ctx has none, it is returned in the response and logged with every
+// RequestId of the cloud APIs, which are listed in the output of the input which sent them.
+func Process(ctx context.Context, pluginRequest *PluginRequest) (*PluginResponse, error) {
 	var pluginResponse = PluginResponse{}
 	var err error
+	start := time.Now()
+	if GetCorrelationId(ctx) == "" {
+		ctx = ContextWithCorrelationId(ctx, "")
+	}
+	pluginResponse.CorrelationId = GetCorrelationId(ctx)
+	ctx, span := StartSpan(ctx, pluginRequest.Name+"."+pluginRequest.Action, SPAN_KIND_SERVER)
+	span.SetAttribute("plugin.name", pluginRequest.Name)
+	span.SetAttribute("plugin.action", pluginRequest.Action)
+	span.SetAttribute("plugin.dry_run", pluginRequest.DryRun)
+	span.SetAttribute("plugin.async", pluginRequest.Async)
+	span.SetAttribute("plugin.correlation_id", pluginResponse.CorrelationId)
 	defer func() {
 		if err != nil {
-			logrus.Errorf("plguin[%v]-action[%v] meet error = %v", pluginRequest.Name, pluginRequest.Action, err)
-			pluginResponse.ResultCode = "1"
-			pluginResponse.ResultMsg = fmt.Sprint(err)
+			Logger(ctx).Errorf("plguin[%v]-action[%v] meet error = %v", pluginRequest.Name, pluginRequest.Action, err)
 		} else {
-			logrus.Infof("plguin[%v]-action[%v] completed", pluginRequest.Name, pluginRequest.Action)
-			pluginResponse.ResultCode = "0"
-			pluginResponse.ResultMsg = "success"
+			Logger(ctx).Infof("plguin[%v]-action[%v] completed", pluginRequest.Name, pluginRequest.Action)
 		}
+		fillPluginResponseResult(&pluginResponse, err)
+		observePluginRequest(pluginRequest.Name, pluginRequest.Action, pluginResponse.ResultCode, time.Since(start))
+		span.SetAttribute("plugin.result_code", pluginResponse.ResultCode)
+		span.End(err)
 	}()
 
-	logrus.Infof("plguin[%v]-action[%v] start...", pluginRequest.Name, pluginRequest.Action)
+	Logger(ctx).Infof("plguin[%v]-action[%v] start...", pluginRequest.Name, pluginRequest.Action)
 
 	if pluginRequest.ProviderName != PROVIDER_NAME {
-		err = fmt.Errorf("ProviderName[%v] is wrong", pluginRequest.ProviderName)
+		err = NewValidationError("ProviderName[%v] is wrong", pluginRequest.ProviderName)
 		return nil, err
 	}
 
 	if pluginRequest.Version != VERSION {
-		err = fmt.Errorf("Version[%v] is wrong", pluginRequest.Version)
+		err = NewValidationError("Version[%v] is wrong", pluginRequest.Version)
 		return nil, err
 	}
 
-	plugin, err := getPluginByName(pluginRequest.Name)
+	action, err := GetAction(pluginRequest.Name, pluginRequest.Action)
 	if err != nil {
 		return &pluginResponse, err
 	}
 
-	action, err := plugin.GetActionByName(pluginRequest.Action)
+	actionParam, err := action.ReadParam(ctx, pluginRequest.Parameters)
 	if err != nil {
+		err = AsValidationError(err)
 		return &pluginResponse, err
 	}
 
-	logrus.Infof("read parameters from http request = %v", pluginRequest.Parameters)
-	actionParam, err := action.ReadParam(pluginRequest.Parameters)
-	if err != nil {
-		return &pluginResponse, err
+	if pluginRequest.DryRun {
+		ctx, pluginResponse.Plan = WithDryRun(ctx, getGuidsFromInputs(actionParam))
+	} else if pluginRequest.Async {
+		task := submitTask(ctx, pluginRequest, action, actionParam)
+		pluginResponse.Results = TaskBrief{Id: task.Id, Status: TASK_STATUS_PENDING}
+		return &pluginResponse, nil
 	}
 
-	logrus.Infof("action do with parameters = %v", actionParam)
-	pluginResponse.Results, err = action.Do(actionParam)
+	ctx, cancel := getActionTimeoutPolicy().WithTimeout(ctx, pluginRequest.Name, pluginRequest.Action)
+	defer cancel()
+
+	Logger(ctx).Infof("action do with parameters = %s", Sanitize(actionParam))
+	pluginResponse.Results, err = doAction(ctx, pluginRequest.Name, pluginRequest.Action, action, actionParam)
 
 	return &pluginResponse, err
 }
+
+// doAction runs the action with the decrypted secrets of the inputs, create actions are run by
+// DefaultIdempotencyStore if it is set and the action is not in dry run mode. If the action is canceled
+// the outputs of the inputs which were not started are filled with the error. The shutdown waits for
+// the action until it returns, and no action is started once the shutdown begins.
+func doAction(ctx context.Context, pluginName, actionName string, action Action, actionParam interface{}) (results interface{}, err error) {
+	actionParam, err = decryptInputSecrets(actionParam)
+	if err != nil {
+		return nil, AsValidationError(err)
+	}
+	if err = validateInputProviderParams(actionParam); err != nil {
+		return nil, AsValidationError(err)
+	}
+
+	ctx, tracked, err := trackAction(ctx, pluginName, actionName, actionParam)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { tracked.finish(results, err) }()
+
+	ctx, recorder := withRequestIdRecorder(ctx)
+	ctx = withActionInfo(ctx, pluginName, actionName, getGuidsFromInputs(actionParam))
+	if store := DefaultIdempotencyStore; store != nil && IsIdempotentAction(actionName) && !IsDryRun(ctx) {
+		results, err = store.Do(ctx, pluginName, actionName, action, actionParam)
+	} else {
+		results, err = action.Do(ctx, actionParam)
+	}
+	if IsDryRun(ctx) {
+		err = finishDryRun(results, err)
+	}
+	if canceledErr, ok := err.(*ActionCanceledError); ok {
+		canceledErr.Guids = getGuidsFromInputs(actionParam)
+		fillNotStartedOutputs(actionParam, results, canceledErr)
+	}
+	classifyOutputErrors(results)
+	recorder.fillOutputs(results)
+	if !IsDryRun(ctx) {
+		observeInputResults(pluginName, actionName, results)
+	}
+	return results, err
+}
+
+// classifyOutputErrors sets the category of the error results which are set without SetError, they are
+// classified by the message.
+func classifyOutputErrors(actionResult interface{}) {
+	outputs := getSliceField(actionResult, "Outputs")
+	if !outputs.IsValid() {
+		return
+	}
+	for i := 0; i < outputs.Len(); i++ {
+		output := reflect.Indirect(outputs.Index(i))
+		if output.Kind() != reflect.Struct {
+			continue
+		}
+		field := output.FieldByName("Result")
+		if !field.IsValid() || !field.CanAddr() {
+			continue
+		}
+		result, ok := field.Addr().Interface().(*Result)
+		if !ok || result.Code != RESULT_CODE_ERROR || result.ErrorCategory != "" {
+			continue
+		}
+		pluginErr := classifyErrorMessage(result.Message)
+		result.ErrorCategory, result.ErrorReason, result.Retryable = pluginErr.Category, pluginErr.Code, pluginErr.Retryable
+	}
+}
+
+// NewErrorResponse returns the response of a request failed before any action is run, with the category of the error.
+func NewErrorResponse(err error) *PluginResponse {
+	pluginResponse := &PluginResponse{}
+	fillPluginResponseResult(pluginResponse, err)
+	return pluginResponse
+}
+
+func fillPluginResponseResult(pluginResponse *PluginResponse, err error) {
+	if err != nil {
+		pluginErr := ClassifyError(err)
+		pluginResponse.ResultCode = RESULT_CODE_ERROR
+		pluginResponse.ResultMsg = fmt.Sprint(err)
+		pluginResponse.ErrorCategory, pluginResponse.ErrorReason, pluginResponse.Retryable = pluginErr.Category, pluginErr.Code, pluginErr.Retryable
+	} else {
+		pluginResponse.ResultCode = RESULT_CODE_SUCCESS
+		pluginResponse.ResultMsg = "success"
+	}
+}
@@ -0,0 +1,337 @@
+package plugins
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const (
+	PROVIDER_PARAMS_REGION     = "Region"
+	PROVIDER_PARAMS_ZONE       = "AvailableZone"
+	PROVIDER_PARAMS_SECRET_ID  = "SecretID"
+	PROVIDER_PARAMS_SECRET_KEY = "SecretKey"
+	PROVIDER_PARAMS_TOKEN      = "Token"
+	PROVIDER_PARAMS_PROJECT_ID = "ProjectId"
+	PROVIDER_PARAMS_LANGUAGE   = "Language"
+	// "Endpoint.{service}={host[:port]}" overrides the endpoint of a service, such as "Endpoint.cvm=cvm.internal.tencentcloudapi.com".
+	PROVIDER_PARAMS_ENDPOINT_PREFIX = "Endpoint."
+)
+
+// providerParamsKeyAliases are the other names of the keys accepted in provider params.
+var providerParamsKeyAliases = map[string]string{
+	"Zone":      PROVIDER_PARAMS_ZONE,
+	"SecretId":  PROVIDER_PARAMS_SECRET_ID,
+	"ProjectID": PROVIDER_PARAMS_PROJECT_ID,
+}
+
+var providerParamsLanguages = []string{"zh-CN", "en-US"}
+
+var (
+	defaultRegionsMutex sync.RWMutex
+	defaultRegions      []string
+)
+
+// SetDefaultRegions sets the regions of the plugins which work on all the regions instead of the region of
+// provider params, such as the security group plugin.
+func SetDefaultRegions(regions []string) {
+	defaultRegionsMutex.Lock()
+	defer defaultRegionsMutex.Unlock()
+
+	defaultRegions = append([]string{}, regions...)
+}
+
+func GetDefaultRegions() []string {
+	defaultRegionsMutex.RLock()
+	defer defaultRegionsMutex.RUnlock()
+
+	return append([]string{}, defaultRegions...)
+}
+
+// ProviderParams are the region, credential and client options of an input, they are given by
+// provider_params, location and api_secret in the format of "Key1=value1;Key2=value2".
+// A value which contains ';' or starts with '"' is quoted by '"' or escaped by '\'.
+type ProviderParams struct {
+	Region    string
+	Zone      string
+	SecretID  string
+	SecretKey string
+	Token     string
+	ProjectId string
+	Language  string
+	// Endpoints overrides the endpoints of the client factory by service.
+	Endpoints map[string]string
+}
+
+// NewProviderParams merges provider_params, location and api_secret of an input, the items of
+// location and api_secret override the ones of provider_params. Region is required. The errors are
+// validation errors of the input, callers should not use the params returned with an error.
+func NewProviderParams(providerParams, location, apiSecret string) (*ProviderParams, error) {
+	params := &ProviderParams{}
+	sources := []struct {
+		name  string
+		value string
+	}{{"provider_params", providerParams}, {"location", location}, {"api_secret", apiSecret}}
+	for _, source := range sources {
+		if err := params.merge(source.name, source.value); err != nil {
+			return params, AsValidationError(err)
+		}
+	}
+	if err := params.validate(); err != nil {
+		return params, AsValidationError(err)
+	}
+	return params, nil
+}
+
+// ParseProviderParams parses the provider params which have been merged, such as the peer
+// provider params of a peering connection.
+func ParseProviderParams(providerParams string) (*ProviderParams, error) {
+	return NewProviderParams(providerParams, "", "")
+}
+
+// mergeProviderParams returns the provider params of an input in the format of provider_params, it is
+// passed to the functions which take the provider params, such as BindCvmInstanceSecurityGroups.
+func mergeProviderParams(providerParams, location, apiSecret string) (string, error) {
+	params, err := NewProviderParams(providerParams, location, apiSecret)
+	if err != nil {
+		return providerParams, err
+	}
+	return params.String(), nil
+}
+
+func (params *ProviderParams) merge(source, text string) error {
+	items, err := splitProviderParams(text)
+	if err != nil {
+		return fmt.Errorf("%s meet error=%v", source, err)
+	}
+	keys := make(map[string]bool)
+	for _, item := range items {
+		key := item[0]
+		if name, found := providerParamsKeyAliases[key]; found {
+			key = name
+		}
+		if keys[key] {
+			return fmt.Errorf("key %s of %s is duplicated", item[0], source)
+		}
+		keys[key] = true
+		if err := params.set(key, item[1]); err != nil {
+			return fmt.Errorf("key %s of %s %v", item[0], source, err)
+		}
+	}
+	return nil
+}
+
+func (params *ProviderParams) set(key, value string) error {
+	switch key {
+	case PROVIDER_PARAMS_REGION:
+		params.Region = value
+	case PROVIDER_PARAMS_ZONE:
+		params.Zone = value
+	case PROVIDER_PARAMS_SECRET_ID:
+		params.SecretID = value
+	case PROVIDER_PARAMS_SECRET_KEY:
+		params.SecretKey = value
+	case PROVIDER_PARAMS_TOKEN:
+		params.Token = value
+	case PROVIDER_PARAMS_PROJECT_ID:
+		if _, err := strconv.ParseUint(value, 10, 64); err != nil {
+			return fmt.Errorf("is not a project id, value=%s", value)
+		}
+		params.ProjectId = value
+	case PROVIDER_PARAMS_LANGUAGE:
+		if !isStringInSlice(value, providerParamsLanguages) {
+			return fmt.Errorf("should be one of %v, value=%s", providerParamsLanguages, value)
+		}
+		params.Language = value
+	default:
+		service := strings.TrimPrefix(key, PROVIDER_PARAMS_ENDPOINT_PREFIX)
+		if service == key {
+			return fmt.Errorf("is unknown")
+		}
+		if service == "" || value == "" {
+			return fmt.Errorf("should be in the format of %s{service}={host[:port]}", PROVIDER_PARAMS_ENDPOINT_PREFIX)
+		}
+		if params.Endpoints == nil {
+			params.Endpoints = make(map[string]string)
+		}
+		params.Endpoints[service] = value
+	}
+	return nil
+}
+
+func (params *ProviderParams) validate() error {
+	if params.Region == "" {
+		return fmt.Errorf("key %s of provider_params is empty", PROVIDER_PARAMS_REGION)
+	}
+	if params.SecretID != "" && params.SecretKey == "" {
+		return fmt.Errorf("key %s of provider_params is empty while %s is set", PROVIDER_PARAMS_SECRET_KEY, PROVIDER_PARAMS_SECRET_ID)
+	}
+	if params.SecretKey != "" && params.SecretID == "" {
+		return fmt.Errorf("key %s of provider_params is empty while %s is set", PROVIDER_PARAMS_SECRET_ID, PROVIDER_PARAMS_SECRET_KEY)
+	}
+	if params.Token != "" && params.SecretID == "" {
+		return fmt.Errorf("key %s of provider_params is set without %s and %s", PROVIDER_PARAMS_TOKEN, PROVIDER_PARAMS_SECRET_ID, PROVIDER_PARAMS_SECRET_KEY)
+	}
+	return nil
+}
+
+// String returns the provider params in the format of provider_params, values are escaped if needed.
+func (params *ProviderParams) String() string {
+	items := []string{}
+	add := func(key, value string) {
+		if value != "" {
+			items = append(items, key+"="+escapeProviderParamsValue(value))
+		}
+	}
+	add(PROVIDER_PARAMS_REGION, params.Region)
+	add(PROVIDER_PARAMS_ZONE, params.Zone)
+	add(PROVIDER_PARAMS_SECRET_ID, params.SecretID)
+	add(PROVIDER_PARAMS_SECRET_KEY, params.SecretKey)
+	add(PROVIDER_PARAMS_TOKEN, params.Token)
+	add(PROVIDER_PARAMS_PROJECT_ID, params.ProjectId)
+	add(PROVIDER_PARAMS_LANGUAGE, params.Language)
+	services := []string{}
+	for service := range params.Endpoints {
+		services = append(services, service)
+	}
+	sort.Strings(services)
+	for _, service := range services {
+		add(PROVIDER_PARAMS_ENDPOINT_PREFIX+service, params.Endpoints[service])
+	}
+	return strings.Join(items, ";")
+}
+
+// GetProjectId returns the project id, 0 is the default project.
+func (params *ProviderParams) GetProjectId() int64 {
+	projectId, _ := strconv.ParseInt(params.ProjectId, 10, 64)
+	return projectId
+}
+
+// splitProviderParams returns the key and value of the items, the value is after the first '='.
+// Spaces around keys and unquoted values are trimmed, empty items are skipped.
+func splitProviderParams(text string) ([][2]string, error) {
+	items := [][2]string{}
+	var key, value strings.Builder
+	current := &key
+	hasValue, quoted, inQuotes, escaped := false, false, false, false
+
+	flush := func() error {
+		name := strings.TrimSpace(key.String())
+		if !hasValue {
+			if name != "" {
+				return fmt.Errorf("key %s has no value", name)
+			}
+			key.Reset()
+			return nil
+		}
+		if name == "" {
+			return fmt.Errorf("item =%s has no key", value.String())
+		}
+		itemValue := value.String()
+		if !quoted {
+			itemValue = strings.TrimSpace(itemValue)
+		}
+		items = append(items, [2]string{name, itemValue})
+		key.Reset()
+		value.Reset()
+		current = &key
+		hasValue, quoted = false, false
+		return nil
+	}
+
+	for _, c := range text {
+		switch {
+		case escaped:
+			current.WriteRune(c)
+			escaped = false
+		case c == '\\':
+			escaped = true
+		case inQuotes:
+			if c == '"' {
+				inQuotes = false
+			} else {
+				current.WriteRune(c)
+			}
+		case c == '"' && hasValue && strings.TrimSpace(value.String()) == "" && !quoted:
+			value.Reset()
+			inQuotes, quoted = true, true
+		case c == '=' && !hasValue:
+			hasValue = true
+			current = &value
+		case c == ';':
+			if err := flush(); err != nil {
+				return items, err
+			}
+		default:
+			if quoted && !isSpace(c) {
+				return items, fmt.Errorf("key %s has characters after the quoted value", strings.TrimSpace(key.String()))
+			}
+			current.WriteRune(c)
+		}
+	}
+	if escaped || inQuotes {
+		return items, fmt.Errorf("key %s has an unterminated value", strings.TrimSpace(key.String()))
+	}
+	if err := flush(); err != nil {
+		return items, err
+	}
+	return items, nil
+}
+
+func isSpace(c rune) bool {
+	return c == ' ' || c == '\t' || c == '\r' || c == '\n'
+}
+
+func escapeProviderParamsValue(value string) string {
+	if !strings.ContainsAny(value, `;"\`) && strings.TrimSpace(value) == value {
+		return value
+	}
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(value) + `"`
+}
+
+func isStringInSlice(value string, values []string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// validateInputProviderParams checks the provider params of the inputs, such as ProviderParams merged with
+// Location and APISecret, and PeerProviderParams merged with PeerLocation. It is called by doAction so a
+// malformed value is reported with the offending key before any cloud API is called.
+func validateInputProviderParams(actionParam interface{}) error {
+	inputs := getSliceField(actionParam, "Inputs")
+	if !inputs.IsValid() || inputs.Type().Elem().Kind() != reflect.Struct {
+		return nil
+	}
+
+	names := []string{}
+	inputType := inputs.Type().Elem()
+	for i := 0; i < inputType.NumField(); i++ {
+		field := inputType.Field(i)
+		if field.Type.Kind() == reflect.String && strings.HasSuffix(field.Name, "ProviderParams") {
+			names = append(names, field.Name)
+		}
+	}
+
+	for i := 0; i < inputs.Len(); i++ {
+		input := inputs.Index(i)
+		for _, name := range names {
+			prefix := strings.TrimSuffix(name, "ProviderParams")
+			location, apiSecret := getStringField(input, prefix+"Location"), getStringField(input, prefix+"APISecret")
+			value := getStringField(input, name)
+			if value == "" && location == "" && apiSecret == "" {
+				continue
+			}
+			if _, err := NewProviderParams(value, location, apiSecret); err != nil {
+				return fmt.Errorf("%s of input %s meet error=%v", name, getStringField(input, "Guid"), err)
+			}
+		}
+	}
+	return nil
+}
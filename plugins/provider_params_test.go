@@ -0,0 +1,152 @@
+package plugins
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	cvm "github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/cvm/v20170312"
+)
+
+func TestNewProviderParams(t *testing.T) {
+	params, err := NewProviderParams(
+		`Region=ap-shanghai; AvailableZone = ap-shanghai-1 ;ProjectId=1001;Language=en-US;Endpoint.cvm=cvm.internal.tencentcloudapi.com`,
+		"Region=ap-guangzhou;Zone=ap-guangzhou-4",
+		`SecretId=id-1;SecretKey=a=b\;c;Token="token;1"`)
+	if err != nil {
+		t.Fatalf("NewProviderParams meet error=%v", err)
+	}
+	expected := ProviderParams{
+		Region:    "ap-guangzhou",
+		Zone:      "ap-guangzhou-4",
+		SecretID:  "id-1",
+		SecretKey: "a=b;c",
+		Token:     "token;1",
+		ProjectId: "1001",
+		Language:  "en-US",
+		Endpoints: map[string]string{QCLOUD_SERVICE_CVM: "cvm.internal.tencentcloudapi.com"},
+	}
+	if !reflect.DeepEqual(*params, expected) {
+		t.Errorf("params=%+v, expected %+v", *params, expected)
+	}
+
+	parsed, err := ParseProviderParams(params.String())
+	if err != nil || !reflect.DeepEqual(*parsed, expected) {
+		t.Errorf("params of %v=%+v, error=%v", params.String(), parsed, err)
+	}
+}
+
+func TestNewProviderParamsErrors(t *testing.T) {
+	cases := []struct {
+		providerParams string
+		apiSecret      string
+		key            string
+	}{
+		{"Region=ap-guangzhou;Zome=ap-guangzhou-4", "", "Zome"},
+		{"Region=ap-guangzhou;Region=ap-shanghai", "", "Region"},
+		{"Region=ap-guangzhou;ProjectId=default", "", "ProjectId"},
+		{"Region=ap-guangzhou;Language=fr", "", "Language"},
+		{"Region=ap-guangzhou;Endpoint.=cvm.tencentcloudapi.com", "", "Endpoint."},
+		{"Region=ap-guangzhou;SecretID", "", "SecretID"},
+		{"Region=ap-guangzhou", `SecretID=id-1;SecretKey="key-1`, "SecretKey"},
+		{"Region=ap-guangzhou", "SecretID=id-1", "SecretKey"},
+		{"AvailableZone=ap-guangzhou-4", "SecretID=id-1;SecretKey=key-1", "Region"},
+	}
+	for _, c := range cases {
+		_, err := NewProviderParams(c.providerParams, "", c.apiSecret)
+		if err == nil || !strings.Contains(err.Error(), c.key) {
+			t.Errorf("provider_params=%v, api_secret=%v, error=%v, expected error of %v", c.providerParams, c.apiSecret, err, c.key)
+		}
+	}
+}
+
+func TestValidateInputProviderParams(t *testing.T) {
+	type peerInput struct {
+		Guid               string
+		ProviderParams     string
+		Location           string
+		APISecret          string
+		PeerProviderParams string
+		PeerLocation       string
+	}
+	type peerInputs struct {
+		Inputs []peerInput
+	}
+
+	param := peerInputs{Inputs: []peerInput{
+		{Guid: "guid_1", Location: "Region=ap-guangzhou", APISecret: "SecretID=id-1;SecretKey=key-1", PeerProviderParams: "SecretID=id-2;SecretKey=key-2", PeerLocation: "Region=ap-shanghai"},
+		{Guid: "guid_2", ProviderParams: "Region=ap-guangzhou"},
+	}}
+	if err := validateInputProviderParams(param); err != nil {
+		t.Fatalf("validateInputProviderParams meet error=%v", err)
+	}
+
+	param.Inputs[1].PeerProviderParams = "Region=ap-shanghai;SecretKey=key-3"
+	err := validateInputProviderParams(param)
+	if err == nil || !strings.Contains(err.Error(), "PeerProviderParams of input guid_2") || !strings.Contains(err.Error(), "SecretID") {
+		t.Errorf("error=%v", err)
+	}
+}
+
+func TestClientFactoryWithProviderParams(t *testing.T) {
+	server, getRequests := newCredentialServer(time.Hour)
+	defer server.Close()
+	defer SetClientFactory(GetClientFactory())
+	setCredentialTestFactory(server, nil)
+
+	serverHost := GetClientFactory().Endpoints[QCLOUD_SERVICE_CVM]
+	GetClientFactory().Endpoints[QCLOUD_SERVICE_CVM] = "cvm.unreachable.invalid"
+	params, err := ParseProviderParams("Region=ap-guangzhou;SecretID=id-1;SecretKey=key-1;Token=token-1;Language=en-US;Endpoint.cvm=" + serverHost)
+	if err != nil {
+		t.Fatalf("ParseProviderParams meet error=%v", err)
+	}
+	client, err := createCvmClient(context.Background(), params)
+	if err != nil {
+		t.Fatalf("createCvmClient meet error=%v", err)
+	}
+	client.DescribeInstances(cvm.NewDescribeInstancesRequest())
+
+	requests := getRequests()
+	if len(requests) != 1 {
+		t.Fatalf("server got %d requests", len(requests))
+	}
+	header := requests[0].Header
+	if header.Get("X-TC-Token") != "token-1" || header.Get("X-TC-Language") != "en-US" ||
+		!strings.Contains(header.Get("Authorization"), "Credential=id-1/") {
+		t.Errorf("headers=%v", header)
+	}
+	if GetClientFactory().Endpoints[QCLOUD_SERVICE_CVM] != "cvm.unreachable.invalid" {
+		t.Errorf("the endpoints of the client factory are modified")
+	}
+}
+
+func TestActionsRejectInvalidProviderParams(t *testing.T) {
+	pluginsMutex.Lock()
+	registered := make(map[string]Plugin)
+	for name, plugin := range plugins {
+		registered[name] = plugin
+	}
+	pluginsMutex.Unlock()
+
+	input := `{"inputs":[{"guid":"guid_1","provider_params":"Region=ap-guangzhou;Zome=ap-guangzhou-4","api_secret":"SecretID=id-1;SecretKey=key-1"}]}`
+	for pluginName, plugin := range registered {
+		for actionName, action := range plugin.GetActions() {
+			func() {
+				defer func() {
+					if r := recover(); r != nil {
+						t.Errorf("%v %v panics with invalid provider params, %v", pluginName, actionName, r)
+					}
+				}()
+				param, err := action.ReadParam(context.Background(), strings.NewReader(input))
+				if err != nil {
+					return
+				}
+				if _, err = action.Do(context.Background(), param); err == nil {
+					t.Errorf("%v %v succeeds with invalid provider params", pluginName, actionName)
+				}
+			}()
+		}
+	}
+}
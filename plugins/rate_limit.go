@@ -0,0 +1,242 @@
+package plugins
+
+import (
+	"math"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// the default request limit of a cloud API action is 20 requests per second for one account in one region.
+const DEFAULT_RATE_LIMIT = 20
+
+// defaultActionRateLimits are the published limits of the actions which differ from the default,
+// the key is "{service}.{action}".
+var defaultActionRateLimits = map[string]float64{
+	QCLOUD_SERVICE_CVM + ".DescribeInstances":        40,
+	QCLOUD_SERVICE_CVM + ".DescribeInstancesStatus":  40,
+	QCLOUD_SERVICE_CVM + ".RunInstances":             10,
+	QCLOUD_SERVICE_CVM + ".TerminateInstances":       10,
+	QCLOUD_SERVICE_CVM + ".StartInstances":           10,
+	QCLOUD_SERVICE_CVM + ".StopInstances":            10,
+	QCLOUD_SERVICE_CVM + ".RebootInstances":          10,
+	QCLOUD_SERVICE_CVM + ".ModifyInstancesAttribute": 10,
+	QCLOUD_SERVICE_CVM + ".ResetInstancesPassword":   10,
+}
+
+// RateLimiter holds token buckets keyed by (service, region, credential, action), as the limits of the cloud
+// APIs are per action. Requests of cloud API wait for a token of their bucket before being sent.
+type RateLimiter struct {
+	// Rate is the requests per second of one action in one region with one credential,
+	// DEFAULT_RATE_LIMIT is used if zero and negative disables the limit.
+	Rate float64
+	// ServiceRates overrides the rate of the actions of services, the key is the service name such as "cvm".
+	ServiceRates map[string]float64
+	// ActionRates overrides the rate of actions with their own limit, the key is "{service}.{action}"
+	// such as "cvm.RunInstances". defaultActionRateLimits is used if nil.
+	ActionRates map[string]float64
+
+	mutex   sync.Mutex
+	buckets map[string]*tokenBucket
+}
+
+// RateLimiterState is the current state of one token bucket.
+type RateLimiterState struct {
+	Service  string
+	Region   string
+	SecretId string
+	Action   string
+	Rate     float64
+	Tokens   float64
+	Requests int64
+	Waits    int64
+	WaitTime time.Duration
+}
+
+var DefaultRateLimiter = &RateLimiter{}
+
+type tokenBucket struct {
+	state RateLimiterState
+	burst float64
+	last  time.Time
+}
+
+// reserve takes a token from the bucket and returns how long the caller must wait before the token is available.
+func (bucket *tokenBucket) reserve(now time.Time) time.Duration {
+	state := &bucket.state
+	if !bucket.last.IsZero() {
+		state.Tokens = math.Min(bucket.burst, state.Tokens+now.Sub(bucket.last).Seconds()*state.Rate)
+	}
+	bucket.last = now
+	state.Requests++
+	state.Tokens--
+	if state.Tokens >= 0 {
+		return 0
+	}
+
+	wait := time.Duration(-state.Tokens / state.Rate * float64(time.Second))
+	state.Waits++
+	state.WaitTime += wait
+	return wait
+}
+
+// GetDefaultActionRateLimits returns a copy of the published limits of the actions which differ from the default.
+func GetDefaultActionRateLimits() map[string]float64 {
+	rates := make(map[string]float64)
+	for key, rate := range defaultActionRateLimits {
+		rates[key] = rate
+	}
+	return rates
+}
+
+func (limiter *RateLimiter) getServiceRate(service string) float64 {
+	if rate, found := limiter.ServiceRates[service]; found {
+		return rate
+	}
+	if limiter.Rate == 0 {
+		return DEFAULT_RATE_LIMIT
+	}
+	return limiter.Rate
+}
+
+// getRate returns the rate of the action, which is its own limit if it has one, or the rate of its service.
+func (limiter *RateLimiter) getRate(service, action string) float64 {
+	actionRates := limiter.ActionRates
+	if actionRates == nil {
+		actionRates = defaultActionRateLimits
+	}
+	if rate, found := actionRates[service+"."+action]; found {
+		return rate
+	}
+	return limiter.getServiceRate(service)
+}
+
+func (limiter *RateLimiter) getBucket(state RateLimiterState) *tokenBucket {
+	key := strings.Join([]string{state.Service, state.Region, state.SecretId, state.Action}, "/")
+	if bucket, found := limiter.buckets[key]; found {
+		return bucket
+	}
+
+	if limiter.buckets == nil {
+		limiter.buckets = make(map[string]*tokenBucket)
+	}
+	bucket := &tokenBucket{state: state, burst: math.Max(1, state.Rate)}
+	bucket.state.Tokens = bucket.burst
+	limiter.buckets[key] = bucket
+	return bucket
+}
+
+// Reserve takes a token of the bucket of the action, it returns how long the request must wait.
+func (limiter *RateLimiter) Reserve(service, region, secretId, action string) time.Duration {
+	limiter.mutex.Lock()
+	defer limiter.mutex.Unlock()
+
+	rate := limiter.getRate(service, action)
+	if rate <= 0 {
+		return 0
+	}
+	bucket := limiter.getBucket(RateLimiterState{Service: service, Region: region, SecretId: secretId, Action: action, Rate: rate})
+	return bucket.reserve(time.Now())
+}
+
+// SetRates replaces the rates of the limiter while requests are sent, the buckets are kept with their
+// tokens capped by the new burst, so the changed limits apply to the next requests.
+func (limiter *RateLimiter) SetRates(rate float64, serviceRates map[string]float64, actionRates map[string]float64) {
+	limiter.mutex.Lock()
+	defer limiter.mutex.Unlock()
+
+	limiter.Rate, limiter.ServiceRates, limiter.ActionRates = rate, serviceRates, actionRates
+	for key, bucket := range limiter.buckets {
+		state := &bucket.state
+		rate := limiter.getRate(state.Service, state.Action)
+		if rate <= 0 {
+			delete(limiter.buckets, key)
+			continue
+		}
+		state.Rate = rate
+		bucket.burst = math.Max(1, rate)
+		state.Tokens = math.Min(bucket.burst, state.Tokens)
+	}
+}
+
+// States returns the state of all the buckets, the secret ids are masked.
+func (limiter *RateLimiter) States() []RateLimiterState {
+	limiter.mutex.Lock()
+	defer limiter.mutex.Unlock()
+
+	keys := []string{}
+	for key := range limiter.buckets {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	states := []RateLimiterState{}
+	now := time.Now()
+	for _, key := range keys {
+		bucket := limiter.buckets[key]
+		state := bucket.state
+		state.SecretId = maskSecretId(state.SecretId)
+		state.Tokens = math.Min(bucket.burst, state.Tokens+now.Sub(bucket.last).Seconds()*state.Rate)
+		states = append(states, state)
+	}
+	return states
+}
+
+func maskSecretId(secretId string) string {
+	if len(secretId) <= 8 {
+		return "****"
+	}
+	return secretId[:4] + "****" + secretId[len(secretId)-4:]
+}
+
+// getRateLimitKey returns the service, region and secret id of a cloud API request.
+// Requests of the current API carry them in the signature and headers, legacy API requests in the query.
+func getRateLimitKey(request *http.Request) (service, region, secretId string) {
+	if authorization := request.Header.Get("Authorization"); strings.Contains(authorization, "Credential=") {
+		credential := strings.TrimPrefix(authorization[strings.Index(authorization, "Credential="):], "Credential=")
+		credential = strings.SplitN(credential, ",", 2)[0]
+		// Credential={secretId}/{date}/{service}/tc3_request
+		if parts := strings.Split(credential, "/"); len(parts) == 4 {
+			secretId, service = parts[0], parts[2]
+		}
+		if values := request.Header["X-TC-Region"]; len(values) > 0 {
+			region = values[0]
+		}
+		return
+	}
+
+	host := request.Host
+	if host == "" {
+		host = request.URL.Hostname()
+	}
+	if strings.HasSuffix(host, "."+QCLOUD_LEGACY_API_DOMAIN) {
+		service = QCLOUD_LEGACY_SERVICE_PREFIX + strings.TrimSuffix(host, "."+QCLOUD_LEGACY_API_DOMAIN)
+	}
+	query := request.URL.Query()
+	return service, query.Get("Region"), query.Get("SecretId")
+}
+
+// rateLimitTransport delays cloud API requests until the rate limiter allows them.
+type rateLimitTransport struct {
+	limiter *RateLimiter
+	next    http.RoundTripper
+}
+
+func (transport *rateLimitTransport) RoundTrip(request *http.Request) (*http.Response, error) {
+	action := getApiAction(request)
+	if action == "" {
+		return transport.next.RoundTrip(request)
+	}
+
+	service, region, secretId := getRateLimitKey(request)
+	if wait := transport.limiter.Reserve(service, region, secretId, action); wait > 0 {
+		Logger(request.Context()).Infof("cloud api %v of service %v in region %v (secretId=%v) is delayed %v by the rate limiter",
+			action, service, region, maskSecretId(secretId), wait)
+		if err := sleepWithContext(request.Context(), wait); err != nil {
+			return nil, err
+		}
+	}
+	return transport.next.RoundTrip(request)
+}
@@ -0,0 +1,163 @@
+package plugins
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	cvm "github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/cvm/v20170312"
+	unversioned "github.com/zqfan/tencentcloud-sdk-go/services/vpc/unversioned"
+)
+
+func TestTokenBucketReserve(t *testing.T) {
+	bucket := &tokenBucket{state: RateLimiterState{Rate: 2, Tokens: 2}, burst: 2}
+	now := time.Now()
+
+	for i, expected := range []time.Duration{0, 0, 500 * time.Millisecond, time.Second} {
+		if wait := bucket.reserve(now); wait != expected {
+			t.Errorf("reserve %d, wait=%v, expected %v", i, wait, expected)
+		}
+	}
+	// the reserved tokens are paid back first.
+	if wait := bucket.reserve(now.Add(time.Second)); wait != 500*time.Millisecond {
+		t.Errorf("reserve after 1s, wait=%v", wait)
+	}
+	if wait := bucket.reserve(now.Add(10 * time.Second)); wait != 0 {
+		t.Errorf("reserve after 10s, wait=%v", wait)
+	}
+	if bucket.state.Tokens != 1 {
+		t.Errorf("tokens=%v, expected the burst minus 1", bucket.state.Tokens)
+	}
+	if bucket.state.Requests != 6 || bucket.state.Waits != 3 {
+		t.Errorf("requests=%v, waits=%v", bucket.state.Requests, bucket.state.Waits)
+	}
+}
+
+func TestRateLimiterBuckets(t *testing.T) {
+	limiter := &RateLimiter{
+		Rate:         1,
+		ServiceRates: map[string]float64{QCLOUD_SERVICE_VPC: -1},
+		ActionRates:  map[string]float64{"cvm.RunInstances": 0.5},
+	}
+
+	if wait := limiter.Reserve(QCLOUD_SERVICE_CVM, "ap-guangzhou", "AKID00000001", "RunInstances"); wait != 0 {
+		t.Errorf("first request waits %v", wait)
+	}
+	// the action has its own limit, it is slower than the rate of the service.
+	if wait := limiter.Reserve(QCLOUD_SERVICE_CVM, "ap-guangzhou", "AKID00000001", "RunInstances"); wait < 1900*time.Millisecond {
+		t.Errorf("second RunInstances waits %v", wait)
+	}
+	// other actions of the service are not throttled by RunInstances.
+	if wait := limiter.Reserve(QCLOUD_SERVICE_CVM, "ap-guangzhou", "AKID00000001", "DescribeInstances"); wait != 0 {
+		t.Errorf("first DescribeInstances waits %v", wait)
+	}
+	if wait := limiter.Reserve(QCLOUD_SERVICE_CVM, "ap-guangzhou", "AKID00000001", "DescribeInstances"); wait < 900*time.Millisecond || wait > time.Second {
+		t.Errorf("second DescribeInstances waits %v", wait)
+	}
+	// other regions, credentials and services have their own buckets, vpc is not limited.
+	if wait := limiter.Reserve(QCLOUD_SERVICE_CVM, "ap-shanghai", "AKID00000001", "RunInstances"); wait != 0 {
+		t.Errorf("request of another region waits %v", wait)
+	}
+	if wait := limiter.Reserve(QCLOUD_SERVICE_CVM, "ap-guangzhou", "AKID00000002", "DescribeInstances"); wait != 0 {
+		t.Errorf("request of another credential waits %v", wait)
+	}
+	for i := 0; i < 10; i++ {
+		if wait := limiter.Reserve(QCLOUD_SERVICE_VPC, "ap-guangzhou", "AKID00000001", "CreateVpc"); wait != 0 {
+			t.Errorf("vpc request waits %v", wait)
+		}
+	}
+
+	states := limiter.States()
+	if len(states) != 4 {
+		t.Fatalf("states=%+v", states)
+	}
+	if states[0].SecretId != "AKID****0001" || states[0].Action != "DescribeInstances" || states[0].Requests != 2 || states[0].Waits != 1 {
+		t.Errorf("unexpected state %+v", states[0])
+	}
+	if states[1].Action != "RunInstances" || states[1].Rate != 0.5 || states[1].Requests != 2 {
+		t.Errorf("unexpected state %+v", states[1])
+	}
+}
+
+func TestRateLimiterSetRates(t *testing.T) {
+	limiter := &RateLimiter{Rate: 1, ActionRates: map[string]float64{"cvm.RunInstances": 1}}
+	limiter.Reserve(QCLOUD_SERVICE_CVM, "ap-guangzhou", "AKID00000001", "RunInstances")
+	limiter.Reserve(QCLOUD_SERVICE_VPC, "ap-guangzhou", "AKID00000001", "CreateVpc")
+
+	// the action limit is removed and vpc is not limited any more.
+	limiter.SetRates(10, map[string]float64{QCLOUD_SERVICE_VPC: -1}, map[string]float64{})
+	states := limiter.States()
+	if len(states) != 1 || states[0].Service != QCLOUD_SERVICE_CVM || states[0].Action != "RunInstances" || states[0].Rate != 10 {
+		t.Fatalf("states=%+v", states)
+	}
+	// the bucket is refilled at the new rate, it waits about 1s at the old one.
+	if wait := limiter.Reserve(QCLOUD_SERVICE_CVM, "ap-guangzhou", "AKID00000001", "RunInstances"); wait > 200*time.Millisecond {
+		t.Errorf("request waits %v after the rate is raised", wait)
+	}
+	if wait := limiter.Reserve(QCLOUD_SERVICE_VPC, "ap-guangzhou", "AKID00000001", "CreateVpc"); wait != 0 {
+		t.Errorf("vpc request waits %v", wait)
+	}
+}
+
+func TestRateLimitTransportKeys(t *testing.T) {
+	server, _ := newRecordingServer(`{"Response":{"TotalCount":0,"InstanceSet":[],"RequestId":"fake-request-id"}}`)
+	defer server.Close()
+	serverUrl, _ := url.Parse(server.URL)
+
+	limiter := &RateLimiter{}
+	factory := &ClientFactory{
+		Scheme:    "http",
+		Endpoints: map[string]string{QCLOUD_SERVICE_CVM: serverUrl.Host, QCLOUD_SERVICE_LEGACY_VPC: serverUrl.Host},
+		RateLimit: limiter,
+	}
+	client, _ := factory.NewCvmClient("ap-guangzhou", "AKID-fake-secret-id", "fake-secret-key")
+	if _, err := client.DescribeInstances(cvm.NewDescribeInstancesRequest()); err != nil {
+		t.Fatalf("DescribeInstances meet error=%v", err)
+	}
+
+	defer SetClientFactory(GetClientFactory())
+	SetClientFactory(factory)
+	legacyClient, _ := newVpcClient(context.Background(), &ProviderParams{Region: "ap-shanghai", SecretID: "AKID-fake-secret-id", SecretKey: "fake-secret-key"})
+	legacyClient.DescribeNatGateway(unversioned.NewDescribeNatGatewayRequest())
+
+	states := limiter.States()
+	expected := []RateLimiterState{
+		{Service: QCLOUD_SERVICE_CVM, Region: "ap-guangzhou", SecretId: "AKID****t-id", Action: "DescribeInstances", Rate: 40},
+		{Service: QCLOUD_SERVICE_LEGACY_VPC, Region: "ap-shanghai", SecretId: "AKID****t-id", Action: "DescribeNatGateway", Rate: DEFAULT_RATE_LIMIT},
+	}
+	if len(states) != len(expected) {
+		t.Fatalf("states=%+v", states)
+	}
+	for i := range expected {
+		state := states[i]
+		if state.Service != expected[i].Service || state.Region != expected[i].Region || state.SecretId != expected[i].SecretId ||
+			state.Action != expected[i].Action || state.Rate != expected[i].Rate || state.Requests != 1 {
+			t.Errorf("state %d=%+v, expected %+v", i, state, expected[i])
+		}
+	}
+}
+
+func TestRateLimitTransportSkipsOtherRequests(t *testing.T) {
+	limiter := &RateLimiter{Rate: 1}
+	transport := &rateLimitTransport{limiter: limiter, next: roundTripFunc(func(request *http.Request) (*http.Response, error) {
+		return &http.Response{StatusCode: http.StatusOK, Body: http.NoBody}, nil
+	})}
+
+	for i := 0; i < 3; i++ {
+		request, _ := http.NewRequest(http.MethodGet, "http://bucket-1250000000.cos.ap-guangzhou.myqcloud.com/object", nil)
+		if _, err := transport.RoundTrip(request); err != nil {
+			t.Fatalf("RoundTrip meet error=%v", err)
+		}
+	}
+	if states := limiter.States(); len(states) != 0 {
+		t.Errorf("states=%+v", states)
+	}
+}
+
+type roundTripFunc func(request *http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(request *http.Request) (*http.Response, error) {
+	return f(request)
+}
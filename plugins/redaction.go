@@ -0,0 +1,208 @@
+package plugins
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+)
+
+const SENSITIVE_MASK = "******"
+
+// defaultSensitiveFields are the json names of the secrets which are masked in logs whichever plugin has them,
+// such as the secrets in outputs, the inputs marked by the sensitive option of the register tag are added from
+// the registered plugins. Fields whose name ends with one of them, such as "instance_password", are
+// masked too.
+var defaultSensitiveFields = []string{"password", "seed", "secret_key", "token"}
+
+// providerParamsFields are the json names of the fields in the format of provider params, only their
+// secret items are masked, so the region is still in logs.
+var providerParamsFields = []string{"provider_params", "api_secret"}
+
+// sensitiveParams are the items of provider params and the parameters of urls which are masked.
+var sensitiveParams = []string{"SecretKey", "Token"}
+
+var sensitiveParamPattern = regexp.MustCompile(`(?i)\b(` + strings.Join(sensitiveParams, "|") + `)=[^;&"\s,}\]]+`)
+
+var (
+	sensitiveMutex sync.RWMutex
+	// the fields are loaded at the first use after a plugin is registered, since the actions of the plugins
+	// are added by the init of their files.
+	sensitiveFields     []string
+	sensitiveGeneration int
+	// "password":"..." in json.
+	sensitiveJsonPattern *regexp.Regexp
+	// Password:... in structs printed by %+v and password:... in maps printed by %v.
+	sensitiveStructPattern *regexp.Regexp
+)
+
+// getSensitiveFields returns the json names of the fields which are masked in logs, they are the default fields
+// and the inputs of the registered plugins marked by the sensitive option of the register tag.
+func getSensitiveFields() []string {
+	fields, _, _ := loadSensitiveFields()
+	return fields
+}
+
+func loadSensitiveFields() ([]string, *regexp.Regexp, *regexp.Regexp) {
+	sensitiveMutex.RLock()
+	fields, jsonPattern, structPattern, generation := sensitiveFields, sensitiveJsonPattern, sensitiveStructPattern, sensitiveGeneration
+	sensitiveMutex.RUnlock()
+	if fields != nil {
+		return fields, jsonPattern, structPattern
+	}
+
+	pluginsMutex.Lock()
+	registered := make(map[string]Plugin)
+	for name, plugin := range plugins {
+		registered[name] = plugin
+	}
+	pluginsMutex.Unlock()
+
+	fields = append([]string{}, defaultSensitiveFields...)
+	for pluginName, plugin := range registered {
+		for _, name := range getTaggedSensitiveFields(pluginName, plugin) {
+			if !isSensitiveField(name, fields) {
+				fields = append(fields, name)
+			}
+		}
+	}
+	jsonPattern, structPattern = compileSensitiveJsonPattern(fields), compileSensitiveStructPattern(fields)
+
+	sensitiveMutex.Lock()
+	// the fields loaded before a plugin is registered are not kept.
+	if generation == sensitiveGeneration {
+		sensitiveFields, sensitiveJsonPattern, sensitiveStructPattern = fields, jsonPattern, structPattern
+	}
+	sensitiveMutex.Unlock()
+	return fields, jsonPattern, structPattern
+}
+
+// resetSensitiveFields makes the fields loaded again with the plugins registered.
+func resetSensitiveFields() {
+	sensitiveMutex.Lock()
+	defer sensitiveMutex.Unlock()
+	sensitiveFields = nil
+	sensitiveGeneration++
+}
+
+func compileSensitiveJsonPattern(fields []string) *regexp.Regexp {
+	return regexp.MustCompile(`(?i)"(\w*(?:` + toFieldPatterns(fields) + `))"(\s*):(\s*)"(?:[^"\\]|\\.)*"`)
+}
+
+func compileSensitiveStructPattern(fields []string) *regexp.Regexp {
+	return regexp.MustCompile(`(?i)\b(\w*(?:` + toFieldPatterns(fields) + `)):[^\s}\]]+`)
+}
+
+// toFieldPatterns returns the alternation of the fields, the case is ignored by the patterns and the underscores
+// are optional, so "secret_key" matches "SecretKey" of structs as well.
+func toFieldPatterns(fields []string) string {
+	patterns := []string{}
+	for _, field := range fields {
+		patterns = append(patterns, strings.Replace(regexp.QuoteMeta(field), "_", "_?", -1))
+	}
+	return strings.Join(patterns, "|")
+}
+
+// isSensitiveField tells whether the name ends with one of the fields, the case and the underscores are ignored,
+// so the json names of the plugins and the parameters of the cloud APIs such as "SecretKey" match the same fields.
+func isSensitiveField(name string, fields []string) bool {
+	name = normalizeFieldName(name)
+	for _, field := range fields {
+		if strings.HasSuffix(name, normalizeFieldName(field)) {
+			return true
+		}
+	}
+	return false
+}
+
+func normalizeFieldName(name string) string {
+	return strings.ToLower(strings.Replace(name, "_", "", -1))
+}
+
+// MaskSecretsInText masks the secrets in a log message, such as the SecretKey of provider params,
+// sensitive fields in json, in structs printed by %+v and in maps printed by %v.
+func MaskSecretsInText(text string) string {
+	_, jsonPattern, structPattern := loadSensitiveFields()
+	text = sensitiveParamPattern.ReplaceAllString(text, "${1}="+SENSITIVE_MASK)
+	text = jsonPattern.ReplaceAllString(text, `"${1}"${2}:${3}"`+SENSITIVE_MASK+`"`)
+	return structPattern.ReplaceAllString(text, "${1}:"+SENSITIVE_MASK)
+}
+
+// Sanitize returns the json of v whose sensitive fields are masked, it is used to log inputs, outputs and responses.
+func Sanitize(v interface{}) string {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return MaskSecretsInText(fmt.Sprintf("%+v", v))
+	}
+	var value interface{}
+	if err = json.Unmarshal(b, &value); err != nil {
+		return MaskSecretsInText(string(b))
+	}
+	if b, err = json.Marshal(sanitizeValue("", value)); err != nil {
+		return MaskSecretsInText(fmt.Sprintf("%+v", v))
+	}
+	return string(b)
+}
+
+// sanitizeParameters masks the sensitive fields of the parameters of a cloud API call in place with the rules of
+// Sanitize, the parameters are listed in the plan of dry run and in the audit journal.
+func sanitizeParameters(parameters map[string]interface{}) map[string]interface{} {
+	sanitizeValue("", parameters)
+	return parameters
+}
+
+func sanitizeValue(name string, value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, item := range v {
+			v[key] = sanitizeValue(key, item)
+		}
+	case []interface{}:
+		for i, item := range v {
+			v[i] = sanitizeValue(name, item)
+		}
+	case string:
+		if v == "" {
+			return v
+		}
+		if isSensitiveField(name, getSensitiveFields()) {
+			return SENSITIVE_MASK
+		}
+		if isSensitiveField(name, providerParamsFields) {
+			return sensitiveParamPattern.ReplaceAllString(v, "${1}="+SENSITIVE_MASK)
+		}
+		return MaskSecretsInText(v)
+	}
+	return value
+}
+
+// RedactionHook masks the secrets of all log entries, it should be added before the hooks which write the entries.
+type RedactionHook struct{}
+
+func (hook *RedactionHook) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+func (hook *RedactionHook) Fire(entry *logrus.Entry) error {
+	entry.Message = MaskSecretsInText(entry.Message)
+	if len(entry.Data) == 0 {
+		return nil
+	}
+	// the data may be shared with other entries.
+	data := make(logrus.Fields, len(entry.Data))
+	for key, value := range entry.Data {
+		switch v := value.(type) {
+		case string:
+			data[key] = sanitizeValue(key, v)
+		case error:
+			data[key] = MaskSecretsInText(v.Error())
+		default:
+			data[key] = value
+		}
+	}
+	entry.Data = data
+	return nil
+}
@@ -0,0 +1,197 @@
+package plugins
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+)
+
+type redactionTestOutput struct {
+	Guid           string `json:"guid,omitempty"`
+	Password       string `json:"password,omitempty"`
+	SecretKey      string `json:"secret_key,omitempty"`
+	ProviderParams string `json:"provider_params,omitempty"`
+	APISecret      string `json:"api_secret"`
+	InstanceSeed   string `json:"seed,omitempty"`
+}
+
+type redactionTestOutputs struct {
+	Outputs []redactionTestOutput `json:"outputs,omitempty"`
+}
+
+func TestSanitize(t *testing.T) {
+	outputs := redactionTestOutputs{Outputs: []redactionTestOutput{{
+		Guid:           "guid_1",
+		Password:       "Ab888888",
+		SecretKey:      "user-secret-key",
+		ProviderParams: "Region=ap-guangzhou;SecretID=id-1;SecretKey=key-1",
+		APISecret:      "SecretID=id-2;SecretKey=key-2",
+		InstanceSeed:   "seed-1",
+	}}}
+	sanitized := Sanitize(outputs)
+	for _, secret := range []string{"Ab888888", "user-secret-key", "key-1", "key-2", "seed-1"} {
+		if strings.Contains(sanitized, secret) {
+			t.Errorf("%v is in %v", secret, sanitized)
+		}
+	}
+	for _, text := range []string{`"guid":"guid_1"`, "Region=ap-guangzhou", "SecretID=id-2"} {
+		if !strings.Contains(sanitized, text) {
+			t.Errorf("%v is not in %v", text, sanitized)
+		}
+	}
+	if outputs.Outputs[0].Password != "Ab888888" {
+		t.Errorf("the outputs are modified")
+	}
+}
+
+func TestSanitizeParameters(t *testing.T) {
+	parameters := map[string]interface{}{
+		"InstanceName":  "vm-1",
+		"SecretKey":     "api-secret-key",
+		"LoginSettings": map[string]interface{}{"Password": "Ab888888"},
+		"Disks":         []interface{}{map[string]interface{}{"DiskSize": float64(50)}},
+	}
+	sanitized := sanitizeParameters(parameters)
+	if sanitized["InstanceName"] != "vm-1" || sanitized["SecretKey"] != SENSITIVE_MASK {
+		t.Errorf("parameters=%+v", sanitized)
+	}
+	if loginSettings := sanitized["LoginSettings"].(map[string]interface{}); loginSettings["Password"] != SENSITIVE_MASK {
+		t.Errorf("login settings=%+v", loginSettings)
+	}
+	if disk := sanitized["Disks"].([]interface{})[0].(map[string]interface{}); disk["DiskSize"] != float64(50) {
+		t.Errorf("disk=%+v", disk)
+	}
+}
+
+func TestMaskSecretsInText(t *testing.T) {
+	cases := []struct {
+		text     string
+		expected string
+	}{
+		{"providerParams=Region=ap-guangzhou;SecretID=id-1;SecretKey=key-1", "providerParams=Region=ap-guangzhou;SecretID=id-1;SecretKey=******"},
+		{`{"guid":"guid_1","password":"Ab\"888888","instance_password": "x"}`, `{"guid":"guid_1","password":"******","instance_password": "******"}`},
+		{"input={Guid:guid_1 InstancePassword:Ab888888 Seed:seed-1}", "input={Guid:guid_1 InstancePassword:****** Seed:******}"},
+		{"GET /v2/index.php?Action=DescribeNatGateway&Token=token-1&SecretId=id-1", "GET /v2/index.php?Action=DescribeNatGateway&Token=******&SecretId=id-1"},
+		{`{"Guid":"guid_1","Password":"Ab888888","SecretKey":"key-1"}`, `{"Guid":"guid_1","Password":"******","SecretKey":"******"}`},
+		{"input={guid_1 Ab888888 seed-1} output=map[guid:guid_1 password:Ab888888 secret_key:key-1]", "input={guid_1 Ab888888 seed-1} output=map[guid:guid_1 password:****** secret_key:******]"},
+		{"outputs=map[vm:map[InstancePassword:Ab888888] user:{Uin:1 SECRETKEY:key-1}]", "outputs=map[vm:map[InstancePassword:******] user:{Uin:1 SECRETKEY:******}]"},
+	}
+	for _, c := range cases {
+		if masked := MaskSecretsInText(c.text); masked != c.expected {
+			t.Errorf("masked=%v, expected %v", masked, c.expected)
+		}
+	}
+}
+
+func TestSanitizeMapValues(t *testing.T) {
+	outputs := map[string]interface{}{
+		"vm":    map[string]string{"guid": "guid_1", "Password": "Ab888888"},
+		"users": []map[string]string{{"SecretKey": "key-1", "seed": "seed-1"}},
+	}
+	sanitized := Sanitize(outputs)
+	for _, secret := range []string{"Ab888888", "key-1", "seed-1"} {
+		if strings.Contains(sanitized, secret) {
+			t.Errorf("%v is in %v", secret, sanitized)
+		}
+	}
+	if !strings.Contains(sanitized, `"guid":"guid_1"`) {
+		t.Errorf("guid is not in %v", sanitized)
+	}
+}
+
+func TestSensitiveFieldsOfRegisterTags(t *testing.T) {
+	if fields := getTaggedFields(reflect.TypeOf(registerTestInput{}), "sensitive"); len(fields) != 1 || fields[0] != "auth" {
+		t.Errorf("sensitive fields of tags=%v", fields)
+	}
+	// bucket_permission of user inputs is tagged sensitive, so it is added when the user plugin is registered.
+	if fields := getSensitiveFields(); !isSensitiveField("bucket_permission", fields) {
+		t.Errorf("sensitive fields=%v", fields)
+	}
+	if masked := MaskSecretsInText(`input={BucketPermission:READ} json={"bucket_permission":"READ"}`); strings.Contains(masked, "READ") {
+		t.Errorf("masked=%v", masked)
+	}
+}
+
+func TestActionLogsMaskSecrets(t *testing.T) {
+	buffer := &bytes.Buffer{}
+	defer logrus.SetOutput(logrus.StandardLogger().Out)
+	logrus.SetOutput(buffer)
+
+	server, _ := newScriptedServer(func(action, body string, times int) string {
+		switch action {
+		case "RunInstances":
+			return `{"Response":{"InstanceIdSet":["ins-1"],"RequestId":"fake-request-id"}}`
+		case "DescribeInstances":
+			return `{"Response":{"TotalCount":1,"InstanceSet":[{"InstanceId":"ins-1","InstanceState":"RUNNING","CPU":1,"Memory":2,"PrivateIpAddresses":["10.0.0.1"]}],"RequestId":"fake-request-id"}}`
+		}
+		return okResponse
+	})
+	defer server.Close()
+	defer SetClientFactory(GetClientFactory())
+	SetClientFactory(newRetryTestFactory(server, &RetryPolicy{}))
+
+	inputs := VmCreateInputs{Inputs: []VmCreateInput{{
+		Guid:               "guid_1",
+		Seed:               "seed-secret",
+		Password:           "Ab888888",
+		ProviderParams:     "Region=ap-guangzhou;AvailableZone=ap-guangzhou-1;SecretID=fake-secret-id;SecretKey=fake-secret-key",
+		VpcId:              "vpc-1",
+		SubnetId:           "subnet-1",
+		InstanceType:       "S1.SMALL1",
+		ImageId:            "img-1",
+		SystemDiskSize:     "50",
+		InstanceChargeType: "POSTPAID_BY_HOUR",
+	}}}
+	results, err := doAction(context.Background(), "vm", "create", &VmCreateAction{}, inputs)
+	if err != nil {
+		t.Fatalf("vm create meet error=%v", err)
+	}
+	output := results.(*VmCreateOutputs).Outputs[0]
+	if output.Id != "ins-1" || output.Password == "" {
+		t.Fatalf("output=%+v", output)
+	}
+	logs := buffer.String()
+	if !strings.Contains(logs, "ins-1") {
+		t.Fatalf("vm create is not logged, logs=%v", logs)
+	}
+	for _, secret := range []string{"Ab888888", "seed-secret", "fake-secret-key", output.Password} {
+		if strings.Contains(logs, secret) {
+			t.Errorf("%v is in logs=%v", secret, logs)
+		}
+	}
+}
+
+func TestRedactionHook(t *testing.T) {
+	buffer := &bytes.Buffer{}
+	logger := logrus.New()
+	logger.SetOutput(buffer)
+	logger.AddHook(&RedactionHook{})
+
+	fields := logrus.Fields{"provider_params": "Region=ap-guangzhou;SecretKey=key-1", "password": "Ab888888"}
+	logger.WithFields(fields).Infof("vm output={Password:Cd888888}")
+	if output := buffer.String(); strings.Contains(output, "key-1") || strings.Contains(output, "888888") {
+		t.Errorf("secrets are in log %v", output)
+	}
+	if fields["password"] != "Ab888888" {
+		t.Errorf("the fields are modified")
+	}
+}
+
+func TestSensitiveFieldsCoverRegisterXml(t *testing.T) {
+	b, err := ioutil.ReadFile("../build/register.xml.tpl")
+	if err != nil {
+		t.Fatalf("read register.xml.tpl meet error=%v", err)
+	}
+	pattern := regexp.MustCompile(`<parameter [^>]*sensitiveData="Y"[^>]*>(\w+)</parameter>`)
+	for _, match := range pattern.FindAllStringSubmatch(string(b), -1) {
+		if name := match[1]; !isSensitiveField(name, getSensitiveFields()) && !isSensitiveField(name, providerParamsFields) {
+			t.Errorf("sensitive parameter %v of register.xml.tpl is not masked in logs", name)
+		}
+	}
+}
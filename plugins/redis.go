@@ -1,15 +1,14 @@
 package plugins
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"strconv"
-	"time"
 
 	"github.com/WeBankPartners/wecube-plugins-qcloud/plugins/utils"
 	"github.com/sirupsen/logrus"
 	"github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/common"
-	"github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/common/profile"
 	cvm "github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/cvm/v20170312"
 	redis "github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/redis/v20180412"
 )
@@ -17,6 +16,9 @@ import (
 const (
 	REDIS_STATUS_RUNNING  = 4
 	REDIS_STATUS_ISOLATED = 5
+
+	REDIS_INSTANCE_STATUS_RUNNING  = 2
+	REDIS_INSTANCE_STATUS_ISOLATED = -2
 )
 
 var BillingModeMap = map[string]int64{
@@ -31,13 +33,8 @@ func init() {
 	RedisActions["delete"] = new(RedisDeleteAction)
 }
 
-func CreateRedisClient(region, secretId, secretKey string) (client *redis.Client, err error) {
-	credential := common.NewCredential(secretId, secretKey)
-
-	clientProfile := profile.NewClientProfile()
-	clientProfile.HttpProfile.Endpoint = "redis.tencentcloudapi.com"
-
-	return redis.NewClient(credential, region, clientProfile)
+func CreateRedisClient(ctx context.Context, params *ProviderParams) (client *redis.Client, err error) {
+	return GetClientFactory().WithContext(ctx).WithProviderParams(params).NewRedisClient(params.Region, params.SecretID, params.SecretKey)
 }
 
 type RedisInputs struct {
@@ -46,22 +43,23 @@ type RedisInputs struct {
 
 type RedisInput struct {
 	CallBackParameter
-	Guid             string `json:"guid,omitempty"`
-	InstanceName     string `json:"instance_name,omitempty"`
-	ProviderParams   string `json:"provider_params,omitempty"`
-	TypeID           string `json:"type_id,omitempty"`
-	MemSize          string `json:"mem_size,omitempty"`
+	WaitParameter
+	Guid             string `json:"guid,omitempty" register:"required"`
+	InstanceName     string `json:"instance_name,omitempty" register:"optional"`
+	ProviderParams   string `json:"provider_params,omitempty" register:"optional"`
+	TypeID           string `json:"type_id,omitempty" register:"required"`
+	MemSize          string `json:"mem_size,omitempty" register:"required"`
 	GoodsNum         uint64 `json:"goods_num,omitempty"`
-	Period           string `json:"period,omitempty"`
-	Password         string `json:"password,omitempty"`
-	BillingMode      string `json:"billing_mode,omitempty"`
-	VpcID            string `json:"vpc_id,omitempty"`
-	SubnetID         string `json:"subnet_id,omitempty"`
-	SecurityGroupIds string `json:"security_group_ids,omitempty"`
-	ID               string `json:"id,omitempty"`
-	Location         string `json:"location"`
-	APISecret        string `json:"api_secret"`
-	Seed             string `json:"seed,omitempty"`
+	Period           string `json:"period,omitempty" register:"optional"`
+	Password         string `json:"password,omitempty" register:"required"`
+	BillingMode      string `json:"billing_mode,omitempty" register:"required" enum:"charge_type"`
+	VpcID            string `json:"vpc_id,omitempty" register:"required"`
+	SubnetID         string `json:"subnet_id,omitempty" register:"required"`
+	SecurityGroupIds string `json:"security_group_ids,omitempty" register:"optional"`
+	ID               string `json:"id,omitempty" register:"optional"`
+	Location         string `json:"location" register:"required"`
+	APISecret        string `json:"api_secret" register:"required,system_variable=QCLOUD_API_SECRET"`
+	Seed             string `json:"seed,omitempty" register:"required,system_variable=ENCRYPT_SEED"`
 }
 
 type RedisOutputs struct {
@@ -72,14 +70,14 @@ type RedisOutput struct {
 	CallBackParameter
 	Result
 	RequestId    string `json:"request_id,omitempty"`
-	Guid         string `json:"guid,omitempty"`
-	InstanceName string `json:"instance_name,omitempty"`
+	Guid         string `json:"guid,omitempty" register:"optional"`
+	InstanceName string `json:"instance_name,omitempty" register:"optional"`
 	DealID       string `json:"deal_id,omitempty"`
 	TaskID       int64  `json:"task_id,omitempty"`
-	ID           string `json:"id,omitempty"`
-	Vip          string `json:"vip,omitempty"`
-	Port         string `json:"port,omitempty"`
-	Password     string `json:"password,omitempty"`
+	ID           string `json:"id,omitempty" register:"optional"`
+	Vip          string `json:"vip,omitempty" register:"optional"`
+	Port         string `json:"port,omitempty" register:"optional"`
+	Password     string `json:"password,omitempty" register:"optional"`
 }
 
 type RedisPlugin struct {
@@ -94,10 +92,14 @@ func (plugin *RedisPlugin) GetActionByName(actionName string) (Action, error) {
 	return action, nil
 }
 
+func (plugin *RedisPlugin) GetActions() map[string]Action {
+	return RedisActions
+}
+
 type RedisCreateAction struct {
 }
 
-func (action *RedisCreateAction) ReadParam(param interface{}) (interface{}, error) {
+func (action *RedisCreateAction) ReadParam(ctx context.Context, param interface{}) (interface{}, error) {
 	var inputs RedisInputs
 	err := UnmarshalJson(param, &inputs)
 	if err != nil {
@@ -113,7 +115,7 @@ func redisCreateCheckParam(redis *RedisInput) error {
 	if redis.Password == "" {
 		return errors.New("RedisCreateAction input password is empty")
 	}
-	if redis.BillingMode != CHARGE_TYPE_BY_HOUR && redis.BillingMode != CHARGE_TYPE_PREPAID {
+	if err := IsValidValue(redis.BillingMode, chargeTypes); err != nil {
 		return errors.New("RedisCreateAction input billing_mode is invalid")
 	}
 	if redis.Guid == "" {
@@ -143,25 +145,24 @@ func redisCreateCheckParam(redis *RedisInput) error {
 	return nil
 }
 
-func (action *RedisCreateAction) createRedis(redisInput *RedisInput) (output RedisOutput, err error) {
+func (action *RedisCreateAction) createRedis(ctx context.Context, redisInput *RedisInput) (output RedisOutput, err error) {
 	output.Guid = redisInput.Guid
 	output.Result.Code = RESULT_CODE_SUCCESS
 	output.CallBackParameter.Parameter = redisInput.CallBackParameter.Parameter
 	output.InstanceName = redisInput.InstanceName
 
-	if redisInput.Location != "" && redisInput.APISecret != "" {
-		redisInput.ProviderParams = fmt.Sprintf("%s;%s", redisInput.Location, redisInput.APISecret)
-	}
-	paramsMap, err := GetMapFromProviderParams(redisInput.ProviderParams)
-	client, _ := CreateRedisClient(paramsMap["Region"], paramsMap["SecretID"], paramsMap["SecretKey"])
-
 	defer func() {
 		if err != nil {
-			output.Result.Code = RESULT_CODE_ERROR
-			output.Result.Message = err.Error()
+			output.Result.SetError(err)
 		}
 	}()
 
+	params, err := NewProviderParams(redisInput.ProviderParams, redisInput.Location, redisInput.APISecret)
+	if err != nil {
+		return output, err
+	}
+	client, _ := CreateRedisClient(ctx, params)
+
 	securityGroupIds, _ := GetArrayFromString(redisInput.SecurityGroupIds, ARRAY_SIZE_REAL, 0)
 
 	//check resource exist
@@ -181,21 +182,21 @@ func (action *RedisCreateAction) createRedis(redisInput *RedisInput) (output Red
 		}
 	}
 
-	zonemap, err := GetAvaliableZoneInfo(paramsMap["Region"], paramsMap["SecretID"], paramsMap["SecretKey"])
+	zonemap, err := GetAvaliableZoneInfo(ctx, params)
 	if err != nil {
 		return output, err
 	}
 	for k, _ := range zonemap {
-		logrus.Infof("zone : %s", k)
+		Logger(ctx).Infof("zone : %s", k)
 	}
 
 	request := redis.NewCreateInstancesRequest()
-	if _, found := zonemap[paramsMap["AvailableZone"]]; !found {
+	if _, found := zonemap[params.Zone]; !found {
 		err = errors.New("not found available zone info")
 		return output, err
 	}
 
-	zoneid := uint64(zonemap[paramsMap["AvailableZone"]])
+	zoneid := uint64(zonemap[params.Zone])
 	request.ZoneId = &zoneid
 	typeId, er := strconv.ParseInt(redisInput.TypeID, 10, 64)
 	if er != nil {
@@ -249,47 +250,22 @@ func (action *RedisCreateAction) createRedis(redisInput *RedisInput) (output Red
 		return output, err
 	}
 
-	logrus.Info("create redis instance response = ", *response.Response.RequestId)
-	logrus.Info("new redis instance dealid = ", *response.Response.DealId)
-	logrus.Info("new redis instance instance ids length = ", len(response.Response.InstanceIds))
+	Logger(ctx).Info("create redis instance response = ", *response.Response.RequestId)
+	Logger(ctx).Info("new redis instance dealid = ", *response.Response.DealId)
+	Logger(ctx).Info("new redis instance instance ids length = ", len(response.Response.InstanceIds))
 
 	var instanceId string
 	if len(response.Response.InstanceIds) > 0 {
 		instanceId = *response.Response.InstanceIds[0]
-		logrus.Info("new redis instance instance ids 1 = ", instanceId)
-		var tmpError error
-		tmpCount := 0
-		for {
-			newInstanceRequest := redis.NewDescribeInstancesRequest()
-			newInstanceRequest.InstanceId = &instanceId
-			tmpInstanceResponse, err := client.DescribeInstances(newInstanceRequest)
-
-			if err != nil {
-				logrus.Errorf("client DescribeInstances ", err)
-				tmpError = err
-				break
-			}
-			if len(tmpInstanceResponse.Response.InstanceSet) == 0 {
-				tmpError = fmt.Errorf("get redis instance %s fail,response have no instance item ", *response.Response.InstanceIds[0])
-				break
-			}
-			logrus.Infof("get redis instance %s,count: %d, status: %d ", *response.Response.InstanceIds[0], tmpCount, *tmpInstanceResponse.Response.InstanceSet[0].Status)
-			if *tmpInstanceResponse.Response.InstanceSet[0].Status == 2 {
-				break
-			}
-			time.Sleep(5 * time.Second)
-			tmpCount++
-			if tmpCount >= 20 {
-				tmpError = fmt.Errorf("get redis instance %s timeout ", *response.Response.InstanceIds[0])
-				break
-			}
-		}
-		if tmpError != nil {
-			logrus.Errorf("get redis instance info meet error: %s", tmpError)
-			return output, tmpError
+		Logger(ctx).Info("new redis instance instance ids 1 = ", instanceId)
+		recordResourceId(ctx, redisInput.Guid, instanceId)
+		err = waitRedisInstanceStatus(ctx, client, instanceId, REDIS_INSTANCE_STATUS_RUNNING, redisInput.WaitTimeout)
+		if err != nil {
+			Logger(ctx).Errorf("get redis instance info meet error: %s", err)
+			return output, err
 		}
 	} else {
-		instanceId, err = action.waitForRedisInstancesCreationToFinish(client, *response.Response.DealId)
+		instanceId, err = action.waitForRedisInstancesCreationToFinish(ctx, client, *response.Response.DealId, redisInput.WaitTimeout)
 		if err != nil {
 			return output, err
 		}
@@ -300,7 +276,7 @@ func (action *RedisCreateAction) createRedis(redisInput *RedisInput) (output Red
 
 	instanceResponse, err := client.DescribeInstances(instanceRequest)
 	if err != nil {
-		logrus.Errorf("query redis instance info meet error: %s", err)
+		Logger(ctx).Errorf("query redis instance info meet error: %s", err)
 		return output, err
 	}
 
@@ -320,80 +296,98 @@ func (action *RedisCreateAction) createRedis(redisInput *RedisInput) (output Red
 	return output, err
 }
 
-func (action *RedisCreateAction) Do(input interface{}) (interface{}, error) {
+func (action *RedisCreateAction) Do(ctx context.Context, input interface{}) (interface{}, error) {
 	rediss, _ := input.(RedisInputs)
-	outputs := RedisOutputs{}
-	var finalErr error
-
-	for _, redis := range rediss.Inputs {
-		redisOutput, err := action.createRedis(&redis)
-		if err != nil {
-			finalErr = err
-		}
-		outputs.Outputs = append(outputs.Outputs, redisOutput)
-	}
-
-	logrus.Infof("all rediss = %v are created", rediss)
+	outputs := RedisOutputs{Outputs: make([]RedisOutput, len(rediss.Inputs))}
+	finalErr := runInputs(ctx, len(rediss.Inputs), func(i int) []string {
+		return []string{rediss.Inputs[i].Guid, rediss.Inputs[i].ID}
+	}, func(ctx context.Context, i int) error {
+		redis := rediss.Inputs[i]
+		redisOutput, err := action.createRedis(ctx, &redis)
+		outputs.Outputs[i] = redisOutput
+		return err
+	})
+
+	Logger(ctx).Infof("all rediss = %s are created", Sanitize(rediss))
 	return &outputs, finalErr
 }
 
-func (action *RedisCreateAction) waitForRedisInstancesCreationToFinish(client *redis.Client, dealid string) (string, error) {
-	time.Sleep(2 * time.Second)
+func (action *RedisCreateAction) waitForRedisInstancesCreationToFinish(ctx context.Context, client *redis.Client, dealid string, timeout string) (string, error) {
 	request := redis.NewDescribeInstanceDealDetailRequest()
 	request.DealIds = append(request.DealIds, &dealid)
-	var instanceids string
-	count := 0
 
-	for {
+	waiter, err := NewWaiter(WAIT_RESOURCE_REDIS, dealid, timeout)
+	if err != nil {
+		return "", err
+	}
+	var instanceids string
+	check := 0
+	err = waiter.Wait(ctx, func() (string, bool, error) {
+		// the new deal may not be found at the first check.
+		check++
 		response, err := client.DescribeInstanceDealDetail(request)
 		if err != nil {
-			if count > 0 {
-				return "", fmt.Errorf("call DescribeInstanceDealDetail with dealid = %v meet error = %v", dealid, err)
+			if check > 1 {
+				return "", false, fmt.Errorf("call DescribeInstanceDealDetail with dealid = %v meet error = %v", dealid, err)
 			}
-		} else {
-			if len(response.Response.DealDetails) == 0 {
-				if count > 0 {
-					return "", fmt.Errorf("the redis (dealid = %v) not found", dealid)
-				}
-			} else {
-				if *response.Response.DealDetails[0].Status == REDIS_STATUS_RUNNING {
-					for _, instanceid := range response.Response.DealDetails[0].InstanceIds {
-						if instanceids == "" {
-							instanceids = *instanceid
-						} else {
-							instanceids = instanceids + "," + *instanceid
-						}
-					}
-					return instanceids, nil
-				}
+			return "", false, nil
+		}
+		if len(response.Response.DealDetails) == 0 {
+			if check > 1 {
+				return "", false, fmt.Errorf("the redis (dealid = %v) not found", dealid)
 			}
+			return "", false, nil
 		}
-
-		time.Sleep(10 * time.Second)
-		count++
-		if count >= 20 {
-			return "", errors.New("waitForRedisInstancesCreationToFinish timeout")
+		status := *response.Response.DealDetails[0].Status
+		if status != REDIS_STATUS_RUNNING {
+			return strconv.Itoa(int(status)), false, nil
 		}
-	}
+		for _, instanceid := range response.Response.DealDetails[0].InstanceIds {
+			if instanceids == "" {
+				instanceids = *instanceid
+			} else {
+				instanceids = instanceids + "," + *instanceid
+			}
+		}
+		return strconv.Itoa(int(status)), true, nil
+	})
+	return instanceids, err
 }
 
-func CreateDescribeZonesClient(region, secretId, secretKey string) (client *cvm.Client, err error) {
-	credential := common.NewCredential(secretId, secretKey)
+func waitRedisInstanceStatus(ctx context.Context, client *redis.Client, instanceId string, desireStatus int64, timeout string) error {
+	request := redis.NewDescribeInstancesRequest()
+	request.InstanceId = &instanceId
 
-	clientProfile := profile.NewClientProfile()
-	clientProfile.HttpProfile.Endpoint = "cvm.tencentcloudapi.com"
+	waiter, err := NewWaiter(WAIT_RESOURCE_REDIS, instanceId, timeout)
+	if err != nil {
+		return err
+	}
+	return waiter.Wait(ctx, func() (string, bool, error) {
+		response, err := client.DescribeInstances(request)
+		if err != nil {
+			Logger(ctx).Errorf("client DescribeInstances meet error=%v", err)
+			return "", false, err
+		}
+		if len(response.Response.InstanceSet) == 0 {
+			return "", false, fmt.Errorf("get redis instance %s fail,response have no instance item ", instanceId)
+		}
+		status := *response.Response.InstanceSet[0].Status
+		return strconv.Itoa(int(status)), status == desireStatus, nil
+	})
+}
 
-	return cvm.NewClient(credential, region, clientProfile)
+func CreateDescribeZonesClient(ctx context.Context, params *ProviderParams) (client *cvm.Client, err error) {
+	return GetClientFactory().WithContext(ctx).WithProviderParams(params).NewCvmClient(params.Region, params.SecretID, params.SecretKey)
 }
 
-func GetAvaliableZoneInfo(region, secretid, secretkey string) (map[string]int, error) {
+func GetAvaliableZoneInfo(ctx context.Context, params *ProviderParams) (map[string]int, error) {
 	ZoneMap := make(map[string]int)
 	//获取redis zoneid
 	zonerequest := cvm.NewDescribeZonesRequest()
-	zoneClient, _ := CreateDescribeZonesClient(region, secretid, secretkey)
+	zoneClient, _ := CreateDescribeZonesClient(ctx, params)
 	zoneresponse, err := zoneClient.DescribeZones(zonerequest)
 	if err != nil {
-		logrus.Errorf("failed to get availablezone list, error=%s", err)
+		Logger(ctx).Errorf("failed to get availablezone list, error=%s", err)
 		return nil, err
 	}
 
@@ -453,11 +447,12 @@ type RedisDeleteInputs struct {
 
 type RedisDeleteInput struct {
 	CallBackParameter
-	Guid           string `json:"guid,omitempty"`
-	ID             string `json:"id,omitempty"`
-	ProviderParams string `json:"provider_params,omitempty"`
-	Location       string `json:"location"`
-	APISecret      string `json:"api_secret"`
+	WaitParameter
+	Guid           string `json:"guid,omitempty" register:"required"`
+	ID             string `json:"id,omitempty" register:"required"`
+	ProviderParams string `json:"provider_params,omitempty" register:"optional"`
+	Location       string `json:"location" register:"required"`
+	APISecret      string `json:"api_secret" register:"required,system_variable=QCLOUD_API_SECRET"`
 }
 
 type RedisDeleteOutputs struct {
@@ -467,15 +462,15 @@ type RedisDeleteOutputs struct {
 type RedisDeleteOutput struct {
 	CallBackParameter
 	Result
-	Guid      string `json:"guid,omitempty"`
+	Guid      string `json:"guid,omitempty" register:"optional"`
 	RequestId string `json:"request_id,omitempty"`
-	ID        string `json:"id,omitempty"`
+	ID        string `json:"id,omitempty" register:"optional"`
 }
 
 type RedisDeleteAction struct {
 }
 
-func (action *RedisDeleteAction) ReadParam(param interface{}) (interface{}, error) {
+func (action *RedisDeleteAction) ReadParam(ctx context.Context, param interface{}) (interface{}, error) {
 	var inputs RedisDeleteInputs
 	err := UnmarshalJson(param, &inputs)
 	if err != nil {
@@ -484,20 +479,19 @@ func (action *RedisDeleteAction) ReadParam(param interface{}) (interface{}, erro
 	return inputs, nil
 }
 
-func (action *RedisDeleteAction) Do(input interface{}) (interface{}, error) {
+func (action *RedisDeleteAction) Do(ctx context.Context, input interface{}) (interface{}, error) {
 	rediss, _ := input.(RedisDeleteInputs)
-	outputs := RedisDeleteOutputs{}
-	var finalErr error
-
-	for _, tmpRedisInput := range rediss.Inputs {
-		redisOutput, err := action.deleteRedis(&tmpRedisInput)
-		if err != nil {
-			finalErr = err
-		}
-		outputs.Outputs = append(outputs.Outputs, redisOutput)
-	}
-
-	logrus.Infof("all rediss = %v are delete", rediss)
+	outputs := RedisDeleteOutputs{Outputs: make([]RedisDeleteOutput, len(rediss.Inputs))}
+	finalErr := runInputs(ctx, len(rediss.Inputs), func(i int) []string {
+		return []string{rediss.Inputs[i].Guid, rediss.Inputs[i].ID}
+	}, func(ctx context.Context, i int) error {
+		tmpRedisInput := rediss.Inputs[i]
+		redisOutput, err := action.deleteRedis(ctx, &tmpRedisInput)
+		outputs.Outputs[i] = redisOutput
+		return err
+	})
+
+	Logger(ctx).Infof("all rediss = %s are delete", Sanitize(rediss))
 	return &outputs, finalErr
 }
 
@@ -519,37 +513,36 @@ func (action *RedisDeleteAction) checkParams(input *RedisDeleteInput) error {
 	return nil
 }
 
-func (action *RedisDeleteAction) deleteRedis(redisInput *RedisDeleteInput) (output RedisDeleteOutput, err error) {
+func (action *RedisDeleteAction) deleteRedis(ctx context.Context, redisInput *RedisDeleteInput) (output RedisDeleteOutput, err error) {
 	output.Guid = redisInput.Guid
 	output.ID = redisInput.ID
 	output.Result.Code = RESULT_CODE_SUCCESS
 	output.CallBackParameter.Parameter = redisInput.CallBackParameter.Parameter
 	defer func() {
 		if err != nil {
-			output.Result.Code = RESULT_CODE_ERROR
-			output.Result.Message = err.Error()
+			output.Result.SetError(err)
 		}
 	}()
-	err = action.checkParams(redisInput)
+	err = AsValidationError(action.checkParams(redisInput))
 	if err != nil {
-		logrus.Errorf("Delete redis check param error %v ", err)
+		Logger(ctx).Errorf("Delete redis check param error %v ", err)
 		return
 	}
 
-	if redisInput.Location != "" && redisInput.APISecret != "" {
-		redisInput.ProviderParams = fmt.Sprintf("%s;%s", redisInput.Location, redisInput.APISecret)
+	params, err := NewProviderParams(redisInput.ProviderParams, redisInput.Location, redisInput.APISecret)
+	if err != nil {
+		return
 	}
-	paramsMap, err := GetMapFromProviderParams(redisInput.ProviderParams)
-	client, _ := CreateRedisClient(paramsMap["Region"], paramsMap["SecretID"], paramsMap["SecretKey"])
+	client, _ := CreateRedisClient(ctx, params)
 	instanceRequest := redis.NewDescribeInstancesRequest()
 	instanceRequest.InstanceId = &redisInput.ID
 	instanceResponse, err := client.DescribeInstances(instanceRequest)
 	if err != nil {
-		logrus.Errorf("query redis instance with id:%s error=%v ", redisInput.ID, err)
+		Logger(ctx).Errorf("query redis instance with id:%s error=%v ", redisInput.ID, err)
 		return
 	}
 	if len(instanceResponse.Response.InstanceSet) == 0 {
-		logrus.Infof("redis instance with id:%s already deleted ", redisInput.ID)
+		Logger(ctx).Infof("redis instance with id:%s already deleted ", redisInput.ID)
 		return
 	}
 	if *instanceResponse.Response.InstanceSet[0].BillingMode == 1 {
@@ -570,15 +563,18 @@ func (action *RedisDeleteAction) deleteRedis(redisInput *RedisDeleteInput) (outp
 		}
 	}
 	if err != nil {
-		logrus.Errorf("Delete redis instance:%s error=%v ", redisInput.ID, err)
+		Logger(ctx).Errorf("Delete redis instance:%s error=%v ", redisInput.ID, err)
+		return
+	}
+	// the instance can only be cleaned up after it has been isolated.
+	if err = waitRedisInstanceStatus(ctx, client, redisInput.ID, REDIS_INSTANCE_STATUS_ISOLATED, redisInput.WaitTimeout); err != nil {
 		return
 	}
-	time.Sleep(3 * time.Second)
 	request := redis.NewCleanUpInstanceRequest()
 	request.InstanceId = &redisInput.ID
 	response, err := client.CleanUpInstance(request)
 	if err != nil {
-		logrus.Errorf("Delete redis cleanup instance:%s error %v ", redisInput.ID, err)
+		Logger(ctx).Errorf("Delete redis cleanup instance:%s error %v ", redisInput.ID, err)
 		return
 	}
 	output.RequestId = *response.Response.RequestId
@@ -0,0 +1,359 @@
+package plugins
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// REGISTER_TAG marks the fields of the inputs and outputs which are parameters of the interfaces in
+// register.xml, fields without the tag are not registered. Its value is a list of options split by ",":
+//
+//	required                  the input is required by all the actions of the struct
+//	required=create|delete    the input is only required by the actions
+//	optional                  the field is registered with no other option
+//	sensitive                 the value is sensitive, so are the fields masked in logs such as password
+//	context                   the parameter is mapped to the context of the platform, such as errorCode
+//	system_variable=NAME      the input is mapped to the system parameter of the platform
+//	actions=create|delete     the field is only registered by the actions, all by default
+const REGISTER_TAG = "register"
+
+const (
+	REGISTER_MAPPING_ENTITY          = "entity"
+	REGISTER_MAPPING_CONTEXT         = "context"
+	REGISTER_MAPPING_SYSTEM_VARIABLE = "system_variable"
+
+	REGISTER_PLUGINS_BEGIN = "<plugins>"
+	REGISTER_PLUGINS_END   = "</plugins>"
+)
+
+// registerNames are the names in register.xml of the actions registered under another plugin or action
+// name, keyed by "{plugin}.{action}". The paths of their interfaces are still the paths of the actions.
+var registerNames = map[string][2]string{
+	"bucket.create":             {"bucket", "add-bucket"},
+	"bucket.delete":             {"bucket", "del-bucket"},
+	"cbs.create-mount":          {"storage", "buy-and-mount-cbs-disk"},
+	"cbs.umount-terminate":      {"storage", "umount-destroy-cbs-disk"},
+	"mysql.create-backup":       {"mysql", "create-instance-backup"},
+	"mysql.delete-backup":       {"mysql", "delete-instance-backup"},
+	"vm.add-security-groups":    {"vm", "bind-security-group"},
+	"vm.remove-security-groups": {"vm", "remove-security-group"},
+}
+
+// unregisteredActions are not registered to the platform, keyed by "{plugin}" or "{plugin}.{action}".
+var unregisteredActions = map[string]bool{
+	"bs-security-group": true,
+	"eip":               true,
+	"elastic-nic":       true,
+	"storage.create":    true,
+	"storage.terminate": true,
+}
+
+type RegisterXmlParameter struct {
+	Name string
+	// DataType is string or number.
+	DataType  string
+	Required  bool
+	Sensitive bool
+	// MappingType is entity, context or system_variable.
+	MappingType    string
+	SystemVariable string
+}
+
+type RegisterXmlInterface struct {
+	Action  string
+	Path    string
+	Inputs  []RegisterXmlParameter
+	Outputs []RegisterXmlParameter
+}
+
+type RegisterXmlPlugin struct {
+	Name       string
+	Interfaces []RegisterXmlInterface
+}
+
+// GetRegisterPlugins returns the plugins of register.xml sorted by name, with the interfaces of the registered
+// actions sorted by action. The parameters are the tagged fields of one item of the inputs and outputs.
+func GetRegisterPlugins() ([]RegisterXmlPlugin, error) {
+	pluginsMutex.Lock()
+	registered := make(map[string]Plugin)
+	for name, plugin := range plugins {
+		registered[name] = plugin
+	}
+	pluginsMutex.Unlock()
+
+	interfaces := make(map[string][]RegisterXmlInterface)
+	for pluginName, plugin := range registered {
+		if unregisteredActions[pluginName] {
+			continue
+		}
+		for actionName, action := range plugin.GetActions() {
+			key := pluginName + "." + actionName
+			if unregisteredActions[key] {
+				continue
+			}
+			registerPlugin, registerAction := pluginName, actionName
+			if names, found := registerNames[key]; found {
+				registerPlugin, registerAction = names[0], names[1]
+			}
+			registerInterface, err := getRegisterInterface(pluginName, actionName, action)
+			if err != nil {
+				return nil, err
+			}
+			registerInterface.Action = registerAction
+			interfaces[registerPlugin] = append(interfaces[registerPlugin], registerInterface)
+		}
+	}
+
+	names := []string{}
+	for name := range interfaces {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	registerPlugins := []RegisterXmlPlugin{}
+	for _, name := range names {
+		sort.Slice(interfaces[name], func(i, j int) bool { return interfaces[name][i].Action < interfaces[name][j].Action })
+		registerPlugins = append(registerPlugins, RegisterXmlPlugin{Name: name, Interfaces: interfaces[name]})
+	}
+	return registerPlugins, nil
+}
+
+func getRegisterInterface(pluginName, actionName string, action Action) (RegisterXmlInterface, error) {
+	key := pluginName + "." + actionName
+	registerInterface := RegisterXmlInterface{Path: "/" + PROVIDER_NAME + "/" + VERSION + "/" + pluginName + "/" + actionName}
+	param, results := getActionSamples(key, action)
+	inputs := getSliceField(param, "Inputs")
+	if !inputs.IsValid() {
+		return registerInterface, fmt.Errorf("action %v does not take a list of inputs", key)
+	}
+	var err error
+	if registerInterface.Inputs, err = getRegisterParameters(inputs.Type().Elem(), actionName); err != nil {
+		return registerInterface, err
+	}
+	if outputs := getSliceField(results, "Outputs"); outputs.IsValid() {
+		if registerInterface.Outputs, err = getRegisterParameters(outputs.Type().Elem(), actionName); err != nil {
+			return registerInterface, err
+		}
+	}
+	return registerInterface, nil
+}
+
+// getRegisterParameters returns the tagged fields of a struct registered by the action in the order of
+// the fields, fields of embedded structs are promoted as json does.
+func getRegisterParameters(t reflect.Type, actionName string) ([]RegisterXmlParameter, error) {
+	t = indirectType(t)
+	parameters := []RegisterXmlParameter{}
+	if t.Kind() != reflect.Struct {
+		return parameters, nil
+	}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name := strings.Split(field.Tag.Get("json"), ",")[0]
+		if field.Anonymous && name == "" && indirectType(field.Type).Kind() == reflect.Struct {
+			embedded, err := getRegisterParameters(field.Type, actionName)
+			if err != nil {
+				return nil, err
+			}
+			parameters = append(parameters, embedded...)
+			continue
+		}
+		tag, found := field.Tag.Lookup(REGISTER_TAG)
+		if !found {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		parameter, registered, err := parseRegisterTag(tag, actionName)
+		if err != nil {
+			return nil, fmt.Errorf("register tag of %v.%v is invalid: %v", t.Name(), field.Name, err)
+		}
+		if !registered {
+			continue
+		}
+		parameter.Name = name
+		parameter.DataType = "string"
+		if schemaType := getSchemaType(field.Type); schemaType == "integer" || schemaType == "number" {
+			parameter.DataType = "number"
+		}
+		parameter.Sensitive = parameter.Sensitive || isSensitiveField(name, getSensitiveFields()) || isSensitiveField(name, providerParamsFields)
+		parameters = append(parameters, parameter)
+	}
+	return parameters, nil
+}
+
+// getTaggedSensitiveFields returns the json names of the inputs of the actions of a plugin marked by the sensitive
+// option of the register tag, which are sensitiveData="Y" in register.xml.
+func getTaggedSensitiveFields(pluginName string, plugin Plugin) []string {
+	fields := []string{}
+	for actionName, action := range plugin.GetActions() {
+		param := readSampleParam(pluginName+"."+actionName, action)
+		if inputs := getSliceField(param, "Inputs"); inputs.IsValid() {
+			fields = append(fields, getTaggedFields(inputs.Type().Elem(), "sensitive")...)
+		}
+	}
+	return fields
+}
+
+// getTaggedFields returns the json names of the fields of a struct whose register tag has the option, fields of
+// embedded structs are promoted as json does.
+func getTaggedFields(t reflect.Type, option string) []string {
+	t = indirectType(t)
+	fields := []string{}
+	if t.Kind() != reflect.Struct {
+		return fields
+	}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name := strings.Split(field.Tag.Get("json"), ",")[0]
+		if field.Anonymous && name == "" && indirectType(field.Type).Kind() == reflect.Struct {
+			fields = append(fields, getTaggedFields(field.Type, option)...)
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		for _, tagOption := range strings.Split(field.Tag.Get(REGISTER_TAG), ",") {
+			if tagOption == option {
+				fields = append(fields, name)
+			}
+		}
+	}
+	return fields
+}
+
+// parseRegisterTag returns the parameter of the options of the tag, and whether the action registers it.
+func parseRegisterTag(tag, actionName string) (RegisterXmlParameter, bool, error) {
+	parameter := RegisterXmlParameter{MappingType: REGISTER_MAPPING_ENTITY}
+	registered := true
+	for _, option := range strings.Split(tag, ",") {
+		key, value := option, ""
+		if i := strings.Index(option, "="); i >= 0 {
+			key, value = option[:i], option[i+1:]
+		}
+		switch key {
+		case "required":
+			parameter.Required = value == "" || containsAction(value, actionName)
+		case "optional":
+		case "sensitive":
+			parameter.Sensitive = true
+		case REGISTER_MAPPING_CONTEXT:
+			parameter.MappingType = REGISTER_MAPPING_CONTEXT
+		case REGISTER_MAPPING_SYSTEM_VARIABLE:
+			if value == "" {
+				return parameter, false, fmt.Errorf("system_variable has no name")
+			}
+			parameter.MappingType, parameter.SystemVariable = REGISTER_MAPPING_SYSTEM_VARIABLE, value
+		case "actions":
+			registered = containsAction(value, actionName)
+		default:
+			return parameter, false, fmt.Errorf("unknown option %q", option)
+		}
+	}
+	return parameter, registered, nil
+}
+
+func containsAction(actions, actionName string) bool {
+	for _, action := range strings.Split(actions, "|") {
+		if action == actionName {
+			return true
+		}
+	}
+	return false
+}
+
+// RenderRegisterPlugins returns the <plugins> section of register.xml, indented as it is in the template.
+func RenderRegisterPlugins(registerPlugins []RegisterXmlPlugin) string {
+	var buffer bytes.Buffer
+	buffer.WriteString("    " + REGISTER_PLUGINS_BEGIN + "\n")
+	for _, plugin := range registerPlugins {
+		fmt.Fprintf(&buffer, "        <plugin name=\"%s\" targetPackage=\"\" targetEntity=\"\" registerName=\"\" targetEntityFilterRule=\"\">\n", plugin.Name)
+		for _, registerInterface := range plugin.Interfaces {
+			fmt.Fprintf(&buffer, "            <interface action=\"%s\" path=\"%s\" filterRule=\"\">\n", registerInterface.Action, registerInterface.Path)
+			buffer.WriteString("                <inputParameters>\n")
+			for _, parameter := range registerInterface.Inputs {
+				fmt.Fprintf(&buffer, "                    <parameter datatype=\"%s\" required=\"%s\" sensitiveData=\"%s\" %s>%s</parameter>\n",
+					parameter.DataType, yesOrNo(parameter.Required), yesOrNo(parameter.Sensitive), getMappingAttributes(parameter), parameter.Name)
+			}
+			buffer.WriteString("                </inputParameters>\n")
+			buffer.WriteString("                <outputParameters>\n")
+			for _, parameter := range registerInterface.Outputs {
+				fmt.Fprintf(&buffer, "                    <parameter datatype=\"%s\" sensitiveData=\"%s\" %s>%s</parameter>\n",
+					parameter.DataType, yesOrNo(parameter.Sensitive), getMappingAttributes(parameter), parameter.Name)
+			}
+			buffer.WriteString("                </outputParameters>\n")
+			buffer.WriteString("            </interface>\n")
+		}
+		buffer.WriteString("        </plugin>\n")
+	}
+	buffer.WriteString("    " + REGISTER_PLUGINS_END + "\n")
+	return buffer.String()
+}
+
+func getMappingAttributes(parameter RegisterXmlParameter) string {
+	switch parameter.MappingType {
+	case REGISTER_MAPPING_CONTEXT:
+		return `mappingType="context"`
+	case REGISTER_MAPPING_SYSTEM_VARIABLE:
+		return fmt.Sprintf(`mappingType="system_variable" mappingSystemVariableName="%s"`, parameter.SystemVariable)
+	}
+	return `mappingType="entity" mappingEntityExpression=""`
+}
+
+func yesOrNo(b bool) string {
+	if b {
+		return "Y"
+	}
+	return "N"
+}
+
+// getRegisterPluginsSection returns the offsets of the lines of the <plugins> section in the template.
+func getRegisterPluginsSection(template string) (int, int, error) {
+	begin := strings.Index(template, REGISTER_PLUGINS_BEGIN)
+	end := strings.Index(template, REGISTER_PLUGINS_END)
+	if begin < 0 || end < begin {
+		return 0, 0, fmt.Errorf("template has no %v section", REGISTER_PLUGINS_BEGIN)
+	}
+	begin = strings.LastIndex(template[:begin], "\n") + 1
+	end += len(REGISTER_PLUGINS_END)
+	if i := strings.Index(template[end:], "\n"); i >= 0 {
+		end += i + 1
+	}
+	return begin, end, nil
+}
+
+// ReplaceRegisterPlugins returns the template of register.xml whose <plugins> section is generated from the
+// registered plugins.
+func ReplaceRegisterPlugins(template string) (string, error) {
+	begin, end, err := getRegisterPluginsSection(template)
+	if err != nil {
+		return "", err
+	}
+	registerPlugins, err := GetRegisterPlugins()
+	if err != nil {
+		return "", err
+	}
+	return template[:begin] + RenderRegisterPlugins(registerPlugins) + template[end:], nil
+}
+
+// CheckRegisterTemplate returns an error with the first different line if the <plugins> section of the
+// template is not the one generated from the registered plugins.
+func CheckRegisterTemplate(template string) error {
+	expected, err := ReplaceRegisterPlugins(template)
+	if err != nil {
+		return err
+	}
+	if expected == template {
+		return nil
+	}
+	templateLines, expectedLines := strings.Split(template, "\n"), strings.Split(expected, "\n")
+	for i := 0; i < len(templateLines) && i < len(expectedLines); i++ {
+		if templateLines[i] != expectedLines[i] {
+			return fmt.Errorf("template differs from the plugins at line %d\ntemplate: %s\nplugins:  %s",
+				i+1, strings.TrimSpace(templateLines[i]), strings.TrimSpace(expectedLines[i]))
+		}
+	}
+	return fmt.Errorf("template differs from the plugins at line %d", len(templateLines))
+}
@@ -0,0 +1,69 @@
+package plugins
+
+import (
+	"io/ioutil"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// the plugins of the template must be generated from the structs, see tools/register_xml.
+func TestRegisterTemplate(t *testing.T) {
+	template, err := ioutil.ReadFile("../build/register.xml.tpl")
+	if err != nil {
+		t.Fatalf("read template meet error=%v", err)
+	}
+	if err = CheckRegisterTemplate(string(template)); err != nil {
+		t.Errorf("%v\nrun \"go run ./tools/register_xml -write\" after the structs are changed", err)
+	}
+}
+
+type registerTestInput struct {
+	CallBackParameter
+	Guid      string `json:"guid,omitempty" register:"required"`
+	Id        string `json:"id,omitempty" register:"required=terminate"`
+	Name      string `json:"name,omitempty" register:"optional,actions=create"`
+	Count     int    `json:"count,omitempty" register:"optional"`
+	Password  string `json:"password,omitempty" register:"optional"`
+	Token     string `json:"auth,omitempty" register:"sensitive"`
+	APISecret string `json:"api_secret" register:"required,system_variable=QCLOUD_API_SECRET"`
+	Ignored   string `json:"ignored,omitempty"`
+}
+
+type registerTestOutput struct {
+	Result
+	Guid string `json:"guid,omitempty" register:"optional"`
+}
+
+func TestRegisterParameters(t *testing.T) {
+	inputType := reflect.TypeOf(registerTestInput{})
+	create, err := getRegisterParameters(inputType, "create")
+	if err != nil {
+		t.Fatalf("get parameters meet error=%v", err)
+	}
+	terminate, _ := getRegisterParameters(inputType, "terminate")
+	if len(create) != 7 || len(terminate) != 6 {
+		t.Fatalf("parameters of create=%+v, terminate=%+v", create, terminate)
+	}
+	if !create[0].Required || create[1].Required || !terminate[1].Required {
+		t.Errorf("required of guid and id, create=%+v, terminate=%+v", create[:2], terminate[:2])
+	}
+	if create[3].DataType != "number" || !create[4].Sensitive || !create[5].Sensitive || create[2].Sensitive {
+		t.Errorf("datatype or sensitive, parameters=%+v", create)
+	}
+	if secret := create[6]; secret.MappingType != REGISTER_MAPPING_SYSTEM_VARIABLE || secret.SystemVariable != "QCLOUD_API_SECRET" || !secret.Sensitive {
+		t.Errorf("api_secret=%+v", secret)
+	}
+
+	outputs, _ := getRegisterParameters(reflect.TypeOf(registerTestOutput{}), "create")
+	if len(outputs) != 3 || outputs[0].Name != "errorCode" || outputs[0].MappingType != REGISTER_MAPPING_CONTEXT || outputs[2].MappingType != REGISTER_MAPPING_ENTITY {
+		t.Errorf("outputs=%+v", outputs)
+	}
+
+	type invalidInput struct {
+		Guid string `json:"guid" register:"mandatory"`
+	}
+	if _, err = getRegisterParameters(reflect.TypeOf(invalidInput{}), "create"); err == nil || !strings.Contains(err.Error(), "mandatory") {
+		t.Errorf("unknown option is accepted, err=%v", err)
+	}
+}
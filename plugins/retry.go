@@ -0,0 +1,268 @@
+package plugins
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/common/errors"
+)
+
+const (
+	DEFAULT_RETRY_MAX_ATTEMPTS = 5
+	DEFAULT_RETRY_BASE_DELAY   = 500 * time.Millisecond
+	DEFAULT_RETRY_MAX_DELAY    = 10 * time.Second
+	// the signature of a request expires after 5 minutes, retries must end before that.
+	DEFAULT_RETRY_BUDGET = 60 * time.Second
+
+	QCLOUD_ERR_CODE_REQUEST_LIMIT_EXCEEDED = "RequestLimitExceeded"
+	QCLOUD_ERR_CODE_INTERNAL_ERROR         = "InternalError"
+)
+
+// throttledErrorCodes are rejected before the request is handled, retrying them is always safe.
+var throttledErrorCodes = []string{
+	QCLOUD_ERR_CODE_REQUEST_LIMIT_EXCEEDED,
+}
+
+// transientErrorCodes may be returned after the request has been handled, so they are only retried
+// for read only actions, same as network errors.
+var transientErrorCodes = []string{
+	QCLOUD_ERR_CODE_INTERNAL_ERROR,
+	"ResourceUnavailable.ServiceUnavailable",
+}
+
+// readOnlyActionPrefixes are the prefixes of cloud API actions which do not change any resource.
+var readOnlyActionPrefixes = []string{"Describe", "Get", "List", "Inquiry", "Query"}
+
+// RetryPolicy retries cloud API requests failed by throttling or transient errors
+// with jittered exponential backoff. Zero fields fall back to the defaults.
+type RetryPolicy struct {
+	// MaxAttempts of one request including the first one, 1 disables retries.
+	MaxAttempts int
+	BaseDelay   time.Duration
+	MaxDelay    time.Duration
+	// Budget is the total time one cloud API action may spend on attempts and backoff.
+	Budget time.Duration
+	// ActionBudgets overrides the budget of actions, the key is the action name such as "RunInstances".
+	ActionBudgets map[string]time.Duration
+}
+
+var (
+	retryPolicyMutex   sync.RWMutex
+	DefaultRetryPolicy = &RetryPolicy{}
+)
+
+// SetRetryPolicy replaces DefaultRetryPolicy, it may be called while requests are sent, nil restores the default one.
+func SetRetryPolicy(policy *RetryPolicy) {
+	if policy == nil {
+		policy = &RetryPolicy{}
+	}
+	retryPolicyMutex.Lock()
+	DefaultRetryPolicy = policy
+	retryPolicyMutex.Unlock()
+}
+
+func getRetryPolicy() *RetryPolicy {
+	retryPolicyMutex.RLock()
+	defer retryPolicyMutex.RUnlock()
+
+	return DefaultRetryPolicy
+}
+
+func (policy *RetryPolicy) GetMaxAttempts() int {
+	if policy.MaxAttempts <= 0 {
+		return DEFAULT_RETRY_MAX_ATTEMPTS
+	}
+	return policy.MaxAttempts
+}
+
+func (policy *RetryPolicy) GetBudget(action string) time.Duration {
+	if budget, found := policy.ActionBudgets[action]; found && budget > 0 {
+		return budget
+	}
+	if policy.Budget <= 0 {
+		return DEFAULT_RETRY_BUDGET
+	}
+	return policy.Budget
+}
+
+// GetBackoff returns a random delay in [0, min(MaxDelay, BaseDelay * 2^(attempt-1))] before the next attempt.
+func (policy *RetryPolicy) GetBackoff(attempt int) time.Duration {
+	baseDelay, maxDelay := policy.BaseDelay, policy.MaxDelay
+	if baseDelay <= 0 {
+		baseDelay = DEFAULT_RETRY_BASE_DELAY
+	}
+	if maxDelay <= 0 {
+		maxDelay = DEFAULT_RETRY_MAX_DELAY
+	}
+
+	delay := maxDelay
+	if attempt < 32 && baseDelay<<uint(attempt-1) < maxDelay {
+		delay = baseDelay << uint(attempt-1)
+	}
+	return time.Duration(rand.Int63n(int64(delay) + 1))
+}
+
+func isReadOnlyAction(action string) bool {
+	for _, prefix := range readOnlyActionPrefixes {
+		if strings.HasPrefix(action, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+func matchErrorCode(code string, codes []string) bool {
+	for _, c := range codes {
+		if code == c || strings.HasPrefix(code, c+".") {
+			return true
+		}
+	}
+	return false
+}
+
+// isRetryableError tells whether a failed call of the cloud API action can be sent again.
+func isRetryableError(action string, err error) bool {
+	if err == nil {
+		return false
+	}
+	if sdkErr, ok := err.(*errors.TencentCloudSDKError); ok {
+		if matchErrorCode(sdkErr.Code, throttledErrorCodes) {
+			return true
+		}
+		return matchErrorCode(sdkErr.Code, transientErrorCodes) && isReadOnlyAction(action)
+	}
+	if !isReadOnlyAction(action) {
+		return false
+	}
+	if err == io.EOF || err == io.ErrUnexpectedEOF {
+		return true
+	}
+	if netErr, ok := err.(net.Error); ok {
+		return netErr.Timeout() || netErr.Temporary()
+	}
+	return strings.Contains(err.Error(), "connection reset") || strings.Contains(err.Error(), "connection refused")
+}
+
+// readApiError returns the error in the body of the cloud API response, the body is kept for the caller.
+func readApiError(response *http.Response) (*errors.TencentCloudSDKError, error) {
+	body, err := ioutil.ReadAll(response.Body)
+	response.Body.Close()
+	response.Body = ioutil.NopCloser(bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+
+	apiResponse := struct {
+		Response *struct {
+			Error *struct {
+				Code    string `json:"Code"`
+				Message string `json:"Message"`
+			} `json:"Error"`
+			RequestId string `json:"RequestId"`
+		} `json:"Response"`
+	}{}
+	if json.Unmarshal(body, &apiResponse) != nil || apiResponse.Response == nil || apiResponse.Response.Error == nil {
+		return nil, nil
+	}
+	return &errors.TencentCloudSDKError{
+		Code:      apiResponse.Response.Error.Code,
+		Message:   apiResponse.Response.Error.Message,
+		RequestId: apiResponse.Response.RequestId,
+	}, nil
+}
+
+func getApiAction(request *http.Request) string {
+	// the sdk sets the header without canonicalizing its key.
+	if values := request.Header["X-TC-Action"]; len(values) > 0 {
+		return values[0]
+	}
+	if action := request.Header.Get("X-TC-Action"); action != "" {
+		return action
+	}
+	return request.URL.Query().Get("Action")
+}
+
+// retryTransport sends a cloud API request again when it fails with a retryable error,
+// until the attempts or the time budget of the action run out.
+type retryTransport struct {
+	policy *RetryPolicy
+	next   http.RoundTripper
+}
+
+func (transport *retryTransport) RoundTrip(request *http.Request) (*http.Response, error) {
+	policy := transport.policy
+	maxAttempts := policy.GetMaxAttempts()
+	action := getApiAction(request)
+	// requests without an action are not cloud API requests, such as the requests of COS.
+	if maxAttempts <= 1 || action == "" {
+		return transport.next.RoundTrip(request)
+	}
+
+	var body []byte
+	if request.Body != nil {
+		var err error
+		if body, err = ioutil.ReadAll(request.Body); err != nil {
+			return nil, err
+		}
+		request.Body.Close()
+	}
+
+	deadline := time.Now().Add(policy.GetBudget(action))
+	for attempt := 1; ; attempt++ {
+		attemptRequest := cloneRequestWithUrl(request)
+		if request.Body != nil {
+			attemptRequest.Body = ioutil.NopCloser(bytes.NewReader(body))
+		}
+
+		response, err := transport.next.RoundTrip(attemptRequest)
+		retryErr, requestId := err, ""
+		if err == nil {
+			apiErr, readErr := readApiError(response)
+			if readErr != nil {
+				return response, nil
+			}
+			if apiErr != nil {
+				retryErr, requestId = apiErr, apiErr.RequestId
+			}
+		}
+		if attempt >= maxAttempts || !isRetryableError(action, retryErr) {
+			return response, err
+		}
+
+		backoff := policy.GetBackoff(attempt)
+		if time.Now().Add(backoff).After(deadline) {
+			Logger(request.Context()).Warnf("cloud api %v retry budget %v is used up after %v attempts, requestId=%v, error=%v",
+				action, policy.GetBudget(action), attempt, requestId, retryErr)
+			return response, err
+		}
+		Logger(request.Context()).Warnf("cloud api %v attempt %v meet retryable error, retry in %v, requestId=%v, error=%v",
+			action, attempt, backoff, requestId, retryErr)
+		if response != nil {
+			response.Body.Close()
+		}
+		if err := sleepWithContext(request.Context(), backoff); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// sleepWithContext waits for the duration, it returns the error of ctx if ctx is done before that.
+func sleepWithContext(ctx context.Context, duration time.Duration) error {
+	timer := time.NewTimer(duration)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
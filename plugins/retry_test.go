@@ -0,0 +1,254 @@
+package plugins
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/common"
+	"github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/common/errors"
+	cvm "github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/cvm/v20170312"
+	vpc "github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/vpc/v20170312"
+)
+
+const okResponse = `{"Response":{"RequestId":"fake-request-id"}}`
+
+func errorResponse(code string) string {
+	return fmt.Sprintf(`{"Response":{"Error":{"Code":"%s","Message":"fake error"},"RequestId":"fake-request-id"}}`, code)
+}
+
+// newScriptedServer answers each request with the response returned by reply,
+// which gets the action and the body of the request and how many times the same request has been sent.
+func newScriptedServer(reply func(action, body string, times int) string) (*httptest.Server, func() int) {
+	mutex := sync.Mutex{}
+	requestCount := 0
+	bodyTimes := make(map[string]int)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		action := r.Header.Get("X-TC-Action")
+		body, _ := ioutil.ReadAll(r.Body)
+		mutex.Lock()
+		requestCount++
+		bodyTimes[action+string(body)]++
+		times := bodyTimes[action+string(body)]
+		mutex.Unlock()
+		w.Write([]byte(reply(action, string(body), times)))
+	}))
+	return server, func() int {
+		mutex.Lock()
+		defer mutex.Unlock()
+		return requestCount
+	}
+}
+
+func newRetryTestFactory(server *httptest.Server, policy *RetryPolicy) *ClientFactory {
+	serverUrl, _ := url.Parse(server.URL)
+	return &ClientFactory{
+		Scheme: "http",
+		Endpoints: map[string]string{
+			QCLOUD_SERVICE_CVM: serverUrl.Host,
+			QCLOUD_SERVICE_VPC: serverUrl.Host,
+		},
+		Retry:     policy,
+		RateLimit: &RateLimiter{Rate: -1, ActionRates: map[string]float64{}},
+	}
+}
+
+func getErrorCode(err error) string {
+	if sdkErr, ok := err.(*errors.TencentCloudSDKError); ok {
+		return sdkErr.GetCode()
+	}
+	return ""
+}
+
+func TestRetryTransportRetriesThrottledRequests(t *testing.T) {
+	server, getRequestCount := newScriptedServer(func(action, body string, times int) string {
+		if times <= 2 {
+			return errorResponse(QCLOUD_ERR_CODE_REQUEST_LIMIT_EXCEEDED)
+		}
+		return okResponse
+	})
+	defer server.Close()
+
+	factory := newRetryTestFactory(server, &RetryPolicy{BaseDelay: time.Millisecond})
+	client, _ := factory.NewCvmClient("ap-guangzhou", "fake-secret-id", "fake-secret-key")
+	if _, err := client.RunInstances(cvm.NewRunInstancesRequest()); err != nil {
+		t.Fatalf("RunInstances meet error=%v", err)
+	}
+	if count := getRequestCount(); count != 3 {
+		t.Errorf("server got %d requests, expected 3", count)
+	}
+}
+
+func TestRetryTransportRetriesInternalErrorOfReadOnlyAction(t *testing.T) {
+	server, getRequestCount := newScriptedServer(func(action, body string, times int) string {
+		if times == 1 {
+			return errorResponse(QCLOUD_ERR_CODE_INTERNAL_ERROR)
+		}
+		return okResponse
+	})
+	defer server.Close()
+
+	factory := newRetryTestFactory(server, &RetryPolicy{BaseDelay: time.Millisecond})
+	client, _ := factory.NewCvmClient("ap-guangzhou", "fake-secret-id", "fake-secret-key")
+	if _, err := client.DescribeInstances(cvm.NewDescribeInstancesRequest()); err != nil {
+		t.Fatalf("DescribeInstances meet error=%v", err)
+	}
+	if count := getRequestCount(); count != 2 {
+		t.Errorf("DescribeInstances, server got %d requests, expected 2", count)
+	}
+
+	_, err := client.RunInstances(cvm.NewRunInstancesRequest())
+	if code := getErrorCode(err); code != QCLOUD_ERR_CODE_INTERNAL_ERROR {
+		t.Errorf("RunInstances, error code=%v, error=%v", code, err)
+	}
+	if count := getRequestCount(); count != 3 {
+		t.Errorf("RunInstances, server got %d requests, expected 1", count-2)
+	}
+}
+
+func TestRetryTransportDoesNotRetryOtherErrors(t *testing.T) {
+	server, getRequestCount := newScriptedServer(func(action, body string, times int) string {
+		return errorResponse("InvalidParameterValue.Range")
+	})
+	defer server.Close()
+
+	factory := newRetryTestFactory(server, &RetryPolicy{BaseDelay: time.Millisecond})
+	client, _ := factory.NewCvmClient("ap-guangzhou", "fake-secret-id", "fake-secret-key")
+	_, err := client.DescribeInstances(cvm.NewDescribeInstancesRequest())
+	if code := getErrorCode(err); code != "InvalidParameterValue.Range" {
+		t.Errorf("error code=%v, error=%v", code, err)
+	}
+	if count := getRequestCount(); count != 1 {
+		t.Errorf("server got %d requests, expected 1", count)
+	}
+}
+
+func TestRetryTransportStopsAfterAttemptsOrBudget(t *testing.T) {
+	server, getRequestCount := newScriptedServer(func(action, body string, times int) string {
+		return errorResponse(QCLOUD_ERR_CODE_REQUEST_LIMIT_EXCEEDED)
+	})
+	defer server.Close()
+
+	factory := newRetryTestFactory(server, &RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond})
+	client, _ := factory.NewCvmClient("ap-guangzhou", "fake-secret-id", "fake-secret-key")
+	_, err := client.RunInstances(cvm.NewRunInstancesRequest())
+	if code := getErrorCode(err); code != QCLOUD_ERR_CODE_REQUEST_LIMIT_EXCEEDED {
+		t.Errorf("error code=%v, error=%v", code, err)
+	}
+	if count := getRequestCount(); count != 3 {
+		t.Errorf("max attempts, server got %d requests, expected 3", count)
+	}
+
+	factory = newRetryTestFactory(server, &RetryPolicy{
+		BaseDelay:     time.Millisecond,
+		ActionBudgets: map[string]time.Duration{"RunInstances": time.Nanosecond},
+	})
+	client, _ = factory.NewCvmClient("ap-guangzhou", "fake-secret-id", "fake-secret-key")
+	_, err = client.RunInstances(cvm.NewRunInstancesRequest())
+	if code := getErrorCode(err); code != QCLOUD_ERR_CODE_REQUEST_LIMIT_EXCEEDED {
+		t.Errorf("error code=%v, error=%v", code, err)
+	}
+	if count := getRequestCount(); count != 4 {
+		t.Errorf("budget, server got %d requests, expected 1", count-3)
+	}
+}
+
+func TestRetryTransportBatchOfThrottledPolicies(t *testing.T) {
+	server, _ := newScriptedServer(func(action, body string, times int) string {
+		if times <= 3 {
+			return errorResponse(QCLOUD_ERR_CODE_REQUEST_LIMIT_EXCEEDED)
+		}
+		return okResponse
+	})
+	defer server.Close()
+
+	factory := newRetryTestFactory(server, &RetryPolicy{BaseDelay: time.Millisecond})
+	errs := make(chan error, 50)
+	wg := sync.WaitGroup{}
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			client, _ := factory.NewVpcClient("ap-guangzhou", "fake-secret-id", "fake-secret-key")
+			request := vpc.NewCreateSecurityGroupPoliciesRequest()
+			request.SecurityGroupId = common.StringPtr(fmt.Sprintf("sg-%08d", i))
+			request.SecurityGroupPolicySet = &vpc.SecurityGroupPolicySet{
+				Ingress: []*vpc.SecurityGroupPolicy{{
+					Protocol:  common.StringPtr("TCP"),
+					Port:      common.StringPtr("80"),
+					CidrBlock: common.StringPtr("10.0.0.0/8"),
+					Action:    common.StringPtr("ACCEPT"),
+				}},
+			}
+			_, err := client.CreateSecurityGroupPolicies(request)
+			errs <- err
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		if err != nil {
+			t.Errorf("CreateSecurityGroupPolicies meet error=%v", err)
+		}
+	}
+}
+
+func TestIsRetryableError(t *testing.T) {
+	cases := []struct {
+		action    string
+		err       error
+		retryable bool
+	}{
+		{"RunInstances", errors.NewTencentCloudSDKError("RequestLimitExceeded", "", ""), true},
+		{"RunInstances", errors.NewTencentCloudSDKError("RequestLimitExceeded.UinLimitExceeded", "", ""), true},
+		{"RunInstances", errors.NewTencentCloudSDKError("InternalError", "", ""), false},
+		{"DescribeInstances", errors.NewTencentCloudSDKError("InternalError.DbError", "", ""), true},
+		{"DescribeInstances", errors.NewTencentCloudSDKError("InternalErrorX", "", ""), false},
+		{"DescribeInstances", errors.NewTencentCloudSDKError("ResourceNotFound", "", ""), false},
+		{"DescribeInstances", fmt.Errorf("read: connection reset by peer"), true},
+		{"RunInstances", fmt.Errorf("read: connection reset by peer"), false},
+		{"DescribeInstances", nil, false},
+	}
+	for _, c := range cases {
+		if retryable := isRetryableError(c.action, c.err); retryable != c.retryable {
+			t.Errorf("action=%v, error=%v, retryable=%v, expected %v", c.action, c.err, retryable, c.retryable)
+		}
+	}
+}
+
+func TestRetryTransportStopsWhenContextIsDone(t *testing.T) {
+	server, getRequestCount := newScriptedServer(func(action, body string, times int) string {
+		return errorResponse(QCLOUD_ERR_CODE_REQUEST_LIMIT_EXCEEDED)
+	})
+	defer server.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	factory := newRetryTestFactory(server, &RetryPolicy{BaseDelay: time.Millisecond}).WithContext(ctx)
+	client, _ := factory.NewCvmClient("ap-guangzhou", "fake-secret-id", "fake-secret-key")
+	if _, err := client.DescribeInstances(cvm.NewDescribeInstancesRequest()); err == nil {
+		t.Errorf("canceled request succeeded")
+	}
+	if count := getRequestCount(); count != 0 {
+		t.Errorf("canceled context, server got %d requests, expected 0", count)
+	}
+
+	ctx, cancel = context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	factory = newRetryTestFactory(server, &RetryPolicy{MaxAttempts: 100, BaseDelay: 10 * time.Second, MaxDelay: 10 * time.Second}).WithContext(ctx)
+	client, _ = factory.NewCvmClient("ap-guangzhou", "fake-secret-id", "fake-secret-key")
+	start := time.Now()
+	if _, err := client.DescribeInstances(cvm.NewDescribeInstancesRequest()); err == nil {
+		t.Errorf("throttled request succeeded")
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("retry backoff is not stopped by the deadline, elapsed=%v", elapsed)
+	}
+}
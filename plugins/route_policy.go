@@ -1,12 +1,12 @@
 package plugins
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"strconv"
 	"strings"
 
-	"github.com/sirupsen/logrus"
 	vpc "github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/vpc/v20170312"
 )
 
@@ -29,22 +29,26 @@ func (plugin *RoutePolicyPlugin) GetActionByName(actionName string) (Action, err
 	return action, nil
 }
 
+func (plugin *RoutePolicyPlugin) GetActions() map[string]Action {
+	return RoutePolicyActions
+}
+
 type CreateRoutePolicyInputs struct {
 	Inputs []CreateRoutePolicyInput `json:"inputs,omitempty"`
 }
 
 type CreateRoutePolicyInput struct {
 	CallBackParameter
-	Guid            string `json:"guid,omitempty"`
-	Id              string `json:"id,omitempty"`
-	ProviderParams  string `json:"provider_params,omitempty"`
-	RouteTableId    string `json:"route_table_id,omitempty"`
-	DestinationCidr string `json:"dest_cidr,omitempty"`
-	GatewayType     string `json:"gateway_type,omitempty"`
-	GatewayId       string `json:"gateway_id,omitempty"`
-	Description     string `json:"desc,omitempty"`
-	Location        string `json:"location"`
-	APISecret       string `json:"api_secret"`
+	Guid            string `json:"guid,omitempty" register:"required"`
+	Id              string `json:"id,omitempty" register:"optional"`
+	ProviderParams  string `json:"provider_params,omitempty" register:"optional"`
+	RouteTableId    string `json:"route_table_id,omitempty" register:"required"`
+	DestinationCidr string `json:"dest_cidr,omitempty" register:"required"`
+	GatewayType     string `json:"gateway_type,omitempty" register:"required" enum:"route_gateway_type"`
+	GatewayId       string `json:"gateway_id,omitempty" register:"required"`
+	Description     string `json:"desc,omitempty" register:"optional"`
+	Location        string `json:"location" register:"required"`
+	APISecret       string `json:"api_secret" register:"required,system_variable=QCLOUD_API_SECRET"`
 }
 
 type CreateRoutePolicyOutputs struct {
@@ -55,14 +59,14 @@ type CreateRoutePolicyOutput struct {
 	CallBackParameter
 	Result
 	RequestId string `json:"request_id,omitempty"`
-	Guid      string `json:"guid,omitempty"`
-	Id        string `json:"id,omitempty"`
+	Guid      string `json:"guid,omitempty" register:"optional"`
+	Id        string `json:"id,omitempty" register:"optional"`
 }
 
 type CreateRoutePolicyAction struct {
 }
 
-func (action *CreateRoutePolicyAction) ReadParam(param interface{}) (interface{}, error) {
+func (action *CreateRoutePolicyAction) ReadParam(ctx context.Context, param interface{}) (interface{}, error) {
 	var inputs CreateRoutePolicyInputs
 	err := UnmarshalJson(param, &inputs)
 	if err != nil {
@@ -71,14 +75,15 @@ func (action *CreateRoutePolicyAction) ReadParam(param interface{}) (interface{}
 	return inputs, nil
 }
 
+// routeGatewayTypes are the valid types of the next hops of routes, they are case insensitive.
+var routeGatewayTypes = []string{
+	"CVM", "VPN", "DIRECTCONNECT", "PEERCONNECTION", "SSLVPN",
+	"NAT", "NORMAL_CVM", "EIP", "CCN",
+}
+
 func isValidGatewayType(gatewayType string) error {
 	upperGatewayType := strings.ToUpper(gatewayType)
-	validGatewayTypes := []string{
-		"CVM", "VPN", "DIRECTCONNECT", "PEERCONNECTION", "SSLVPN",
-		"NAT", "NORMAL_CVM", "EIP", "CCN",
-	}
-
-	for _, validGatewayType := range validGatewayTypes {
+	for _, validGatewayType := range routeGatewayTypes {
 		if upperGatewayType == validGatewayType {
 			return nil
 		}
@@ -87,12 +92,12 @@ func isValidGatewayType(gatewayType string) error {
 	return fmt.Errorf("invalid gatewayType %s", gatewayType)
 }
 
-func isRouteConflicts(input CreateRoutePolicyInput) error {
-	if input.Location != "" && input.APISecret != "" {
-		input.ProviderParams = fmt.Sprintf("%s;%s", input.Location, input.APISecret)
+func isRouteConflicts(ctx context.Context, input CreateRoutePolicyInput) error {
+	params, err := NewProviderParams(input.ProviderParams, input.Location, input.APISecret)
+	if err != nil {
+		return err
 	}
-	paramsMap, _ := GetMapFromProviderParams(input.ProviderParams)
-	client, err := CreateRouteTableClient(paramsMap["Region"], paramsMap["SecretID"], paramsMap["SecretKey"])
+	client, err := CreateRouteTableClient(ctx, params)
 	if err != nil {
 		return err
 	}
@@ -103,7 +108,7 @@ func isRouteConflicts(input CreateRoutePolicyInput) error {
 
 	response, err := client.DescribeRouteConflicts(request)
 	if err != nil {
-		logrus.Errorf("DescribeRouteConflicts meet err=%v", err)
+		Logger(ctx).Errorf("DescribeRouteConflicts meet err=%v", err)
 		return err
 	}
 	if len(response.Response.RouteConflictSet) != 1 {
@@ -115,14 +120,14 @@ func isRouteConflicts(input CreateRoutePolicyInput) error {
 			conflictCidr := fmt.Sprintf("%s(%d)", *route.DestinationCidrBlock, *route.RouteId)
 			conflictDestCidrs = append(conflictDestCidrs, conflictCidr)
 		}
-		logrus.Errorf("route conflict,conflictSet=%++v", strings.Join(conflictDestCidrs, ","))
+		Logger(ctx).Errorf("route conflict,conflictSet=%++v", strings.Join(conflictDestCidrs, ","))
 		return fmt.Errorf("route conflict,confclitSet=%++v", strings.Join(conflictDestCidrs, ","))
 	}
 
 	return nil
 }
 
-func createRoutePolicyCheckParam(input CreateRoutePolicyInput) error {
+func createRoutePolicyCheckParam(ctx context.Context, input CreateRoutePolicyInput) error {
 	if input.ProviderParams == "" {
 		if input.Location == "" {
 			return errors.New("CreateRoutePolicyAction input Location is empty")
@@ -137,7 +142,7 @@ func createRoutePolicyCheckParam(input CreateRoutePolicyInput) error {
 	if err := isValidGatewayType(input.GatewayType); err != nil {
 		return err
 	}
-	if err := isRouteConflicts(input); err != nil {
+	if err := isRouteConflicts(ctx, input); err != nil {
 		return err
 	}
 
@@ -154,56 +159,54 @@ func createRoutePolicyCheckParam(input CreateRoutePolicyInput) error {
 	return nil
 }
 
-func (action *CreateRoutePolicyAction) Do(input interface{}) (interface{}, error) {
-	outputs := CreateRoutePolicyOutputs{}
+func (action *CreateRoutePolicyAction) Do(ctx context.Context, input interface{}) (interface{}, error) {
 	inputs, _ := input.(CreateRoutePolicyInputs)
+	outputs := CreateRoutePolicyOutputs{Outputs: make([]CreateRoutePolicyOutput, len(inputs.Inputs))}
 	enable := true
-	var finalErr error
 
-	for _, input := range inputs.Inputs {
+	finalErr := runInputs(ctx, len(inputs.Inputs), func(i int) []string {
+		return []string{inputs.Inputs[i].Guid, inputs.Inputs[i].RouteTableId}
+	}, func(ctx context.Context, i int) error {
+		input := inputs.Inputs[i]
 		output := CreateRoutePolicyOutput{
 			Guid: input.Guid,
 		}
 		output.CallBackParameter.Parameter = input.CallBackParameter.Parameter
 		output.Result.Code = RESULT_CODE_SUCCESS
 
-		if err := createRoutePolicyCheckParam(input); err != nil {
-			output.Result.Code = RESULT_CODE_ERROR
-			output.Result.Message = err.Error()
-			outputs.Outputs = append(outputs.Outputs, output)
-			finalErr = err
-			continue
+		if err := AsValidationError(createRoutePolicyCheckParam(ctx, input)); err != nil {
+			output.Result.SetError(err)
+			outputs.Outputs[i] = output
+			return err
 		}
 
-		if input.Location != "" && input.APISecret != "" {
-			input.ProviderParams = fmt.Sprintf("%s;%s", input.Location, input.APISecret)
+		params, err := NewProviderParams(input.ProviderParams, input.Location, input.APISecret)
+		if err != nil {
+			output.Result.SetError(err)
+			outputs.Outputs[i] = output
+			return err
 		}
-		paramsMap, _ := GetMapFromProviderParams(input.ProviderParams)
-		client, err := CreateRouteTableClient(paramsMap["Region"], paramsMap["SecretID"], paramsMap["SecretKey"])
+		client, err := CreateRouteTableClient(ctx, params)
 		if err != nil {
-			output.Result.Code = RESULT_CODE_ERROR
-			output.Result.Message = err.Error()
-			outputs.Outputs = append(outputs.Outputs, output)
-			finalErr = err
-			continue
+			output.Result.SetError(err)
+			outputs.Outputs[i] = output
+			return err
 		}
 
 		// check wether the route policy is exist.
 		if input.Id != "" {
 			route, ok, err := queryRoutePolicyById(client, input.Id, input.RouteTableId)
 			if err != nil {
-				output.Result.Code = RESULT_CODE_ERROR
-				output.Result.Message = err.Error()
-				outputs.Outputs = append(outputs.Outputs, output)
-				finalErr = err
-				continue
+				output.Result.SetError(err)
+				outputs.Outputs[i] = output
+				return err
 			}
 			if ok {
-				logrus.Infof("the route[id=%v] is exist.", input.Id)
+				Logger(ctx).Infof("the route[id=%v] is exist.", input.Id)
 				output.RequestId = "legacy qcloud API doesn't support returnning request id"
 				output.Id = strconv.Itoa(int(*route.RouteId))
-				outputs.Outputs = append(outputs.Outputs, output)
-				continue
+				outputs.Outputs[i] = output
+				return nil
 			}
 		}
 
@@ -223,26 +226,23 @@ func (action *CreateRoutePolicyAction) Do(input interface{}) (interface{}, error
 
 		response, err := client.CreateRoutes(request)
 		if err != nil {
-			output.Result.Code = RESULT_CODE_ERROR
-			output.Result.Message = err.Error()
-			outputs.Outputs = append(outputs.Outputs, output)
-			finalErr = err
-			continue
+			output.Result.SetError(err)
+			outputs.Outputs[i] = output
+			return err
 		}
 
 		if *response.Response.TotalCount != 1 {
 			err = fmt.Errorf("createRoutePolicy add count(%d)!=1", response.Response.TotalCount)
-			output.Result.Code = RESULT_CODE_ERROR
-			output.Result.Message = err.Error()
-			outputs.Outputs = append(outputs.Outputs, output)
-			finalErr = err
-			continue
+			output.Result.SetError(err)
+			outputs.Outputs[i] = output
+			return err
 		}
 
 		output.RequestId = *response.Response.RequestId
 		output.Id = fmt.Sprintf("%d", *response.Response.RouteTableSet[0].RouteSet[0].RouteId)
-		outputs.Outputs = append(outputs.Outputs, output)
-	}
+		outputs.Outputs[i] = output
+		return nil
+	})
 
 	return &outputs, finalErr
 }
@@ -276,12 +276,12 @@ type DeleteRoutePolicyInputs struct {
 }
 type DeleteRoutePolicyInput struct {
 	CallBackParameter
-	Guid           string `json:"guid,omitempty"`
-	Id             string `json:"id,omitempty"`
-	ProviderParams string `json:"provider_params,omitempty"`
-	RouteTableId   string `json:"route_table_id,omitempty"`
-	Location       string `json:"location"`
-	APISecret      string `json:"api_secret"`
+	Guid           string `json:"guid,omitempty" register:"required"`
+	Id             string `json:"id,omitempty" register:"required"`
+	ProviderParams string `json:"provider_params,omitempty" register:"optional"`
+	RouteTableId   string `json:"route_table_id,omitempty" register:"required"`
+	Location       string `json:"location" register:"required"`
+	APISecret      string `json:"api_secret" register:"required,system_variable=QCLOUD_API_SECRET"`
 }
 
 type DeleteRoutePolicyOutputs struct {
@@ -292,13 +292,13 @@ type DeleteRoutePolicyOutput struct {
 	CallBackParameter
 	Result
 	RequestId string `json:"request_id,omitempty"`
-	Guid      string `json:"guid,omitempty"`
+	Guid      string `json:"guid,omitempty" register:"optional"`
 }
 
 type DeleteRoutePolicyAction struct {
 }
 
-func (action *DeleteRoutePolicyAction) ReadParam(param interface{}) (interface{}, error) {
+func (action *DeleteRoutePolicyAction) ReadParam(ctx context.Context, param interface{}) (interface{}, error) {
 	var inputs DeleteRoutePolicyInputs
 	err := UnmarshalJson(param, &inputs)
 	if err != nil {
@@ -328,64 +328,59 @@ func deleteRoutePolicyCheckParam(input DeleteRoutePolicyInput) error {
 	return nil
 }
 
-func (action *DeleteRoutePolicyAction) Do(input interface{}) (interface{}, error) {
+func (action *DeleteRoutePolicyAction) Do(ctx context.Context, input interface{}) (interface{}, error) {
 	inputs, _ := input.(DeleteRoutePolicyInputs)
-	outputs := DeleteRoutePolicyOutputs{}
-	var finalErr error
-
-	for _, input := range inputs.Inputs {
+	outputs := DeleteRoutePolicyOutputs{Outputs: make([]DeleteRoutePolicyOutput, len(inputs.Inputs))}
+	finalErr := runInputs(ctx, len(inputs.Inputs), func(i int) []string {
+		return []string{inputs.Inputs[i].Guid, inputs.Inputs[i].RouteTableId}
+	}, func(ctx context.Context, i int) error {
+		input := inputs.Inputs[i]
 		output := DeleteRoutePolicyOutput{
 			Guid: input.Guid,
 		}
 		output.CallBackParameter.Parameter = input.CallBackParameter.Parameter
 		output.Result.Code = RESULT_CODE_SUCCESS
 
-		if err := deleteRoutePolicyCheckParam(input); err != nil {
-			output.Result.Code = RESULT_CODE_ERROR
-			output.Result.Message = err.Error()
-			outputs.Outputs = append(outputs.Outputs, output)
-			finalErr = err
-			continue
+		if err := AsValidationError(deleteRoutePolicyCheckParam(input)); err != nil {
+			output.Result.SetError(err)
+			outputs.Outputs[i] = output
+			return err
 		}
 
-		if input.Location != "" && input.APISecret != "" {
-			input.ProviderParams = fmt.Sprintf("%s;%s", input.Location, input.APISecret)
+		params, err := NewProviderParams(input.ProviderParams, input.Location, input.APISecret)
+		if err != nil {
+			output.Result.SetError(err)
+			outputs.Outputs[i] = output
+			return err
 		}
-		paramsMap, _ := GetMapFromProviderParams(input.ProviderParams)
-		client, err := CreateRouteTableClient(paramsMap["Region"], paramsMap["SecretID"], paramsMap["SecretKey"])
+		client, err := CreateRouteTableClient(ctx, params)
 		if err != nil {
-			output.Result.Code = RESULT_CODE_ERROR
-			output.Result.Message = err.Error()
-			outputs.Outputs = append(outputs.Outputs, output)
-			finalErr = err
-			continue
+			output.Result.SetError(err)
+			outputs.Outputs[i] = output
+			return err
 		}
 
 		// check wether the route policy is exist.
 		_, ok, err := queryRoutePolicyById(client, input.Id, input.RouteTableId)
 		if err != nil {
-			output.Result.Code = RESULT_CODE_ERROR
-			output.Result.Message = err.Error()
-			outputs.Outputs = append(outputs.Outputs, output)
-			finalErr = err
-			continue
+			output.Result.SetError(err)
+			outputs.Outputs[i] = output
+			return err
 		}
 		if !ok {
-			logrus.Infof("the route[id=%v] is not exist.", input.Id)
+			Logger(ctx).Infof("the route[id=%v] is not exist.", input.Id)
 			output.RequestId = "legacy qcloud API doesn't support returnning request id"
-			outputs.Outputs = append(outputs.Outputs, output)
-			continue
+			outputs.Outputs[i] = output
+			return nil
 		}
 
 		request := vpc.NewDeleteRoutesRequest()
 		request.RouteTableId = &input.RouteTableId
 		routePolicyId, err := strconv.ParseUint(input.Id, 10, 0)
 		if err != nil {
-			output.Result.Code = RESULT_CODE_ERROR
-			output.Result.Message = err.Error()
-			outputs.Outputs = append(outputs.Outputs, output)
-			finalErr = err
-			continue
+			output.Result.SetError(err)
+			outputs.Outputs[i] = output
+			return err
 		}
 		route := vpc.Route{
 			RouteId: &routePolicyId,
@@ -394,15 +389,14 @@ func (action *DeleteRoutePolicyAction) Do(input interface{}) (interface{}, error
 		request.Routes = []*vpc.Route{&route}
 		response, err := client.DeleteRoutes(request)
 		if err != nil {
-			output.Result.Code = RESULT_CODE_ERROR
-			output.Result.Message = err.Error()
-			outputs.Outputs = append(outputs.Outputs, output)
-			finalErr = err
-			continue
+			output.Result.SetError(err)
+			outputs.Outputs[i] = output
+			return err
 		}
 
 		output.RequestId = *response.Response.RequestId
-		outputs.Outputs = append(outputs.Outputs, output)
-	}
+		outputs.Outputs[i] = output
+		return nil
+	})
 	return &outputs, finalErr
 }
@@ -1,13 +1,12 @@
 package plugins
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"strings"
 
 	"github.com/sirupsen/logrus"
-	"github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/common"
-	"github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/common/profile"
 	vpc "github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/vpc/v20170312"
 )
 
@@ -31,13 +30,12 @@ func (plugin *RouteTablePlugin) GetActionByName(actionName string) (Action, erro
 	return action, nil
 }
 
-func CreateRouteTableClient(region, secretId, secretKey string) (client *vpc.Client, err error) {
-	credential := common.NewCredential(secretId, secretKey)
-
-	clientProfile := profile.NewClientProfile()
-	clientProfile.HttpProfile.Endpoint = "vpc.tencentcloudapi.com"
+func (plugin *RouteTablePlugin) GetActions() map[string]Action {
+	return RouteTableActions
+}
 
-	return vpc.NewClient(credential, region, clientProfile)
+func CreateRouteTableClient(ctx context.Context, params *ProviderParams) (client *vpc.Client, err error) {
+	return GetClientFactory().WithContext(ctx).WithProviderParams(params).NewVpcClient(params.Region, params.SecretID, params.SecretKey)
 }
 
 type RouteTableInputs struct {
@@ -46,13 +44,13 @@ type RouteTableInputs struct {
 
 type RouteTableInput struct {
 	CallBackParameter
-	Guid           string `json:"guid,omitempty"`
-	ProviderParams string `json:"provider_params,omitempty"`
-	Id             string `json:"id,omitempty"`
-	Name           string `json:"name,omitempty"`
-	VpcId          string `json:"vpc_id,omitempty"`
-	Location       string `json:"location"`
-	APISecret      string `json:"api_secret"`
+	Guid           string `json:"guid,omitempty" register:"required"`
+	ProviderParams string `json:"provider_params,omitempty" register:"optional"`
+	Id             string `json:"id,omitempty" register:"required=terminate"`
+	Name           string `json:"name,omitempty" register:"required,actions=create"`
+	VpcId          string `json:"vpc_id,omitempty" register:"required,actions=create"`
+	Location       string `json:"location" register:"required"`
+	APISecret      string `json:"api_secret" register:"required,system_variable=QCLOUD_API_SECRET"`
 }
 
 type RouteTableOutputs struct {
@@ -63,14 +61,14 @@ type RouteTableOutput struct {
 	CallBackParameter
 	Result
 	RequestId string `json:"request_id,omitempty"`
-	Guid      string `json:"guid,omitempty"`
-	Id        string `json:"id,omitempty"`
+	Guid      string `json:"guid,omitempty" register:"optional"`
+	Id        string `json:"id,omitempty" register:"optional,actions=create"`
 }
 
 type RouteTableCreateAction struct {
 }
 
-func (action *RouteTableCreateAction) ReadParam(param interface{}) (interface{}, error) {
+func (action *RouteTableCreateAction) ReadParam(ctx context.Context, param interface{}) (interface{}, error) {
 	var inputs RouteTableInputs
 	err := UnmarshalJson(param, &inputs)
 	if err != nil {
@@ -99,27 +97,26 @@ func routeTableCreateCheckParam(routeTable *RouteTableInput) error {
 	return nil
 }
 
-func (action *RouteTableCreateAction) createRouteTable(input *RouteTableInput) (output RouteTableOutput, err error) {
+func (action *RouteTableCreateAction) createRouteTable(ctx context.Context, input *RouteTableInput) (output RouteTableOutput, err error) {
 	output.Guid = input.Guid
 	output.Result.Code = RESULT_CODE_SUCCESS
 	output.CallBackParameter.Parameter = input.CallBackParameter.Parameter
 
 	defer func() {
 		if err != nil {
-			output.Result.Code = RESULT_CODE_ERROR
-			output.Result.Message = err.Error()
+			output.Result.SetError(err)
 		}
 	}()
 
-	if err = routeTableCreateCheckParam(input); err != nil {
+	if err = AsValidationError(routeTableCreateCheckParam(input)); err != nil {
 		return output, err
 	}
 
-	if input.Location != "" && input.APISecret != "" {
-		input.ProviderParams = fmt.Sprintf("%s;%s", input.Location, input.APISecret)
+	params, err := NewProviderParams(input.ProviderParams, input.Location, input.APISecret)
+	if err != nil {
+		return output, err
 	}
-	paramsMap, _ := GetMapFromProviderParams(input.ProviderParams)
-	client, err := CreateRouteTableClient(paramsMap["Region"], paramsMap["SecretID"], paramsMap["SecretKey"])
+	client, err := CreateRouteTableClient(ctx, params)
 	if err != nil {
 		return output, err
 	}
@@ -153,27 +150,26 @@ func (action *RouteTableCreateAction) createRouteTable(input *RouteTableInput) (
 	return output, err
 }
 
-func (action *RouteTableCreateAction) Do(input interface{}) (interface{}, error) {
+func (action *RouteTableCreateAction) Do(ctx context.Context, input interface{}) (interface{}, error) {
 	inputs, _ := input.(RouteTableInputs)
-	var finalErr error
-	outputs := RouteTableOutputs{}
-	for _, input := range inputs.Inputs {
-		output, err := action.createRouteTable(&input)
-		if err != nil {
-			finalErr = err
-		}
-
-		outputs.Outputs = append(outputs.Outputs, output)
-	}
+	outputs := RouteTableOutputs{Outputs: make([]RouteTableOutput, len(inputs.Inputs))}
+	finalErr := runInputs(ctx, len(inputs.Inputs), func(i int) []string {
+		return []string{inputs.Inputs[i].Guid, inputs.Inputs[i].Id}
+	}, func(ctx context.Context, i int) error {
+		input := inputs.Inputs[i]
+		output, err := action.createRouteTable(ctx, &input)
+		outputs.Outputs[i] = output
+		return err
+	})
 
-	logrus.Infof("all routeTable = %v are created", outputs)
+	Logger(ctx).Infof("all routeTable = %s are created", Sanitize(outputs))
 	return &outputs, finalErr
 }
 
 type RouteTableTerminateAction struct {
 }
 
-func (action *RouteTableTerminateAction) ReadParam(param interface{}) (interface{}, error) {
+func (action *RouteTableTerminateAction) ReadParam(ctx context.Context, param interface{}) (interface{}, error) {
 	var inputs RouteTableInputs
 	err := UnmarshalJson(param, &inputs)
 	if err != nil {
@@ -197,9 +193,12 @@ func routeTableTerminateCheckParam(routeTable *RouteTableInput) error {
 	return nil
 }
 
-func makeSureRouteTableHasNoPolicy(input RouteTableInput) (bool, error) {
-	paramsMap, _ := GetMapFromProviderParams(input.ProviderParams)
-	client, err := CreateRouteTableClient(paramsMap["Region"], paramsMap["SecretID"], paramsMap["SecretKey"])
+func makeSureRouteTableHasNoPolicy(ctx context.Context, input RouteTableInput) (bool, error) {
+	params, err := NewProviderParams(input.ProviderParams, input.Location, input.APISecret)
+	if err != nil {
+		return false, err
+	}
+	client, err := CreateRouteTableClient(ctx, params)
 	if err != nil {
 		return false, err
 	}
@@ -219,37 +218,37 @@ func makeSureRouteTableHasNoPolicy(input RouteTableInput) (bool, error) {
 	return true, nil
 }
 
-func (action *RouteTableTerminateAction) terminateRouteTable(routeTable *RouteTableInput) (output RouteTableOutput, err error) {
+func (action *RouteTableTerminateAction) terminateRouteTable(ctx context.Context, routeTable *RouteTableInput) (output RouteTableOutput, err error) {
 	output.Guid = routeTable.Guid
 	output.Result.Code = RESULT_CODE_SUCCESS
 	output.CallBackParameter.Parameter = routeTable.CallBackParameter.Parameter
 
 	defer func() {
 		if err != nil {
-			output.Result.Message = err.Error()
+			output.Result.SetError(err)
 			output.Result.Code = RESULT_CODE_ERROR
 		}
 	}()
 
-	if err = routeTableTerminateCheckParam(routeTable); err != nil {
+	if err = AsValidationError(routeTableTerminateCheckParam(routeTable)); err != nil {
 		return output, err
 	}
 
-	if routeTable.Location != "" && routeTable.APISecret != "" {
-		routeTable.ProviderParams = fmt.Sprintf("%s;%s", routeTable.Location, routeTable.APISecret)
+	params, err := NewProviderParams(routeTable.ProviderParams, routeTable.Location, routeTable.APISecret)
+	if err != nil {
+		return output, err
 	}
-	paramsMap, _ := GetMapFromProviderParams(routeTable.ProviderParams)
-	client, err := CreateRouteTableClient(paramsMap["Region"], paramsMap["SecretID"], paramsMap["SecretKey"])
+	client, err := CreateRouteTableClient(ctx, params)
 	if err != nil {
 		return output, err
 	}
 
-	ok, err := makeSureRouteTableHasNoPolicy(*routeTable)
+	ok, err := makeSureRouteTableHasNoPolicy(ctx, *routeTable)
 	if err != nil {
 		return output, err
 	}
 	if !ok {
-		logrus.Infof("the route table[id=%v] is not exist.", routeTable.Id)
+		Logger(ctx).Infof("the route table[id=%v] is not exist.", routeTable.Id)
 		output.RequestId = "legacy qcloud API doesn't support returnning request id"
 		output.Id = routeTable.Id
 		return output, err
@@ -270,17 +269,17 @@ func (action *RouteTableTerminateAction) terminateRouteTable(routeTable *RouteTa
 	return output, err
 }
 
-func (action *RouteTableTerminateAction) Do(input interface{}) (interface{}, error) {
+func (action *RouteTableTerminateAction) Do(ctx context.Context, input interface{}) (interface{}, error) {
 	routeTables, _ := input.(RouteTableInputs)
-	outputs := RouteTableOutputs{}
-	var finalErr error
-	for _, routeTable := range routeTables.Inputs {
-		output, err := action.terminateRouteTable(&routeTable)
-		if err != nil {
-			finalErr = err
-		}
-		outputs.Outputs = append(outputs.Outputs, output)
-	}
+	outputs := RouteTableOutputs{Outputs: make([]RouteTableOutput, len(routeTables.Inputs))}
+	finalErr := runInputs(ctx, len(routeTables.Inputs), func(i int) []string {
+		return []string{routeTables.Inputs[i].Guid, routeTables.Inputs[i].Id}
+	}, func(ctx context.Context, i int) error {
+		routeTable := routeTables.Inputs[i]
+		output, err := action.terminateRouteTable(ctx, &routeTable)
+		outputs.Outputs[i] = output
+		return err
+	})
 
 	return &outputs, finalErr
 }
@@ -316,12 +315,12 @@ type AssociateRouteTableInputs struct {
 
 type AssociateRouteTableInput struct {
 	CallBackParameter
-	Guid           string `json:"guid,omitempty"`
-	ProviderParams string `json:"provider_params,omitempty"`
-	SubnetId       string `json:"subnet_id,omitempty"`
-	RouteTableId   string `json:"route_table_id,omitempty"`
-	Location       string `json:"location"`
-	APISecret      string `json:"api_secret"`
+	Guid           string `json:"guid,omitempty" register:"required"`
+	ProviderParams string `json:"provider_params,omitempty" register:"optional"`
+	SubnetId       string `json:"subnet_id,omitempty" register:"required"`
+	RouteTableId   string `json:"route_table_id,omitempty" register:"required"`
+	Location       string `json:"location" register:"required"`
+	APISecret      string `json:"api_secret" register:"required,system_variable=QCLOUD_API_SECRET"`
 }
 
 type AssociateRouteTableOutputs struct {
@@ -332,13 +331,13 @@ type AssociateRouteTableOutput struct {
 	CallBackParameter
 	Result
 	RequestId string `json:"request_id,omitempty"`
-	Guid      string `json:"guid,omitempty"`
+	Guid      string `json:"guid,omitempty" register:"optional"`
 }
 
 type RouteTableAssociateSubnetAction struct {
 }
 
-func (action *RouteTableAssociateSubnetAction) ReadParam(param interface{}) (interface{}, error) {
+func (action *RouteTableAssociateSubnetAction) ReadParam(ctx context.Context, param interface{}) (interface{}, error) {
 	var inputs AssociateRouteTableInputs
 	err := UnmarshalJson(param, &inputs)
 	if err != nil {
@@ -366,9 +365,12 @@ func routeTableAssociateSubnetCheckParam(input AssociateRouteTableInput) error {
 	return nil
 }
 
-func associateSubnetWithRouteTable(providerParams string, subnetId string, routeTableId string) error {
-	paramsMap, _ := GetMapFromProviderParams(providerParams)
-	client, err := CreateRouteTableClient(paramsMap["Region"], paramsMap["SecretID"], paramsMap["SecretKey"])
+func associateSubnetWithRouteTable(ctx context.Context, providerParams string, subnetId string, routeTableId string) error {
+	params, err := ParseProviderParams(providerParams)
+	if err != nil {
+		return err
+	}
+	client, err := CreateRouteTableClient(ctx, params)
 	if err != nil {
 		return err
 	}
@@ -381,38 +383,39 @@ func associateSubnetWithRouteTable(providerParams string, subnetId string, route
 	return err
 }
 
-func (action *RouteTableAssociateSubnetAction) Do(input interface{}) (interface{}, error) {
-	outputs := AssociateRouteTableOutputs{}
+func (action *RouteTableAssociateSubnetAction) Do(ctx context.Context, input interface{}) (interface{}, error) {
 	inputs, _ := input.(AssociateRouteTableInputs)
-	var finalErr error
+	outputs := AssociateRouteTableOutputs{Outputs: make([]AssociateRouteTableOutput, len(inputs.Inputs))}
 
-	for _, input := range inputs.Inputs {
+	finalErr := runInputs(ctx, len(inputs.Inputs), func(i int) []string {
+		return []string{inputs.Inputs[i].Guid, inputs.Inputs[i].SubnetId, inputs.Inputs[i].RouteTableId}
+	}, func(ctx context.Context, i int) error {
+		input := inputs.Inputs[i]
 		output := AssociateRouteTableOutput{
 			Guid: input.Guid,
 		}
 		output.Result.Code = RESULT_CODE_SUCCESS
 		output.CallBackParameter.Parameter = input.CallBackParameter.Parameter
 
-		if err := routeTableAssociateSubnetCheckParam(input); err != nil {
-			output.Result.Code = RESULT_CODE_ERROR
-			output.Result.Message = err.Error()
-			finalErr = err
-			outputs.Outputs = append(outputs.Outputs, output)
-			continue
+		if err := AsValidationError(routeTableAssociateSubnetCheckParam(input)); err != nil {
+			output.Result.SetError(err)
+			outputs.Outputs[i] = output
+			return err
 		}
 
-		if input.Location != "" && input.APISecret != "" {
-			input.ProviderParams = fmt.Sprintf("%s;%s", input.Location, input.APISecret)
+		providerParams, err := mergeProviderParams(input.ProviderParams, input.Location, input.APISecret)
+		if err == nil {
+			err = associateSubnetWithRouteTable(ctx, providerParams, input.SubnetId, input.RouteTableId)
 		}
-		err := associateSubnetWithRouteTable(input.ProviderParams, input.SubnetId, input.RouteTableId)
 		if err != nil {
-			output.Result.Code = RESULT_CODE_ERROR
-			output.Result.Message = err.Error()
-			finalErr = err
+			output.Result.SetError(err)
+			outputs.Outputs[i] = output
+			return err
 		}
 
-		outputs.Outputs = append(outputs.Outputs, output)
-	}
+		outputs.Outputs[i] = output
+		return nil
+	})
 
 	return &outputs, finalErr
 }
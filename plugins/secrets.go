@@ -0,0 +1,123 @@
+package plugins
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/WeBankPartners/wecube-plugins-qcloud/plugins/utils"
+)
+
+// SecretMasterKey decrypts the api_secret and provider_params which are not encrypted with the guid and seed
+// of their input, secrets can only be encrypted with the guid and seed if it is empty.
+var SecretMasterKey string
+
+// DecryptSecret returns the plaintext of a value in the cipher envelope of utils.AesEnPassword, such as
+// "{cipher_a}...", values without the envelope are returned as they are. The value is decrypted with the guid
+// and seed of its input, or with SecretMasterKey if they do not give a valid plaintext.
+func DecryptSecret(guid, seed, value string) (string, error) {
+	if !utils.IsEncryptedPassword(value) {
+		return value, nil
+	}
+	if guid != "" {
+		if plaintext, err := utils.AesDePassword(guid, seed, value); err == nil && isValidSecret(plaintext) {
+			return plaintext, nil
+		}
+	}
+	if SecretMasterKey != "" {
+		if plaintext, err := utils.AesDePasswordByKey(SecretMasterKey, value); err == nil && isValidSecret(plaintext) {
+			return plaintext, nil
+		}
+	}
+	return "", errors.New("secret is not encrypted with the guid and seed of the input or the master key")
+}
+
+// isValidSecret tells whether a decrypted value is text, a value decrypted with a wrong key is random bytes.
+func isValidSecret(plaintext string) bool {
+	if !utf8.ValidString(plaintext) {
+		return false
+	}
+	for _, r := range plaintext {
+		if !unicode.IsPrint(r) {
+			return false
+		}
+	}
+	return true
+}
+
+// DecryptProviderParams decrypts the api_secret or provider_params of an input, the whole value or the value
+// of each item, such as "SecretKey={cipher_a}...", may be encrypted.
+func DecryptProviderParams(guid, seed, params string) (string, error) {
+	params, err := DecryptSecret(guid, seed, params)
+	if err != nil {
+		return "", err
+	}
+	items := strings.Split(params, ";")
+	for i, item := range items {
+		kv := strings.SplitN(item, "=", 2)
+		if len(kv) != 2 || !utils.IsEncryptedPassword(strings.TrimSpace(kv[1])) {
+			continue
+		}
+		value, err := DecryptSecret(guid, seed, strings.TrimSpace(kv[1]))
+		if err != nil {
+			return "", fmt.Errorf("decrypt %s meet error=%v", strings.TrimSpace(kv[0]), err)
+		}
+		items[i] = kv[0] + "=" + escapeProviderParamsValue(value)
+	}
+	return strings.Join(items, ";"), nil
+}
+
+// decryptInputSecrets returns the action param whose inputs have the plaintext of their api secrets and provider params,
+// such as APISecret and PeerProviderParams,
+// it is called by doAction so all plugins build clients with the decrypted keys. The inputs of actionParam are not
+// modified, the encrypted values are kept in the tasks and logs.
+func decryptInputSecrets(actionParam interface{}) (interface{}, error) {
+	inputs := getSliceField(actionParam, "Inputs")
+	if !inputs.IsValid() || inputs.Type().Elem().Kind() != reflect.Struct {
+		return actionParam, nil
+	}
+
+	names := []string{}
+	inputType := inputs.Type().Elem()
+	for i := 0; i < inputType.NumField(); i++ {
+		field := inputType.Field(i)
+		if field.Type.Kind() == reflect.String && (strings.HasSuffix(field.Name, "APISecret") || strings.HasSuffix(field.Name, "ProviderParams")) {
+			names = append(names, field.Name)
+		}
+	}
+
+	var decrypted reflect.Value
+	for i := 0; i < inputs.Len(); i++ {
+		input := inputs.Index(i)
+		guid, seed := getStringField(input, "Guid"), getStringField(input, "Seed")
+		for _, name := range names {
+			value := getStringField(input, name)
+			plaintext, err := DecryptProviderParams(guid, seed, value)
+			if err != nil {
+				return nil, fmt.Errorf("decrypt %s of input %s meet error=%v", name, guid, err)
+			}
+			if plaintext == value {
+				continue
+			}
+			if !decrypted.IsValid() {
+				decrypted = reflect.MakeSlice(inputs.Type(), inputs.Len(), inputs.Len())
+				reflect.Copy(decrypted, inputs)
+			}
+			decrypted.Index(i).FieldByName(name).SetString(plaintext)
+		}
+	}
+	if !decrypted.IsValid() {
+		return actionParam, nil
+	}
+	return withField(actionParam, "Inputs", decrypted), nil
+}
+
+func getStringField(v reflect.Value, name string) string {
+	if field := v.FieldByName(name); field.IsValid() && field.Kind() == reflect.String {
+		return field.String()
+	}
+	return ""
+}
@@ -0,0 +1,75 @@
+package plugins
+
+import (
+	"testing"
+
+	"github.com/WeBankPartners/wecube-plugins-qcloud/plugins/utils"
+)
+
+type secretTestInput struct {
+	Guid               string
+	Seed               string
+	ProviderParams     string
+	APISecret          string
+	PeerProviderParams string
+}
+
+type secretTestInputs struct {
+	Inputs []secretTestInput
+}
+
+func TestDecryptProviderParams(t *testing.T) {
+	defer func(key string) { SecretMasterKey = key }(SecretMasterKey)
+	SecretMasterKey = "master-key"
+
+	bySeed, _ := utils.AesEnPassword("guid_1", "seed", "SecretID=id-1;SecretKey=key-1", utils.DEFALT_CIPHER)
+	byMasterKey, _ := utils.AesEnPasswordByKey("master-key", "SecretID=id-2;SecretKey=key-2", utils.DEFALT_CIPHER)
+	secretKey, _ := utils.AesEnPasswordByKey("master-key", "key-3", utils.DEFALT_CIPHER)
+	cases := []struct {
+		params   string
+		expected string
+	}{
+		{"Region=ap-guangzhou;SecretID=id-0;SecretKey=key-0", "Region=ap-guangzhou;SecretID=id-0;SecretKey=key-0"},
+		{bySeed, "SecretID=id-1;SecretKey=key-1"},
+		{byMasterKey, "SecretID=id-2;SecretKey=key-2"},
+		{"Region=ap-guangzhou;SecretID=id-3;SecretKey=" + secretKey, "Region=ap-guangzhou;SecretID=id-3;SecretKey=key-3"},
+	}
+	for _, c := range cases {
+		if params, err := DecryptProviderParams("guid_1", "seed", c.params); err != nil || params != c.expected {
+			t.Errorf("params=%v, error=%v, expected %v", params, err, c.expected)
+		}
+	}
+
+	// encrypted with another key.
+	SecretMasterKey = "another-key"
+	if params, err := DecryptProviderParams("guid_2", "seed", byMasterKey); err == nil {
+		t.Errorf("params=%v is decrypted with a wrong key", params)
+	}
+}
+
+func TestDecryptInputSecrets(t *testing.T) {
+	apiSecret, _ := utils.AesEnPassword("guid_1", "seed", "SecretID=id-1;SecretKey=key-1", utils.DEFALT_CIPHER)
+	peerParams, _ := utils.AesEnPassword("guid_1", "seed", "Region=ap-shanghai;SecretID=id-2;SecretKey=key-2", utils.DEFALT_CIPHER)
+	param := secretTestInputs{Inputs: []secretTestInput{
+		{Guid: "guid_1", Seed: "seed", ProviderParams: "Region=ap-guangzhou", APISecret: apiSecret, PeerProviderParams: peerParams},
+		{Guid: "guid_2", ProviderParams: "Region=ap-guangzhou;SecretID=id-3;SecretKey=key-3"},
+	}}
+
+	decrypted, err := decryptInputSecrets(param)
+	if err != nil {
+		t.Fatalf("decryptInputSecrets meet error=%v", err)
+	}
+	inputs := decrypted.(secretTestInputs).Inputs
+	if inputs[0].APISecret != "SecretID=id-1;SecretKey=key-1" || inputs[0].PeerProviderParams != "Region=ap-shanghai;SecretID=id-2;SecretKey=key-2" ||
+		inputs[0].ProviderParams != "Region=ap-guangzhou" || inputs[1] != param.Inputs[1] {
+		t.Errorf("decrypted inputs=%+v", inputs)
+	}
+	if param.Inputs[0].APISecret != apiSecret {
+		t.Errorf("the inputs of the action param are modified, %+v", param.Inputs[0])
+	}
+
+	param.Inputs[0].Seed = "another-seed"
+	if _, err = decryptInputSecrets(param); err == nil {
+		t.Errorf("secret encrypted with another seed is decrypted")
+	}
+}
@@ -1,18 +1,17 @@
 package plugins
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"strings"
 
 	"github.com/sirupsen/logrus"
 	"github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/common"
-	"github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/common/profile"
 	vpc "github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/vpc/v20170312"
 )
 
 const (
-	QCLOUD_ENDPOINT_VPC                = "vpc.tencentcloudapi.com"
 	QCLOUD_ERR_CODE_RESOURCE_NOT_FOUND = "ResourceNotFound"
 )
 
@@ -33,15 +32,14 @@ func (plugin *SecurityGroupPlugin) GetActionByName(actionName string) (Action, e
 	return action, nil
 }
 
-func createVpcClient(region, secretId, secretKey string) (client *vpc.Client, err error) {
-	credential := common.NewCredential(secretId, secretKey)
-
-	clientProfile := profile.NewClientProfile()
-	clientProfile.HttpProfile.Endpoint = QCLOUD_ENDPOINT_VPC
+func (plugin *SecurityGroupPlugin) GetActions() map[string]Action {
+	return SecurityGroupActions
+}
 
-	client, err = vpc.NewClient(credential, region, clientProfile)
+func createVpcClient(ctx context.Context, params *ProviderParams) (client *vpc.Client, err error) {
+	client, err = GetClientFactory().WithContext(ctx).WithProviderParams(params).NewVpcClient(params.Region, params.SecretID, params.SecretKey)
 	if err != nil {
-		logrus.Errorf("Create Qcloud vm client failed,err=%v", err)
+		Logger(ctx).Errorf("Create Qcloud vm client failed,err=%v", err)
 	}
 	return
 }
@@ -52,13 +50,13 @@ type SecurityGroupCreateInputs struct {
 
 type SecurityGroupCreateInput struct {
 	CallBackParameter
-	Guid           string `json:"guid,omitempty"`
-	ProviderParams string `json:"provider_params,omitempty"`
-	Name           string `json:"name,omitempty"`
-	Id             string `json:"id,omitempty"`
-	Description    string `json:"description,omitempty"`
-	Location       string `json:"location"`
-	APISecret      string `json:"api_secret"`
+	Guid           string `json:"guid,omitempty" register:"required"`
+	ProviderParams string `json:"provider_params,omitempty" register:"optional"`
+	Name           string `json:"name,omitempty" register:"required"`
+	Id             string `json:"id,omitempty" register:"optional"`
+	Description    string `json:"description,omitempty" register:"required"`
+	Location       string `json:"location" register:"required"`
+	APISecret      string `json:"api_secret" register:"required,system_variable=QCLOUD_API_SECRET"`
 }
 
 type SecurityGroupCreateOutputs struct {
@@ -69,13 +67,13 @@ type SecurityGroupCreateOutput struct {
 	CallBackParameter
 	Result
 	// RequestId string `json:"request_id,omitempty"`
-	Guid string `json:"guid,omitempty"`
-	Id   string `json:"id,omitempty"`
+	Guid string `json:"guid,omitempty" register:"optional"`
+	Id   string `json:"id,omitempty" register:"optional"`
 }
 
 type SecurityGroupCreateAction struct{}
 
-func (action *SecurityGroupCreateAction) ReadParam(param interface{}) (interface{}, error) {
+func (action *SecurityGroupCreateAction) ReadParam(ctx context.Context, param interface{}) (interface{}, error) {
 	var inputs SecurityGroupCreateInputs
 	err := UnmarshalJson(param, &inputs)
 	if err != nil {
@@ -106,28 +104,27 @@ func (action *SecurityGroupCreateAction) checkCreateSecurityGroupParams(input Se
 	return nil
 }
 
-func (action *SecurityGroupCreateAction) createSecurityGroup(input *SecurityGroupCreateInput) (output SecurityGroupCreateOutput, err error) {
+func (action *SecurityGroupCreateAction) createSecurityGroup(ctx context.Context, input *SecurityGroupCreateInput) (output SecurityGroupCreateOutput, err error) {
 	defer func() {
 		output.Guid = input.Guid
 		output.CallBackParameter.Parameter = input.CallBackParameter.Parameter
 		if err == nil {
 			output.Result.Code = RESULT_CODE_SUCCESS
 		} else {
-			output.Result.Code = RESULT_CODE_ERROR
-			output.Result.Message = err.Error()
+			output.Result.SetError(err)
 		}
 	}()
 
-	if err = action.checkCreateSecurityGroupParams(*input); err != nil {
-		logrus.Errorf("checkCreateSecurityGroupParams meet error=%v", err)
+	if err = AsValidationError(action.checkCreateSecurityGroupParams(*input)); err != nil {
+		Logger(ctx).Errorf("checkCreateSecurityGroupParams meet error=%v", err)
 		return
 	}
 
-	if input.Location != "" && input.APISecret != "" {
-		input.ProviderParams = fmt.Sprintf("%s;%s", input.Location, input.APISecret)
+	params, err := NewProviderParams(input.ProviderParams, input.Location, input.APISecret)
+	if err != nil {
+		return
 	}
-	paramsMap, err := GetMapFromProviderParams(input.ProviderParams)
-	client, err := createVpcClient(paramsMap["Region"], paramsMap["SecretID"], paramsMap["SecretKey"])
+	client, err := createVpcClient(ctx, params)
 	if err != nil {
 		return
 	}
@@ -137,12 +134,12 @@ func (action *SecurityGroupCreateAction) createSecurityGroup(input *SecurityGrou
 		var ok bool
 		ok, err = querySecurityGroupsInfo(client, input.Id)
 		if err != nil {
-			logrus.Errorf("querySecurityGroupsInfo meet error=%v", err)
+			Logger(ctx).Errorf("querySecurityGroupsInfo meet error=%v", err)
 			return
 		}
 
 		if ok {
-			logrus.Infof("querySecurityGroupsInfo the securityGroup[%v] is exist", input.Id)
+			Logger(ctx).Infof("querySecurityGroupsInfo the securityGroup[%v] is exist", input.Id)
 			output.Id = input.Id
 			return
 		}
@@ -154,11 +151,11 @@ func (action *SecurityGroupCreateAction) createSecurityGroup(input *SecurityGrou
 
 	response, err := client.CreateSecurityGroup(request)
 	if err != nil {
-		logrus.Errorf("CreateSecurityGroup meet error=%v", err)
+		Logger(ctx).Errorf("CreateSecurityGroup meet error=%v", err)
 		return
 	}
 	output.Id = *response.Response.SecurityGroup.SecurityGroupId
-	logrus.Infof("create SecurityGroup's request has been submitted, SecurityGroupId is [%v], RequestID is [%v]", output.Id, *response.Response.RequestId)
+	Logger(ctx).Infof("create SecurityGroup's request has been submitted, SecurityGroupId is [%v], RequestID is [%v]", output.Id, *response.Response.RequestId)
 
 	return
 }
@@ -187,19 +184,19 @@ func querySecurityGroupsInfo(client *vpc.Client, securityGroupId string) (bool,
 	return true, nil
 }
 
-func (action *SecurityGroupCreateAction) Do(input interface{}) (interface{}, error) {
+func (action *SecurityGroupCreateAction) Do(ctx context.Context, input interface{}) (interface{}, error) {
 	securityGroups, _ := input.(SecurityGroupCreateInputs)
-	outputs := SecurityGroupCreateOutputs{}
-	var finalErr error
-	for _, securityGroup := range securityGroups.Inputs {
-		output, err := action.createSecurityGroup(&securityGroup)
-		if err != nil {
-			finalErr = err
-		}
-		outputs.Outputs = append(outputs.Outputs, output)
-	}
-
-	logrus.Infof("all securityGroups = %v are created", securityGroups)
+	outputs := SecurityGroupCreateOutputs{Outputs: make([]SecurityGroupCreateOutput, len(securityGroups.Inputs))}
+	finalErr := runInputs(ctx, len(securityGroups.Inputs), func(i int) []string {
+		return []string{securityGroups.Inputs[i].Guid, securityGroups.Inputs[i].Id}
+	}, func(ctx context.Context, i int) error {
+		securityGroup := securityGroups.Inputs[i]
+		output, err := action.createSecurityGroup(ctx, &securityGroup)
+		outputs.Outputs[i] = output
+		return err
+	})
+
+	Logger(ctx).Infof("all securityGroups = %s are created", Sanitize(securityGroups))
 	return &outputs, finalErr
 }
 
@@ -209,11 +206,11 @@ type SecurityGroupTerminateInputs struct {
 
 type SecurityGroupTerminateInput struct {
 	CallBackParameter
-	Guid           string `json:"guid,omitempty"`
-	ProviderParams string `json:"provider_params,omitempty"`
-	Id             string `json:"id,omitempty"`
-	Location       string `json:"location"`
-	APISecret      string `json:"api_secret"`
+	Guid           string `json:"guid,omitempty" register:"required"`
+	ProviderParams string `json:"provider_params,omitempty" register:"optional"`
+	Id             string `json:"id,omitempty" register:"required"`
+	Location       string `json:"location" register:"required"`
+	APISecret      string `json:"api_secret" register:"required,system_variable=QCLOUD_API_SECRET"`
 }
 
 type SecurityGroupTerminateOutputs struct {
@@ -224,13 +221,13 @@ type SecurityGroupTerminateOutput struct {
 	CallBackParameter
 	Result
 	// RequestId string `json:"request_id,omitempty"`
-	Guid string `json:"guid,omitempty"`
+	Guid string `json:"guid,omitempty" register:"optional"`
 	Id   string `json:"id,omitempty"`
 }
 
 type SecurityGroupTerminateAction struct{}
 
-func (action *SecurityGroupTerminateAction) ReadParam(param interface{}) (interface{}, error) {
+func (action *SecurityGroupTerminateAction) ReadParam(ctx context.Context, param interface{}) (interface{}, error) {
 	var inputs SecurityGroupTerminateInputs
 	err := UnmarshalJson(param, &inputs)
 	if err != nil {
@@ -258,7 +255,7 @@ func (action *SecurityGroupTerminateAction) checkTerminateSecurityGroupParams(in
 	return nil
 }
 
-func (action *SecurityGroupTerminateAction) terminateSecurityGroup(input *SecurityGroupTerminateInput) (output SecurityGroupTerminateOutput, err error) {
+func (action *SecurityGroupTerminateAction) terminateSecurityGroup(ctx context.Context, input *SecurityGroupTerminateInput) (output SecurityGroupTerminateOutput, err error) {
 	defer func() {
 		output.Guid = input.Guid
 		output.Id = input.Id
@@ -266,21 +263,20 @@ func (action *SecurityGroupTerminateAction) terminateSecurityGroup(input *Securi
 		if err == nil {
 			output.Result.Code = RESULT_CODE_SUCCESS
 		} else {
-			output.Result.Code = RESULT_CODE_ERROR
-			output.Result.Message = err.Error()
+			output.Result.SetError(err)
 		}
 	}()
 
-	if err = action.checkTerminateSecurityGroupParams(*input); err != nil {
-		logrus.Errorf("checkTerminateSecurityGroupParams meet error=%v", err)
+	if err = AsValidationError(action.checkTerminateSecurityGroupParams(*input)); err != nil {
+		Logger(ctx).Errorf("checkTerminateSecurityGroupParams meet error=%v", err)
 		return
 	}
 
-	if input.Location != "" && input.APISecret != "" {
-		input.ProviderParams = fmt.Sprintf("%s;%s", input.Location, input.APISecret)
+	params, err := NewProviderParams(input.ProviderParams, input.Location, input.APISecret)
+	if err != nil {
+		return
 	}
-	paramsMap, err := GetMapFromProviderParams(input.ProviderParams)
-	client, err := createVpcClient(paramsMap["Region"], paramsMap["SecretID"], paramsMap["SecretKey"])
+	client, err := createVpcClient(ctx, params)
 	if err != nil {
 		return
 	}
@@ -288,12 +284,12 @@ func (action *SecurityGroupTerminateAction) terminateSecurityGroup(input *Securi
 	// check wether securityGroup is exist.
 	ok, err := querySecurityGroupsInfo(client, input.Id)
 	if err != nil {
-		logrus.Errorf("querySecurityGroupsInfo meet error=%v", err)
+		Logger(ctx).Errorf("querySecurityGroupsInfo meet error=%v", err)
 		return
 	}
 
 	if !ok {
-		logrus.Infof("querySecurityGroupsInfo the securityGroup[%v] is not exist", input.Id)
+		Logger(ctx).Infof("querySecurityGroupsInfo the securityGroup[%v] is not exist", input.Id)
 		return
 	}
 	request := vpc.NewDeleteSecurityGroupRequest()
@@ -301,34 +297,37 @@ func (action *SecurityGroupTerminateAction) terminateSecurityGroup(input *Securi
 
 	response, err := client.DeleteSecurityGroup(request)
 	if err != nil {
-		logrus.Errorf("DeleteSecurityGroup meet error=%v", err)
+		Logger(ctx).Errorf("DeleteSecurityGroup meet error=%v", err)
 		return
 	}
 
-	logrus.Infof("Terminate SecurityGroup[%v] has been submitted in Qcloud, RequestID is [%v]", input.Id, *response.Response.RequestId)
+	Logger(ctx).Infof("Terminate SecurityGroup[%v] has been submitted in Qcloud, RequestID is [%v]", input.Id, *response.Response.RequestId)
 	return
 }
 
-func (action *SecurityGroupTerminateAction) Do(input interface{}) (interface{}, error) {
+func (action *SecurityGroupTerminateAction) Do(ctx context.Context, input interface{}) (interface{}, error) {
 	securityGroups, _ := input.(SecurityGroupTerminateInputs)
-	outputs := SecurityGroupTerminateOutputs{}
-	var finalErr error
-	for _, securityGroup := range securityGroups.Inputs {
-		output, err := action.terminateSecurityGroup(&securityGroup)
-		if err != nil {
-			finalErr = err
-		}
-		outputs.Outputs = append(outputs.Outputs, output)
-	}
-
-	logrus.Infof("all securityGroups = %v are deleted", securityGroups)
+	outputs := SecurityGroupTerminateOutputs{Outputs: make([]SecurityGroupTerminateOutput, len(securityGroups.Inputs))}
+	finalErr := runInputs(ctx, len(securityGroups.Inputs), func(i int) []string {
+		return []string{securityGroups.Inputs[i].Guid, securityGroups.Inputs[i].Id}
+	}, func(ctx context.Context, i int) error {
+		securityGroup := securityGroups.Inputs[i]
+		output, err := action.terminateSecurityGroup(ctx, &securityGroup)
+		outputs.Outputs[i] = output
+		return err
+	})
+
+	Logger(ctx).Infof("all securityGroups = %s are deleted", Sanitize(securityGroups))
 	return &outputs, finalErr
 }
 
-func QuerySecurityGroups(providerParam string, securityGroupIds []string) ([]*vpc.SecurityGroup, error) {
+func QuerySecurityGroups(ctx context.Context, providerParam string, securityGroupIds []string) ([]*vpc.SecurityGroup, error) {
 	securityGroups := []*vpc.SecurityGroup{}
-	paramsMap, err := GetMapFromProviderParams(providerParam)
-	client, err := createVpcClient(paramsMap["Region"], paramsMap["SecretID"], paramsMap["SecretKey"])
+	params, err := ParseProviderParams(providerParam)
+	if err != nil {
+		return securityGroups, err
+	}
+	client, err := createVpcClient(ctx, params)
 	if err != nil {
 		return securityGroups, err
 	}
@@ -343,9 +342,12 @@ func QuerySecurityGroups(providerParam string, securityGroupIds []string) ([]*vp
 	return resp.Response.SecurityGroupSet, nil
 }
 
-func CreateSecurityGroup(providerParam string, name string, description string) (string, error) {
-	paramsMap, err := GetMapFromProviderParams(providerParam)
-	client, err := createVpcClient(paramsMap["Region"], paramsMap["SecretID"], paramsMap["SecretKey"])
+func CreateSecurityGroup(ctx context.Context, providerParam string, name string, description string) (string, error) {
+	params, err := ParseProviderParams(providerParam)
+	if err != nil {
+		return "", err
+	}
+	client, err := createVpcClient(ctx, params)
 	if err != nil {
 		return "", err
 	}
@@ -1,6 +1,7 @@
 package plugins
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"testing"
@@ -9,16 +10,11 @@ import (
 	vpc "github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/vpc/v20170312"
 )
 
-const (
-	ENV_SECRET_ID  = "SECRET_ID"
-	ENV_SECRET_KEY = "SECRET_KEY"
-)
-
 func TestCreateSecurityGroupPolicies(t *testing.T) {
 	secretId := os.Getenv(ENV_SECRET_ID)
 	secretKey := os.Getenv(ENV_SECRET_KEY)
 	providerParams := "Region=ap-guangzhou;AvailableZone=ap-guanghzou-4;SecretID=" + secretId + ";SecretKey=" + secretKey
-	paramsMap, err := GetMapFromProviderParams(providerParams)
+	params, err := ParseProviderParams(providerParams)
 
 	securityGroupId := "sg-3jh0itt3"
 
@@ -37,7 +33,7 @@ func TestCreateSecurityGroupPolicies(t *testing.T) {
 		},
 	}
 
-	client, err := createVpcClient(paramsMap["Region"], paramsMap["SecretID"], paramsMap["SecretKey"])
+	client, err := createVpcClient(context.Background(), params)
 	if err != nil {
 		fmt.Printf("TestCreateSecurityGroupPolicies vpc CreateSecurityGroupPolicies meet err=%v\n", err)
 		return
@@ -59,7 +55,7 @@ func TestCreateSecurityGroupPoliciesMore(t *testing.T) {
 	secretId := os.Getenv(ENV_SECRET_ID)
 	secretKey := os.Getenv(ENV_SECRET_KEY)
 	providerParams := "Region=ap-guangzhou;AvailableZone=ap-guanghzou-4;SecretID=" + secretId + ";SecretKey=" + secretKey
-	paramsMap, err := GetMapFromProviderParams(providerParams)
+	params, err := ParseProviderParams(providerParams)
 
 	securityGroupId := "sg-3jh0itt3"
 	securityGroupPolicySet := &vpc.SecurityGroupPolicySet{
@@ -75,7 +71,7 @@ func TestCreateSecurityGroupPoliciesMore(t *testing.T) {
 		securityGroupPolicySet.Egress = append(securityGroupPolicySet.Egress, policy)
 	}
 
-	client, err := createVpcClient(paramsMap["Region"], paramsMap["SecretID"], paramsMap["SecretKey"])
+	client, err := createVpcClient(context.Background(), params)
 	if err != nil {
 		fmt.Printf("TestCreateSecurityGroupPolicies vpc CreateSecurityGroupPolicies meet err=%v\n", err)
 		return
@@ -1,10 +1,10 @@
 package plugins
 
 import (
+	"context"
 	"fmt"
 	"strings"
 
-	"github.com/sirupsen/logrus"
 	"github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/common"
 	vpc "github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/vpc/v20170312"
 )
@@ -27,23 +27,27 @@ func (plugin *SecurityPolicyPlugin) GetActionByName(actionName string) (Action,
 	return action, nil
 }
 
+func (plugin *SecurityPolicyPlugin) GetActions() map[string]Action {
+	return SecurityPolicyActions
+}
+
 type SecurityGroupPolicyInputs struct {
 	Inputs []SecurityGroupPolicyInput `json:"inputs,omitempty"`
 }
 
 type SecurityGroupPolicyInput struct {
 	CallBackParameter
-	Guid              string `json:"guid,omitempty"`
-	ProviderParams    string `json:"provider_params,omitempty"`
-	Id                string `json:"security_group_id,omitempty"`
-	PolicyType        string `json:"policy_type,omitempty"`
-	PolicyCidrBlock   string `json:"policy_cidr_block,omitempty"`
-	PolicyProtocol    string `json:"policy_protocol,omitempty"`
-	PolicyPort        string `json:"policy_port,omitempty"`
-	PolicyAction      string `json:"policy_action,omitempty"`
-	PolicyDescription string `json:"policy_description,omitempty"`
-	Location          string `json:"location"`
-	APISecret         string `json:"api_secret"`
+	Guid              string `json:"guid,omitempty" register:"required"`
+	ProviderParams    string `json:"provider_params,omitempty" register:"optional"`
+	Id                string `json:"security_group_id,omitempty" register:"optional"`
+	PolicyType        string `json:"policy_type,omitempty" register:"required" enum:"security_policy_type"`
+	PolicyCidrBlock   string `json:"policy_cidr_block,omitempty" register:"required"`
+	PolicyProtocol    string `json:"policy_protocol,omitempty" register:"required"`
+	PolicyPort        string `json:"policy_port,omitempty" register:"required"`
+	PolicyAction      string `json:"policy_action,omitempty" register:"required" enum:"security_policy_action"`
+	PolicyDescription string `json:"policy_description,omitempty" register:"optional,actions=create-policies"`
+	Location          string `json:"location" register:"required"`
+	APISecret         string `json:"api_secret" register:"required,system_variable=QCLOUD_API_SECRET"`
 }
 
 type SecurityGroupPolicyOutputs struct {
@@ -54,13 +58,13 @@ type SecurityGroupPolicyOutput struct {
 	CallBackParameter
 	Result
 	RequestId string `json:"requestId,omitempty"`
-	Guid      string `json:"guid,omitempty"`
+	Guid      string `json:"guid,omitempty" register:"optional"`
 }
 
 type SecurityGroupCreatePolicies struct {
 }
 
-func (action *SecurityGroupCreatePolicies) ReadParam(param interface{}) (interface{}, error) {
+func (action *SecurityGroupCreatePolicies) ReadParam(ctx context.Context, param interface{}) (interface{}, error) {
 	var inputs SecurityGroupPolicyInputs
 	err := UnmarshalJson(param, &inputs)
 	if err != nil {
@@ -69,16 +73,22 @@ func (action *SecurityGroupCreatePolicies) ReadParam(param interface{}) (interfa
 	return inputs, nil
 }
 
+// the valid types and actions of security policies, they are case insensitive.
+var (
+	securityPolicyTypes   = []string{"INGRESS", "EGRESS"}
+	securityPolicyActions = []string{"ACCEPT", "DROP"}
+)
+
 func createSecurityPolices(input SecurityGroupPolicyInput) ([]*vpc.SecurityGroupPolicy, error) {
 	policies := []*vpc.SecurityGroupPolicy{}
 
 	upperPolicyType := strings.ToUpper(input.PolicyType)
-	if upperPolicyType != "EGRESS" && upperPolicyType != "INGRESS" {
+	if err := IsValidValue(upperPolicyType, securityPolicyTypes); err != nil {
 		return policies, fmt.Errorf("%s is unknown security policy type", upperPolicyType)
 	}
 
 	action := strings.ToUpper(input.PolicyAction)
-	if action != "ACCEPT" && action != "DROP" {
+	if err := IsValidValue(action, securityPolicyActions); err != nil {
 		return policies, fmt.Errorf("%v is unkown security policy action", action)
 	}
 
@@ -161,51 +171,50 @@ func newSecurityPolicySet(policyType string, policies []*vpc.SecurityGroupPolicy
 	return policySet
 }
 
-func getSecurityGroupById(providerParam string, id string) error {
+func getSecurityGroupById(ctx context.Context, providerParam string, id string) error {
 	if id == "" {
 		return fmt.Errorf("securityGroup id is empty")
 	}
 
-	groups, err := QuerySecurityGroups(providerParam, []string{id})
+	groups, err := QuerySecurityGroups(ctx, providerParam, []string{id})
 	if err != nil || len(groups) == 0 {
 		return fmt.Errorf("check securityGroupId(%s):err=%v,len(groups)=%v", id, err, len(groups))
 	}
 	return nil
 }
 
-func (action *SecurityGroupCreatePolicies) Do(input interface{}) (interface{}, error) {
+func (action *SecurityGroupCreatePolicies) Do(ctx context.Context, input interface{}) (interface{}, error) {
 	securityGroupPolicies, _ := input.(SecurityGroupPolicyInputs)
-	outputs := SecurityGroupPolicyOutputs{}
-	var finalErr error
-
-	for _, input := range securityGroupPolicies.Inputs {
-		if input.Location != "" && input.APISecret != "" {
-			input.ProviderParams = fmt.Sprintf("%s;%s", input.Location, input.APISecret)
-		}
-		paramsMap, _ := GetMapFromProviderParams(input.ProviderParams)
-		client, err := createVpcClient(paramsMap["Region"], paramsMap["SecretID"], paramsMap["SecretKey"])
+	outputs := SecurityGroupPolicyOutputs{Outputs: make([]SecurityGroupPolicyOutput, len(securityGroupPolicies.Inputs))}
+	finalErr := runInputs(ctx, len(securityGroupPolicies.Inputs), func(i int) []string {
+		return []string{securityGroupPolicies.Inputs[i].Guid, securityGroupPolicies.Inputs[i].Id}
+	}, func(ctx context.Context, i int) error {
+		input := securityGroupPolicies.Inputs[i]
 		output := SecurityGroupPolicyOutput{
 			Guid: input.Guid,
 		}
 		output.CallBackParameter.Parameter = input.CallBackParameter.Parameter
 		output.Result.Code = RESULT_CODE_SUCCESS
+		params, err := NewProviderParams(input.ProviderParams, input.Location, input.APISecret)
+		if err != nil {
+			output.Result.SetError(err)
+			outputs.Outputs[i] = output
+			return err
+		}
+		client, err := createVpcClient(ctx, params)
 		// check if securityGroup exist
-		if err := getSecurityGroupById(input.ProviderParams, input.Id); err != nil {
-			finalErr = err
-			output.Result.Code = RESULT_CODE_ERROR
-			output.Result.Message = err.Error()
-			outputs.Outputs = append(outputs.Outputs, output)
-			continue
+		if err := getSecurityGroupById(ctx, params.String(), input.Id); err != nil {
+			output.Result.SetError(err)
+			outputs.Outputs[i] = output
+			return err
 		}
 
 		// create policies
 		policies, err := createSecurityPolices(input)
 		if err != nil {
-			finalErr = err
-			output.Result.Code = RESULT_CODE_ERROR
-			output.Result.Message = err.Error()
-			outputs.Outputs = append(outputs.Outputs, output)
-			continue
+			output.Result.SetError(err)
+			outputs.Outputs[i] = output
+			return err
 		}
 		for _, policy := range policies {
 			policy.PolicyIndex = common.Int64Ptr(0)
@@ -217,14 +226,13 @@ func (action *SecurityGroupCreatePolicies) Do(input interface{}) (interface{}, e
 		req.SecurityGroupPolicySet = newSecurityPolicySet(input.PolicyType, policies)
 		_, err = client.CreateSecurityGroupPolicies(req)
 		if err != nil {
-			finalErr = err
-			output.Result.Code = RESULT_CODE_ERROR
-			output.Result.Message = err.Error()
-			outputs.Outputs = append(outputs.Outputs, output)
-			continue
+			output.Result.SetError(err)
+			outputs.Outputs[i] = output
+			return err
 		}
-		outputs.Outputs = append(outputs.Outputs, output)
-	}
+		outputs.Outputs[i] = output
+		return nil
+	})
 
 	return outputs, finalErr
 }
@@ -232,7 +240,7 @@ func (action *SecurityGroupCreatePolicies) Do(input interface{}) (interface{}, e
 type SecurityGroupDeletePolicies struct {
 }
 
-func (action *SecurityGroupDeletePolicies) ReadParam(param interface{}) (interface{}, error) {
+func (action *SecurityGroupDeletePolicies) ReadParam(ctx context.Context, param interface{}) (interface{}, error) {
 	var inputs SecurityGroupPolicyInputs
 	err := UnmarshalJson(param, &inputs)
 	if err != nil {
@@ -241,39 +249,38 @@ func (action *SecurityGroupDeletePolicies) ReadParam(param interface{}) (interfa
 	return inputs, nil
 }
 
-func (action *SecurityGroupDeletePolicies) Do(input interface{}) (interface{}, error) {
+func (action *SecurityGroupDeletePolicies) Do(ctx context.Context, input interface{}) (interface{}, error) {
 	securityGroupPolicies, _ := input.(SecurityGroupPolicyInputs)
-	outputs := SecurityGroupPolicyOutputs{}
-	var finalErr error
-
-	for _, input := range securityGroupPolicies.Inputs {
-		if input.Location != "" && input.APISecret != "" {
-			input.ProviderParams = fmt.Sprintf("%s;%s", input.Location, input.APISecret)
-		}
-		paramsMap, _ := GetMapFromProviderParams(input.ProviderParams)
-		client, err := createVpcClient(paramsMap["Region"], paramsMap["SecretID"], paramsMap["SecretKey"])
+	outputs := SecurityGroupPolicyOutputs{Outputs: make([]SecurityGroupPolicyOutput, len(securityGroupPolicies.Inputs))}
+	finalErr := runInputs(ctx, len(securityGroupPolicies.Inputs), func(i int) []string {
+		return []string{securityGroupPolicies.Inputs[i].Guid, securityGroupPolicies.Inputs[i].Id}
+	}, func(ctx context.Context, i int) error {
+		input := securityGroupPolicies.Inputs[i]
 		output := SecurityGroupPolicyOutput{
 			Guid: input.Guid,
 		}
 		output.CallBackParameter.Parameter = input.CallBackParameter.Parameter
 		output.Result.Code = RESULT_CODE_SUCCESS
+		params, err := NewProviderParams(input.ProviderParams, input.Location, input.APISecret)
+		if err != nil {
+			output.Result.SetError(err)
+			outputs.Outputs[i] = output
+			return err
+		}
+		client, err := createVpcClient(ctx, params)
 		//check if securityGroup exist
-		if err := getSecurityGroupById(input.ProviderParams, input.Id); err != nil {
-			finalErr = err
-			output.Result.Code = RESULT_CODE_ERROR
-			output.Result.Message = err.Error()
-			outputs.Outputs = append(outputs.Outputs, output)
-			continue
+		if err := getSecurityGroupById(ctx, params.String(), input.Id); err != nil {
+			output.Result.SetError(err)
+			outputs.Outputs[i] = output
+			return err
 		}
 
 		// create policies
 		policies, err := createSecurityPolices(input)
 		if err != nil {
-			finalErr = err
-			output.Result.Code = RESULT_CODE_ERROR
-			output.Result.Message = err.Error()
-			outputs.Outputs = append(outputs.Outputs, output)
-			continue
+			output.Result.SetError(err)
+			outputs.Outputs[i] = output
+			return err
 		}
 		// delete policies to securityGroups
 		req := vpc.NewDeleteSecurityGroupPoliciesRequest()
@@ -281,22 +288,24 @@ func (action *SecurityGroupDeletePolicies) Do(input interface{}) (interface{}, e
 		req.SecurityGroupPolicySet = newSecurityPolicySet(input.PolicyType, policies)
 		_, err = client.DeleteSecurityGroupPolicies(req)
 		if err != nil {
-			finalErr = err
-			output.Result.Code = RESULT_CODE_ERROR
-			output.Result.Message = err.Error()
-			outputs.Outputs = append(outputs.Outputs, output)
-			continue
+			output.Result.SetError(err)
+			outputs.Outputs[i] = output
+			return err
 		}
-		outputs.Outputs = append(outputs.Outputs, output)
-	}
+		outputs.Outputs[i] = output
+		return nil
+	})
 
 	return outputs, finalErr
 }
 
-func QuerySecurityGroupPolicies(providerParam string, securityGroupId string) (vpc.SecurityGroupPolicySet, error) {
+func QuerySecurityGroupPolicies(ctx context.Context, providerParam string, securityGroupId string) (vpc.SecurityGroupPolicySet, error) {
 	emptyPolicySet := vpc.SecurityGroupPolicySet{}
-	paramsMap, err := GetMapFromProviderParams(providerParam)
-	client, err := createVpcClient(paramsMap["Region"], paramsMap["SecretID"], paramsMap["SecretKey"])
+	params, err := ParseProviderParams(providerParam)
+	if err != nil {
+		return emptyPolicySet, err
+	}
+	client, err := createVpcClient(ctx, params)
 	if err != nil {
 		return emptyPolicySet, err
 	}
@@ -309,7 +318,7 @@ func QuerySecurityGroupPolicies(providerParam string, securityGroupId string) (v
 	}
 
 	if resp.Response.SecurityGroupPolicySet == nil {
-		logrus.Errorf("securityGroup(%s) descirbe policies get null pointer", securityGroupId)
+		Logger(ctx).Errorf("securityGroup(%s) descirbe policies get null pointer", securityGroupId)
 		return emptyPolicySet, fmt.Errorf("securityGroup(%s) descirbe policies get null pointer", securityGroupId)
 	}
 
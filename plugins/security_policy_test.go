@@ -1,6 +1,7 @@
 package plugins
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"testing"
@@ -28,8 +29,8 @@ func TestDeleteSecurityGroupPolicies(t *testing.T) {
 		},
 	}
 
-	paramsMap, _ := GetMapFromProviderParams(providerParams)
-	client, err := createVpcClient(paramsMap["Region"], paramsMap["SecretID"], paramsMap["SecretKey"])
+	params, _ := ParseProviderParams(providerParams)
+	client, err := createVpcClient(context.Background(), params)
 	_, err = client.DeleteSecurityGroupPolicies(req)
 	if err != nil {
 		fmt.Printf("err=%v", err)
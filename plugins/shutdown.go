@@ -0,0 +1,267 @@
+package plugins
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// INTERRUPTED_STATUS_CANCELED is an input which was running when the grace period expired, and stopped after
+	// it was canceled. INTERRUPTED_STATUS_ABANDONED is an input which did not stop before the process exited.
+	INTERRUPTED_STATUS_CANCELED  = "canceled"
+	INTERRUPTED_STATUS_ABANDONED = "abandoned"
+
+	// the actions canceled after the grace period stop at their next cloud API call or wait, they are
+	// abandoned if they do not stop in time.
+	ACTION_CANCEL_TIMEOUT = 10 * time.Second
+)
+
+// InterruptedInput is an input which the shutdown stopped halfway, its resources may need to be cleaned up.
+type InterruptedInput struct {
+	Time   time.Time `json:"time"`
+	Plugin string    `json:"plugin"`
+	Action string    `json:"action"`
+	Index  int       `json:"index"`
+	Guid   string    `json:"guid,omitempty"`
+	// ResourceId is the id of the resource of the input, read from its output or the idempotency record.
+	ResourceId string `json:"resourceId,omitempty"`
+	Status     string `json:"status"`
+	Message    string `json:"message,omitempty"`
+}
+
+// InterruptionJournal appends the interrupted inputs to File, one json object per line.
+type InterruptionJournal struct {
+	File string
+
+	mutex sync.Mutex
+}
+
+// DefaultInterruptionJournal records the inputs interrupted by the shutdown, nil only logs them.
+var DefaultInterruptionJournal *InterruptionJournal
+
+func (journal *InterruptionJournal) Append(inputs []InterruptedInput) error {
+	journal.mutex.Lock()
+	defer journal.mutex.Unlock()
+
+	if err := os.MkdirAll(filepath.Dir(journal.File), 0700); err != nil {
+		return err
+	}
+	file, err := os.OpenFile(journal.File, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return err
+	}
+	encoder := json.NewEncoder(file)
+	for _, input := range inputs {
+		if err = encoder.Encode(input); err != nil {
+			break
+		}
+	}
+	if err == nil {
+		err = file.Sync()
+	}
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// inFlightAction is an action being run by doAction, it is canceled if it does not finish in the grace period.
+type inFlightAction struct {
+	plugin      string
+	action      string
+	actionParam interface{}
+	dryRun      bool
+	cancel      context.CancelFunc
+	// interrupted and journaled are guarded by inFlightMutex.
+	interrupted bool
+	journaled   bool
+}
+
+var (
+	inFlightMutex   sync.Mutex
+	inFlightActions = make(map[*inFlightAction]bool)
+	inFlightWg      sync.WaitGroup
+	shuttingDown    bool
+)
+
+// IsShuttingDown tells whether DrainActions is called, no action is started afterwards.
+func IsShuttingDown() bool {
+	inFlightMutex.Lock()
+	defer inFlightMutex.Unlock()
+
+	return shuttingDown
+}
+
+// trackAction registers the action until finish is called, the returned ctx is canceled if the action
+// is still running when the grace period of the shutdown expires.
+func trackAction(ctx context.Context, pluginName, actionName string, actionParam interface{}) (context.Context, *inFlightAction, error) {
+	inFlightMutex.Lock()
+	defer inFlightMutex.Unlock()
+
+	if shuttingDown {
+		return ctx, nil, newPluginError(ERROR_CATEGORY_UNAVAILABLE, ERROR_CODE_SHUTTING_DOWN, true, "plugin is shutting down, retry later")
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	tracked := &inFlightAction{
+		plugin:      pluginName,
+		action:      actionName,
+		actionParam: actionParam,
+		dryRun:      IsDryRun(ctx),
+		cancel:      cancel,
+	}
+	inFlightActions[tracked] = true
+	inFlightWg.Add(1)
+	return ctx, tracked, nil
+}
+
+// finish unregisters the action, the inputs which did not finish are journaled if the action was canceled by the shutdown.
+func (tracked *inFlightAction) finish(results interface{}, err error) {
+	inFlightMutex.Lock()
+	delete(inFlightActions, tracked)
+	journal := tracked.interrupted && !tracked.journaled
+	tracked.journaled = true
+	inFlightMutex.Unlock()
+
+	tracked.cancel()
+	if journal {
+		recordInterruptedInputs(tracked.getInterruptedInputs(results, err))
+	}
+	inFlightWg.Done()
+}
+
+// DrainActions stops new actions from starting and waits for the running ones, both of requests and of async tasks.
+// Once the grace period expires the running actions are canceled, and the inputs which were running are recorded in
+// DefaultInterruptionJournal, so are the inputs of the actions which do not stop in ACTION_CANCEL_TIMEOUT.
+func DrainActions(gracePeriod time.Duration) {
+	inFlightMutex.Lock()
+	shuttingDown = true
+	count := len(inFlightActions)
+	inFlightMutex.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		inFlightWg.Wait()
+		close(done)
+	}()
+
+	logrus.Infof("wait %v running actions to finish in %v", count, gracePeriod)
+	if waitDone(done, gracePeriod) {
+		logrus.Infof("all actions are finished")
+		return
+	}
+
+	inFlightMutex.Lock()
+	logrus.Warnf("grace period %v expires, cancel %v running actions", gracePeriod, len(inFlightActions))
+	for tracked := range inFlightActions {
+		tracked.interrupted = true
+		tracked.cancel()
+	}
+	inFlightMutex.Unlock()
+	if waitDone(done, ACTION_CANCEL_TIMEOUT) {
+		return
+	}
+
+	interruptedInputs := []InterruptedInput{}
+	inFlightMutex.Lock()
+	for tracked := range inFlightActions {
+		if tracked.journaled {
+			continue
+		}
+		tracked.journaled = true
+		interruptedInputs = append(interruptedInputs, tracked.getInterruptedInputs(nil, nil)...)
+	}
+	inFlightMutex.Unlock()
+	recordInterruptedInputs(interruptedInputs)
+}
+
+func waitDone(done <-chan struct{}, timeout time.Duration) bool {
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	select {
+	case <-done:
+		return true
+	case <-timer.C:
+		return false
+	}
+}
+
+// getInterruptedInputs returns the inputs which were started but did not succeed, results are nil if the action
+// did not stop, then all its inputs are abandoned. Actions in dry run mode change nothing, they have no such inputs.
+func (tracked *inFlightAction) getInterruptedInputs(results interface{}, err error) []InterruptedInput {
+	if tracked.dryRun {
+		return nil
+	}
+	status := INTERRUPTED_STATUS_CANCELED
+	if results == nil && err == nil {
+		status = INTERRUPTED_STATUS_ABANDONED
+	}
+	notStarted := make(map[int]bool)
+	if canceledErr, ok := err.(*ActionCanceledError); ok {
+		for _, i := range canceledErr.NotStarted {
+			notStarted[i] = true
+		}
+	}
+
+	inputs := getSliceField(tracked.actionParam, "Inputs")
+	outputs := getSliceField(results, "Outputs")
+	resultsOfOutputs := getResultsFromOutputs(results)
+	interruptedInputs := []InterruptedInput{}
+	for i, guid := range getGuidsFromInputs(tracked.actionParam) {
+		if notStarted[i] || (i < len(resultsOfOutputs) && resultsOfOutputs[i].Code == RESULT_CODE_SUCCESS) {
+			continue
+		}
+		interruptedInput := InterruptedInput{
+			Time:   time.Now(),
+			Plugin: tracked.plugin,
+			Action: tracked.action,
+			Index:  i,
+			Guid:   guid,
+			Status: status,
+		}
+		if i < len(resultsOfOutputs) {
+			interruptedInput.Message = resultsOfOutputs[i].Message
+		}
+		if outputs.IsValid() && i < outputs.Len() {
+			interruptedInput.ResourceId = getStructResourceId(outputs.Index(i))
+		}
+		if interruptedInput.ResourceId == "" && inputs.IsValid() && i < inputs.Len() {
+			interruptedInput.ResourceId = getStructResourceId(inputs.Index(i))
+		}
+		if store := DefaultIdempotencyStore; interruptedInput.ResourceId == "" && store != nil && guid != "" && IsIdempotentAction(tracked.action) {
+			if record, _ := store.Get(tracked.plugin, tracked.action, guid); record != nil {
+				interruptedInput.ResourceId = record.ResourceId
+			}
+		}
+		interruptedInputs = append(interruptedInputs, interruptedInput)
+	}
+	return interruptedInputs
+}
+
+func getStructResourceId(v reflect.Value) string {
+	if s := reflect.Indirect(v); s.Kind() == reflect.Struct {
+		return getResourceId(s)
+	}
+	return ""
+}
+
+func recordInterruptedInputs(inputs []InterruptedInput) {
+	for _, input := range inputs {
+		logrus.Warnf("input guid %v of plugin[%v]-action[%v] is interrupted by the shutdown, status=%v, resourceId=%v",
+			input.Guid, input.Plugin, input.Action, input.Status, input.ResourceId)
+	}
+	journal := DefaultInterruptionJournal
+	if journal == nil || len(inputs) == 0 {
+		return
+	}
+	if err := journal.Append(inputs); err != nil {
+		logrus.Errorf("record %v interrupted inputs in %v meet error=%v", len(inputs), journal.File, err)
+	}
+}
@@ -0,0 +1,101 @@
+package plugins
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newShutdownTestAction creates the resource of each input, the input of blockGuid blocks after the creation until it is canceled.
+func newShutdownTestAction(blockGuid string, blocked chan struct{}) *testAction {
+	return &testAction{serial: true, runInput: func(ctx context.Context, i int, input testInput, output *testOutput) error {
+		output.Id = "res-" + input.Guid
+		if input.Guid == blockGuid {
+			close(blocked)
+			<-ctx.Done()
+			return ctx.Err()
+		}
+		return nil
+	}}
+}
+
+func resetShutdown() {
+	inFlightMutex.Lock()
+	shuttingDown = false
+	inFlightMutex.Unlock()
+}
+
+func TestDrainActionsJournalsInterruptedInputs(t *testing.T) {
+	dir, err := ioutil.TempDir("", "shutdown")
+	if err != nil {
+		t.Fatalf("create temp dir meet error=%v", err)
+	}
+	defer os.RemoveAll(dir)
+	defer func(journal *InterruptionJournal) { DefaultInterruptionJournal = journal }(DefaultInterruptionJournal)
+	DefaultInterruptionJournal = &InterruptionJournal{File: filepath.Join(dir, "journal", "interrupted_inputs.log")}
+	defer resetShutdown()
+
+	blocked := make(chan struct{})
+	action := newShutdownTestAction("guid_2", blocked)
+	inputs := newTestInputs("guid_1", "guid_2", "guid_3")
+	actionErr := make(chan error, 1)
+	go func() {
+		_, err := doAction(context.Background(), "test", "update", action, inputs)
+		actionErr <- err
+	}()
+	<-blocked
+
+	DrainActions(10 * time.Millisecond)
+	if err := <-actionErr; err == nil || !strings.Contains(err.Error(), "1 not started [guid_3]") {
+		t.Errorf("action err=%v", err)
+	}
+	if _, err := doAction(context.Background(), "test", "update", action, inputs); ClassifyError(err).Code != ERROR_CODE_SHUTTING_DOWN {
+		t.Errorf("action started after shutdown, err=%v", err)
+	}
+
+	data, err := ioutil.ReadFile(DefaultInterruptionJournal.File)
+	if err != nil {
+		t.Fatalf("read journal meet error=%v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	if len(lines) != 1 {
+		t.Fatalf("journal=%s", data)
+	}
+	interruptedInput := InterruptedInput{}
+	if err = json.Unmarshal([]byte(lines[0]), &interruptedInput); err != nil {
+		t.Fatalf("unmarshal journal meet error=%v", err)
+	}
+	if interruptedInput.Plugin != "test" || interruptedInput.Action != "update" || interruptedInput.Index != 1 || interruptedInput.Guid != "guid_2" ||
+		interruptedInput.ResourceId != "res-guid_2" || interruptedInput.Status != INTERRUPTED_STATUS_CANCELED {
+		t.Errorf("interrupted input=%+v", interruptedInput)
+	}
+}
+
+func TestDrainActionsWaitsForActions(t *testing.T) {
+	defer resetShutdown()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	blocked := make(chan struct{})
+	action := newShutdownTestAction("guid_1", blocked)
+	actionErr := make(chan error, 1)
+	go func() {
+		_, err := doAction(ctx, "test", "update", action, newTestInputs("guid_1"))
+		actionErr <- err
+	}()
+	<-blocked
+
+	time.AfterFunc(10*time.Millisecond, cancel)
+	start := time.Now()
+	DrainActions(time.Minute)
+	if elapsed := time.Since(start); elapsed > 10*time.Second {
+		t.Errorf("drain actions takes %v", elapsed)
+	}
+	if err := <-actionErr; err == nil {
+		t.Errorf("action err=%v", err)
+	}
+}
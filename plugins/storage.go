@@ -1,14 +1,11 @@
 package plugins
 
 import (
+	"context"
 	"fmt"
 	"strconv"
-	"time"
 
-	"github.com/sirupsen/logrus"
 	cbs "github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/cbs/v20170312"
-	"github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/common"
-	"github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/common/profile"
 )
 
 const (
@@ -23,13 +20,8 @@ func init() {
 	StorageActions["terminate"] = new(StorageTerminateAction)
 }
 
-func CreateCbsClient(region, secretId, secretKey string) (client *cbs.Client, err error) {
-	credential := common.NewCredential(secretId, secretKey)
-
-	clientProfile := profile.NewClientProfile()
-	clientProfile.HttpProfile.Endpoint = "cbs.tencentcloudapi.com"
-
-	return cbs.NewClient(credential, region, clientProfile)
+func CreateCbsClient(ctx context.Context, params *ProviderParams) (client *cbs.Client, err error) {
+	return GetClientFactory().WithContext(ctx).WithProviderParams(params).NewCbsClient(params.Region, params.SecretID, params.SecretKey)
 }
 
 type StorageInputs struct {
@@ -38,6 +30,7 @@ type StorageInputs struct {
 
 type StorageInput struct {
 	CallBackParameter
+	WaitParameter
 	Guid             string `json:"guid,omitempty"`
 	ProviderParams   string `json:"provider_params,omitempty"`
 	DiskType         string `json:"disk_type,omitempty"`
@@ -75,10 +68,14 @@ func (plugin *StoragePlugin) GetActionByName(actionName string) (Action, error)
 	return action, nil
 }
 
+func (plugin *StoragePlugin) GetActions() map[string]Action {
+	return StorageActions
+}
+
 type StorageCreateAction struct {
 }
 
-func (action *StorageCreateAction) ReadParam(param interface{}) (interface{}, error) {
+func (action *StorageCreateAction) ReadParam(ctx context.Context, param interface{}) (interface{}, error) {
 	var inputs StorageInputs
 	err := UnmarshalJson(param, &inputs)
 	if err != nil {
@@ -87,68 +84,66 @@ func (action *StorageCreateAction) ReadParam(param interface{}) (interface{}, er
 	return inputs, nil
 }
 
-func (action *StorageCreateAction) Do(input interface{}) (interface{}, error) {
+func (action *StorageCreateAction) Do(ctx context.Context, input interface{}) (interface{}, error) {
 	storages, _ := input.(StorageInputs)
-	outputs := StorageOutputs{}
-	var finalErr error
-
-	for _, storage := range storages.Inputs {
+	outputs := StorageOutputs{Outputs: make([]StorageOutput, len(storages.Inputs))}
+	finalErr := runInputs(ctx, len(storages.Inputs), func(i int) []string {
+		return []string{storages.Inputs[i].Guid, storages.Inputs[i].Id, storages.Inputs[i].InstanceId}
+	}, func(ctx context.Context, i int) error {
+		storage := storages.Inputs[i]
 		output := StorageOutput{
 			Guid: storage.Guid,
 		}
 		output.CallBackParameter.Parameter = storage.CallBackParameter.Parameter
 		output.Result.Code = RESULT_CODE_SUCCESS
 
-		err := action.checkCreateStorageParams(storage)
+		err := AsValidationError(action.checkCreateStorageParams(storage))
 		if err != nil {
-			output.Result.Code = RESULT_CODE_ERROR
-			output.Result.Message = err.Error()
-			outputs.Outputs = append(outputs.Outputs, output)
-			finalErr = err
-			continue
+			output.Result.SetError(err)
+			outputs.Outputs[i] = output
+			return err
 		}
 
-		result, err := action.createStorage(&storage)
+		result, err := action.createStorage(ctx, &storage)
 		if err != nil {
-			output.Result.Code = RESULT_CODE_ERROR
-			output.Result.Message = err.Error()
-			outputs.Outputs = append(outputs.Outputs, output)
-			finalErr = err
-			continue
+			output.Result.SetError(err)
+			outputs.Outputs[i] = output
+			return err
 		}
 		output.Id = result.Id
 		storage.Id = result.Id
 
-		err = action.attachStorage(&storage)
+		err = action.attachStorage(ctx, &storage)
 		if err != nil {
-			output.Result.Code = RESULT_CODE_ERROR
-			output.Result.Message = err.Error()
-			finalErr = err
+			output.Result.SetError(err)
+			outputs.Outputs[i] = output
+			return err
 		}
 
-		outputs.Outputs = append(outputs.Outputs, output)
-	}
+		outputs.Outputs[i] = output
+		return nil
+	})
 
-	logrus.Infof("all storages = %v are created", storages)
+	Logger(ctx).Infof("all storages = %s are created", Sanitize(storages))
 	return &outputs, finalErr
 }
 
-func (action *StorageCreateAction) attachStorage(storage *StorageInput) error {
-	logrus.Infof("storage input: %v", storage)
+func (action *StorageCreateAction) attachStorage(ctx context.Context, storage *StorageInput) error {
+	Logger(ctx).Infof("storage input: %s", Sanitize(storage))
 
-	if storage.Location != "" && storage.APISecret != "" {
-		storage.ProviderParams = fmt.Sprintf("%s;%s", storage.Location, storage.APISecret)
+	params, err := NewProviderParams(storage.ProviderParams, storage.Location, storage.APISecret)
+	if err != nil {
+		return err
 	}
-	paramsMap, _ := GetMapFromProviderParams(storage.ProviderParams)
-	client, _ := CreateCbsClient(paramsMap["Region"], paramsMap["SecretID"], paramsMap["SecretKey"])
+	client, _ := CreateCbsClient(ctx, params)
 
 	disk, ok, err := queryStorageInfo(client, storage.Id)
 	if err != nil || !ok {
 		if err != nil {
-			logrus.Errorf("queryStorageInfo meet error=%v", err)
+			Logger(ctx).Errorf("queryStorageInfo meet error=%v", err)
 		} else {
 			err = fmt.Errorf("queryStorageInfo meet error=disk not found")
-			logrus.Errorf("queryStorageInfo meet error=disk not found")
+			Logger(ctx).Errorf("queryStorageInfo meet error=disk not found")
 		}
 		return err
 	}
@@ -166,13 +161,13 @@ func (action *StorageCreateAction) attachStorage(storage *StorageInput) error {
 	request.DeleteWithInstance = &deleteWithInstance
 	_, err = client.AttachDisks(request)
 	if err != nil {
-		logrus.Errorf("attach storage[%v] meet error=%v", storage.Id, err)
+		Logger(ctx).Errorf("attach storage[%v] meet error=%v", storage.Id, err)
 		return err
 	}
 
-	err = checkDiksState(client, storage.Id, true, DISK_STATE_ATTACHED)
+	err = checkDiksState(ctx, client, storage.Id, true, DISK_STATE_ATTACHED, storage.WaitTimeout)
 	if err != nil {
-		logrus.Errorf("checkDiksState meet error=%v", err)
+		Logger(ctx).Errorf("checkDiksState meet error=%v", err)
 		return err
 	}
 	return nil
@@ -205,25 +200,22 @@ func (action *StorageCreateAction) checkCreateStorageParams(input StorageInput)
 	return nil
 }
 
-func (action *StorageCreateAction) createStorage(storage *StorageInput) (*StorageOutput, error) {
-	if storage.Location != "" && storage.APISecret != "" {
-		storage.ProviderParams = fmt.Sprintf("%s;%s", storage.Location, storage.APISecret)
+func (action *StorageCreateAction) createStorage(ctx context.Context, storage *StorageInput) (*StorageOutput, error) {
+	params, err := NewProviderParams(storage.ProviderParams, storage.Location, storage.APISecret)
+	if err != nil {
+		return nil, err
 	}
-	paramsMap, err := GetMapFromProviderParams(storage.ProviderParams)
-	if zone, ok := paramsMap["AvailableZone"]; ok {
-		if zone == "" {
-			err = fmt.Errorf("wrong AvailableZone value")
-			return nil, err
-		}
+	if params.Zone == "" {
+		return nil, fmt.Errorf("AvailableZone of provider_params is empty")
 	}
-	client, _ := CreateCbsClient(paramsMap["Region"], paramsMap["SecretID"], paramsMap["SecretKey"])
+	client, _ := CreateCbsClient(ctx, params)
 
 	output := StorageOutput{}
 	//check resource exist
 	if storage.Id != "" {
 		_, ok, err := queryStorageInfo(client, storage.Id)
 		if err != nil {
-			logrus.Errorf("queryStorageInfo meet error=%v", err)
+			Logger(ctx).Errorf("queryStorageInfo meet error=%v", err)
 			return nil, err
 		}
 		if ok {
@@ -233,6 +225,7 @@ func (action *StorageCreateAction) createStorage(storage *StorageInput) (*Storag
 	}
 
 	request := cbs.NewCreateDisksRequest()
+	request.ClientToken = GetClientToken(ctx)
 	if storage.DiskName != "" {
 		request.DiskName = &storage.DiskName
 	}
@@ -259,8 +252,12 @@ func (action *StorageCreateAction) createStorage(storage *StorageInput) (*Storag
 		}
 	}
 
-	availableZone := paramsMap["AvailableZone"]
+	availableZone := params.Zone
 	placement := cbs.Placement{Zone: &availableZone}
+	if params.ProjectId != "" {
+		projectId := uint64(params.GetProjectId())
+		placement.ProjectId = &projectId
+	}
 	request.Placement = &placement
 
 	response, err := client.CreateDisks(request)
@@ -274,11 +271,11 @@ func (action *StorageCreateAction) createStorage(storage *StorageInput) (*Storag
 
 	output.RequestId = *response.Response.RequestId
 	output.Id = *response.Response.DiskIdSet[0]
-	logrus.Infof("create disk response: diskId=%v", output.Id)
+	Logger(ctx).Infof("create disk response: diskId=%v", output.Id)
 
-	err = checkDiksState(client, output.Id, true, DISK_STATE_UNATTACHED)
+	err = checkDiksState(ctx, client, output.Id, true, DISK_STATE_UNATTACHED, storage.WaitTimeout)
 	if err != nil {
-		logrus.Errorf("checkDiksState meet error=%v", err)
+		Logger(ctx).Errorf("checkDiksState meet error=%v", err)
 		return &output, err
 	}
 	return &output, nil
@@ -287,7 +284,7 @@ func (action *StorageCreateAction) createStorage(storage *StorageInput) (*Storag
 type StorageTerminateAction struct {
 }
 
-func (action *StorageTerminateAction) ReadParam(param interface{}) (interface{}, error) {
+func (action *StorageTerminateAction) ReadParam(ctx context.Context, param interface{}) (interface{}, error) {
 	var inputs StorageInputs
 	err := UnmarshalJson(param, &inputs)
 	if err != nil {
@@ -314,12 +311,13 @@ func (action *StorageTerminateAction) checkTerminateStorageParams(input StorageI
 	return nil
 }
 
-func (action *StorageTerminateAction) Do(input interface{}) (interface{}, error) {
+func (action *StorageTerminateAction) Do(ctx context.Context, input interface{}) (interface{}, error) {
 	storages, _ := input.(StorageInputs)
-	outputs := StorageOutputs{}
-	var finalErr error
-
-	for _, storage := range storages.Inputs {
+	outputs := StorageOutputs{Outputs: make([]StorageOutput, len(storages.Inputs))}
+	finalErr := runInputs(ctx, len(storages.Inputs), func(i int) []string {
+		return []string{storages.Inputs[i].Guid, storages.Inputs[i].Id, storages.Inputs[i].InstanceId}
+	}, func(ctx context.Context, i int) error {
+		storage := storages.Inputs[i]
 		output := StorageOutput{
 			Guid: storage.Guid,
 			Id:   storage.Id,
@@ -327,63 +325,60 @@ func (action *StorageTerminateAction) Do(input interface{}) (interface{}, error)
 		output.CallBackParameter.Parameter = storage.CallBackParameter.Parameter
 		output.Result.Code = RESULT_CODE_SUCCESS
 
-		if err := action.checkTerminateStorageParams(storage); err != nil {
-			output.Result.Code = RESULT_CODE_ERROR
-			output.Result.Message = err.Error()
-			outputs.Outputs = append(outputs.Outputs, output)
-			finalErr = err
-			continue
+		if err := AsValidationError(action.checkTerminateStorageParams(storage)); err != nil {
+			output.Result.SetError(err)
+			outputs.Outputs[i] = output
+			return err
 		}
 
 		// check whether the storage is existed(and attached).
-		if storage.Location != "" && storage.APISecret != "" {
-			storage.ProviderParams = fmt.Sprintf("%s;%s", storage.Location, storage.APISecret)
+		params, err := NewProviderParams(storage.ProviderParams, storage.Location, storage.APISecret)
+		if err != nil {
+			output.Result.SetError(err)
+			outputs.Outputs[i] = output
+			return err
 		}
-		paramsMap, err := GetMapFromProviderParams(storage.ProviderParams)
-		client, _ := CreateCbsClient(paramsMap["Region"], paramsMap["SecretID"], paramsMap["SecretKey"])
+		client, _ := CreateCbsClient(ctx, params)
 		disk, ok, err := queryStorageInfo(client, storage.Id)
 		if err != nil {
-			logrus.Errorf("queryStorageInfo meet error=%v", err)
-			output.Result.Code = RESULT_CODE_ERROR
-			output.Result.Message = err.Error()
-			outputs.Outputs = append(outputs.Outputs, output)
-			finalErr = err
-			continue
+			Logger(ctx).Errorf("queryStorageInfo meet error=%v", err)
+			output.Result.SetError(err)
+			outputs.Outputs[i] = output
+			return err
 		}
 		if !ok {
-			logrus.Infof("queryStorageInfo disk[%v] is not existed", storage.Id)
-			outputs.Outputs = append(outputs.Outputs, output)
-			continue
+			Logger(ctx).Infof("queryStorageInfo disk[%v] is not existed", storage.Id)
+			outputs.Outputs[i] = output
+			return nil
 		}
 		if *disk.DiskState == DISK_STATE_ATTACHED {
-			err = action.detachStorage(&storage)
+			err = action.detachStorage(ctx, &storage)
 			if err != nil {
-				output.Result.Code = RESULT_CODE_ERROR
-				output.Result.Message = err.Error()
-				outputs.Outputs = append(outputs.Outputs, output)
-				finalErr = err
-				continue
+				output.Result.SetError(err)
+				outputs.Outputs[i] = output
+				return err
 			}
 		}
-		_, err = action.terminateStorage(&storage)
+		_, err = action.terminateStorage(ctx, &storage)
 		if err != nil {
-			output.Result.Code = RESULT_CODE_ERROR
-			output.Result.Message = err.Error()
-			finalErr = err
+			output.Result.SetError(err)
+			outputs.Outputs[i] = output
+			return err
 		}
 
-		outputs.Outputs = append(outputs.Outputs, output)
-	}
+		outputs.Outputs[i] = output
+		return nil
+	})
 
 	return &outputs, finalErr
 }
 
-func (action *StorageTerminateAction) detachStorage(storage *StorageInput) error {
-	if storage.Location != "" && storage.APISecret != "" {
-		storage.ProviderParams = fmt.Sprintf("%s;%s", storage.Location, storage.APISecret)
+func (action *StorageTerminateAction) detachStorage(ctx context.Context, storage *StorageInput) error {
+	params, err := NewProviderParams(storage.ProviderParams, storage.Location, storage.APISecret)
+	if err != nil {
+		return err
 	}
-	paramsMap, err := GetMapFromProviderParams(storage.ProviderParams)
-	client, _ := CreateCbsClient(paramsMap["Region"], paramsMap["SecretID"], paramsMap["SecretKey"])
+	client, _ := CreateCbsClient(ctx, params)
 
 	request := cbs.NewDetachDisksRequest()
 	request.DiskIds = []*string{&storage.Id}
@@ -392,38 +387,37 @@ func (action *StorageTerminateAction) detachStorage(storage *StorageInput) error
 		return fmt.Errorf("detach storage(id = %v) in cloud meet error = %v", storage.Id, err)
 	}
 
-	err = checkDiksState(client, storage.Id, true, DISK_STATE_UNATTACHED)
+	err = checkDiksState(ctx, client, storage.Id, true, DISK_STATE_UNATTACHED, storage.WaitTimeout)
 	if err != nil {
-		logrus.Errorf("checkDiksState meet error=%v", err)
+		Logger(ctx).Errorf("checkDiksState meet error=%v", err)
 		return err
 	}
 
-	logrus.Infof("detach storage request id = %v", response.Response.RequestId)
+	Logger(ctx).Infof("detach storage request id = %v", response.Response.RequestId)
 	return nil
 }
 
-func (action *StorageTerminateAction) terminateStorage(storage *StorageInput) (*StorageOutput, error) {
-	if storage.Location != "" && storage.APISecret != "" {
-		storage.ProviderParams = fmt.Sprintf("%s;%s", storage.Location, storage.APISecret)
+func (action *StorageTerminateAction) terminateStorage(ctx context.Context, storage *StorageInput) (*StorageOutput, error) {
+	params, err := NewProviderParams(storage.ProviderParams, storage.Location, storage.APISecret)
+	if err != nil {
+		return nil, err
 	}
-	paramsMap, _ := GetMapFromProviderParams(storage.ProviderParams)
-
-	client, _ := CreateCbsClient(paramsMap["Region"], paramsMap["SecretID"], paramsMap["SecretKey"])
+	client, _ := CreateCbsClient(ctx, params)
 
 	request := cbs.NewTerminateDisksRequest()
 	request.DiskIds = []*string{&storage.Id}
 	response, err := client.TerminateDisks(request)
 	if err != nil {
-		logrus.Errorf("teminate disks meet error=%v", err)
+		Logger(ctx).Errorf("teminate disks meet error=%v", err)
 		return nil, err
 	}
 	output := StorageOutput{}
 	output.RequestId = *response.Response.RequestId
 	output.Id = storage.Id
 
-	err = checkDiksState(client, storage.Id, false, "")
+	err = checkDiksState(ctx, client, storage.Id, false, "", storage.WaitTimeout)
 	if err != nil {
-		logrus.Errorf("checkDiksState meet error=%v", err)
+		Logger(ctx).Errorf("checkDiksState meet error=%v", err)
 		return &output, err
 	}
 	return &output, nil
@@ -431,32 +425,27 @@ func (action *StorageTerminateAction) terminateStorage(storage *StorageInput) (*
 
 // isExist:  the disk is exist or not expected; state: the disk state expected.
 // 1. if state is not "", the isExist must be true; 2. if state is "", the isExist can be false or true.
-func checkDiksState(client *cbs.Client, storageId string, isExist bool, state string) error {
-	count := 1
-	for {
+func checkDiksState(ctx context.Context, client *cbs.Client, storageId string, isExist bool, state string, timeout string) error {
+	waiter, err := NewWaiter(WAIT_RESOURCE_DISK, storageId, timeout)
+	if err != nil {
+		return err
+	}
+	return waiter.Wait(ctx, func() (string, bool, error) {
 		disk, ok, err := queryStorageInfo(client, storageId)
 		if err != nil {
-			return err
+			return "", false, err
 		}
 
 		// check whether the disk is existed.
-		if state == "" {
-			if isExist == ok {
-				return nil
-			}
-		} else {
-			// if the state is expected, return no error; the isExist is true default.
-			if ok && *disk.DiskState == state {
-				return nil
-			}
+		if !ok {
+			return "", state == "" && !isExist, nil
 		}
-
-		if count > 20 {
-			logrus.Errorf("after %vs, the disk[%v] state=%v", 5*count, storageId, *disk.DiskState)
-			return fmt.Errorf("after %vs, the disk[%v] state=%v", 5*count, storageId, *disk.DiskState)
+		if state == "" {
+			return *disk.DiskState, isExist, nil
 		}
-		time.Sleep(5 * time.Second)
-	}
+		// if the state is expected, return no error; the isExist is true default.
+		return *disk.DiskState, *disk.DiskState == state, nil
+	})
 }
 
 func queryStorageInfo(client *cbs.Client, storageId string) (*cbs.Disk, bool, error) {
@@ -1,13 +1,12 @@
 package plugins
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"net"
 
 	"github.com/sirupsen/logrus"
-	"github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/common"
-	"github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/common/profile"
 	vpc "github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/vpc/v20170312"
 )
 
@@ -20,13 +19,8 @@ func init() {
 	SubnetActions["terminate-with-routetable"] = new(TerminateSubnetWithRouteTableAction)
 }
 
-func CreateSubnetClient(region, secretId, secretKey string) (client *vpc.Client, err error) {
-	credential := common.NewCredential(secretId, secretKey)
-
-	clientProfile := profile.NewClientProfile()
-	clientProfile.HttpProfile.Endpoint = "vpc.tencentcloudapi.com"
-
-	return vpc.NewClient(credential, region, clientProfile)
+func CreateSubnetClient(ctx context.Context, params *ProviderParams) (client *vpc.Client, err error) {
+	return GetClientFactory().WithContext(ctx).WithProviderParams(params).NewVpcClient(params.Region, params.SecretID, params.SecretKey)
 }
 
 type SubnetInputs struct {
@@ -35,15 +29,15 @@ type SubnetInputs struct {
 
 type SubnetInput struct {
 	CallBackParameter
-	Guid           string `json:"guid,omitempty"`
-	ProviderParams string `json:"provider_params,omitempty"`
-	Id             string `json:"id,omitempty"`
-	Name           string `json:"name,omitempty"`
-	CidrBlock      string `json:"cidr_block,omitempty"`
-	VpcId          string `json:"vpc_id,omitempty"`
-	RouteTableId   string `json:"route_table_id,omitempty"`
-	Location       string `json:"location"`
-	APISecret      string `json:"api_secret"`
+	Guid           string `json:"guid,omitempty" register:"required"`
+	ProviderParams string `json:"provider_params,omitempty" register:"optional"`
+	Id             string `json:"id,omitempty" register:"required=terminate|terminate-with-routetable"`
+	Name           string `json:"name,omitempty" register:"required,actions=create|create-with-routetable"`
+	CidrBlock      string `json:"cidr_block,omitempty" register:"required,actions=create|create-with-routetable"`
+	VpcId          string `json:"vpc_id,omitempty" register:"required,actions=create|create-with-routetable"`
+	RouteTableId   string `json:"route_table_id,omitempty" register:"required,actions=terminate-with-routetable"`
+	Location       string `json:"location" register:"required"`
+	APISecret      string `json:"api_secret" register:"required,system_variable=QCLOUD_API_SECRET"`
 }
 
 type SubnetOutputs struct {
@@ -54,9 +48,9 @@ type SubnetOutput struct {
 	CallBackParameter
 	Result
 	RequestId    string `json:"request_id,omitempty"`
-	Guid         string `json:"guid,omitempty"`
-	Id           string `json:"id,omitempty"`
-	RouteTableId string `json:"route_table_id,omitempty"`
+	Guid         string `json:"guid,omitempty" register:"optional"`
+	Id           string `json:"id,omitempty" register:"optional,actions=create|create-with-routetable"`
+	RouteTableId string `json:"route_table_id,omitempty" register:"optional,actions=create-with-routetable"`
 }
 
 type SubnetPlugin struct {
@@ -71,10 +65,14 @@ func (plugin *SubnetPlugin) GetActionByName(actionName string) (Action, error) {
 	return action, nil
 }
 
+func (plugin *SubnetPlugin) GetActions() map[string]Action {
+	return SubnetActions
+}
+
 type SubnetCreateAction struct {
 }
 
-func (action *SubnetCreateAction) ReadParam(param interface{}) (interface{}, error) {
+func (action *SubnetCreateAction) ReadParam(ctx context.Context, param interface{}) (interface{}, error) {
 	var inputs SubnetInputs
 	err := UnmarshalJson(param, &inputs)
 	if err != nil {
@@ -97,33 +95,30 @@ func subnetCreateCheckParam(subnet *SubnetInput) error {
 	return nil
 }
 
-func (action *SubnetCreateAction) createSubnet(subnet *SubnetInput) (output SubnetOutput, err error) {
+func (action *SubnetCreateAction) createSubnet(ctx context.Context, subnet *SubnetInput) (output SubnetOutput, err error) {
 	output.Guid = subnet.Guid
 	output.Result.Code = RESULT_CODE_SUCCESS
 	output.CallBackParameter.Parameter = subnet.CallBackParameter.Parameter
 
 	defer func() {
 		if err != nil {
-			output.Result.Code = RESULT_CODE_ERROR
-			output.Result.Message = err.Error()
+			output.Result.SetError(err)
 		}
 	}()
 
-	if err = subnetCreateCheckParam(subnet); err != nil {
+	if err = AsValidationError(subnetCreateCheckParam(subnet)); err != nil {
 		return output, err
 	}
 
-	if subnet.Location != "" && subnet.APISecret != "" {
-		subnet.ProviderParams = fmt.Sprintf("%s;%s", subnet.Location, subnet.APISecret)
+	params, err := NewProviderParams(subnet.ProviderParams, subnet.Location, subnet.APISecret)
+	if err != nil {
+		return output, err
 	}
-	paramsMap, _ := GetMapFromProviderParams(subnet.ProviderParams)
-	if zone, ok := paramsMap["AvailableZone"]; ok {
-		if zone == "" {
-			err = fmt.Errorf("wrong AvailableZone value")
-			return
-		}
+	if params.Zone == "" {
+		err = fmt.Errorf("AvailableZone of provider_params is empty")
+		return output, err
 	}
-	client, err := CreateSubnetClient(paramsMap["Region"], paramsMap["SecretID"], paramsMap["SecretKey"])
+	client, err := CreateSubnetClient(ctx, params)
 	if err != nil {
 		return output, err
 	}
@@ -148,7 +143,7 @@ func (action *SubnetCreateAction) createSubnet(subnet *SubnetInput) (output Subn
 	request.VpcId = &subnet.VpcId
 	request.SubnetName = &subnet.Name
 	request.CidrBlock = &subnet.CidrBlock
-	az := paramsMap["AvailableZone"]
+	az := params.Zone
 	request.Zone = &az
 
 	response, err := client.CreateSubnet(request)
@@ -163,28 +158,26 @@ func (action *SubnetCreateAction) createSubnet(subnet *SubnetInput) (output Subn
 	return output, err
 }
 
-func (action *SubnetCreateAction) Do(input interface{}) (interface{}, error) {
+func (action *SubnetCreateAction) Do(ctx context.Context, input interface{}) (interface{}, error) {
 	subnets, _ := input.(SubnetInputs)
-	outputs := SubnetOutputs{}
-	var finalErr error
-
-	for _, subnet := range subnets.Inputs {
-		output, err := action.createSubnet(&subnet)
-		if err != nil {
-			finalErr = err
-		}
-
-		outputs.Outputs = append(outputs.Outputs, output)
-	}
-
-	logrus.Infof("all subnet = %v are created", subnets)
+	outputs := SubnetOutputs{Outputs: make([]SubnetOutput, len(subnets.Inputs))}
+	finalErr := runInputs(ctx, len(subnets.Inputs), func(i int) []string {
+		return []string{subnets.Inputs[i].Guid, subnets.Inputs[i].Id}
+	}, func(ctx context.Context, i int) error {
+		subnet := subnets.Inputs[i]
+		output, err := action.createSubnet(ctx, &subnet)
+		outputs.Outputs[i] = output
+		return err
+	})
+
+	Logger(ctx).Infof("all subnet = %s are created", Sanitize(subnets))
 	return &outputs, finalErr
 }
 
 type SubnetTerminateAction struct {
 }
 
-func (action *SubnetTerminateAction) ReadParam(param interface{}) (interface{}, error) {
+func (action *SubnetTerminateAction) ReadParam(ctx context.Context, param interface{}) (interface{}, error) {
 	var inputs SubnetInputs
 	err := UnmarshalJson(param, &inputs)
 	if err != nil {
@@ -193,18 +186,19 @@ func (action *SubnetTerminateAction) ReadParam(param interface{}) (interface{},
 	return inputs, nil
 }
 
-func (action *SubnetTerminateAction) terminateSubnet(subnet *SubnetInput) (SubnetOutput, error) {
+func (action *SubnetTerminateAction) terminateSubnet(ctx context.Context, subnet *SubnetInput) (SubnetOutput, error) {
 	output := SubnetOutput{
 		Guid: subnet.Guid,
 	}
 	output.CallBackParameter.Parameter = subnet.CallBackParameter.Parameter
 	output.Result.Code = RESULT_CODE_SUCCESS
 
-	if subnet.Location != "" && subnet.APISecret != "" {
-		subnet.ProviderParams = fmt.Sprintf("%s;%s", subnet.Location, subnet.APISecret)
+	params, err := NewProviderParams(subnet.ProviderParams, subnet.Location, subnet.APISecret)
+	if err != nil {
+		output.Result.SetError(err)
+		return output, err
 	}
-	paramsMap, err := GetMapFromProviderParams(subnet.ProviderParams)
-	client, _ := CreateSubnetClient(paramsMap["Region"], paramsMap["SecretID"], paramsMap["SecretKey"])
+	client, _ := CreateSubnetClient(ctx, params)
 
 	if subnet.Id == "" {
 		output.Result.Code = RESULT_CODE_ERROR
@@ -215,8 +209,7 @@ func (action *SubnetTerminateAction) terminateSubnet(subnet *SubnetInput) (Subne
 	// check whether subnet is exist.
 	_, ok, err := querySubnetsInfo(client, subnet)
 	if err != nil {
-		output.Result.Code = RESULT_CODE_ERROR
-		output.Result.Message = err.Error()
+		output.Result.SetError(err)
 		return output, err
 	}
 
@@ -242,18 +235,17 @@ func (action *SubnetTerminateAction) terminateSubnet(subnet *SubnetInput) (Subne
 	return output, nil
 }
 
-func (action *SubnetTerminateAction) Do(input interface{}) (interface{}, error) {
+func (action *SubnetTerminateAction) Do(ctx context.Context, input interface{}) (interface{}, error) {
 	subnets, _ := input.(SubnetInputs)
-	outputs := SubnetOutputs{}
-	var finalErr error
-
-	for _, subnet := range subnets.Inputs {
-		output, err := action.terminateSubnet(&subnet)
-		if err != nil {
-			finalErr = err
-		}
-		outputs.Outputs = append(outputs.Outputs, output)
-	}
+	outputs := SubnetOutputs{Outputs: make([]SubnetOutput, len(subnets.Inputs))}
+	finalErr := runInputs(ctx, len(subnets.Inputs), func(i int) []string {
+		return []string{subnets.Inputs[i].Guid, subnets.Inputs[i].Id}
+	}, func(ctx context.Context, i int) error {
+		subnet := subnets.Inputs[i]
+		output, err := action.terminateSubnet(ctx, &subnet)
+		outputs.Outputs[i] = output
+		return err
+	})
 
 	return &outputs, finalErr
 }
@@ -289,19 +281,19 @@ func querySubnetsInfo(client *vpc.Client, input *SubnetInput) (*SubnetOutput, bo
 type CreateSubnetWithRouteTableAction struct {
 }
 
-func (action *CreateSubnetWithRouteTableAction) ReadParam(param interface{}) (interface{}, error) {
+func (action *CreateSubnetWithRouteTableAction) ReadParam(ctx context.Context, param interface{}) (interface{}, error) {
 	createAction := SubnetCreateAction{}
-	return createAction.ReadParam(param)
+	return createAction.ReadParam(ctx, param)
 }
 
-func destroySubnetWithRouteTable(providerParams string, subnetId string, routeTableId string) error {
+func destroySubnetWithRouteTable(ctx context.Context, providerParams string, subnetId string, routeTableId string) error {
 	//destroy subnet
 	terminateSubnetAction := SubnetTerminateAction{}
 	subnetInput := &SubnetInput{
 		ProviderParams: providerParams,
 		Id:             subnetId,
 	}
-	_, terminateSubnetErr := terminateSubnetAction.terminateSubnet(subnetInput)
+	_, terminateSubnetErr := terminateSubnetAction.terminateSubnet(ctx, subnetInput)
 
 	//destroy routeTable
 	terminateRouteTableAction := RouteTableTerminateAction{}
@@ -309,7 +301,7 @@ func destroySubnetWithRouteTable(providerParams string, subnetId string, routeTa
 		ProviderParams: providerParams,
 		Id:             routeTableId,
 	}
-	_, terminateRouteTableErr := terminateRouteTableAction.terminateRouteTable(routeTableInput)
+	_, terminateRouteTableErr := terminateRouteTableAction.terminateRouteTable(ctx, routeTableInput)
 
 	if terminateSubnetErr != nil {
 		return terminateSubnetErr
@@ -320,28 +312,28 @@ func destroySubnetWithRouteTable(providerParams string, subnetId string, routeTa
 	return nil
 }
 
-func createSubnetWithRouteTable(input *SubnetInput) (output SubnetOutput, err error) {
+func createSubnetWithRouteTable(ctx context.Context, input *SubnetInput) (output SubnetOutput, err error) {
 	output.Guid = input.Guid
 	output.CallBackParameter.Parameter = input.CallBackParameter.Parameter
 	output.Result.Code = RESULT_CODE_SUCCESS
-	if input.Location != "" && input.APISecret != "" {
-		input.ProviderParams = fmt.Sprintf("%s;%s", input.Location, input.APISecret)
+	if input.ProviderParams, err = mergeProviderParams(input.ProviderParams, input.Location, input.APISecret); err != nil {
+		output.Result.SetError(err)
+		return output, err
 	}
 
 	defer func() {
 		if err != nil {
-			output.Result.Code = RESULT_CODE_ERROR
-			output.Result.Message = err.Error()
-			destroySubnetWithRouteTable(input.ProviderParams, output.Id, output.RouteTableId)
+			output.Result.SetError(err)
+			destroySubnetWithRouteTable(ctx, input.ProviderParams, output.Id, output.RouteTableId)
 		}
 	}()
 
-	if err = subnetCreateCheckParam(input); err != nil {
+	if err = AsValidationError(subnetCreateCheckParam(input)); err != nil {
 		return output, err
 	}
 
 	action := SubnetCreateAction{}
-	createSubnetOutput, err := action.createSubnet(input)
+	createSubnetOutput, err := action.createSubnet(ctx, input)
 	if err != nil {
 		return output, err
 	}
@@ -357,28 +349,28 @@ func createSubnetWithRouteTable(input *SubnetInput) (output SubnetOutput, err er
 	}
 
 	createRouteTableAction := RouteTableCreateAction{}
-	createRouteTableOutput, err := createRouteTableAction.createRouteTable(&routeTableInput)
+	createRouteTableOutput, err := createRouteTableAction.createRouteTable(ctx, &routeTableInput)
 	if err != nil {
 		return output, err
 	}
 	output.RouteTableId = createRouteTableOutput.Id
 
 	//associate subnet with route table
-	err = associateSubnetWithRouteTable(input.ProviderParams, output.Id, output.RouteTableId)
+	err = associateSubnetWithRouteTable(ctx, input.ProviderParams, output.Id, output.RouteTableId)
 	return output, err
 }
 
-func (action *CreateSubnetWithRouteTableAction) Do(input interface{}) (interface{}, error) {
+func (action *CreateSubnetWithRouteTableAction) Do(ctx context.Context, input interface{}) (interface{}, error) {
 	subnets, _ := input.(SubnetInputs)
-	outputs := SubnetOutputs{}
-	var finalErr error
-	for _, subnet := range subnets.Inputs {
-		output, err := createSubnetWithRouteTable(&subnet)
-		if err != nil {
-			finalErr = err
-		}
-		outputs.Outputs = append(outputs.Outputs, output)
-	}
+	outputs := SubnetOutputs{Outputs: make([]SubnetOutput, len(subnets.Inputs))}
+	finalErr := runInputs(ctx, len(subnets.Inputs), func(i int) []string {
+		return []string{subnets.Inputs[i].Guid, subnets.Inputs[i].Id, subnets.Inputs[i].RouteTableId}
+	}, func(ctx context.Context, i int) error {
+		subnet := subnets.Inputs[i]
+		output, err := createSubnetWithRouteTable(ctx, &subnet)
+		outputs.Outputs[i] = output
+		return err
+	})
 
 	return &outputs, finalErr
 }
@@ -386,9 +378,9 @@ func (action *CreateSubnetWithRouteTableAction) Do(input interface{}) (interface
 type TerminateSubnetWithRouteTableAction struct {
 }
 
-func (action *TerminateSubnetWithRouteTableAction) ReadParam(param interface{}) (interface{}, error) {
+func (action *TerminateSubnetWithRouteTableAction) ReadParam(ctx context.Context, param interface{}) (interface{}, error) {
 	terminateAction := SubnetTerminateAction{}
-	return terminateAction.ReadParam(param)
+	return terminateAction.ReadParam(ctx, param)
 }
 
 func terminateSubnetWithRouteTableCheckParam(input SubnetInput) error {
@@ -403,12 +395,13 @@ func terminateSubnetWithRouteTableCheckParam(input SubnetInput) error {
 	return nil
 }
 
-func (action *TerminateSubnetWithRouteTableAction) Do(input interface{}) (interface{}, error) {
+func (action *TerminateSubnetWithRouteTableAction) Do(ctx context.Context, input interface{}) (interface{}, error) {
 	inputs, _ := input.(SubnetInputs)
-	outputs := SubnetOutputs{}
-	var finalErr error
-
-	for _, input := range inputs.Inputs {
+	outputs := SubnetOutputs{Outputs: make([]SubnetOutput, len(inputs.Inputs))}
+	finalErr := runInputs(ctx, len(inputs.Inputs), func(i int) []string {
+		return []string{inputs.Inputs[i].Guid, inputs.Inputs[i].Id, inputs.Inputs[i].RouteTableId}
+	}, func(ctx context.Context, i int) error {
+		input := inputs.Inputs[i]
 		output := SubnetOutput{
 			Guid: input.Guid,
 			Id:   input.Id,
@@ -416,24 +409,24 @@ func (action *TerminateSubnetWithRouteTableAction) Do(input interface{}) (interf
 		output.CallBackParameter.Parameter = input.CallBackParameter.Parameter
 		output.Result.Code = RESULT_CODE_SUCCESS
 
-		if err := terminateSubnetWithRouteTableCheckParam(input); err != nil {
-			finalErr = err
-			output.Result.Code = RESULT_CODE_ERROR
-			output.Result.Message = err.Error()
-			outputs.Outputs = append(outputs.Outputs, output)
-			continue
+		if err := AsValidationError(terminateSubnetWithRouteTableCheckParam(input)); err != nil {
+			output.Result.SetError(err)
+			outputs.Outputs[i] = output
+			return err
 		}
 
-		if input.Location != "" && input.APISecret != "" {
-			input.ProviderParams = fmt.Sprintf("%s;%s", input.Location, input.APISecret)
+		providerParams, err := mergeProviderParams(input.ProviderParams, input.Location, input.APISecret)
+		if err == nil {
+			err = destroySubnetWithRouteTable(ctx, providerParams, input.Id, input.RouteTableId)
 		}
-		if err := destroySubnetWithRouteTable(input.ProviderParams, input.Id, input.RouteTableId); err != nil {
-			finalErr = err
-			output.Result.Code = RESULT_CODE_ERROR
-			output.Result.Message = err.Error()
+		if err != nil {
+			output.Result.SetError(err)
+			outputs.Outputs[i] = output
+			return err
 		}
 
-		outputs.Outputs = append(outputs.Outputs, output)
-	}
+		outputs.Outputs[i] = output
+		return nil
+	})
 	return outputs, finalErr
 }
@@ -0,0 +1,326 @@
+package plugins
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"reflect"
+	"sync"
+	"time"
+)
+
+const (
+	TASK_STATUS_PENDING = "pending"
+	TASK_STATUS_RUNNING = "running"
+	TASK_STATUS_SUCCESS = "success"
+	TASK_STATUS_FAILED  = "failed"
+
+	TASK_EXPIRE_DURATION = 24 * time.Hour
+)
+
+type TaskInputProgress struct {
+	Index   int    `json:"index"`
+	Guid    string `json:"guid,omitempty"`
+	Status  string `json:"status"`
+	Code    string `json:"errorCode,omitempty"`
+	Message string `json:"errorMessage,omitempty"`
+}
+
+type Task struct {
+	Id         string              `json:"taskId"`
+	Plugin     string              `json:"plugin"`
+	Action     string              `json:"action"`
+	Status     string              `json:"status"`
+	CreateTime time.Time           `json:"createTime"`
+	StartTime  *time.Time          `json:"startTime,omitempty"`
+	EndTime    *time.Time          `json:"endTime,omitempty"`
+	Total      int                 `json:"total"`
+	Finished   int                 `json:"finished"`
+	Inputs     []TaskInputProgress `json:"inputs"`
+	Response   *PluginResponse     `json:"response,omitempty"`
+
+	mutex sync.Mutex
+}
+
+type TaskBrief struct {
+	Id     string `json:"taskId"`
+	Status string `json:"status"`
+}
+
+var (
+	tasksMutex sync.Mutex
+	tasks      = make(map[string]*Task)
+)
+
+func newTaskId() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return fmt.Sprintf("task-%d", time.Now().UnixNano())
+	}
+	return "task-" + hex.EncodeToString(b)
+}
+
+// submitTask registers a new task for the action and runs it in background, the task is traced as a child
+// of the span of ctx and keeps its correlation id.
+func submitTask(ctx context.Context, pluginRequest *PluginRequest, action Action, actionParam interface{}) *Task {
+	task := &Task{
+		Id:         newTaskId(),
+		Plugin:     pluginRequest.Name,
+		Action:     pluginRequest.Action,
+		Status:     TASK_STATUS_PENDING,
+		CreateTime: time.Now(),
+	}
+	for i, guid := range getGuidsFromInputs(actionParam) {
+		task.Inputs = append(task.Inputs, TaskInputProgress{Index: i, Guid: guid, Status: TASK_STATUS_PENDING})
+	}
+	task.Total = len(task.Inputs)
+
+	tasksMutex.Lock()
+	removeExpiredTasks()
+	tasks[task.Id] = task
+	tasksMutex.Unlock()
+
+	go runTask(detachContext(ctx), task, action, actionParam)
+
+	Logger(ctx).Infof("plguin[%v]-action[%v] submitted as task[%v]", task.Plugin, task.Action, task.Id)
+	return task
+}
+
+func runTask(ctx context.Context, task *Task, action Action, actionParam interface{}) {
+	pluginResponse := PluginResponse{CorrelationId: GetCorrelationId(ctx)}
+	var err error
+	ctx, span := StartSpan(ctx, "task "+task.Plugin+"."+task.Action, SPAN_KIND_INTERNAL)
+	span.SetAttribute("task.id", task.Id)
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("task[%v] panic: %v", task.Id, r)
+		}
+		if err != nil {
+			Logger(ctx).Errorf("plguin[%v]-action[%v] task[%v] meet error = %v", task.Plugin, task.Action, task.Id, err)
+		} else {
+			Logger(ctx).Infof("plguin[%v]-action[%v] task[%v] completed", task.Plugin, task.Action, task.Id)
+		}
+		fillPluginResponseResult(&pluginResponse, err)
+		task.finish(&pluginResponse)
+		span.SetAttribute("plugin.result_code", pluginResponse.ResultCode)
+		span.End(err)
+	}()
+
+	ctx, cancel := getActionTimeoutPolicy().WithTimeout(ctx, task.Plugin, task.Action)
+	defer cancel()
+
+	task.start()
+	ctx = withInputProgress(ctx, task.updateInput)
+	Logger(ctx).Infof("task[%v] action do with parameters = %s", task.Id, Sanitize(actionParam))
+	pluginResponse.Results, err = doAction(ctx, task.Plugin, task.Action, action, actionParam)
+}
+
+func (task *Task) start() {
+	task.mutex.Lock()
+	defer task.mutex.Unlock()
+
+	now := time.Now()
+	task.StartTime = &now
+	task.Status = TASK_STATUS_RUNNING
+}
+
+// updateInput sets the status of the input when runInputs starts it or it is done, so the progress of the
+// inputs is seen while the task is running.
+func (task *Task) updateInput(i int, done bool, err error) {
+	task.mutex.Lock()
+	defer task.mutex.Unlock()
+
+	if i < 0 || i >= len(task.Inputs) {
+		return
+	}
+	input := &task.Inputs[i]
+	if !done {
+		input.Status = TASK_STATUS_RUNNING
+		return
+	}
+	if input.Status == TASK_STATUS_SUCCESS || input.Status == TASK_STATUS_FAILED {
+		return
+	}
+	input.Status = TASK_STATUS_SUCCESS
+	if err != nil {
+		result := Result{}
+		result.SetError(err)
+		input.Status, input.Code, input.Message = TASK_STATUS_FAILED, result.Code, result.Message
+	}
+	task.Finished++
+}
+
+func (task *Task) finish(pluginResponse *PluginResponse) {
+	task.mutex.Lock()
+	defer task.mutex.Unlock()
+
+	now := time.Now()
+	task.EndTime = &now
+	task.Response = pluginResponse
+	task.Status = TASK_STATUS_SUCCESS
+	if pluginResponse.ResultCode != RESULT_CODE_SUCCESS {
+		task.Status = TASK_STATUS_FAILED
+	}
+
+	results := getResultsFromOutputs(pluginResponse.Results)
+	for i := range task.Inputs {
+		input := &task.Inputs[i]
+		if i < len(results) {
+			input.Code = results[i].Code
+			input.Message = results[i].Message
+			input.Status = TASK_STATUS_SUCCESS
+			if results[i].Code != RESULT_CODE_SUCCESS {
+				input.Status = TASK_STATUS_FAILED
+			}
+		} else {
+			input.Status = task.Status
+		}
+	}
+	task.Finished = len(task.Inputs)
+}
+
+// Snapshot returns a copy of the task which is safe to marshal while the task is running.
+func (task *Task) Snapshot() *Task {
+	task.mutex.Lock()
+	defer task.mutex.Unlock()
+
+	snapshot := &Task{
+		Id:         task.Id,
+		Plugin:     task.Plugin,
+		Action:     task.Action,
+		Status:     task.Status,
+		CreateTime: task.CreateTime,
+		StartTime:  task.StartTime,
+		EndTime:    task.EndTime,
+		Total:      task.Total,
+		Finished:   task.Finished,
+		Inputs:     append([]TaskInputProgress{}, task.Inputs...),
+		Response:   task.Response,
+	}
+	return snapshot
+}
+
+func (task *Task) IsDone() bool {
+	task.mutex.Lock()
+	defer task.mutex.Unlock()
+
+	return task.Status == TASK_STATUS_SUCCESS || task.Status == TASK_STATUS_FAILED
+}
+
+func GetTaskById(taskId string) (*Task, error) {
+	tasksMutex.Lock()
+	defer tasksMutex.Unlock()
+
+	task, found := tasks[taskId]
+	if !found {
+		return nil, newPluginError(ERROR_CATEGORY_NOT_FOUND, ERROR_CODE_TASK_NOT_FOUND, false, "task[%s] not found", taskId)
+	}
+	return task, nil
+}
+
+// removeExpiredTasks must be called with tasksMutex held.
+func removeExpiredTasks() {
+	for id, task := range tasks {
+		if task.IsDone() && time.Since(task.CreateTime) > TASK_EXPIRE_DURATION {
+			delete(tasks, id)
+		}
+	}
+}
+
+// getGuidsFromInputs returns the guid of each element of the Inputs field of an action param.
+func getGuidsFromInputs(actionParam interface{}) []string {
+	guids := []string{}
+	inputs := getSliceField(actionParam, "Inputs")
+	if !inputs.IsValid() {
+		return guids
+	}
+	for i := 0; i < inputs.Len(); i++ {
+		guid := ""
+		item := reflect.Indirect(inputs.Index(i))
+		if item.Kind() == reflect.Struct {
+			if field := item.FieldByName("Guid"); field.IsValid() && field.Kind() == reflect.String {
+				guid = field.String()
+			}
+		}
+		guids = append(guids, guid)
+	}
+	return guids
+}
+
+// getResultsFromOutputs returns the Result of each element of the Outputs field of an action result.
+func getResultsFromOutputs(actionResult interface{}) []Result {
+	results := []Result{}
+	outputs := getSliceField(actionResult, "Outputs")
+	if !outputs.IsValid() {
+		return results
+	}
+	for i := 0; i < outputs.Len(); i++ {
+		result := Result{}
+		item := reflect.Indirect(outputs.Index(i))
+		if item.Kind() == reflect.Struct {
+			if field := item.FieldByName("Result"); field.IsValid() {
+				result, _ = field.Interface().(Result)
+			}
+		}
+		results = append(results, result)
+	}
+	return results
+}
+
+// fillNotStartedOutputs sets the guid, the callback parameter and the error result of the outputs
+// of the inputs which were not started.
+func fillNotStartedOutputs(actionParam interface{}, actionResult interface{}, canceledErr *ActionCanceledError) {
+	inputs := getSliceField(actionParam, "Inputs")
+	outputs := getSliceField(actionResult, "Outputs")
+	if !inputs.IsValid() || !outputs.IsValid() {
+		return
+	}
+	result := Result{Code: RESULT_CODE_ERROR, Message: fmt.Sprintf("input is not started, action is canceled (%v)", canceledErr.Err)}
+	for _, i := range canceledErr.NotStarted {
+		if i >= inputs.Len() || i >= outputs.Len() {
+			continue
+		}
+		copyInputIdentity(inputs.Index(i), outputs.Index(i))
+		setOutputResult(outputs.Index(i), result)
+	}
+}
+
+// copyInputIdentity sets the guid and the callback parameter of the output from the input.
+func copyInputIdentity(input, output reflect.Value) {
+	input, output = reflect.Indirect(input), reflect.Indirect(output)
+	if input.Kind() != reflect.Struct || output.Kind() != reflect.Struct {
+		return
+	}
+	for _, name := range []string{"Guid", "CallBackParameter"} {
+		inputField, outputField := input.FieldByName(name), output.FieldByName(name)
+		if inputField.IsValid() && outputField.IsValid() && outputField.CanSet() && inputField.Type() == outputField.Type() {
+			outputField.Set(inputField)
+		}
+	}
+}
+
+func setOutputResult(output reflect.Value, result Result) {
+	output = reflect.Indirect(output)
+	if output.Kind() != reflect.Struct {
+		return
+	}
+	if field := output.FieldByName("Result"); field.IsValid() && field.CanSet() && field.Type() == reflect.TypeOf(result) {
+		field.Set(reflect.ValueOf(result))
+	}
+}
+
+func getSliceField(s interface{}, name string) reflect.Value {
+	if s == nil {
+		return reflect.Value{}
+	}
+	v := reflect.Indirect(reflect.ValueOf(s))
+	if v.Kind() != reflect.Struct {
+		return reflect.Value{}
+	}
+	field := v.FieldByName(name)
+	if !field.IsValid() || field.Kind() != reflect.Slice {
+		return reflect.Value{}
+	}
+	return field
+}
@@ -0,0 +1,105 @@
+package plugins
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func waitForTask(t *testing.T, task *Task, done func(snapshot *Task) bool) *Task {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		snapshot := task.Snapshot()
+		if done(snapshot) {
+			return snapshot
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("task does not reach the state, task=%+v", snapshot)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestSubmitTaskReportsInputProgress(t *testing.T) {
+	blocked, unblock := make(chan struct{}), make(chan struct{})
+	action := &testAction{serial: true, runInput: func(ctx context.Context, i int, input testInput, output *testOutput) error {
+		switch input.Guid {
+		case "guid_1":
+			return errors.New("input is wrong")
+		case "guid_2":
+			close(blocked)
+			<-unblock
+		}
+		output.Id = "res-" + input.Guid
+		return nil
+	}}
+
+	pluginRequest := &PluginRequest{Name: "test", Action: "update", Async: true}
+	task := submitTask(ContextWithCorrelationId(context.Background(), "task-correlation"), pluginRequest, action, newTestInputs("guid_1", "guid_2", "guid_3"))
+	if found, err := GetTaskById(task.Id); err != nil || found != task {
+		t.Fatalf("task is not found, err=%v", err)
+	}
+	if _, err := GetTaskById("task-not-exist"); ClassifyError(err).Code != ERROR_CODE_TASK_NOT_FOUND {
+		t.Errorf("get task not exist, err=%v", err)
+	}
+
+	<-blocked
+	snapshot := waitForTask(t, task, func(snapshot *Task) bool { return snapshot.Inputs[1].Status == TASK_STATUS_RUNNING })
+	if snapshot.Status != TASK_STATUS_RUNNING || snapshot.Total != 3 || snapshot.Finished != 1 || snapshot.Response != nil {
+		t.Errorf("running task=%+v", snapshot)
+	}
+	if input := snapshot.Inputs[0]; input.Guid != "guid_1" || input.Status != TASK_STATUS_FAILED || input.Code != RESULT_CODE_ERROR || input.Message != "input is wrong" {
+		t.Errorf("failed input=%+v", input)
+	}
+	if input := snapshot.Inputs[2]; input.Status != TASK_STATUS_PENDING {
+		t.Errorf("input not started=%+v", input)
+	}
+
+	close(unblock)
+	snapshot = waitForTask(t, task, func(snapshot *Task) bool { return snapshot.Response != nil })
+	if snapshot.Status != TASK_STATUS_FAILED || snapshot.Finished != 3 || snapshot.StartTime == nil || snapshot.EndTime == nil {
+		t.Errorf("done task=%+v", snapshot)
+	}
+	for i, status := range []string{TASK_STATUS_FAILED, TASK_STATUS_SUCCESS, TASK_STATUS_SUCCESS} {
+		if snapshot.Inputs[i].Status != status {
+			t.Errorf("input %v=%+v", i, snapshot.Inputs[i])
+		}
+	}
+	if snapshot.Response.CorrelationId != "task-correlation" || snapshot.Response.ResultCode != RESULT_CODE_ERROR {
+		t.Errorf("response=%+v", snapshot.Response)
+	}
+	if outputs := getTestOutputs(snapshot.Response.Results); len(outputs) != 3 || outputs[2].Id != "res-guid_3" {
+		t.Errorf("outputs=%+v", outputs)
+	}
+}
+
+func TestRemoveExpiredTasks(t *testing.T) {
+	expired := time.Now().Add(-TASK_EXPIRE_DURATION - time.Minute)
+	doneExpired := &Task{Id: "task-done-expired", Status: TASK_STATUS_SUCCESS, CreateTime: expired}
+	runningExpired := &Task{Id: "task-running-expired", Status: TASK_STATUS_RUNNING, CreateTime: expired}
+	done := &Task{Id: "task-done", Status: TASK_STATUS_FAILED, CreateTime: time.Now()}
+
+	tasksMutex.Lock()
+	for _, task := range []*Task{doneExpired, runningExpired, done} {
+		tasks[task.Id] = task
+	}
+	removeExpiredTasks()
+	tasksMutex.Unlock()
+	defer func() {
+		tasksMutex.Lock()
+		delete(tasks, runningExpired.Id)
+		delete(tasks, done.Id)
+		tasksMutex.Unlock()
+	}()
+
+	if _, err := GetTaskById(doneExpired.Id); err == nil {
+		t.Errorf("expired task is not removed")
+	}
+	for _, task := range []*Task{runningExpired, done} {
+		if _, err := GetTaskById(task.Id); err != nil {
+			t.Errorf("task %v is removed", task.Id)
+		}
+	}
+}
@@ -0,0 +1,582 @@
+package plugins
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// TRACEPARENT_HEADER carries the trace context of the platform in the format of W3C trace context.
+	TRACEPARENT_HEADER = "traceparent"
+
+	// the kinds and status codes of spans, same as the values of OTLP.
+	SPAN_KIND_INTERNAL = 1
+	SPAN_KIND_SERVER   = 2
+	SPAN_KIND_CLIENT   = 3
+
+	SPAN_STATUS_OK    = 1
+	SPAN_STATUS_ERROR = 2
+
+	TRACING_EXPORTER_OTLP = "otlp"
+	TRACING_EXPORTER_FILE = "file"
+
+	TRACING_SERVICE_NAME = "wecube-plugins-qcloud"
+
+	// ended spans are exported in batches every interval or once the batch is full, the spans ended
+	// when the queue is full are dropped.
+	SPAN_EXPORT_INTERVAL   = 5 * time.Second
+	SPAN_EXPORT_BATCH_SIZE = 512
+	SPAN_QUEUE_SIZE        = 4096
+	SPAN_EXPORT_TIMEOUT    = 10 * time.Second
+)
+
+type TraceId [16]byte
+
+type SpanId [8]byte
+
+func (id TraceId) String() string {
+	return hex.EncodeToString(id[:])
+}
+
+func (id SpanId) String() string {
+	return hex.EncodeToString(id[:])
+}
+
+func (id TraceId) IsValid() bool {
+	return id != TraceId{}
+}
+
+func (id SpanId) IsValid() bool {
+	return id != SpanId{}
+}
+
+// SpanContext identifies a span across processes, Sampled tells whether the spans of the trace are exported.
+type SpanContext struct {
+	TraceId TraceId
+	SpanId  SpanId
+	Sampled bool
+}
+
+func (spanContext SpanContext) IsValid() bool {
+	return spanContext.TraceId.IsValid() && spanContext.SpanId.IsValid()
+}
+
+// TraceParent returns the value of the traceparent header of the span context.
+func (spanContext SpanContext) TraceParent() string {
+	flags := "00"
+	if spanContext.Sampled {
+		flags = "01"
+	}
+	return "00-" + spanContext.TraceId.String() + "-" + spanContext.SpanId.String() + "-" + flags
+}
+
+// ParseTraceParent parses the traceparent header, "{version}-{trace id}-{parent id}-{flags}" in hex.
+func ParseTraceParent(traceParent string) (SpanContext, error) {
+	spanContext := SpanContext{}
+	parts := strings.Split(strings.TrimSpace(traceParent), "-")
+	if len(parts) < 4 || len(parts[0]) != 2 || parts[0] == "ff" || (parts[0] == "00" && len(parts) != 4) {
+		return spanContext, fmt.Errorf("traceparent %q is invalid", traceParent)
+	}
+	traceId, traceErr := hex.DecodeString(parts[1])
+	spanId, spanErr := hex.DecodeString(parts[2])
+	flags, flagsErr := hex.DecodeString(parts[3])
+	if traceErr != nil || spanErr != nil || flagsErr != nil || len(traceId) != 16 || len(spanId) != 8 || len(flags) != 1 ||
+		parts[1] != strings.ToLower(parts[1]) || parts[2] != strings.ToLower(parts[2]) {
+		return spanContext, fmt.Errorf("traceparent %q is invalid", traceParent)
+	}
+	copy(spanContext.TraceId[:], traceId)
+	copy(spanContext.SpanId[:], spanId)
+	spanContext.Sampled = flags[0]&0x01 == 0x01
+	if !spanContext.IsValid() {
+		return spanContext, fmt.Errorf("traceparent %q has zero ids", traceParent)
+	}
+	return spanContext, nil
+}
+
+type remoteSpanContextKey struct{}
+
+type spanKey struct{}
+
+// ContextWithTraceParent returns ctx with the span context of the traceparent header as the parent of the spans
+// started with it, the header is ignored if it is empty or invalid.
+func ContextWithTraceParent(ctx context.Context, traceParent string) context.Context {
+	if traceParent == "" {
+		return ctx
+	}
+	spanContext, err := ParseTraceParent(traceParent)
+	if err != nil {
+		logrus.Warnf("ignore trace context, err=%v", err)
+		return ctx
+	}
+	return context.WithValue(ctx, remoteSpanContextKey{}, spanContext)
+}
+
+// SpanFromContext returns the span started by StartSpan with ctx or its parents, nil if there is none.
+func SpanFromContext(ctx context.Context) *Span {
+	span, _ := ctx.Value(spanKey{}).(*Span)
+	return span
+}
+
+// ContextWithSpan returns ctx with the span as the parent of the spans started with it, such as a background
+// context which keeps the span of the request which started it.
+func ContextWithSpan(ctx context.Context, span *Span) context.Context {
+	if span == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, spanKey{}, span)
+}
+
+// Span is a unit of work of a trace, it is exported once End is called if its trace is sampled.
+type Span struct {
+	SpanContext
+	ParentSpanId SpanId
+	Name         string
+	Kind         int
+	StartTime    time.Time
+
+	mutex         sync.Mutex
+	endTime       time.Time
+	attributes    map[string]interface{}
+	statusCode    int
+	statusMessage string
+}
+
+// StartSpan starts a span which is the child of the span of ctx, or of the trace context of the platform,
+// or the root of a new trace. The returned ctx carries the new span.
+func StartSpan(ctx context.Context, name string, kind int) (context.Context, *Span) {
+	span := &Span{Name: name, Kind: kind, StartTime: time.Now(), attributes: make(map[string]interface{})}
+	if parent := SpanFromContext(ctx); parent != nil {
+		span.TraceId, span.ParentSpanId, span.Sampled = parent.TraceId, parent.SpanId, parent.Sampled
+	} else if remote, ok := ctx.Value(remoteSpanContextKey{}).(SpanContext); ok {
+		span.TraceId, span.ParentSpanId, span.Sampled = remote.TraceId, remote.SpanId, remote.Sampled
+	} else {
+		rand.Read(span.TraceId[:])
+		span.Sampled = true
+	}
+	rand.Read(span.SpanId[:])
+	return context.WithValue(ctx, spanKey{}, span), span
+}
+
+// SetAttribute sets the attribute of the span, values are strings, bools, integers or floats.
+func (span *Span) SetAttribute(key string, value interface{}) {
+	span.mutex.Lock()
+	defer span.mutex.Unlock()
+
+	span.attributes[key] = value
+}
+
+func (span *Span) GetAttribute(key string) interface{} {
+	span.mutex.Lock()
+	defer span.mutex.Unlock()
+
+	return span.attributes[key]
+}
+
+// End ends the span with the status of err, only the first call takes effect.
+func (span *Span) End(err error) {
+	span.mutex.Lock()
+	if !span.endTime.IsZero() {
+		span.mutex.Unlock()
+		return
+	}
+	span.endTime = time.Now()
+	span.statusCode = SPAN_STATUS_OK
+	if err != nil {
+		span.statusCode = SPAN_STATUS_ERROR
+		span.statusMessage = MaskSecretsInText(err.Error())
+		if pluginErr := ClassifyError(err); pluginErr != nil {
+			span.attributes["error.category"] = pluginErr.Category
+			span.attributes["error.code"] = pluginErr.Code
+		}
+	}
+	span.mutex.Unlock()
+
+	if processor := getSpanProcessor(); processor != nil && span.Sampled {
+		processor.onEnd(span)
+	}
+}
+
+// SpanExporter sends the ended spans to the tracing backend.
+type SpanExporter interface {
+	Export(ctx context.Context, spans []*Span) error
+}
+
+var (
+	spanProcessorMutex sync.RWMutex
+	spanProcessor      *batchSpanProcessor
+)
+
+// SetSpanExporter exports the spans ended afterwards by the exporter in batches, nil stops exporting.
+// The spans of the previous exporter are flushed.
+func SetSpanExporter(exporter SpanExporter) {
+	var processor *batchSpanProcessor
+	if exporter != nil {
+		processor = newBatchSpanProcessor(exporter)
+	}
+	spanProcessorMutex.Lock()
+	previous := spanProcessor
+	spanProcessor = processor
+	spanProcessorMutex.Unlock()
+
+	if previous != nil {
+		previous.shutdown(SPAN_EXPORT_TIMEOUT)
+	}
+}
+
+// FlushSpans exports the spans which are ended but not exported yet, it waits for up to the timeout.
+func FlushSpans(timeout time.Duration) {
+	if processor := getSpanProcessor(); processor != nil {
+		processor.flush(timeout)
+	}
+}
+
+func getSpanProcessor() *batchSpanProcessor {
+	spanProcessorMutex.RLock()
+	defer spanProcessorMutex.RUnlock()
+
+	return spanProcessor
+}
+
+type batchSpanProcessor struct {
+	exporter SpanExporter
+	queue    chan *Span
+	flushes  chan chan struct{}
+	stop     chan struct{}
+	done     chan struct{}
+	stopOnce sync.Once
+}
+
+func newBatchSpanProcessor(exporter SpanExporter) *batchSpanProcessor {
+	processor := &batchSpanProcessor{
+		exporter: exporter,
+		queue:    make(chan *Span, SPAN_QUEUE_SIZE),
+		flushes:  make(chan chan struct{}),
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+	go processor.run()
+	return processor
+}
+
+func (processor *batchSpanProcessor) onEnd(span *Span) {
+	select {
+	case processor.queue <- span:
+	default:
+		logrus.Warnf("span queue is full, drop span %v(%v)", span.Name, span.SpanId)
+	}
+}
+
+func (processor *batchSpanProcessor) run() {
+	defer close(processor.done)
+	ticker := time.NewTicker(SPAN_EXPORT_INTERVAL)
+	defer ticker.Stop()
+
+	batch := []*Span{}
+	for {
+		select {
+		case span := <-processor.queue:
+			if batch = append(batch, span); len(batch) >= SPAN_EXPORT_BATCH_SIZE {
+				batch = processor.export(batch)
+			}
+		case <-ticker.C:
+			batch = processor.export(batch)
+		case flushed := <-processor.flushes:
+			batch = processor.export(processor.drain(batch))
+			close(flushed)
+		case <-processor.stop:
+			processor.export(processor.drain(batch))
+			return
+		}
+	}
+}
+
+func (processor *batchSpanProcessor) drain(batch []*Span) []*Span {
+	for {
+		select {
+		case span := <-processor.queue:
+			batch = append(batch, span)
+		default:
+			return batch
+		}
+	}
+}
+
+func (processor *batchSpanProcessor) export(batch []*Span) []*Span {
+	if len(batch) == 0 {
+		return batch
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), SPAN_EXPORT_TIMEOUT)
+	defer cancel()
+	if err := processor.exporter.Export(ctx, batch); err != nil {
+		logrus.Errorf("export %v spans meet error=%v", len(batch), err)
+	}
+	return batch[:0]
+}
+
+func (processor *batchSpanProcessor) flush(timeout time.Duration) {
+	flushed := make(chan struct{})
+	select {
+	case processor.flushes <- flushed:
+	case <-processor.done:
+		return
+	case <-time.After(timeout):
+		logrus.Warnf("flush spans timeout after %v", timeout)
+		return
+	}
+	if !waitDone(flushed, timeout) {
+		logrus.Warnf("flush spans timeout after %v", timeout)
+	}
+}
+
+func (processor *batchSpanProcessor) shutdown(timeout time.Duration) {
+	processor.stopOnce.Do(func() { close(processor.stop) })
+	if !waitDone(processor.done, timeout) {
+		logrus.Warnf("shutdown span exporter timeout after %v", timeout)
+	}
+}
+
+// OtlpExporter sends spans to Endpoint by OTLP over http in json, such as "http://otel-collector:4318",
+// "/v1/traces" is appended unless the endpoint has a path.
+type OtlpExporter struct {
+	Endpoint string
+	Headers  map[string]string
+	Client   *http.Client
+}
+
+func (exporter *OtlpExporter) getUrl() string {
+	endpoint := strings.TrimSuffix(exporter.Endpoint, "/")
+	if i := strings.Index(endpoint, "://"); i >= 0 && strings.Contains(endpoint[i+3:], "/") {
+		return endpoint
+	}
+	return endpoint + "/v1/traces"
+}
+
+func (exporter *OtlpExporter) Export(ctx context.Context, spans []*Span) error {
+	body, err := json.Marshal(newOtlpTraces(spans))
+	if err != nil {
+		return err
+	}
+	request, err := http.NewRequest(http.MethodPost, exporter.getUrl(), bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	request.Header.Set("Content-Type", "application/json")
+	for key, value := range exporter.Headers {
+		request.Header.Set(key, value)
+	}
+	client := exporter.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	response, err := client.Do(request.WithContext(ctx))
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	respBody, _ := ioutil.ReadAll(response.Body)
+	if response.StatusCode/100 != 2 {
+		return fmt.Errorf("otlp endpoint %v returns %v: %s", exporter.Endpoint, response.Status, respBody)
+	}
+	return nil
+}
+
+// FileSpanExporter appends spans to File in the json of OTLP, one export request per line, which can be read
+// by the otlpjsonfile receiver of the collector for offline debugging.
+type FileSpanExporter struct {
+	File string
+
+	mutex sync.Mutex
+}
+
+func (exporter *FileSpanExporter) Export(ctx context.Context, spans []*Span) error {
+	line, err := json.Marshal(newOtlpTraces(spans))
+	if err != nil {
+		return err
+	}
+	exporter.mutex.Lock()
+	defer exporter.mutex.Unlock()
+
+	if err = os.MkdirAll(filepath.Dir(exporter.File), 0700); err != nil {
+		return err
+	}
+	file, err := os.OpenFile(exporter.File, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return err
+	}
+	_, err = file.Write(append(line, '\n'))
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// the json of the ExportTraceServiceRequest of OTLP.
+type otlpTraces struct {
+	ResourceSpans []otlpResourceSpans `json:"resourceSpans"`
+}
+
+type otlpResourceSpans struct {
+	Resource   otlpResource     `json:"resource"`
+	ScopeSpans []otlpScopeSpans `json:"scopeSpans"`
+}
+
+type otlpResource struct {
+	Attributes []otlpAttribute `json:"attributes"`
+}
+
+type otlpScopeSpans struct {
+	Scope otlpScope  `json:"scope"`
+	Spans []otlpSpan `json:"spans"`
+}
+
+type otlpScope struct {
+	Name string `json:"name"`
+}
+
+type otlpSpan struct {
+	TraceId           string          `json:"traceId"`
+	SpanId            string          `json:"spanId"`
+	ParentSpanId      string          `json:"parentSpanId,omitempty"`
+	Name              string          `json:"name"`
+	Kind              int             `json:"kind"`
+	StartTimeUnixNano string          `json:"startTimeUnixNano"`
+	EndTimeUnixNano   string          `json:"endTimeUnixNano"`
+	Attributes        []otlpAttribute `json:"attributes,omitempty"`
+	Status            otlpStatus      `json:"status"`
+}
+
+type otlpStatus struct {
+	Code    int    `json:"code"`
+	Message string `json:"message,omitempty"`
+}
+
+type otlpAttribute struct {
+	Key   string                 `json:"key"`
+	Value map[string]interface{} `json:"value"`
+}
+
+func newOtlpTraces(spans []*Span) *otlpTraces {
+	otlpSpans := make([]otlpSpan, 0, len(spans))
+	for _, span := range spans {
+		otlpSpans = append(otlpSpans, span.toOtlp())
+	}
+	return &otlpTraces{ResourceSpans: []otlpResourceSpans{{
+		Resource: otlpResource{Attributes: []otlpAttribute{newOtlpAttribute("service.name", TRACING_SERVICE_NAME)}},
+		ScopeSpans: []otlpScopeSpans{{
+			Scope: otlpScope{Name: TRACING_SERVICE_NAME},
+			Spans: otlpSpans,
+		}},
+	}}}
+}
+
+func (span *Span) toOtlp() otlpSpan {
+	span.mutex.Lock()
+	defer span.mutex.Unlock()
+
+	otlp := otlpSpan{
+		TraceId:           span.TraceId.String(),
+		SpanId:            span.SpanId.String(),
+		Name:              span.Name,
+		Kind:              span.Kind,
+		StartTimeUnixNano: strconv.FormatInt(span.StartTime.UnixNano(), 10),
+		EndTimeUnixNano:   strconv.FormatInt(span.endTime.UnixNano(), 10),
+		Status:            otlpStatus{Code: span.statusCode, Message: span.statusMessage},
+	}
+	if span.ParentSpanId.IsValid() {
+		otlp.ParentSpanId = span.ParentSpanId.String()
+	}
+	for key, value := range span.attributes {
+		otlp.Attributes = append(otlp.Attributes, newOtlpAttribute(key, value))
+	}
+	return otlp
+}
+
+// newOtlpAttribute returns the attribute in the json of OTLP, whose 64 bit integers are strings.
+func newOtlpAttribute(key string, value interface{}) otlpAttribute {
+	attribute := otlpAttribute{Key: key}
+	switch v := value.(type) {
+	case string:
+		attribute.Value = map[string]interface{}{"stringValue": v}
+	case bool:
+		attribute.Value = map[string]interface{}{"boolValue": v}
+	case int:
+		attribute.Value = map[string]interface{}{"intValue": strconv.Itoa(v)}
+	case int64:
+		attribute.Value = map[string]interface{}{"intValue": strconv.FormatInt(v, 10)}
+	case float64:
+		attribute.Value = map[string]interface{}{"doubleValue": v}
+	default:
+		attribute.Value = map[string]interface{}{"stringValue": fmt.Sprint(v)}
+	}
+	return attribute
+}
+
+// tracingTransport records a client span for every cloud API request which is sent, the parent is the span
+// of the context bound to the client factory. The RequestId of the response is recorded for the input.
+type tracingTransport struct {
+	next http.RoundTripper
+}
+
+func (transport *tracingTransport) RoundTrip(request *http.Request) (*http.Response, error) {
+	action := getApiAction(request)
+	if action == "" {
+		return transport.next.RoundTrip(request)
+	}
+
+	service, region, _ := getRateLimitKey(request)
+	_, span := StartSpan(request.Context(), service+"."+action, SPAN_KIND_CLIENT)
+	span.SetAttribute("cloud.service", service)
+	span.SetAttribute("cloud.action", action)
+	span.SetAttribute("cloud.region", region)
+
+	response, err := transport.next.RoundTrip(request)
+	if err != nil {
+		span.End(err)
+		return response, err
+	}
+	span.SetAttribute("http.status_code", response.StatusCode)
+	if requestId := getApiRequestId(response); requestId != "" {
+		span.SetAttribute("cloud.request_id", requestId)
+		recordRequestId(request.Context(), service, action, region, requestId)
+	}
+	if errorCode := getApiErrorCode(response); errorCode != "" {
+		span.SetAttribute("cloud.error_code", errorCode)
+		span.End(fmt.Errorf("cloud api %v.%v meet error code %v", service, action, errorCode))
+		return response, nil
+	}
+	span.End(nil)
+	return response, nil
+}
+
+// getApiRequestId returns the RequestId in the body of the cloud API response, the body is kept for the caller.
+func getApiRequestId(response *http.Response) string {
+	body, err := ioutil.ReadAll(response.Body)
+	response.Body.Close()
+	response.Body = ioutil.NopCloser(bytes.NewReader(body))
+	if err != nil {
+		return ""
+	}
+	apiResponse := struct {
+		Response *struct {
+			RequestId string `json:"RequestId"`
+		} `json:"Response"`
+	}{}
+	if json.Unmarshal(body, &apiResponse) != nil || apiResponse.Response == nil {
+		return ""
+	}
+	return apiResponse.Response.RequestId
+}
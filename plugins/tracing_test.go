@@ -0,0 +1,178 @@
+package plugins
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	cvm "github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/cvm/v20170312"
+)
+
+type recordingSpanExporter struct {
+	mutex sync.Mutex
+	spans []*Span
+}
+
+func (exporter *recordingSpanExporter) Export(ctx context.Context, spans []*Span) error {
+	exporter.mutex.Lock()
+	defer exporter.mutex.Unlock()
+
+	exporter.spans = append(exporter.spans, spans...)
+	return nil
+}
+
+func (exporter *recordingSpanExporter) getSpans(name string) []*Span {
+	exporter.mutex.Lock()
+	defer exporter.mutex.Unlock()
+
+	spans := []*Span{}
+	for _, span := range exporter.spans {
+		if span.Name == name {
+			spans = append(spans, span)
+		}
+	}
+	return spans
+}
+
+func TestParseTraceParent(t *testing.T) {
+	traceParent := "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+	spanContext, err := ParseTraceParent(traceParent)
+	if err != nil {
+		t.Fatalf("parse traceparent meet error=%v", err)
+	}
+	if spanContext.TraceId.String() != "4bf92f3577b34da6a3ce929d0e0e4736" || spanContext.SpanId.String() != "00f067aa0ba902b7" || !spanContext.Sampled {
+		t.Errorf("span context=%+v", spanContext)
+	}
+	if spanContext.TraceParent() != traceParent {
+		t.Errorf("traceparent=%v", spanContext.TraceParent())
+	}
+
+	for _, invalid := range []string{"", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7", "00-00000000000000000000000000000000-00f067aa0ba902b7-01",
+		"00-4BF92F3577B34DA6A3CE929D0E0E4736-00f067aa0ba902b7-01", "ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"} {
+		if _, err := ParseTraceParent(invalid); err == nil {
+			t.Errorf("traceparent %q is parsed", invalid)
+		}
+	}
+}
+
+func TestSpansOfInputsAndCloudApis(t *testing.T) {
+	exporter := &recordingSpanExporter{}
+	SetSpanExporter(exporter)
+	defer SetSpanExporter(nil)
+
+	server, _ := newScriptedServer(func(action, body string, times int) string {
+		return okResponse
+	})
+	defer server.Close()
+	factory := newRetryTestFactory(server, &RetryPolicy{})
+
+	ctx := ContextWithTraceParent(context.Background(), "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	ctx, span := StartSpan(ctx, "test.describe", SPAN_KIND_SERVER)
+	err := runInputs(ctx, 2, nil, func(ctx context.Context, i int) error {
+		client, _ := factory.WithContext(ctx).NewCvmClient("ap-tracing", "fake-secret-id", "fake-secret-key")
+		_, err := client.DescribeInstances(cvm.NewDescribeInstancesRequest())
+		return err
+	})
+	span.End(err)
+	FlushSpans(time.Second)
+
+	if span.TraceId.String() != "4bf92f3577b34da6a3ce929d0e0e4736" || span.ParentSpanId.String() != "00f067aa0ba902b7" {
+		t.Errorf("span of request is not the child of the platform, trace id=%v, parent=%v", span.TraceId, span.ParentSpanId)
+	}
+	inputSpans := exporter.getSpans("input")
+	apiSpans := exporter.getSpans(QCLOUD_SERVICE_CVM + ".DescribeInstances")
+	if len(inputSpans) != 2 || len(apiSpans) != 2 || len(exporter.getSpans("test.describe")) != 1 {
+		t.Fatalf("spans=%v", exporter.spans)
+	}
+	inputSpanIds := map[SpanId]bool{}
+	for _, inputSpan := range inputSpans {
+		if inputSpan.ParentSpanId != span.SpanId || inputSpan.TraceId != span.TraceId {
+			t.Errorf("input span is not the child of the request")
+		}
+		inputSpanIds[inputSpan.SpanId] = true
+	}
+	for _, apiSpan := range apiSpans {
+		if !inputSpanIds[apiSpan.ParentSpanId] || apiSpan.Kind != SPAN_KIND_CLIENT {
+			t.Errorf("api span is not the client span of the input")
+		}
+		if apiSpan.GetAttribute("cloud.request_id") != "fake-request-id" || apiSpan.GetAttribute("cloud.region") != "ap-tracing" ||
+			apiSpan.GetAttribute("cloud.service") != QCLOUD_SERVICE_CVM {
+			t.Errorf("attributes of api span=%v", apiSpan.attributes)
+		}
+	}
+}
+
+func TestSpanOfWait(t *testing.T) {
+	exporter := &recordingSpanExporter{}
+	SetSpanExporter(exporter)
+	defer SetSpanExporter(nil)
+
+	server, _ := newScriptedServer(func(action, body string, times int) string {
+		return okResponse
+	})
+	defer server.Close()
+	factory := newRetryTestFactory(server, &RetryPolicy{})
+
+	ctx, span := StartSpan(context.Background(), "input", SPAN_KIND_INTERNAL)
+	client, _ := factory.WithContext(ctx).NewCvmClient("ap-tracing", "fake-secret-id", "fake-secret-key")
+	waiter := &Waiter{Resource: "tracing", Id: "res-1", Timeout: time.Millisecond, Interval: time.Millisecond, MaxInterval: time.Millisecond, Backoff: 1}
+	err := waiter.Wait(ctx, func() (string, bool, error) {
+		_, err := client.DescribeInstances(cvm.NewDescribeInstancesRequest())
+		return "PENDING", false, err
+	})
+	if err == nil {
+		t.Fatalf("wait does not time out")
+	}
+	span.End(nil)
+	FlushSpans(time.Second)
+
+	spans := exporter.getSpans("wait tracing")
+	if len(spans) != 1 || spans[0].statusCode != SPAN_STATUS_ERROR || spans[0].GetAttribute("wait.result") != WAIT_RESULT_TIMEOUT || spans[0].ParentSpanId != span.SpanId {
+		t.Errorf("spans of wait=%v", exporter.spans)
+	}
+	// the cloud APIs of the condition are traced by the ctx of their client.
+	apiSpans := exporter.getSpans(QCLOUD_SERVICE_CVM + ".DescribeInstances")
+	if len(apiSpans) == 0 {
+		t.Fatalf("spans=%v", exporter.spans)
+	}
+	for _, apiSpan := range apiSpans {
+		if apiSpan.ParentSpanId != span.SpanId {
+			t.Errorf("api span is not the child of the input")
+		}
+	}
+}
+
+func TestFileSpanExporter(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tracing")
+	if err != nil {
+		t.Fatalf("create temp dir meet error=%v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	exporter := &FileSpanExporter{File: filepath.Join(dir, "spans", "spans.json")}
+	_, span := StartSpan(context.Background(), "test", SPAN_KIND_INTERNAL)
+	span.SetAttribute("input.index", 1)
+	span.End(nil)
+	if err = exporter.Export(context.Background(), []*Span{span}); err != nil {
+		t.Fatalf("export spans meet error=%v", err)
+	}
+
+	data, err := ioutil.ReadFile(exporter.File)
+	if err != nil {
+		t.Fatalf("read spans meet error=%v", err)
+	}
+	traces := otlpTraces{}
+	if err = json.Unmarshal(data, &traces); err != nil {
+		t.Fatalf("unmarshal spans meet error=%v", err)
+	}
+	spans := traces.ResourceSpans[0].ScopeSpans[0].Spans
+	if len(spans) != 1 || spans[0].TraceId != span.TraceId.String() || spans[0].Status.Code != SPAN_STATUS_OK ||
+		spans[0].Attributes[0].Value["intValue"] != "1" {
+		t.Errorf("spans=%s", data)
+	}
+}
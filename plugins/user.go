@@ -1,11 +1,10 @@
 package plugins
 
 import (
+	"context"
 	"fmt"
 	"github.com/sirupsen/logrus"
 	cam "github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/cam/v20190116"
-	"github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/common"
-	"github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/common/profile"
 	"github.com/WeBankPartners/wecube-plugins-qcloud/plugins/utils"
 )
 
@@ -21,6 +20,10 @@ func (plugin *UserPlugin) GetActionByName(actionName string) (Action, error) {
 	return action, nil
 }
 
+func (plugin *UserPlugin) GetActions() map[string]Action {
+	return UserActions
+}
+
 var UserActions = make(map[string]Action)
 
 func init() {
@@ -34,15 +37,15 @@ type UserInputs struct {
 
 type UserInput struct {
 	CallBackParameter
-	Guid             string `json:"guid,omitempty"`
-	UserName         string `json:"user_name,omitempty"`
+	Guid             string `json:"guid,omitempty" register:"required"`
+	UserName         string `json:"user_name,omitempty" register:"required"`
 	Password         string `json:"password,omitempty"`
-	ProviderParams   string `json:"provider_params,omitempty"`
-	Location         string `json:"location,omitempty"`
-	APISecret        string `json:"api_secret,omitempty"`
-	BucketUrl        string `json:"bucket_url,omitempty"`
-	BucketPermission string `json:"bucket_permission,omitempty"`
-	Seed             string `json:"seed,omitempty"`
+	ProviderParams   string `json:"provider_params,omitempty" register:"optional"`
+	Location         string `json:"location,omitempty" register:"required"`
+	APISecret        string `json:"api_secret,omitempty" register:"required,system_variable=QCLOUD_API_SECRET"`
+	BucketUrl        string `json:"bucket_url,omitempty" register:"optional,actions=add"`
+	BucketPermission string `json:"bucket_permission,omitempty" register:"optional,sensitive,system_variable=QCLOUD_BUCKET_READ,actions=add"`
+	Seed             string `json:"seed,omitempty" register:"required,system_variable=ENCRYPT_SEED,actions=add"`
 }
 
 type UserOutputs struct {
@@ -53,12 +56,12 @@ type UserOutput struct {
 	CallBackParameter
 	Result
 	RequestId    string `json:"request_id,omitempty"`
-	Guid         string `json:"guid,omitempty"`
-	SecretId     string `json:"secret_id,omitempty"`
-	SecretKey    string `json:"secret_key,omitempty"`
+	Guid         string `json:"guid,omitempty" register:"optional"`
+	SecretId     string `json:"secret_id,omitempty" register:"optional,actions=add"`
+	SecretKey    string `json:"secret_key,omitempty" register:"optional,actions=add"`
 	Password     string `json:"password,omitempty"`
 	Uid          string `json:"uid,omitempty"`
-	Uin          string `json:"uin,omitempty"`
+	Uin          string `json:"uin,omitempty" register:"optional,actions=add"`
 }
 
 type UserObj struct {
@@ -73,7 +76,7 @@ type UserAddAction struct {
 type UserDeleteAction struct {
 }
 
-func (action *UserAddAction) ReadParam(param interface{}) (interface{}, error) {
+func (action *UserAddAction) ReadParam(ctx context.Context, param interface{}) (interface{}, error) {
 	var inputs UserInputs
 	err := UnmarshalJson(param, &inputs)
 	if err != nil {
@@ -82,7 +85,7 @@ func (action *UserAddAction) ReadParam(param interface{}) (interface{}, error) {
 	return inputs, nil
 }
 
-func (action *UserDeleteAction) ReadParam(param interface{}) (interface{}, error) {
+func (action *UserDeleteAction) ReadParam(ctx context.Context, param interface{}) (interface{}, error) {
 	var inputs UserInputs
 	err := UnmarshalJson(param, &inputs)
 	if err != nil {
@@ -91,37 +94,32 @@ func (action *UserDeleteAction) ReadParam(param interface{}) (interface{}, error
 	return inputs, nil
 }
 
-func createUserClient(region, secretId, secretKey string) (client *cam.Client, err error) {
-	credential := common.NewCredential(secretId, secretKey)
-	clientProfile := profile.NewClientProfile()
-	clientProfile.HttpProfile.Endpoint = "cam.tencentcloudapi.com"
-	return cam.NewClient(credential, region, clientProfile)
+func createUserClient(ctx context.Context, params *ProviderParams) (client *cam.Client, err error) {
+	return GetClientFactory().WithContext(ctx).WithProviderParams(params).NewCamClient(params.Region, params.SecretID, params.SecretKey)
 }
 
-func (action *UserAddAction) addUser(userInput *UserInput) (output UserOutput, err error) {
+func (action *UserAddAction) addUser(ctx context.Context, userInput *UserInput) (output UserOutput, err error) {
 	output.Guid = userInput.Guid
 	output.Result.Code = RESULT_CODE_SUCCESS
 	output.CallBackParameter.Parameter = userInput.CallBackParameter.Parameter
 
-	if userInput.Location != "" && userInput.APISecret != "" {
-		userInput.ProviderParams = fmt.Sprintf("%s;%s", userInput.Location, userInput.APISecret)
-	}
-	paramsMap, err := GetMapFromProviderParams(userInput.ProviderParams)
+	params, err := NewProviderParams(userInput.ProviderParams, userInput.Location, userInput.APISecret)
 	defer func() {
 		if err != nil {
-			output.Result.Code = RESULT_CODE_ERROR
-			output.Result.Message = err.Error()
+			output.Result.SetError(err)
 		}else{
 			if userInput.BucketUrl != "" {
-				err = SetBucketAcl(paramsMap["Region"], paramsMap["SecretID"], paramsMap["SecretKey"], userInput.BucketUrl, output.Uin, userInput.BucketPermission)
+				err = SetBucketAcl(ctx, params, userInput.BucketUrl, output.Uin, userInput.BucketPermission)
 				if err != nil {
-					output.Result.Code = RESULT_CODE_ERROR
-					output.Result.Message = err.Error()
+					output.Result.SetError(err)
 				}
 			}
 		}
 	}()
-	client,_ := createUserClient(paramsMap["Region"], paramsMap["SecretID"], paramsMap["SecretKey"])
+	if err != nil {
+		return output, err
+	}
+	client,_ := createUserClient(ctx, params)
 	isExist,checkResult := isExistUser(client, userInput.UserName)
 	if isExist {
 		output.Uin = checkResult.Uin
@@ -144,7 +142,7 @@ func (action *UserAddAction) addUser(userInput *UserInput) (output UserOutput, e
 	output.SecretId = *addUserResponse.Response.SecretId
 	output.SecretKey,err = utils.AesEnPassword(userInput.Guid, userInput.Seed, *addUserResponse.Response.SecretKey, utils.DEFALT_CIPHER)
 	if err != nil {
-		logrus.Errorf("AesEnPassword meet error(%v)", err)
+		Logger(ctx).Errorf("AesEnPassword meet error(%v)", err)
 		return output, err
 	}
 	output.Uid = fmt.Sprintf("%d", *addUserResponse.Response.Uid)
@@ -153,39 +151,37 @@ func (action *UserAddAction) addUser(userInput *UserInput) (output UserOutput, e
 	return output,nil
 }
 
-func (action *UserAddAction) Do(input interface{}) (interface{}, error) {
+func (action *UserAddAction) Do(ctx context.Context, input interface{}) (interface{}, error) {
 	users, _ := input.(UserInputs)
-	outputs := UserOutputs{}
-	var finalErr error
-
-	for _, user := range users.Inputs {
-		userOutput, err := action.addUser(&user)
-		if err != nil {
-			finalErr = err
-		}
-		outputs.Outputs = append(outputs.Outputs, userOutput)
-	}
-
-	logrus.Infof("all users = %v are created", users)
+	outputs := UserOutputs{Outputs: make([]UserOutput, len(users.Inputs))}
+	finalErr := runInputs(ctx, len(users.Inputs), func(i int) []string {
+		return []string{users.Inputs[i].Guid, users.Inputs[i].UserName}
+	}, func(ctx context.Context, i int) error {
+		user := users.Inputs[i]
+		userOutput, err := action.addUser(ctx, &user)
+		outputs.Outputs[i] = userOutput
+		return err
+	})
+
+	Logger(ctx).Infof("all users = %s are created", Sanitize(users))
 	return &outputs, finalErr
 }
 
-func (action *UserDeleteAction) deleteUser(userInput *UserInput) (output UserOutput, err error) {
+func (action *UserDeleteAction) deleteUser(ctx context.Context, userInput *UserInput) (output UserOutput, err error) {
 	output.Guid = userInput.Guid
 	output.Result.Code = RESULT_CODE_SUCCESS
 	output.CallBackParameter.Parameter = userInput.CallBackParameter.Parameter
 
-	if userInput.Location != "" && userInput.APISecret != "" {
-		userInput.ProviderParams = fmt.Sprintf("%s;%s", userInput.Location, userInput.APISecret)
-	}
-	paramsMap, err := GetMapFromProviderParams(userInput.ProviderParams)
+	params, err := NewProviderParams(userInput.ProviderParams, userInput.Location, userInput.APISecret)
 	defer func() {
 		if err != nil {
-			output.Result.Code = RESULT_CODE_ERROR
-			output.Result.Message = err.Error()
+			output.Result.SetError(err)
 		}
 	}()
-	client,_ := createUserClient(paramsMap["Region"], paramsMap["SecretID"], paramsMap["SecretKey"])
+	if err != nil {
+		return output, err
+	}
+	client,_ := createUserClient(ctx, params)
 	isExist,_ := isExistUser(client, userInput.UserName)
 	if !isExist {
 		return output,nil
@@ -202,20 +198,19 @@ func (action *UserDeleteAction) deleteUser(userInput *UserInput) (output UserOut
 	return output,nil
 }
 
-func (action *UserDeleteAction) Do(input interface{}) (interface{}, error) {
+func (action *UserDeleteAction) Do(ctx context.Context, input interface{}) (interface{}, error) {
 	users, _ := input.(UserInputs)
-	outputs := UserOutputs{}
-	var finalErr error
-
-	for _, user := range users.Inputs {
-		userOutput, err := action.deleteUser(&user)
-		if err != nil {
-			finalErr = err
-		}
-		outputs.Outputs = append(outputs.Outputs, userOutput)
-	}
-
-	logrus.Infof("all users = %v are deleted", users)
+	outputs := UserOutputs{Outputs: make([]UserOutput, len(users.Inputs))}
+	finalErr := runInputs(ctx, len(users.Inputs), func(i int) []string {
+		return []string{users.Inputs[i].Guid, users.Inputs[i].UserName}
+	}, func(ctx context.Context, i int) error {
+		user := users.Inputs[i]
+		userOutput, err := action.deleteUser(ctx, &user)
+		outputs.Outputs[i] = userOutput
+		return err
+	})
+
+	Logger(ctx).Infof("all users = %s are deleted", Sanitize(users))
 	return &outputs, finalErr
 }
 
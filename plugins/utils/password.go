@@ -70,3 +70,24 @@ func AesDePassword(guid, seed, password string) (string, error) {
 	}
 	return dePassword, nil
 }
+
+// AesEnPasswordByKey encrypts the password with a key, such as the master key of the plugin,
+// instead of the guid and seed of its input.
+func AesEnPasswordByKey(key, password, cipher string) (string, error) {
+	return AesEnPassword(key, "", password, cipher)
+}
+
+// AesDePasswordByKey decrypts the password encrypted by AesEnPasswordByKey.
+func AesDePasswordByKey(key, password string) (string, error) {
+	return AesDePassword(key, "", password)
+}
+
+// IsEncryptedPassword tells whether the password is in the envelope of a cipher, such as "{cipher_a}...".
+func IsEncryptedPassword(password string) bool {
+	for _, cipher := range CIPHER_MAP {
+		if strings.HasPrefix(password, cipher) {
+			return true
+		}
+	}
+	return false
+}
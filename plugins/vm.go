@@ -1,33 +1,34 @@
 package plugins
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"strconv"
 	"strings"
-	"time"
 
 	"github.com/WeBankPartners/wecube-plugins-qcloud/plugins/utils"
 	"github.com/sirupsen/logrus"
 	"github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/common"
-	"github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/common/profile"
 	cvm "github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/cvm/v20170312"
 )
 
 const (
-	INSTANCE_STATE_RUNNING = "RUNNING"
+	INSTANCE_STATE_RUNNING       = "RUNNING"
+	INSTANCE_STATE_LAUNCH_FAILED = "LAUNCH_FAILED"
 )
 
 const (
-	QCLOUD_ENDPOINT_CVM              = "cvm.tencentcloudapi.com"
 	RENEW_FLAG_NOTIFY_AND_AUTO_RENEW = "NOTIFY_AND_AUTO_RENEW"
 )
 
+// VM_SECRET_PASSWORD is the name of the encrypted password of a vm in the idempotency record.
+const VM_SECRET_PASSWORD = "password"
+
 var (
-	INVALID_PARAMETERS          = errors.New("Invalid parameters")
-	VM_WAIT_STATE_TIMEOUT_ERROR = errors.New("qcloud wait vm timeout")
-	VM_NOT_FOUND_ERROR          = errors.New("qcloud vm not found")
+	INVALID_PARAMETERS = errors.New("Invalid parameters")
+	VM_NOT_FOUND_ERROR = errors.New("qcloud vm not found")
 )
 
 type VmPlugin struct{}
@@ -53,15 +54,14 @@ func (plugin *VmPlugin) GetActionByName(actionName string) (Action, error) {
 	return action, nil
 }
 
-func createCvmClient(region, secretId, secretKey string) (client *cvm.Client, err error) {
-	credential := common.NewCredential(secretId, secretKey)
-
-	clientProfile := profile.NewClientProfile()
-	clientProfile.HttpProfile.Endpoint = QCLOUD_ENDPOINT_CVM
+func (plugin *VmPlugin) GetActions() map[string]Action {
+	return VmActions
+}
 
-	client, err = cvm.NewClient(credential, region, clientProfile)
+func createCvmClient(ctx context.Context, params *ProviderParams) (client *cvm.Client, err error) {
+	client, err = GetClientFactory().WithContext(ctx).WithProviderParams(params).NewCvmClient(params.Region, params.SecretID, params.SecretKey)
 	if err != nil {
-		logrus.Errorf("Create Qcloud vm client failed,err=%v", err)
+		Logger(ctx).Errorf("Create Qcloud vm client failed,err=%v", err)
 	}
 	return
 }
@@ -72,25 +72,26 @@ type VmCreateInputs struct {
 
 type VmCreateInput struct {
 	CallBackParameter
-	Guid                 string `json:"guid,omitempty"`
-	Seed                 string `json:"seed,omitempty"`
-	ProviderParams       string `json:"provider_params,omitempty"`
-	Location             string `json:"location"`
-	APISecret            string `json:"api_secret"`
-	VpcId                string `json:"vpc_id,omitempty"`
-	SubnetId             string `json:"subnet_id,omitempty"`
-	InstanceName         string `json:"instance_name,omitempty"`
-	Id                   string `json:"id,omitempty"`
-	HostType             string `json:"host_type,omitempty"`
-	InstanceType         string `json:"instance_type,omitempty"`
-	InstanceFamily       string `json:"instance_family,omitempty"`
-	ImageId              string `json:"image_id,omitempty"`
-	SystemDiskSize       string `json:"system_disk_size,omitempty"`
-	InstanceChargeType   string `json:"instance_charge_type,omitempty"`
-	InstanceChargePeriod string `json:"instance_charge_period,omitempty"`
-	InstancePrivateIp    string `json:"instance_private_ip,omitempty"`
-	Password             string `json:"password,omitempty"`
-	ProjectId            string `json:"project_id,omitempty"`
+	WaitParameter
+	Guid                 string `json:"guid,omitempty" register:"required"`
+	Seed                 string `json:"seed,omitempty" register:"required,system_variable=ENCRYPT_SEED"`
+	ProviderParams       string `json:"provider_params,omitempty" register:"optional"`
+	Location             string `json:"location" register:"required"`
+	APISecret            string `json:"api_secret" register:"required,system_variable=QCLOUD_API_SECRET"`
+	VpcId                string `json:"vpc_id,omitempty" register:"required"`
+	SubnetId             string `json:"subnet_id,omitempty" register:"required"`
+	InstanceName         string `json:"instance_name,omitempty" register:"optional"`
+	Id                   string `json:"id,omitempty" register:"optional"`
+	HostType             string `json:"host_type,omitempty" register:"required"`
+	InstanceType         string `json:"instance_type,omitempty" register:"optional"`
+	InstanceFamily       string `json:"instance_family,omitempty" register:"optional"`
+	ImageId              string `json:"image_id,omitempty" register:"required"`
+	SystemDiskSize       string `json:"system_disk_size,omitempty" register:"required"`
+	InstanceChargeType   string `json:"instance_charge_type,omitempty" register:"required" enum:"charge_type"`
+	InstanceChargePeriod string `json:"instance_charge_period,omitempty" register:"optional"`
+	InstancePrivateIp    string `json:"instance_private_ip,omitempty" register:"optional"`
+	Password             string `json:"password,omitempty" register:"required"`
+	ProjectId            string `json:"project_id,omitempty" register:"optional"`
 }
 
 type VmCreateOutputs struct {
@@ -100,20 +101,20 @@ type VmCreateOutputs struct {
 type VmCreateOutput struct {
 	CallBackParameter
 	Result
-	Guid              string `json:"guid,omitempty"`
+	Guid              string `json:"guid,omitempty" register:"optional"`
 	RequestId         string `json:"request_id,omitempty"`
-	Id                string `json:"id,omitempty"`
-	Cpu               string `json:"cpu,omitempty"`
-	Memory            string `json:"memory,omitempty"`
-	Password          string `json:"password,omitempty"`
+	Id                string `json:"id,omitempty" register:"optional"`
+	Cpu               string `json:"cpu,omitempty" register:"optional"`
+	Memory            string `json:"memory,omitempty" register:"optional"`
+	Password          string `json:"password,omitempty" register:"optional"`
 	InstanceState     string `json:"instance_state,omitempty"`
-	InstancePrivateIp string `json:"instance_private_ip,omitempty"`
+	InstancePrivateIp string `json:"instance_private_ip,omitempty" register:"optional"`
 }
 
 type VmCreateAction struct {
 }
 
-func (action *VmCreateAction) ReadParam(param interface{}) (interface{}, error) {
+func (action *VmCreateAction) ReadParam(ctx context.Context, param interface{}) (interface{}, error) {
 	var inputs VmCreateInputs
 	err := UnmarshalJson(param, &inputs)
 	if err != nil {
@@ -134,7 +135,7 @@ func (action *VmCreateAction) checkCreateVmParams(input VmCreateInput) error {
 	if input.SystemDiskSize == "" {
 		return errors.New("SystemDiskSize is empty")
 	}
-	if input.InstanceChargeType != CHARGE_TYPE_PREPAID && input.InstanceChargeType != CHARGE_TYPE_BY_HOUR {
+	if err := IsValidValue(input.InstanceChargeType, chargeTypes); err != nil {
 		return errors.New("wrong InstanceChargeType string")
 	}
 	if input.Guid == "" {
@@ -159,35 +160,31 @@ func (action *VmCreateAction) checkCreateVmParams(input VmCreateInput) error {
 	return nil
 }
 
-func (action *VmCreateAction) createVm(input *VmCreateInput) (output VmCreateOutput, err error) {
+func (action *VmCreateAction) createVm(ctx context.Context, input *VmCreateInput) (output VmCreateOutput, err error) {
 	defer func() {
 		output.Guid = input.Guid
 		output.CallBackParameter.Parameter = input.CallBackParameter.Parameter
 		if err == nil {
 			output.Result.Code = RESULT_CODE_SUCCESS
 		} else {
-			output.Result.Code = RESULT_CODE_ERROR
-			output.Result.Message = err.Error()
+			output.Result.SetError(err)
 		}
 	}()
 
-	if err = action.checkCreateVmParams(*input); err != nil {
+	if err = AsValidationError(action.checkCreateVmParams(*input)); err != nil {
 		return
 	}
 
-	if input.ProviderParams == "" {
-		input.ProviderParams = fmt.Sprintf("%s;%s", input.Location, input.APISecret)
+	params, err := NewProviderParams(input.ProviderParams, input.Location, input.APISecret)
+	if err != nil {
+		return
 	}
-
-	paramsMap, err := GetMapFromProviderParams(input.ProviderParams)
-	if zone, ok := paramsMap["AvailableZone"]; ok {
-		if zone == "" {
-			err = fmt.Errorf("wrong AvailableZone value")
-			return
-		}
+	if params.Zone == "" {
+		err = fmt.Errorf("AvailableZone of provider_params is empty")
+		return
 	}
 
-	client, err := createCvmClient(paramsMap["Region"], paramsMap["SecretID"], paramsMap["SecretKey"])
+	client, err := createCvmClient(ctx, params)
 	if err != nil {
 		return
 	}
@@ -197,27 +194,39 @@ func (action *VmCreateAction) createVm(input *VmCreateInput) (output VmCreateOut
 		vmInfo, ok, er := queryInstanceById(client, input.Id)
 		if er != nil {
 			err = er
-			logrus.Errorf("queryInstanceById meet error=%v", err)
+			Logger(ctx).Errorf("queryInstanceById meet error=%v", err)
 			return
 		}
 		if ok {
-			output.RequestId = "legacy qcloud API doesn't support returnning request id"
-			output.Id = input.Id
-			output.Memory = strconv.Itoa(int(*vmInfo.Memory))
-			output.Cpu = strconv.Itoa(int(*vmInfo.CPU))
-			output.InstanceState = *vmInfo.InstanceState
-			output.InstancePrivateIp = *vmInfo.PrivateIpAddresses[0]
-			output.Password = input.Password
+			// the vm may be resumed by a retry right after it was created, it is returned once it is running.
+			if *vmInfo.InstanceState != INSTANCE_STATE_RUNNING {
+				if err = waitVmInDesireState(ctx, client, input.Id, INSTANCE_STATE_RUNNING, input.WaitTimeout); err != nil {
+					Logger(ctx).Errorf("waitVmInDesireState meet error=%v", err)
+					return
+				}
+			}
+			password := getResourceSecret(ctx, input.Guid, VM_SECRET_PASSWORD)
+			if password == "" && input.Password != "" {
+				if password, err = utils.AesEnPassword(input.Guid, input.Seed, input.Password, utils.DEFALT_CIPHER); err != nil {
+					Logger(ctx).Errorf("AesEnPassword meet error=%v", err)
+					return
+				}
+			}
+			err = fillVmCreateOutput(ctx, client, input.Id, password, &output)
 			return
 		}
 	}
 
 	request := cvm.NewRunInstancesRequest()
+	if IsDryRun(ctx) {
+		request.DryRun = common.BoolPtr(true)
+	}
+	request.ClientToken = GetClientToken(ctx)
 	if input.InstanceName != "" {
 		request.InstanceName = &input.InstanceName
 	}
 
-	zone := paramsMap["AvailableZone"]
+	zone := params.Zone
 	request.Placement = &cvm.Placement{
 		Zone: &zone,
 	}
@@ -266,6 +275,13 @@ func (action *VmCreateAction) createVm(input *VmCreateInput) (output VmCreateOut
 	request.LoginSettings = &cvm.LoginSettings{
 		Password: &input.Password,
 	}
+	password, err := utils.AesEnPassword(input.Guid, input.Seed, input.Password, utils.DEFALT_CIPHER)
+	if err != nil {
+		Logger(ctx).Errorf("AesEnPassword meet error=%v", err)
+		return
+	}
+	// the generated password is kept with the vm, it can't be read from the cloud API if the vm is resumed.
+	recordResourceSecret(ctx, input.Guid, VM_SECRET_PASSWORD, password)
 
 	assignPublicIp := false
 	maxBandwidth := int64(10)
@@ -275,7 +291,7 @@ func (action *VmCreateAction) createVm(input *VmCreateInput) (output VmCreateOut
 	}
 
 	if input.InstanceType == "" && input.HostType != "" {
-		input.InstanceType = getInstanceType(client, paramsMap["AvailableZone"], input.InstanceChargeType, input.HostType, input.InstanceFamily)
+		input.InstanceType = getInstanceType(client, params.Zone, input.InstanceChargeType, input.HostType, input.InstanceFamily)
 		if input.InstanceType == "" {
 			err = fmt.Errorf("can't found instanceType(%v)", input.HostType)
 			return
@@ -290,46 +306,51 @@ func (action *VmCreateAction) createVm(input *VmCreateInput) (output VmCreateOut
 			return
 		}
 		request.Placement.ProjectId = &projectId
+	} else if params.ProjectId != "" {
+		projectId := params.GetProjectId()
+		request.Placement.ProjectId = &projectId
 	}
 
 	response, err := client.RunInstances(request)
 	if err != nil {
-		logrus.Errorf("RunInstances meet error=%v", err)
+		Logger(ctx).Errorf("RunInstances meet error=%v", err)
 		return
 	}
 	input.Id = *response.Response.InstanceIdSet[0]
+	recordResourceId(ctx, input.Guid, input.Id)
 
-	if err = waitVmInDesireState(client, input.Id, INSTANCE_STATE_RUNNING, 120); err != nil {
-		logrus.Errorf("waitVmInDesireState meet error=%v", err)
+	if err = waitVmInDesireState(ctx, client, input.Id, INSTANCE_STATE_RUNNING, input.WaitTimeout); err != nil {
+		Logger(ctx).Errorf("waitVmInDesireState meet error=%v", err)
 		return
 	}
-	logrus.Infof("Created VM's state is [%v] now", INSTANCE_STATE_RUNNING)
+	Logger(ctx).Infof("Created VM's state is [%v] now", INSTANCE_STATE_RUNNING)
 
-	vmInfo, ok, err := queryInstanceById(client, input.Id)
+	err = fillVmCreateOutput(ctx, client, input.Id, password, &output)
+	return
+}
+
+// fillVmCreateOutput sets the output of the created vm, password is the encrypted password of the vm.
+func fillVmCreateOutput(ctx context.Context, client *cvm.Client, instanceId string, password string, output *VmCreateOutput) error {
+	vmInfo, ok, err := queryInstanceById(client, instanceId)
 	if err != nil {
-		logrus.Errorf("queryInstanceById meet error=%v", err)
-		return
+		Logger(ctx).Errorf("queryInstanceById meet error=%v", err)
+		return err
 	}
-	if ok {
-		output.RequestId = "legacy qcloud API doesn't support returnning request id"
-		output.Id = input.Id
-		output.Memory = strconv.Itoa(int(*vmInfo.Memory))
-		output.Cpu = strconv.Itoa(int(*vmInfo.CPU))
-		output.InstanceState = *vmInfo.InstanceState
-		output.InstancePrivateIp = *vmInfo.PrivateIpAddresses[0]
-		password, er := utils.AesEnPassword(input.Guid, input.Seed, input.Password, utils.DEFALT_CIPHER)
-		if er != nil {
-			err = er
-			logrus.Errorf("AesEnPassword meet error=%v", err)
-			return
-		}
-		output.Password = password
-		return
+	if !ok {
+		Logger(ctx).Errorf("vm[%v] could not be found", instanceId)
+		return fmt.Errorf("vm[%v] could not be found", instanceId)
 	}
 
-	err = fmt.Errorf("vm[%v] could not be found", input.Id)
-	logrus.Errorf("vm[%v] could not be found", input.Id)
-	return
+	output.RequestId = "legacy qcloud API doesn't support returnning request id"
+	output.Id = instanceId
+	output.Memory = strconv.Itoa(int(*vmInfo.Memory))
+	output.Cpu = strconv.Itoa(int(*vmInfo.CPU))
+	output.InstanceState = *vmInfo.InstanceState
+	if len(vmInfo.PrivateIpAddresses) > 0 {
+		output.InstancePrivateIp = *vmInfo.PrivateIpAddresses[0]
+	}
+	output.Password = password
+	return nil
 }
 func getInstanceType(client *cvm.Client, zone string, chargeType string, hostType string, instanceFamily string) string {
 	cpu, memory, err := getCpuAndMemoryFromHostType(hostType)
@@ -415,19 +436,19 @@ func getCpuAndMemoryFromHostType(hostType string) (int64, int64, error) {
 	return cpu, mem, nil
 }
 
-func (action *VmCreateAction) Do(input interface{}) (interface{}, error) {
+func (action *VmCreateAction) Do(ctx context.Context, input interface{}) (interface{}, error) {
 	vms, _ := input.(VmCreateInputs)
-	outputs := VmCreateOutputs{}
-	var finalErr error
-	for _, vm := range vms.Inputs {
-		output, err := action.createVm(&vm)
-		if err != nil {
-			finalErr = err
-		}
-		outputs.Outputs = append(outputs.Outputs, output)
-	}
+	outputs := VmCreateOutputs{Outputs: make([]VmCreateOutput, len(vms.Inputs))}
+	finalErr := runInputs(ctx, len(vms.Inputs), func(i int) []string {
+		return []string{vms.Inputs[i].Guid, vms.Inputs[i].Id}
+	}, func(ctx context.Context, i int) error {
+		vm := vms.Inputs[i]
+		output, err := action.createVm(ctx, &vm)
+		outputs.Outputs[i] = output
+		return err
+	})
 
-	logrus.Infof("all vms = %v are created", vms)
+	Logger(ctx).Infof("all vms = %s are created", Sanitize(vms))
 	return &outputs, finalErr
 }
 
@@ -451,26 +472,18 @@ func queryInstanceById(client *cvm.Client, instanceId string) (*cvm.Instance, bo
 	return response.Response.InstanceSet[0], true, nil
 }
 
-func waitVmInDesireState(client *cvm.Client, instanceId string, desireState string, timeout int) error {
-	count := 0
-
-	for {
-		time.Sleep(5 * time.Second)
+func waitVmInDesireState(ctx context.Context, client *cvm.Client, instanceId string, desireState string, timeout string) error {
+	waiter, err := NewWaiter(WAIT_RESOURCE_VM, instanceId, timeout)
+	if err != nil {
+		return err
+	}
+	return waiter.WithFailureStates(INSTANCE_STATE_LAUNCH_FAILED).Wait(ctx, func() (string, bool, error) {
 		instance, _, err := queryInstanceById(client, instanceId)
-		if err != nil {
-			return err
+		if err != nil || instance == nil {
+			return "", false, err
 		}
-
-		if instance != nil && *instance.InstanceState == desireState {
-			break
-		}
-
-		count++
-		if count*5 > timeout {
-			return VM_WAIT_STATE_TIMEOUT_ERROR
-		}
-	}
-	return nil
+		return *instance.InstanceState, *instance.InstanceState == desireState, nil
+	})
 }
 
 type VmTerminateInputs struct {
@@ -479,11 +492,12 @@ type VmTerminateInputs struct {
 
 type VmTerminateInput struct {
 	CallBackParameter
-	Guid           string `json:"guid,omitempty"`
-	Id             string `json:"id,omitempty"`
-	ProviderParams string `json:"provider_params,omitempty"`
-	Location       string `json:"location"`
-	APISecret      string `json:"api_secret"`
+	WaitParameter
+	Guid           string `json:"guid,omitempty" register:"required"`
+	Id             string `json:"id,omitempty" register:"required"`
+	ProviderParams string `json:"provider_params,omitempty" register:"optional"`
+	Location       string `json:"location" register:"required"`
+	APISecret      string `json:"api_secret" register:"required,system_variable=QCLOUD_API_SECRET"`
 }
 
 type VmTerminateOutputs struct {
@@ -492,7 +506,7 @@ type VmTerminateOutputs struct {
 type VmTerminateOutput struct {
 	CallBackParameter
 	Result
-	Guid      string `json:"guid,omitempty"`
+	Guid      string `json:"guid,omitempty" register:"optional"`
 	Id        string `json:"id,omitempty"`
 	RequestId string `json:"request_id,omitempty"`
 }
@@ -500,7 +514,7 @@ type VmTerminateOutput struct {
 type VmTerminateAction struct {
 }
 
-func (action *VmTerminateAction) ReadParam(param interface{}) (interface{}, error) {
+func (action *VmTerminateAction) ReadParam(ctx context.Context, param interface{}) (interface{}, error) {
 	var inputs VmTerminateInputs
 	err := UnmarshalJson(param, &inputs)
 	if err != nil {
@@ -528,7 +542,7 @@ func (action *VmTerminateAction) checkTerminateVmParams(input VmTerminateInput)
 	return nil
 }
 
-func (action *VmTerminateAction) terminateVm(input *VmTerminateInput) (output VmTerminateOutput, err error) {
+func (action *VmTerminateAction) terminateVm(ctx context.Context, input *VmTerminateInput) (output VmTerminateOutput, err error) {
 	defer func() {
 		output.Guid = input.Guid
 		output.Id = input.Id
@@ -536,24 +550,19 @@ func (action *VmTerminateAction) terminateVm(input *VmTerminateInput) (output Vm
 		if err == nil {
 			output.Result.Code = RESULT_CODE_SUCCESS
 		} else {
-			output.Result.Code = RESULT_CODE_ERROR
-			output.Result.Message = err.Error()
+			output.Result.SetError(err)
 		}
 	}()
 
-	if err = action.checkTerminateVmParams(*input); err != nil {
+	if err = AsValidationError(action.checkTerminateVmParams(*input)); err != nil {
 		return
 	}
 
-	if input.ProviderParams == "" {
-		input.ProviderParams = fmt.Sprintf("%s;%s", input.Location, input.APISecret)
-	}
-
-	paramsMap, err := GetMapFromProviderParams(input.ProviderParams)
+	params, err := NewProviderParams(input.ProviderParams, input.Location, input.APISecret)
 	if err != nil {
 		return
 	}
-	client, err := createCvmClient(paramsMap["Region"], paramsMap["SecretID"], paramsMap["SecretKey"])
+	client, err := createCvmClient(ctx, params)
 	if err != nil {
 		return
 	}
@@ -576,48 +585,42 @@ func (action *VmTerminateAction) terminateVm(input *VmTerminateInput) (output Vm
 	}
 	output.RequestId = *response.Response.RequestId
 
-	if err = waitVmTerminateDone(client, input.Id, 600); err != nil {
+	if err = waitVmTerminateDone(ctx, client, input.Id, input.WaitTimeout); err != nil {
 		return
 	}
 
 	return
 }
 
-func waitVmTerminateDone(client *cvm.Client, instanceId string, timeout int) error {
-	count := 0
-	for {
-		time.Sleep(5 * time.Second)
-		_, ok, err := queryInstanceById(client, instanceId)
-		if err != nil {
-			return err
-		}
-		if !ok {
-			break
-		}
-
-		count++
-		if count*5 > timeout {
-			return VM_WAIT_STATE_TIMEOUT_ERROR
-		}
+func waitVmTerminateDone(ctx context.Context, client *cvm.Client, instanceId string, timeout string) error {
+	waiter, err := NewWaiter(WAIT_RESOURCE_VM, instanceId, timeout)
+	if err != nil {
+		return err
 	}
-	return nil
+	return waiter.Wait(ctx, func() (string, bool, error) {
+		instance, ok, err := queryInstanceById(client, instanceId)
+		if err != nil || !ok {
+			return "", !ok, err
+		}
+		return *instance.InstanceState, false, nil
+	})
 }
 
-func (action *VmTerminateAction) Do(input interface{}) (interface{}, error) {
+func (action *VmTerminateAction) Do(ctx context.Context, input interface{}) (interface{}, error) {
 	vms, _ := input.(VmTerminateInputs)
-	outputs := VmTerminateOutputs{}
-	var finalErr error
-	for _, vm := range vms.Inputs {
-		output, err := action.terminateVm(&vm)
+	outputs := VmTerminateOutputs{Outputs: make([]VmTerminateOutput, len(vms.Inputs))}
+	finalErr := runInputs(ctx, len(vms.Inputs), func(i int) []string {
+		return []string{vms.Inputs[i].Guid, vms.Inputs[i].Id}
+	}, func(ctx context.Context, i int) error {
+		vm := vms.Inputs[i]
+		output, err := action.terminateVm(ctx, &vm)
 		outPrint,_ := json.Marshal(output)
-		logrus.Infof("terminate vm output------------>%s ", string(outPrint))
-		if err != nil {
-			finalErr = err
-		}
-		outputs.Outputs = append(outputs.Outputs, output)
-	}
+		Logger(ctx).Infof("terminate vm output------------>%s ", string(outPrint))
+		outputs.Outputs[i] = output
+		return err
+	})
 
-	logrus.Infof("all vms = %v are terminate", vms)
+	Logger(ctx).Infof("all vms = %s are terminate", Sanitize(vms))
 	return &outputs, finalErr
 }
 
@@ -634,7 +637,7 @@ type VmStartOutputs struct {
 type VmStartAction struct {
 }
 
-func (action *VmStartAction) ReadParam(param interface{}) (interface{}, error) {
+func (action *VmStartAction) ReadParam(ctx context.Context, param interface{}) (interface{}, error) {
 	var inputs VmStartInputs
 	err := UnmarshalJson(param, &inputs)
 	if err != nil {
@@ -662,7 +665,7 @@ func (action *VmStartAction) checkStartVmParams(input VmStartInput) error {
 	return nil
 }
 
-func (action *VmStartAction) startVm(input *VmStartInput) (output VmStartOutput, err error) {
+func (action *VmStartAction) startVm(ctx context.Context, input *VmStartInput) (output VmStartOutput, err error) {
 	defer func() {
 		output.Guid = input.Guid
 		output.Id = input.Id
@@ -670,24 +673,19 @@ func (action *VmStartAction) startVm(input *VmStartInput) (output VmStartOutput,
 		if err == nil {
 			output.Result.Code = RESULT_CODE_SUCCESS
 		} else {
-			output.Result.Code = RESULT_CODE_ERROR
-			output.Result.Message = err.Error()
+			output.Result.SetError(err)
 		}
 	}()
 
-	if err = action.checkStartVmParams(*input); err != nil {
+	if err = AsValidationError(action.checkStartVmParams(*input)); err != nil {
 		return
 	}
 
-	if input.ProviderParams == "" {
-		input.ProviderParams = fmt.Sprintf("%s;%s", input.Location, input.APISecret)
-	}
-
-	paramsMap, err := GetMapFromProviderParams(input.ProviderParams)
+	params, err := NewProviderParams(input.ProviderParams, input.Location, input.APISecret)
 	if err != nil {
 		return
 	}
-	client, err := createCvmClient(paramsMap["Region"], paramsMap["SecretID"], paramsMap["SecretKey"])
+	client, err := createCvmClient(ctx, params)
 	if err != nil {
 		return
 	}
@@ -703,19 +701,19 @@ func (action *VmStartAction) startVm(input *VmStartInput) (output VmStartOutput,
 	return
 }
 
-func (action *VmStartAction) Do(input interface{}) (interface{}, error) {
+func (action *VmStartAction) Do(ctx context.Context, input interface{}) (interface{}, error) {
 	vms, _ := input.(VmStartInputs)
-	outputs := VmStartOutputs{}
-	var finalErr error
-	for _, vm := range vms.Inputs {
-		output, err := action.startVm(&vm)
-		if err != nil {
-			finalErr = err
-		}
-		outputs.Outputs = append(outputs.Outputs, output)
-	}
+	outputs := VmStartOutputs{Outputs: make([]VmStartOutput, len(vms.Inputs))}
+	finalErr := runInputs(ctx, len(vms.Inputs), func(i int) []string {
+		return []string{vms.Inputs[i].Guid, vms.Inputs[i].Id}
+	}, func(ctx context.Context, i int) error {
+		vm := vms.Inputs[i]
+		output, err := action.startVm(ctx, &vm)
+		outputs.Outputs[i] = output
+		return err
+	})
 
-	logrus.Infof("all vms = %v are created", vms)
+	Logger(ctx).Infof("all vms = %s are created", Sanitize(vms))
 	return &outputs, finalErr
 }
 
@@ -732,7 +730,7 @@ type VmStopOutputs struct {
 type VmStopAction struct {
 }
 
-func (action *VmStopAction) ReadParam(param interface{}) (interface{}, error) {
+func (action *VmStopAction) ReadParam(ctx context.Context, param interface{}) (interface{}, error) {
 	var inputs VmStopInputs
 	err := UnmarshalJson(param, &inputs)
 	if err != nil {
@@ -760,7 +758,7 @@ func (action *VmStopAction) checkStopVmParams(input VmStopInput) error {
 	return nil
 }
 
-func (action *VmStopAction) stopVm(input *VmStopInput) (output VmStopOutput, err error) {
+func (action *VmStopAction) stopVm(ctx context.Context, input *VmStopInput) (output VmStopOutput, err error) {
 	defer func() {
 		output.Guid = input.Guid
 		output.Id = input.Id
@@ -768,24 +766,19 @@ func (action *VmStopAction) stopVm(input *VmStopInput) (output VmStopOutput, err
 		if err == nil {
 			output.Result.Code = RESULT_CODE_SUCCESS
 		} else {
-			output.Result.Code = RESULT_CODE_ERROR
-			output.Result.Message = err.Error()
+			output.Result.SetError(err)
 		}
 	}()
 
-	if err = action.checkStopVmParams(*input); err != nil {
+	if err = AsValidationError(action.checkStopVmParams(*input)); err != nil {
 		return
 	}
 
-	if input.ProviderParams == "" {
-		input.ProviderParams = fmt.Sprintf("%s;%s", input.Location, input.APISecret)
-	}
-
-	paramsMap, err := GetMapFromProviderParams(input.ProviderParams)
+	params, err := NewProviderParams(input.ProviderParams, input.Location, input.APISecret)
 	if err != nil {
 		return
 	}
-	client, err := createCvmClient(paramsMap["Region"], paramsMap["SecretID"], paramsMap["SecretKey"])
+	client, err := createCvmClient(ctx, params)
 	if err != nil {
 		return
 	}
@@ -801,19 +794,19 @@ func (action *VmStopAction) stopVm(input *VmStopInput) (output VmStopOutput, err
 	return
 }
 
-func (action *VmStopAction) Do(input interface{}) (interface{}, error) {
+func (action *VmStopAction) Do(ctx context.Context, input interface{}) (interface{}, error) {
 	vms, _ := input.(VmStopInputs)
-	outputs := VmStopOutputs{}
-	var finalErr error
-	for _, vm := range vms.Inputs {
-		output, err := action.stopVm(&vm)
-		if err != nil {
-			finalErr = err
-		}
-		outputs.Outputs = append(outputs.Outputs, output)
-	}
+	outputs := VmStopOutputs{Outputs: make([]VmStopOutput, len(vms.Inputs))}
+	finalErr := runInputs(ctx, len(vms.Inputs), func(i int) []string {
+		return []string{vms.Inputs[i].Guid, vms.Inputs[i].Id}
+	}, func(ctx context.Context, i int) error {
+		vm := vms.Inputs[i]
+		output, err := action.stopVm(ctx, &vm)
+		outputs.Outputs[i] = output
+		return err
+	})
 
-	logrus.Infof("all vms = %v are created", vms)
+	Logger(ctx).Infof("all vms = %s are created", Sanitize(vms))
 	return &outputs, finalErr
 }
 
@@ -826,12 +819,12 @@ type VmBindSecurityGroupInputs struct {
 
 type VmBindSecurityGroupInput struct {
 	CallBackParameter
-	Guid             string `json:"guid,omitempty"`
-	ProviderParams   string `json:"provider_params,omitempty"`
-	InstanceId       string `json:"instance_id,omitempty"`
-	SecurityGroupIds string `json:"security_group_ids,omitempty"`
-	Location         string `json:"location"`
-	APISecret        string `json:"api_secret"`
+	Guid             string `json:"guid,omitempty" register:"required"`
+	ProviderParams   string `json:"provider_params,omitempty" register:"optional"`
+	InstanceId       string `json:"instance_id,omitempty" register:"required"`
+	SecurityGroupIds string `json:"security_group_ids,omitempty" register:"required"`
+	Location         string `json:"location" register:"required"`
+	APISecret        string `json:"api_secret" register:"required,system_variable=QCLOUD_API_SECRET"`
 }
 
 type VmBindSecurityGroupOutputs struct {
@@ -841,10 +834,10 @@ type VmBindSecurityGroupOutputs struct {
 type VmBindSecurityGroupOutput struct {
 	CallBackParameter
 	Result
-	Guid string `json:"guid,omitempty"`
+	Guid string `json:"guid,omitempty" register:"optional"`
 }
 
-func (action *VmBindSecurityGroupsAction) ReadParam(param interface{}) (interface{}, error) {
+func (action *VmBindSecurityGroupsAction) ReadParam(ctx context.Context, param interface{}) (interface{}, error) {
 	var inputs VmBindSecurityGroupInputs
 	err := UnmarshalJson(param, &inputs)
 	if err != nil {
@@ -874,28 +867,27 @@ func (action *VmBindSecurityGroupsAction) checkVmBindSecurityGroupParams(input V
 	return nil
 }
 
-func (action *VmBindSecurityGroupsAction) vmBindSecurityGroup(input *VmBindSecurityGroupInput) (output VmBindSecurityGroupOutput, err error) {
+func (action *VmBindSecurityGroupsAction) vmBindSecurityGroup(ctx context.Context, input *VmBindSecurityGroupInput) (output VmBindSecurityGroupOutput, err error) {
 	defer func() {
 		output.Guid = input.Guid
 		output.CallBackParameter.Parameter = input.CallBackParameter.Parameter
 		if err == nil {
 			output.Result.Code = RESULT_CODE_SUCCESS
 		} else {
-			output.Result.Code = RESULT_CODE_ERROR
-			output.Result.Message = err.Error()
+			output.Result.SetError(err)
 		}
 	}()
 
-	if err = action.checkVmBindSecurityGroupParams(*input); err != nil {
+	if err = AsValidationError(action.checkVmBindSecurityGroupParams(*input)); err != nil {
 		return
 	}
 
-	if input.ProviderParams == "" {
-		input.ProviderParams = fmt.Sprintf("%s;%s", input.Location, input.APISecret)
+	if input.ProviderParams, err = mergeProviderParams(input.ProviderParams, input.Location, input.APISecret); err != nil {
+		return
 	}
 
 	securityGroups := strings.Split(input.SecurityGroupIds, ",")
-	err = BindCvmInstanceSecurityGroups(input.ProviderParams, input.InstanceId, securityGroups)
+	err = BindCvmInstanceSecurityGroups(ctx, input.ProviderParams, input.InstanceId, securityGroups)
 	if err != nil {
 		return
 	}
@@ -903,12 +895,12 @@ func (action *VmBindSecurityGroupsAction) vmBindSecurityGroup(input *VmBindSecur
 	return
 }
 
-func BindCvmInstanceSecurityGroups(providerParams string, instanceId string, securityGroups []string) error {
-	paramsMap, err := GetMapFromProviderParams(providerParams)
+func BindCvmInstanceSecurityGroups(ctx context.Context, providerParams string, instanceId string, securityGroups []string) error {
+	params, err := ParseProviderParams(providerParams)
 	if err != nil {
 		return err
 	}
-	client, err := createCvmClient(paramsMap["Region"], paramsMap["SecretID"], paramsMap["SecretKey"])
+	client, err := createCvmClient(ctx, params)
 	if err != nil {
 		return err
 	}
@@ -917,38 +909,38 @@ func BindCvmInstanceSecurityGroups(providerParams string, instanceId string, sec
 	request.InstanceIds = common.StringPtrs([]string{instanceId})
 	request.SecurityGroups = common.StringPtrs(securityGroups)
 	if _, err = client.ModifyInstancesAttribute(request); err != nil {
-		logrus.Errorf("cvm AssociateSecurityGroups meet err=%v", err)
+		Logger(ctx).Errorf("cvm AssociateSecurityGroups meet err=%v", err)
 	}
 
 	return err
 }
 
-func (action *VmBindSecurityGroupsAction) Do(input interface{}) (interface{}, error) {
+func (action *VmBindSecurityGroupsAction) Do(ctx context.Context, input interface{}) (interface{}, error) {
 	inputs, _ := input.(VmBindSecurityGroupInputs)
-	outputs := VmBindSecurityGroupOutputs{}
-	var finalErr error
-	for _, input := range inputs.Inputs {
-		output, err := action.vmBindSecurityGroup(&input)
-		if err != nil {
-			finalErr = err
-		}
-		outputs.Outputs = append(outputs.Outputs, output)
-	}
+	outputs := VmBindSecurityGroupOutputs{Outputs: make([]VmBindSecurityGroupOutput, len(inputs.Inputs))}
+	finalErr := runInputs(ctx, len(inputs.Inputs), func(i int) []string {
+		return []string{inputs.Inputs[i].Guid, inputs.Inputs[i].InstanceId}
+	}, func(ctx context.Context, i int) error {
+		input := inputs.Inputs[i]
+		output, err := action.vmBindSecurityGroup(ctx, &input)
+		outputs.Outputs[i] = output
+		return err
+	})
 
-	logrus.Infof("all vm  bind securityGroups = %v have been completed", inputs)
+	Logger(ctx).Infof("all vm  bind securityGroups = %s have been completed", Sanitize(inputs))
 	return &outputs, finalErr
 }
 
-func QueryCvmInstance(providerParams string, filter Filter) ([]*cvm.Instance, error) {
+func QueryCvmInstance(ctx context.Context, providerParams string, filter Filter) ([]*cvm.Instance, error) {
 	validFilterNames := []string{"instanceId", "privateIpAddress"}
 	filterValues := common.StringPtrs(filter.Values)
 	var limit int64
 
-	paramsMap, err := GetMapFromProviderParams(providerParams)
+	params, err := ParseProviderParams(providerParams)
 	if err != nil {
 		return nil, err
 	}
-	client, err := createCvmClient(paramsMap["Region"], paramsMap["SecretID"], paramsMap["SecretKey"])
+	client, err := createCvmClient(ctx, params)
 	if err != nil {
 		return nil, err
 	}
@@ -973,7 +965,7 @@ func QueryCvmInstance(providerParams string, filter Filter) ([]*cvm.Instance, er
 
 	response, err := client.DescribeInstances(request)
 	if err != nil {
-		logrus.Errorf("cvm DescribeInstances meet err=%v", err)
+		Logger(ctx).Errorf("cvm DescribeInstances meet err=%v", err)
 		return nil, err
 	}
 
@@ -1026,13 +1018,13 @@ func checkVmAddSecurityGoupsParam(input VmBindSecurityGroupInput) error {
 	return nil
 }
 
-func getSecurityGroupsByVm(providerParam string, instanceId string) ([]*string, error) {
+func getSecurityGroupsByVm(ctx context.Context, providerParam string, instanceId string) ([]*string, error) {
 	filter := Filter{
 		Name:   "instanceId",
 		Values: []string{instanceId},
 	}
 
-	items, err := QueryCvmInstance(providerParam, filter)
+	items, err := QueryCvmInstance(ctx, providerParam, filter)
 	if err != nil {
 		return []*string{}, err
 	}
@@ -1044,15 +1036,14 @@ func getSecurityGroupsByVm(providerParam string, instanceId string) ([]*string,
 	return items[0].SecurityGroupIds, nil
 }
 
-func vmAddSecurityGoups(input *VmBindSecurityGroupInput) (output VmBindSecurityGroupOutput, err error) {
+func vmAddSecurityGoups(ctx context.Context, input *VmBindSecurityGroupInput) (output VmBindSecurityGroupOutput, err error) {
 	defer func() {
 		output.Guid = input.Guid
 		output.CallBackParameter.Parameter = input.CallBackParameter.Parameter
 		if err == nil {
 			output.Result.Code = RESULT_CODE_SUCCESS
 		} else {
-			output.Result.Code = RESULT_CODE_ERROR
-			output.Result.Message = err.Error()
+			output.Result.SetError(err)
 		}
 	}()
 
@@ -1060,18 +1051,17 @@ func vmAddSecurityGoups(input *VmBindSecurityGroupInput) (output VmBindSecurityG
 		return
 	}
 
-	if input.Location != "" && input.APISecret != "" {
-		input.ProviderParams = fmt.Sprintf("%s;%s", input.Location, input.APISecret)
-	}
-
 	// do input.SecurityGoups to []string
 	sgIds, err := GetArrayFromString(input.SecurityGroupIds, ARRAY_SIZE_REAL, 0)
 	if err != nil {
 		return
 	}
 
-	paramsMap, _ := GetMapFromProviderParams(input.ProviderParams)
-	client, err := createVpcClient(paramsMap["Region"], paramsMap["SecretID"], paramsMap["SecretKey"])
+	params, err := NewProviderParams(input.ProviderParams, input.Location, input.APISecret)
+	if err != nil {
+		return
+	}
+	client, err := createVpcClient(ctx, params)
 	if err != nil {
 		return
 	}
@@ -1091,7 +1081,7 @@ func vmAddSecurityGoups(input *VmBindSecurityGroupInput) (output VmBindSecurityG
 	}
 
 	// get all security groups of the vm
-	sgs, err := getSecurityGroupsByVm(input.ProviderParams, input.InstanceId)
+	sgs, err := getSecurityGroupsByVm(ctx, params.String(), input.InstanceId)
 	if err != nil {
 		return
 	}
@@ -1116,7 +1106,7 @@ func vmAddSecurityGoups(input *VmBindSecurityGroupInput) (output VmBindSecurityG
 	}
 
 	// add input.SecurityGoups to vm
-	err = BindCvmInstanceSecurityGroups(input.ProviderParams, input.InstanceId, addSgIds)
+	err = BindCvmInstanceSecurityGroups(ctx, params.String(), input.InstanceId, addSgIds)
 	if err != nil {
 		return
 	}
@@ -1124,7 +1114,7 @@ func vmAddSecurityGoups(input *VmBindSecurityGroupInput) (output VmBindSecurityG
 	return
 }
 
-func (action *VmAddSecurityGroupsAction) ReadParam(param interface{}) (interface{}, error) {
+func (action *VmAddSecurityGroupsAction) ReadParam(ctx context.Context, param interface{}) (interface{}, error) {
 	var inputs VmAddSecurityGroupsInputs
 	err := UnmarshalJson(param, &inputs)
 	if err != nil {
@@ -1133,20 +1123,19 @@ func (action *VmAddSecurityGroupsAction) ReadParam(param interface{}) (interface
 	return inputs, nil
 }
 
-func (action *VmAddSecurityGroupsAction) Do(inputs interface{}) (interface{}, error) {
+func (action *VmAddSecurityGroupsAction) Do(ctx context.Context, inputs interface{}) (interface{}, error) {
 	vms, _ := inputs.(VmAddSecurityGroupsInputs)
-	outputs := VmAddSecurityGroupsOutputs{}
-	var finalErr error
-
-	for _, input := range vms.Inputs {
-		output, err := vmAddSecurityGoups(&input)
-		if err != nil {
-			finalErr = err
-		}
-		outputs.Outputs = append(outputs.Outputs, output)
-	}
+	outputs := VmAddSecurityGroupsOutputs{Outputs: make([]VmBindSecurityGroupOutput, len(vms.Inputs))}
+	finalErr := runInputs(ctx, len(vms.Inputs), func(i int) []string {
+		return []string{vms.Inputs[i].Guid, vms.Inputs[i].InstanceId}
+	}, func(ctx context.Context, i int) error {
+		input := vms.Inputs[i]
+		output, err := vmAddSecurityGoups(ctx, &input)
+		outputs.Outputs[i] = output
+		return err
+	})
 
-	logrus.Infof("all securityGoups had been added, input = %++v", vms)
+	Logger(ctx).Infof("all securityGoups had been added, input = %s", Sanitize(vms))
 	return &outputs, finalErr
 }
 
@@ -1162,7 +1151,7 @@ type VmRemoveSecurityGroupsOutputs struct {
 	Outputs []VmBindSecurityGroupOutput `json:"outputs,omitempty"`
 }
 
-func (action *VmRemoveSecurityGroupsAction) ReadParam(param interface{}) (interface{}, error) {
+func (action *VmRemoveSecurityGroupsAction) ReadParam(ctx context.Context, param interface{}) (interface{}, error) {
 	var inputs VmRemoveSecurityGroupsInputs
 	err := UnmarshalJson(param, &inputs)
 	if err != nil {
@@ -1190,15 +1179,14 @@ func checkVmRemoveSecurityGoupsParam(input VmBindSecurityGroupInput) error {
 	return nil
 }
 
-func vmRemoveSecurityGoups(input *VmBindSecurityGroupInput) (output VmBindSecurityGroupOutput, err error) {
+func vmRemoveSecurityGoups(ctx context.Context, input *VmBindSecurityGroupInput) (output VmBindSecurityGroupOutput, err error) {
 	defer func() {
 		output.Guid = input.Guid
 		output.CallBackParameter.Parameter = input.CallBackParameter.Parameter
 		if err == nil {
 			output.Result.Code = RESULT_CODE_SUCCESS
 		} else {
-			output.Result.Code = RESULT_CODE_ERROR
-			output.Result.Message = err.Error()
+			output.Result.SetError(err)
 		}
 	}()
 
@@ -1206,18 +1194,17 @@ func vmRemoveSecurityGoups(input *VmBindSecurityGroupInput) (output VmBindSecuri
 		return
 	}
 
-	if input.Location != "" && input.APISecret != "" {
-		input.ProviderParams = fmt.Sprintf("%s;%s", input.Location, input.APISecret)
-	}
-
 	// do input.SecurityGoups to []string
 	sgIds, err := GetArrayFromString(input.SecurityGroupIds, ARRAY_SIZE_REAL, 0)
 	if err != nil {
 		return
 	}
 
-	paramsMap, _ := GetMapFromProviderParams(input.ProviderParams)
-	client, err := createVpcClient(paramsMap["Region"], paramsMap["SecretID"], paramsMap["SecretKey"])
+	params, err := NewProviderParams(input.ProviderParams, input.Location, input.APISecret)
+	if err != nil {
+		return
+	}
+	client, err := createVpcClient(ctx, params)
 	if err != nil {
 		return
 	}
@@ -1236,7 +1223,7 @@ func vmRemoveSecurityGoups(input *VmBindSecurityGroupInput) (output VmBindSecuri
 	}
 
 	// get all security groups of the vm
-	sgs, err := getSecurityGroupsByVm(input.ProviderParams, input.InstanceId)
+	sgs, err := getSecurityGroupsByVm(ctx, params.String(), input.InstanceId)
 	if err != nil {
 		return
 	}
@@ -1257,7 +1244,7 @@ func vmRemoveSecurityGoups(input *VmBindSecurityGroupInput) (output VmBindSecuri
 	}
 
 	// add input.SecurityGoups to vm
-	err = BindCvmInstanceSecurityGroups(input.ProviderParams, input.InstanceId, newSgs)
+	err = BindCvmInstanceSecurityGroups(ctx, params.String(), input.InstanceId, newSgs)
 	if err != nil {
 		return
 	}
@@ -1265,19 +1252,18 @@ func vmRemoveSecurityGoups(input *VmBindSecurityGroupInput) (output VmBindSecuri
 	return
 }
 
-func (action *VmRemoveSecurityGroupsAction) Do(inputs interface{}) (interface{}, error) {
+func (action *VmRemoveSecurityGroupsAction) Do(ctx context.Context, inputs interface{}) (interface{}, error) {
 	vms, _ := inputs.(VmRemoveSecurityGroupsInputs)
-	outputs := VmRemoveSecurityGroupsOutputs{}
-	var finalErr error
-
-	for _, input := range vms.Inputs {
-		output, err := vmRemoveSecurityGoups(&input)
-		if err != nil {
-			finalErr = err
-		}
-		outputs.Outputs = append(outputs.Outputs, output)
-	}
+	outputs := VmRemoveSecurityGroupsOutputs{Outputs: make([]VmBindSecurityGroupOutput, len(vms.Inputs))}
+	finalErr := runInputs(ctx, len(vms.Inputs), func(i int) []string {
+		return []string{vms.Inputs[i].Guid, vms.Inputs[i].InstanceId}
+	}, func(ctx context.Context, i int) error {
+		input := vms.Inputs[i]
+		output, err := vmRemoveSecurityGoups(ctx, &input)
+		outputs.Outputs[i] = output
+		return err
+	})
 
-	logrus.Infof("all securityGoups had been removed, input = %++v", vms)
+	Logger(ctx).Infof("all securityGoups had been removed, input = %s", Sanitize(vms))
 	return &outputs, finalErr
 }
@@ -1,14 +1,13 @@
 package plugins
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"net"
-	"time"
 
 	"github.com/sirupsen/logrus"
 	"github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/common"
-	"github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/common/profile"
 	vpc "github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/vpc/v20170312"
 )
 
@@ -19,13 +18,8 @@ func init() {
 	VpcActions["terminate"] = new(VpcTerminateAction)
 }
 
-func CreateVpcClient(region, secretId, secretKey string) (client *vpc.Client, err error) {
-	credential := common.NewCredential(secretId, secretKey)
-
-	clientProfile := profile.NewClientProfile()
-	clientProfile.HttpProfile.Endpoint = "vpc.tencentcloudapi.com"
-
-	return vpc.NewClient(credential, region, clientProfile)
+func CreateVpcClient(ctx context.Context, params *ProviderParams) (client *vpc.Client, err error) {
+	return GetClientFactory().WithContext(ctx).WithProviderParams(params).NewVpcClient(params.Region, params.SecretID, params.SecretKey)
 }
 
 type VpcInputs struct {
@@ -34,13 +28,14 @@ type VpcInputs struct {
 
 type VpcInput struct {
 	CallBackParameter
-	Guid           string `json:"guid,omitempty"`
-	ProviderParams string `json:"provider_params,omitempty"`
-	Id             string `json:"id,omitempty"`
-	Name           string `json:"name,omitempty"`
-	CidrBlock      string `json:"cidr_block,omitempty"`
-	Location       string `json:"location"`
-	APISecret      string `json:"api_secret"`
+	WaitParameter
+	Guid           string `json:"guid,omitempty" register:"required"`
+	ProviderParams string `json:"provider_params,omitempty" register:"optional"`
+	Id             string `json:"id,omitempty" register:"required"`
+	Name           string `json:"name,omitempty" register:"required,actions=create"`
+	CidrBlock      string `json:"cidr_block,omitempty" register:"required,actions=create"`
+	Location       string `json:"location" register:"required"`
+	APISecret      string `json:"api_secret" register:"required,system_variable=QCLOUD_API_SECRET"`
 }
 
 type VpcOutputs struct {
@@ -51,9 +46,9 @@ type VpcOutput struct {
 	CallBackParameter
 	Result
 	RequestId    string `json:"request_id,omitempty"`
-	Guid         string `json:"guid,omitempty"`
-	RouteTableId string `json:"route_table_id,omitempty"`
-	Id           string `json:"id,omitempty"`
+	Guid         string `json:"guid,omitempty" register:"optional"`
+	RouteTableId string `json:"route_table_id,omitempty" register:"optional,actions=create"`
+	Id           string `json:"id,omitempty" register:"optional,actions=create"`
 }
 
 type VpcPlugin struct {
@@ -68,10 +63,14 @@ func (plugin *VpcPlugin) GetActionByName(actionName string) (Action, error) {
 	return action, nil
 }
 
+func (plugin *VpcPlugin) GetActions() map[string]Action {
+	return VpcActions
+}
+
 type VpcCreateAction struct {
 }
 
-func (action *VpcCreateAction) ReadParam(param interface{}) (interface{}, error) {
+func (action *VpcCreateAction) ReadParam(ctx context.Context, param interface{}) (interface{}, error) {
 	var inputs VpcInputs
 	err := UnmarshalJson(param, &inputs)
 	if err != nil {
@@ -91,25 +90,25 @@ func vpcCreateCheckParam(vpc *VpcInput) error {
 	return nil
 }
 
-func (action *VpcCreateAction) createVpc(vpcInput *VpcInput) (output VpcOutput, err error) {
+func (action *VpcCreateAction) createVpc(ctx context.Context, vpcInput *VpcInput) (output VpcOutput, err error) {
 	output.Guid = vpcInput.Guid
 	output.Result.Code = RESULT_CODE_SUCCESS
 	output.CallBackParameter.Parameter = vpcInput.CallBackParameter.Parameter
 
-	if vpcInput.Location != "" && vpcInput.APISecret != "" {
-		vpcInput.ProviderParams = fmt.Sprintf("%s;%s", vpcInput.Location, vpcInput.APISecret)
+	params, err := NewProviderParams(vpcInput.ProviderParams, vpcInput.Location, vpcInput.APISecret)
+	if err != nil {
+		output.Result.SetError(err)
+		return output, err
 	}
-	paramsMap, err := GetMapFromProviderParams(vpcInput.ProviderParams)
-	client, _ := CreateVpcClient(paramsMap["Region"], paramsMap["SecretID"], paramsMap["SecretKey"])
+	client, _ := CreateVpcClient(ctx, params)
 
 	defer func() {
 		if err != nil {
-			output.Result.Code = RESULT_CODE_ERROR
-			output.Result.Message = err.Error()
+			output.Result.SetError(err)
 		}
 	}()
 
-	if err = vpcCreateCheckParam(vpcInput); err != nil {
+	if err = AsValidationError(vpcCreateCheckParam(vpcInput)); err != nil {
 		return output, err
 	}
 
@@ -140,7 +139,7 @@ func (action *VpcCreateAction) createVpc(vpcInput *VpcInput) (output VpcOutput,
 
 	response, err := client.CreateVpc(request)
 	if err != nil {
-		logrus.Errorf("failed to create vpc, error=%s", err)
+		Logger(ctx).Errorf("failed to create vpc, error=%s", err)
 		return output, err
 	}
 
@@ -148,7 +147,7 @@ func (action *VpcCreateAction) createVpc(vpcInput *VpcInput) (output VpcOutput,
 	output.Id = *response.Response.Vpc.VpcId
 
 	// query defalut route_table
-	err = action.waitVpcCreatedone(client, *response.Response.Vpc.VpcId, 30)
+	err = action.waitVpcCreatedone(ctx, client, *response.Response.Vpc.VpcId, vpcInput.WaitTimeout)
 	if err != nil {
 		return output, err
 	}
@@ -162,28 +161,21 @@ func (action *VpcCreateAction) createVpc(vpcInput *VpcInput) (output VpcOutput,
 	return output, nil
 }
 
-func (action *VpcCreateAction) waitVpcCreatedone(client *vpc.Client, vpcId string, timeout int) error {
+func (action *VpcCreateAction) waitVpcCreatedone(ctx context.Context, client *vpc.Client, vpcId string, timeout string) error {
 	request := vpc.NewDescribeVpcsRequest()
 	request.VpcIds = common.StringPtrs([]string{vpcId})
-	count := 1
 
-	for {
+	waiter, err := NewWaiter(WAIT_RESOURCE_VPC, vpcId, timeout)
+	if err != nil {
+		return err
+	}
+	return waiter.Wait(ctx, func() (string, bool, error) {
 		response, err := client.DescribeVpcs(request)
 		if err != nil {
-			return fmt.Errorf("waiting vpc to create, %v", err)
-		}
-		if *response.Response.TotalCount == 1 {
-			return nil
-		}
-
-		if count >= timeout {
-			break
+			return "", false, fmt.Errorf("waiting vpc to create, %v", err)
 		}
-		time.Sleep(5 * time.Second)
-		count++
-	}
-
-	return fmt.Errorf("waiting vpc to create is timeout")
+		return "", *response.Response.TotalCount == 1, nil
+	})
 }
 
 func (action *VpcCreateAction) describeRouteTablesByVpc(client *vpc.Client, vpcId string) (routeTableId string, err error) {
@@ -215,26 +207,26 @@ func (action *VpcCreateAction) describeRouteTablesByVpc(client *vpc.Client, vpcI
 
 	return routeTableId, err
 }
-func (action *VpcCreateAction) Do(input interface{}) (interface{}, error) {
+func (action *VpcCreateAction) Do(ctx context.Context, input interface{}) (interface{}, error) {
 	vpcs, _ := input.(VpcInputs)
-	outputs := VpcOutputs{}
-	var finalErr error
-	for _, vpc := range vpcs.Inputs {
-		vpcOutput, err := action.createVpc(&vpc)
-		if err != nil {
-			finalErr = err
-		}
-		outputs.Outputs = append(outputs.Outputs, vpcOutput)
-	}
-
-	logrus.Infof("all vpcs = %v are created", vpcs)
+	outputs := VpcOutputs{Outputs: make([]VpcOutput, len(vpcs.Inputs))}
+	finalErr := runInputs(ctx, len(vpcs.Inputs), func(i int) []string {
+		return []string{vpcs.Inputs[i].Guid, vpcs.Inputs[i].Id}
+	}, func(ctx context.Context, i int) error {
+		vpc := vpcs.Inputs[i]
+		vpcOutput, err := action.createVpc(ctx, &vpc)
+		outputs.Outputs[i] = vpcOutput
+		return err
+	})
+
+	Logger(ctx).Infof("all vpcs = %s are created", Sanitize(vpcs))
 	return &outputs, finalErr
 }
 
 type VpcTerminateAction struct {
 }
 
-func (action *VpcTerminateAction) ReadParam(param interface{}) (interface{}, error) {
+func (action *VpcTerminateAction) ReadParam(ctx context.Context, param interface{}) (interface{}, error) {
 	var inputs VpcInputs
 	err := UnmarshalJson(param, &inputs)
 	if err != nil {
@@ -243,23 +235,23 @@ func (action *VpcTerminateAction) ReadParam(param interface{}) (interface{}, err
 	return inputs, nil
 }
 
-func (action *VpcTerminateAction) terminateVpc(vpcInput *VpcInput) (output VpcOutput, err error) {
+func (action *VpcTerminateAction) terminateVpc(ctx context.Context, vpcInput *VpcInput) (output VpcOutput, err error) {
 	output.Guid = vpcInput.Guid
 	output.Id = vpcInput.Id
 	output.Result.Code = RESULT_CODE_SUCCESS
 	output.CallBackParameter.Parameter = vpcInput.CallBackParameter.Parameter
 
-	if vpcInput.Location != "" && vpcInput.APISecret != "" {
-		vpcInput.ProviderParams = fmt.Sprintf("%s;%s", vpcInput.Location, vpcInput.APISecret)
+	params, err := NewProviderParams(vpcInput.ProviderParams, vpcInput.Location, vpcInput.APISecret)
+	if err != nil {
+		output.Result.SetError(err)
+		return output, err
 	}
-	paramsMap, err := GetMapFromProviderParams(vpcInput.ProviderParams)
-	client, _ := CreateVpcClient(paramsMap["Region"], paramsMap["SecretID"], paramsMap["SecretKey"])
+	client, _ := CreateVpcClient(ctx, params)
 
 	// check wether vpc is exist.
 	_, ok, err := queryVpcsInfo(client, vpcInput)
 	if err != nil {
-		output.Result.Code = RESULT_CODE_ERROR
-		output.Result.Message = err.Error()
+		output.Result.SetError(err)
 		output.RequestId = "legacy qcloud API doesn't support returnning request id"
 		return output, err
 	}
@@ -275,8 +267,7 @@ func (action *VpcTerminateAction) terminateVpc(vpcInput *VpcInput) (output VpcOu
 	response, err := client.DeleteVpc(request)
 	if err != nil {
 		err = fmt.Errorf("Failed to DeleteVpc(vpcId=%v), error=%s", vpcInput.Id, err)
-		output.Result.Code = RESULT_CODE_ERROR
-		output.Result.Message = err.Error()
+		output.Result.SetError(err)
 		return output, err
 	}
 	output.RequestId = *response.Response.RequestId
@@ -284,17 +275,17 @@ func (action *VpcTerminateAction) terminateVpc(vpcInput *VpcInput) (output VpcOu
 	return output, err
 }
 
-func (action *VpcTerminateAction) Do(input interface{}) (interface{}, error) {
+func (action *VpcTerminateAction) Do(ctx context.Context, input interface{}) (interface{}, error) {
 	vpcs, _ := input.(VpcInputs)
-	outputs := VpcOutputs{}
-	var finalErr error
-	for _, vpc := range vpcs.Inputs {
-		output, err := action.terminateVpc(&vpc)
-		if err != nil {
-			finalErr = err
-		}
-		outputs.Outputs = append(outputs.Outputs, output)
-	}
+	outputs := VpcOutputs{Outputs: make([]VpcOutput, len(vpcs.Inputs))}
+	finalErr := runInputs(ctx, len(vpcs.Inputs), func(i int) []string {
+		return []string{vpcs.Inputs[i].Guid, vpcs.Inputs[i].Id}
+	}, func(ctx context.Context, i int) error {
+		vpc := vpcs.Inputs[i]
+		output, err := action.terminateVpc(ctx, &vpc)
+		outputs.Outputs[i] = output
+		return err
+	})
 
 	return &outputs, finalErr
 }
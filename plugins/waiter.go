@@ -0,0 +1,234 @@
+package plugins
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	DEFAULT_WAIT_INTERVAL     = 5 * time.Second
+	DEFAULT_WAIT_MAX_INTERVAL = 20 * time.Second
+	DEFAULT_WAIT_BACKOFF      = 1.5
+	DEFAULT_WAIT_TIMEOUT      = 10 * time.Minute
+)
+
+// resources waited by actions, they are the keys of the default wait timeouts.
+const (
+	WAIT_RESOURCE_VM                 = "vm"
+	WAIT_RESOURCE_VPC                = "vpc"
+	WAIT_RESOURCE_DISK               = "disk"
+	WAIT_RESOURCE_ELASTIC_NIC        = "elastic-nic"
+	WAIT_RESOURCE_EIP                = "eip"
+	WAIT_RESOURCE_NAT_GATEWAY        = "nat-gateway"
+	WAIT_RESOURCE_PEERING_CONNECTION = "peering-connection"
+	WAIT_RESOURCE_CLB                = "clb"
+	WAIT_RESOURCE_CLB_TASK           = "clb-task"
+	WAIT_RESOURCE_MYSQL              = "mysql"
+	WAIT_RESOURCE_MYSQL_TASK         = "mysql-task"
+	WAIT_RESOURCE_MYSQL_BACKUP       = "mysql-backup"
+	WAIT_RESOURCE_MARIADB            = "mariadb"
+	WAIT_RESOURCE_MARIADB_FLOW       = "mariadb-flow"
+	WAIT_RESOURCE_REDIS              = "redis"
+)
+
+var defaultWaitTimeouts = map[string]time.Duration{
+	WAIT_RESOURCE_VM:                 10 * time.Minute,
+	WAIT_RESOURCE_VPC:                2 * time.Minute,
+	WAIT_RESOURCE_DISK:               5 * time.Minute,
+	WAIT_RESOURCE_ELASTIC_NIC:        5 * time.Minute,
+	WAIT_RESOURCE_EIP:                5 * time.Minute,
+	WAIT_RESOURCE_NAT_GATEWAY:        10 * time.Minute,
+	WAIT_RESOURCE_PEERING_CONNECTION: 5 * time.Minute,
+	WAIT_RESOURCE_CLB:                5 * time.Minute,
+	WAIT_RESOURCE_CLB_TASK:           5 * time.Minute,
+	WAIT_RESOURCE_MYSQL:              30 * time.Minute,
+	WAIT_RESOURCE_MYSQL_TASK:         10 * time.Minute,
+	WAIT_RESOURCE_MYSQL_BACKUP:       10 * time.Minute,
+	WAIT_RESOURCE_MARIADB:            30 * time.Minute,
+	WAIT_RESOURCE_MARIADB_FLOW:       10 * time.Minute,
+	WAIT_RESOURCE_REDIS:              30 * time.Minute,
+}
+
+// WaitPolicy is how often and how long actions wait for resources. Zero fields fall back to the defaults.
+type WaitPolicy struct {
+	// Interval before the second check, the first check is done immediately.
+	Interval    time.Duration
+	MaxInterval time.Duration
+	// Backoff multiplies the interval after each check, 1 keeps the interval fixed.
+	Backoff float64
+	// Timeouts overrides the default timeouts of resources, the key is the resource such as "vm".
+	Timeouts map[string]time.Duration
+}
+
+var (
+	waitPolicyMutex   sync.RWMutex
+	DefaultWaitPolicy = &WaitPolicy{}
+)
+
+// SetWaitPolicy replaces DefaultWaitPolicy, waiters created afterwards use the new policy, nil restores the default one.
+func SetWaitPolicy(policy *WaitPolicy) {
+	if policy == nil {
+		policy = &WaitPolicy{}
+	}
+	waitPolicyMutex.Lock()
+	DefaultWaitPolicy = policy
+	waitPolicyMutex.Unlock()
+}
+
+func getWaitPolicy() *WaitPolicy {
+	waitPolicyMutex.RLock()
+	defer waitPolicyMutex.RUnlock()
+
+	return DefaultWaitPolicy
+}
+
+func (policy *WaitPolicy) GetTimeout(resource string) time.Duration {
+	if timeout, found := policy.Timeouts[resource]; found && timeout > 0 {
+		return timeout
+	}
+	if timeout, found := defaultWaitTimeouts[resource]; found {
+		return timeout
+	}
+	return DEFAULT_WAIT_TIMEOUT
+}
+
+// WaitParameter is embedded in the inputs of actions which wait for resources,
+// wait_timeout overrides the default timeout of the resource in seconds.
+type WaitParameter struct {
+	WaitTimeout string `json:"wait_timeout,omitempty"`
+}
+
+// WaitCondition checks the resource once, it returns the current state of the resource
+// and whether the resource has reached the desired state.
+type WaitCondition func() (state string, done bool, err error)
+
+// Waiter checks a resource until it reaches the desired state, reaches a failure state,
+// the timeout expires or the context is done.
+type Waiter struct {
+	Resource      string
+	Id            string
+	Timeout       time.Duration
+	Interval      time.Duration
+	MaxInterval   time.Duration
+	Backoff       float64
+	FailureStates []string
+}
+
+// NewWaiter returns the waiter of the resource with DefaultWaitPolicy, timeout is the wait_timeout of
+// the input in seconds, the default timeout of the resource is used if it is empty.
+func NewWaiter(resource, id string, timeout string) (*Waiter, error) {
+	policy := getWaitPolicy()
+	waiter := &Waiter{
+		Resource:    resource,
+		Id:          id,
+		Timeout:     policy.GetTimeout(resource),
+		Interval:    policy.Interval,
+		MaxInterval: policy.MaxInterval,
+		Backoff:     policy.Backoff,
+	}
+	if waiter.Interval <= 0 {
+		waiter.Interval = DEFAULT_WAIT_INTERVAL
+	}
+	if waiter.MaxInterval <= 0 {
+		waiter.MaxInterval = DEFAULT_WAIT_MAX_INTERVAL
+	}
+	if waiter.Backoff < 1 {
+		waiter.Backoff = DEFAULT_WAIT_BACKOFF
+	}
+
+	if timeout != "" {
+		seconds, err := strconv.Atoi(timeout)
+		if err != nil || seconds <= 0 {
+			return nil, NewValidationError("wait_timeout(%v) is not a positive integer", timeout)
+		}
+		waiter.Timeout = time.Duration(seconds) * time.Second
+	}
+	return waiter, nil
+}
+
+// WithFailureStates sets the states from which the resource never reaches the desired state.
+func (waiter *Waiter) WithFailureStates(states ...string) *Waiter {
+	waiter.FailureStates = states
+	return waiter
+}
+
+func (waiter *Waiter) isFailureState(state string) bool {
+	for _, failureState := range waiter.FailureStates {
+		if state == failureState {
+			return true
+		}
+	}
+	return false
+}
+
+// Wait checks the resource with condition until the wait ends, the wait is traced as a span whose ctx is used by
+// the logs of the wait. The condition calls the cloud APIs with the clients of its caller, so their spans are the
+// children of the span of the caller, which is usually the input, and the siblings of the span of the wait.
+func (waiter *Waiter) Wait(ctx context.Context, condition WaitCondition) (err error) {
+	start := time.Now()
+	result := WAIT_RESULT_ERROR
+	ctx, span := StartSpan(ctx, "wait "+waiter.Resource, SPAN_KIND_INTERNAL)
+	span.SetAttribute("wait.resource", waiter.Resource)
+	span.SetAttribute("wait.id", waiter.Id)
+	checks := 0
+	defer func() {
+		observeWait(waiter.Resource, time.Since(start), result)
+		span.SetAttribute("wait.result", result)
+		span.SetAttribute("wait.checks", checks)
+		span.End(err)
+	}()
+
+	deadline := start.Add(waiter.Timeout)
+	interval := waiter.Interval
+	state := ""
+
+	for check := 1; ; check++ {
+		checks = check
+		var done bool
+		state, done, err = condition()
+		if err != nil {
+			Logger(ctx).Errorf("wait %v(%v) meet error=%v, check=%v, elapsed=%v", waiter.Resource, waiter.Id, err, check, time.Since(start))
+			return err
+		}
+		if done {
+			result = WAIT_RESULT_DONE
+			Logger(ctx).Infof("wait %v(%v) done, state=%v, check=%v, elapsed=%v", waiter.Resource, waiter.Id, state, check, time.Since(start))
+			return nil
+		}
+		if waiter.isFailureState(state) {
+			result = WAIT_RESULT_FAILED
+			return fmt.Errorf("%v(%v) is in failure state %v", waiter.Resource, waiter.Id, state)
+		}
+
+		// the last check is at the deadline, the resource may reach the state between the checks.
+		remaining := time.Until(deadline)
+		if remaining <= 0 {
+			result = WAIT_RESULT_TIMEOUT
+			return newPluginError(ERROR_CATEGORY_TIMEOUT, ERROR_CODE_WAIT_TIMEOUT, true, "wait %v(%v) timeout after %v, state=%v",
+				waiter.Resource, waiter.Id, waiter.Timeout, state)
+		}
+		sleep := interval
+		if sleep > remaining {
+			sleep = remaining
+		}
+		Logger(ctx).Infof("waiting %v(%v), state=%v, check=%v, elapsed=%v, next check in %v",
+			waiter.Resource, waiter.Id, state, check, time.Since(start), sleep)
+
+		timer := time.NewTimer(sleep)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			result = WAIT_RESULT_CANCELED
+			return fmt.Errorf("wait %v(%v) is canceled, state=%v, error=%v", waiter.Resource, waiter.Id, state, ctx.Err())
+		case <-timer.C:
+		}
+
+		interval = time.Duration(float64(interval) * waiter.Backoff)
+		if interval > waiter.MaxInterval {
+			interval = waiter.MaxInterval
+		}
+	}
+}
@@ -0,0 +1,142 @@
+package plugins
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newTestWaiter(timeout time.Duration) *Waiter {
+	return &Waiter{
+		Resource:    WAIT_RESOURCE_VM,
+		Id:          "ins-00000001",
+		Timeout:     timeout,
+		Interval:    time.Millisecond,
+		MaxInterval: 4 * time.Millisecond,
+		Backoff:     2,
+	}
+}
+
+func TestWaiterDoneAtFirstCheck(t *testing.T) {
+	checks := 0
+	err := newTestWaiter(time.Second).Wait(context.Background(), func() (string, bool, error) {
+		checks++
+		return INSTANCE_STATE_RUNNING, true, nil
+	})
+	if err != nil || checks != 1 {
+		t.Errorf("checks=%v, error=%v", checks, err)
+	}
+}
+
+func TestWaiterDoneAfterChecks(t *testing.T) {
+	checks := 0
+	err := newTestWaiter(time.Second).Wait(context.Background(), func() (string, bool, error) {
+		checks++
+		return "PENDING", checks == 5, nil
+	})
+	if err != nil || checks != 5 {
+		t.Errorf("checks=%v, error=%v", checks, err)
+	}
+}
+
+func TestWaiterStopsOnErrorAndFailureState(t *testing.T) {
+	conditionErr := fmt.Errorf("instance not found")
+	err := newTestWaiter(time.Second).Wait(context.Background(), func() (string, bool, error) {
+		return "", false, conditionErr
+	})
+	if err != conditionErr {
+		t.Errorf("error=%v, expected the error of the condition", err)
+	}
+
+	checks := 0
+	err = newTestWaiter(time.Second).WithFailureStates(INSTANCE_STATE_LAUNCH_FAILED).Wait(context.Background(), func() (string, bool, error) {
+		checks++
+		if checks == 2 {
+			return INSTANCE_STATE_LAUNCH_FAILED, false, nil
+		}
+		return "PENDING", false, nil
+	})
+	if err == nil || !strings.Contains(err.Error(), "failure state "+INSTANCE_STATE_LAUNCH_FAILED) || checks != 2 {
+		t.Errorf("checks=%v, error=%v", checks, err)
+	}
+}
+
+func TestWaiterTimeout(t *testing.T) {
+	start := time.Now()
+	err := newTestWaiter(20*time.Millisecond).Wait(context.Background(), func() (string, bool, error) {
+		return "PENDING", false, nil
+	})
+	if err == nil || !strings.Contains(err.Error(), "timeout") {
+		t.Errorf("error=%v, expected timeout", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("timeout after %v", elapsed)
+	}
+}
+
+func TestWaiterChecksAtDeadline(t *testing.T) {
+	waiter := &Waiter{Resource: WAIT_RESOURCE_VM, Id: "ins-00000001", Timeout: 50 * time.Millisecond, Interval: time.Second, MaxInterval: time.Second, Backoff: 1}
+	start := time.Now()
+	checks := 0
+	err := waiter.Wait(context.Background(), func() (string, bool, error) {
+		checks++
+		return "PENDING", checks == 2, nil
+	})
+	if elapsed := time.Since(start); err != nil || checks != 2 || elapsed < 50*time.Millisecond || elapsed > 500*time.Millisecond {
+		t.Errorf("checks=%v, elapsed=%v, error=%v", checks, elapsed, err)
+	}
+
+	start, checks = time.Now(), 0
+	err = waiter.Wait(context.Background(), func() (string, bool, error) {
+		checks++
+		return "PENDING", false, nil
+	})
+	if elapsed := time.Since(start); err == nil || !strings.Contains(err.Error(), "timeout") || checks != 2 || elapsed > 500*time.Millisecond {
+		t.Errorf("checks=%v, elapsed=%v, error=%v", checks, elapsed, err)
+	}
+}
+
+func TestWaiterCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	checks := 0
+	err := newTestWaiter(time.Minute).Wait(ctx, func() (string, bool, error) {
+		checks++
+		if checks == 3 {
+			cancel()
+		}
+		return "PENDING", false, nil
+	})
+	if err == nil || !strings.Contains(err.Error(), "canceled") || checks != 3 {
+		t.Errorf("checks=%v, error=%v", checks, err)
+	}
+}
+
+func TestNewWaiterTimeout(t *testing.T) {
+	defer func(policy *WaitPolicy) { DefaultWaitPolicy = policy }(DefaultWaitPolicy)
+	DefaultWaitPolicy = &WaitPolicy{Timeouts: map[string]time.Duration{WAIT_RESOURCE_VPC: time.Minute}}
+
+	cases := []struct {
+		resource string
+		timeout  string
+		expected time.Duration
+	}{
+		{WAIT_RESOURCE_VM, "", 10 * time.Minute},
+		{WAIT_RESOURCE_VPC, "", time.Minute},
+		{"unknown", "", DEFAULT_WAIT_TIMEOUT},
+		{WAIT_RESOURCE_VM, "30", 30 * time.Second},
+	}
+	for _, c := range cases {
+		waiter, err := NewWaiter(c.resource, "id", c.timeout)
+		if err != nil || waiter.Timeout != c.expected {
+			t.Errorf("resource=%v, wait_timeout=%v, waiter=%+v, error=%v", c.resource, c.timeout, waiter, err)
+		}
+	}
+
+	for _, timeout := range []string{"abc", "0", "-1"} {
+		if _, err := NewWaiter(WAIT_RESOURCE_VM, "id", timeout); err == nil {
+			t.Errorf("wait_timeout=%v, expected error", timeout)
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package router
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/WeBankPartners/wecube-plugins-qcloud/plugins"
+)
+
+const AUDIT_PATH = "/" + plugins.PROVIDER_NAME + "/" + plugins.VERSION + "/audit"
+
+// auditDispatcher returns the records of the audit journal filtered by the query parameters guid, resourceId,
+// start and end in RFC3339, and limit. It is 404 if the journal is not enabled.
+func auditDispatcher(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", http.MethodGet)
+		writeError(w, http.StatusMethodNotAllowed, &plugins.PluginError{
+			Category: plugins.ERROR_CATEGORY_VALIDATION,
+			Code:     plugins.ERROR_CODE_METHOD_NOT_ALLOWED,
+			Message:  fmt.Sprintf("method[%s] is not allowed, use GET", r.Method),
+		})
+		return
+	}
+	journal := plugins.DefaultAuditJournal
+	if journal == nil {
+		writeError(w, http.StatusNotFound, &plugins.PluginError{
+			Category: plugins.ERROR_CATEGORY_NOT_FOUND,
+			Code:     plugins.ERROR_CODE_AUDIT_DISABLED,
+			Message:  "audit journal is not enabled, set audit_journal_file",
+		})
+		return
+	}
+
+	query := r.URL.Query()
+	filter := plugins.AuditFilter{Guid: query.Get("guid"), ResourceId: query.Get("resourceId")}
+	var err error
+	if filter.StartTime, err = parseAuditTime(query.Get("start")); err != nil {
+		writeError(w, http.StatusBadRequest, plugins.NewValidationError("start[%s] is not RFC3339 time", query.Get("start")))
+		return
+	}
+	if filter.EndTime, err = parseAuditTime(query.Get("end")); err != nil {
+		writeError(w, http.StatusBadRequest, plugins.NewValidationError("end[%s] is not RFC3339 time", query.Get("end")))
+		return
+	}
+	if limit := query.Get("limit"); limit != "" {
+		if filter.Limit, err = strconv.Atoi(limit); err != nil || filter.Limit <= 0 {
+			writeError(w, http.StatusBadRequest, plugins.NewValidationError("limit[%s] is not a positive integer", limit))
+			return
+		}
+	}
+
+	records, err := journal.Query(filter)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, &plugins.PluginError{
+			Category: plugins.ERROR_CATEGORY_INTERNAL,
+			Code:     plugins.ERROR_CODE_INTERNAL,
+			Message:  fmt.Sprintf("query audit journal meet error=%v", err),
+		})
+		return
+	}
+	write(w, &plugins.PluginResponse{ResultCode: plugins.RESULT_CODE_SUCCESS, ResultMsg: "success", Results: records})
+}
+
+func parseAuditTime(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	return time.Parse(time.RFC3339, value)
+}
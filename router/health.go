@@ -0,0 +1,126 @@
+package router
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/WeBankPartners/wecube-plugins-qcloud/plugins"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	HEALTH_PATH  = "/healthz"
+	READY_PATH   = "/readyz"
+	METRICS_PATH = "/metrics"
+	CATALOG_PATH = "/" + plugins.PROVIDER_NAME + "/" + plugins.VERSION + "/catalog"
+	OPENAPI_PATH = "/" + plugins.PROVIDER_NAME + "/" + plugins.VERSION + "/openapi.json"
+
+	HEALTH_STATUS_OK          = "ok"
+	HEALTH_STATUS_UNAVAILABLE = "unavailable"
+
+	// the checks of /readyz are canceled after the timeout, so a slow cloud API does not block the probe.
+	READINESS_CHECK_TIMEOUT = 10 * time.Second
+)
+
+// ReadinessCheck returns an error if the plugin is not ready to run actions.
+type ReadinessCheck func(ctx context.Context) error
+
+type CheckResult struct {
+	Name    string `json:"name"`
+	Ready   bool   `json:"ready"`
+	Message string `json:"message,omitempty"`
+}
+
+type HealthResponse struct {
+	Status string        `json:"status"`
+	Checks []CheckResult `json:"checks,omitempty"`
+}
+
+var (
+	readinessChecksMutex sync.Mutex
+	readinessChecks      = make(map[string]ReadinessCheck)
+)
+
+// SetReadinessCheck adds the check run by /readyz, nil removes it.
+func SetReadinessCheck(name string, check ReadinessCheck) {
+	readinessChecksMutex.Lock()
+	defer readinessChecksMutex.Unlock()
+
+	if check == nil {
+		delete(readinessChecks, name)
+		return
+	}
+	readinessChecks[name] = check
+}
+
+// healthDispatcher tells the process is alive, it checks nothing else.
+func healthDispatcher(w http.ResponseWriter, r *http.Request) {
+	writeHealth(w, &HealthResponse{Status: HEALTH_STATUS_OK})
+}
+
+// readyDispatcher runs the readiness checks by name, the status is unavailable if any of them fails.
+func readyDispatcher(w http.ResponseWriter, r *http.Request) {
+	readinessChecksMutex.Lock()
+	names := []string{}
+	checks := make(map[string]ReadinessCheck)
+	for name, check := range readinessChecks {
+		names = append(names, name)
+		checks[name] = check
+	}
+	readinessChecksMutex.Unlock()
+	sort.Strings(names)
+
+	ctx, cancel := context.WithTimeout(r.Context(), READINESS_CHECK_TIMEOUT)
+	defer cancel()
+
+	response := &HealthResponse{Status: HEALTH_STATUS_OK, Checks: []CheckResult{}}
+	for _, name := range names {
+		result := CheckResult{Name: name, Ready: true}
+		if err := checks[name](ctx); err != nil {
+			logrus.Warnf("readiness check %v meet error=%v", name, err)
+			result.Ready = false
+			result.Message = plugins.MaskSecretsInText(err.Error())
+			response.Status = HEALTH_STATUS_UNAVAILABLE
+		}
+		response.Checks = append(response.Checks, result)
+	}
+	writeHealth(w, response)
+}
+
+func writeHealth(w http.ResponseWriter, response *HealthResponse) {
+	w.Header().Set("content-type", "application/json")
+	if response.Status != HEALTH_STATUS_OK {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	b, err := json.Marshal(response)
+	if err != nil {
+		logrus.Errorf("write http response (%v) meet error (%v)", response, err)
+	}
+	w.Write(b)
+}
+
+// catalogDispatcher lists the registered plugins and actions with the fields of their inputs and outputs.
+func catalogDispatcher(w http.ResponseWriter, r *http.Request) {
+	write(w, &plugins.PluginResponse{ResultCode: plugins.RESULT_CODE_SUCCESS, ResultMsg: "success", Results: plugins.GetCatalog()})
+}
+
+// openApiDispatcher writes the OpenAPI 3 document of the actions, which is the same as openapi.json of the builds.
+func openApiDispatcher(w http.ResponseWriter, r *http.Request) {
+	b, err := plugins.GetOpenApiJson()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	w.Header().Set("content-type", "application/json")
+	w.Write(b)
+}
+
+// metricsDispatcher writes the metrics of plugin requests, inputs, cloud API requests and waits in the text format of prometheus.
+func metricsDispatcher(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("content-type", plugins.METRICS_CONTENT_TYPE)
+	plugins.WriteMetrics(w)
+}
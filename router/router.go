@@ -0,0 +1,224 @@
+package router
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"runtime/debug"
+	"strings"
+	"sync/atomic"
+
+	"github.com/WeBankPartners/wecube-plugins-qcloud/plugins"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	PLUGIN_PATH_PREFIX = "/" + plugins.PROVIDER_NAME + "/" + plugins.VERSION + "/"
+	TASK_PATH_PREFIX   = PLUGIN_PATH_PREFIX + "tasks/"
+	TASK_RESULT_SUFFIX = "/result"
+
+	DEFAULT_MAX_REQUEST_BODY_SIZE = 10 << 20
+)
+
+// maxRequestBodySize is the max bytes of the body of plugin requests, larger requests are rejected with 413.
+var maxRequestBodySize int64 = DEFAULT_MAX_REQUEST_BODY_SIZE
+
+// SetMaxRequestBodySize sets the max bytes of the body of plugin requests, zero or less restores the default.
+func SetMaxRequestBodySize(size int64) {
+	if size <= 0 {
+		size = DEFAULT_MAX_REQUEST_BODY_SIZE
+	}
+	atomic.StoreInt64(&maxRequestBodySize, size)
+}
+
+// InitRouter registers the plugin, task, catalog, openapi, health, metrics and audit handlers on the mux,
+// the panics of the handlers are written as internal errors.
+func InitRouter(mux *http.ServeMux) {
+	mux.HandleFunc("/", withRecovery(notFoundDispatcher))
+	//path should be defined as "/[package name]/[version]/[plugin]/[action]"
+	mux.HandleFunc(PLUGIN_PATH_PREFIX, withRecovery(routeDispatcher))
+	mux.HandleFunc(TASK_PATH_PREFIX, withRecovery(taskDispatcher))
+	mux.HandleFunc(CATALOG_PATH, withRecovery(catalogDispatcher))
+	mux.HandleFunc(OPENAPI_PATH, withRecovery(openApiDispatcher))
+	mux.HandleFunc(HEALTH_PATH, withRecovery(healthDispatcher))
+	mux.HandleFunc(READY_PATH, withRecovery(readyDispatcher))
+	mux.HandleFunc(METRICS_PATH, withRecovery(metricsDispatcher))
+	mux.HandleFunc(AUDIT_PATH, withRecovery(auditDispatcher))
+}
+
+func withRecovery(handler http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		defer func() {
+			if p := recover(); p != nil {
+				logrus.Errorf("handle %v %v meet panic: %v\n%s", r.Method, r.URL.Path, p, debug.Stack())
+				writeError(w, http.StatusInternalServerError, &plugins.PluginError{
+					Category: plugins.ERROR_CATEGORY_INTERNAL,
+					Code:     plugins.ERROR_CODE_INTERNAL,
+					Message:  plugins.MaskSecretsInText(fmt.Sprintf("internal error: %v", p)),
+				})
+			}
+		}()
+		handler(w, r)
+	}
+}
+
+func notFoundDispatcher(w http.ResponseWriter, r *http.Request) {
+	writeError(w, http.StatusNotFound, &plugins.PluginError{
+		Category: plugins.ERROR_CATEGORY_NOT_FOUND,
+		Code:     plugins.ERROR_CODE_PATH_NOT_FOUND,
+		Message:  fmt.Sprintf("path[%s] not found", r.URL.Path),
+	})
+}
+
+// routeDispatcher runs the action of "/[package name]/[version]/[plugin]/[action]". Requests of unknown
+// plugins or actions are rejected with 404, other methods than POST with 405, bodies which are not json
+// with 415 and too large bodies with 413. The errors of actions are written with 200 as before.
+// The correlation id of the request is returned in the header of all the responses.
+func routeDispatcher(w http.ResponseWriter, r *http.Request) {
+	ctx := plugins.ContextWithCorrelationId(r.Context(), r.Header.Get(plugins.CORRELATION_ID_HEADER))
+	w.Header().Set(plugins.CORRELATION_ID_HEADER, plugins.GetCorrelationId(ctx))
+	pluginName, actionName, ok := parsePluginPath(r.URL.Path)
+	if !ok {
+		notFoundDispatcher(w, r)
+		return
+	}
+	if _, err := plugins.GetAction(pluginName, actionName); err != nil {
+		writeError(w, http.StatusNotFound, err)
+		return
+	}
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		writeError(w, http.StatusMethodNotAllowed, &plugins.PluginError{
+			Category: plugins.ERROR_CATEGORY_VALIDATION,
+			Code:     plugins.ERROR_CODE_METHOD_NOT_ALLOWED,
+			Message:  fmt.Sprintf("method[%s] is not allowed, use POST", r.Method),
+		})
+		return
+	}
+	if mediaType, _, err := mime.ParseMediaType(r.Header.Get("content-type")); err != nil || mediaType != "application/json" {
+		writeError(w, http.StatusUnsupportedMediaType, &plugins.PluginError{
+			Category: plugins.ERROR_CATEGORY_VALIDATION,
+			Code:     plugins.ERROR_CODE_UNSUPPORTED_MEDIA_TYPE,
+			Message:  fmt.Sprintf("content-type[%s] is not supported, use application/json", r.Header.Get("content-type")),
+		})
+		return
+	}
+	body, err := readBody(r)
+	if err != nil {
+		status := http.StatusBadRequest
+		if pluginErr, ok := err.(*plugins.PluginError); ok && pluginErr.Code == plugins.ERROR_CODE_REQUEST_TOO_LARGE {
+			status = http.StatusRequestEntityTooLarge
+		}
+		writeError(w, status, err)
+		return
+	}
+
+	pluginRequest := &plugins.PluginRequest{
+		ProviderName: plugins.PROVIDER_NAME,
+		Version:      plugins.VERSION,
+		Name:         pluginName,
+		Action:       actionName,
+		Parameters:   bytes.NewReader(body),
+		Async:        strings.EqualFold(r.URL.Query().Get("async"), "true"),
+		DryRun:       strings.EqualFold(r.URL.Query().Get("dry_run"), "true"),
+	}
+	plugins.Logger(ctx).Infof("parsed request of plugin[%v]-action[%v], async=%v, dry run=%v", pluginName, actionName, pluginRequest.Async, pluginRequest.DryRun)
+	// the spans of the request are the children of the span of the platform if it sends the trace context.
+	ctx = plugins.ContextWithTraceParent(ctx, r.Header.Get(plugins.TRACEPARENT_HEADER))
+	pluginResponse, _ := plugins.Process(ctx, pluginRequest)
+	plugins.Logger(ctx).Infof("write data to client response=%s", plugins.Sanitize(pluginResponse))
+	write(w, pluginResponse)
+}
+
+// parsePluginPath returns the plugin and action of "/[package name]/[version]/[plugin]/[action]".
+func parsePluginPath(path string) (string, string, bool) {
+	pathStrings := strings.Split(strings.TrimPrefix(path, PLUGIN_PATH_PREFIX), "/")
+	if len(pathStrings) != 2 || pathStrings[0] == "" || pathStrings[1] == "" {
+		return "", "", false
+	}
+	return pathStrings[0], pathStrings[1], true
+}
+
+// readBody reads the body of the request up to the max size, the error is a plugin error.
+func readBody(r *http.Request) ([]byte, error) {
+	maxSize := atomic.LoadInt64(&maxRequestBodySize)
+	tooLargeErr := &plugins.PluginError{
+		Category: plugins.ERROR_CATEGORY_VALIDATION,
+		Code:     plugins.ERROR_CODE_REQUEST_TOO_LARGE,
+		Message:  fmt.Sprintf("request body is larger than %d bytes", maxSize),
+	}
+	if r.ContentLength > maxSize {
+		return nil, tooLargeErr
+	}
+	body, err := ioutil.ReadAll(io.LimitReader(r.Body, maxSize+1))
+	if err != nil {
+		return nil, plugins.NewValidationError("read request body meet error=%v", err)
+	}
+	if int64(len(body)) > maxSize {
+		return nil, tooLargeErr
+	}
+	return body, nil
+}
+
+func write(w http.ResponseWriter, output *plugins.PluginResponse) {
+	writeStatus(w, http.StatusOK, output)
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	logrus.Warnf("reject request with status %d, err=%v", status, err)
+	writeStatus(w, status, plugins.NewErrorResponse(err))
+}
+
+func writeStatus(w http.ResponseWriter, status int, output *plugins.PluginResponse) {
+	w.Header().Set("content-type", "application/json")
+	b, err := json.Marshal(output)
+	if err != nil {
+		logrus.Errorf("write http response (%v) meet error (%v)", output, err)
+	}
+	w.WriteHeader(status)
+	w.Write(b)
+}
+
+//task path should be "/[package name]/[version]/tasks/[task id]" or "/[package name]/[version]/tasks/[task id]/result"
+//tasks not exist are rejected with 404, and other methods than GET with 405.
+func taskDispatcher(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", http.MethodGet)
+		writeError(w, http.StatusMethodNotAllowed, &plugins.PluginError{
+			Category: plugins.ERROR_CATEGORY_VALIDATION,
+			Code:     plugins.ERROR_CODE_METHOD_NOT_ALLOWED,
+			Message:  fmt.Sprintf("method[%s] is not allowed, use GET", r.Method),
+		})
+		return
+	}
+	taskId := strings.TrimPrefix(r.URL.Path, TASK_PATH_PREFIX)
+	wantResult := strings.HasSuffix(taskId, TASK_RESULT_SUFFIX)
+	taskId = strings.TrimSuffix(taskId, TASK_RESULT_SUFFIX)
+
+	task, err := plugins.GetTaskById(taskId)
+	if err != nil {
+		writeError(w, http.StatusNotFound, err)
+		return
+	}
+
+	snapshot := task.Snapshot()
+	if !wantResult {
+		write(w, &plugins.PluginResponse{ResultCode: plugins.RESULT_CODE_SUCCESS, ResultMsg: "success", Results: snapshot})
+		return
+	}
+
+	if snapshot.Response == nil {
+		write(w, plugins.NewErrorResponse(&plugins.PluginError{
+			Category:  plugins.ERROR_CATEGORY_CONFLICT,
+			Code:      plugins.ERROR_CODE_IN_PROGRESS,
+			Message:   fmt.Sprintf("task[%s] is %s, result is not ready", taskId, snapshot.Status),
+			Retryable: true,
+		}))
+		return
+	}
+	write(w, snapshot.Response)
+}
@@ -6,53 +6,119 @@ import (
 	"io"
 	"io/ioutil"
 	"net/http"
+	"net/http/httptest"
 	"strings"
+	"testing"
+	"time"
 
+	"github.com/WeBankPartners/wecube-plugins-qcloud/plugins"
+	"github.com/WeBankPartners/wecube-plugins-qcloud/router"
+	"github.com/WeBankPartners/wecube-plugins-qcloud/test_fixtures/fake_qcloud"
 	"github.com/sirupsen/logrus"
 )
 
 const (
-	SECRET_ID       = "Your Qcloud Secret Id"
-	SECRET_KEY      = "Your Qcloud Secret Key"
-	PLUGIN_HOST_URL = "Your Plugin Host IP"
+	SECRET_ID  = "fake-secret-id"
+	SECRET_KEY = "fake-secret-key"
+	SEED       = "fake-seed"
 )
 
-var resourceIds = make(map[string]string)
-
 type Outputs struct {
 	Outputs []Output `json:"outputs,omitempty"`
 }
 
 type Output struct {
-	RequestId string `json:"request_id,omitempty"`
-	Guid      string `json:"guid,omitempty"`
-	Id        string `json:"id,omitempty"`
+	RequestId         string `json:"request_id,omitempty"`
+	Guid              string `json:"guid,omitempty"`
+	Id                string `json:"id,omitempty"`
+	CallbackParameter string `json:"callbackParameter,omitempty"`
+	ErrorCode         string `json:"errorCode,omitempty"`
+	ErrorMessage      string `json:"errorMessage,omitempty"`
+}
+
+type PlannedCall struct {
+	Guid    string `json:"guid,omitempty"`
+	Service string `json:"service"`
+	Action  string `json:"action"`
+	Checked bool   `json:"checked"`
+}
+
+type Plan struct {
+	Calls []PlannedCall `json:"calls"`
 }
 
 type PluginResponse struct {
-	ResultCode string  `json:"result_code"`
-	ResultMsg  string  `json:"result_message"`
+	ResultCode string  `json:"resultCode"`
+	ResultMsg  string  `json:"resultMessage"`
 	Results    Outputs `json:"results"`
+	Plan       *Plan   `json:"plan,omitempty"`
+}
+
+// FakeEnv is a plugin host served by the router in process, the plugins of which call
+// the fake qcloud server instead of the real cloud.
+type FakeEnv struct {
+	Qcloud     *fake_qcloud.Server
+	pluginHost *httptest.Server
+	oldFactory *plugins.ClientFactory
+	oldWait    *plugins.WaitPolicy
+}
+
+func NewFakeEnv(t *testing.T) *FakeEnv {
+	if testing.Short() {
+		t.Skip("skip the integration test in short mode")
+	}
+
+	env := &FakeEnv{
+		Qcloud:     fake_qcloud.NewServer(map[string]string{SECRET_ID: SECRET_KEY}),
+		oldFactory: plugins.GetClientFactory(),
+		oldWait:    plugins.DefaultWaitPolicy,
+	}
+	env.Qcloud.Start()
+	plugins.SetClientFactory(&plugins.ClientFactory{Scheme: "http", Endpoints: env.Qcloud.Endpoints()})
+	// the fake resources change their state after being polled, there is no need to wait long.
+	plugins.DefaultWaitPolicy = &plugins.WaitPolicy{Interval: 10 * time.Millisecond, MaxInterval: 50 * time.Millisecond}
+
+	mux := http.NewServeMux()
+	router.InitRouter(mux)
+	env.pluginHost = httptest.NewServer(mux)
+	return env
 }
 
-func CallPlugin(name, action, input string) map[string]string {
-	output, err := http.Post(PLUGIN_HOST_URL+"/v1/qcloud/"+name+"/"+action, "application/json", strings.NewReader(input))
+func (env *FakeEnv) Close() {
+	env.pluginHost.Close()
+	env.Qcloud.Close()
+	plugins.SetClientFactory(env.oldFactory)
+	plugins.DefaultWaitPolicy = env.oldWait
+}
+
+// ProviderParams returns the provider params of the region and zone with the fake credential.
+func ProviderParams(region, zone string) string {
+	return fmt.Sprintf("Region=%s;AvailableZone=%s;SecretID=%s;SecretKey=%s", region, zone, SECRET_ID, SECRET_KEY)
+}
 
+// PostPlugin calls the action of the plugin and returns the response whatever the result is.
+func (env *FakeEnv) PostPlugin(t *testing.T, name, action, input string) PluginResponse {
+	t.Helper()
+	output, err := http.Post(env.pluginHost.URL+"/"+plugins.PROVIDER_NAME+"/"+plugins.VERSION+"/"+name+"/"+action, "application/json", strings.NewReader(input))
 	if err != nil {
-		logrus.Errorf("call plugin server meet error = %v", err)
-		//panic("failed")
+		t.Fatalf("call plugin server meet error = %v", err)
 	}
+	defer output.Body.Close()
 
 	pluginResponse := PluginResponse{}
-	err = UnmarshalJson(output.Body, &pluginResponse)
-	if err != nil {
-		logrus.Errorf("unmarshal plugin response meet error = %v", err)
-		//panic("failed")
+	if err = UnmarshalJson(output.Body, &pluginResponse); err != nil {
+		t.Fatalf("unmarshal plugin response meet error = %v", err)
 	}
+	return pluginResponse
+}
 
-	if pluginResponse.ResultCode == "1" {
-		logrus.Errorf("call plugin meet error = %v", pluginResponse.ResultMsg)
-		//panic("failed")
+// CallPlugin calls the action of the plugin and returns the ids of the outputs keyed by guid,
+// the test fails if the action does not succeed.
+func (env *FakeEnv) CallPlugin(t *testing.T, name, action, input string) map[string]string {
+	t.Helper()
+	pluginResponse := env.PostPlugin(t, name, action, input)
+	if pluginResponse.ResultCode != plugins.RESULT_CODE_SUCCESS {
+		t.Fatalf("call plugin %v action %v meet error = %v, outputs = %+v", name, action, pluginResponse.ResultMsg, pluginResponse.Results.Outputs)
 	}
 
 	outputMap := make(map[string]string)
@@ -64,6 +130,16 @@ func CallPlugin(name, action, input string) map[string]string {
 	return outputMap
 }
 
+// ExpectNoResources fails the test if the fake qcloud still has resources of the kinds.
+func (env *FakeEnv) ExpectNoResources(t *testing.T, kinds ...string) {
+	t.Helper()
+	for _, kind := range kinds {
+		if ids := env.Qcloud.ResourceIds(kind); len(ids) > 0 {
+			t.Errorf("%v resources (ids=%v) are not terminated", kind, ids)
+		}
+	}
+}
+
 func UnmarshalJson(source interface{}, target interface{}) error {
 	reader, ok := source.(io.Reader)
 	if !ok {
@@ -0,0 +1,127 @@
+package fake_qcloud
+
+import (
+	"fmt"
+
+	cam "github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/cam/v20190116"
+	"github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/common"
+)
+
+const CAM_UIN_BASE = 100000000000
+
+// camUser is a sub account, the users of CAM are global and the region of the requests is ignored.
+type camUser struct {
+	info      *cam.SubAccountInfo
+	secretId  string
+	secretKey string
+}
+
+func init() {
+	registerAction("cam", "AddUser", addCamUser)
+	registerAction("cam", "GetUser", getCamUser)
+	registerAction("cam", "ListUsers", listCamUsers)
+	registerAction("cam", "DeleteUser", deleteCamUser)
+}
+
+// addCamUser creates a sub account, the api key of which is accepted by the server when UseApi is 1.
+func addCamUser(ctx *actionContext) (map[string]interface{}, error) {
+	request := cam.NewAddUserRequest()
+	if err := ctx.decode(request); err != nil {
+		return nil, err
+	}
+	if err := requireString("Name", request.Name); err != nil {
+		return nil, err
+	}
+	server := ctx.server
+	name := *request.Name
+	if _, found := server.users[name]; found {
+		return nil, newApiError(ERROR_CODE_INVALID_VALUE+".UserNameExist", "the user (%s) already exists", name)
+	}
+
+	server.sequence++
+	uid := uint64(server.sequence)
+	user := &camUser{
+		info: &cam.SubAccountInfo{
+			Uin:          common.Uint64Ptr(CAM_UIN_BASE + uid),
+			Name:         common.StringPtr(name),
+			Uid:          common.Uint64Ptr(uid),
+			Remark:       common.StringPtr(stringValue(request.Remark)),
+			ConsoleLogin: common.Uint64Ptr(uint64Value(request.ConsoleLogin)),
+			PhoneNum:     common.StringPtr(stringValue(request.PhoneNum)),
+			CountryCode:  common.StringPtr(stringValue(request.CountryCode)),
+			Email:        common.StringPtr(stringValue(request.Email)),
+		},
+	}
+	if uint64Value(request.UseApi) == 1 {
+		user.secretId = fmt.Sprintf("AKIDfake%016x", uid)
+		user.secretKey = sha256Hex(user.secretId)[:32]
+		server.credentials[user.secretId] = user.secretKey
+	}
+	server.users[name] = user
+
+	return map[string]interface{}{
+		"Uin":       *user.info.Uin,
+		"Name":      name,
+		"Password":  stringValue(request.Password),
+		"SecretId":  user.secretId,
+		"SecretKey": user.secretKey,
+		"Uid":       uid,
+	}, nil
+}
+
+func getCamUser(ctx *actionContext) (map[string]interface{}, error) {
+	request := cam.NewGetUserRequest()
+	if err := ctx.decode(request); err != nil {
+		return nil, err
+	}
+	name := stringValue(request.Name)
+	user, found := ctx.server.users[name]
+	if !found {
+		return nil, newApiError(ERROR_CODE_RESOURCE_NOT_FOUND+".UserNotExist", "the user (%s) does not exist", name)
+	}
+	info := user.info
+	return map[string]interface{}{
+		"Uin":          *info.Uin,
+		"Name":         *info.Name,
+		"Uid":          *info.Uid,
+		"Remark":       *info.Remark,
+		"ConsoleLogin": *info.ConsoleLogin,
+		"PhoneNum":     *info.PhoneNum,
+		"CountryCode":  *info.CountryCode,
+		"Email":        *info.Email,
+	}, nil
+}
+
+func listCamUsers(ctx *actionContext) (map[string]interface{}, error) {
+	request := cam.NewListUsersRequest()
+	if err := ctx.decode(request); err != nil {
+		return nil, err
+	}
+	data := []*cam.SubAccountInfo{}
+	for _, user := range ctx.server.users {
+		data = append(data, user.info)
+	}
+	sortByField(data, func(i int) string { return *data[i].Name })
+	return map[string]interface{}{"Data": data}, nil
+}
+
+func deleteCamUser(ctx *actionContext) (map[string]interface{}, error) {
+	request := cam.NewDeleteUserRequest()
+	if err := ctx.decode(request); err != nil {
+		return nil, err
+	}
+	server := ctx.server
+	name := stringValue(request.Name)
+	user, found := server.users[name]
+	if !found {
+		return nil, newApiError(ERROR_CODE_RESOURCE_NOT_FOUND+".UserNotExist", "the user (%s) does not exist", name)
+	}
+	if user.secretId != "" {
+		if uint64Value(request.Force) != 1 {
+			return nil, newApiError(ERROR_CODE_UNSUPPORTED_OPERATION, "the user (%s) has api keys, Force should be 1", name)
+		}
+		delete(server.credentials, user.secretId)
+	}
+	delete(server.users, name)
+	return nil, nil
+}
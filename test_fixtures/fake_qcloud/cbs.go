@@ -0,0 +1,236 @@
+package fake_qcloud
+
+import (
+	"fmt"
+
+	cbs "github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/cbs/v20170312"
+	"github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/common"
+)
+
+const (
+	DISK_STATE_UNATTACHED = "UNATTACHED"
+	DISK_STATE_ATTACHING  = "ATTACHING"
+	DISK_STATE_ATTACHED   = "ATTACHED"
+	DISK_STATE_DETACHING  = "DETACHING"
+)
+
+var diskTypes = []string{"CLOUD_BASIC", "CLOUD_PREMIUM", "CLOUD_SSD"}
+
+type diskResource struct {
+	region string
+	info   *cbs.Disk
+}
+
+func init() {
+	registerAction("cbs", "CreateDisks", createDisks)
+	registerAction("cbs", "DescribeDisks", describeDisks)
+	registerAction("cbs", "AttachDisks", attachDisks)
+	registerAction("cbs", "DetachDisks", detachDisks)
+	registerAction("cbs", "TerminateDisks", terminateDisks)
+}
+
+func createDisks(ctx *actionContext) (map[string]interface{}, error) {
+	request := cbs.NewCreateDisksRequest()
+	if err := ctx.decode(request); err != nil {
+		return nil, err
+	}
+	server := ctx.server
+	if request.Placement == nil {
+		return nil, newApiError(ERROR_CODE_MISSING_PARAMETER, "the parameter Placement is missing")
+	}
+	if _, err := server.findZone(ctx.region, stringValue(request.Placement.Zone)); err != nil {
+		return nil, err
+	}
+	if !containsString(diskTypes, stringValue(request.DiskType)) {
+		return nil, newApiError(ERROR_CODE_INVALID_VALUE+".DiskType", "the DiskType (%s) is not supported", stringValue(request.DiskType))
+	}
+	chargeType := stringValue(request.DiskChargeType)
+	if chargeType != INSTANCE_CHARGE_TYPE_BY_HOUR && chargeType != INSTANCE_CHARGE_TYPE_PREPAID {
+		return nil, newApiError(ERROR_CODE_INVALID_VALUE, "the DiskChargeType (%s) is not supported", chargeType)
+	}
+	if chargeType == INSTANCE_CHARGE_TYPE_PREPAID && (request.DiskChargePrepaid == nil || uint64Value(request.DiskChargePrepaid.Period) == 0) {
+		return nil, newApiError(ERROR_CODE_MISSING_PARAMETER, "the parameter DiskChargePrepaid.Period is missing")
+	}
+	size := uint64Value(request.DiskSize)
+	if size < 10 || size > 16000 || size%10 != 0 {
+		return nil, newApiError(ERROR_CODE_INVALID_VALUE+".DiskSize", "the DiskSize (%d) should be a multiple of 10 in [10, 16000]", size)
+	}
+	count := uint64Value(request.DiskCount)
+	if count == 0 {
+		count = 1
+	}
+
+	diskIds := []string{}
+	for i := uint64(0); i < count; i++ {
+		diskId := server.newId("disk")
+		name := stringValue(request.DiskName)
+		if name == "" {
+			name = "Unnamed"
+		} else if count > 1 {
+			name = fmt.Sprintf("%s%d", name, i+1)
+		}
+		server.disks[diskId] = &diskResource{
+			region: ctx.region,
+			info: &cbs.Disk{
+				DiskId:             common.StringPtr(diskId),
+				DiskUsage:          common.StringPtr("DATA_DISK"),
+				DiskChargeType:     common.StringPtr(chargeType),
+				Portable:           common.BoolPtr(true),
+				Placement:          &cbs.Placement{Zone: request.Placement.Zone, ProjectId: common.Uint64Ptr(uint64Value(request.Placement.ProjectId))},
+				DiskName:           common.StringPtr(name),
+				DiskSize:           common.Uint64Ptr(size),
+				DiskState:          common.StringPtr(DISK_STATE_UNATTACHED),
+				DiskType:           request.DiskType,
+				Attached:           common.BoolPtr(false),
+				InstanceId:         common.StringPtr(""),
+				CreateTime:         common.StringPtr(now()),
+				DeleteWithInstance: common.BoolPtr(false),
+			},
+		}
+		diskIds = append(diskIds, diskId)
+	}
+	return map[string]interface{}{"DiskIdSet": diskIds}, nil
+}
+
+func describeDisks(ctx *actionContext) (map[string]interface{}, error) {
+	request := cbs.NewDescribeDisksRequest()
+	if err := ctx.decode(request); err != nil {
+		return nil, err
+	}
+	ids := stringValues(request.DiskIds)
+	filters, err := ctx.filters()
+	if err != nil {
+		return nil, err
+	}
+
+	server := ctx.server
+	diskSet := []*cbs.Disk{}
+	for id, resource := range server.disks {
+		if resource.region != ctx.region || (len(ids) > 0 && !containsString(ids, id)) {
+			continue
+		}
+		fields := map[string]string{
+			"disk-id":     id,
+			"disk-state":  *resource.info.DiskState,
+			"instance-id": *resource.info.InstanceId,
+			"zone":        *resource.info.Placement.Zone,
+		}
+		if !matchFilters(filters, fields) {
+			continue
+		}
+		server.poll(id)
+		if _, found := server.disks[id]; found {
+			diskSet = append(diskSet, resource.info)
+		}
+	}
+	sortByField(diskSet, func(i int) string { return *diskSet[i].DiskId })
+	return map[string]interface{}{"TotalCount": len(diskSet), "DiskSet": diskSet}, nil
+}
+
+// findDisks returns the disks of the ids, which should be in the state and have no pending change.
+func (ctx *actionContext) findDisks(ids []*string, state string) ([]*cbs.Disk, error) {
+	server := ctx.server
+	disks := []*cbs.Disk{}
+	for _, id := range stringValues(ids) {
+		resource, found := server.disks[id]
+		if !found || resource.region != ctx.region {
+			return nil, newApiError(ERROR_CODE_INVALID_VALUE+".DiskIdNotFound", "the disk (%s) does not exist", id)
+		}
+		if *resource.info.DiskState != state || server.hasPendingChanges(id) {
+			return nil, newApiError(ERROR_CODE_UNSUPPORTED_OPERATION+".DiskState", "the disk (%s) is %s, expected %s", id, *resource.info.DiskState, state)
+		}
+		disks = append(disks, resource.info)
+	}
+	if len(disks) == 0 {
+		return nil, newApiError(ERROR_CODE_MISSING_PARAMETER, "the parameter DiskIds is missing")
+	}
+	return disks, nil
+}
+
+func attachDisks(ctx *actionContext) (map[string]interface{}, error) {
+	request := cbs.NewAttachDisksRequest()
+	if err := ctx.decode(request); err != nil {
+		return nil, err
+	}
+	disks, err := ctx.findDisks(request.DiskIds, DISK_STATE_UNATTACHED)
+	if err != nil {
+		return nil, err
+	}
+	server := ctx.server
+	instanceId := stringValue(request.InstanceId)
+	instance, found := server.instances[instanceId]
+	if !found || instance.region != ctx.region {
+		return nil, newApiError(ERROR_CODE_INVALID_VALUE+".InstanceIdNotFound", "the instance (%s) does not exist", instanceId)
+	}
+	state := *instance.info.InstanceState
+	if state != INSTANCE_STATE_RUNNING && state != INSTANCE_STATE_STOPPED {
+		return nil, newApiError(ERROR_CODE_UNSUPPORTED_OPERATION+".InstanceState", "the instance (%s) is %s", instanceId, state)
+	}
+	for _, disk := range disks {
+		if *disk.Placement.Zone != *instance.info.Placement.Zone {
+			return nil, newApiError(ERROR_CODE_INVALID_VALUE, "the disk (%s) and instance (%s) are not in the same zone", *disk.DiskId, instanceId)
+		}
+	}
+
+	deleteWithInstance := request.DeleteWithInstance != nil && *request.DeleteWithInstance
+	for _, disk := range disks {
+		info := disk
+		info.DiskState = common.StringPtr(DISK_STATE_ATTACHING)
+		server.later(*info.DiskId, func() {
+			info.DiskState = common.StringPtr(DISK_STATE_ATTACHED)
+			info.Attached = common.BoolPtr(true)
+			info.InstanceId = common.StringPtr(instanceId)
+			info.DeleteWithInstance = common.BoolPtr(deleteWithInstance)
+		})
+	}
+	return nil, nil
+}
+
+func detachDisks(ctx *actionContext) (map[string]interface{}, error) {
+	request := cbs.NewDetachDisksRequest()
+	if err := ctx.decode(request); err != nil {
+		return nil, err
+	}
+	disks, err := ctx.findDisks(request.DiskIds, DISK_STATE_ATTACHED)
+	if err != nil {
+		return nil, err
+	}
+	instanceId := stringValue(request.InstanceId)
+	for _, disk := range disks {
+		if instanceId != "" && *disk.InstanceId != instanceId {
+			return nil, newApiError(ERROR_CODE_INVALID_VALUE, "the disk (%s) is not attached to instance (%s)", *disk.DiskId, instanceId)
+		}
+	}
+
+	for _, disk := range disks {
+		info := disk
+		info.DiskState = common.StringPtr(DISK_STATE_DETACHING)
+		ctx.server.later(*info.DiskId, func() {
+			info.DiskState = common.StringPtr(DISK_STATE_UNATTACHED)
+			info.Attached = common.BoolPtr(false)
+			info.InstanceId = common.StringPtr("")
+			info.DeleteWithInstance = common.BoolPtr(false)
+		})
+	}
+	return nil, nil
+}
+
+func terminateDisks(ctx *actionContext) (map[string]interface{}, error) {
+	request := cbs.NewTerminateDisksRequest()
+	if err := ctx.decode(request); err != nil {
+		return nil, err
+	}
+	disks, err := ctx.findDisks(request.DiskIds, DISK_STATE_UNATTACHED)
+	if err != nil {
+		return nil, err
+	}
+
+	server := ctx.server
+	for _, disk := range disks {
+		diskId := *disk.DiskId
+		server.later(diskId, func() {
+			delete(server.disks, diskId)
+		})
+	}
+	return nil, nil
+}
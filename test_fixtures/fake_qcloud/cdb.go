@@ -0,0 +1,424 @@
+package fake_qcloud
+
+import (
+	cdb "github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/cdb/v20170320"
+	"github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/common"
+)
+
+const (
+	CDB_STATUS_CREATING  = 0
+	CDB_STATUS_RUNNING   = 1
+	CDB_STATUS_ISOLATING = 4
+	CDB_STATUS_ISOLATED  = 5
+
+	CDB_ASYNC_REQUEST_RUNNING = "RUNNING"
+	CDB_ASYNC_REQUEST_SUCCESS = "SUCCESS"
+
+	CDB_PAY_TYPE_PREPAID  = 0
+	CDB_PAY_TYPE_POSTPAID = 1
+
+	CDB_DEFAULT_PORT = 3306
+)
+
+var cdbEngineVersions = []string{"5.5", "5.6", "5.7", "8.0"}
+
+type cdbInstance struct {
+	region   string
+	info     *cdb.InstanceInfo
+	accounts map[string]string
+}
+
+type cdbAsyncRequest struct {
+	status string
+}
+
+func init() {
+	registerAction("cdb", "CreateDBInstance", createCdbInstance)
+	registerAction("cdb", "CreateDBInstanceHour", createCdbInstanceHour)
+	registerAction("cdb", "DescribeDBInstances", describeCdbInstances)
+	registerAction("cdb", "InitDBInstances", initCdbInstances)
+	registerAction("cdb", "CreateAccounts", createCdbAccounts)
+	registerAction("cdb", "ModifyAccountPrivileges", modifyCdbAccountPrivileges)
+	registerAction("cdb", "DescribeAsyncRequestInfo", describeCdbAsyncRequestInfo)
+	registerAction("cdb", "RestartDBInstances", restartCdbInstances)
+	registerAction("cdb", "IsolateDBInstance", isolateCdbInstance)
+	registerAction("cdb", "OfflineIsolatedInstances", offlineIsolatedCdbInstances)
+}
+
+type cdbCreateParams struct {
+	goodsNum         int64
+	memory           int64
+	volume           int64
+	engineVersion    *string
+	vpcId            string
+	subnetId         string
+	zone             *string
+	instanceName     *string
+	instanceRole     *string
+	masterInstanceId *string
+	payType          int64
+}
+
+// createCdbInstances creates the instances in CREATING status, they are running after being polled.
+func createCdbInstances(ctx *actionContext, params *cdbCreateParams) (map[string]interface{}, error) {
+	server := ctx.server
+	zone, err := server.findZone(ctx.region, stringValue(params.zone))
+	if err != nil {
+		return nil, err
+	}
+	if params.memory <= 0 {
+		return nil, newApiError(ERROR_CODE_INVALID_VALUE, "the Memory (%d) is invalid", params.memory)
+	}
+	if params.volume <= 0 || params.volume%5 != 0 {
+		return nil, newApiError(ERROR_CODE_INVALID_VALUE, "the Volume (%d) should be a multiple of 5", params.volume)
+	}
+	if !containsString(cdbEngineVersions, stringValue(params.engineVersion)) {
+		return nil, newApiError(ERROR_CODE_INVALID_VALUE, "the EngineVersion (%s) is not supported", stringValue(params.engineVersion))
+	}
+	switch stringValue(params.instanceRole) {
+	case "", "master":
+	case "ro", "dr":
+		master, found := server.cdbInstances[stringValue(params.masterInstanceId)]
+		if !found || *master.info.Status != CDB_STATUS_RUNNING {
+			return nil, newApiError(ERROR_CODE_INVALID_VALUE, "the master instance (%s) is not running", stringValue(params.masterInstanceId))
+		}
+	default:
+		return nil, newApiError(ERROR_CODE_INVALID_VALUE, "the InstanceRole (%s) is not supported", stringValue(params.instanceRole))
+	}
+	if err := server.checkSubnet(ctx.region, params.vpcId, params.subnetId); err != nil {
+		return nil, err
+	}
+	goodsNum := params.goodsNum
+	if goodsNum <= 0 {
+		goodsNum = 1
+	}
+
+	instanceIds := []string{}
+	for i := int64(0); i < goodsNum; i++ {
+		instanceId := server.newId("cdb")
+		name := stringValue(params.instanceName)
+		if name == "" {
+			name = instanceId
+		}
+		info := &cdb.InstanceInfo{
+			InstanceId:    common.StringPtr(instanceId),
+			InstanceName:  common.StringPtr(name),
+			Region:        common.StringPtr(ctx.region),
+			Zone:          common.StringPtr(zone.Zone),
+			ZoneName:      common.StringPtr(zone.Name),
+			Memory:        common.Int64Ptr(params.memory),
+			Volume:        common.Int64Ptr(params.volume),
+			EngineVersion: params.engineVersion,
+			UniqVpcId:     common.StringPtr(params.vpcId),
+			UniqSubnetId:  common.StringPtr(params.subnetId),
+			Status:        common.Int64Ptr(CDB_STATUS_CREATING),
+			InitFlag:      common.Int64Ptr(0),
+			PayType:       common.Int64Ptr(params.payType),
+			Vip:           common.StringPtr(server.allocateIp(params.subnetId)),
+			Vport:         common.Int64Ptr(CDB_DEFAULT_PORT),
+			CreateTime:    common.StringPtr(now()),
+		}
+		server.cdbInstances[instanceId] = &cdbInstance{region: ctx.region, info: info, accounts: map[string]string{}}
+		server.later(instanceId, func() {
+			info.Status = common.Int64Ptr(CDB_STATUS_RUNNING)
+		})
+		instanceIds = append(instanceIds, instanceId)
+	}
+	return map[string]interface{}{"DealIds": []string{server.newId("deal")}, "InstanceIds": instanceIds}, nil
+}
+
+func createCdbInstance(ctx *actionContext) (map[string]interface{}, error) {
+	request := cdb.NewCreateDBInstanceRequest()
+	if err := ctx.decode(request); err != nil {
+		return nil, err
+	}
+	if int64Value(request.Period) <= 0 {
+		return nil, newApiError(ERROR_CODE_MISSING_PARAMETER, "the parameter Period is missing")
+	}
+	return createCdbInstances(ctx, &cdbCreateParams{
+		goodsNum:         int64Value(request.GoodsNum),
+		memory:           int64Value(request.Memory),
+		volume:           int64Value(request.Volume),
+		engineVersion:    request.EngineVersion,
+		vpcId:            stringValue(request.UniqVpcId),
+		subnetId:         stringValue(request.UniqSubnetId),
+		zone:             request.Zone,
+		instanceName:     request.InstanceName,
+		instanceRole:     request.InstanceRole,
+		masterInstanceId: request.MasterInstanceId,
+		payType:          CDB_PAY_TYPE_PREPAID,
+	})
+}
+
+func createCdbInstanceHour(ctx *actionContext) (map[string]interface{}, error) {
+	request := cdb.NewCreateDBInstanceHourRequest()
+	if err := ctx.decode(request); err != nil {
+		return nil, err
+	}
+	return createCdbInstances(ctx, &cdbCreateParams{
+		goodsNum:         int64Value(request.GoodsNum),
+		memory:           int64Value(request.Memory),
+		volume:           int64Value(request.Volume),
+		engineVersion:    request.EngineVersion,
+		vpcId:            stringValue(request.UniqVpcId),
+		subnetId:         stringValue(request.UniqSubnetId),
+		zone:             request.Zone,
+		instanceName:     request.InstanceName,
+		instanceRole:     request.InstanceRole,
+		masterInstanceId: request.MasterInstanceId,
+		payType:          CDB_PAY_TYPE_POSTPAID,
+	})
+}
+
+func describeCdbInstances(ctx *actionContext) (map[string]interface{}, error) {
+	request := cdb.NewDescribeDBInstancesRequest()
+	if err := ctx.decode(request); err != nil {
+		return nil, err
+	}
+	server := ctx.server
+	ids := stringValues(request.InstanceIds)
+	vips := stringValues(request.Vips)
+
+	items := []*cdb.InstanceInfo{}
+	for id, resource := range server.cdbInstances {
+		if resource.region != ctx.region || (len(ids) > 0 && !containsString(ids, id)) {
+			continue
+		}
+		if len(vips) > 0 && !containsString(vips, *resource.info.Vip) {
+			continue
+		}
+		server.poll(id)
+		if _, found := server.cdbInstances[id]; found {
+			items = append(items, resource.info)
+		}
+	}
+	sortByField(items, func(i int) string { return *items[i].InstanceId })
+	total := len(items)
+
+	offset, limit := uint64Value(request.Offset), uint64Value(request.Limit)
+	if offset > uint64(len(items)) {
+		offset = uint64(len(items))
+	}
+	items = items[offset:]
+	if limit > 0 && limit < uint64(len(items)) {
+		items = items[:limit]
+	}
+	return map[string]interface{}{"TotalCount": total, "Items": items}, nil
+}
+
+// findCdbInstance returns the instance of the id, which should be in the status and have no pending change.
+func (ctx *actionContext) findCdbInstance(instanceId string, status int64) (*cdbInstance, error) {
+	server := ctx.server
+	resource, found := server.cdbInstances[instanceId]
+	if !found || resource.region != ctx.region {
+		return nil, newApiError(ERROR_CODE_INVALID_VALUE+".InstanceNotFound", "the cdb instance (%s) does not exist", instanceId)
+	}
+	if *resource.info.Status != status || server.hasPendingChanges(instanceId) {
+		return nil, newApiError(ERROR_CODE_UNSUPPORTED_OPERATION+".InstanceStatus", "the cdb instance (%s) is in status %d, expected %d", instanceId, *resource.info.Status, status)
+	}
+	return resource, nil
+}
+
+// newAsyncRequest creates an async request which succeeds after being polled.
+func (server *Server) newAsyncRequest(apply func()) string {
+	requestId := server.newId("async")
+	asyncRequest := &cdbAsyncRequest{status: CDB_ASYNC_REQUEST_RUNNING}
+	server.cdbRequests[requestId] = asyncRequest
+	server.later(requestId, func() {
+		if apply != nil {
+			apply()
+		}
+		asyncRequest.status = CDB_ASYNC_REQUEST_SUCCESS
+	})
+	return requestId
+}
+
+func initCdbInstances(ctx *actionContext) (map[string]interface{}, error) {
+	request := cdb.NewInitDBInstancesRequest()
+	if err := ctx.decode(request); err != nil {
+		return nil, err
+	}
+	if err := requireString("NewPassword", request.NewPassword); err != nil {
+		return nil, err
+	}
+	for _, param := range request.Parameters {
+		if param == nil || stringValue(param.Name) == "" {
+			return nil, newApiError(ERROR_CODE_INVALID_PARAMETER, "the parameter name is missing")
+		}
+	}
+	instances := []*cdbInstance{}
+	for _, id := range stringValues(request.InstanceIds) {
+		instance, err := ctx.findCdbInstance(id, CDB_STATUS_RUNNING)
+		if err != nil {
+			return nil, err
+		}
+		if *instance.info.InitFlag != 0 {
+			return nil, newApiError(ERROR_CODE_UNSUPPORTED_OPERATION, "the cdb instance (%s) has been initialized", id)
+		}
+		instances = append(instances, instance)
+	}
+	if len(instances) == 0 {
+		return nil, newApiError(ERROR_CODE_MISSING_PARAMETER, "the parameter InstanceIds is missing")
+	}
+
+	server := ctx.server
+	requestIds := []string{}
+	for _, instance := range instances {
+		info, accounts := instance.info, instance.accounts
+		password, port := *request.NewPassword, int64Value(request.Vport)
+		if port == 0 {
+			port = CDB_DEFAULT_PORT
+		}
+		apply := func() {
+			info.InitFlag = common.Int64Ptr(1)
+			info.Vport = common.Int64Ptr(port)
+			accounts["root@%"] = password
+		}
+		server.later(*info.InstanceId, apply)
+		requestIds = append(requestIds, server.newAsyncRequest(nil))
+	}
+	return map[string]interface{}{"AsyncRequestIds": requestIds}, nil
+}
+
+// findInitializedCdbInstance returns the running instance of the id which should have been initialized.
+func (ctx *actionContext) findInitializedCdbInstance(instanceId string) (*cdbInstance, error) {
+	instance, err := ctx.findCdbInstance(instanceId, CDB_STATUS_RUNNING)
+	if err != nil {
+		return nil, err
+	}
+	if *instance.info.InitFlag != 1 {
+		return nil, newApiError(ERROR_CODE_UNSUPPORTED_OPERATION, "the cdb instance (%s) has not been initialized", instanceId)
+	}
+	return instance, nil
+}
+
+func createCdbAccounts(ctx *actionContext) (map[string]interface{}, error) {
+	request := cdb.NewCreateAccountsRequest()
+	if err := ctx.decode(request); err != nil {
+		return nil, err
+	}
+	instance, err := ctx.findInitializedCdbInstance(stringValue(request.InstanceId))
+	if err != nil {
+		return nil, err
+	}
+	if err := requireString("Password", request.Password); err != nil {
+		return nil, err
+	}
+	if len(request.Accounts) == 0 {
+		return nil, newApiError(ERROR_CODE_MISSING_PARAMETER, "the parameter Accounts is missing")
+	}
+	names := []string{}
+	for _, account := range request.Accounts {
+		if account == nil || stringValue(account.User) == "" || stringValue(account.Host) == "" {
+			return nil, newApiError(ERROR_CODE_INVALID_PARAMETER, "the account user and host are required")
+		}
+		name := *account.User + "@" + *account.Host
+		if _, found := instance.accounts[name]; found {
+			return nil, newApiError(ERROR_CODE_INVALID_VALUE, "the account (%s) already exists", name)
+		}
+		names = append(names, name)
+	}
+
+	password := *request.Password
+	requestId := ctx.server.newAsyncRequest(func() {
+		for _, name := range names {
+			instance.accounts[name] = password
+		}
+	})
+	return map[string]interface{}{"AsyncRequestId": requestId}, nil
+}
+
+func modifyCdbAccountPrivileges(ctx *actionContext) (map[string]interface{}, error) {
+	request := cdb.NewModifyAccountPrivilegesRequest()
+	if err := ctx.decode(request); err != nil {
+		return nil, err
+	}
+	instance, err := ctx.findInitializedCdbInstance(stringValue(request.InstanceId))
+	if err != nil {
+		return nil, err
+	}
+	if len(request.Accounts) == 0 {
+		return nil, newApiError(ERROR_CODE_MISSING_PARAMETER, "the parameter Accounts is missing")
+	}
+	for _, account := range request.Accounts {
+		name := stringValue(account.User) + "@" + stringValue(account.Host)
+		if _, found := instance.accounts[name]; !found {
+			return nil, newApiError(ERROR_CODE_INVALID_VALUE, "the account (%s) does not exist", name)
+		}
+	}
+	return map[string]interface{}{"AsyncRequestId": ctx.server.newAsyncRequest(nil)}, nil
+}
+
+func describeCdbAsyncRequestInfo(ctx *actionContext) (map[string]interface{}, error) {
+	request := cdb.NewDescribeAsyncRequestInfoRequest()
+	if err := ctx.decode(request); err != nil {
+		return nil, err
+	}
+	server := ctx.server
+	requestId := stringValue(request.AsyncRequestId)
+	asyncRequest, found := server.cdbRequests[requestId]
+	if !found {
+		return nil, notFoundError("async request", requestId)
+	}
+	server.poll(requestId)
+	return map[string]interface{}{"Status": asyncRequest.status, "Info": ""}, nil
+}
+
+func restartCdbInstances(ctx *actionContext) (map[string]interface{}, error) {
+	request := cdb.NewRestartDBInstancesRequest()
+	if err := ctx.decode(request); err != nil {
+		return nil, err
+	}
+	ids := stringValues(request.InstanceIds)
+	if len(ids) == 0 {
+		return nil, newApiError(ERROR_CODE_MISSING_PARAMETER, "the parameter InstanceIds is missing")
+	}
+	for _, id := range ids {
+		if _, err := ctx.findCdbInstance(id, CDB_STATUS_RUNNING); err != nil {
+			return nil, err
+		}
+	}
+	return map[string]interface{}{"AsyncRequestId": ctx.server.newAsyncRequest(nil)}, nil
+}
+
+func isolateCdbInstance(ctx *actionContext) (map[string]interface{}, error) {
+	request := cdb.NewIsolateDBInstanceRequest()
+	if err := ctx.decode(request); err != nil {
+		return nil, err
+	}
+	instance, err := ctx.findCdbInstance(stringValue(request.InstanceId), CDB_STATUS_RUNNING)
+	if err != nil {
+		return nil, err
+	}
+	info := instance.info
+	info.Status = common.Int64Ptr(CDB_STATUS_ISOLATING)
+	ctx.server.later(*info.InstanceId, func() {
+		info.Status = common.Int64Ptr(CDB_STATUS_ISOLATED)
+	})
+	return map[string]interface{}{"AsyncRequestId": ctx.server.newAsyncRequest(nil)}, nil
+}
+
+func offlineIsolatedCdbInstances(ctx *actionContext) (map[string]interface{}, error) {
+	request := cdb.NewOfflineIsolatedInstancesRequest()
+	if err := ctx.decode(request); err != nil {
+		return nil, err
+	}
+	ids := stringValues(request.InstanceIds)
+	if len(ids) == 0 {
+		return nil, newApiError(ERROR_CODE_MISSING_PARAMETER, "the parameter InstanceIds is missing")
+	}
+	for _, id := range ids {
+		if _, err := ctx.findCdbInstance(id, CDB_STATUS_ISOLATED); err != nil {
+			return nil, err
+		}
+	}
+
+	server := ctx.server
+	for _, id := range ids {
+		instanceId := id
+		server.later(instanceId, func() {
+			delete(server.cdbInstances, instanceId)
+		})
+	}
+	return map[string]interface{}{}, nil
+}
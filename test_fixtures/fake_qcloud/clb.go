@@ -0,0 +1,157 @@
+package fake_qcloud
+
+import (
+	"fmt"
+
+	clb "github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/clb/v20180317"
+	"github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/common"
+)
+
+const (
+	CLB_STATUS_CREATING = 0
+	CLB_STATUS_RUNNING  = 1
+
+	CLB_TYPE_OPEN     = "OPEN"
+	CLB_TYPE_INTERNAL = "INTERNAL"
+)
+
+type loadBalancer struct {
+	region string
+	info   *clb.LoadBalancer
+}
+
+func init() {
+	registerAction("clb", "CreateLoadBalancer", createLoadBalancer)
+	registerAction("clb", "DescribeLoadBalancers", describeLoadBalancers)
+	registerAction("clb", "DeleteLoadBalancer", deleteLoadBalancer)
+}
+
+// createLoadBalancer creates the load balancers in CREATING status, they are running after being polled.
+// The vips of OPEN load balancers are allocated from 203.0.113.0/24, which is reserved for documentation.
+func createLoadBalancer(ctx *actionContext) (map[string]interface{}, error) {
+	request := clb.NewCreateLoadBalancerRequest()
+	if err := ctx.decode(request); err != nil {
+		return nil, err
+	}
+	server := ctx.server
+	lbType := stringValue(request.LoadBalancerType)
+	if lbType != CLB_TYPE_OPEN && lbType != CLB_TYPE_INTERNAL {
+		return nil, newApiError(ERROR_CODE_INVALID_VALUE, "the LoadBalancerType (%s) is not supported", lbType)
+	}
+	forward := int64Value(request.Forward)
+	if forward != 0 && forward != 1 {
+		return nil, newApiError(ERROR_CODE_INVALID_VALUE, "the Forward (%d) is not supported", forward)
+	}
+	vpcId, subnetId := stringValue(request.VpcId), stringValue(request.SubnetId)
+	if lbType == CLB_TYPE_INTERNAL {
+		if err := server.checkSubnet(ctx.region, vpcId, subnetId); err != nil {
+			return nil, err
+		}
+	} else if vpc, found := server.vpcs[vpcId]; vpcId != "" && (!found || vpc.region != ctx.region) {
+		return nil, notFoundError("vpc", vpcId)
+	} else if subnetId != "" {
+		return nil, newApiError(ERROR_CODE_INVALID_PARAMETER, "the SubnetId should not be set for OPEN load balancer")
+	}
+	number := uint64Value(request.Number)
+	if number == 0 {
+		number = 1
+	}
+
+	loadBalancerIds := []string{}
+	for i := uint64(0); i < number; i++ {
+		loadBalancerId := server.newId("lb")
+		name := stringValue(request.LoadBalancerName)
+		if name == "" {
+			name = loadBalancerId
+		}
+		var vip string
+		if lbType == CLB_TYPE_INTERNAL {
+			vip = server.allocateIp(subnetId)
+		} else {
+			vip = fmt.Sprintf("203.0.113.%d", len(server.loadBalancers)%254+1)
+		}
+		info := &clb.LoadBalancer{
+			LoadBalancerId:   common.StringPtr(loadBalancerId),
+			LoadBalancerName: common.StringPtr(name),
+			LoadBalancerType: common.StringPtr(lbType),
+			Forward:          common.Uint64Ptr(uint64(forward)),
+			LoadBalancerVips: []*string{common.StringPtr(vip)},
+			Status:           common.Uint64Ptr(CLB_STATUS_CREATING),
+			CreateTime:       common.StringPtr(now()),
+			ProjectId:        common.Uint64Ptr(uint64(int64Value(request.ProjectId))),
+			VpcId:            common.StringPtr(vpcId),
+			SubnetId:         common.StringPtr(subnetId),
+			AddressIPVersion: common.StringPtr("ipv4"),
+		}
+		server.loadBalancers[loadBalancerId] = &loadBalancer{region: ctx.region, info: info}
+		server.later(loadBalancerId, func() {
+			info.Status = common.Uint64Ptr(CLB_STATUS_RUNNING)
+			info.StatusTime = common.StringPtr(now())
+		})
+		loadBalancerIds = append(loadBalancerIds, loadBalancerId)
+	}
+	return map[string]interface{}{"LoadBalancerIds": loadBalancerIds}, nil
+}
+
+func describeLoadBalancers(ctx *actionContext) (map[string]interface{}, error) {
+	request := clb.NewDescribeLoadBalancersRequest()
+	if err := ctx.decode(request); err != nil {
+		return nil, err
+	}
+	server := ctx.server
+	ids := stringValues(request.LoadBalancerIds)
+	vips := stringValues(request.LoadBalancerVips)
+	lbType, vpcId := stringValue(request.LoadBalancerType), stringValue(request.VpcId)
+
+	loadBalancerSet := []*clb.LoadBalancer{}
+	for id, resource := range server.loadBalancers {
+		if resource.region != ctx.region || (len(ids) > 0 && !containsString(ids, id)) {
+			continue
+		}
+		if (len(vips) > 0 && !containsString(vips, *resource.info.LoadBalancerVips[0])) ||
+			(lbType != "" && *resource.info.LoadBalancerType != lbType) ||
+			(vpcId != "" && *resource.info.VpcId != vpcId) {
+			continue
+		}
+		server.poll(id)
+		loadBalancerSet = append(loadBalancerSet, resource.info)
+	}
+	sortByField(loadBalancerSet, func(i int) string { return *loadBalancerSet[i].LoadBalancerId })
+	total := len(loadBalancerSet)
+
+	offset, limit := int64Value(request.Offset), int64Value(request.Limit)
+	if offset < 0 || offset > int64(len(loadBalancerSet)) {
+		offset = int64(len(loadBalancerSet))
+	}
+	loadBalancerSet = loadBalancerSet[offset:]
+	if limit > 0 && limit < int64(len(loadBalancerSet)) {
+		loadBalancerSet = loadBalancerSet[:limit]
+	}
+	return map[string]interface{}{"TotalCount": total, "LoadBalancerSet": loadBalancerSet}, nil
+}
+
+func deleteLoadBalancer(ctx *actionContext) (map[string]interface{}, error) {
+	request := clb.NewDeleteLoadBalancerRequest()
+	if err := ctx.decode(request); err != nil {
+		return nil, err
+	}
+	server := ctx.server
+	ids := stringValues(request.LoadBalancerIds)
+	if len(ids) == 0 {
+		return nil, newApiError(ERROR_CODE_MISSING_PARAMETER, "the parameter LoadBalancerIds is missing")
+	}
+	for _, id := range ids {
+		resource, found := server.loadBalancers[id]
+		if !found || resource.region != ctx.region {
+			return nil, notFoundError("load balancer", id)
+		}
+		if *resource.info.Status != CLB_STATUS_RUNNING {
+			return nil, newApiError(ERROR_CODE_UNSUPPORTED_OPERATION, "the load balancer (%s) is not running", id)
+		}
+	}
+	for _, id := range ids {
+		server.forget(id)
+		delete(server.loadBalancers, id)
+	}
+	return nil, nil
+}
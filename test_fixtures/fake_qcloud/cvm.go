@@ -0,0 +1,430 @@
+package fake_qcloud
+
+import (
+	"fmt"
+
+	"github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/common"
+	cvm "github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/cvm/v20170312"
+)
+
+const (
+	INSTANCE_STATE_PENDING       = "PENDING"
+	INSTANCE_STATE_RUNNING       = "RUNNING"
+	INSTANCE_STATE_STARTING      = "STARTING"
+	INSTANCE_STATE_STOPPING      = "STOPPING"
+	INSTANCE_STATE_STOPPED       = "STOPPED"
+	INSTANCE_STATE_TERMINATING   = "TERMINATING"
+	INSTANCE_CHARGE_TYPE_PREPAID = "PREPAID"
+	INSTANCE_CHARGE_TYPE_BY_HOUR = "POSTPAID_BY_HOUR"
+)
+
+type zone struct {
+	Zone   string
+	ZoneId string
+	Name   string
+}
+
+// regionZones are the zones of the regions supported by the fake server.
+var regionZones = map[string][]zone{
+	"ap-guangzhou": {
+		{"ap-guangzhou-1", "100001", "Guangzhou Zone 1"},
+		{"ap-guangzhou-2", "100002", "Guangzhou Zone 2"},
+		{"ap-guangzhou-3", "100003", "Guangzhou Zone 3"},
+	},
+	"ap-shanghai": {
+		{"ap-shanghai-1", "200001", "Shanghai Zone 1"},
+		{"ap-shanghai-2", "200002", "Shanghai Zone 2"},
+	},
+	"ap-beijing": {
+		{"ap-beijing-1", "800001", "Beijing Zone 1"},
+		{"ap-beijing-2", "800002", "Beijing Zone 2"},
+	},
+	"ap-chengdu": {
+		{"ap-chengdu-1", "160001", "Chengdu Zone 1"},
+		{"ap-chengdu-2", "160002", "Chengdu Zone 2"},
+	},
+	"ap-chongqing": {
+		{"ap-chongqing-1", "162001", "Chongqing Zone 1"},
+	},
+}
+
+type instanceType struct {
+	Name   string
+	Family string
+	Cpu    int64
+	Memory int64
+}
+
+// instanceTypes are sold in all the zones with both charge types.
+var instanceTypes = []instanceType{
+	{"S2.SMALL1", "S2", 1, 1},
+	{"S2.SMALL2", "S2", 1, 2},
+	{"S2.MEDIUM4", "S2", 2, 4},
+	{"S2.MEDIUM8", "S2", 2, 8},
+	{"S2.LARGE8", "S2", 4, 8},
+	{"S2.LARGE16", "S2", 4, 16},
+}
+
+type instance struct {
+	region string
+	info   *cvm.Instance
+}
+
+func init() {
+	registerAction("cvm", "DescribeZones", describeZones)
+	registerAction("cvm", "DescribeZoneInstanceConfigInfos", describeZoneInstanceConfigInfos)
+	registerAction("cvm", "RunInstances", runInstances)
+	registerAction("cvm", "DescribeInstances", describeInstances)
+	registerAction("cvm", "StartInstances", startInstances)
+	registerAction("cvm", "StopInstances", stopInstances)
+	registerAction("cvm", "TerminateInstances", terminateInstances)
+	registerAction("cvm", "ModifyInstancesAttribute", modifyInstancesAttribute)
+}
+
+func (server *Server) findZone(region, zoneName string) (zone, error) {
+	zones, found := regionZones[region]
+	if !found {
+		return zone{}, newApiError(ERROR_CODE_INVALID_VALUE+".Region", "the region (%s) is not supported", region)
+	}
+	for _, z := range zones {
+		if z.Zone == zoneName {
+			return z, nil
+		}
+	}
+	return zone{}, newApiError(ERROR_CODE_INVALID_VALUE+".Zone", "the zone (%s) does not exist in region (%s)", zoneName, region)
+}
+
+func (server *Server) findZoneById(region, zoneId string) (zone, error) {
+	for _, z := range regionZones[region] {
+		if z.ZoneId == zoneId {
+			return z, nil
+		}
+	}
+	return zone{}, newApiError(ERROR_CODE_INVALID_VALUE+".Zone", "the zone id (%s) does not exist in region (%s)", zoneId, region)
+}
+
+func findInstanceType(name string) (instanceType, bool) {
+	for _, t := range instanceTypes {
+		if t.Name == name {
+			return t, true
+		}
+	}
+	return instanceType{}, false
+}
+
+func describeZones(ctx *actionContext) (map[string]interface{}, error) {
+	zones, found := regionZones[ctx.region]
+	if !found {
+		return nil, newApiError(ERROR_CODE_INVALID_VALUE+".Region", "the region (%s) is not supported", ctx.region)
+	}
+	zoneSet := []*cvm.ZoneInfo{}
+	for _, z := range zones {
+		zoneSet = append(zoneSet, &cvm.ZoneInfo{
+			Zone:      common.StringPtr(z.Zone),
+			ZoneId:    common.StringPtr(z.ZoneId),
+			ZoneName:  common.StringPtr(z.Name),
+			ZoneState: common.StringPtr("AVAILABLE"),
+		})
+	}
+	return map[string]interface{}{"TotalCount": len(zoneSet), "ZoneSet": zoneSet}, nil
+}
+
+func describeZoneInstanceConfigInfos(ctx *actionContext) (map[string]interface{}, error) {
+	filters, err := ctx.filters()
+	if err != nil {
+		return nil, err
+	}
+	zones, found := regionZones[ctx.region]
+	if !found {
+		return nil, newApiError(ERROR_CODE_INVALID_VALUE+".Region", "the region (%s) is not supported", ctx.region)
+	}
+
+	quotaSet := []*cvm.InstanceTypeQuotaItem{}
+	for _, z := range zones {
+		for _, chargeType := range []string{INSTANCE_CHARGE_TYPE_BY_HOUR, INSTANCE_CHARGE_TYPE_PREPAID} {
+			for _, t := range instanceTypes {
+				fields := map[string]string{
+					"zone":                 z.Zone,
+					"instance-family":      t.Family,
+					"instance-type":        t.Name,
+					"instance-charge-type": chargeType,
+				}
+				if !matchFilters(filters, fields) {
+					continue
+				}
+				quotaSet = append(quotaSet, &cvm.InstanceTypeQuotaItem{
+					Zone:               common.StringPtr(z.Zone),
+					InstanceType:       common.StringPtr(t.Name),
+					InstanceChargeType: common.StringPtr(chargeType),
+					InstanceFamily:     common.StringPtr(t.Family),
+					TypeName:           common.StringPtr(t.Name),
+					Cpu:                common.Int64Ptr(t.Cpu),
+					Memory:             common.Int64Ptr(t.Memory),
+					Status:             common.StringPtr("SELL"),
+				})
+			}
+		}
+	}
+	return map[string]interface{}{"InstanceTypeQuotaSet": quotaSet}, nil
+}
+
+func runInstances(ctx *actionContext) (map[string]interface{}, error) {
+	request := cvm.NewRunInstancesRequest()
+	if err := ctx.decode(request); err != nil {
+		return nil, err
+	}
+	server := ctx.server
+	if request.Placement == nil {
+		return nil, newApiError(ERROR_CODE_MISSING_PARAMETER, "the parameter Placement is missing")
+	}
+	if _, err := server.findZone(ctx.region, stringValue(request.Placement.Zone)); err != nil {
+		return nil, err
+	}
+	if err := requireString("ImageId", request.ImageId); err != nil {
+		return nil, err
+	}
+	chargeType := stringValue(request.InstanceChargeType)
+	if chargeType == "" {
+		chargeType = INSTANCE_CHARGE_TYPE_BY_HOUR
+	}
+	if chargeType != INSTANCE_CHARGE_TYPE_BY_HOUR && chargeType != INSTANCE_CHARGE_TYPE_PREPAID {
+		return nil, newApiError(ERROR_CODE_INVALID_VALUE, "the InstanceChargeType (%s) is not supported", chargeType)
+	}
+	if chargeType == INSTANCE_CHARGE_TYPE_PREPAID && (request.InstanceChargePrepaid == nil || int64Value(request.InstanceChargePrepaid.Period) <= 0) {
+		return nil, newApiError(ERROR_CODE_MISSING_PARAMETER, "the parameter InstanceChargePrepaid.Period is missing")
+	}
+	typeName := stringValue(request.InstanceType)
+	if typeName == "" {
+		typeName = "S2.SMALL1"
+	}
+	t, found := findInstanceType(typeName)
+	if !found {
+		return nil, newApiError(ERROR_CODE_INVALID_VALUE+".InstanceType", "the InstanceType (%s) is not sold", typeName)
+	}
+	systemDisk := &cvm.SystemDisk{DiskType: common.StringPtr("CLOUD_BASIC"), DiskSize: common.Int64Ptr(50)}
+	if request.SystemDisk != nil {
+		if request.SystemDisk.DiskType != nil {
+			systemDisk.DiskType = request.SystemDisk.DiskType
+		}
+		if request.SystemDisk.DiskSize != nil {
+			systemDisk.DiskSize = request.SystemDisk.DiskSize
+		}
+	}
+	if size := *systemDisk.DiskSize; size < 50 || size > 500 {
+		return nil, newApiError(ERROR_CODE_INVALID_VALUE, "the SystemDisk.DiskSize (%d) should be in [50, 500]", size)
+	}
+	if request.VirtualPrivateCloud == nil {
+		return nil, newApiError(ERROR_CODE_MISSING_PARAMETER, "the parameter VirtualPrivateCloud is missing")
+	}
+	vpcId, subnetId := stringValue(request.VirtualPrivateCloud.VpcId), stringValue(request.VirtualPrivateCloud.SubnetId)
+	if err := server.checkSubnet(ctx.region, vpcId, subnetId); err != nil {
+		return nil, err
+	}
+	if *server.subnets[subnetId].info.Zone != *request.Placement.Zone {
+		return nil, newApiError(ERROR_CODE_INVALID_VALUE, "the subnet (%s) is not in zone (%s)", subnetId, *request.Placement.Zone)
+	}
+	for _, securityGroupId := range stringValues(request.SecurityGroupIds) {
+		if _, found := server.securityGroups[securityGroupId]; !found {
+			return nil, notFoundError("security group", securityGroupId)
+		}
+	}
+	count := int64Value(request.InstanceCount)
+	if count == 0 {
+		count = 1
+	}
+	if request.DryRun != nil && *request.DryRun {
+		return nil, newApiError(ERROR_CODE_DRY_RUN_OPERATION, "the request would have succeeded, but DryRun is set")
+	}
+
+	instanceIds := []string{}
+	for i := int64(0); i < count; i++ {
+		instanceId := server.newId("ins")
+		name := stringValue(request.InstanceName)
+		if name == "" {
+			name = "Unnamed"
+		} else if count > 1 {
+			name = fmt.Sprintf("%s%d", name, i+1)
+		}
+		privateIp := server.allocateIp(subnetId)
+		if i == 0 && len(request.VirtualPrivateCloud.PrivateIpAddresses) > 0 {
+			privateIp = *request.VirtualPrivateCloud.PrivateIpAddresses[0]
+		}
+		info := &cvm.Instance{
+			Placement:          &cvm.Placement{Zone: request.Placement.Zone, ProjectId: common.Int64Ptr(int64Value(request.Placement.ProjectId))},
+			InstanceId:         common.StringPtr(instanceId),
+			InstanceType:       common.StringPtr(t.Name),
+			CPU:                common.Int64Ptr(t.Cpu),
+			Memory:             common.Int64Ptr(t.Memory),
+			RestrictState:      common.StringPtr("NORMAL"),
+			InstanceName:       common.StringPtr(name),
+			InstanceChargeType: common.StringPtr(chargeType),
+			SystemDisk:         &cvm.SystemDisk{DiskId: common.StringPtr(server.newId("disk")), DiskType: systemDisk.DiskType, DiskSize: systemDisk.DiskSize},
+			PrivateIpAddresses: common.StringPtrs([]string{privateIp}),
+			VirtualPrivateCloud: &cvm.VirtualPrivateCloud{
+				VpcId:              common.StringPtr(vpcId),
+				SubnetId:           common.StringPtr(subnetId),
+				PrivateIpAddresses: common.StringPtrs([]string{privateIp}),
+			},
+			ImageId:          request.ImageId,
+			CreatedTime:      common.StringPtr(now()),
+			SecurityGroupIds: request.SecurityGroupIds,
+			InstanceState:    common.StringPtr(INSTANCE_STATE_PENDING),
+		}
+		server.instances[instanceId] = &instance{region: ctx.region, info: info}
+		server.later(instanceId, func() {
+			info.InstanceState = common.StringPtr(INSTANCE_STATE_RUNNING)
+		})
+		instanceIds = append(instanceIds, instanceId)
+	}
+	return map[string]interface{}{"InstanceIdSet": instanceIds}, nil
+}
+
+func describeInstances(ctx *actionContext) (map[string]interface{}, error) {
+	request := cvm.NewDescribeInstancesRequest()
+	if err := ctx.decode(request); err != nil {
+		return nil, err
+	}
+	ids := stringValues(request.InstanceIds)
+	filters, err := ctx.filters()
+	if err != nil {
+		return nil, err
+	}
+
+	server := ctx.server
+	instanceSet := []*cvm.Instance{}
+	for id, resource := range server.instances {
+		if resource.region != ctx.region || (len(ids) > 0 && !containsString(ids, id)) {
+			continue
+		}
+		fields := map[string]string{
+			"instance-id":        id,
+			"instance-name":      *resource.info.InstanceName,
+			"zone":               *resource.info.Placement.Zone,
+			"vpc-id":             *resource.info.VirtualPrivateCloud.VpcId,
+			"subnet-id":          *resource.info.VirtualPrivateCloud.SubnetId,
+			"private-ip-address": *resource.info.PrivateIpAddresses[0],
+		}
+		if !matchFilters(filters, fields) {
+			continue
+		}
+		server.poll(id)
+		if _, found := server.instances[id]; found {
+			instanceSet = append(instanceSet, resource.info)
+		}
+	}
+	sortByField(instanceSet, func(i int) string { return *instanceSet[i].InstanceId })
+	return map[string]interface{}{"TotalCount": len(instanceSet), "InstanceSet": instanceSet}, nil
+}
+
+// changeInstancesState moves the instances from one of the fromStates to the transient state,
+// and then to the final state after the instances have been polled.
+func changeInstancesState(ctx *actionContext, ids []*string, fromStates []string, transientState string, finalState string) (map[string]interface{}, error) {
+	server := ctx.server
+	instances := []*instance{}
+	for _, id := range stringValues(ids) {
+		resource, found := server.instances[id]
+		if !found || resource.region != ctx.region {
+			return nil, newApiError(ERROR_CODE_INVALID_VALUE+".InstanceIdNotFound", "the instance (%s) does not exist", id)
+		}
+		if !containsString(fromStates, *resource.info.InstanceState) || server.hasPendingChanges(id) {
+			return nil, newApiError(ERROR_CODE_UNSUPPORTED_OPERATION+".InstanceState"+*resource.info.InstanceState,
+				"the instance (%s) is %s", id, *resource.info.InstanceState)
+		}
+		instances = append(instances, resource)
+	}
+	if len(instances) == 0 {
+		return nil, newApiError(ERROR_CODE_MISSING_PARAMETER, "the parameter InstanceIds is missing")
+	}
+	for _, resource := range instances {
+		info := resource.info
+		info.InstanceState = common.StringPtr(transientState)
+		server.later(*info.InstanceId, func() {
+			info.InstanceState = common.StringPtr(finalState)
+		})
+	}
+	return nil, nil
+}
+
+func startInstances(ctx *actionContext) (map[string]interface{}, error) {
+	request := cvm.NewStartInstancesRequest()
+	if err := ctx.decode(request); err != nil {
+		return nil, err
+	}
+	return changeInstancesState(ctx, request.InstanceIds, []string{INSTANCE_STATE_STOPPED}, INSTANCE_STATE_STARTING, INSTANCE_STATE_RUNNING)
+}
+
+func stopInstances(ctx *actionContext) (map[string]interface{}, error) {
+	request := cvm.NewStopInstancesRequest()
+	if err := ctx.decode(request); err != nil {
+		return nil, err
+	}
+	return changeInstancesState(ctx, request.InstanceIds, []string{INSTANCE_STATE_RUNNING}, INSTANCE_STATE_STOPPING, INSTANCE_STATE_STOPPED)
+}
+
+func terminateInstances(ctx *actionContext) (map[string]interface{}, error) {
+	request := cvm.NewTerminateInstancesRequest()
+	if err := ctx.decode(request); err != nil {
+		return nil, err
+	}
+	server := ctx.server
+	for _, id := range stringValues(request.InstanceIds) {
+		resource, found := server.instances[id]
+		if !found || resource.region != ctx.region {
+			return nil, newApiError(ERROR_CODE_INVALID_VALUE+".InstanceIdNotFound", "the instance (%s) does not exist", id)
+		}
+		if *resource.info.InstanceState == INSTANCE_STATE_TERMINATING {
+			return nil, newApiError(ERROR_CODE_UNSUPPORTED_OPERATION+".InstanceStateTerminating", "the instance (%s) is terminating", id)
+		}
+	}
+	for _, id := range stringValues(request.InstanceIds) {
+		instanceId := id
+		server.forget(instanceId)
+		server.instances[instanceId].info.InstanceState = common.StringPtr(INSTANCE_STATE_TERMINATING)
+		server.later(instanceId, func() {
+			// data disks are detached when the instance is terminated, or deleted if DeleteWithInstance is set.
+			for diskId, disk := range server.disks {
+				if stringValue(disk.info.InstanceId) != instanceId {
+					continue
+				}
+				server.forget(diskId)
+				if disk.info.DeleteWithInstance != nil && *disk.info.DeleteWithInstance {
+					delete(server.disks, diskId)
+				} else {
+					disk.info.InstanceId = common.StringPtr("")
+					disk.info.Attached = common.BoolPtr(false)
+					disk.info.DiskState = common.StringPtr(DISK_STATE_UNATTACHED)
+				}
+			}
+			delete(server.instances, instanceId)
+		})
+	}
+	return nil, nil
+}
+
+func modifyInstancesAttribute(ctx *actionContext) (map[string]interface{}, error) {
+	request := cvm.NewModifyInstancesAttributeRequest()
+	if err := ctx.decode(request); err != nil {
+		return nil, err
+	}
+	server := ctx.server
+	for _, id := range stringValues(request.InstanceIds) {
+		if resource, found := server.instances[id]; !found || resource.region != ctx.region {
+			return nil, newApiError(ERROR_CODE_INVALID_VALUE+".InstanceIdNotFound", "the instance (%s) does not exist", id)
+		}
+	}
+	for _, securityGroupId := range stringValues(request.SecurityGroups) {
+		if _, found := server.securityGroups[securityGroupId]; !found {
+			return nil, notFoundError("security group", securityGroupId)
+		}
+	}
+	for _, id := range stringValues(request.InstanceIds) {
+		info := server.instances[id].info
+		if request.InstanceName != nil {
+			info.InstanceName = request.InstanceName
+		}
+		if request.SecurityGroups != nil {
+			info.SecurityGroupIds = request.SecurityGroups
+		}
+	}
+	return nil, nil
+}
@@ -0,0 +1,302 @@
+package fake_qcloud
+
+import (
+	"fmt"
+
+	"github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/common"
+	mariadb "github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/mariadb/v20170312"
+)
+
+const (
+	MARIADB_STATUS_CREATING  = 0
+	MARIADB_STATUS_RUNNING   = 2
+	MARIADB_STATUS_WAIT_INIT = 3
+
+	MARIADB_FLOW_SUCCESS = 0
+	MARIADB_FLOW_RUNNING = 2
+
+	MARIADB_PORT = 3306
+)
+
+var mariadbVersions = []string{"10.0.10", "10.1.9", "5.7.17"}
+
+type mariadbDeal struct {
+	region string
+	info   *mariadb.Deal
+}
+
+type mariadbInstance struct {
+	region   string
+	info     *mariadb.DBInstance
+	accounts map[string]string
+}
+
+type mariadbFlow struct {
+	status int64
+}
+
+func init() {
+	registerAction("mariadb", "CreateDBInstance", createMariadbInstance)
+	registerAction("mariadb", "DescribeOrders", describeMariadbOrders)
+	registerAction("mariadb", "DescribeDBInstances", describeMariadbInstances)
+	registerAction("mariadb", "InitDBInstances", initMariadbInstances)
+	registerAction("mariadb", "DescribeFlow", describeMariadbFlow)
+	registerAction("mariadb", "CreateAccount", createMariadbAccount)
+	registerAction("mariadb", "GrantAccountPrivileges", grantMariadbAccountPrivileges)
+}
+
+func flowKey(flowId int64) string {
+	return fmt.Sprintf("flow-%d", flowId)
+}
+
+// createMariadbInstance creates a deal, the instances are delivered after the deal has been polled
+// and wait for initialization after being polled again.
+func createMariadbInstance(ctx *actionContext) (map[string]interface{}, error) {
+	request := mariadb.NewCreateDBInstanceRequest()
+	if err := ctx.decode(request); err != nil {
+		return nil, err
+	}
+	server := ctx.server
+	zones := stringValues(request.Zones)
+	if len(zones) == 0 {
+		return nil, newApiError(ERROR_CODE_MISSING_PARAMETER, "the parameter Zones is missing")
+	}
+	for _, zone := range zones {
+		if _, err := server.findZone(ctx.region, zone); err != nil {
+			return nil, err
+		}
+	}
+	nodeCount := int64Value(request.NodeCount)
+	if nodeCount < 2 || nodeCount > 3 {
+		return nil, newApiError(ERROR_CODE_INVALID_VALUE, "the NodeCount (%d) should be 2 or 3", nodeCount)
+	}
+	memory, storage := int64Value(request.Memory), int64Value(request.Storage)
+	if memory <= 0 || storage <= 0 {
+		return nil, newApiError(ERROR_CODE_INVALID_VALUE, "the Memory (%d) and Storage (%d) should be positive", memory, storage)
+	}
+	if int64Value(request.Period) <= 0 {
+		return nil, newApiError(ERROR_CODE_INVALID_VALUE, "the Period (%d) should be positive", int64Value(request.Period))
+	}
+	dbVersion := stringValue(request.DbVersionId)
+	if dbVersion != "" && !containsString(mariadbVersions, dbVersion) {
+		return nil, newApiError(ERROR_CODE_INVALID_VALUE, "the DbVersionId (%s) is not supported", dbVersion)
+	}
+	vpcId, subnetId := stringValue(request.VpcId), stringValue(request.SubnetId)
+	if err := server.checkSubnet(ctx.region, vpcId, subnetId); err != nil {
+		return nil, err
+	}
+	count := int64Value(request.Count)
+	if count <= 0 {
+		count = 1
+	}
+
+	dealName := server.newId("deal")
+	deal := &mariadb.Deal{
+		DealName: common.StringPtr(dealName),
+		Count:    common.Int64Ptr(count),
+		PayMode:  common.Int64Ptr(1),
+	}
+	server.mariadbDeals[dealName] = &mariadbDeal{region: ctx.region, info: deal}
+
+	region := ctx.region
+	server.later(dealName, func() {
+		for i := int64(0); i < count; i++ {
+			instanceId := server.newId("tdsql")
+			name := stringValue(request.InstanceName)
+			if name == "" {
+				name = instanceId
+			}
+			info := &mariadb.DBInstance{
+				InstanceId:     common.StringPtr(instanceId),
+				InstanceName:   common.StringPtr(name),
+				ProjectId:      common.Int64Ptr(int64Value(request.ProjectId)),
+				Region:         common.StringPtr(region),
+				Zone:           common.StringPtr(zones[0]),
+				Status:         common.Int64Ptr(MARIADB_STATUS_CREATING),
+				Vip:            common.StringPtr(server.allocateIp(subnetId)),
+				Vport:          common.Int64Ptr(MARIADB_PORT),
+				CreateTime:     common.StringPtr(now()),
+				Memory:         common.Int64Ptr(memory),
+				Storage:        common.Int64Ptr(storage),
+				UniqueVpcId:    common.StringPtr(vpcId),
+				UniqueSubnetId: common.StringPtr(subnetId),
+				NodeCount:      common.Uint64Ptr(uint64(nodeCount)),
+				TdsqlVersion:   common.StringPtr(dbVersion),
+			}
+			server.mariadbs[instanceId] = &mariadbInstance{region: region, info: info, accounts: map[string]string{}}
+			server.later(instanceId, func() {
+				info.Status = common.Int64Ptr(MARIADB_STATUS_WAIT_INIT)
+			})
+			deal.InstanceIds = append(deal.InstanceIds, common.StringPtr(instanceId))
+		}
+	})
+	return map[string]interface{}{"DealName": dealName}, nil
+}
+
+func describeMariadbOrders(ctx *actionContext) (map[string]interface{}, error) {
+	request := mariadb.NewDescribeOrdersRequest()
+	if err := ctx.decode(request); err != nil {
+		return nil, err
+	}
+	server := ctx.server
+	deals := []*mariadb.Deal{}
+	for _, name := range stringValues(request.DealNames) {
+		deal, found := server.mariadbDeals[name]
+		if !found || deal.region != ctx.region {
+			continue
+		}
+		server.poll(name)
+		deals = append(deals, deal.info)
+	}
+	return map[string]interface{}{"TotalCount": []int{len(deals)}, "Deals": deals}, nil
+}
+
+func describeMariadbInstances(ctx *actionContext) (map[string]interface{}, error) {
+	request := mariadb.NewDescribeDBInstancesRequest()
+	if err := ctx.decode(request); err != nil {
+		return nil, err
+	}
+	server := ctx.server
+	ids := stringValues(request.InstanceIds)
+	vpcId, subnetId := stringValue(request.VpcId), stringValue(request.SubnetId)
+
+	instances := []*mariadb.DBInstance{}
+	for id, resource := range server.mariadbs {
+		if resource.region != ctx.region || (len(ids) > 0 && !containsString(ids, id)) {
+			continue
+		}
+		if (vpcId != "" && *resource.info.UniqueVpcId != vpcId) || (subnetId != "" && *resource.info.UniqueSubnetId != subnetId) {
+			continue
+		}
+		server.poll(id)
+		instances = append(instances, resource.info)
+	}
+	sortByField(instances, func(i int) string { return *instances[i].InstanceId })
+	total := len(instances)
+
+	offset, limit := int64Value(request.Offset), int64Value(request.Limit)
+	if offset < 0 || offset > int64(len(instances)) {
+		offset = int64(len(instances))
+	}
+	instances = instances[offset:]
+	if limit > 0 && limit < int64(len(instances)) {
+		instances = instances[:limit]
+	}
+	return map[string]interface{}{"TotalCount": total, "Instances": instances}, nil
+}
+
+// findMariadbInstance returns the instance of the id, which should be in the status and have no pending change.
+func (ctx *actionContext) findMariadbInstance(instanceId string, status int64) (*mariadbInstance, error) {
+	server := ctx.server
+	resource, found := server.mariadbs[instanceId]
+	if !found || resource.region != ctx.region {
+		return nil, notFoundError("mariadb instance", instanceId)
+	}
+	if *resource.info.Status != status || server.hasPendingChanges(instanceId) {
+		return nil, newApiError(ERROR_CODE_UNSUPPORTED_OPERATION, "the mariadb instance (%s) is in status %d, expected %d", instanceId, *resource.info.Status, status)
+	}
+	return resource, nil
+}
+
+// initMariadbInstances starts a flow, the instances are running once the flow has succeeded.
+func initMariadbInstances(ctx *actionContext) (map[string]interface{}, error) {
+	request := mariadb.NewInitDBInstancesRequest()
+	if err := ctx.decode(request); err != nil {
+		return nil, err
+	}
+	ids := stringValues(request.InstanceIds)
+	if len(ids) == 0 {
+		return nil, newApiError(ERROR_CODE_MISSING_PARAMETER, "the parameter InstanceIds is missing")
+	}
+	infos := []*mariadb.DBInstance{}
+	for _, id := range ids {
+		instance, err := ctx.findMariadbInstance(id, MARIADB_STATUS_WAIT_INIT)
+		if err != nil {
+			return nil, err
+		}
+		infos = append(infos, instance.info)
+	}
+	for _, param := range request.Params {
+		if param == nil || stringValue(param.Param) == "" {
+			return nil, newApiError(ERROR_CODE_INVALID_PARAMETER, "the parameter name is missing")
+		}
+	}
+
+	server := ctx.server
+	server.sequence++
+	flowId := int64(server.sequence)
+	flow := &mariadbFlow{status: MARIADB_FLOW_RUNNING}
+	server.mariadbFlows[flowId] = flow
+	server.later(flowKey(flowId), func() {
+		for _, info := range infos {
+			info.Status = common.Int64Ptr(MARIADB_STATUS_RUNNING)
+		}
+		flow.status = MARIADB_FLOW_SUCCESS
+	})
+	return map[string]interface{}{"FlowId": flowId, "InstanceIds": ids}, nil
+}
+
+func describeMariadbFlow(ctx *actionContext) (map[string]interface{}, error) {
+	request := mariadb.NewDescribeFlowRequest()
+	if err := ctx.decode(request); err != nil {
+		return nil, err
+	}
+	server := ctx.server
+	flowId := int64Value(request.FlowId)
+	flow, found := server.mariadbFlows[flowId]
+	if !found {
+		return nil, notFoundError("flow", fmt.Sprint(flowId))
+	}
+	server.poll(flowKey(flowId))
+	return map[string]interface{}{"Status": flow.status}, nil
+}
+
+func createMariadbAccount(ctx *actionContext) (map[string]interface{}, error) {
+	request := mariadb.NewCreateAccountRequest()
+	if err := ctx.decode(request); err != nil {
+		return nil, err
+	}
+	instanceId := stringValue(request.InstanceId)
+	instance, err := ctx.findMariadbInstance(instanceId, MARIADB_STATUS_RUNNING)
+	if err != nil {
+		return nil, err
+	}
+	for name, value := range map[string]*string{"UserName": request.UserName, "Host": request.Host, "Password": request.Password} {
+		if err := requireString(name, value); err != nil {
+			return nil, err
+		}
+	}
+	account := *request.UserName + "@" + *request.Host
+	if _, found := instance.accounts[account]; found {
+		return nil, newApiError(ERROR_CODE_INVALID_VALUE, "the account (%s) already exists", account)
+	}
+	instance.accounts[account] = *request.Password
+	return map[string]interface{}{
+		"InstanceId": instanceId,
+		"UserName":   *request.UserName,
+		"Host":       *request.Host,
+		"ReadOnly":   int64Value(request.ReadOnly),
+	}, nil
+}
+
+func grantMariadbAccountPrivileges(ctx *actionContext) (map[string]interface{}, error) {
+	request := mariadb.NewGrantAccountPrivilegesRequest()
+	if err := ctx.decode(request); err != nil {
+		return nil, err
+	}
+	instance, err := ctx.findMariadbInstance(stringValue(request.InstanceId), MARIADB_STATUS_RUNNING)
+	if err != nil {
+		return nil, err
+	}
+	account := stringValue(request.UserName) + "@" + stringValue(request.Host)
+	if _, found := instance.accounts[account]; !found {
+		return nil, newApiError(ERROR_CODE_INVALID_VALUE, "the account (%s) does not exist", account)
+	}
+	if err := requireString("DbName", request.DbName); err != nil {
+		return nil, err
+	}
+	if len(request.Privileges) == 0 {
+		return nil, newApiError(ERROR_CODE_MISSING_PARAMETER, "the parameter Privileges is missing")
+	}
+	return nil, nil
+}
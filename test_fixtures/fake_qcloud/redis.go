@@ -0,0 +1,244 @@
+package fake_qcloud
+
+import (
+	"strconv"
+
+	"github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/common"
+	redis "github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/redis/v20180412"
+)
+
+const (
+	REDIS_DEAL_STATUS_DELIVERING = 2
+	REDIS_DEAL_STATUS_SUCCESS    = 4
+
+	REDIS_STATUS_RUNNING  = 2
+	REDIS_STATUS_ISOLATED = -2
+
+	REDIS_BILLING_MODE_POSTPAID = 0
+	REDIS_BILLING_MODE_PREPAID  = 1
+
+	REDIS_PORT = 6379
+)
+
+var redisTypeIds = []uint64{1, 2, 3, 4, 5, 6, 7, 8, 9}
+
+type redisInstance struct {
+	region string
+	info   *redis.InstanceSet
+}
+
+type redisDeal struct {
+	region string
+	info   *redis.TradeDealDetail
+}
+
+func init() {
+	registerAction("redis", "CreateInstances", createRedisInstances)
+	registerAction("redis", "DescribeInstanceDealDetail", describeRedisInstanceDealDetail)
+	registerAction("redis", "DescribeInstances", describeRedisInstances)
+	registerAction("redis", "DestroyPostpaidInstance", destroyRedisPostpaidInstance)
+	registerAction("redis", "DestroyPrepaidInstance", destroyRedisPrepaidInstance)
+	registerAction("redis", "CleanUpInstance", cleanUpRedisInstance)
+}
+
+// createRedisInstances creates a deal, the instances are delivered after the deal has been polled.
+func createRedisInstances(ctx *actionContext) (map[string]interface{}, error) {
+	request := redis.NewCreateInstancesRequest()
+	if err := ctx.decode(request); err != nil {
+		return nil, err
+	}
+	server := ctx.server
+	if _, err := server.findZoneById(ctx.region, strconv.FormatUint(uint64Value(request.ZoneId), 10)); err != nil {
+		return nil, err
+	}
+	typeId := uint64Value(request.TypeId)
+	found := false
+	for _, id := range redisTypeIds {
+		found = found || id == typeId
+	}
+	if !found {
+		return nil, newApiError(ERROR_CODE_INVALID_VALUE, "the TypeId (%d) is not supported", typeId)
+	}
+	memSize := uint64Value(request.MemSize)
+	if memSize == 0 || memSize%256 != 0 {
+		return nil, newApiError(ERROR_CODE_INVALID_VALUE, "the MemSize (%d) should be a multiple of 256", memSize)
+	}
+	goodsNum := uint64Value(request.GoodsNum)
+	if goodsNum == 0 || goodsNum > 10 {
+		return nil, newApiError(ERROR_CODE_INVALID_VALUE, "the GoodsNum (%d) should be in [1, 10]", goodsNum)
+	}
+	if uint64Value(request.Period) == 0 {
+		return nil, newApiError(ERROR_CODE_MISSING_PARAMETER, "the parameter Period is missing")
+	}
+	billingMode := int64Value(request.BillingMode)
+	if billingMode != REDIS_BILLING_MODE_POSTPAID && billingMode != REDIS_BILLING_MODE_PREPAID {
+		return nil, newApiError(ERROR_CODE_INVALID_VALUE, "the BillingMode (%d) is not supported", billingMode)
+	}
+	if err := requireString("Password", request.Password); err != nil {
+		return nil, err
+	}
+	vpcId, subnetId := stringValue(request.VpcId), stringValue(request.SubnetId)
+	if err := server.checkSubnet(ctx.region, vpcId, subnetId); err != nil {
+		return nil, err
+	}
+
+	dealId := server.newId("deal")
+	deal := &redis.TradeDealDetail{
+		DealId:    common.StringPtr(dealId),
+		DealName:  common.StringPtr(dealId),
+		ZoneId:    common.Int64Ptr(int64(uint64Value(request.ZoneId))),
+		GoodsNum:  common.Int64Ptr(int64(goodsNum)),
+		CreatTime: common.StringPtr(now()),
+		Status:    common.Int64Ptr(REDIS_DEAL_STATUS_DELIVERING),
+	}
+	server.redisDeals[dealId] = &redisDeal{region: ctx.region, info: deal}
+
+	region := ctx.region
+	server.later(dealId, func() {
+		for i := uint64(0); i < goodsNum; i++ {
+			instanceId := server.newId("crs")
+			name := stringValue(request.InstanceName)
+			if name == "" {
+				name = instanceId
+			}
+			server.redisInstances[instanceId] = &redisInstance{
+				region: region,
+				info: &redis.InstanceSet{
+					InstanceName: common.StringPtr(name),
+					InstanceId:   common.StringPtr(instanceId),
+					ProjectId:    common.Int64Ptr(int64Value(request.ProjectId)),
+					ZoneId:       common.Int64Ptr(int64(uint64Value(request.ZoneId))),
+					Status:       common.Int64Ptr(REDIS_STATUS_RUNNING),
+					WanIp:        common.StringPtr(server.allocateIp(subnetId)),
+					Port:         common.Int64Ptr(REDIS_PORT),
+					Createtime:   common.StringPtr(now()),
+					Size:         common.Float64Ptr(float64(memSize)),
+					SizeUsed:     common.Float64Ptr(0),
+					Type:         common.Int64Ptr(int64(typeId)),
+					UniqVpcId:    common.StringPtr(vpcId),
+					UniqSubnetId: common.StringPtr(subnetId),
+					BillingMode:  common.Int64Ptr(billingMode),
+				},
+			}
+			deal.InstanceIds = append(deal.InstanceIds, common.StringPtr(instanceId))
+		}
+		deal.Status = common.Int64Ptr(REDIS_DEAL_STATUS_SUCCESS)
+	})
+	return map[string]interface{}{"DealId": dealId}, nil
+}
+
+func describeRedisInstanceDealDetail(ctx *actionContext) (map[string]interface{}, error) {
+	request := redis.NewDescribeInstanceDealDetailRequest()
+	if err := ctx.decode(request); err != nil {
+		return nil, err
+	}
+	server := ctx.server
+	dealDetails := []*redis.TradeDealDetail{}
+	for _, id := range stringValues(request.DealIds) {
+		deal, found := server.redisDeals[id]
+		if !found || deal.region != ctx.region {
+			continue
+		}
+		server.poll(id)
+		dealDetails = append(dealDetails, deal.info)
+	}
+	return map[string]interface{}{"DealDetails": dealDetails}, nil
+}
+
+func describeRedisInstances(ctx *actionContext) (map[string]interface{}, error) {
+	request := redis.NewDescribeInstancesRequest()
+	if err := ctx.decode(request); err != nil {
+		return nil, err
+	}
+	server := ctx.server
+	instanceId := stringValue(request.InstanceId)
+	vpcIds := stringValues(request.UniqVpcIds)
+	subnetIds := stringValues(request.UniqSubnetIds)
+
+	instanceSet := []*redis.InstanceSet{}
+	for id, resource := range server.redisInstances {
+		if resource.region != ctx.region || (instanceId != "" && id != instanceId) {
+			continue
+		}
+		if (len(vpcIds) > 0 && !containsString(vpcIds, *resource.info.UniqVpcId)) ||
+			(len(subnetIds) > 0 && !containsString(subnetIds, *resource.info.UniqSubnetId)) {
+			continue
+		}
+		server.poll(id)
+		if _, found := server.redisInstances[id]; found {
+			instanceSet = append(instanceSet, resource.info)
+		}
+	}
+	sortByField(instanceSet, func(i int) string { return *instanceSet[i].InstanceId })
+	total := len(instanceSet)
+
+	offset, limit := uint64Value(request.Offset), uint64Value(request.Limit)
+	if offset > uint64(len(instanceSet)) {
+		offset = uint64(len(instanceSet))
+	}
+	instanceSet = instanceSet[offset:]
+	if limit > 0 && limit < uint64(len(instanceSet)) {
+		instanceSet = instanceSet[:limit]
+	}
+	return map[string]interface{}{"TotalCount": total, "InstanceSet": instanceSet}, nil
+}
+
+func destroyRedisInstance(ctx *actionContext, instanceId string, billingMode int64) (map[string]interface{}, error) {
+	server := ctx.server
+	resource, found := server.redisInstances[instanceId]
+	if !found || resource.region != ctx.region {
+		return nil, notFoundError("redis instance", instanceId)
+	}
+	if *resource.info.BillingMode != billingMode {
+		return nil, newApiError(ERROR_CODE_UNSUPPORTED_OPERATION, "the billing mode of redis instance (%s) is %d", instanceId, *resource.info.BillingMode)
+	}
+	if *resource.info.Status != REDIS_STATUS_RUNNING {
+		return nil, newApiError(ERROR_CODE_UNSUPPORTED_OPERATION, "the redis instance (%s) is not running, status=%d", instanceId, *resource.info.Status)
+	}
+	resource.info.Status = common.Int64Ptr(REDIS_STATUS_ISOLATED)
+	resource.info.OfflineTime = common.StringPtr(now())
+
+	server.sequence++
+	return map[string]interface{}{"TaskId": server.sequence}, nil
+}
+
+func destroyRedisPostpaidInstance(ctx *actionContext) (map[string]interface{}, error) {
+	request := redis.NewDestroyPostpaidInstanceRequest()
+	if err := ctx.decode(request); err != nil {
+		return nil, err
+	}
+	return destroyRedisInstance(ctx, stringValue(request.InstanceId), REDIS_BILLING_MODE_POSTPAID)
+}
+
+func destroyRedisPrepaidInstance(ctx *actionContext) (map[string]interface{}, error) {
+	request := redis.NewDestroyPrepaidInstanceRequest()
+	if err := ctx.decode(request); err != nil {
+		return nil, err
+	}
+	response, err := destroyRedisInstance(ctx, stringValue(request.InstanceId), REDIS_BILLING_MODE_PREPAID)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{"DealId": ctx.server.newId("deal"), "TaskId": response["TaskId"]}, nil
+}
+
+func cleanUpRedisInstance(ctx *actionContext) (map[string]interface{}, error) {
+	request := redis.NewCleanUpInstanceRequest()
+	if err := ctx.decode(request); err != nil {
+		return nil, err
+	}
+	server := ctx.server
+	instanceId := stringValue(request.InstanceId)
+	resource, found := server.redisInstances[instanceId]
+	if !found || resource.region != ctx.region {
+		return nil, notFoundError("redis instance", instanceId)
+	}
+	if *resource.info.Status != REDIS_STATUS_ISOLATED {
+		return nil, newApiError(ERROR_CODE_UNSUPPORTED_OPERATION, "the redis instance (%s) is not isolated, status=%d", instanceId, *resource.info.Status)
+	}
+	server.forget(instanceId)
+	delete(server.redisInstances, instanceId)
+
+	server.sequence++
+	return map[string]interface{}{"TaskId": server.sequence}, nil
+}
@@ -0,0 +1,526 @@
+// Package fake_qcloud is an in-process fake of the Tencent Cloud API (TC3-HMAC-SHA256 signed JSON requests)
+// used by the integration tests. It keeps the resources of CVM, VPC, CBS, CDB, Redis, MariaDB, CLB and CAM
+// in memory and simulates their async states, such as an instance going from PENDING to RUNNING.
+package fake_qcloud
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	TC3_ALGORITHM     = "TC3-HMAC-SHA256"
+	TC3_TERMINATOR    = "tc3_request"
+	MAX_TIMESTAMP_GAP = 5 * time.Minute
+
+	DEFAULT_PENDING_POLLS = 1
+
+	ERROR_CODE_INVALID_AUTHORIZATION = "AuthFailure.InvalidAuthorization"
+	ERROR_CODE_SECRET_ID_NOT_FOUND   = "AuthFailure.SecretIdNotFound"
+	ERROR_CODE_SIGNATURE_FAILURE     = "AuthFailure.SignatureFailure"
+	ERROR_CODE_SIGNATURE_EXPIRE      = "AuthFailure.SignatureExpire"
+	ERROR_CODE_INVALID_ACTION        = "InvalidAction"
+	ERROR_CODE_INVALID_PARAMETER     = "InvalidParameter"
+	ERROR_CODE_INVALID_VALUE         = "InvalidParameterValue"
+	ERROR_CODE_MISSING_PARAMETER     = "MissingParameter"
+	ERROR_CODE_RESOURCE_NOT_FOUND    = "ResourceNotFound"
+	ERROR_CODE_RESOURCE_IN_USE       = "ResourceInUse"
+	ERROR_CODE_UNSUPPORTED_OPERATION = "UnsupportedOperation"
+	ERROR_CODE_DRY_RUN_OPERATION     = "DryRunOperation"
+)
+
+// Services lists the services which are served by the fake server.
+var Services = []string{"cvm", "vpc", "cbs", "cdb", "redis", "mariadb", "clb", "cam"}
+
+type apiError struct {
+	Code    string
+	Message string
+}
+
+func (err *apiError) Error() string {
+	return fmt.Sprintf("[%s] %s", err.Code, err.Message)
+}
+
+func newApiError(code string, format string, args ...interface{}) *apiError {
+	return &apiError{Code: code, Message: fmt.Sprintf(format, args...)}
+}
+
+func notFoundError(kind string, id string) *apiError {
+	return newApiError(ERROR_CODE_RESOURCE_NOT_FOUND, "the %s (%s) does not exist", kind, id)
+}
+
+// actionContext holds the request which is being handled.
+type actionContext struct {
+	server   *Server
+	region   string
+	secretId string
+	body     []byte
+}
+
+func (ctx *actionContext) decode(request interface{}) error {
+	if len(ctx.body) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(ctx.body, request); err != nil {
+		return newApiError(ERROR_CODE_INVALID_PARAMETER, "request body is not valid, error=%v", err)
+	}
+	return nil
+}
+
+// actionHandler handles one action, the server lock is held while it runs.
+type actionHandler func(ctx *actionContext) (map[string]interface{}, error)
+
+var actions = make(map[string]map[string]actionHandler)
+
+func registerAction(service string, action string, handler actionHandler) {
+	if _, found := actions[service]; !found {
+		actions[service] = make(map[string]actionHandler)
+	}
+	actions[service][action] = handler
+}
+
+// pendingChange is applied to a resource after it has been polled a number of times.
+type pendingChange struct {
+	polls int
+	apply func()
+}
+
+// Server is the fake Tencent Cloud API server, all the services share one endpoint
+// and are distinguished by the service in the credential scope of the request.
+type Server struct {
+	// PendingPolls is how many times a resource is described before its async change is applied,
+	// 0 applies the change immediately.
+	PendingPolls int
+
+	mutex       sync.Mutex
+	credentials map[string]string
+	sequence    int
+	changes     map[string][]*pendingChange
+	httpServer  *httptest.Server
+
+	instances      map[string]*instance
+	vpcs           map[string]*vpcResource
+	subnets        map[string]*subnetResource
+	routeTables    map[string]*routeTableResource
+	securityGroups map[string]*securityGroupResource
+	disks          map[string]*diskResource
+	cdbInstances   map[string]*cdbInstance
+	cdbRequests    map[string]*cdbAsyncRequest
+	redisInstances map[string]*redisInstance
+	redisDeals     map[string]*redisDeal
+	mariadbDeals   map[string]*mariadbDeal
+	mariadbs       map[string]*mariadbInstance
+	mariadbFlows   map[int64]*mariadbFlow
+	loadBalancers  map[string]*loadBalancer
+	users          map[string]*camUser
+}
+
+// NewServer returns a server which accepts requests signed by the credentials (secret id -> secret key),
+// call Start to serve requests.
+func NewServer(credentials map[string]string) *Server {
+	server := &Server{
+		PendingPolls:   DEFAULT_PENDING_POLLS,
+		credentials:    make(map[string]string),
+		changes:        make(map[string][]*pendingChange),
+		instances:      make(map[string]*instance),
+		vpcs:           make(map[string]*vpcResource),
+		subnets:        make(map[string]*subnetResource),
+		routeTables:    make(map[string]*routeTableResource),
+		securityGroups: make(map[string]*securityGroupResource),
+		disks:          make(map[string]*diskResource),
+		cdbInstances:   make(map[string]*cdbInstance),
+		cdbRequests:    make(map[string]*cdbAsyncRequest),
+		redisInstances: make(map[string]*redisInstance),
+		redisDeals:     make(map[string]*redisDeal),
+		mariadbDeals:   make(map[string]*mariadbDeal),
+		mariadbs:       make(map[string]*mariadbInstance),
+		mariadbFlows:   make(map[int64]*mariadbFlow),
+		loadBalancers:  make(map[string]*loadBalancer),
+		users:          make(map[string]*camUser),
+	}
+	for secretId, secretKey := range credentials {
+		server.credentials[secretId] = secretKey
+	}
+	return server
+}
+
+func (server *Server) Start() {
+	server.httpServer = httptest.NewServer(server)
+}
+
+func (server *Server) Close() {
+	if server.httpServer != nil {
+		server.httpServer.Close()
+	}
+}
+
+func (server *Server) URL() string {
+	return server.httpServer.URL
+}
+
+// Host returns the "host:port" of the started server.
+func (server *Server) Host() string {
+	return strings.TrimPrefix(server.httpServer.URL, "http://")
+}
+
+// Endpoints returns the endpoint of every service, which can be used by plugins.ClientFactory
+// with the "http" scheme.
+func (server *Server) Endpoints() map[string]string {
+	endpoints := make(map[string]string)
+	for _, service := range Services {
+		endpoints[service] = server.Host()
+	}
+	return endpoints
+}
+
+// AddCredential allows requests signed by the secret id and key.
+func (server *Server) AddCredential(secretId, secretKey string) {
+	server.mutex.Lock()
+	defer server.mutex.Unlock()
+
+	server.credentials[secretId] = secretKey
+}
+
+// ResourceIds returns the ids of existing resources of the kind, such as "instance", "vpc", "subnet",
+// "route-table", "security-group", "disk", "cdb", "redis", "mariadb", "clb" or "user".
+func (server *Server) ResourceIds(kind string) []string {
+	server.mutex.Lock()
+	defer server.mutex.Unlock()
+
+	ids := []string{}
+	add := func(id string) { ids = append(ids, id) }
+	switch kind {
+	case "instance":
+		for id := range server.instances {
+			add(id)
+		}
+	case "vpc":
+		for id := range server.vpcs {
+			add(id)
+		}
+	case "subnet":
+		for id := range server.subnets {
+			add(id)
+		}
+	case "route-table":
+		for id, routeTable := range server.routeTables {
+			if !routeTable.main {
+				add(id)
+			}
+		}
+	case "security-group":
+		for id := range server.securityGroups {
+			add(id)
+		}
+	case "disk":
+		for id := range server.disks {
+			add(id)
+		}
+	case "cdb":
+		for id := range server.cdbInstances {
+			add(id)
+		}
+	case "redis":
+		for id := range server.redisInstances {
+			add(id)
+		}
+	case "mariadb":
+		for id := range server.mariadbs {
+			add(id)
+		}
+	case "clb":
+		for id := range server.loadBalancers {
+			add(id)
+		}
+	case "user":
+		for name := range server.users {
+			add(name)
+		}
+	}
+	return ids
+}
+
+func (server *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	action := r.Header.Get("X-TC-Action")
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		writeResponse(w, action, nil, newApiError(ERROR_CODE_INVALID_PARAMETER, "read request body meet error=%v", err))
+		return
+	}
+
+	server.mutex.Lock()
+	defer server.mutex.Unlock()
+
+	service, secretId, err := server.authenticate(r, body)
+	if err != nil {
+		writeResponse(w, action, nil, err)
+		return
+	}
+
+	handler, found := actions[service][action]
+	if !found {
+		writeResponse(w, action, nil, newApiError(ERROR_CODE_INVALID_ACTION, "the action %s of service %s is not supported", action, service))
+		return
+	}
+
+	ctx := &actionContext{
+		server:   server,
+		region:   r.Header.Get("X-TC-Region"),
+		secretId: secretId,
+		body:     body,
+	}
+	response, err := handler(ctx)
+	writeResponse(w, action, response, err)
+}
+
+// authenticate verifies the TC3-HMAC-SHA256 signature of the request and returns
+// the service and the secret id in the credential scope.
+func (server *Server) authenticate(r *http.Request, body []byte) (string, string, error) {
+	if r.Method != http.MethodPost {
+		return "", "", newApiError(ERROR_CODE_UNSUPPORTED_OPERATION, "only POST requests with a json body are supported")
+	}
+
+	// TC3-HMAC-SHA256 Credential={secretId}/{date}/{service}/tc3_request, SignedHeaders=content-type;host, Signature={signature}
+	authorization := r.Header.Get("Authorization")
+	if !strings.HasPrefix(authorization, TC3_ALGORITHM+" ") {
+		return "", "", newApiError(ERROR_CODE_INVALID_AUTHORIZATION, "authorization should start with %s", TC3_ALGORITHM)
+	}
+	fields := make(map[string]string)
+	for _, field := range strings.Split(strings.TrimPrefix(authorization, TC3_ALGORITHM+" "), ",") {
+		keyValue := strings.SplitN(strings.TrimSpace(field), "=", 2)
+		if len(keyValue) == 2 {
+			fields[keyValue[0]] = keyValue[1]
+		}
+	}
+	scope := strings.Split(fields["Credential"], "/")
+	if len(scope) != 4 || scope[3] != TC3_TERMINATOR || fields["SignedHeaders"] != "content-type;host" || fields["Signature"] == "" {
+		return "", "", newApiError(ERROR_CODE_INVALID_AUTHORIZATION, "authorization (%s) is not valid", authorization)
+	}
+	secretId, date, service := scope[0], scope[1], scope[2]
+
+	secretKey, found := server.credentials[secretId]
+	if !found {
+		return "", "", newApiError(ERROR_CODE_SECRET_ID_NOT_FOUND, "the secret id (%s) does not exist", secretId)
+	}
+
+	timestamp, err := strconv.ParseInt(r.Header.Get("X-TC-Timestamp"), 10, 64)
+	if err != nil {
+		return "", "", newApiError(ERROR_CODE_INVALID_AUTHORIZATION, "X-TC-Timestamp is not valid")
+	}
+	signedAt := time.Unix(timestamp, 0).UTC()
+	if gap := time.Since(signedAt); gap > MAX_TIMESTAMP_GAP || gap < -MAX_TIMESTAMP_GAP {
+		return "", "", newApiError(ERROR_CODE_SIGNATURE_EXPIRE, "the signature is expired, timestamp=%v", timestamp)
+	}
+	if signedAt.Format("2006-01-02") != date {
+		return "", "", newApiError(ERROR_CODE_INVALID_AUTHORIZATION, "the date (%s) of credential scope does not match the timestamp", date)
+	}
+
+	payloadHash := sha256Hex(string(body))
+	if r.Header.Get("X-TC-Content-SHA256") == "UNSIGNED-PAYLOAD" {
+		payloadHash = sha256Hex("UNSIGNED-PAYLOAD")
+	}
+	canonicalRequest := fmt.Sprintf("%s\n/\n%s\ncontent-type:%s\nhost:%s\n\ncontent-type;host\n%s",
+		r.Method, r.URL.RawQuery, r.Header.Get("Content-Type"), r.Host, payloadHash)
+	credentialScope := fmt.Sprintf("%s/%s/%s", date, service, TC3_TERMINATOR)
+	stringToSign := fmt.Sprintf("%s\n%d\n%s\n%s", TC3_ALGORITHM, timestamp, credentialScope, sha256Hex(canonicalRequest))
+
+	secretDate := hmacSha256(date, "TC3"+secretKey)
+	secretService := hmacSha256(service, secretDate)
+	secretSigning := hmacSha256(TC3_TERMINATOR, secretService)
+	signature := hex.EncodeToString([]byte(hmacSha256(stringToSign, secretSigning)))
+	if !hmac.Equal([]byte(signature), []byte(fields["Signature"])) {
+		return "", "", newApiError(ERROR_CODE_SIGNATURE_FAILURE, "the signature of the request is not correct")
+	}
+	return service, secretId, nil
+}
+
+func writeResponse(w http.ResponseWriter, action string, response map[string]interface{}, err error) {
+	requestId := newRequestId()
+	if err != nil {
+		apiErr, ok := err.(*apiError)
+		if !ok {
+			apiErr = newApiError("InternalError", "%v", err)
+		}
+		logrus.Infof("fake qcloud action %s meet error=%v", action, apiErr)
+		response = map[string]interface{}{
+			"Error": map[string]string{"Code": apiErr.Code, "Message": apiErr.Message},
+		}
+	}
+	if response == nil {
+		response = make(map[string]interface{})
+	}
+	response["RequestId"] = requestId
+
+	// errors are returned with status 200 too, same as the real API.
+	w.Header().Set("Content-Type", "application/json")
+	b, _ := json.Marshal(map[string]interface{}{"Response": response})
+	w.Write(b)
+}
+
+var requestSequence = struct {
+	sync.Mutex
+	value int
+}{}
+
+func newRequestId() string {
+	requestSequence.Lock()
+	defer requestSequence.Unlock()
+
+	requestSequence.value++
+	return fmt.Sprintf("fake-request-%08d", requestSequence.value)
+}
+
+// newId returns a new resource id, such as "ins-00000001".
+func (server *Server) newId(prefix string) string {
+	server.sequence++
+	return fmt.Sprintf("%s-%08x", prefix, server.sequence)
+}
+
+// later applies the change of the resource after it has been polled server.PendingPolls times.
+func (server *Server) later(id string, apply func()) {
+	if server.PendingPolls <= 0 {
+		apply()
+		return
+	}
+	server.changes[id] = append(server.changes[id], &pendingChange{polls: server.PendingPolls, apply: apply})
+}
+
+// poll is called each time the resource is described, it applies the first pending change
+// of the resource when the change is due.
+func (server *Server) poll(id string) {
+	changes := server.changes[id]
+	if len(changes) == 0 {
+		return
+	}
+	changes[0].polls--
+	if changes[0].polls > 0 {
+		return
+	}
+	if len(changes) == 1 {
+		delete(server.changes, id)
+	} else {
+		server.changes[id] = changes[1:]
+	}
+	changes[0].apply()
+}
+
+func (server *Server) hasPendingChanges(id string) bool {
+	return len(server.changes[id]) > 0
+}
+
+func (server *Server) forget(id string) {
+	delete(server.changes, id)
+}
+
+func sha256Hex(s string) string {
+	b := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(b[:])
+}
+
+func hmacSha256(s, key string) string {
+	hashed := hmac.New(sha256.New, []byte(key))
+	hashed.Write([]byte(s))
+	return string(hashed.Sum(nil))
+}
+
+func stringValue(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+func int64Value(i *int64) int64 {
+	if i == nil {
+		return 0
+	}
+	return *i
+}
+
+func uint64Value(i *uint64) uint64 {
+	if i == nil {
+		return 0
+	}
+	return *i
+}
+
+func stringValues(values []*string) []string {
+	result := []string{}
+	for _, value := range values {
+		if value != nil {
+			result = append(result, *value)
+		}
+	}
+	return result
+}
+
+func requireString(name string, value *string) error {
+	if value == nil || *value == "" {
+		return newApiError(ERROR_CODE_MISSING_PARAMETER, "the parameter %s is missing", name)
+	}
+	return nil
+}
+
+type filter struct {
+	Name   string   `json:"Name"`
+	Values []string `json:"Values"`
+}
+
+// filters returns the values of the "Filters" parameter by the filter name.
+func (ctx *actionContext) filters() (map[string][]string, error) {
+	request := struct {
+		Filters []filter `json:"Filters"`
+	}{}
+	if err := ctx.decode(&request); err != nil {
+		return nil, err
+	}
+	filters := make(map[string][]string)
+	for _, f := range request.Filters {
+		filters[f.Name] = append(filters[f.Name], f.Values...)
+	}
+	return filters, nil
+}
+
+// matchFilters returns true if the fields of a resource match all the filters, unknown filters are rejected.
+func matchFilters(filters map[string][]string, fields map[string]string) bool {
+	for name, values := range filters {
+		value, found := fields[name]
+		if !found || !containsString(values, value) {
+			return false
+		}
+	}
+	return true
+}
+
+// sortByField sorts the slice by the key of its elements, so responses are stable.
+func sortByField(slice interface{}, key func(i int) string) {
+	sort.Slice(slice, func(i, j int) bool {
+		return key(i) < key(j)
+	})
+}
+
+func now() string {
+	return time.Now().Format("2006-01-02 15:04:05")
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
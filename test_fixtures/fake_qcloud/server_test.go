@@ -0,0 +1,126 @@
+package fake_qcloud
+
+import (
+	"testing"
+
+	"github.com/WeBankPartners/wecube-plugins-qcloud/plugins"
+	"github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/common"
+	"github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/common/errors"
+	cvm "github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/cvm/v20170312"
+	vpc "github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/vpc/v20170312"
+)
+
+const (
+	testRegion    = "ap-guangzhou"
+	testSecretId  = "fake-secret-id"
+	testSecretKey = "fake-secret-key"
+)
+
+func newTestServer() (*Server, *plugins.ClientFactory) {
+	server := NewServer(map[string]string{testSecretId: testSecretKey})
+	server.Start()
+	return server, &plugins.ClientFactory{Scheme: "http", Endpoints: server.Endpoints()}
+}
+
+func errorCode(err error) string {
+	if sdkErr, ok := err.(*errors.TencentCloudSDKError); ok {
+		return sdkErr.GetCode()
+	}
+	return ""
+}
+
+func TestServerRejectsWrongCredentials(t *testing.T) {
+	server, factory := newTestServer()
+	defer server.Close()
+
+	client, _ := factory.NewCvmClient(testRegion, testSecretId, "wrong-secret-key")
+	_, err := client.DescribeInstances(cvm.NewDescribeInstancesRequest())
+	if code := errorCode(err); code != ERROR_CODE_SIGNATURE_FAILURE {
+		t.Errorf("wrong secret key, error code=%v, error=%v", code, err)
+	}
+
+	client, _ = factory.NewCvmClient(testRegion, "unknown-secret-id", testSecretKey)
+	_, err = client.DescribeInstances(cvm.NewDescribeInstancesRequest())
+	if code := errorCode(err); code != ERROR_CODE_SECRET_ID_NOT_FOUND {
+		t.Errorf("unknown secret id, error code=%v, error=%v", code, err)
+	}
+
+	client, _ = factory.NewCvmClient(testRegion, testSecretId, testSecretKey)
+	if _, err = client.DescribeInstances(cvm.NewDescribeInstancesRequest()); err != nil {
+		t.Errorf("DescribeInstances meet error=%v", err)
+	}
+}
+
+func TestServerAppliesChangesAfterPolling(t *testing.T) {
+	server, factory := newTestServer()
+	defer server.Close()
+	server.PendingPolls = 2
+
+	vpcClient, _ := factory.NewVpcClient(testRegion, testSecretId, testSecretKey)
+	vpcRequest := vpc.NewCreateVpcRequest()
+	vpcRequest.VpcName = common.StringPtr("vpc")
+	vpcRequest.CidrBlock = common.StringPtr("10.0.0.0/16")
+	vpcResponse, err := vpcClient.CreateVpc(vpcRequest)
+	if err != nil {
+		t.Fatalf("CreateVpc meet error=%v", err)
+	}
+	vpcId := *vpcResponse.Response.Vpc.VpcId
+
+	subnetRequest := vpc.NewCreateSubnetRequest()
+	subnetRequest.VpcId = common.StringPtr(vpcId)
+	subnetRequest.SubnetName = common.StringPtr("subnet")
+	subnetRequest.CidrBlock = common.StringPtr("10.0.1.0/24")
+	subnetRequest.Zone = common.StringPtr("ap-guangzhou-3")
+	subnetResponse, err := vpcClient.CreateSubnet(subnetRequest)
+	if err != nil {
+		t.Fatalf("CreateSubnet meet error=%v", err)
+	}
+
+	cvmClient, _ := factory.NewCvmClient(testRegion, testSecretId, testSecretKey)
+	runRequest := cvm.NewRunInstancesRequest()
+	runRequest.Placement = &cvm.Placement{Zone: common.StringPtr("ap-guangzhou-3")}
+	runRequest.ImageId = common.StringPtr("img-test")
+	runRequest.InstanceChargeType = common.StringPtr(INSTANCE_CHARGE_TYPE_BY_HOUR)
+	runRequest.InstanceType = common.StringPtr("S2.SMALL1")
+	runRequest.SystemDisk = &cvm.SystemDisk{DiskType: common.StringPtr("CLOUD_PREMIUM"), DiskSize: common.Int64Ptr(50)}
+	runRequest.VirtualPrivateCloud = &cvm.VirtualPrivateCloud{
+		VpcId:    common.StringPtr(vpcId),
+		SubnetId: subnetResponse.Response.Subnet.SubnetId,
+	}
+	runResponse, err := cvmClient.RunInstances(runRequest)
+	if err != nil {
+		t.Fatalf("RunInstances meet error=%v", err)
+	}
+
+	describeRequest := cvm.NewDescribeInstancesRequest()
+	describeRequest.InstanceIds = runResponse.Response.InstanceIdSet
+	for i, expected := range []string{INSTANCE_STATE_PENDING, INSTANCE_STATE_RUNNING, INSTANCE_STATE_RUNNING} {
+		describeResponse, err := cvmClient.DescribeInstances(describeRequest)
+		if err != nil {
+			t.Fatalf("DescribeInstances meet error=%v", err)
+		}
+		if len(describeResponse.Response.InstanceSet) != 1 {
+			t.Fatalf("describe %d, instances=%v", i, describeResponse.Response.InstanceSet)
+		}
+		if state := *describeResponse.Response.InstanceSet[0].InstanceState; state != expected {
+			t.Errorf("describe %d, state=%v, expected %v", i, state, expected)
+		}
+	}
+
+	deleteRequest := vpc.NewDeleteVpcRequest()
+	deleteRequest.VpcId = common.StringPtr(vpcId)
+	if _, err = vpcClient.DeleteVpc(deleteRequest); errorCode(err) != ERROR_CODE_RESOURCE_IN_USE {
+		t.Errorf("DeleteVpc in use, error=%v", err)
+	}
+}
+
+func TestServerRejectsUnknownAction(t *testing.T) {
+	server, factory := newTestServer()
+	defer server.Close()
+
+	client, _ := factory.NewCvmClient(testRegion, testSecretId, testSecretKey)
+	_, err := client.ResetInstance(cvm.NewResetInstanceRequest())
+	if code := errorCode(err); code != ERROR_CODE_INVALID_ACTION {
+		t.Errorf("error code=%v, error=%v", code, err)
+	}
+}
@@ -0,0 +1,519 @@
+package fake_qcloud
+
+import (
+	"encoding/binary"
+	"net"
+
+	"github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/common"
+	vpc "github.com/tencentcloud/tencentcloud-sdk-go/tencentcloud/vpc/v20170312"
+)
+
+type vpcResource struct {
+	region string
+	info   *vpc.Vpc
+	cidr   *net.IPNet
+}
+
+type subnetResource struct {
+	region string
+	info   *vpc.Subnet
+	cidr   *net.IPNet
+	// nextHost is the host number of the next allocated ip, the first hosts are reserved.
+	nextHost uint32
+}
+
+type routeTableResource struct {
+	region string
+	main   bool
+	info   *vpc.RouteTable
+}
+
+type securityGroupResource struct {
+	region string
+	info   *vpc.SecurityGroup
+}
+
+func init() {
+	registerAction("vpc", "CreateVpc", createVpc)
+	registerAction("vpc", "DescribeVpcs", describeVpcs)
+	registerAction("vpc", "DeleteVpc", deleteVpc)
+	registerAction("vpc", "CreateSubnet", createSubnet)
+	registerAction("vpc", "DescribeSubnets", describeSubnets)
+	registerAction("vpc", "DeleteSubnet", deleteSubnet)
+	registerAction("vpc", "CreateRouteTable", createRouteTable)
+	registerAction("vpc", "DescribeRouteTables", describeRouteTables)
+	registerAction("vpc", "DeleteRouteTable", deleteRouteTable)
+	registerAction("vpc", "ReplaceRouteTableAssociation", replaceRouteTableAssociation)
+	registerAction("vpc", "CreateSecurityGroup", createSecurityGroup)
+	registerAction("vpc", "DescribeSecurityGroups", describeSecurityGroups)
+	registerAction("vpc", "DeleteSecurityGroup", deleteSecurityGroup)
+}
+
+func createVpc(ctx *actionContext) (map[string]interface{}, error) {
+	request := vpc.NewCreateVpcRequest()
+	if err := ctx.decode(request); err != nil {
+		return nil, err
+	}
+	if err := requireString("VpcName", request.VpcName); err != nil {
+		return nil, err
+	}
+	_, cidr, err := net.ParseCIDR(stringValue(request.CidrBlock))
+	if err != nil {
+		return nil, newApiError(ERROR_CODE_INVALID_VALUE, "the CidrBlock (%s) is not valid", stringValue(request.CidrBlock))
+	}
+	if ones, _ := cidr.Mask.Size(); ones < 16 || ones > 28 {
+		return nil, newApiError(ERROR_CODE_INVALID_VALUE, "the mask of CidrBlock (%s) should be in [16, 28]", cidr)
+	}
+
+	server := ctx.server
+	vpcId := server.newId("vpc")
+	info := &vpc.Vpc{
+		VpcId:       common.StringPtr(vpcId),
+		VpcName:     request.VpcName,
+		CidrBlock:   common.StringPtr(cidr.String()),
+		IsDefault:   common.BoolPtr(false),
+		CreatedTime: common.StringPtr(now()),
+	}
+	server.vpcs[vpcId] = &vpcResource{region: ctx.region, info: info, cidr: cidr}
+
+	// every vpc has a main route table, which is deleted with the vpc.
+	routeTableId := server.newId("rtb")
+	server.routeTables[routeTableId] = &routeTableResource{
+		region: ctx.region,
+		main:   true,
+		info: &vpc.RouteTable{
+			VpcId:          common.StringPtr(vpcId),
+			RouteTableId:   common.StringPtr(routeTableId),
+			RouteTableName: common.StringPtr("default"),
+			Main:           common.BoolPtr(true),
+			CreatedTime:    common.StringPtr(now()),
+		},
+	}
+	return map[string]interface{}{"Vpc": info}, nil
+}
+
+func describeVpcs(ctx *actionContext) (map[string]interface{}, error) {
+	request := vpc.NewDescribeVpcsRequest()
+	if err := ctx.decode(request); err != nil {
+		return nil, err
+	}
+	ids := stringValues(request.VpcIds)
+	filters, err := ctx.filters()
+	if err != nil {
+		return nil, err
+	}
+
+	vpcSet := []*vpc.Vpc{}
+	for id, resource := range ctx.server.vpcs {
+		if resource.region != ctx.region || (len(ids) > 0 && !containsString(ids, id)) {
+			continue
+		}
+		if !matchFilters(filters, map[string]string{"vpc-id": id, "vpc-name": stringValue(resource.info.VpcName)}) {
+			continue
+		}
+		vpcSet = append(vpcSet, resource.info)
+	}
+	sortByField(vpcSet, func(i int) string { return *vpcSet[i].VpcId })
+	return map[string]interface{}{"TotalCount": len(vpcSet), "VpcSet": vpcSet}, nil
+}
+
+func deleteVpc(ctx *actionContext) (map[string]interface{}, error) {
+	request := vpc.NewDeleteVpcRequest()
+	if err := ctx.decode(request); err != nil {
+		return nil, err
+	}
+	server := ctx.server
+	vpcId := stringValue(request.VpcId)
+	resource, found := server.vpcs[vpcId]
+	if !found || resource.region != ctx.region {
+		return nil, notFoundError("vpc", vpcId)
+	}
+	for id, subnet := range server.subnets {
+		if *subnet.info.VpcId == vpcId {
+			return nil, newApiError(ERROR_CODE_RESOURCE_IN_USE, "the vpc (%s) still has subnet (%s)", vpcId, id)
+		}
+	}
+	for id, routeTable := range server.routeTables {
+		if *routeTable.info.VpcId == vpcId && !routeTable.main {
+			return nil, newApiError(ERROR_CODE_RESOURCE_IN_USE, "the vpc (%s) still has route table (%s)", vpcId, id)
+		}
+	}
+	if id, found := server.findVpcUser(vpcId); found {
+		return nil, newApiError(ERROR_CODE_RESOURCE_IN_USE, "the vpc (%s) is still used by (%s)", vpcId, id)
+	}
+
+	for id, routeTable := range server.routeTables {
+		if *routeTable.info.VpcId == vpcId {
+			delete(server.routeTables, id)
+		}
+	}
+	delete(server.vpcs, vpcId)
+	return nil, nil
+}
+
+func createSubnet(ctx *actionContext) (map[string]interface{}, error) {
+	request := vpc.NewCreateSubnetRequest()
+	if err := ctx.decode(request); err != nil {
+		return nil, err
+	}
+	if err := requireString("SubnetName", request.SubnetName); err != nil {
+		return nil, err
+	}
+	server := ctx.server
+	vpcId := stringValue(request.VpcId)
+	vpcResource, found := server.vpcs[vpcId]
+	if !found || vpcResource.region != ctx.region {
+		return nil, notFoundError("vpc", vpcId)
+	}
+	if _, err := server.findZone(ctx.region, stringValue(request.Zone)); err != nil {
+		return nil, err
+	}
+	_, cidr, err := net.ParseCIDR(stringValue(request.CidrBlock))
+	if err != nil {
+		return nil, newApiError(ERROR_CODE_INVALID_VALUE, "the CidrBlock (%s) is not valid", stringValue(request.CidrBlock))
+	}
+	if !cidrContains(vpcResource.cidr, cidr) {
+		return nil, newApiError(ERROR_CODE_INVALID_VALUE+".SubnetRange", "the subnet (%s) is not in the range of vpc (%s)", cidr, vpcResource.cidr)
+	}
+	for id, subnet := range server.subnets {
+		if *subnet.info.VpcId == vpcId && cidrOverlaps(subnet.cidr, cidr) {
+			return nil, newApiError(ERROR_CODE_INVALID_VALUE+".SubnetConflict", "the subnet (%s) conflicts with subnet (%s)", cidr, id)
+		}
+	}
+
+	routeTableId := ""
+	for id, routeTable := range server.routeTables {
+		if *routeTable.info.VpcId == vpcId && routeTable.main {
+			routeTableId = id
+		}
+	}
+
+	subnetId := server.newId("subnet")
+	ones, bits := cidr.Mask.Size()
+	total := uint64(1)<<uint(bits-ones) - 3
+	info := &vpc.Subnet{
+		VpcId:                   common.StringPtr(vpcId),
+		SubnetId:                common.StringPtr(subnetId),
+		SubnetName:              request.SubnetName,
+		CidrBlock:               common.StringPtr(cidr.String()),
+		IsDefault:               common.BoolPtr(false),
+		Zone:                    request.Zone,
+		RouteTableId:            common.StringPtr(routeTableId),
+		CreatedTime:             common.StringPtr(now()),
+		AvailableIpAddressCount: common.Uint64Ptr(total),
+		TotalIpAddressCount:     common.Uint64Ptr(total),
+	}
+	server.subnets[subnetId] = &subnetResource{region: ctx.region, info: info, cidr: cidr, nextHost: 2}
+	return map[string]interface{}{"Subnet": info}, nil
+}
+
+func describeSubnets(ctx *actionContext) (map[string]interface{}, error) {
+	request := vpc.NewDescribeSubnetsRequest()
+	if err := ctx.decode(request); err != nil {
+		return nil, err
+	}
+	ids := stringValues(request.SubnetIds)
+	filters, err := ctx.filters()
+	if err != nil {
+		return nil, err
+	}
+
+	subnetSet := []*vpc.Subnet{}
+	for id, resource := range ctx.server.subnets {
+		if resource.region != ctx.region || (len(ids) > 0 && !containsString(ids, id)) {
+			continue
+		}
+		if !matchFilters(filters, map[string]string{"subnet-id": id, "vpc-id": *resource.info.VpcId, "zone": *resource.info.Zone}) {
+			continue
+		}
+		subnetSet = append(subnetSet, resource.info)
+	}
+	sortByField(subnetSet, func(i int) string { return *subnetSet[i].SubnetId })
+	return map[string]interface{}{"TotalCount": len(subnetSet), "SubnetSet": subnetSet}, nil
+}
+
+func deleteSubnet(ctx *actionContext) (map[string]interface{}, error) {
+	request := vpc.NewDeleteSubnetRequest()
+	if err := ctx.decode(request); err != nil {
+		return nil, err
+	}
+	server := ctx.server
+	subnetId := stringValue(request.SubnetId)
+	resource, found := server.subnets[subnetId]
+	if !found || resource.region != ctx.region {
+		return nil, notFoundError("subnet", subnetId)
+	}
+	if id, found := server.findSubnetUser(subnetId); found {
+		return nil, newApiError(ERROR_CODE_RESOURCE_IN_USE, "the subnet (%s) is still used by (%s)", subnetId, id)
+	}
+	delete(server.subnets, subnetId)
+	return nil, nil
+}
+
+// allocateIp returns the next free ip of the subnet.
+func (server *Server) allocateIp(subnetId string) string {
+	subnet := server.subnets[subnetId]
+	base := binary.BigEndian.Uint32(subnet.cidr.IP.To4())
+	ip := make(net.IP, 4)
+	binary.BigEndian.PutUint32(ip, base+subnet.nextHost)
+	subnet.nextHost++
+	*subnet.info.AvailableIpAddressCount--
+	return ip.String()
+}
+
+// checkSubnet returns error if the subnet does not exist or does not belong to the vpc.
+func (server *Server) checkSubnet(region, vpcId, subnetId string) error {
+	if vpcResource, found := server.vpcs[vpcId]; !found || vpcResource.region != region {
+		return notFoundError("vpc", vpcId)
+	}
+	subnet, found := server.subnets[subnetId]
+	if !found || subnet.region != region {
+		return notFoundError("subnet", subnetId)
+	}
+	if *subnet.info.VpcId != vpcId {
+		return newApiError(ERROR_CODE_INVALID_VALUE, "the subnet (%s) does not belong to vpc (%s)", subnetId, vpcId)
+	}
+	return nil
+}
+
+// networkUsers returns the (vpc id, subnet id) of every resource placed in a subnet, keyed by the resource id.
+func (server *Server) networkUsers() map[string][2]string {
+	users := make(map[string][2]string)
+	for id, resource := range server.instances {
+		users[id] = [2]string{*resource.info.VirtualPrivateCloud.VpcId, *resource.info.VirtualPrivateCloud.SubnetId}
+	}
+	for id, resource := range server.cdbInstances {
+		users[id] = [2]string{*resource.info.UniqVpcId, *resource.info.UniqSubnetId}
+	}
+	for id, resource := range server.redisInstances {
+		users[id] = [2]string{*resource.info.UniqVpcId, *resource.info.UniqSubnetId}
+	}
+	for id, resource := range server.mariadbs {
+		users[id] = [2]string{*resource.info.UniqueVpcId, *resource.info.UniqueSubnetId}
+	}
+	for id, resource := range server.loadBalancers {
+		users[id] = [2]string{*resource.info.VpcId, *resource.info.SubnetId}
+	}
+	return users
+}
+
+// findVpcUser returns the id of a resource which is still in the vpc.
+func (server *Server) findVpcUser(vpcId string) (string, bool) {
+	for id, network := range server.networkUsers() {
+		if network[0] == vpcId {
+			return id, true
+		}
+	}
+	return "", false
+}
+
+// findSubnetUser returns the id of a resource which is still in the subnet.
+func (server *Server) findSubnetUser(subnetId string) (string, bool) {
+	for id, network := range server.networkUsers() {
+		if network[1] == subnetId {
+			return id, true
+		}
+	}
+	return "", false
+}
+
+func createRouteTable(ctx *actionContext) (map[string]interface{}, error) {
+	request := vpc.NewCreateRouteTableRequest()
+	if err := ctx.decode(request); err != nil {
+		return nil, err
+	}
+	if err := requireString("RouteTableName", request.RouteTableName); err != nil {
+		return nil, err
+	}
+	server := ctx.server
+	vpcId := stringValue(request.VpcId)
+	if vpcResource, found := server.vpcs[vpcId]; !found || vpcResource.region != ctx.region {
+		return nil, notFoundError("vpc", vpcId)
+	}
+
+	routeTableId := server.newId("rtb")
+	info := &vpc.RouteTable{
+		VpcId:          common.StringPtr(vpcId),
+		RouteTableId:   common.StringPtr(routeTableId),
+		RouteTableName: request.RouteTableName,
+		Main:           common.BoolPtr(false),
+		CreatedTime:    common.StringPtr(now()),
+	}
+	server.routeTables[routeTableId] = &routeTableResource{region: ctx.region, info: info}
+	return map[string]interface{}{"RouteTable": info}, nil
+}
+
+func describeRouteTables(ctx *actionContext) (map[string]interface{}, error) {
+	request := vpc.NewDescribeRouteTablesRequest()
+	if err := ctx.decode(request); err != nil {
+		return nil, err
+	}
+	ids := stringValues(request.RouteTableIds)
+	filters, err := ctx.filters()
+	if err != nil {
+		return nil, err
+	}
+
+	server := ctx.server
+	routeTableSet := []*vpc.RouteTable{}
+	for id, resource := range server.routeTables {
+		if resource.region != ctx.region || (len(ids) > 0 && !containsString(ids, id)) {
+			continue
+		}
+		if !matchFilters(filters, map[string]string{"route-table-id": id, "vpc-id": *resource.info.VpcId, "route-table-name": *resource.info.RouteTableName}) {
+			continue
+		}
+		resource.info.AssociationSet = server.routeTableAssociations(id)
+		routeTableSet = append(routeTableSet, resource.info)
+	}
+	sortByField(routeTableSet, func(i int) string { return *routeTableSet[i].RouteTableId })
+	return map[string]interface{}{"TotalCount": len(routeTableSet), "RouteTableSet": routeTableSet}, nil
+}
+
+func (server *Server) routeTableAssociations(routeTableId string) []*vpc.RouteTableAssociation {
+	associations := []*vpc.RouteTableAssociation{}
+	for id, subnet := range server.subnets {
+		if *subnet.info.RouteTableId == routeTableId {
+			associations = append(associations, &vpc.RouteTableAssociation{
+				SubnetId:     common.StringPtr(id),
+				RouteTableId: common.StringPtr(routeTableId),
+			})
+		}
+	}
+	sortByField(associations, func(i int) string { return *associations[i].SubnetId })
+	return associations
+}
+
+func deleteRouteTable(ctx *actionContext) (map[string]interface{}, error) {
+	request := vpc.NewDeleteRouteTableRequest()
+	if err := ctx.decode(request); err != nil {
+		return nil, err
+	}
+	server := ctx.server
+	routeTableId := stringValue(request.RouteTableId)
+	resource, found := server.routeTables[routeTableId]
+	if !found || resource.region != ctx.region {
+		return nil, notFoundError("route table", routeTableId)
+	}
+	if resource.main {
+		return nil, newApiError(ERROR_CODE_UNSUPPORTED_OPERATION, "the main route table (%s) can not be deleted", routeTableId)
+	}
+	if associations := server.routeTableAssociations(routeTableId); len(associations) > 0 {
+		return nil, newApiError(ERROR_CODE_RESOURCE_IN_USE, "the route table (%s) is still associated with subnet (%s)", routeTableId, *associations[0].SubnetId)
+	}
+	delete(server.routeTables, routeTableId)
+	return nil, nil
+}
+
+func replaceRouteTableAssociation(ctx *actionContext) (map[string]interface{}, error) {
+	request := vpc.NewReplaceRouteTableAssociationRequest()
+	if err := ctx.decode(request); err != nil {
+		return nil, err
+	}
+	server := ctx.server
+	subnetId := stringValue(request.SubnetId)
+	subnet, found := server.subnets[subnetId]
+	if !found || subnet.region != ctx.region {
+		return nil, notFoundError("subnet", subnetId)
+	}
+	routeTableId := stringValue(request.RouteTableId)
+	routeTable, found := server.routeTables[routeTableId]
+	if !found || routeTable.region != ctx.region {
+		return nil, notFoundError("route table", routeTableId)
+	}
+	if *subnet.info.VpcId != *routeTable.info.VpcId {
+		return nil, newApiError(ERROR_CODE_INVALID_VALUE, "the subnet (%s) and route table (%s) are not in the same vpc", subnetId, routeTableId)
+	}
+	subnet.info.RouteTableId = common.StringPtr(routeTableId)
+	return nil, nil
+}
+
+func createSecurityGroup(ctx *actionContext) (map[string]interface{}, error) {
+	request := vpc.NewCreateSecurityGroupRequest()
+	if err := ctx.decode(request); err != nil {
+		return nil, err
+	}
+	if err := requireString("GroupName", request.GroupName); err != nil {
+		return nil, err
+	}
+	if err := requireString("GroupDescription", request.GroupDescription); err != nil {
+		return nil, err
+	}
+	server := ctx.server
+	for _, securityGroup := range server.securityGroups {
+		if securityGroup.region == ctx.region && *securityGroup.info.SecurityGroupName == *request.GroupName {
+			return nil, newApiError(ERROR_CODE_INVALID_VALUE+".Duplicate", "the security group name (%s) already exists", *request.GroupName)
+		}
+	}
+
+	securityGroupId := server.newId("sg")
+	projectId := stringValue(request.ProjectId)
+	if projectId == "" {
+		projectId = "0"
+	}
+	info := &vpc.SecurityGroup{
+		SecurityGroupId:   common.StringPtr(securityGroupId),
+		SecurityGroupName: request.GroupName,
+		SecurityGroupDesc: request.GroupDescription,
+		ProjectId:         common.StringPtr(projectId),
+		IsDefault:         common.BoolPtr(false),
+		CreatedTime:       common.StringPtr(now()),
+	}
+	server.securityGroups[securityGroupId] = &securityGroupResource{region: ctx.region, info: info}
+	return map[string]interface{}{"SecurityGroup": info}, nil
+}
+
+func describeSecurityGroups(ctx *actionContext) (map[string]interface{}, error) {
+	request := vpc.NewDescribeSecurityGroupsRequest()
+	if err := ctx.decode(request); err != nil {
+		return nil, err
+	}
+	ids := stringValues(request.SecurityGroupIds)
+	filters, err := ctx.filters()
+	if err != nil {
+		return nil, err
+	}
+
+	securityGroupSet := []*vpc.SecurityGroup{}
+	for id, resource := range ctx.server.securityGroups {
+		if resource.region != ctx.region || (len(ids) > 0 && !containsString(ids, id)) {
+			continue
+		}
+		if !matchFilters(filters, map[string]string{"security-group-id": id, "security-group-name": *resource.info.SecurityGroupName}) {
+			continue
+		}
+		securityGroupSet = append(securityGroupSet, resource.info)
+	}
+	sortByField(securityGroupSet, func(i int) string { return *securityGroupSet[i].SecurityGroupId })
+	return map[string]interface{}{"TotalCount": len(securityGroupSet), "SecurityGroupSet": securityGroupSet}, nil
+}
+
+func deleteSecurityGroup(ctx *actionContext) (map[string]interface{}, error) {
+	request := vpc.NewDeleteSecurityGroupRequest()
+	if err := ctx.decode(request); err != nil {
+		return nil, err
+	}
+	server := ctx.server
+	securityGroupId := stringValue(request.SecurityGroupId)
+	resource, found := server.securityGroups[securityGroupId]
+	if !found || resource.region != ctx.region {
+		return nil, notFoundError("security group", securityGroupId)
+	}
+	for id, instance := range server.instances {
+		if containsString(stringValues(instance.info.SecurityGroupIds), securityGroupId) {
+			return nil, newApiError(ERROR_CODE_RESOURCE_IN_USE, "the security group (%s) is still bound to instance (%s)", securityGroupId, id)
+		}
+	}
+	delete(server.securityGroups, securityGroupId)
+	return nil, nil
+}
+
+func cidrContains(outer, inner *net.IPNet) bool {
+	outerOnes, _ := outer.Mask.Size()
+	innerOnes, _ := inner.Mask.Size()
+	return innerOnes >= outerOnes && outer.Contains(inner.IP)
+}
+
+func cidrOverlaps(a, b *net.IPNet) bool {
+	return a.Contains(b.IP) || b.Contains(a.IP)
+}
@@ -1,41 +1,73 @@
 package test
 
 import (
-	"encoding/json"
-	"io/ioutil"
 	"testing"
-	"time"
 )
 
+const (
+	REGION = "ap-guangzhou"
+	ZONE   = "ap-guangzhou-3"
+)
+
+var providerParams = ProviderParams(REGION, ZONE)
+
 func TestAllPlugins(t *testing.T) {
+	env := NewFakeEnv(t)
+	defer env.Close()
+
 	//-------CREATION-------//
-	//createResources()
+	resourceIds := createResources(t, env)
 	//-------TERMINATION-------//
-	//terminateResources()
+	terminateResources(t, env, resourceIds)
 
+	env.ExpectNoResources(t, "user", "clb", "cdb", "disk", "instance", "security-group", "subnet", "route-table", "vpc")
 }
 
-func createResources() {
+func TestMariadbPlugin(t *testing.T) {
+	env := NewFakeEnv(t)
+	defer env.Close()
+
+	guid_1 := "guid_1"
+	vpcId, subnetId := createNetwork(t, env)
+	mariadbCreateInput := `
+	{
+		"inputs":[{
+			"guid":"` + guid_1 + `",
+			"seed":"` + SEED + `",
+			"zones": "` + ZONE + `",
+			"node_count": "2",
+			"memory_size": "2",
+			"storage_size": "10",
+			"charge_period": "1",
+			"db_version": "10.1.9",
+			"character_set": "utf8",
+			"lower_case_table_names": "1",
+			"user_name": "mariadb",
+			"vpc_id": "` + vpcId + `",
+			"subnet_id": "` + subnetId + `",
+			"provider_params": "` + providerParams + `"
+		}]
+	}
+	`
+	mariadbId := env.CallPlugin(t, "mariadb", "create", mariadbCreateInput)[guid_1]
+	if ids := env.Qcloud.ResourceIds("mariadb"); len(ids) != 1 || ids[0] != mariadbId {
+		t.Errorf("mariadb id=%v, resources=%v", mariadbId, ids)
+	}
+}
+
+func createNetwork(t *testing.T, env *FakeEnv) (string, string) {
 	guid_1 := "guid_1"
-	guid_2 := "guid_2"
 	vpcCreateInput := `
 	{
-		"Inputs":[{
+		"inputs":[{
 			"guid":"` + guid_1 + `",
 			"name": "VPC-A",
 			"cidr_block": "10.1.0.0/16",
-			"provider_params": "Region=ap-chengdu;AvailableZone=ap-chengdu-1;SecretID=` + SECRET_ID + `;SecretKey=` + SECRET_KEY + `"
-		},{
-			"guid":"` + guid_2 + `",
-			"name": "VPC-B",
-			"cidr_block": "10.2.0.0/16",
-			"provider_params": "Region=ap-chengdu;AvailableZone=ap-chengdu-1;SecretID=` + SECRET_ID + `;SecretKey=` + SECRET_KEY + `"
+			"provider_params": "` + providerParams + `"
 		}]
 	}
 	`
-	vpcs := CallPlugin("vpc", "create", vpcCreateInput)
-	resourceIds["vpcAId"] = vpcs[guid_1]
-	resourceIds["vpcBId"] = vpcs[guid_2]
+	vpcId := env.CallPlugin(t, "vpc", "create", vpcCreateInput)[guid_1]
 
 	subnetCreateInput := `
 	{
@@ -43,95 +75,85 @@ func createResources() {
 			"guid":"` + guid_1 + `",
 			"name": "SUBNET-A",
 			"cidr_block": "10.1.1.0/24",
-			"vpc_id": "` + resourceIds["vpcAId"] + `",
-			    "provider_params": "Region=ap-chengdu;AvailableZone=ap-chengdu-1;SecretID=` + SECRET_ID + `;SecretKey=` + SECRET_KEY + `"
-		},{
-			"guid":"` + guid_2 + `",
-			"name": "SUBNET-B",
-			"cidr_block": "10.2.1.0/24",
-			"vpc_id": "` + resourceIds["vpcBId"] + `",
-			    "provider_params": "Region=ap-chengdu;AvailableZone=ap-chengdu-1;SecretID=` + SECRET_ID + `;SecretKey=` + SECRET_KEY + `"
+			"vpc_id": "` + vpcId + `",
+			"provider_params": "` + providerParams + `"
 		}]
 	}
 	`
-	subnets := CallPlugin("subnet", "create", subnetCreateInput)
-	resourceIds["subnetAId"] = subnets[guid_1]
-	resourceIds["subnetBId"] = subnets[guid_2]
+	subnetId := env.CallPlugin(t, "subnet", "create", subnetCreateInput)[guid_1]
+	return vpcId, subnetId
+}
+
+func createResources(t *testing.T, env *FakeEnv) map[string]string {
+	guid_1 := "guid_1"
+	guid_2 := "guid_2"
+	resourceIds := make(map[string]string)
+	resourceIds["vpcAId"], resourceIds["subnetAId"] = createNetwork(t, env)
 
-	peerConnCreateInput := `
+	routeTableCreateInput := `
 	{
 		"inputs": [{
 			"guid":"` + guid_1 + `",
-			"name": "PeerConnA-B-01",
-			"bandwidth": null,
-			"zone_node_link_type": "Type1",
+			"name": "ROUTE-TABLE-A",
 			"vpc_id": "` + resourceIds["vpcAId"] + `",
-			"provider_params": "Region=ap-chengdu;AvailableZone=ap-chengdu-1;SecretID=` + SECRET_ID + `;SecretKey=` + SECRET_KEY + `",
-			"peer_uin": "100007707812",
-			"peer_vpc_id": "` + resourceIds["vpcBId"] + `",
-			"peer_provider_params": "Region=ap-chengdu;AvailableZone=ap-chengdu-1;SecretID=` + SECRET_ID + `;SecretKey=` + SECRET_KEY + `"
+			"provider_params": "` + providerParams + `"
 		}]
 	}
 	`
-	resourceIds["peerConnId"] = CallPlugin("peering-connection", "create", peerConnCreateInput)[guid_1]
+	resourceIds["routeTableAId"] = env.CallPlugin(t, "route-table", "create", routeTableCreateInput)[guid_1]
 
-	routeTableCreateInput := `
+	routeTableAssociateInput := `
 	{
 		"inputs": [{
 			"guid":"` + guid_1 + `",
-			"name": "ROUTE-TABLE-A",
-			"provider_params": "Region=ap-chengdu;AvailableZone=ap-chengdu-1;SecretID=` + SECRET_ID + `;SecretKey=` + SECRET_KEY + `",
-			"vpc_id": "` + resourceIds["vpcAId"] + `",
-			"route_destination_cidr_block":"10.2.1.0/24",
-			"route_next_type":"PEERCONNECTION",
-			"route_next_id":"` + resourceIds["peerConnId"] + `"
-		},{
-			"guid":"` + guid_2 + `",
-			"name": "ROUTE-TABLE-B",
-			"provider_params": "Region=ap-chengdu;AvailableZone=ap-chengdu-1;SecretID=` + SECRET_ID + `;SecretKey=` + SECRET_KEY + `",
-			"vpc_id": "` + resourceIds["vpcBId"] + `",
-			"route_destination_cidr_block":"10.1.1.0/24",
-			"route_next_type":"PEERCONNECTION",
-			"route_next_id":"` + resourceIds["peerConnId"] + `"
+			"subnet_id": "` + resourceIds["subnetAId"] + `",
+			"route_table_id": "` + resourceIds["routeTableAId"] + `",
+			"provider_params": "` + providerParams + `"
 		}]
 	}
 	`
-	routeTableIds := CallPlugin("route-table", "create", routeTableCreateInput)
-	resourceIds["routeTableAId"] = routeTableIds[guid_1]
-	resourceIds["routeTableBId"] = routeTableIds[guid_2]
+	env.CallPlugin(t, "route-table", "associate-subnet", routeTableAssociateInput)
+
+	securityGroupCreateInput := `
+	{
+		"inputs": [{
+			"guid":"` + guid_1 + `",
+			"name": "Group-A",
+			"description": "PluginAccess",
+			"provider_params": "` + providerParams + `"
+		}]
+	}
+	`
+	resourceIds["securityGroupAId"] = env.CallPlugin(t, "security-group", "create", securityGroupCreateInput)[guid_1]
 
 	vmCreateInput := `
 	{
 		"inputs": [{
 			"guid":"` + guid_1 + `",
+			"seed":"` + SEED + `",
 			"instance_name": "VM-A",
 			"instance_type": "S2.SMALL1",
 			"vpc_id": "` + resourceIds["vpcAId"] + `",
-			"instance_charge_period": null,
 			"image_id": "img-31tjrtph",
-			"instance_id": "ins-qsoy6uct",
 			"instance_charge_type": "POSTPAID_BY_HOUR",
-			"instance_private_ip": null,
-			"system_disk_size": 50,
+			"system_disk_size": "50",
 			"subnet_id": "` + resourceIds["subnetAId"] + `",
-			"provider_params": "Region=ap-chengdu;AvailableZone=ap-chengdu-1;SecretID=` + SECRET_ID + `;SecretKey=` + SECRET_KEY + `"
+			"provider_params": "` + providerParams + `"
 		},{
 			"guid":"` + guid_2 + `",
-			"instance_name": "VM-A",
+			"seed":"` + SEED + `",
+			"instance_name": "VM-B",
 			"instance_type": "S2.SMALL1",
-			"vpc_id": "` + resourceIds["vpcBId"] + `",
-			"instance_charge_period": null,
+			"vpc_id": "` + resourceIds["vpcAId"] + `",
 			"image_id": "img-31tjrtph",
-			"instance_id": "ins-qsoy6uct",
 			"instance_charge_type": "POSTPAID_BY_HOUR",
-			"instance_private_ip": null,
-			"system_disk_size": 50,
-			"subnet_id": "` + resourceIds["subnetBId"] + `",
-			"provider_params": "Region=ap-chengdu;AvailableZone=ap-chengdu-1;SecretID=` + SECRET_ID + `;SecretKey=` + SECRET_KEY + `"
+			"system_disk_size": "50",
+			"subnet_id": "` + resourceIds["subnetAId"] + `",
+			"provider_params": "` + providerParams + `"
 		}]
 	}
 	`
-	vmIds := CallPlugin("vm", "create", vmCreateInput)
+	vmIds := env.CallPlugin(t, "vm", "create", vmCreateInput)
 	resourceIds["vmAId"] = vmIds[guid_1]
 	resourceIds["vmBId"] = vmIds[guid_2]
 
@@ -139,176 +161,175 @@ func createResources() {
 	{
 		"inputs": [{
 			"guid":"` + guid_1 + `",
-			"disk_size": 10,
+			"disk_size": "10",
 			"disk_name": "DISK-A",
 			"disk_type": "CLOUD_BASIC",
 			"instance_id": "` + resourceIds["vmAId"] + `",
 			"disk_charge_type": "POSTPAID_BY_HOUR",
-			"disk_charge_period": null,
-			"provider_params": "Region=ap-chengdu;AvailableZone=ap-chengdu-1;SecretID=` + SECRET_ID + `;SecretKey=` + SECRET_KEY + `"
+			"provider_params": "` + providerParams + `"
 		}]
 	}
 	`
-	resourceIds["storageAId"] = CallPlugin("storage", "create", storageCreateInput)[guid_1]
+	resourceIds["storageAId"] = env.CallPlugin(t, "storage", "create", storageCreateInput)[guid_1]
 
-	securityGroupCreateInput := `
+	mysqlCreateInput := `
 	{
 		"inputs": [{
 			"guid":"` + guid_1 + `",
-			"name": "Group-A",
-			"rule_description": null,
-			"rule_priority": 1,
-			"id": "",
-			"rule_type": "Egress",
-			"description": "PluginAccess",
-			"rule_ip_protocol": "UDP",
-			"rule_port_range": "80",
-			"rule_policy": "DROP",
-			"rule_cidr_ip": "10.10.10.11",
-			"provider_params": "Region=ap-chengdu;AvailableZone=ap-chengdu-1;SecretID=` + SECRET_ID + `;SecretKey=` + SECRET_KEY + `"
+			"seed":"` + SEED + `",
+			"name": "MYSQL-A",
+			"engine_version": "5.7",
+			"memory_size": "1000",
+			"volume_size": "25",
+			"vpc_id": "` + resourceIds["vpcAId"] + `",
+			"subnet_id": "` + resourceIds["subnetAId"] + `",
+			"charge_type": "POSTPAID_BY_HOUR",
+			"user_name": "mysql",
+			"character_set": "utf8",
+			"lower_case_table_names": "1",
+			"instance_role": "master",
+			"provider_params": "` + providerParams + `"
 		}]
 	}
 	`
-	resourceIds["securityGroupAId"] = CallPlugin("security-group", "create", securityGroupCreateInput)[guid_1]
+	resourceIds["mysqlAId"] = env.CallPlugin(t, "mysql", "create", mysqlCreateInput)[guid_1]
 
-	nateGatewayCreateInput := `
+	clbCreateInput := `
 	{
-		"inputs":[{
+		"inputs": [{
 			"guid":"` + guid_1 + `",
-			"name": "NAT-GATEWAY-A",
+			"name": "CLB-A",
+			"type": "internal_lb",
 			"vpc_id": "` + resourceIds["vpcAId"] + `",
-			"max_concurrent": 1000000,
-			"bandwidth": 100,
-			"assigned_eip_set": "",
-			"auto_alloc_eip_num": 1,
-			"provider_params": "Region=ap-chengdu;AvailableZone=ap-chengdu-1;SecretID=` + SECRET_ID + `;SecretKey=` + SECRET_KEY + `"
+			"subnet_id": "` + resourceIds["subnetAId"] + `",
+			"provider_params": "` + providerParams + `"
 		}]
 	}
 	`
-	resourceIds["nateGatewayId"] = CallPlugin("nat-gateway", "create", nateGatewayCreateInput)[guid_1]
+	resourceIds["clbAId"] = env.CallPlugin(t, "clb", "create", clbCreateInput)[guid_1]
 
-	ids, _ := json.MarshalIndent(resourceIds, "", "  ")
-	ioutil.WriteFile("resource.ids", ids, 0666)
+	userAddInput := `
+	{
+		"inputs": [{
+			"guid":"` + guid_1 + `",
+			"seed":"` + SEED + `",
+			"user_name": "USER-A",
+			"password": "Ab888888",
+			"provider_params": "` + providerParams + `"
+		}]
+	}
+	`
+	env.CallPlugin(t, "user", "add", userAddInput)
+	resourceIds["userAName"] = "USER-A"
+
+	return resourceIds
 }
 
-func terminateResources() {
+func terminateResources(t *testing.T, env *FakeEnv, resourceIds map[string]string) {
 	guid_1 := "guid_1"
 	guid_2 := "guid_2"
-	resourceIds := make(map[string]string)
-	bytes, _ := ioutil.ReadFile("resource.ids")
-	json.Unmarshal(bytes, &resourceIds)
 
-	time.Sleep(10 * time.Second)
+	userDeleteInput := `
+	{
+		"inputs": [{
+			"guid":"` + guid_1 + `",
+			"user_name": "` + resourceIds["userAName"] + `",
+			"provider_params": "` + providerParams + `"
+		}]
+	}
+	`
+	env.CallPlugin(t, "user", "delete", userDeleteInput)
 
-	nateGatewayTerminateInput := `
+	clbTerminateInput := `
 	{
-		"inputs":[{
+		"inputs": [{
 			"guid":"` + guid_1 + `",
-			"vpc_id": "` + resourceIds["vpcAId"] + `",
-			"id": "` + resourceIds["nateGatewayId"] + `",
-			"provider_params": "Region=ap-chengdu;AvailableZone=ap-chengdu-1;SecretID=` + SECRET_ID + `;SecretKey=` + SECRET_KEY + `"
+			"id": "` + resourceIds["clbAId"] + `",
+			"provider_params": "` + providerParams + `"
 		}]
 	}
 	`
-	CallPlugin("nat-gateway", "terminate", nateGatewayTerminateInput)
+	env.CallPlugin(t, "clb", "terminate", clbTerminateInput)
 
-	securityGroupTerminateInput := `
+	mysqlTerminateInput := `
 	{
 		"inputs": [{
 			"guid":"` + guid_1 + `",
-			"id": "` + resourceIds["securityGroupAId"] + `",
-			"provider_params": "Region=ap-chengdu;AvailableZone=ap-chengdu-1;SecretID=` + SECRET_ID + `;SecretKey=` + SECRET_KEY + `"
+			"id": "` + resourceIds["mysqlAId"] + `",
+			"provider_params": "` + providerParams + `"
 		}]
 	}
 	`
-	CallPlugin("security-group", "terminate", securityGroupTerminateInput)
+	env.CallPlugin(t, "mysql", "terminate", mysqlTerminateInput)
 
 	storageTerminateInput := `
 	{
 		"inputs": [{
 			"guid":"` + guid_1 + `",
 			"id": "` + resourceIds["storageAId"] + `",
-			"provider_params": "Region=ap-chengdu;AvailableZone=ap-chengdu-1;SecretID=` + SECRET_ID + `;SecretKey=` + SECRET_KEY + `"
+			"instance_id": "` + resourceIds["vmAId"] + `",
+			"provider_params": "` + providerParams + `"
 		}]
 	}
 	`
-	CallPlugin("storage", "terminate", storageTerminateInput)
+	env.CallPlugin(t, "storage", "terminate", storageTerminateInput)
 
 	vmTerminateInput := `
 	{
-	    "inputs": [{
+		"inputs": [{
 			"guid":"` + guid_1 + `",
 			"id": "` + resourceIds["vmAId"] + `",
-			"provider_params": "Region=ap-chengdu;AvailableZone=ap-chengdu-1;SecretID=` + SECRET_ID + `;SecretKey=` + SECRET_KEY + `"
+			"provider_params": "` + providerParams + `"
 		},{
 			"guid":"` + guid_2 + `",
 			"id": "` + resourceIds["vmBId"] + `",
-			"provider_params": "Region=ap-chengdu;AvailableZone=ap-chengdu-1;SecretID=` + SECRET_ID + `;SecretKey=` + SECRET_KEY + `"
-			}]
+			"provider_params": "` + providerParams + `"
+		}]
 	}
 	`
-	CallPlugin("vm", "terminate", vmTerminateInput)
+	env.CallPlugin(t, "vm", "terminate", vmTerminateInput)
 
-	peerConnTerminateInput := `
+	securityGroupTerminateInput := `
 	{
 		"inputs": [{
 			"guid":"` + guid_1 + `",
-			"name": "PeerConnA-B-01",
-			"bandwidth": null,
-			"zone_node_link_type": "Type1",
-			"vpc_id": "` + resourceIds["vpcAId"] + `",
-			"provider_params": "Region=ap-chengdu;AvailableZone=ap-chengdu-1;SecretID=` + SECRET_ID + `;SecretKey=` + SECRET_KEY + `",
-			"peer_uin": "100007707812",
-			"peer_vpc_id": "` + resourceIds["vpcBId"] + `",
-			"peer_provider_params": "Region=ap-chengdu;AvailableZone=ap-chengdu-1;SecretID=` + SECRET_ID + `;SecretKey=` + SECRET_KEY + `",
-			"id":"` + resourceIds["peerConnId"] + `"
+			"id": "` + resourceIds["securityGroupAId"] + `",
+			"provider_params": "` + providerParams + `"
 		}]
 	}
 	`
-	CallPlugin("peering-connection", "terminate", peerConnTerminateInput)
+	env.CallPlugin(t, "security-group", "terminate", securityGroupTerminateInput)
 
 	subnetTerminateInput := `
 	{
 		"inputs":[{
 			"guid":"` + guid_1 + `",
 			"id": "` + resourceIds["subnetAId"] + `",
-			    "provider_params": "Region=ap-chengdu;AvailableZone=ap-chengdu-1;SecretID=` + SECRET_ID + `;SecretKey=` + SECRET_KEY + `"
-		},{
-			"guid":"` + guid_2 + `",
-			"id": "` + resourceIds["subnetBId"] + `",
-			    "provider_params": "Region=ap-chengdu;AvailableZone=ap-chengdu-1;SecretID=` + SECRET_ID + `;SecretKey=` + SECRET_KEY + `"
+			"provider_params": "` + providerParams + `"
 		}]
 	}
 	`
-	CallPlugin("subnet", "terminate", subnetTerminateInput)
+	env.CallPlugin(t, "subnet", "terminate", subnetTerminateInput)
 
 	routeTableTerminateInput := `
 	{
 		"inputs": [{
 			"guid":"` + guid_1 + `",
 			"id": "` + resourceIds["routeTableAId"] + `",
-			"provider_params": "Region=ap-chengdu;AvailableZone=ap-chengdu-1;SecretID=` + SECRET_ID + `;SecretKey=` + SECRET_KEY + `"
-		},{
-		"guid":"` + guid_2 + `",
-			"id": "` + resourceIds["routeTableBId"] + `",
-			"provider_params": "Region=ap-chengdu;AvailableZone=ap-chengdu-1;SecretID=` + SECRET_ID + `;SecretKey=` + SECRET_KEY + `"
+			"provider_params": "` + providerParams + `"
 		}]
 	}
 	`
-	CallPlugin("route-table", "terminate", routeTableTerminateInput)
+	env.CallPlugin(t, "route-table", "terminate", routeTableTerminateInput)
 
 	vpcTerminateInput := `
 	{
 		"inputs":[{
 			"guid":"` + guid_1 + `",
 			"id":"` + resourceIds["vpcAId"] + `",
-			"provider_params": "Region=ap-chengdu;AvailableZone=ap-chengdu-1;SecretID=` + SECRET_ID + `;SecretKey=` + SECRET_KEY + `"
-		},{
-			"guid":"` + guid_2 + `",
-			"id":"` + resourceIds["vpcBId"] + `",
-			"provider_params": "Region=ap-chengdu;AvailableZone=ap-chengdu-1;SecretID=` + SECRET_ID + `;SecretKey=` + SECRET_KEY + `"
+			"provider_params": "` + providerParams + `"
 		}]
-    }
+	}
 	`
-	CallPlugin("vpc", "terminate", vpcTerminateInput)
+	env.CallPlugin(t, "vpc", "terminate", vpcTerminateInput)
 }
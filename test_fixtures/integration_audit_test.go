@@ -0,0 +1,74 @@
+package test
+
+import (
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/WeBankPartners/wecube-plugins-qcloud/plugins"
+	"github.com/WeBankPartners/wecube-plugins-qcloud/router"
+)
+
+type AuditResponse struct {
+	ResultCode string                `json:"resultCode"`
+	Results    []plugins.AuditRecord `json:"results"`
+}
+
+func TestAuditJournal(t *testing.T) {
+	env := NewFakeEnv(t)
+	defer env.Close()
+
+	if status, response := env.request(t, http.MethodGet, router.AUDIT_PATH, "", ""); status != http.StatusNotFound {
+		t.Errorf("audit without journal status=%v, response=%+v", status, response)
+	}
+
+	dir, err := ioutil.TempDir("", "audit")
+	if err != nil {
+		t.Fatalf("create temp dir meet error=%v", err)
+	}
+	defer os.RemoveAll(dir)
+	journal := &plugins.AuditJournal{File: filepath.Join(dir, "audit.log")}
+	defer journal.Close()
+	defer func(journal *plugins.AuditJournal) { plugins.DefaultAuditJournal = journal }(plugins.DefaultAuditJournal)
+	plugins.DefaultAuditJournal = journal
+
+	vpcCreateInput := `
+	{
+		"inputs":[{
+			"guid":"guid_1",
+			"name": "VPC-A",
+			"cidr_block": "10.1.0.0/16",
+			"provider_params": "` + providerParams + `"
+		}]
+	}
+	`
+	vpcId := env.CallPlugin(t, "vpc", "create", vpcCreateInput)["guid_1"]
+	env.CallPlugin(t, "vpc", "terminate", `{"inputs":[{"guid":"guid_1","id":"`+vpcId+`","provider_params":"`+providerParams+`"}]}`)
+	env.ExpectNoResources(t, "vpc")
+
+	response := AuditResponse{}
+	if status := env.getJson(t, router.AUDIT_PATH+"?guid=guid_1", &response); status != http.StatusOK || response.ResultCode != plugins.RESULT_CODE_SUCCESS {
+		t.Fatalf("audit status=%v, response=%+v", status, response)
+	}
+	apiActions := []string{}
+	for _, record := range response.Results {
+		if record.Outcome != plugins.AUDIT_OUTCOME_SUCCESS || record.RequestId == "" || record.CorrelationId == "" {
+			t.Errorf("record=%+v", record)
+		}
+		apiActions = append(apiActions, record.ApiAction)
+	}
+	if len(apiActions) != 2 || apiActions[0] != "CreateVpc" || apiActions[1] != "DeleteVpc" {
+		t.Errorf("audited api actions=%v", apiActions)
+	}
+
+	response = AuditResponse{}
+	env.getJson(t, router.AUDIT_PATH+"?resourceId="+vpcId+"&limit=1", &response)
+	if len(response.Results) != 1 || response.Results[0].ApiAction != "DeleteVpc" {
+		t.Errorf("latest record of %v=%+v", vpcId, response.Results)
+	}
+	if status, response := env.request(t, http.MethodGet, router.AUDIT_PATH+"?start=yesterday", "", ""); status != http.StatusBadRequest {
+		t.Errorf("audit with invalid start status=%v, response=%+v", status, response)
+	}
+}
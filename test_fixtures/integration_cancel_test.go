@@ -0,0 +1,44 @@
+package test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/WeBankPartners/wecube-plugins-qcloud/plugins"
+)
+
+func TestActionTimeout(t *testing.T) {
+	env := NewFakeEnv(t)
+	defer env.Close()
+
+	defer func(policy *plugins.ActionTimeoutPolicy) { plugins.DefaultActionTimeoutPolicy = policy }(plugins.DefaultActionTimeoutPolicy)
+	plugins.DefaultActionTimeoutPolicy = &plugins.ActionTimeoutPolicy{Timeouts: map[string]time.Duration{"vpc.create": time.Nanosecond}}
+
+	vpcCreateInput := `
+	{
+		"inputs":[{
+			"guid":"guid_1",
+			"callbackParameter":"callback_1",
+			"name": "VPC-A",
+			"cidr_block": "10.1.0.0/16",
+			"provider_params": "` + providerParams + `"
+		},{
+			"guid":"guid_2",
+			"name": "VPC-B",
+			"cidr_block": "10.2.0.0/16",
+			"provider_params": "` + providerParams + `"
+		}]
+	}
+	`
+	pluginResponse := env.PostPlugin(t, "vpc", "create", vpcCreateInput)
+	if pluginResponse.ResultCode != plugins.RESULT_CODE_ERROR || !strings.Contains(pluginResponse.ResultMsg, "2 not started [guid_1,guid_2]") {
+		t.Errorf("response=%+v", pluginResponse)
+	}
+	outputs := pluginResponse.Results.Outputs
+	if len(outputs) != 2 || outputs[0].Guid != "guid_1" || outputs[0].CallbackParameter != "callback_1" ||
+		outputs[0].ErrorCode != plugins.RESULT_CODE_ERROR || outputs[1].Guid != "guid_2" {
+		t.Errorf("outputs=%+v", outputs)
+	}
+	env.ExpectNoResources(t, "vpc")
+}
@@ -0,0 +1,89 @@
+package test
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/WeBankPartners/wecube-plugins-qcloud/plugins"
+)
+
+type CorrelationResponse struct {
+	ResultCode    string `json:"resultCode"`
+	CorrelationId string `json:"correlationId"`
+	Results       struct {
+		Outputs []struct {
+			Guid       string   `json:"guid"`
+			Id         string   `json:"id"`
+			RequestIds []string `json:"requestIds"`
+		} `json:"outputs"`
+	} `json:"results"`
+}
+
+func (env *FakeEnv) postWithCorrelationId(t *testing.T, name, action, correlationId, input string) (string, CorrelationResponse) {
+	t.Helper()
+	request, err := http.NewRequest(http.MethodPost, env.pluginHost.URL+"/"+plugins.PROVIDER_NAME+"/"+plugins.VERSION+"/"+name+"/"+action, strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("new request meet error = %v", err)
+	}
+	request.Header.Set("Content-Type", "application/json")
+	if correlationId != "" {
+		request.Header.Set(plugins.CORRELATION_ID_HEADER, correlationId)
+	}
+	output, err := http.DefaultClient.Do(request)
+	if err != nil {
+		t.Fatalf("call plugin server meet error = %v", err)
+	}
+	defer output.Body.Close()
+
+	response := CorrelationResponse{}
+	if err = UnmarshalJson(output.Body, &response); err != nil {
+		t.Fatalf("unmarshal plugin response meet error = %v", err)
+	}
+	return output.Header.Get(plugins.CORRELATION_ID_HEADER), response
+}
+
+func TestCorrelationId(t *testing.T) {
+	env := NewFakeEnv(t)
+	defer env.Close()
+
+	vpcCreateInput := `
+	{
+		"inputs":[{
+			"guid":"guid_1",
+			"name": "VPC-A",
+			"cidr_block": "10.1.0.0/16",
+			"provider_params": "` + providerParams + `"
+		},{
+			"guid":"guid_2",
+			"name": "VPC-B",
+			"cidr_block": "10.2.0.0/16",
+			"provider_params": "` + providerParams + `"
+		}]
+	}
+	`
+	header, response := env.postWithCorrelationId(t, "vpc", "create", "wecube-request-1", vpcCreateInput)
+	if response.ResultCode != plugins.RESULT_CODE_SUCCESS || header != "wecube-request-1" || response.CorrelationId != "wecube-request-1" {
+		t.Fatalf("header=%v, response=%+v", header, response)
+	}
+	requestIds := make(map[string]bool)
+	vpcTerminateInputs := []string{}
+	for _, output := range response.Results.Outputs {
+		if len(output.RequestIds) == 0 {
+			t.Errorf("output of %v has no request id", output.Guid)
+		}
+		for _, requestId := range output.RequestIds {
+			if requestIds[requestId] {
+				t.Errorf("request id %v is listed twice", requestId)
+			}
+			requestIds[requestId] = true
+		}
+		vpcTerminateInputs = append(vpcTerminateInputs, `{"guid":"`+output.Guid+`","id":"`+output.Id+`","provider_params":"`+providerParams+`"}`)
+	}
+
+	header, response = env.postWithCorrelationId(t, "vpc", "terminate", "", `{"inputs":[`+strings.Join(vpcTerminateInputs, ",")+`]}`)
+	if response.ResultCode != plugins.RESULT_CODE_SUCCESS || header == "" || response.CorrelationId != header {
+		t.Errorf("correlation id is not generated, header=%v, response=%+v", header, response)
+	}
+	env.ExpectNoResources(t, "vpc")
+}
@@ -0,0 +1,78 @@
+package test
+
+import (
+	"testing"
+
+	"github.com/WeBankPartners/wecube-plugins-qcloud/plugins"
+)
+
+func TestDryRun(t *testing.T) {
+	env := NewFakeEnv(t)
+	defer env.Close()
+
+	vpcId, subnetId := createNetwork(t, env)
+	vmCreateInput := `
+	{
+		"inputs": [{
+			"guid":"guid_1",
+			"seed":"` + SEED + `",
+			"instance_name": "VM-A",
+			"instance_type": "S2.SMALL1",
+			"vpc_id": "` + vpcId + `",
+			"image_id": "img-31tjrtph",
+			"instance_charge_type": "POSTPAID_BY_HOUR",
+			"system_disk_size": "50",
+			"subnet_id": "` + subnetId + `",
+			"provider_params": "` + providerParams + `"
+		},{
+			"guid":"guid_2",
+			"seed":"` + SEED + `",
+			"instance_name": "VM-B",
+			"instance_type": "S2.SMALL1",
+			"vpc_id": "` + vpcId + `",
+			"image_id": "img-31tjrtph",
+			"instance_charge_type": "POSTPAID_BY_HOUR",
+			"system_disk_size": "50",
+			"subnet_id": "` + subnetId + `",
+			"provider_params": "` + providerParams + `"
+		}]
+	}
+	`
+	pluginResponse := env.PostPlugin(t, "vm", "create?dry_run=true", vmCreateInput)
+	if pluginResponse.ResultCode != plugins.RESULT_CODE_SUCCESS || pluginResponse.Plan == nil {
+		t.Fatalf("response=%+v", pluginResponse)
+	}
+	calls := pluginResponse.Plan.Calls
+	if len(calls) != 2 || calls[0].Guid != "guid_1" || calls[1].Guid != "guid_2" || calls[0].Action != "RunInstances" || !calls[0].Checked {
+		t.Errorf("calls=%+v", calls)
+	}
+	if ids := env.Qcloud.ResourceIds("vm"); len(ids) != 0 {
+		t.Errorf("vms (ids=%v) are created in dry run mode", ids)
+	}
+
+	subnetCreateInput := `
+	{
+		"inputs":[{
+			"guid":"guid_1",
+			"name": "SUBNET-B",
+			"cidr_block": "10.1.2.0/24",
+			"vpc_id": "` + vpcId + `",
+			"provider_params": "` + providerParams + `"
+		}]
+	}
+	`
+	pluginResponse = env.PostPlugin(t, "subnet", "create?dry_run=true", subnetCreateInput)
+	if pluginResponse.ResultCode != plugins.RESULT_CODE_SUCCESS || len(pluginResponse.Plan.Calls) != 1 ||
+		pluginResponse.Plan.Calls[0].Action != "CreateSubnet" || pluginResponse.Plan.Calls[0].Checked {
+		t.Errorf("response=%+v", pluginResponse)
+	}
+	if ids := env.Qcloud.ResourceIds("subnet"); len(ids) != 1 {
+		t.Errorf("subnets=%v, expected the subnet of the network only", ids)
+	}
+
+	// invalid inputs fail as usual.
+	pluginResponse = env.PostPlugin(t, "vm", "create?dry_run=true", `{"inputs":[{"guid":"guid_1","provider_params":"`+providerParams+`"}]}`)
+	if pluginResponse.ResultCode != plugins.RESULT_CODE_ERROR || len(pluginResponse.Plan.Calls) != 0 {
+		t.Errorf("response=%+v", pluginResponse)
+	}
+}
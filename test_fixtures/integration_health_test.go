@@ -0,0 +1,155 @@
+package test
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+
+	_ "github.com/WeBankPartners/wecube-plugins-qcloud/plugins/bussiness_plugins/security_group"
+
+	"github.com/WeBankPartners/wecube-plugins-qcloud/plugins"
+	"github.com/WeBankPartners/wecube-plugins-qcloud/router"
+)
+
+type staticCredentialProvider struct {
+	credential *plugins.Credential
+}
+
+func (provider *staticCredentialProvider) GetCredential() (*plugins.Credential, error) {
+	return provider.credential, nil
+}
+
+func (env *FakeEnv) getJson(t *testing.T, path string, target interface{}) int {
+	t.Helper()
+	output, err := http.Get(env.pluginHost.URL + path)
+	if err != nil {
+		t.Fatalf("call plugin server meet error = %v", err)
+	}
+	defer output.Body.Close()
+
+	if err = UnmarshalJson(output.Body, target); err != nil {
+		t.Fatalf("unmarshal response of %v meet error = %v", path, err)
+	}
+	return output.StatusCode
+}
+
+func TestHealthAndReadiness(t *testing.T) {
+	env := NewFakeEnv(t)
+	defer env.Close()
+
+	health := router.HealthResponse{}
+	if status := env.getJson(t, router.HEALTH_PATH, &health); status != http.StatusOK || health.Status != router.HEALTH_STATUS_OK {
+		t.Errorf("healthz status=%v, response=%+v", status, health)
+	}
+
+	factory := *plugins.GetClientFactory()
+	factory.Credentials = &staticCredentialProvider{credential: &plugins.Credential{SecretId: SECRET_ID, SecretKey: "wrong-secret-key"}}
+	plugins.SetClientFactory(&factory)
+	router.SetReadinessCheck("credential", func(ctx context.Context) error {
+		return plugins.CheckCredential(ctx, REGION)
+	})
+	defer router.SetReadinessCheck("credential", nil)
+
+	ready := router.HealthResponse{}
+	status := env.getJson(t, router.READY_PATH, &ready)
+	if status != http.StatusServiceUnavailable || ready.Status != router.HEALTH_STATUS_UNAVAILABLE ||
+		len(ready.Checks) != 1 || ready.Checks[0].Ready || ready.Checks[0].Message == "" {
+		t.Errorf("readyz with wrong credential, status=%v, response=%+v", status, ready)
+	}
+
+	factory.Credentials = &staticCredentialProvider{credential: &plugins.Credential{SecretId: SECRET_ID, SecretKey: SECRET_KEY}}
+	ready = router.HealthResponse{}
+	status = env.getJson(t, router.READY_PATH, &ready)
+	if status != http.StatusOK || ready.Status != router.HEALTH_STATUS_OK || len(ready.Checks) != 1 || !ready.Checks[0].Ready {
+		t.Errorf("readyz, status=%v, response=%+v", status, ready)
+	}
+}
+
+func TestCatalog(t *testing.T) {
+	env := NewFakeEnv(t)
+	defer env.Close()
+
+	response := struct {
+		ResultCode string                  `json:"resultCode"`
+		Results    []plugins.PluginCatalog `json:"results"`
+	}{}
+	if status := env.getJson(t, router.CATALOG_PATH, &response); status != http.StatusOK || response.ResultCode != plugins.RESULT_CODE_SUCCESS {
+		t.Fatalf("catalog status=%v, response=%+v", status, response)
+	}
+
+	actions := make(map[string]plugins.ActionCatalog)
+	for _, catalog := range response.Results {
+		for _, action := range catalog.Actions {
+			actions[catalog.Name+"."+action.Name] = action
+		}
+	}
+	for _, name := range []string{"vm.create", "vm.terminate", "security-group.create", "bs-security-group.calc-security-policies"} {
+		if action, found := actions[name]; !found || len(action.Inputs) == 0 {
+			t.Errorf("action %v, catalog=%+v", name, action)
+		}
+	}
+	if action := actions["vm.create"]; len(action.Outputs) == 0 {
+		t.Errorf("outputs of vm.create are empty, catalog=%+v", action)
+	}
+}
+
+func TestOpenApi(t *testing.T) {
+	env := NewFakeEnv(t)
+	defer env.Close()
+
+	document := plugins.OpenApiDocument{}
+	if status := env.getJson(t, router.OPENAPI_PATH, &document); status != http.StatusOK || document.OpenApi != plugins.OPENAPI_VERSION {
+		t.Fatalf("openapi status=%v, openapi=%v", status, document.OpenApi)
+	}
+	for _, path := range []string{"/qcloud/v1/vm/create", "/qcloud/v1/bs-security-group/calc-security-policies"} {
+		if document.Paths[path]["post"] == nil {
+			t.Errorf("operation of %v is not found", path)
+		}
+	}
+	if gatewayType := document.Components.Schemas["route-policy.create.Input"].Properties["gateway_type"]; !strings.Contains(strings.Join(gatewayType.Enum, ","), "PEERCONNECTION") {
+		t.Errorf("gateway_type=%+v", gatewayType)
+	}
+
+	static, err := plugins.GetOpenApiJson()
+	if err != nil {
+		t.Fatalf("generate the openapi document meet error=%v", err)
+	}
+	output, err := http.Get(env.pluginHost.URL + router.OPENAPI_PATH)
+	if err != nil {
+		t.Fatalf("call plugin server meet error = %v", err)
+	}
+	defer output.Body.Close()
+	if body, _ := ioutil.ReadAll(output.Body); string(body) != string(static) {
+		t.Errorf("served document is not the same as the static copy")
+	}
+}
+
+func TestMetrics(t *testing.T) {
+	env := NewFakeEnv(t)
+	defer env.Close()
+
+	env.CallPlugin(t, "vpc", "create", `{"inputs":[{"guid":"guid_1","name":"VPC-M","cidr_block":"10.9.0.0/16","provider_params":"`+providerParams+`"}]}`)
+	env.CallPlugin(t, "vpc", "terminate", `{"inputs":[{"guid":"guid_1","id":"`+env.Qcloud.ResourceIds("vpc")[0]+`","provider_params":"`+providerParams+`"}]}`)
+
+	output, err := http.Get(env.pluginHost.URL + router.METRICS_PATH)
+	if err != nil {
+		t.Fatalf("call plugin server meet error = %v", err)
+	}
+	defer output.Body.Close()
+	body, _ := ioutil.ReadAll(output.Body)
+	for _, metric := range []string{
+		`qcloud_plugin_requests_total{plugin="vpc",action="create",result_code="0"}`,
+		`qcloud_plugin_request_duration_seconds_count{plugin="vpc",action="terminate",result_code="0"}`,
+		`qcloud_plugin_inputs_total{plugin="vpc",action="create",result="success",error_category=""}`,
+		`qcloud_cloud_api_requests_total{service="vpc",action="CreateVpc",region="` + REGION + `",error_code=""}`,
+		`qcloud_cloud_api_request_duration_seconds_bucket{service="vpc",action="DeleteVpc",region="` + REGION + `",error_code="",le="+Inf"}`,
+		`# TYPE qcloud_plugin_inflight_actions gauge`,
+		`qcloud_rate_limit_requests{service="vpc",action="CreateVpc",region="` + REGION + `"`,
+	} {
+		if !strings.Contains(string(body), metric) {
+			t.Errorf("metric %v is not found in\n%s", metric, body)
+		}
+	}
+}
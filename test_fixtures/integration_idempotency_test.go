@@ -0,0 +1,52 @@
+package test
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/WeBankPartners/wecube-plugins-qcloud/plugins"
+)
+
+func TestIdempotentCreate(t *testing.T) {
+	env := NewFakeEnv(t)
+	defer env.Close()
+
+	dir, err := ioutil.TempDir("", "idempotency")
+	if err != nil {
+		t.Fatalf("create temp dir meet error=%v", err)
+	}
+	defer os.RemoveAll(dir)
+	defer func(store *plugins.IdempotencyStore) { plugins.DefaultIdempotencyStore = store }(plugins.DefaultIdempotencyStore)
+	plugins.DefaultIdempotencyStore = &plugins.IdempotencyStore{Dir: dir}
+
+	vpcCreateInput := `
+	{
+		"inputs":[{
+			"guid":"guid_1",
+			"name": "VPC-A",
+			"cidr_block": "10.1.0.0/16",
+			"provider_params": "` + providerParams + `"
+		}]
+	}
+	`
+	vpcId := env.CallPlugin(t, "vpc", "create", vpcCreateInput)["guid_1"]
+	if replayedId := env.CallPlugin(t, "vpc", "create", vpcCreateInput)["guid_1"]; replayedId != vpcId {
+		t.Errorf("retry created vpc %v, expected the output of vpc %v", replayedId, vpcId)
+	}
+	if ids := env.Qcloud.ResourceIds("vpc"); len(ids) != 1 {
+		t.Errorf("vpcs=%v, expected 1 vpc", ids)
+	}
+
+	vpcTerminateInput := `
+	{
+		"inputs":[{
+			"guid":"guid_1",
+			"id": "` + vpcId + `",
+			"provider_params": "` + providerParams + `"
+		}]
+	}
+	`
+	env.CallPlugin(t, "vpc", "terminate", vpcTerminateInput)
+	env.ExpectNoResources(t, "vpc")
+}
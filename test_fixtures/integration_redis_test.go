@@ -1,58 +1,46 @@
 package test
 
 import (
-	"encoding/json"
-	"io/ioutil"
 	"testing"
 )
 
 func TestRedisPlugin(t *testing.T) {
-	//createRedis()
-	//terminateRedis()
-}
+	env := NewFakeEnv(t)
+	defer env.Close()
 
-func createRedis() {
 	guid_1 := "guid_1"
+	vpcId, subnetId := createNetwork(t, env)
 	redisCreateInput := `
 	{
-		"Inputs":[{
+		"inputs":[{
 			"guid":"` + guid_1 + `",
-			"type_id":2,
-			"mem_size":1024,
+			"seed":"` + SEED + `",
+			"type_id":"2",
+			"mem_size":"1024",
 			"goods_num":1,
 			"password":"Ab888888",
-			"billing_mode":0,
-			"period":1,
-			"vpc_id": "vpc-35mi3son",
-			"subnet_id": "subnet-akvyfsio",
-			"provider_params": "Region=ap-chongqing;AvailableZone=ap-chongqing-1;SecretID=` + SECRET_ID + `;SecretKey=` + SECRET_KEY + `"
+			"billing_mode":"0",
+			"period":"1",
+			"vpc_id": "` + vpcId + `",
+			"subnet_id": "` + subnetId + `",
+			"provider_params": "` + providerParams + `"
 		}]
 	}
 	`
-	resourceIds["redis"] = CallPlugin("redis", "create", redisCreateInput)[guid_1]
-
-	ids, _ := json.MarshalIndent(resourceIds, "", "  ")
-	ioutil.WriteFile("resource.ids", ids, 0666)
-}
-
-func terminateRedis() {
-	guid_1 := "guid_1"
-	resourceIds := make(map[string]string)
-	bytes, _ := ioutil.ReadFile("resource.ids")
-	json.Unmarshal(bytes, &resourceIds)
+	redisId := env.CallPlugin(t, "redis", "create", redisCreateInput)[guid_1]
+	if ids := env.Qcloud.ResourceIds("redis"); len(ids) != 1 || ids[0] != redisId {
+		t.Fatalf("redis id=%v, resources=%v", redisId, ids)
+	}
 
-	redisTerminateInput := `
+	redisDeleteInput := `
 	{
-		"Inputs":[{
+		"inputs":[{
 			"guid":"` + guid_1 + `",
-			"instance_id":"` + resourceIds["redis"] + `",
-			"password":"Ab888888",
-			"provider_params": "Region=ap-chongqing;AvailableZone=ap-chongqing-1;SecretID=` + SECRET_ID + `;SecretKey=` + SECRET_KEY + `"
+			"id":"` + redisId + `",
+			"provider_params": "` + providerParams + `"
 		}]
 	}
 	`
-	resourceIds["redis"] = CallPlugin("redis", "terminate", redisTerminateInput)[guid_1]
-
-	ids, _ := json.MarshalIndent(resourceIds, "", "  ")
-	ioutil.WriteFile("resource.ids", ids, 0666)
+	env.CallPlugin(t, "redis", "delete", redisDeleteInput)
+	env.ExpectNoResources(t, "redis")
 }
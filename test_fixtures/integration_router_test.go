@@ -0,0 +1,76 @@
+package test
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/WeBankPartners/wecube-plugins-qcloud/plugins"
+	"github.com/WeBankPartners/wecube-plugins-qcloud/router"
+)
+
+type ErrorResponse struct {
+	ResultCode    string `json:"resultCode"`
+	ResultMsg     string `json:"resultMessage"`
+	ErrorCategory string `json:"errorCategory"`
+	ErrorReason   string `json:"errorReason"`
+}
+
+func (env *FakeEnv) request(t *testing.T, method, path, contentType, body string) (int, ErrorResponse) {
+	t.Helper()
+	request, err := http.NewRequest(method, env.pluginHost.URL+path, strings.NewReader(body))
+	if err != nil {
+		t.Fatalf("new request meet error = %v", err)
+	}
+	if contentType != "" {
+		request.Header.Set("Content-Type", contentType)
+	}
+	output, err := http.DefaultClient.Do(request)
+	if err != nil {
+		t.Fatalf("call plugin server meet error = %v", err)
+	}
+	defer output.Body.Close()
+
+	response := ErrorResponse{}
+	if err = UnmarshalJson(output.Body, &response); err != nil {
+		t.Fatalf("unmarshal response of %v %v meet error = %v", method, path, err)
+	}
+	return output.StatusCode, response
+}
+
+func TestRouterRejectsRequests(t *testing.T) {
+	env := NewFakeEnv(t)
+	defer env.Close()
+
+	defer router.SetMaxRequestBodySize(0)
+	router.SetMaxRequestBodySize(64)
+
+	cases := []struct {
+		method      string
+		path        string
+		contentType string
+		body        string
+		status      int
+		reason      string
+	}{
+		{http.MethodPost, "/qcloud/v1/unknown/create", "application/json", "{}", http.StatusNotFound, plugins.ERROR_CODE_PLUGIN_NOT_FOUND},
+		{http.MethodPost, "/qcloud/v1/vpc/unknown", "application/json", "{}", http.StatusNotFound, plugins.ERROR_CODE_ACTION_NOT_FOUND},
+		{http.MethodPost, "/qcloud/v1/vpc/create/more", "application/json", "{}", http.StatusNotFound, plugins.ERROR_CODE_PATH_NOT_FOUND},
+		{http.MethodPost, "/v1/qcloud/vpc/create", "application/json", "{}", http.StatusNotFound, plugins.ERROR_CODE_PATH_NOT_FOUND},
+		{http.MethodGet, "/qcloud/v1/vpc/create", "", "", http.StatusMethodNotAllowed, plugins.ERROR_CODE_METHOD_NOT_ALLOWED},
+		{http.MethodPost, "/qcloud/v1/vpc/create", "text/plain", "{}", http.StatusUnsupportedMediaType, plugins.ERROR_CODE_UNSUPPORTED_MEDIA_TYPE},
+		{http.MethodPost, "/qcloud/v1/vpc/create", "", "{}", http.StatusUnsupportedMediaType, plugins.ERROR_CODE_UNSUPPORTED_MEDIA_TYPE},
+		{http.MethodPost, "/qcloud/v1/vpc/create", "application/json", `{"inputs":[` + strings.Repeat(" ", 64) + `]}`, http.StatusRequestEntityTooLarge, plugins.ERROR_CODE_REQUEST_TOO_LARGE},
+	}
+	for _, c := range cases {
+		status, response := env.request(t, c.method, c.path, c.contentType, c.body)
+		if status != c.status || response.ResultCode != plugins.RESULT_CODE_ERROR || response.ErrorReason != c.reason {
+			t.Errorf("%v %v, status=%v, response=%+v", c.method, c.path, status, response)
+		}
+	}
+
+	status, response := env.request(t, http.MethodPost, "/qcloud/v1/vpc/create", "application/json; charset=utf-8", `{"inputs":[]}`)
+	if status != http.StatusOK || response.ResultCode != plugins.RESULT_CODE_SUCCESS {
+		t.Errorf("vpc create, status=%v, response=%+v", status, response)
+	}
+}
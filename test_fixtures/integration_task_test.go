@@ -0,0 +1,101 @@
+package test
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/WeBankPartners/wecube-plugins-qcloud/plugins"
+	"github.com/WeBankPartners/wecube-plugins-qcloud/router"
+)
+
+type Task struct {
+	Id       string                      `json:"taskId"`
+	Status   string                      `json:"status"`
+	Total    int                         `json:"total"`
+	Finished int                         `json:"finished"`
+	Inputs   []plugins.TaskInputProgress `json:"inputs"`
+}
+
+type TaskResponse struct {
+	ResultCode string `json:"resultCode"`
+	ResultMsg  string `json:"resultMessage"`
+	Results    Task   `json:"results"`
+}
+
+func TestAsyncTask(t *testing.T) {
+	env := NewFakeEnv(t)
+	defer env.Close()
+
+	input := `{"inputs":[{"guid":"guid_1","name":"VPC-T1","cidr_block":"10.7.0.0/16","provider_params":"` + providerParams + `"},
+		{"guid":"guid_2","name":"VPC-T2","cidr_block":"10.8.0.0/16","provider_params":"` + providerParams + `"}]}`
+	output, err := http.Post(env.pluginHost.URL+"/qcloud/v1/vpc/create?async=true", "application/json", strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("call plugin server meet error = %v", err)
+	}
+	defer output.Body.Close()
+	submitted := struct {
+		ResultCode string            `json:"resultCode"`
+		Results    plugins.TaskBrief `json:"results"`
+	}{}
+	if err = UnmarshalJson(output.Body, &submitted); err != nil {
+		t.Fatalf("unmarshal plugin response meet error = %v", err)
+	}
+	if submitted.ResultCode != plugins.RESULT_CODE_SUCCESS || submitted.Results.Id == "" || submitted.Results.Status != plugins.TASK_STATUS_PENDING {
+		t.Fatalf("async vpc create, response=%+v", submitted)
+	}
+
+	task := TaskResponse{}
+	for deadline := time.Now().Add(10 * time.Second); ; time.Sleep(10 * time.Millisecond) {
+		task = TaskResponse{}
+		if status := env.getJson(t, router.TASK_PATH_PREFIX+submitted.Results.Id, &task); status != http.StatusOK || task.ResultCode != plugins.RESULT_CODE_SUCCESS {
+			t.Fatalf("get task, status=%v, response=%+v", status, task)
+		}
+		if task.Results.Status == plugins.TASK_STATUS_SUCCESS || task.Results.Status == plugins.TASK_STATUS_FAILED || time.Now().After(deadline) {
+			break
+		}
+	}
+	if task.Results.Status != plugins.TASK_STATUS_SUCCESS || task.Results.Total != 2 || task.Results.Finished != 2 || len(task.Results.Inputs) != 2 {
+		t.Fatalf("task=%+v", task.Results)
+	}
+	for i, guid := range []string{"guid_1", "guid_2"} {
+		if progress := task.Results.Inputs[i]; progress.Index != i || progress.Guid != guid || progress.Status != plugins.TASK_STATUS_SUCCESS {
+			t.Errorf("input %v=%+v", i, progress)
+		}
+	}
+
+	result := PluginResponse{}
+	if status := env.getJson(t, router.TASK_PATH_PREFIX+submitted.Results.Id+router.TASK_RESULT_SUFFIX, &result); status != http.StatusOK ||
+		result.ResultCode != plugins.RESULT_CODE_SUCCESS || len(result.Results.Outputs) != 2 {
+		t.Fatalf("task result, status=%v, response=%+v", status, result)
+	}
+	ids := env.Qcloud.ResourceIds("vpc")
+	if len(ids) != 2 {
+		t.Fatalf("vpcs=%v", ids)
+	}
+
+	for _, path := range []string{router.TASK_PATH_PREFIX + "task-not-exist", router.TASK_PATH_PREFIX + "task-not-exist" + router.TASK_RESULT_SUFFIX} {
+		notFound := ErrorResponse{}
+		if status := env.getJson(t, path, &notFound); status != http.StatusNotFound ||
+			notFound.ResultCode != plugins.RESULT_CODE_ERROR || notFound.ErrorReason != plugins.ERROR_CODE_TASK_NOT_FOUND {
+			t.Errorf("get %v, status=%v, response=%+v", path, status, notFound)
+		}
+	}
+	notAllowed, err := http.Post(env.pluginHost.URL+router.TASK_PATH_PREFIX+submitted.Results.Id, "application/json", strings.NewReader("{}"))
+	if err != nil {
+		t.Fatalf("call plugin server meet error = %v", err)
+	}
+	notAllowed.Body.Close()
+	if notAllowed.StatusCode != http.StatusMethodNotAllowed || notAllowed.Header.Get("Allow") != http.MethodGet {
+		t.Errorf("post task, status=%v, allow=%v", notAllowed.StatusCode, notAllowed.Header.Get("Allow"))
+	}
+
+	// actions are run in the request unless async is asked.
+	outputs := env.CallPlugin(t, "vpc", "terminate", `{"inputs":[{"guid":"guid_1","id":"`+ids[0]+`","provider_params":"`+providerParams+`"},
+		{"guid":"guid_2","id":"`+ids[1]+`","provider_params":"`+providerParams+`"}]}`)
+	if len(outputs) != 2 {
+		t.Errorf("outputs of vpc terminate=%v", outputs)
+	}
+	env.ExpectNoResources(t, "vpc")
+}
@@ -0,0 +1,36 @@
+// openapi writes the OpenAPI 3 document of the actions of the registered plugins, which is also served at
+// /qcloud/v1/openapi.json, so the builds ship a static copy of it.
+//
+//	go run ./tools/openapi -output openapi.json
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	_ "github.com/WeBankPartners/wecube-plugins-qcloud/plugins/bussiness_plugins/security_group"
+
+	"github.com/WeBankPartners/wecube-plugins-qcloud/plugins"
+	"github.com/sirupsen/logrus"
+)
+
+func main() {
+	output := flag.String("output", "openapi.json", "the file the document is written to")
+	flag.Parse()
+	logrus.SetLevel(logrus.ErrorLevel)
+
+	content, err := plugins.GetOpenApiJson()
+	if err != nil {
+		fail("generate the openapi document meet error=%v", err)
+	}
+	if err = ioutil.WriteFile(*output, content, 0644); err != nil {
+		fail("write %v meet error=%v", *output, err)
+	}
+}
+
+func fail(format string, args ...interface{}) {
+	fmt.Fprintf(os.Stderr, format+"\n", args...)
+	os.Exit(1)
+}
@@ -0,0 +1,50 @@
+// register_xml generates the <plugins> section of the register.xml template from the input and output
+// structs of the registered plugins, whose fields are tagged with "register". By default it fails if the
+// template is not the generated one, -write rewrites the section of the template.
+//
+//	go run ./tools/register_xml -template build/register.xml.tpl [-write]
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	_ "github.com/WeBankPartners/wecube-plugins-qcloud/plugins/bussiness_plugins/security_group"
+
+	"github.com/WeBankPartners/wecube-plugins-qcloud/plugins"
+	"github.com/sirupsen/logrus"
+)
+
+func main() {
+	templateFile := flag.String("template", "build/register.xml.tpl", "the template of register.xml")
+	write := flag.Bool("write", false, "rewrite the <plugins> section of the template instead of checking it")
+	flag.Parse()
+	logrus.SetLevel(logrus.ErrorLevel)
+
+	content, err := ioutil.ReadFile(*templateFile)
+	if err != nil {
+		fail("read %v meet error=%v", *templateFile, err)
+	}
+	template := string(content)
+	if !*write {
+		if err = plugins.CheckRegisterTemplate(template); err != nil {
+			fail("%v: %v\nrun \"go run ./tools/register_xml -write\" after the structs are changed", *templateFile, err)
+		}
+		return
+	}
+
+	generated, err := plugins.ReplaceRegisterPlugins(template)
+	if err != nil {
+		fail("generate %v meet error=%v", *templateFile, err)
+	}
+	if err = ioutil.WriteFile(*templateFile, []byte(generated), 0644); err != nil {
+		fail("write %v meet error=%v", *templateFile, err)
+	}
+}
+
+func fail(format string, args ...interface{}) {
+	fmt.Fprintf(os.Stderr, format+"\n", args...)
+	os.Exit(1)
+}